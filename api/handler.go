@@ -0,0 +1,514 @@
+// Package api serves a small REST API over the WhatsApp session, for systems
+// that don't speak MCP (n8n, Zapier-style tools, cron scripts): send a text
+// message, list chats and read a chat's messages. Responses are JSON.
+package api
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"whatsapp-mcp/export"
+	"whatsapp-mcp/storage"
+	"whatsapp-mcp/whatsapp"
+)
+
+const (
+	defaultChatLimit    = 50
+	maxChatLimit        = 100
+	defaultMessageLimit = 50
+	maxMessageLimit     = 200
+)
+
+// Handler serves the REST API.
+type Handler struct {
+	wa       *whatsapp.Client
+	store    *storage.MessageStore
+	timezone *time.Location
+}
+
+// NewHandler creates a new REST API handler. Dates without an offset are
+// interpreted in timezone.
+func NewHandler(wa *whatsapp.Client, store *storage.MessageStore, timezone *time.Location) *Handler {
+	return &Handler{wa: wa, store: store, timezone: timezone}
+}
+
+// errorResponse writes a JSON error response.
+func errorResponse(w http.ResponseWriter, message string, statusCode int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
+
+// jsonResponse writes v as a JSON response.
+func jsonResponse(w http.ResponseWriter, v any, statusCode int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// SendMessageRequest is the body of POST /api/messages.
+type SendMessageRequest struct {
+	ChatJID        string   `json:"chat_jid"`
+	Text           string   `json:"text"`
+	Mentions       []string `json:"mentions,omitempty"`        // JIDs to @-mention (groups)
+	IdempotencyKey string   `json:"idempotency_key,omitempty"` // retries with the same key don't send again (kept for 24h)
+}
+
+// SendMessageResponse is the response of POST /api/messages.
+type SendMessageResponse struct {
+	ID        string    `json:"id"`
+	ChatJID   string    `json:"chat_jid"`
+	Text      string    `json:"text,omitempty"`
+	Timestamp time.Time `json:"timestamp,omitempty"`
+	Replayed  bool      `json:"replayed,omitempty"` // true when an earlier send with the same idempotency key is returned
+}
+
+// SendMessage handles POST /api/messages.
+func (h *Handler) SendMessage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		errorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req SendMessageRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errorResponse(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.ChatJID == "" || req.Text == "" {
+		errorResponse(w, "chat_jid and text are required", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.wa.ConnectionError(); err != nil {
+		errorResponse(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	// replay the original send when the same key is retried
+	if req.IdempotencyKey != "" {
+		existing, err := h.store.ReserveIdempotencyKey(req.IdempotencyKey, req.ChatJID)
+		if err != nil {
+			errorResponse(w, fmt.Sprintf("failed to check idempotency key: %v", err), http.StatusInternalServerError)
+			return
+		}
+		if existing != nil {
+			switch {
+			case existing.ChatJID != req.ChatJID:
+				errorResponse(w, fmt.Sprintf("idempotency_key %q was already used for chat %s", req.IdempotencyKey, existing.ChatJID), http.StatusConflict)
+			case existing.MessageID == "":
+				errorResponse(w, fmt.Sprintf("a send with idempotency_key %q is still in progress", req.IdempotencyKey), http.StatusConflict)
+			default:
+				jsonResponse(w, SendMessageResponse{ID: existing.MessageID, ChatJID: existing.ChatJID, Replayed: true}, http.StatusOK)
+			}
+			return
+		}
+	}
+
+	sent, err := h.wa.SendTextMessage(r.Context(), req.ChatJID, req.Text, req.Mentions)
+	if err != nil {
+		if req.IdempotencyKey != "" {
+			if err := h.store.ReleaseIdempotencyKey(req.IdempotencyKey); err != nil {
+				log.Printf("Failed to release idempotency key %s: %v", req.IdempotencyKey, err)
+			}
+		}
+		errorResponse(w, fmt.Sprintf("failed to send message: %v", err), http.StatusBadGateway)
+		return
+	}
+
+	if req.IdempotencyKey != "" {
+		// same result text as the send_message tool, so the key can be replayed there too
+		result := fmt.Sprintf("Message sent successfully to %s (ID: %s)", req.ChatJID, sent.ID)
+		if err := h.store.CompleteIdempotencyKey(req.IdempotencyKey, sent.ID, result); err != nil {
+			log.Printf("Failed to store idempotency key %s: %v", req.IdempotencyKey, err)
+		}
+	}
+
+	jsonResponse(w, SendMessageResponse{
+		ID:        sent.ID,
+		ChatJID:   sent.ChatJID,
+		Text:      sent.Text,
+		Timestamp: sent.Timestamp,
+	}, http.StatusCreated)
+}
+
+// ChatResponse is a chat in API responses.
+type ChatResponse struct {
+	JID               string     `json:"jid"`
+	Name              string     `json:"name"`
+	IsGroup           bool       `json:"is_group"`
+	LastMessageTime   time.Time  `json:"last_message_time"`
+	UnreadCount       int        `json:"unread_count"`
+	MarkedUnread      bool       `json:"marked_unread,omitempty"`
+	MessageCount      int        `json:"message_count"`
+	DisappearingTimer int        `json:"disappearing_timer,omitempty"` // seconds
+	Muted             bool       `json:"muted,omitempty"`
+	MutedUntil        *time.Time `json:"muted_until,omitempty"` // not set when muted forever
+	Archived          bool       `json:"archived,omitempty"`
+	PinnedAt          *time.Time `json:"pinned_at,omitempty"`
+}
+
+// ListChats handles GET /api/chats.
+//
+// Query parameters: limit (default 50, max 100), tag, archived, pinned, muted
+// (true or false) and cursor (next_cursor of the previous page).
+func (h *Handler) ListChats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		errorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	limit, err := limitParam(query, defaultChatLimit, maxChatLimit)
+	if err != nil {
+		errorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	cursor, err := decodeCursor(query.Get("cursor"))
+	if err != nil {
+		errorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	archived, archivedErr := boolParam(query, "archived")
+	pinned, pinnedErr := boolParam(query, "pinned")
+	muted, mutedErr := boolParam(query, "muted")
+	if err := errors.Join(archivedErr, pinnedErr, mutedErr); err != nil {
+		errorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	filter := storage.ChatFilter{Tag: query.Get("tag"), Archived: archived, Pinned: pinned, Muted: muted}
+
+	chats, err := h.store.ListChats(limit, cursor, filter)
+	if err != nil {
+		errorResponse(w, "Failed to list chats", http.StatusInternalServerError)
+		return
+	}
+
+	resp := make([]ChatResponse, 0, len(chats))
+	for _, chat := range chats {
+		name := chat.ContactName
+		if name == "" {
+			name = chat.PushName
+		}
+		item := ChatResponse{
+			JID:               chat.JID,
+			Name:              name,
+			IsGroup:           chat.IsGroup,
+			LastMessageTime:   chat.LastMessageTime,
+			UnreadCount:       chat.UnreadCount,
+			MarkedUnread:      chat.MarkedUnread,
+			MessageCount:      chat.MessageCount,
+			DisappearingTimer: chat.DisappearingTimer,
+			Muted:             chat.Muted,
+			Archived:          chat.Archived,
+		}
+		if !chat.MutedUntil.IsZero() {
+			item.MutedUntil = &chat.MutedUntil
+		}
+		if !chat.PinnedAt.IsZero() {
+			item.PinnedAt = &chat.PinnedAt
+		}
+		resp = append(resp, item)
+	}
+
+	var nextCursor string
+	if len(chats) == limit {
+		last := chats[len(chats)-1]
+		nextCursor = encodeCursor(last.LastMessageTime, last.JID)
+	}
+
+	jsonResponse(w, map[string]any{"chats": resp, "next_cursor": nextCursor}, http.StatusOK)
+}
+
+// DownloadQueue handles GET /api/media/downloads: the background media
+// download queue, totals since startup and recent failures.
+func (h *Handler) DownloadQueue(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		errorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	jsonResponse(w, h.wa.DownloadQueueStatus(), http.StatusOK)
+}
+
+// SyncContacts handles POST /api/contacts/sync: fetches the contact list from
+// WhatsApp again and stores it.
+func (h *Handler) SyncContacts(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		errorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := h.wa.ConnectionError(); err != nil {
+		errorResponse(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	result, err := h.wa.SyncContacts(r.Context(), true)
+	if err != nil {
+		errorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	jsonResponse(w, result, http.StatusOK)
+}
+
+// PairingRequest is the optional body of POST /api/auth/pairing and
+// POST /api/auth/logout.
+type PairingRequest struct {
+	Phone string `json:"phone"` // pair with a pairing code for this number instead of a QR code
+}
+
+// Pairing handles /api/auth/pairing: GET returns the current QR code or
+// pairing code while not logged in, POST starts pairing again (e.g. after the
+// QR codes timed out).
+func (h *Handler) Pairing(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		jsonResponse(w, h.wa.PairingStatus(), http.StatusOK)
+	case http.MethodPost:
+		req, ok := decodePairingRequest(w, r)
+		if !ok {
+			return
+		}
+		if err := h.wa.StartPairing(req.Phone); err != nil {
+			errorResponse(w, err.Error(), http.StatusConflict)
+			return
+		}
+		jsonResponse(w, h.wa.PairingStatus(), http.StatusAccepted)
+	default:
+		errorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// Logout handles POST /api/auth/logout: unlinks the device and starts pairing
+// a new one, see GET /api/auth/pairing for the QR code or pairing code.
+func (h *Handler) Logout(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		errorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	req, ok := decodePairingRequest(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.wa.Logout(r.Context(), req.Phone); err != nil {
+		errorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	jsonResponse(w, h.wa.PairingStatus(), http.StatusAccepted)
+}
+
+// decodePairingRequest reads the optional body of the pairing endpoints,
+// writing an error response if it is invalid.
+func decodePairingRequest(w http.ResponseWriter, r *http.Request) (PairingRequest, bool) {
+	var req PairingRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		errorResponse(w, "Invalid request body", http.StatusBadRequest)
+		return req, false
+	}
+	return req, true
+}
+
+// MediaResponse is a message's media attachment in API responses.
+type MediaResponse struct {
+	FileName string `json:"file_name,omitempty"`
+	MimeType string `json:"mime_type"`
+	FileSize int64  `json:"file_size"`
+}
+
+// MessageResponse is a message in API responses.
+type MessageResponse struct {
+	ID          string         `json:"id"`
+	ChatJID     string         `json:"chat_jid"`
+	SenderJID   string         `json:"sender_jid"`
+	SenderName  string         `json:"sender_name,omitempty"`
+	Timestamp   time.Time      `json:"timestamp"`
+	IsFromMe    bool           `json:"is_from_me"`
+	MessageType string         `json:"message_type"`
+	Text        string         `json:"text"`
+	ReplyToID   string         `json:"reply_to_id,omitempty"`
+	Media       *MediaResponse `json:"media,omitempty"`
+	EditedAt    *time.Time     `json:"edited_at,omitempty"`
+	DeletedAt   *time.Time     `json:"deleted_at,omitempty"`
+}
+
+// HandleChatByJID routes requests under /api/chats/{jid}.
+func (h *Handler) HandleChatByJID(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/chats/"), "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] != "messages" {
+		errorResponse(w, "Not found", http.StatusNotFound)
+		return
+	}
+
+	h.ListMessages(w, r, parts[0])
+}
+
+// ListMessages handles GET /api/chats/{jid}/messages, newest first.
+//
+// Query parameters: limit (default 50, max 200), before and after (RFC 3339
+// or 2006-01-02), sender_jid, include_deleted and cursor (next_cursor of the
+// previous page, for older messages).
+func (h *Handler) ListMessages(w http.ResponseWriter, r *http.Request, chatJID string) {
+	if r.Method != http.MethodGet {
+		errorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	chatJID, err := h.store.CanonicalJID(chatJID)
+	if err != nil {
+		errorResponse(w, "Failed to resolve chat", http.StatusInternalServerError)
+		return
+	}
+
+	query := r.URL.Query()
+	limit, err := limitParam(query, defaultMessageLimit, maxMessageLimit)
+	if err != nil {
+		errorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	cursor, err := decodeCursor(query.Get("cursor"))
+	if err != nil {
+		errorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	before, err := h.timeParam(query, "before")
+	if err != nil {
+		errorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	after, err := h.timeParam(query, "after")
+	if err != nil {
+		errorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	includeDeleted, _ := strconv.ParseBool(query.Get("include_deleted"))
+
+	senderJID := query.Get("sender_jid")
+	if senderJID != "" {
+		if senderJID, err = h.store.CanonicalJID(senderJID); err != nil {
+			errorResponse(w, "Failed to resolve sender", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	messages, err := h.store.GetChatMessagesWithNamesFiltered(r.Context(), chatJID, limit, before, after, senderJID, cursor, nil, 0, includeDeleted)
+	if err != nil {
+		errorResponse(w, "Failed to get messages", http.StatusInternalServerError)
+		return
+	}
+
+	resp := make([]MessageResponse, 0, len(messages))
+	for _, msg := range messages {
+		senderName := msg.SenderContactName
+		if senderName == "" {
+			senderName = msg.SenderPushName
+		}
+		m := MessageResponse{
+			ID:          msg.ID,
+			ChatJID:     msg.ChatJID,
+			SenderJID:   msg.SenderJID,
+			SenderName:  senderName,
+			Timestamp:   msg.Timestamp,
+			IsFromMe:    msg.IsFromMe,
+			MessageType: msg.MessageType,
+			Text:        msg.Text,
+			ReplyToID:   msg.ReplyToID,
+			EditedAt:    msg.EditedAt,
+			DeletedAt:   msg.DeletedAt,
+		}
+		if msg.MediaMetadata != nil {
+			m.Media = &MediaResponse{
+				FileName: msg.MediaMetadata.FileName,
+				MimeType: msg.MediaMetadata.MimeType,
+				FileSize: msg.MediaMetadata.FileSize,
+			}
+		}
+		resp = append(resp, m)
+	}
+
+	var nextCursor string
+	if len(messages) == limit {
+		last := messages[len(messages)-1]
+		nextCursor = encodeCursor(last.Timestamp, last.ID)
+	}
+
+	jsonResponse(w, map[string]any{"messages": resp, "next_cursor": nextCursor}, http.StatusOK)
+}
+
+// limitParam reads the limit query parameter, capped at max.
+func limitParam(query url.Values, def, max int) (int, error) {
+	value := query.Get("limit")
+	if value == "" {
+		return def, nil
+	}
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit < 1 {
+		return 0, fmt.Errorf("invalid limit %q", value)
+	}
+	if limit > max {
+		limit = max
+	}
+	return limit, nil
+}
+
+// boolParam reads an optional true/false query parameter, nil when missing.
+func boolParam(query url.Values, name string) (*bool, error) {
+	value := query.Get(name)
+	if value == "" {
+		return nil, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s %q", name, value)
+	}
+	return &b, nil
+}
+
+// timeParam reads an optional time query parameter.
+func (h *Handler) timeParam(query url.Values, name string) (*time.Time, error) {
+	value := query.Get(name)
+	if value == "" {
+		return nil, nil
+	}
+	t, err := export.ParseTime(value, h.timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", name, err)
+	}
+	return &t, nil
+}
+
+// encodeCursor returns an opaque cursor for the page after the row with the
+// given timestamp and key (chat JID or message ID).
+func encodeCursor(timestamp time.Time, key string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(timestamp.Unix(), 10) + "|" + key))
+}
+
+// decodeCursor parses a cursor produced by encodeCursor, or returns nil for
+// an empty one.
+func decodeCursor(cursor string) (*storage.PageCursor, error) {
+	if cursor == "" {
+		return nil, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, errors.New("malformed cursor")
+	}
+	timestamp, key, ok := strings.Cut(string(data), "|")
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if !ok || err != nil || key == "" {
+		return nil, errors.New("malformed cursor")
+	}
+
+	return &storage.PageCursor{Timestamp: time.Unix(unix, 0), Key: key}, nil
+}
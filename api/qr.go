@@ -0,0 +1,124 @@
+package api
+
+import (
+	"encoding/base64"
+	"html/template"
+	"net/http"
+
+	"whatsapp-mcp/whatsapp"
+
+	"github.com/skip2/go-qrcode"
+)
+
+// qrImageSize is the width and height of the pairing QR code PNG, in pixels.
+const qrImageSize = 320
+
+// qrRefreshSeconds is how often the pairing page reloads. WhatsApp replaces
+// the QR code about every 20 seconds.
+const qrRefreshSeconds = 5
+
+// qrPage is the pairing page served at /qr. The QR code is inlined so the
+// page works with the API key in the URL or in a header.
+var qrPage = template.Must(template.New("qr").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+{{if .Refresh}}<meta http-equiv="refresh" content="{{.Refresh}}">{{end}}
+<title>WhatsApp MCP pairing</title>
+<style>body{font-family:sans-serif;text-align:center;margin:2em}code{font-size:2em;letter-spacing:.1em}</style>
+</head>
+<body>
+<h1>WhatsApp MCP</h1>
+{{if .LoggedIn}}
+<p>Logged in, nothing to pair.</p>
+{{else if .Status.PairingCode}}
+<p>On your phone, open WhatsApp &gt; Linked devices &gt; Link a device &gt; Link with phone number instead, and enter:</p>
+<p><code>{{.Status.PairingCode}}</code></p>
+{{else if .Image}}
+<p>On your phone, open WhatsApp &gt; Linked devices &gt; Link a device and scan:</p>
+<p><img src="{{.Image}}" width="{{.Size}}" height="{{.Size}}" alt="WhatsApp pairing QR code"></p>
+{{else if .Status.Active}}
+<p>Waiting for a QR code&hellip;</p>
+{{else}}
+<p>Pairing stopped{{with .Status.Error}} ({{.}}){{end}}.</p>
+<form method="post"><button type="submit">Get a new QR code</button></form>
+{{end}}
+</body>
+</html>
+`))
+
+// qrPageData is the data of qrPage.
+type qrPageData struct {
+	LoggedIn bool
+	Status   whatsapp.PairingStatus
+	Image    template.URL // data: URL of the QR code PNG
+	Size     int
+	Refresh  int // seconds until the page reloads, 0 to not reload
+}
+
+// QRPage handles /qr: GET shows the pairing QR code (or pairing code) on a
+// page that reloads itself while not logged in, POST starts QR pairing again
+// after it timed out. The terminal QR printed to the logs is often mangled
+// (e.g. by docker logs), this one can be scanned from a browser.
+func (h *Handler) QRPage(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		if err := h.wa.StartPairing(""); err != nil {
+			errorResponse(w, err.Error(), http.StatusConflict)
+			return
+		}
+		// back to the page, keeping the api_key parameter
+		http.Redirect(w, r, r.URL.RequestURI(), http.StatusSeeOther)
+		return
+	default:
+		errorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	status := h.wa.PairingStatus()
+	data := qrPageData{LoggedIn: h.wa.IsLoggedIn(), Status: status, Size: qrImageSize}
+	if !data.LoggedIn {
+		if status.Active {
+			data.Refresh = qrRefreshSeconds
+		}
+		if status.QRCode != "" && status.PairingCode == "" {
+			png, err := qrcode.Encode(status.QRCode, qrcode.Low, qrImageSize)
+			if err != nil {
+				errorResponse(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			data.Image = template.URL("data:image/png;base64," + base64.StdEncoding.EncodeToString(png))
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	_ = qrPage.Execute(w, data)
+}
+
+// QRImage handles GET /qr.png: the current pairing QR code as a PNG, or 404
+// when there is none (logged in, pairing with a phone number or stopped).
+func (h *Handler) QRImage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		errorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	status := h.wa.PairingStatus()
+	if h.wa.IsLoggedIn() || status.QRCode == "" {
+		errorResponse(w, "No QR code to scan", http.StatusNotFound)
+		return
+	}
+
+	png, err := qrcode.Encode(status.QRCode, qrcode.Low, qrImageSize)
+	if err != nil {
+		errorResponse(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Cache-Control", "no-store")
+	_, _ = w.Write(png)
+}
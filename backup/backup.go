@@ -0,0 +1,440 @@
+// Package backup takes consistent snapshots of the databases and restores them.
+//
+// A snapshot is a directory named after its UTC creation time, to the
+// millisecond, that holds copies of messages.db and whatsapp_auth.db, made
+// with SQLite's online backup API, and a manifest.json describing the schema
+// version and the media files present at that time. Media files themselves
+// are not copied. Snapshots can be uploaded to an S3-compatible bucket and
+// downloaded from it to be restored.
+package backup
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+	"whatsapp-mcp/paths"
+	"whatsapp-mcp/storage"
+)
+
+const (
+	manifestFile   = "manifest.json"
+	messagesDBFile = "messages.db"
+	authDBFile     = "whatsapp_auth.db"
+	snapshotLayout = "20060102T150405.000Z"
+)
+
+// Logger defines the logging interface for the backup manager.
+type Logger interface {
+	Printf(format string, v ...any)
+	Println(v ...any)
+}
+
+// Manifest describes the contents of a snapshot.
+type Manifest struct {
+	CreatedAt     time.Time   `json:"created_at"`
+	SchemaVersion int         `json:"schema_version"` // messages.db schema version
+	Databases     []string    `json:"databases"`      // database files in the snapshot
+	Media         []MediaFile `json:"media"`
+}
+
+// MediaFile is an entry of the media directory manifest.
+type MediaFile struct {
+	Path    string    `json:"path"` // relative to data/media/
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+}
+
+// Manager creates, lists and restores snapshots and runs scheduled backups.
+type Manager struct {
+	config *Config
+	s3     *s3Bucket // nil when no bucket is configured
+	log    Logger
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewManager creates a new backup manager. Invalid S3 settings are logged and
+// leave uploads disabled.
+func NewManager(config *Config, logger Logger) *Manager {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	m := &Manager{
+		config: config,
+		log:    logger,
+		ctx:    ctx,
+		cancel: cancel,
+	}
+	if config.S3 != nil {
+		bucket, err := newS3Bucket(config.S3)
+		if err != nil {
+			logger.Printf("Warning: not uploading backups: %v", err)
+		}
+		m.s3 = bucket
+	}
+	return m
+}
+
+// Start runs a snapshot every configured interval in the background.
+// It does nothing if scheduling is disabled.
+func (m *Manager) Start() {
+	if m.config.Interval <= 0 {
+		return
+	}
+
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+
+		ticker := time.NewTicker(m.config.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-m.ctx.Done():
+				return
+			case <-ticker.C:
+				dir, err := m.Snapshot(m.ctx)
+				if err != nil {
+					m.log.Printf("Scheduled backup failed: %v", err)
+					continue
+				}
+				m.log.Printf("Backup written to %s", dir)
+			}
+		}
+	}()
+
+	m.log.Printf("Scheduled backups every %s to %s", m.config.Interval, m.config.Dir)
+}
+
+// Stop stops scheduled backups, waiting for a running snapshot to finish.
+func (m *Manager) Stop() {
+	m.cancel()
+	m.wg.Wait()
+}
+
+// databases are the live database files to back up, keyed by their file name
+// inside a snapshot.
+var databases = map[string]string{
+	messagesDBFile: paths.MessagesDBPath,
+	authDBFile:     paths.WhatsAppAuthDBPath,
+}
+
+// Snapshot writes a new snapshot and returns its directory. The snapshot is
+// uploaded to the S3 bucket if one is configured, and old local snapshots
+// beyond the configured count are removed.
+func (m *Manager) Snapshot(ctx context.Context) (string, error) {
+	now := time.Now().UTC()
+	name := now.Format(snapshotLayout)
+	dir := filepath.Join(m.config.Dir, name)
+
+	// write into a temporary directory so an interrupted backup never looks
+	// complete; creating it fails if another snapshot has the same name
+	if err := os.MkdirAll(m.config.Dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create backup directory: %w", err)
+	}
+	tmpDir := dir + ".tmp"
+	if err := os.Mkdir(tmpDir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create backup directory: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	manifest := Manifest{CreatedAt: now}
+	var err error
+
+	for _, file := range []string{messagesDBFile, authDBFile} {
+		src := databases[file]
+		if _, err := os.Stat(src); errors.Is(err, fs.ErrNotExist) {
+			// not logged in yet, nothing to back up
+			continue
+		}
+		if err := copyDatabase(ctx, src, filepath.Join(tmpDir, file)); err != nil {
+			return "", fmt.Errorf("failed to back up %s: %w", file, err)
+		}
+		manifest.Databases = append(manifest.Databases, file)
+	}
+
+	if slices.Contains(manifest.Databases, messagesDBFile) {
+		manifest.SchemaVersion, err = schemaVersion(filepath.Join(tmpDir, messagesDBFile))
+		if err != nil {
+			return "", err
+		}
+	}
+
+	manifest.Media, err = mediaManifest()
+	if err != nil {
+		return "", fmt.Errorf("failed to list media files: %w", err)
+	}
+
+	if err := writeManifest(filepath.Join(tmpDir, manifestFile), manifest); err != nil {
+		return "", err
+	}
+
+	if err := os.Rename(tmpDir, dir); err != nil {
+		return "", fmt.Errorf("failed to finalize backup: %w", err)
+	}
+
+	if m.s3 != nil {
+		for _, file := range append(manifest.Databases, manifestFile) {
+			if err := m.s3.upload(ctx, name+"/"+file, filepath.Join(dir, file)); err != nil {
+				return dir, err
+			}
+		}
+	}
+
+	if err := m.prune(); err != nil {
+		m.log.Printf("Failed to remove old backups: %v", err)
+	}
+
+	return dir, nil
+}
+
+// List returns the local snapshot directories, newest first.
+func (m *Manager) List() ([]string, error) {
+	entries, err := os.ReadDir(m.config.Dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+
+	var snapshots []string
+	for _, name := range sortSnapshots(names) {
+		snapshots = append(snapshots, filepath.Join(m.config.Dir, name))
+	}
+	return snapshots, nil
+}
+
+// ListS3 returns the names of the snapshots in the S3 bucket, newest first.
+func (m *Manager) ListS3(ctx context.Context) ([]string, error) {
+	if m.s3 == nil {
+		return nil, errors.New("no S3 bucket is configured (BACKUP_S3_BUCKET)")
+	}
+
+	names, err := m.s3.snapshots(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return sortSnapshots(names), nil
+}
+
+// Download copies a snapshot from the S3 bucket to the local backup directory,
+// unless it is already there, and returns its directory for Restore.
+func (m *Manager) Download(ctx context.Context, name string) (string, error) {
+	if m.s3 == nil {
+		return "", errors.New("no S3 bucket is configured (BACKUP_S3_BUCKET)")
+	}
+	if _, ok := snapshotTime(name); !ok {
+		return "", fmt.Errorf("invalid snapshot name %q", name)
+	}
+
+	dir := filepath.Join(m.config.Dir, name)
+	if _, err := os.Stat(dir); err == nil {
+		return dir, nil
+	}
+
+	// like Snapshot, a partial download never looks complete
+	if err := os.MkdirAll(m.config.Dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create backup directory: %w", err)
+	}
+	tmpDir := dir + ".tmp"
+	if err := os.Mkdir(tmpDir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create backup directory: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if err := m.s3.download(ctx, name+"/"+manifestFile, filepath.Join(tmpDir, manifestFile)); err != nil {
+		return "", err
+	}
+	manifest, err := readManifest(filepath.Join(tmpDir, manifestFile))
+	if err != nil {
+		return "", err
+	}
+	for _, file := range manifest.Databases {
+		if _, ok := databases[file]; !ok {
+			return "", fmt.Errorf("unknown database %s in snapshot", file)
+		}
+		if err := m.s3.download(ctx, name+"/"+file, filepath.Join(tmpDir, file)); err != nil {
+			return "", err
+		}
+	}
+
+	if err := os.Rename(tmpDir, dir); err != nil {
+		return "", fmt.Errorf("failed to finalize download: %w", err)
+	}
+	return dir, nil
+}
+
+// snapshotTime parses the creation time in a snapshot name. Snapshots taken
+// before names had milliseconds are recognized too.
+func snapshotTime(name string) (time.Time, bool) {
+	// parsing accepts a fractional second the layout doesn't have
+	t, err := time.Parse("20060102T150405Z", name)
+	return t, err == nil
+}
+
+// sortSnapshots returns the snapshot names among names, newest first.
+func sortSnapshots(names []string) []string {
+	type snapshot struct {
+		name string
+		at   time.Time
+	}
+	var snapshots []snapshot
+	for _, name := range names {
+		if at, ok := snapshotTime(name); ok {
+			snapshots = append(snapshots, snapshot{name, at})
+		}
+	}
+	sort.Slice(snapshots, func(i, j int) bool { return snapshots[i].at.After(snapshots[j].at) })
+
+	sorted := make([]string, len(snapshots))
+	for i, s := range snapshots {
+		sorted[i] = s.name
+	}
+	return sorted
+}
+
+// prune removes the oldest local snapshots beyond the configured count.
+func (m *Manager) prune() error {
+	if m.config.Keep <= 0 {
+		return nil
+	}
+
+	snapshots, err := m.List()
+	if err != nil {
+		return err
+	}
+
+	for i := m.config.Keep; i < len(snapshots); i++ {
+		if err := os.RemoveAll(snapshots[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Restore replaces the live databases with the ones in the snapshot directory.
+// The snapshot's schema is validated against this build's migrations first, so
+// a snapshot from a newer version or with modified migrations is rejected.
+// The server must not be running. It returns the snapshot manifest and the
+// media files listed in it that are missing from the media directory.
+func Restore(ctx context.Context, dir string) (*Manifest, []string, error) {
+	manifest, err := readManifest(filepath.Join(dir, manifestFile))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if slices.Contains(manifest.Databases, messagesDBFile) {
+		version, err := schemaVersion(filepath.Join(dir, messagesDBFile))
+		if err != nil {
+			return nil, nil, err
+		}
+		if version != manifest.SchemaVersion {
+			return nil, nil, fmt.Errorf("snapshot messages.db is at schema version %d but the manifest says %d", version, manifest.SchemaVersion)
+		}
+	}
+
+	for _, file := range manifest.Databases {
+		dst, ok := databases[file]
+		if !ok {
+			return nil, nil, fmt.Errorf("unknown database %s in snapshot", file)
+		}
+		if err := restoreDatabase(ctx, filepath.Join(dir, file), dst); err != nil {
+			return nil, nil, fmt.Errorf("failed to restore %s: %w", file, err)
+		}
+	}
+
+	var missing []string
+	for _, media := range manifest.Media {
+		if _, err := os.Stat(paths.GetMediaPath(media.Path)); err != nil {
+			missing = append(missing, media.Path)
+		}
+	}
+
+	return manifest, missing, nil
+}
+
+// schemaVersion validates the messages database at path against the embedded
+// migrations and returns its schema version.
+func schemaVersion(path string) (int, error) {
+	db, err := sql.Open("sqlite", "file:"+path+"?mode=ro")
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+
+	version, err := storage.NewMigrator(db).Validate()
+	if err != nil {
+		return 0, fmt.Errorf("invalid messages.db schema: %w", err)
+	}
+	return version, nil
+}
+
+// mediaManifest lists the files of the media directory.
+func mediaManifest() ([]MediaFile, error) {
+	var files []MediaFile
+	err := filepath.WalkDir(paths.DataMediaDir, func(path string, d fs.DirEntry, err error) error {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(paths.DataMediaDir, path)
+		if err != nil {
+			return err
+		}
+
+		files = append(files, MediaFile{
+			Path:    filepath.ToSlash(rel),
+			Size:    info.Size(),
+			ModTime: info.ModTime().UTC(),
+		})
+		return nil
+	})
+	return files, err
+}
+
+func writeManifest(path string, manifest Manifest) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+func readManifest(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup manifest: %w", err)
+	}
+
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("invalid backup manifest: %w", err)
+	}
+	return &manifest, nil
+}
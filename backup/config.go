@@ -0,0 +1,48 @@
+package backup
+
+import (
+	"path/filepath"
+	"time"
+	"whatsapp-mcp/config"
+	"whatsapp-mcp/paths"
+)
+
+// Config holds the backup system configuration.
+type Config struct {
+	Dir      string        // Local directory where snapshots are written
+	Interval time.Duration // Time between scheduled snapshots (0 disables scheduling)
+	Keep     int           // Number of local snapshots kept (0 keeps all)
+	S3       *S3Config     // Optional S3-compatible upload target
+}
+
+// S3Config holds the settings of an S3-compatible bucket snapshots are uploaded to.
+type S3Config struct {
+	Endpoint        string // e.g. https://s3.amazonaws.com or https://minio.local:9000
+	Region          string
+	Bucket          string
+	Prefix          string // key prefix inside the bucket
+	AccessKeyID     string
+	SecretAccessKey string
+}
+
+// LoadConfig loads backup configuration from environment variables.
+func LoadConfig() *Config {
+	cfg := &Config{
+		Dir:      config.GetEnv("BACKUP_DIR", filepath.Join(paths.DataDir, "backups")),
+		Interval: time.Duration(config.GetEnvInt("BACKUP_INTERVAL_HOURS", 0)) * time.Hour,
+		Keep:     config.GetEnvInt("BACKUP_KEEP", 7),
+	}
+
+	if bucket := config.GetEnv("BACKUP_S3_BUCKET", ""); bucket != "" {
+		cfg.S3 = &S3Config{
+			Endpoint:        config.GetEnv("BACKUP_S3_ENDPOINT", "https://s3.amazonaws.com"),
+			Region:          config.GetEnv("BACKUP_S3_REGION", "us-east-1"),
+			Bucket:          bucket,
+			Prefix:          config.GetEnv("BACKUP_S3_PREFIX", "whatsapp-mcp"),
+			AccessKeyID:     config.GetEnv("BACKUP_S3_ACCESS_KEY_ID", ""),
+			SecretAccessKey: config.GetEnv("BACKUP_S3_SECRET_ACCESS_KEY", ""),
+		}
+	}
+
+	return cfg
+}
@@ -0,0 +1,86 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// s3PartSize is the part size of multipart uploads. Files larger than this
+// are uploaded in parts, several at a time.
+const s3PartSize = 64 << 20
+
+// s3Bucket uploads and downloads snapshot files in an S3-compatible bucket,
+// under the configured prefix.
+type s3Bucket struct {
+	config *S3Config
+	client *minio.Client
+}
+
+func newS3Bucket(config *S3Config) (*s3Bucket, error) {
+	endpoint, err := url.Parse(config.Endpoint)
+	if err != nil || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid S3 endpoint %q", config.Endpoint)
+	}
+
+	client, err := minio.New(endpoint.Host, &minio.Options{
+		Creds:  credentials.NewStaticV4(config.AccessKeyID, config.SecretAccessKey, ""),
+		Secure: endpoint.Scheme != "http",
+		Region: config.Region,
+		// path-style requests work with every S3-compatible server
+		BucketLookup: minio.BucketLookupPath,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid S3 settings: %w", err)
+	}
+
+	return &s3Bucket{config: config, client: client}, nil
+}
+
+// key returns the object key of name, relative to the configured prefix.
+func (b *s3Bucket) key(name string) string {
+	if prefix := strings.Trim(b.config.Prefix, "/"); prefix != "" {
+		return prefix + "/" + name
+	}
+	return name
+}
+
+// upload stores the local file at path under name.
+func (b *s3Bucket) upload(ctx context.Context, name, path string) error {
+	_, err := b.client.FPutObject(ctx, b.config.Bucket, b.key(name), path, minio.PutObjectOptions{
+		PartSize: s3PartSize,
+	})
+	if err != nil {
+		return fmt.Errorf("S3 upload of %s failed: %w", name, err)
+	}
+	return nil
+}
+
+// download writes the object stored under name to the local file at path.
+func (b *s3Bucket) download(ctx context.Context, name, path string) error {
+	if err := b.client.FGetObject(ctx, b.config.Bucket, b.key(name), path, minio.GetObjectOptions{}); err != nil {
+		return fmt.Errorf("S3 download of %s failed: %w", name, err)
+	}
+	return nil
+}
+
+// snapshots returns the names of the snapshots in the bucket, the "directories"
+// right under the prefix.
+func (b *s3Bucket) snapshots(ctx context.Context) ([]string, error) {
+	prefix := b.key("")
+
+	var names []string
+	for object := range b.client.ListObjects(ctx, b.config.Bucket, minio.ListObjectsOptions{Prefix: prefix}) {
+		if object.Err != nil {
+			return nil, fmt.Errorf("failed to list S3 snapshots: %w", object.Err)
+		}
+		if name, ok := strings.CutSuffix(strings.TrimPrefix(object.Key, prefix), "/"); ok {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
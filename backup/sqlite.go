@@ -0,0 +1,67 @@
+package backup
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"modernc.org/sqlite"
+)
+
+// onlineBackup is implemented by the modernc.org/sqlite driver connection and
+// exposes SQLite's online backup API.
+type onlineBackup interface {
+	NewBackup(dstURI string) (*sqlite.Backup, error)
+	NewRestore(srcURI string) (*sqlite.Backup, error)
+}
+
+// copyDatabase copies the SQLite database at srcPath to dstPath with the online
+// backup API, so it is consistent even while the server is writing to it.
+func copyDatabase(ctx context.Context, srcPath, dstPath string) error {
+	return withBackupConn(ctx, srcPath, func(conn onlineBackup) (*sqlite.Backup, error) {
+		return conn.NewBackup(dstPath)
+	})
+}
+
+// restoreDatabase overwrites the SQLite database at dstPath with the contents
+// of the snapshot at srcPath.
+func restoreDatabase(ctx context.Context, srcPath, dstPath string) error {
+	return withBackupConn(ctx, dstPath, func(conn onlineBackup) (*sqlite.Backup, error) {
+		return conn.NewRestore(srcPath)
+	})
+}
+
+// withBackupConn opens the database at path and runs the backup returned by
+// start on its driver connection.
+func withBackupConn(ctx context.Context, path string, start func(onlineBackup) (*sqlite.Backup, error)) error {
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=busy_timeout(5000)")
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	return conn.Raw(func(driverConn any) error {
+		c, ok := driverConn.(onlineBackup)
+		if !ok {
+			return fmt.Errorf("sqlite driver doesn't support online backup")
+		}
+
+		b, err := start(c)
+		if err != nil {
+			return err
+		}
+
+		// copy all pages in one step so concurrent writes can't restart the backup
+		if _, err := b.Step(-1); err != nil {
+			b.Finish()
+			return err
+		}
+		return b.Finish()
+	})
+}
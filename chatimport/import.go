@@ -0,0 +1,330 @@
+// Package chatimport imports chat histories from WhatsApp's "Export chat"
+// feature (the .txt file, or the .zip with the .txt and attachments).
+//
+// Senders are mapped to JIDs by phone number or by matching their name against
+// the stored contacts; in a direct chat every sender other than me is the
+// other party. Imported messages get deterministic IDs, so importing the same
+// file twice is harmless, and messages that were already synced are skipped.
+package chatimport
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"whatsapp-mcp/storage"
+)
+
+// Options configures an import.
+type Options struct {
+	ChatJID   string         // chat the messages belong to (required)
+	ChatName  string         // name for the chat if it isn't stored yet (default: from the file name)
+	MyName    string         // my name as shown in the export (detected for direct chats)
+	DateOrder string         // auto (default), dmy, mdy or ymd
+	Location  *time.Location // time zone of the phone that made the export (default: UTC)
+}
+
+// Result summarizes an import.
+type Result struct {
+	Parsed   int      // messages read from the file, system messages excluded
+	Imported int      // messages stored; the rest were already in the database
+	Unmapped []string // group senders that couldn't be matched to a JID
+}
+
+var (
+	phoneNameRe      = regexp.MustCompile(`^\+?[\d\s().-]{8,}$`)
+	iosAttachmentRe  = regexp.MustCompile(`^<attached: (?:\d+-)?(.+)>$`)
+	androidAttachRe  = regexp.MustCompile(`^(.+\.\w+) \(.+\)$`)
+	chatFileNameTrim = regexp.MustCompile(`(?i)^whatsapp chat( with| -)?\s*`)
+)
+
+// ImportFile imports the export at path (a .txt or .zip file) into the chat
+// given in opts.
+func ImportFile(store *storage.MessageStore, path string, opts Options) (*Result, error) {
+	if opts.ChatJID == "" {
+		return nil, fmt.Errorf("chat JID is required")
+	}
+	if opts.Location == nil {
+		opts.Location = time.UTC
+	}
+	if opts.ChatName == "" {
+		opts.ChatName = chatNameFromFile(path)
+	}
+
+	r, closeFile, err := openExport(path)
+	if err != nil {
+		return nil, err
+	}
+	defer closeFile()
+
+	entries, err := Parse(r, opts.DateOrder, opts.Location)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filepath.Base(path), err)
+	}
+
+	resolver, err := newSenderResolver(store, opts, entries)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{}
+	var messages []storage.Message
+	seen := make(map[string]int)
+
+	for _, e := range entries {
+		if e.System {
+			continue
+		}
+		result.Parsed++
+
+		senderJID, isFromMe, err := resolver.resolve(e.Sender)
+		if err != nil {
+			return nil, err
+		}
+
+		// identical messages in the same second are told apart by their order
+		key := strings.Join([]string{opts.ChatJID, e.Time.UTC().Format(time.RFC3339), e.Sender, e.Text}, "\x00")
+		seen[key]++
+		hash := sha256.Sum256([]byte(fmt.Sprintf("%s\x00%d", key, seen[key])))
+
+		messages = append(messages, storage.Message{
+			ID:          storage.ImportedIDPrefix + hex.EncodeToString(hash[:12]),
+			ChatJID:     opts.ChatJID,
+			SenderJID:   senderJID,
+			Text:        e.Text,
+			Timestamp:   e.Time,
+			IsFromMe:    isFromMe,
+			MessageType: messageType(e.Text),
+		})
+	}
+
+	if err := resolver.saveNames(); err != nil {
+		return nil, err
+	}
+
+	chat := storage.Chat{
+		JID:         opts.ChatJID,
+		ContactName: opts.ChatName,
+		IsGroup:     strings.HasSuffix(opts.ChatJID, "@g.us"),
+	}
+	result.Imported, err = store.ImportMessages(chat, messages)
+	if err != nil {
+		return nil, err
+	}
+
+	result.Unmapped = resolver.unmapped
+	return result, nil
+}
+
+// openExport opens the chat text of a .txt export or of the .txt inside a .zip export.
+func openExport(path string) (io.Reader, func() error, error) {
+	if !strings.EqualFold(filepath.Ext(path), ".zip") {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		return f, f.Close, nil
+	}
+
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, f := range zr.File {
+		if strings.EqualFold(filepath.Ext(f.Name), ".txt") {
+			rc, err := f.Open()
+			if err != nil {
+				zr.Close()
+				return nil, nil, err
+			}
+			return rc, func() error {
+				rc.Close()
+				return zr.Close()
+			}, nil
+		}
+	}
+
+	zr.Close()
+	return nil, nil, fmt.Errorf("no chat .txt file in %s", filepath.Base(path))
+}
+
+// chatNameFromFile derives the chat name from an export file name such as
+// "WhatsApp Chat with Alice.txt" or "WhatsApp Chat - Family.zip".
+func chatNameFromFile(path string) string {
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	name = strings.TrimSpace(chatFileNameTrim.ReplaceAllString(name, ""))
+	if name == "" || strings.EqualFold(name, "_chat") {
+		return ""
+	}
+	return name
+}
+
+// messageType guesses the message type from attachment placeholders.
+func messageType(text string) string {
+	var fileName string
+	if m := iosAttachmentRe.FindStringSubmatch(text); m != nil {
+		fileName = m[1]
+	} else if m := androidAttachRe.FindStringSubmatch(text); m != nil && !strings.Contains(m[1], " ") {
+		fileName = m[1]
+	} else {
+		return "text"
+	}
+
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".jpg", ".jpeg", ".png", ".gif":
+		return "image"
+	case ".webp":
+		return "sticker"
+	case ".mp4", ".mov", ".3gp":
+		return "video"
+	case ".opus", ".ogg", ".m4a", ".mp3", ".aac":
+		return "audio"
+	default:
+		return "document"
+	}
+}
+
+// senderResolver maps the sender names of an export to JIDs.
+type senderResolver struct {
+	store    *storage.MessageStore
+	opts     Options
+	isGroup  bool
+	ownJID   string
+	jids     map[string]string // name -> JID
+	newNames map[string]string // JID -> name, for senders without a known JID
+	unmapped []string
+}
+
+func newSenderResolver(store *storage.MessageStore, opts Options, entries []Entry) (*senderResolver, error) {
+	ownJID, err := store.GetOwnJID()
+	if err != nil {
+		return nil, err
+	}
+
+	r := &senderResolver{
+		store:    store,
+		opts:     opts,
+		isGroup:  strings.HasSuffix(opts.ChatJID, "@g.us"),
+		ownJID:   ownJID,
+		jids:     make(map[string]string),
+		newNames: make(map[string]string),
+	}
+
+	if r.opts.MyName == "" {
+		if err := r.detectMyName(entries); err != nil {
+			return nil, err
+		}
+	}
+
+	return r, nil
+}
+
+// detectMyName finds my name in a direct chat: of the two senders, the one
+// that isn't the other party.
+func (r *senderResolver) detectMyName(entries []Entry) error {
+	var senders []string
+	for _, e := range entries {
+		if !e.System && e.Sender != "" && !containsFold(senders, e.Sender) {
+			senders = append(senders, e.Sender)
+		}
+	}
+
+	if r.isGroup || len(senders) > 2 {
+		return fmt.Errorf("my name is required to tell my messages apart (senders: %s)", strings.Join(senders, ", "))
+	}
+
+	var others []string
+	for _, sender := range senders {
+		if jid, _ := r.lookup(sender); jid == r.opts.ChatJID {
+			continue
+		}
+		others = append(others, sender)
+	}
+
+	switch {
+	case len(others) == 0:
+		return nil // only the other party wrote
+	case len(others) == 1 && len(senders) == 2:
+		r.opts.MyName = others[0]
+		return nil
+	default:
+		return fmt.Errorf("my name is required to tell my messages apart (senders: %s)", strings.Join(senders, ", "))
+	}
+}
+
+// resolve returns the JID of a sender and whether the sender is me.
+func (r *senderResolver) resolve(name string) (string, bool, error) {
+	if r.opts.MyName != "" && strings.EqualFold(name, r.opts.MyName) {
+		return r.ownJID, true, nil
+	}
+	if !r.isGroup {
+		return r.opts.ChatJID, false, nil
+	}
+
+	if jid, ok := r.jids[name]; ok {
+		return jid, false, nil
+	}
+
+	jid, err := r.lookup(name)
+	if err != nil {
+		return "", false, err
+	}
+	if jid == "" {
+		// keep the name visible through push_names under a stable placeholder JID
+		hash := sha256.Sum256([]byte(name))
+		jid = hex.EncodeToString(hash[:8]) + "@import"
+		r.newNames[jid] = name
+		r.unmapped = append(r.unmapped, name)
+	}
+
+	r.jids[name] = jid
+	return jid, false, nil
+}
+
+// lookup returns the JID of a phone number or of the single contact with the
+// given name, or an empty string if there is none.
+func (r *senderResolver) lookup(name string) (string, error) {
+	if phoneNameRe.MatchString(name) {
+		digits := strings.Map(func(c rune) rune {
+			if c >= '0' && c <= '9' {
+				return c
+			}
+			return -1
+		}, name)
+		return digits + "@s.whatsapp.net", nil
+	}
+
+	jids, err := r.store.FindContactJIDsByName(name)
+	if err != nil {
+		return "", fmt.Errorf("failed to look up %s: %w", name, err)
+	}
+	if len(jids) != 1 {
+		return "", nil
+	}
+	return jids[0], nil
+}
+
+// saveNames stores the names of unmapped senders so messages show who sent them.
+func (r *senderResolver) saveNames() error {
+	if len(r.newNames) == 0 {
+		return nil
+	}
+	return r.store.SavePushNames(r.newNames)
+}
+
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
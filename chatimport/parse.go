@@ -0,0 +1,181 @@
+package chatimport
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Date orders of the date in a message header.
+const (
+	DateOrderAuto = "auto" // detect from the file, day first if ambiguous
+	DateOrderDMY  = "dmy"
+	DateOrderMDY  = "mdy"
+	DateOrderYMD  = "ymd"
+)
+
+// Entry is a single message parsed from a chat export.
+type Entry struct {
+	Time   time.Time
+	Sender string // name or phone number as shown in the export (empty for system messages)
+	Text   string // may span several lines
+	System bool   // encryption notices, "X added Y", ...
+}
+
+// headerRe matches the header of a message line in both export formats:
+//
+//	iOS:     [12/03/2021, 14:05:09] Name: text
+//	Android: 12/03/2021, 14:05 - Name: text
+//
+// Day, month and year may be separated by / . or -, the year may come first
+// and have two or four digits, and the time may be 12-hour with AM/PM.
+var headerRe = regexp.MustCompile(`^\[?(\d{1,4})[./-](\d{1,2})[./-](\d{1,4}),?\s+(\d{1,2})[:.](\d{2})(?:[:.](\d{2}))?(?:\s*([AaPp])\.?\s?[Mm]\.?)?\]?\s*(?:[-–]\s)?(.*)$`)
+
+// bidiMarks are invisible direction marks WhatsApp inserts around names and system text.
+var bidiMarks = strings.NewReplacer("\u200e", "", "\u200f", "", "\u202a", "", "\u202c", "", "\ufeff", "")
+
+// header is a parsed message header before the date order is known.
+type header struct {
+	date      [3]int
+	longFirst bool // the first date field has four digits (year first)
+	hour, min int
+	sec       int
+	ampm      byte // 'a', 'p' or 0 for 24-hour time
+}
+
+// Parse reads a WhatsApp "Export chat" text file. Times are interpreted in loc,
+// the time zone of the phone that made the export.
+func Parse(r io.Reader, dateOrder string, loc *time.Location) ([]Entry, error) {
+	var entries []Entry
+	var headers []header
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+
+	for scanner.Scan() {
+		line := strings.TrimLeft(scanner.Text(), "\u200e\u200f\ufeff")
+		line = strings.NewReplacer("\u202f", " ", "\u00a0", " ").Replace(line)
+
+		m := headerRe.FindStringSubmatch(line)
+		if m == nil {
+			// continuation of a multi-line message
+			if len(entries) > 0 {
+				last := &entries[len(entries)-1]
+				last.Text += "\n" + bidiMarks.Replace(line)
+			}
+			continue
+		}
+
+		h := header{longFirst: len(m[1]) == 4}
+		for i := 0; i < 3; i++ {
+			h.date[i], _ = strconv.Atoi(m[i+1])
+		}
+		h.hour, _ = strconv.Atoi(m[4])
+		h.min, _ = strconv.Atoi(m[5])
+		h.sec, _ = strconv.Atoi(m[6]) // empty when the export has no seconds
+		if m[7] != "" {
+			h.ampm = strings.ToLower(m[7])[0]
+		}
+		headers = append(headers, h)
+		entries = append(entries, parseBody(m[8]))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if dateOrder == "" || dateOrder == DateOrderAuto {
+		var err error
+		if dateOrder, err = detectDateOrder(headers); err != nil {
+			return nil, err
+		}
+	}
+
+	for i, h := range headers {
+		t, err := h.time(dateOrder, loc)
+		if err != nil {
+			return nil, fmt.Errorf("message %d: %w", i+1, err)
+		}
+		entries[i].Time = t
+	}
+
+	return entries, nil
+}
+
+// parseBody splits the text after the header into sender and message text.
+func parseBody(body string) Entry {
+	sender, text, found := strings.Cut(body, ": ")
+	if !found {
+		return Entry{Text: bidiMarks.Replace(body), System: true}
+	}
+
+	// iOS marks system messages (and attachments) with a leading direction mark
+	system := strings.HasPrefix(text, "\u200e") && !strings.HasPrefix(text, "\u200e<attached:")
+
+	return Entry{
+		Sender: strings.TrimSpace(bidiMarks.Replace(sender)),
+		Text:   bidiMarks.Replace(text),
+		System: system,
+	}
+}
+
+// detectDateOrder picks the date order that fits every header: year first if
+// the year has four digits, otherwise day first unless a month would be over 12.
+func detectDateOrder(headers []header) (string, error) {
+	dmy, mdy := true, true
+	for _, h := range headers {
+		if h.longFirst {
+			return DateOrderYMD, nil
+		}
+		if h.date[1] > 12 {
+			dmy = false
+		}
+		if h.date[0] > 12 {
+			mdy = false
+		}
+	}
+
+	switch {
+	case dmy:
+		return DateOrderDMY, nil
+	case mdy:
+		return DateOrderMDY, nil
+	default:
+		return "", fmt.Errorf("unrecognized date format: set the date order explicitly")
+	}
+}
+
+// time converts the header to a time in loc.
+func (h header) time(dateOrder string, loc *time.Location) (time.Time, error) {
+	var day, month, year int
+	switch dateOrder {
+	case DateOrderDMY:
+		day, month, year = h.date[0], h.date[1], h.date[2]
+	case DateOrderMDY:
+		month, day, year = h.date[0], h.date[1], h.date[2]
+	case DateOrderYMD:
+		year, month, day = h.date[0], h.date[1], h.date[2]
+	default:
+		return time.Time{}, fmt.Errorf("invalid date order %q: must be auto, dmy, mdy or ymd", dateOrder)
+	}
+
+	if year < 100 {
+		year += 2000
+	}
+	if month < 1 || month > 12 || day < 1 || day > 31 {
+		return time.Time{}, fmt.Errorf("invalid date %d-%02d-%02d for date order %s", year, month, day, dateOrder)
+	}
+
+	hour := h.hour
+	switch {
+	case h.ampm == 'p' && hour < 12:
+		hour += 12
+	case h.ampm == 'a' && hour == 12:
+		hour = 0
+	}
+
+	return time.Date(year, time.Month(month), day, hour, h.min, h.sec, 0, loc), nil
+}
@@ -0,0 +1,151 @@
+// Backup is a CLI tool for taking and restoring database snapshots.
+//
+// Snapshots are made with SQLite's online backup API, so "create" is safe to
+// run while the server is running. "restore" must be run with the server
+// stopped; it validates the snapshot's schema version against the migrations
+// of this build before overwriting the live databases.
+//
+// Commands:
+//
+//	create                - Write a new snapshot (and upload it if BACKUP_S3_BUCKET is set)
+//	list [--s3]           - List local snapshots, or the ones in the bucket, newest first
+//	restore <dir>         - Restore the databases from a snapshot directory
+//	restore --s3 <name>   - Download a snapshot from the bucket and restore it
+//
+// Examples:
+//
+//	# Take a snapshot
+//	go run cmd/backup/main.go create
+//
+//	# Restore a snapshot
+//	go run cmd/backup/main.go restore data/backups/20261016T120000.000Z
+//
+//	# Restore a snapshot from the bucket
+//	go run cmd/backup/main.go restore --s3 20261016T120000.000Z
+//
+// Settings are read from the environment (and .env): BACKUP_DIR, BACKUP_KEEP
+// and the BACKUP_S3_* variables, see .env.example.
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"whatsapp-mcp/backup"
+
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		printUsage()
+		os.Exit(1)
+	}
+
+	// .env is optional, the variables may come from the environment
+	_ = godotenv.Load()
+
+	ctx := context.Background()
+	manager := backup.NewManager(backup.LoadConfig(), log.New(os.Stdout, "[BACKUP] ", log.LstdFlags))
+
+	switch os.Args[1] {
+	case "create":
+		dir, err := manager.Snapshot(ctx)
+		if err != nil {
+			fmt.Printf("Error creating backup: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Backup written to %s\n", dir)
+	case "list":
+		var snapshots []string
+		var err error
+		if len(os.Args) > 2 && os.Args[2] == "--s3" {
+			snapshots, err = manager.ListS3(ctx)
+		} else {
+			snapshots, err = manager.List()
+		}
+		if err != nil {
+			fmt.Printf("Error listing backups: %v\n", err)
+			os.Exit(1)
+		}
+		if len(snapshots) == 0 {
+			fmt.Println("No backups found")
+		}
+		for _, dir := range snapshots {
+			fmt.Println(dir)
+		}
+	case "restore":
+		dir, err := snapshotDir(ctx, manager, os.Args[2:])
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			fmt.Println("Usage: go run cmd/backup/main.go restore <dir> | --s3 <name>")
+			os.Exit(1)
+		}
+		if err := runRestore(ctx, dir); err != nil {
+			fmt.Printf("Error restoring backup: %v\n", err)
+			os.Exit(1)
+		}
+	default:
+		printUsage()
+		os.Exit(1)
+	}
+}
+
+// printUsage prints the usage information for the backup tool.
+func printUsage() {
+	fmt.Println("Backup CLI Tool")
+	fmt.Println("")
+	fmt.Println("Usage:")
+	fmt.Println("  go run cmd/backup/main.go create")
+	fmt.Println("  go run cmd/backup/main.go list [--s3]")
+	fmt.Println("  go run cmd/backup/main.go restore <dir> | --s3 <name>")
+	fmt.Println("")
+	fmt.Println("Commands:")
+	fmt.Println("  create      Write a snapshot of messages.db and whatsapp_auth.db")
+	fmt.Println("  list        List local snapshots, or with --s3 the ones in the bucket, newest first")
+	fmt.Println("  restore     Restore the databases from a snapshot (stop the server first);")
+	fmt.Println("              with --s3 it is downloaded from the bucket first")
+}
+
+// snapshotDir returns the local directory of the snapshot named by the
+// restore arguments, downloading it from the bucket for --s3.
+func snapshotDir(ctx context.Context, manager *backup.Manager, args []string) (string, error) {
+	if len(args) == 0 {
+		return "", fmt.Errorf("snapshot directory required")
+	}
+	if args[0] != "--s3" {
+		return args[0], nil
+	}
+	if len(args) < 2 {
+		return "", fmt.Errorf("snapshot name required")
+	}
+
+	dir, err := manager.Download(ctx, args[1])
+	if err != nil {
+		return "", err
+	}
+	fmt.Printf("Downloaded %s to %s\n", args[1], dir)
+	return dir, nil
+}
+
+// runRestore restores a snapshot and reports media files it references that
+// are no longer on disk.
+func runRestore(ctx context.Context, dir string) error {
+	manifest, missing, err := backup.Restore(ctx, dir)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Restored %v from %s (schema version %d, taken %s)\n",
+		manifest.Databases, dir, manifest.SchemaVersion, manifest.CreatedAt.Format("2006-01-02 15:04:05 MST"))
+
+	if len(missing) > 0 {
+		fmt.Printf("\n%d of %d media files in the snapshot manifest are missing from the media directory:\n", len(missing), len(manifest.Media))
+		for _, path := range missing {
+			fmt.Printf("  %s\n", path)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,127 @@
+// Export is a CLI tool for dumping chats and messages to JSON Lines, CSV or
+// Parquet.
+//
+// Rows are streamed from the database, so large exports use constant memory.
+// Message rows include the media metadata of the message. The same export is
+// served by the server at GET /api/export.
+//
+// Examples:
+//
+//	# Export all messages as JSON Lines to stdout
+//	go run cmd/export/main.go
+//
+//	# Export one chat for a date range as CSV
+//	go run cmd/export/main.go -format csv -chat 5511999999999@s.whatsapp.net \
+//	    -since 2026-01-01 -until 2026-02-01 -o messages.csv
+//
+//	# Export the chat list
+//	go run cmd/export/main.go -dataset chats -format csv
+//
+//	# Export all messages as Parquet
+//	go run cmd/export/main.go -format parquet -o messages.parquet
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+	"whatsapp-mcp/config"
+	"whatsapp-mcp/export"
+	"whatsapp-mcp/storage"
+
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	// .env is optional, the variables may come from the environment
+	_ = godotenv.Load()
+
+	dataset := flag.String("dataset", export.DatasetMessages, "dataset to export: messages or chats")
+	format := flag.String("format", export.FormatJSONL, "output format: jsonl, csv or parquet")
+	chats := flag.String("chat", "", "comma-separated chat JIDs to export (default: all chats)")
+	since := flag.String("since", "", "only messages after this time (RFC 3339 or 2006-01-02)")
+	until := flag.String("until", "", "only messages before this time (RFC 3339 or 2006-01-02)")
+	includeDeleted := flag.Bool("include-deleted", false, "also export messages deleted for everyone")
+	output := flag.String("o", "", "output file (default: stdout)")
+	flag.Parse()
+
+	if err := run(*dataset, *format, *chats, *since, *until, *includeDeleted, *output); err != nil {
+		fmt.Fprintf(os.Stderr, "Error exporting: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// run parses the options and writes the export.
+func run(dataset, format, chats, since, until string, includeDeleted bool, output string) error {
+	timezone, err := time.LoadLocation(config.GetEnv("TIMEZONE", "UTC"))
+	if err != nil {
+		timezone = time.UTC
+	}
+
+	opts := export.Options{
+		Dataset:        strings.ToLower(dataset),
+		Format:         strings.ToLower(format),
+		IncludeDeleted: includeDeleted,
+	}
+	for _, jid := range strings.Split(chats, ",") {
+		if jid = strings.TrimSpace(jid); jid != "" {
+			opts.ChatJIDs = append(opts.ChatJIDs, jid)
+		}
+	}
+	if since != "" {
+		t, err := export.ParseTime(since, timezone)
+		if err != nil {
+			return err
+		}
+		opts.Since = &t
+	}
+	if until != "" {
+		t, err := export.ParseTime(until, timezone)
+		if err != nil {
+			return err
+		}
+		opts.Until = &t
+	}
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+
+	db, err := storage.InitDB()
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	var out io.Writer = os.Stdout
+	if output != "" {
+		f, err := os.Create(output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+
+	w := bufio.NewWriter(out)
+
+	// Ctrl+C stops the export cleanly
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	count, err := export.Write(ctx, storage.NewMessageStore(db), w, opts)
+	if err != nil {
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Exported %d %s\n", count, opts.Dataset)
+	return nil
+}
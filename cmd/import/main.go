@@ -0,0 +1,92 @@
+// Import is a CLI tool for importing chats exported from the WhatsApp app
+// ("Export chat"), as the .txt file or the .zip that contains it.
+//
+// Messages are merged into the chat given with -chat. Messages that were
+// already synced, or imported before, are skipped, so running an import twice
+// is safe. The export's times are local to the phone that made it: set -tz
+// (default: TIMEZONE) to that phone's time zone.
+//
+// Examples:
+//
+//	# Import a direct chat (my name is detected from the senders)
+//	go run cmd/import/main.go -chat 5511999999999@s.whatsapp.net "WhatsApp Chat with Alice.zip"
+//
+//	# Import a group with US dates
+//	go run cmd/import/main.go -chat 120363000000000000@g.us -me "Ana" -date-order mdy _chat.txt
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+	"whatsapp-mcp/chatimport"
+	"whatsapp-mcp/config"
+	"whatsapp-mcp/storage"
+
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	// .env is optional, the variables may come from the environment
+	_ = godotenv.Load()
+
+	chatJID := flag.String("chat", "", "JID of the chat to import into (required)")
+	chatName := flag.String("name", "", "chat name if the chat isn't stored yet (default: from the file name)")
+	myName := flag.String("me", "", "my name as shown in the export (required for groups)")
+	dateOrder := flag.String("date-order", chatimport.DateOrderAuto, "date order of the export: auto, dmy, mdy or ymd")
+	tz := flag.String("tz", config.GetEnv("TIMEZONE", "UTC"), "time zone of the phone that made the export")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: go run cmd/import/main.go -chat <jid> [options] <export.txt|export.zip>...")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if *chatJID == "" || flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	location, err := time.LoadLocation(*tz)
+	if err != nil {
+		fmt.Printf("Error: invalid time zone %q: %v\n", *tz, err)
+		os.Exit(1)
+	}
+
+	db, err := storage.InitDB()
+	if err != nil {
+		fmt.Printf("Error opening database: %v\n", err)
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	store := storage.NewMessageStore(db)
+	opts := chatimport.Options{
+		ChatJID:   *chatJID,
+		ChatName:  *chatName,
+		MyName:    *myName,
+		DateOrder: strings.ToLower(*dateOrder),
+		Location:  location,
+	}
+
+	failed := false
+	for _, path := range flag.Args() {
+		result, err := chatimport.ImportFile(store, path, opts)
+		if err != nil {
+			fmt.Printf("Error importing %s: %v\n", path, err)
+			failed = true
+			continue
+		}
+
+		fmt.Printf("%s: imported %d of %d messages (%d already stored)\n",
+			path, result.Imported, result.Parsed, result.Parsed-result.Imported)
+		if len(result.Unmapped) > 0 {
+			fmt.Printf("  Senders without a known JID: %s\n", strings.Join(result.Unmapped, ", "))
+		}
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+}
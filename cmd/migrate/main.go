@@ -9,6 +9,10 @@
 //	create <description>  - Create a new migration file
 //	status                - Show migration status
 //	upgrade [version]     - Apply pending migrations (all or up to version)
+//	merge <other.db>      - Import chats and messages from another database
+//	maintain [--full]     - Checkpoint the WAL, vacuum, ANALYZE and report sizes
+//	doctor [--fix]        - Check integrity, orphaned rows and media files
+//	archive <months> [chat_jid...] - Move older messages to the archive database
 //
 // Examples:
 //
@@ -24,11 +28,24 @@
 //	# Upgrade to specific version
 //	go run cmd/migrate/main.go upgrade 5
 //
+//	# Merge the database of another instance
+//	go run cmd/migrate/main.go merge /path/to/other/messages.db
+//
+//	# Reclaim space and see what uses it
+//	go run cmd/migrate/main.go maintain
+//
+//	# Check the database and repair what can be repaired
+//	go run cmd/migrate/main.go doctor --fix
+//
+//	# Archive messages older than a year in two chats
+//	go run cmd/migrate/main.go archive 12 5511999999999@s.whatsapp.net 120363000000000000@g.us
+//
 // Migration files are stored in storage/migrations/ and are automatically
 // embedded in the application binary. Never modify applied migrations.
 package main
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"os"
@@ -78,6 +95,42 @@ func main() {
 			fmt.Printf("Error running upgrade: %v\n", err)
 			os.Exit(1)
 		}
+	case "merge":
+		if len(os.Args) < 3 {
+			fmt.Println("Error: database path required")
+			fmt.Println("Usage: go run cmd/migrate/main.go merge <other.db>")
+			os.Exit(1)
+		}
+		if err := runMerge(os.Args[2]); err != nil {
+			fmt.Printf("Error merging database: %v\n", err)
+			os.Exit(1)
+		}
+	case "maintain":
+		full := len(os.Args) > 2 && os.Args[2] == "--full"
+		if err := runMaintain(full); err != nil {
+			fmt.Printf("Error running maintenance: %v\n", err)
+			os.Exit(1)
+		}
+	case "doctor":
+		fix := len(os.Args) > 2 && os.Args[2] == "--fix"
+		ok, err := runDoctor(fix)
+		if err != nil {
+			fmt.Printf("Error running doctor: %v\n", err)
+			os.Exit(1)
+		}
+		if !ok {
+			os.Exit(1)
+		}
+	case "archive":
+		if len(os.Args) < 3 {
+			fmt.Println("Error: age in months required")
+			fmt.Println("Usage: go run cmd/migrate/main.go archive <months> [chat_jid...]")
+			os.Exit(1)
+		}
+		if err := runArchive(os.Args[2], os.Args[3:]); err != nil {
+			fmt.Printf("Error archiving messages: %v\n", err)
+			os.Exit(1)
+		}
 	default:
 		printUsage()
 		os.Exit(1)
@@ -92,11 +145,21 @@ func printUsage() {
 	fmt.Println("  go run cmd/migrate/main.go create <description>")
 	fmt.Println("  go run cmd/migrate/main.go status")
 	fmt.Println("  go run cmd/migrate/main.go upgrade [version|latest]")
+	fmt.Println("  go run cmd/migrate/main.go merge <other.db>")
+	fmt.Println("  go run cmd/migrate/main.go maintain [--full]")
+	fmt.Println("  go run cmd/migrate/main.go doctor [--fix]")
+	fmt.Println("  go run cmd/migrate/main.go archive <months> [chat_jid...]")
 	fmt.Println("")
 	fmt.Println("Commands:")
 	fmt.Println("  create      Create a new migration file")
 	fmt.Println("  status      Show migration status (applied and pending)")
 	fmt.Println("  upgrade     Apply migrations up to specified version or latest")
+	fmt.Println("  merge       Import chats, messages and media metadata from another database")
+	fmt.Println("  maintain    Checkpoint the WAL, release free pages, ANALYZE and report table, index and media sizes")
+	fmt.Println("              (--full rebuilds the database with VACUUM; stop the server first)")
+	fmt.Println("  doctor      Check integrity, rows that lost their chat or message, and media files")
+	fmt.Println("              (--fix repairs them and deletes untracked media files; stop the server first)")
+	fmt.Println("  archive     Move messages older than <months> months (of the given chats, or all) to the archive database")
 	fmt.Println("")
 	fmt.Println("Examples:")
 	fmt.Println("  go run cmd/migrate/main.go create add_message_reactions")
@@ -345,3 +408,177 @@ func runUpgrade(target string) error {
 	fmt.Printf("Upgrading to version %d...\n", version)
 	return migrator.MigrateTo(version)
 }
+
+// runMerge merges another whatsapp-mcp database into the current one.
+func runMerge(otherPath string) error {
+	if _, err := os.Stat(otherPath); err != nil {
+		return err
+	}
+
+	db, err := openDB()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	stats, err := storage.MergeDatabase(context.Background(), db, otherPath)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Merged %s:\n", otherPath)
+	fmt.Printf("  Chats added or updated: %d\n", stats.Chats)
+	fmt.Printf("  Messages added: %d\n", stats.Messages)
+	fmt.Printf("  Media metadata added: %d\n", stats.Media)
+	fmt.Println("\nMedia files are not copied: copy the other instance's data/media/ directory to keep its downloaded files.")
+
+	return nil
+}
+
+// runMaintain runs database maintenance and prints the size report.
+func runMaintain(full bool) error {
+	db, err := openDB()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if full {
+		fmt.Println("Rebuilding the database with VACUUM, this may take a while...")
+	}
+
+	report, err := storage.Maintain(context.Background(), db, full, paths.DataMediaDir)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("\nMaintenance:")
+	fmt.Printf("  WAL checkpoint: %d of %d frames", report.Checkpointed, report.WALFrames)
+	if report.WALBusy {
+		fmt.Print(" (busy, WAL not truncated)")
+	}
+	fmt.Println()
+	fmt.Printf("  Freed pages: %d (%s)\n", report.FreedPages, formatFileSize(int64(report.FreedPages)*report.PageSize))
+	if report.FreePages > 0 && !report.IncrementalReady {
+		fmt.Printf("  Free pages left: %d (%s), run 'maintain --full' once to enable incremental vacuum\n",
+			report.FreePages, formatFileSize(int64(report.FreePages)*report.PageSize))
+	}
+
+	fmt.Println("\nSizes:")
+	fmt.Println(strings.Repeat("-", 80))
+	fmt.Printf("%-40s %-7s %-20s %10s\n", "Name", "Type", "Table", "Size")
+	fmt.Println(strings.Repeat("-", 80))
+	for _, o := range report.Objects {
+		fmt.Printf("%-40s %-7s %-20s %10s\n", truncateString(o.Name, 40), o.Type, truncateString(o.Table, 20), formatFileSize(o.Size))
+	}
+	if len(report.Objects) == 0 {
+		fmt.Println("(per-table sizes unavailable: SQLite built without dbstat)")
+	}
+	fmt.Println(strings.Repeat("-", 80))
+	fmt.Printf("Database: %s\n", formatFileSize(report.DatabaseSize))
+	fmt.Printf("Media (%s): %d files, %s\n", paths.DataMediaDir, report.MediaFiles, formatFileSize(report.MediaSize))
+
+	return nil
+}
+
+// runDoctor checks the database and prints the problems found. It returns
+// false if problems remain.
+func runDoctor(fix bool) (bool, error) {
+	db, err := openDB()
+	if err != nil {
+		return false, err
+	}
+	defer db.Close()
+
+	report, err := storage.Doctor(context.Background(), db, paths.DataMediaDir, fix)
+	if err != nil {
+		return false, err
+	}
+
+	if report.OK() {
+		fmt.Println("No problems found.")
+		return true, nil
+	}
+
+	fmt.Println("\nProblems found:")
+	for _, problem := range report.Integrity {
+		fmt.Printf("  Integrity: %s\n", problem)
+	}
+	if len(report.MissingChats) > 0 {
+		fmt.Printf("  Messages in %d chats that aren't stored: %s\n", len(report.MissingChats), strings.Join(report.MissingChats, ", "))
+	}
+	for table, count := range report.Orphans {
+		fmt.Printf("  Orphaned %s rows: %d\n", table, count)
+	}
+	if len(report.MissingFiles) > 0 {
+		fmt.Printf("  Downloaded media with a missing file: %d\n", len(report.MissingFiles))
+	}
+	if len(report.UntrackedFiles) > 0 {
+		fmt.Printf("  Untracked files in %s: %d\n", paths.DataMediaDir, len(report.UntrackedFiles))
+		for _, path := range report.UntrackedFiles {
+			fmt.Printf("    %s\n", path)
+		}
+	}
+	if report.CountDrift > 0 {
+		fmt.Printf("  Chats with a wrong message count: %d\n", report.CountDrift)
+	}
+
+	if len(report.Integrity) > 0 {
+		fmt.Println("\nThe database file is corrupted: restore a backup (go run cmd/backup/main.go list).")
+	}
+	if !report.Fixed {
+		fmt.Println("\nRun with --fix to repair the rows and files.")
+		return false, nil
+	}
+
+	fmt.Println("\nRepaired: missing chats recreated, orphaned rows deleted, media with missing files reset to pending,")
+	fmt.Println("untracked files deleted and message counts recomputed.")
+	return len(report.Integrity) == 0, nil
+}
+
+// runArchive moves messages older than the given number of months to the
+// archive database.
+func runArchive(months string, chatJIDs []string) error {
+	n, err := strconv.Atoi(months)
+	if err != nil || n <= 0 {
+		return fmt.Errorf("invalid age %q: must be a positive number of months", months)
+	}
+
+	db, err := openDB()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	path := paths.ArchiveDBPath
+
+	before := time.Now().AddDate(0, -n, 0)
+	count, err := storage.NewMessageStore(db).ArchiveMessages(context.Background(), chatJIDs, before)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Archived %d messages older than %s to %s\n", count, before.Format("2006-01-02"), path)
+	if count > 0 {
+		fmt.Println("Search them with search_messages and archive=true.")
+	}
+	return nil
+}
+
+// formatFileSize converts bytes to a human-readable size string.
+func formatFileSize(bytes int64) string {
+	const (
+		KB = 1024
+		MB = KB * 1024
+		GB = MB * 1024
+	)
+
+	if bytes >= GB {
+		return fmt.Sprintf("%.2f GB", float64(bytes)/float64(GB))
+	} else if bytes >= MB {
+		return fmt.Sprintf("%.2f MB", float64(bytes)/float64(MB))
+	} else if bytes >= KB {
+		return fmt.Sprintf("%.2f KB", float64(bytes)/float64(KB))
+	}
+	return fmt.Sprintf("%d B", bytes)
+}
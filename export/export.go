@@ -0,0 +1,308 @@
+// Package export dumps chats and messages (with their media metadata) to
+// JSON Lines, CSV or Parquet. Rows are streamed straight from the database, so
+// memory use doesn't grow with the size of the export; Parquet output holds at
+// most one row group in memory.
+package export
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/xitongsys/parquet-go/writer"
+
+	"whatsapp-mcp/storage"
+)
+
+// Datasets that can be exported.
+const (
+	DatasetMessages = "messages"
+	DatasetChats    = "chats"
+)
+
+// Formats that can be exported.
+const (
+	FormatJSONL   = "jsonl"
+	FormatCSV     = "csv"
+	FormatParquet = "parquet"
+)
+
+// parquetRowGroupSize caps the size of a Parquet row group, which is buffered
+// in memory until it is written.
+const parquetRowGroupSize = 16 << 20
+
+// ContentTypes maps export formats to their MIME type.
+var ContentTypes = map[string]string{
+	FormatJSONL:   "application/x-ndjson",
+	FormatCSV:     "text/csv",
+	FormatParquet: "application/vnd.apache.parquet",
+}
+
+// Options selects what an export contains.
+type Options struct {
+	Dataset        string     // messages (default) or chats
+	Format         string     // jsonl (default), csv or parquet
+	ChatJIDs       []string   // only these chats (empty means all chats)
+	Since          *time.Time // only messages strictly after this time
+	Until          *time.Time // only messages strictly before this time
+	IncludeDeleted bool       // also export messages deleted for everyone
+}
+
+// Validate fills in defaults and checks the dataset and format.
+func (o *Options) Validate() error {
+	if o.Dataset == "" {
+		o.Dataset = DatasetMessages
+	}
+	if o.Format == "" {
+		o.Format = FormatJSONL
+	}
+
+	if o.Dataset != DatasetMessages && o.Dataset != DatasetChats {
+		return fmt.Errorf("invalid dataset %q: must be messages or chats", o.Dataset)
+	}
+	if _, ok := ContentTypes[o.Format]; !ok {
+		return fmt.Errorf("invalid format %q: must be jsonl, csv or parquet", o.Format)
+	}
+	return nil
+}
+
+// messageRow is an exported message, media metadata included.
+type messageRow struct {
+	ID                  string `json:"id" parquet:"name=id, type=BYTE_ARRAY, convertedtype=UTF8"`
+	ChatJID             string `json:"chat_jid" parquet:"name=chat_jid, type=BYTE_ARRAY, convertedtype=UTF8"`
+	ChatName            string `json:"chat_name" parquet:"name=chat_name, type=BYTE_ARRAY, convertedtype=UTF8"`
+	SenderJID           string `json:"sender_jid" parquet:"name=sender_jid, type=BYTE_ARRAY, convertedtype=UTF8"`
+	SenderName          string `json:"sender_name" parquet:"name=sender_name, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Timestamp           string `json:"timestamp" parquet:"name=timestamp, type=BYTE_ARRAY, convertedtype=UTF8"`
+	IsFromMe            bool   `json:"is_from_me" parquet:"name=is_from_me, type=BOOLEAN"`
+	MessageType         string `json:"message_type" parquet:"name=message_type, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Text                string `json:"text" parquet:"name=text, type=BYTE_ARRAY, convertedtype=UTF8"`
+	ReplyToID           string `json:"reply_to_id,omitempty" parquet:"name=reply_to_id, type=BYTE_ARRAY, convertedtype=UTF8"`
+	EditedAt            string `json:"edited_at,omitempty" parquet:"name=edited_at, type=BYTE_ARRAY, convertedtype=UTF8"`
+	DeletedAt           string `json:"deleted_at,omitempty" parquet:"name=deleted_at, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Transcript          string `json:"transcript,omitempty" parquet:"name=transcript, type=BYTE_ARRAY, convertedtype=UTF8"`
+	MediaFileName       string `json:"media_file_name,omitempty" parquet:"name=media_file_name, type=BYTE_ARRAY, convertedtype=UTF8"`
+	MediaMimeType       string `json:"media_mime_type,omitempty" parquet:"name=media_mime_type, type=BYTE_ARRAY, convertedtype=UTF8"`
+	MediaFileSize       int64  `json:"media_file_size,omitempty" parquet:"name=media_file_size, type=INT64"`
+	MediaFilePath       string `json:"media_file_path,omitempty" parquet:"name=media_file_path, type=BYTE_ARRAY, convertedtype=UTF8"`
+	MediaDownloadStatus string `json:"media_download_status,omitempty" parquet:"name=media_download_status, type=BYTE_ARRAY, convertedtype=UTF8"`
+}
+
+var messageColumns = []string{
+	"id", "chat_jid", "chat_name", "sender_jid", "sender_name", "timestamp", "is_from_me",
+	"message_type", "text", "reply_to_id", "edited_at", "deleted_at", "transcript",
+	"media_file_name", "media_mime_type", "media_file_size", "media_file_path", "media_download_status",
+}
+
+func (r messageRow) record() []string {
+	return []string{
+		r.ID, r.ChatJID, r.ChatName, r.SenderJID, r.SenderName, r.Timestamp, strconv.FormatBool(r.IsFromMe),
+		r.MessageType, r.Text, r.ReplyToID, r.EditedAt, r.DeletedAt, r.Transcript,
+		r.MediaFileName, r.MediaMimeType, strconv.FormatInt(r.MediaFileSize, 10), r.MediaFilePath, r.MediaDownloadStatus,
+	}
+}
+
+func newMessageRow(msg storage.MessageWithNames) messageRow {
+	senderName := msg.SenderContactName
+	if senderName == "" {
+		senderName = msg.SenderPushName
+	}
+
+	row := messageRow{
+		ID:          msg.ID,
+		ChatJID:     msg.ChatJID,
+		ChatName:    msg.ChatName,
+		SenderJID:   msg.SenderJID,
+		SenderName:  senderName,
+		Timestamp:   formatTime(msg.Timestamp),
+		IsFromMe:    msg.IsFromMe,
+		MessageType: msg.MessageType,
+		Text:        msg.Text,
+		ReplyToID:   msg.ReplyToID,
+		Transcript:  msg.Transcript,
+	}
+	if msg.EditedAt != nil {
+		row.EditedAt = formatTime(*msg.EditedAt)
+	}
+	if msg.DeletedAt != nil {
+		row.DeletedAt = formatTime(*msg.DeletedAt)
+	}
+	if meta := msg.MediaMetadata; meta != nil {
+		row.MediaFileName = meta.FileName
+		row.MediaMimeType = meta.MimeType
+		row.MediaFileSize = meta.FileSize
+		row.MediaFilePath = meta.FilePath
+		row.MediaDownloadStatus = meta.DownloadStatus
+	}
+	return row
+}
+
+// chatRow is an exported chat.
+type chatRow struct {
+	JID               string `json:"jid" parquet:"name=jid, type=BYTE_ARRAY, convertedtype=UTF8"`
+	ContactName       string `json:"contact_name" parquet:"name=contact_name, type=BYTE_ARRAY, convertedtype=UTF8"`
+	PushName          string `json:"push_name" parquet:"name=push_name, type=BYTE_ARRAY, convertedtype=UTF8"`
+	IsGroup           bool   `json:"is_group" parquet:"name=is_group, type=BOOLEAN"`
+	LastMessageTime   string `json:"last_message_time" parquet:"name=last_message_time, type=BYTE_ARRAY, convertedtype=UTF8"`
+	UnreadCount       int    `json:"unread_count" parquet:"name=unread_count, type=INT64"`
+	DisappearingTimer int    `json:"disappearing_timer" parquet:"name=disappearing_timer, type=INT64"`
+}
+
+var chatColumns = []string{
+	"jid", "contact_name", "push_name", "is_group", "last_message_time", "unread_count", "disappearing_timer",
+}
+
+func (r chatRow) record() []string {
+	return []string{
+		r.JID, r.ContactName, r.PushName, strconv.FormatBool(r.IsGroup), r.LastMessageTime,
+		strconv.Itoa(r.UnreadCount), strconv.Itoa(r.DisappearingTimer),
+	}
+}
+
+func newChatRow(chat storage.Chat) chatRow {
+	return chatRow{
+		JID:               chat.JID,
+		ContactName:       chat.ContactName,
+		PushName:          chat.PushName,
+		IsGroup:           chat.IsGroup,
+		LastMessageTime:   formatTime(chat.LastMessageTime),
+		UnreadCount:       chat.UnreadCount,
+		DisappearingTimer: chat.DisappearingTimer,
+	}
+}
+
+// row is a single exported record.
+type row interface {
+	record() []string
+}
+
+// rowWriter writes rows in a specific format.
+type rowWriter interface {
+	write(r row) error
+	flush() error
+}
+
+type jsonlWriter struct {
+	enc *json.Encoder
+}
+
+func (w *jsonlWriter) write(r row) error { return w.enc.Encode(r) }
+func (w *jsonlWriter) flush() error      { return nil }
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func (w *csvWriter) write(r row) error { return w.w.Write(r.record()) }
+
+func (w *csvWriter) flush() error {
+	w.w.Flush()
+	return w.w.Error()
+}
+
+// parquetWriter writes rows to a Parquet file, one row group at a time.
+type parquetWriter struct {
+	pw *writer.ParquetWriter
+}
+
+func newParquetWriter(w io.Writer, schema row) (*parquetWriter, error) {
+	pw, err := writer.NewParquetWriterFromWriter(w, schema, 1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create parquet writer: %w", err)
+	}
+	pw.RowGroupSize = parquetRowGroupSize
+	return &parquetWriter{pw: pw}, nil
+}
+
+func (w *parquetWriter) write(r row) error { return w.pw.Write(r) }
+func (w *parquetWriter) flush() error      { return w.pw.WriteStop() }
+
+// Write streams the export selected by opts to w and returns the number of rows.
+// A cancelled ctx stops the export.
+func Write(ctx context.Context, store *storage.MessageStore, w io.Writer, opts Options) (int, error) {
+	if err := opts.Validate(); err != nil {
+		return 0, err
+	}
+
+	columns, schema := messageColumns, row(new(messageRow))
+	if opts.Dataset == DatasetChats {
+		columns, schema = chatColumns, new(chatRow)
+	}
+
+	var rw rowWriter
+	switch opts.Format {
+	case FormatCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(columns); err != nil {
+			return 0, err
+		}
+		rw = &csvWriter{w: cw}
+	case FormatParquet:
+		pw, err := newParquetWriter(w, schema)
+		if err != nil {
+			return 0, err
+		}
+		rw = pw
+	default:
+		rw = &jsonlWriter{enc: json.NewEncoder(w)}
+	}
+
+	// LIDs given for contacts stored under their phone number
+	chatJIDs, err := store.CanonicalJIDs(opts.ChatJIDs)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	switch opts.Dataset {
+	case DatasetChats:
+		err = store.ForEachChat(ctx, chatJIDs, func(chat storage.Chat) error {
+			count++
+			return rw.write(newChatRow(chat))
+		})
+	default:
+		filter := storage.SearchFilter{
+			ChatJIDs:       chatJIDs,
+			After:          opts.Since,
+			Before:         opts.Until,
+			IncludeDeleted: opts.IncludeDeleted,
+		}
+		err = store.ForEachMessageWithNames(ctx, filter, func(msg storage.MessageWithNames) error {
+			count++
+			return rw.write(newMessageRow(msg))
+		})
+	}
+	if err != nil {
+		return count, err
+	}
+
+	return count, rw.flush()
+}
+
+// ParseTime parses an export date filter: RFC 3339, or a local date and time
+// such as "2006-01-02T15:04:05" or "2006-01-02" in loc.
+func ParseTime(s string, loc *time.Location) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t, nil
+	}
+
+	for _, format := range []string{"2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02"} {
+		if t, err := time.ParseInLocation(format, s, loc); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("invalid time %q (expected RFC 3339 or 2006-01-02)", s)
+}
+
+// formatTime formats t in UTC for export, or returns "" for a zero time.
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
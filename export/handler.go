@@ -0,0 +1,98 @@
+package export
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"whatsapp-mcp/storage"
+)
+
+// Handler serves exports over HTTP.
+type Handler struct {
+	store    *storage.MessageStore
+	timezone *time.Location
+}
+
+// NewHandler creates a new export HTTP handler. Dates without an offset are
+// interpreted in timezone.
+func NewHandler(store *storage.MessageStore, timezone *time.Location) *Handler {
+	return &Handler{store: store, timezone: timezone}
+}
+
+// ServeHTTP handles GET /api/export.
+//
+// Query parameters: dataset (messages, chats), format (jsonl, csv, parquet),
+// chat_jid (repeatable or comma-separated), since, until and include_deleted.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, `{"error":"Method not allowed"}`, http.StatusMethodNotAllowed)
+		return
+	}
+
+	opts, err := h.parseOptions(r)
+	if err == nil {
+		err = opts.Validate()
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":%q}`, err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	fileName := fmt.Sprintf("%s_%s.%s", opts.Dataset, time.Now().In(h.timezone).Format("20060102-150405"), opts.Format)
+	w.Header().Set("Content-Type", ContentTypes[opts.Format])
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, fileName))
+
+	// the status is already sent once rows are streamed, so a failure can only be logged
+	count, err := Write(r.Context(), h.store, w, opts)
+	if err != nil {
+		log.Printf("Export of %s stopped after %d rows: %v", opts.Dataset, count, err)
+	}
+}
+
+// parseOptions reads the export options from the query string.
+func (h *Handler) parseOptions(r *http.Request) (Options, error) {
+	query := r.URL.Query()
+
+	opts := Options{
+		Dataset: strings.ToLower(query.Get("dataset")),
+		Format:  strings.ToLower(query.Get("format")),
+	}
+
+	for _, value := range query["chat_jid"] {
+		for _, jid := range strings.Split(value, ",") {
+			if jid = strings.TrimSpace(jid); jid != "" {
+				opts.ChatJIDs = append(opts.ChatJIDs, jid)
+			}
+		}
+	}
+
+	if since := query.Get("since"); since != "" {
+		t, err := ParseTime(since, h.timezone)
+		if err != nil {
+			return opts, err
+		}
+		opts.Since = &t
+	}
+
+	if until := query.Get("until"); until != "" {
+		t, err := ParseTime(until, h.timezone)
+		if err != nil {
+			return opts, err
+		}
+		opts.Until = &t
+	}
+
+	if includeDeleted := query.Get("include_deleted"); includeDeleted != "" {
+		b, err := strconv.ParseBool(includeDeleted)
+		if err != nil {
+			return opts, fmt.Errorf("invalid include_deleted %q", includeDeleted)
+		}
+		opts.IncludeDeleted = b
+	}
+
+	return opts, nil
+}
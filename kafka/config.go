@@ -0,0 +1,40 @@
+package kafka
+
+import (
+	"strings"
+	"whatsapp-mcp/config"
+)
+
+// Config holds the Kafka producer configuration.
+type Config struct {
+	Brokers      []string // bootstrap brokers, host:port (empty disables producing)
+	Topic        string   // single topic for all events, with the type in a header (empty = topic per type)
+	TopicPrefix  string   // per-type topics are <prefix><event type>, e.g. whatsapp.message.received
+	RequiredAcks int16    // -1 waits for all in-sync replicas, 1 for the leader only
+	TLS          bool     // connect over TLS
+	SASLUsername string   // SASL/PLAIN credentials, if the cluster requires them
+	SASLPassword string
+	QueueSize    int // events buffered while brokers are unreachable
+}
+
+// LoadConfig loads Kafka configuration from environment variables.
+func LoadConfig() *Config {
+	cfg := &Config{
+		Topic:        config.GetEnv("KAFKA_TOPIC", ""),
+		TopicPrefix:  config.GetEnv("KAFKA_TOPIC_PREFIX", "whatsapp."),
+		RequiredAcks: -1,
+		TLS:          config.GetEnvBool("KAFKA_TLS", false),
+		SASLUsername: config.GetEnv("KAFKA_SASL_USERNAME", ""),
+		SASLPassword: config.GetEnv("KAFKA_SASL_PASSWORD", ""),
+		QueueSize:    config.GetEnvInt("KAFKA_QUEUE_SIZE", 10000),
+	}
+	for _, broker := range strings.Split(config.GetEnv("KAFKA_BROKERS", ""), ",") {
+		if broker = strings.TrimSpace(broker); broker != "" {
+			cfg.Brokers = append(cfg.Brokers, broker)
+		}
+	}
+	if config.GetEnv("KAFKA_ACKS", "all") == "1" {
+		cfg.RequiredAcks = 1
+	}
+	return cfg
+}
@@ -0,0 +1,223 @@
+// Package kafka produces webhook events to Kafka.
+//
+// Each event is a record whose value is its webhook JSON payload and whose
+// key is the chat or group JID, so a chat's events stay in order on one
+// partition. Records go to a topic per event type (<prefix><event type>) or,
+// with KAFKA_TOPIC, to a single topic; either way the event_type and event_id
+// headers are set. The producer uses the franz-go client, with optional TLS
+// and SASL/PLAIN. Events are queued in memory and retried until the brokers
+// accept them, so delivery is at least once while the server runs; they are
+// dropped when the queue is full or on shutdown.
+package kafka
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/sasl/plain"
+
+	"whatsapp-mcp/webhook"
+)
+
+const (
+	clientID        = "whatsapp-mcp"
+	dialTimeout     = 10 * time.Second
+	deliveryTimeout = 30 * time.Second
+	maxBatchRecords = 500
+)
+
+// permanentErrors are produce errors that retrying won't fix, such as a
+// record larger than the topic allows or a missing ACL.
+var permanentErrors = []error{
+	kerr.MessageTooLarge,
+	kerr.InvalidTopicException,
+	kerr.RecordListTooLarge,
+	kerr.TopicAuthorizationFailed,
+	kerr.InvalidRecord,
+}
+
+// Publisher produces events to Kafka. It implements webhook.Publisher.
+type Publisher struct {
+	config *Config
+	log    webhook.Logger
+	queue  *webhook.PublishQueue
+	client *kgo.Client
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewPublisher creates a new Kafka producer.
+func NewPublisher(config *Config, logger webhook.Logger) *Publisher {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &Publisher{
+		config: config,
+		log:    logger,
+		queue:  webhook.NewPublishQueue("Kafka", config.QueueSize, logger),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// Enabled reports whether brokers are configured.
+func (p *Publisher) Enabled() bool {
+	return len(p.config.Brokers) > 0
+}
+
+// Start produces queued events in the background. It does nothing if no
+// brokers are configured.
+func (p *Publisher) Start() {
+	if !p.Enabled() {
+		return
+	}
+
+	client, err := kgo.NewClient(p.options()...)
+	if err != nil {
+		p.log.Printf("Warning: not producing to Kafka: %v", err)
+		return
+	}
+	p.client = client
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		p.queue.Run(p.ctx, maxBatchRecords, p.produceEvents)
+	}()
+
+	if p.config.Topic != "" {
+		p.log.Printf("Producing events to Kafka topic %s", p.config.Topic)
+	} else {
+		p.log.Printf("Producing events to Kafka topics %s*", p.config.TopicPrefix)
+	}
+}
+
+// options returns the client options for the configuration. Keyed records
+// are partitioned like Kafka's default partitioner (murmur2), and brokers
+// create missing topics if they're set to auto-create them.
+func (p *Publisher) options() []kgo.Opt {
+	options := []kgo.Opt{
+		kgo.SeedBrokers(p.config.Brokers...),
+		kgo.ClientID(clientID),
+		kgo.DialTimeout(dialTimeout),
+		kgo.AllowAutoTopicCreation(),
+		// fail records the brokers don't take in time, so the queue retries
+		// them with its own backoff
+		kgo.RecordDeliveryTimeout(deliveryTimeout),
+	}
+	if p.config.RequiredAcks == 1 {
+		// idempotent writes need acks from all in-sync replicas
+		options = append(options, kgo.RequiredAcks(kgo.LeaderAck()), kgo.DisableIdempotentWrite())
+	} else {
+		options = append(options, kgo.RequiredAcks(kgo.AllISRAcks()))
+	}
+	if p.config.TLS {
+		options = append(options, kgo.DialTLSConfig(&tls.Config{MinVersion: tls.VersionTLS12}))
+	}
+	if p.config.SASLUsername != "" {
+		options = append(options, kgo.SASL(plain.Auth{
+			User: p.config.SASLUsername,
+			Pass: p.config.SASLPassword,
+		}.AsMechanism()))
+	}
+	return options
+}
+
+// Stop waits for the batch being produced and closes the client.
+func (p *Publisher) Stop() {
+	p.cancel()
+	p.wg.Wait()
+
+	if p.client != nil {
+		p.client.Close()
+	}
+}
+
+// Publish queues an event for producing without blocking.
+func (p *Publisher) Publish(payload webhook.WebhookPayload) {
+	if !p.Enabled() {
+		return
+	}
+	p.queue.Push(payload)
+}
+
+// newRecord builds the record of an event.
+func (p *Publisher) newRecord(payload webhook.WebhookPayload) (*kgo.Record, error) {
+	value, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	topic := p.config.Topic
+	if topic == "" {
+		topic = p.config.TopicPrefix + payload.EventType
+	}
+
+	var key []byte
+	if chat := payload.ChatJID(); chat != "" {
+		key = []byte(chat)
+	}
+
+	return &kgo.Record{
+		Topic: topic,
+		Key:   key,
+		Value: value,
+		Headers: []kgo.RecordHeader{
+			{Key: "event_type", Value: []byte(payload.EventType)},
+			{Key: "event_id", Value: []byte(payload.ID)},
+		},
+		Timestamp: payload.Timestamp,
+	}, nil
+}
+
+// produceEvents produces a batch of events and waits for the brokers to
+// acknowledge them. It returns the ones that failed, with the first error.
+// Events that can't be encoded or that the brokers reject for good are logged
+// and dropped.
+func (p *Publisher) produceEvents(batch []webhook.WebhookPayload) ([]webhook.WebhookPayload, error) {
+	records := make([]*kgo.Record, 0, len(batch))
+	payloads := make(map[*kgo.Record]webhook.WebhookPayload, len(batch))
+	for _, payload := range batch {
+		record, err := p.newRecord(payload)
+		if err != nil {
+			p.log.Printf("Failed to marshal event %s: %v", payload.ID, err)
+			continue
+		}
+		records = append(records, record)
+		payloads[record] = payload
+	}
+
+	var failed []webhook.WebhookPayload
+	var firstErr error
+	for _, result := range p.client.ProduceSync(p.ctx, records...) {
+		if result.Err == nil {
+			continue
+		}
+		if isPermanent(result.Err) {
+			p.log.Printf("Dropped event %s: producing to %s failed: %v", payloads[result.Record].ID, result.Record.Topic, result.Err)
+			continue
+		}
+		failed = append(failed, payloads[result.Record])
+		if firstErr == nil {
+			firstErr = result.Err
+		}
+	}
+	return failed, firstErr
+}
+
+// isPermanent reports whether a produce error won't go away on retry.
+func isPermanent(err error) bool {
+	for _, permanent := range permanentErrors {
+		if errors.Is(err, permanent) {
+			return true
+		}
+	}
+	return false
+}
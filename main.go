@@ -14,11 +14,13 @@
 //   - Timezone-aware message formatting
 //
 // Configuration is done via environment variables (see .env.example).
-// Authentication uses QR code scanning on first launch.
+// Authentication uses QR code scanning on first launch, or a pairing code
+// entered on the phone for headless servers.
 //
 // Usage:
 //
 //	whatsapp-mcp
+//	whatsapp-mcp -pair-phone 5511999999999
 //
 // The server runs as an MCP stdio server and communicates via JSON-RPC.
 package main
@@ -26,6 +28,8 @@ package main
 import (
 	"context"
 	"crypto/subtle"
+	"encoding/json"
+	"flag"
 	"fmt"
 	"log"
 	"net/http"
@@ -35,8 +39,15 @@ import (
 	"syscall"
 	"time"
 
+	"whatsapp-mcp/api"
+	"whatsapp-mcp/backup"
+	"whatsapp-mcp/export"
+	"whatsapp-mcp/kafka"
 	"whatsapp-mcp/mcp"
+	"whatsapp-mcp/mqtt"
+	"whatsapp-mcp/nats"
 	"whatsapp-mcp/paths"
+	"whatsapp-mcp/redis"
 	"whatsapp-mcp/storage"
 	"whatsapp-mcp/webhook"
 	"whatsapp-mcp/whatsapp"
@@ -44,7 +55,6 @@ import (
 	"github.com/joho/godotenv"
 	"github.com/mark3labs/mcp-go/server"
 	"github.com/mdp/qrterminal/v3"
-	"github.com/skip2/go-qrcode"
 )
 
 // TODO: cleanup main entry
@@ -58,6 +68,9 @@ func main() {
 		log.Println("Warning: .env file not found, using environment variables only")
 	}
 
+	pairPhone := flag.String("pair-phone", os.Getenv("WHATSAPP_PAIR_PHONE"), "log in with a pairing code for this phone number (with country code) instead of a QR code")
+	flag.Parse()
+
 	// get API key from environment
 	apiKey := os.Getenv("MCP_API_KEY")
 	if apiKey == "" {
@@ -119,6 +132,8 @@ func main() {
 	webhookStore := storage.NewWebhookStore(db)
 	webhookLogger := log.New(os.Stdout, "[WEBHOOK] ", log.LstdFlags)
 	webhookManager := webhook.NewWebhookManager(webhookStore, webhookConfig, webhookLogger)
+	webhookManager.SetMediaAccess(mediaStore, apiKey)
+	webhookManager.SetMessageStore(store)
 
 	// Register primary webhook from env var if configured.
 	// Note: Changing WEBHOOK_URL and restarting will update the existing "system:primary" webhook.
@@ -140,9 +155,41 @@ func main() {
 		}
 	}
 
+	// mirror events to NATS (disabled unless NATS_URL is set)
+	natsPublisher := nats.NewPublisher(nats.LoadConfig(), log.New(os.Stdout, "[NATS] ", log.LstdFlags))
+	if natsPublisher.Enabled() {
+		webhookManager.AddPublisher(natsPublisher)
+		natsPublisher.Start()
+	}
+
+	// produce events to Kafka (disabled unless KAFKA_BROKERS is set)
+	kafkaPublisher := kafka.NewPublisher(kafka.LoadConfig(), log.New(os.Stdout, "[KAFKA] ", log.LstdFlags))
+	if kafkaPublisher.Enabled() {
+		webhookManager.AddPublisher(kafkaPublisher)
+		kafkaPublisher.Start()
+	}
+
+	// add events to a Redis stream or channels (disabled unless REDIS_URL is set)
+	redisPublisher := redis.NewPublisher(redis.LoadConfig(), log.New(os.Stdout, "[REDIS] ", log.LstdFlags))
+	if redisPublisher.Enabled() {
+		webhookManager.AddPublisher(redisPublisher)
+		redisPublisher.Start()
+	}
+
+	// publish message events to an MQTT broker (disabled unless MQTT_URL is set)
+	mqttPublisher := mqtt.NewPublisher(mqtt.LoadConfig(), log.New(os.Stdout, "[MQTT] ", log.LstdFlags))
+	if mqttPublisher.Enabled() {
+		webhookManager.AddPublisher(mqttPublisher)
+		mqttPublisher.Start()
+	}
+
 	webhookManager.Start()
 	log.Println("Webhook manager started")
 
+	// scheduled backups (disabled unless BACKUP_INTERVAL_HOURS is set)
+	backupManager := backup.NewManager(backup.LoadConfig(), log.New(os.Stdout, "[BACKUP] ", log.LstdFlags))
+	backupManager.Start()
+
 	// initialize WhatsApp client
 	waClient, err := whatsapp.NewClient(store, mediaStore, webhookManager, logLevel)
 	if err != nil {
@@ -150,48 +197,79 @@ func main() {
 	}
 	log.Println("WhatsApp client created")
 
-	// check authentication and connect
-	if !waClient.IsLoggedIn() {
-		log.Println("Not logged in. Please scan QR code:")
+	// show QR codes and pairing codes whenever a device is paired, at
+	// startup or after a logout
+	lastPairingCode := ""
+	waClient.AddPairingListener(func(status whatsapp.PairingStatus) {
+		switch {
+		case status.PairingCode != "" && status.PairingCode != lastPairingCode:
+			lastPairingCode = status.PairingCode
+			fmt.Printf("\nPairing code: %s\n", status.PairingCode)
+			fmt.Println("On your phone, open WhatsApp > Linked devices > Link a device > Link with phone number instead, and enter the code.")
+		case status.Phone == "" && status.QRCode != "" && status.LastEvent == "code":
+			fmt.Println("\nScan the QR code below:")
+			qrterminal.GenerateHalfBlock(status.QRCode, qrterminal.L, os.Stdout)
+			fmt.Printf("\nIf it doesn't scan, open http://%s:%s/qr?api_key=<MCP_API_KEY> in a browser\n", host, httpPort)
+		case status.Error != "":
+			log.Println("Pairing:", status.Error)
+		case status.LastEvent != "code":
+			log.Println("QR event:", status.LastEvent)
+		}
+	})
 
-		ctx := context.Background()
-		qrChan, err := waClient.GetQRChannel(ctx)
-		if err != nil {
-			log.Fatal("Failed to get QR channel:", err)
+	// check authentication and connect; pairing runs in the background so
+	// the HTTP server (and its pairing endpoints) start right away
+	if !waClient.IsLoggedIn() {
+		if *pairPhone != "" {
+			log.Printf("Not logged in. Requesting a pairing code for %s...", *pairPhone)
+		} else {
+			log.Println("Not logged in. Please scan QR code:")
 		}
 
-		for evt := range qrChan {
-			if evt.Event == "code" {
-				fmt.Println("\nScan the QR code below:")
-				qrterminal.GenerateHalfBlock(evt.Code, qrterminal.L, os.Stdout)
-				fmt.Println("\nQR Code also saved to qr.png")
-				qrcode.WriteFile(evt.Code, qrcode.Low, 256, paths.QRCodePath)
-			} else {
-				log.Println("QR event:", evt.Event)
-			}
+		if err := waClient.StartPairing(*pairPhone); err != nil {
+			log.Fatal("Failed to start pairing:", err)
 		}
 	} else {
 		log.Println("Already logged in")
 
 		if err := waClient.Connect(); err != nil {
-			log.Fatal("Failed to connect:", err)
+			log.Printf("Failed to connect, retrying in the background: %v", err)
+		} else {
+			log.Println("Connected to WhatsApp")
 		}
-		log.Println("Connected to WhatsApp")
 	}
 
 	// initialize MCP server
-	mcpServer := mcp.NewMCPServer(waClient, store, mediaStore, timezone)
+	mcpServer := mcp.NewMCPServer(waClient, store, mediaStore, webhookManager, timezone)
 	log.Println("MCP server initialized")
 
+	webhookManager.SetQueueFullHandler(func(webhookID string) {
+		mcpServer.LogToClients("warning", "webhook", fmt.Sprintf("Webhook delivery queue full, dropping events for webhook %s", webhookID))
+	})
+
 	mux := http.NewServeMux()
 
+	// Health check. ?format=json adds the connection state and reconnect counters.
 	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
-		if waClient.IsLoggedIn() {
-			w.WriteHeader(http.StatusOK)
-			w.Write([]byte("OK"))
+		connErr := waClient.ConnectionError()
+
+		status := http.StatusOK
+		if connErr != nil {
+			status = http.StatusServiceUnavailable
+		}
+
+		if r.URL.Query().Get("format") == "json" {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(status)
+			json.NewEncoder(w).Encode(waClient.ConnectionStatus())
+			return
+		}
+
+		w.WriteHeader(status)
+		if connErr != nil {
+			w.Write([]byte(connErr.Error()))
 		} else {
-			w.WriteHeader(http.StatusServiceUnavailable)
-			w.Write([]byte("WhatsApp not connected"))
+			w.Write([]byte("OK"))
 		}
 	})
 
@@ -200,6 +278,16 @@ func main() {
 		server.WithEndpointPath("/mcp"),
 	)
 
+	// argument completion isn't implemented by the MCP server library, it's served in front of it
+	mcpHandler := mcpServer.CompletionHandler(streamableServer)
+
+	// optional key that also unlocks the admin tools (webhook management) and
+	// the admin REST endpoints, which are refused while it is unset
+	adminAPIKey := os.Getenv("MCP_ADMIN_API_KEY")
+	validateAdminAuth := func(r *http.Request) bool {
+		return adminAPIKey != "" && subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte("Bearer "+adminAPIKey)) == 1
+	}
+
 	// MCP endpoint. Authenticates via either an "Authorization: Bearer <key>"
 	// header (preferred — keeps the key out of URLs/logs) or the API key as the
 	// first path segment (/mcp/{apiKey}) for backward compatibility.
@@ -210,14 +298,16 @@ func main() {
 		authHeader := r.Header.Get("Authorization")
 		headerOK := subtle.ConstantTimeCompare([]byte(authHeader), []byte("Bearer "+apiKey)) == 1
 		pathOK := subtle.ConstantTimeCompare([]byte(providedKey), []byte(apiKey)) == 1
+		adminHeaderOK := adminAPIKey != "" && subtle.ConstantTimeCompare([]byte(authHeader), []byte("Bearer "+adminAPIKey)) == 1
+		adminPathOK := adminAPIKey != "" && subtle.ConstantTimeCompare([]byte(providedKey), []byte(adminAPIKey)) == 1
 
 		// remainingPath is the MCP path after the auth segment is stripped.
 		var remainingPath string
 		switch {
-		case headerOK:
+		case headerOK || adminHeaderOK:
 			// Key is in the header; the whole path after /mcp/ is the MCP path.
 			remainingPath = path
-		case pathOK:
+		case pathOK || adminPathOK:
 			// Key is in the path; strip it.
 			remainingPath = strings.TrimPrefix(path, providedKey)
 		default:
@@ -231,8 +321,12 @@ func main() {
 		}
 		r.URL.Path = "/mcp" + remainingPath
 
+		if adminHeaderOK || adminPathOK {
+			r = r.WithContext(mcp.WithAdminScope(r.Context()))
+		}
+
 		// Serve the MCP request
-		streamableServer.ServeHTTP(w, r)
+		mcpHandler.ServeHTTP(w, r)
 	})
 
 	// Webhook management API
@@ -263,6 +357,96 @@ func main() {
 		webhookHandler.HandleWebhookByID(w, r)
 	})
 
+	// Real-time event stream over WebSocket, with the same events as webhooks
+	mux.HandleFunc("/ws", webhookHandler.ServeStream)
+
+	// Media files, for the API key or signed URLs sent in webhook payloads
+	mux.HandleFunc("/media/", webhookHandler.ServeMedia)
+
+	// Bulk export API (streams JSON Lines, CSV or Parquet)
+	exportHandler := export.NewHandler(store, timezone)
+
+	mux.HandleFunc("/api/export", func(w http.ResponseWriter, r *http.Request) {
+		if !webhookHandler.ValidateAuth(r) {
+			http.Error(w, `{"error":"Unauthorized"}`, http.StatusUnauthorized)
+			return
+		}
+
+		exportHandler.ServeHTTP(w, r)
+	})
+
+	// REST API for systems that don't speak MCP (send a message, list chats and messages)
+	apiHandler := api.NewHandler(waClient, store, timezone)
+
+	for path, handle := range map[string]http.HandlerFunc{
+		"/api/messages":        apiHandler.SendMessage,
+		"/api/chats":           apiHandler.ListChats,
+		"/api/chats/":          apiHandler.HandleChatByJID,
+		"/api/media/downloads": apiHandler.DownloadQueue,
+		"/api/contacts/sync":   apiHandler.SyncContacts,
+		"/api/auth/pairing":    apiHandler.Pairing,
+	} {
+		mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+			if !webhookHandler.ValidateAuth(r) {
+				http.Error(w, `{"error":"Unauthorized"}`, http.StatusUnauthorized)
+				return
+			}
+
+			handle(w, r)
+		})
+	}
+
+	// Logging out unlinks the device, so it takes the admin key
+	mux.HandleFunc("/api/auth/logout", func(w http.ResponseWriter, r *http.Request) {
+		if !validateAdminAuth(r) {
+			http.Error(w, `{"error":"Unauthorized: requires the admin API key (MCP_ADMIN_API_KEY)"}`, http.StatusUnauthorized)
+			return
+		}
+
+		apiHandler.Logout(w, r)
+	})
+
+	// Pairing QR code for headless logins, in a browser (?api_key=) or as a PNG
+	mux.HandleFunc("/qr", func(w http.ResponseWriter, r *http.Request) {
+		if !webhookHandler.ValidateAuthOrKey(r) {
+			http.Error(w, `{"error":"Unauthorized"}`, http.StatusUnauthorized)
+			return
+		}
+
+		apiHandler.QRPage(w, r)
+	})
+
+	mux.HandleFunc("/qr.png", func(w http.ResponseWriter, r *http.Request) {
+		if !webhookHandler.ValidateAuthOrKey(r) {
+			http.Error(w, `{"error":"Unauthorized"}`, http.StatusUnauthorized)
+			return
+		}
+
+		apiHandler.QRImage(w, r)
+	})
+
+	// Database maintenance API (WAL checkpoint, vacuum, ANALYZE and size report)
+	mux.HandleFunc("/api/maintenance", func(w http.ResponseWriter, r *http.Request) {
+		// ?full=true blocks writes with a VACUUM, so it takes the admin key
+		if !validateAdminAuth(r) {
+			http.Error(w, `{"error":"Unauthorized: requires the admin API key (MCP_ADMIN_API_KEY)"}`, http.StatusUnauthorized)
+			return
+		}
+		if r.Method != http.MethodPost {
+			http.Error(w, `{"error":"Method not allowed"}`, http.StatusMethodNotAllowed)
+			return
+		}
+
+		report, err := storage.Maintain(r.Context(), db, r.URL.Query().Get("full") == "true", paths.DataMediaDir)
+		if err != nil {
+			http.Error(w, fmt.Sprintf(`{"error":%q}`, err.Error()), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(report)
+	})
+
 	httpServer := &http.Server{
 		Addr:    host + ":" + httpPort,
 		Handler: mux,
@@ -296,12 +480,19 @@ func main() {
 		log.Printf("HTTP server shutdown error: %v", err)
 	}
 
+	// disconnect WhatsApp first, the events it still writes are emitted
+	waClient.Disconnect()
+
 	// stop webhook manager
 	webhookManager.Stop()
 	log.Println("Webhook manager stopped")
 
-	// disconnect WhatsApp
-	waClient.Disconnect()
+	natsPublisher.Stop()
+	kafkaPublisher.Stop()
+	redisPublisher.Stop()
+	mqttPublisher.Stop()
+	backupManager.Stop()
+
 	log.Println("Shutdown complete")
 
 }
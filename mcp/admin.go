@@ -0,0 +1,52 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// adminScopeKey is the context key of requests authenticated with the admin
+// API key.
+type adminScopeKey struct{}
+
+// WithAdminScope marks a request context as authenticated with the admin API
+// key (MCP_ADMIN_API_KEY), which unlocks the admin tools.
+func WithAdminScope(ctx context.Context) context.Context {
+	return context.WithValue(ctx, adminScopeKey{}, true)
+}
+
+// hasAdminScope reports whether a request was made with the admin API key.
+func hasAdminScope(ctx context.Context) bool {
+	admin, _ := ctx.Value(adminScopeKey{}).(bool)
+	return admin
+}
+
+// addAdminTool registers a tool that is only listed and callable for requests
+// with the admin scope.
+func (m *MCPServer) addAdminTool(tool mcp.Tool, handler server.ToolHandlerFunc) {
+	m.adminTools[tool.Name] = true
+	m.addTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if !hasAdminScope(ctx) {
+			return mcp.NewToolResultError(fmt.Sprintf("%s requires the admin API key (MCP_ADMIN_API_KEY)", tool.Name)), nil
+		}
+		return handler(ctx, request)
+	})
+}
+
+// filterAdminTools hides the admin tools from requests without the admin scope.
+func (m *MCPServer) filterAdminTools(ctx context.Context, tools []mcp.Tool) []mcp.Tool {
+	if hasAdminScope(ctx) {
+		return tools
+	}
+
+	visible := make([]mcp.Tool, 0, len(tools))
+	for _, tool := range tools {
+		if !m.adminTools[tool.Name] {
+			visible = append(visible, tool)
+		}
+	}
+	return visible
+}
@@ -0,0 +1,121 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// activityPeriods maps the period parameter of get_activity_report to the
+// number of days it covers, today included.
+var activityPeriods = map[string]int{
+	"today": 1,
+	"week":  7,
+	"month": 30,
+	"year":  365,
+}
+
+// mediaTypes are the message types reported as media.
+var mediaTypes = []string{"image", "video", "audio", "document", "sticker"}
+
+// handleGetActivityReport handles the get_activity_report tool request.
+func (m *MCPServer) handleGetActivityReport(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	period := strings.ToLower(request.GetString("period", "week"))
+	days, ok := activityPeriods[period]
+	if !ok {
+		return mcp.NewToolResultError(fmt.Sprintf("invalid period %q: must be today, week, month or year", period)), nil
+	}
+
+	chatJID := m.canonicalJID(request.GetString("chat_jid", ""))
+
+	limit := request.GetFloat("limit", 10.0)
+	if limit > 50 {
+		limit = 50
+	}
+
+	now := time.Now().In(m.timezone)
+	since := time.Date(now.Year(), now.Month(), now.Day()-days+1, 0, 0, 0, 0, m.timezone)
+
+	report, err := m.store.GetActivityReport(ctx, since, now, chatJID, m.timezone, int(limit))
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to get activity report: %v", err)), nil
+	}
+
+	// format response
+	var result strings.Builder
+	fmt.Fprintf(&result, "# Activity report: %s (%s to %s)\n", period, since.Format("2006-01-02"), now.Format("2006-01-02"))
+	if chatJID != "" {
+		fmt.Fprintf(&result, "Chat: %s\n", chatJID)
+	}
+	if report.Total == 0 {
+		result.WriteString("\nNo messages in this period.\n")
+		return mcp.NewToolResultText(result.String()), nil
+	}
+
+	fmt.Fprintf(&result, "\n%d messages (%d sent by you, %d received)\n", report.Total, report.FromMe, report.Total-report.FromMe)
+
+	var media []string
+	for _, t := range mediaTypes {
+		if n := report.Types[t]; n > 0 {
+			media = append(media, fmt.Sprintf("%d %s", n, t))
+		}
+	}
+	if len(media) > 0 {
+		fmt.Fprintf(&result, "Media: %s\n", strings.Join(media, ", "))
+	}
+
+	if r := report.Response; r.Replies > 0 {
+		fmt.Fprintf(&result, "Your reply time in direct chats: median %s, average %s, longest %s (%d replies)\n",
+			formatReplyTime(r.Median), formatReplyTime(r.Average), formatReplyTime(r.Longest), r.Replies)
+	}
+
+	result.WriteString("\n## Messages per day\n")
+	for _, day := range report.Days {
+		fmt.Fprintf(&result, "- %s: %d (%d sent)\n", day.Day, day.Count, day.FromMe)
+	}
+
+	if chatJID == "" && len(report.Chats) > 0 {
+		result.WriteString("\n## Busiest chats\n")
+		for i, c := range report.Chats {
+			fmt.Fprintf(&result, "%d. %s: %d (%s)\n", i+1, c.Name, c.Count, c.JID)
+		}
+	}
+
+	if len(report.Senders) > 0 {
+		result.WriteString("\n## Top senders\n")
+		for i, s := range report.Senders {
+			fmt.Fprintf(&result, "%d. %s: %d (%s)\n", i+1, s.Name, s.Count, s.JID)
+		}
+	}
+
+	// remaining types, busiest first
+	types := make([]string, 0, len(report.Types))
+	for t := range report.Types {
+		types = append(types, t)
+	}
+	sort.Slice(types, func(i, j int) bool { return report.Types[types[i]] > report.Types[types[j]] })
+
+	result.WriteString("\n## Message types\n")
+	for _, t := range types {
+		fmt.Fprintf(&result, "- %s: %d\n", t, report.Types[t])
+	}
+
+	return mcp.NewToolResultText(result.String()), nil
+}
+
+// formatReplyTime formats a reply time rounded for reading, e.g. 45s, 12m or 3h20m.
+func formatReplyTime(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return d.Round(time.Second).String()
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Round(time.Minute)/time.Minute))
+	default:
+		d = d.Round(time.Minute)
+		return fmt.Sprintf("%dh%02dm", int(d/time.Hour), int(d%time.Hour/time.Minute))
+	}
+}
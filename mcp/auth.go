@@ -0,0 +1,108 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"whatsapp-mcp/whatsapp"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// handleAuthStatus handles the auth_status tool request. It works whatever the
+// connection state, to explain why other tools fail.
+func (m *MCPServer) handleAuthStatus(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	status := m.wa.AuthStatus()
+	conn := status.Connection
+
+	var result strings.Builder
+	if status.LoggedIn {
+		fmt.Fprintf(&result, "Logged in as %s (%s)", status.Phone, status.JID)
+		if status.PushName != "" {
+			fmt.Fprintf(&result, ", %s", status.PushName)
+		}
+		result.WriteString("\n")
+	} else {
+		result.WriteString("Not logged in\n")
+	}
+	fmt.Fprintf(&result, "Linked device: %s (%s)", status.DeviceName, status.Platform)
+	if status.PhonePlatform != "" {
+		fmt.Fprintf(&result, ", phone platform: %s", status.PhonePlatform)
+	}
+	result.WriteString("\n\n")
+
+	fmt.Fprintf(&result, "Connection: %s since %s\n", conn.State, m.formatDateTime(conn.Since))
+	if !conn.LastConnected.IsZero() {
+		fmt.Fprintf(&result, "Last connected: %s\n", m.formatDateTime(conn.LastConnected))
+	}
+	if conn.ReconnectAttempts > 0 {
+		fmt.Fprintf(&result, "Reconnect attempts: %d\n", conn.ReconnectAttempts)
+	}
+	if conn.Reconnects > 0 || conn.FailedReconnects > 0 {
+		fmt.Fprintf(&result, "Reconnects since startup: %d (%d failed attempts)\n", conn.Reconnects, conn.FailedReconnects)
+	}
+	if conn.LastError != "" {
+		fmt.Fprintf(&result, "Last error: %s\n", conn.LastError)
+	}
+	if err := m.wa.ConnectionError(); err != nil {
+		fmt.Fprintf(&result, "Tools needing WhatsApp fail with: %v\n", err)
+	}
+
+	if !status.LoggedIn {
+		result.WriteString("\n")
+		writePairingStatus(&result, status.Pairing)
+	}
+
+	history := status.HistorySync
+	result.WriteString("\nHistory sync: ")
+	if history.Batches == 0 {
+		result.WriteString("no batches received since startup")
+	} else {
+		fmt.Fprintf(&result, "%d%% of the initial history, %d batches (%d chats, %d messages) since startup, last %s at %s",
+			history.Progress, history.Batches, history.Chats, history.Messages, history.LastType, m.formatDateTime(history.LastBatchAt))
+	}
+	if history.OnDemandCount > 0 {
+		fmt.Fprintf(&result, ", %d on-demand requests pending", history.OnDemandCount)
+	}
+	result.WriteString("\n")
+
+	return mcp.NewToolResultText(result.String()), nil
+}
+
+// writePairingStatus writes whether a QR code or pairing code is waiting to
+// be used.
+func writePairingStatus(result *strings.Builder, pairing whatsapp.PairingStatus) {
+	switch {
+	case pairing.PairingCode != "":
+		fmt.Fprintf(result, "Pairing: enter code %s on the phone of %s (Linked devices > Link with phone number instead)\n", pairing.PairingCode, pairing.Phone)
+	case pairing.QRCode != "":
+		result.WriteString("Pairing: a QR code is waiting to be scanned at /qr?api_key=<MCP_API_KEY> or in the server logs\n")
+	case pairing.Active:
+		result.WriteString("Pairing: connecting, waiting for the first QR code\n")
+	default:
+		result.WriteString("Pairing: not running; start it from /qr, with POST /api/auth/pairing or the logout admin tool\n")
+		if pairing.Error != "" {
+			fmt.Fprintf(result, "Last pairing error: %s\n", pairing.Error)
+		}
+	}
+}
+
+// handleLogout handles the logout tool request.
+func (m *MCPServer) handleLogout(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	phone := request.GetString("phone", "")
+
+	if err := m.wa.Logout(ctx, phone); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	var result strings.Builder
+	result.WriteString("Logged out from WhatsApp, pairing a new device.\n")
+	if phone != "" {
+		fmt.Fprintf(&result, "A pairing code for %s will be available at GET /api/auth/pairing and in the server logs shortly. ", phone)
+		result.WriteString("Enter it on the phone under Linked devices > Link a device > Link with phone number instead.")
+	} else {
+		result.WriteString("Scan the QR code shown at /qr?api_key=<MCP_API_KEY> in a browser (or in the server logs) under Linked devices > Link a device.")
+	}
+	return mcp.NewToolResultText(result.String()), nil
+}
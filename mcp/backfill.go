@@ -0,0 +1,142 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"whatsapp-mcp/storage"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// maxBackfillCount caps the count of a backfill_chat request.
+const maxBackfillCount = 10000
+
+// backfillPollInterval is how often backfill_chat checks the progress of a
+// backfill it waits for.
+const backfillPollInterval = 2 * time.Second
+
+// handleBackfillChat handles the backfill_chat tool request.
+func (m *MCPServer) handleBackfillChat(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	chatJID, err := request.RequireString("chat_jid")
+	if err != nil {
+		return mcp.NewToolResultError("chat_jid parameter is required"), nil
+	}
+
+	if request.GetBool("cancel", false) {
+		backfill, err := m.wa.CancelBackfill(chatJID)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to cancel backfill: %v", err)), nil
+		}
+		return mcp.NewToolResultText("Backfill cancelled, the messages fetched so far are kept.\n\n" + m.formatBackfill(backfill)), nil
+	}
+
+	var until time.Time
+	if untilStr := request.GetString("until", ""); untilStr != "" {
+		until, err = m.parseTimestamp(untilStr)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+	}
+	count := min(int(request.GetFloat("count", 0)), maxBackfillCount)
+	if until.IsZero() && count <= 0 {
+		return mcp.NewToolResultError("until or count is required"), nil
+	}
+
+	// check WhatsApp connection
+	if err := m.wa.ConnectionError(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	backfill, err := m.wa.StartBackfill(chatJID, until, count)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to start backfill: %v", err)), nil
+	}
+
+	if backfill.Status == storage.BackfillDone {
+		return mcp.NewToolResultText("Nothing to backfill, the chat already goes back that far.\n\n" + m.formatBackfill(backfill)), nil
+	}
+	if !request.GetBool("wait", false) {
+		return mcp.NewToolResultText("Backfill started, follow it with list_backfills.\n\n" + m.formatBackfill(backfill)), nil
+	}
+
+	notify := m.progressNotifier(ctx, request)
+	ticker := time.NewTicker(backfillPollInterval)
+	defer ticker.Stop()
+
+	for backfill.Status == storage.BackfillRunning {
+		select {
+		case <-ctx.Done():
+			// the backfill goes on without the caller
+			return mcp.NewToolResultError(fmt.Sprintf("stopped waiting, the backfill goes on: %v", ctx.Err())), nil
+		case <-ticker.C:
+		}
+
+		current, err := m.wa.GetBackfill(chatJID)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to get backfill: %v", err)), nil
+		}
+		if current == nil {
+			return mcp.NewToolResultError("backfill was removed"), nil
+		}
+		backfill = *current
+
+		notify(float64(backfill.Fetched), float64(backfill.TargetCount), m.backfillProgress(backfill))
+
+		if err := m.wa.ConnectionError(); err != nil && backfill.Status == storage.BackfillRunning {
+			return mcp.NewToolResultText(fmt.Sprintf("Backfill paused until WhatsApp reconnects (%v).\n\n%s", err, m.formatBackfill(backfill))), nil
+		}
+	}
+
+	return mcp.NewToolResultText(m.formatBackfill(backfill)), nil
+}
+
+// handleListBackfills handles the list_backfills tool request.
+func (m *MCPServer) handleListBackfills(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	backfills, err := m.store.ListBackfills(request.GetString("status", ""))
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to list backfills: %v", err)), nil
+	}
+
+	var result strings.Builder
+	fmt.Fprintf(&result, "Found %d backfills:\n\n", len(backfills))
+	for i, backfill := range backfills {
+		fmt.Fprintf(&result, "%d. %s\n\n", i+1, strings.ReplaceAll(m.formatBackfill(backfill), "\n", "\n   "))
+	}
+
+	return mcp.NewToolResultText(result.String()), nil
+}
+
+// formatBackfill describes a backfill, one field per line.
+func (m *MCPServer) formatBackfill(backfill storage.Backfill) string {
+	var result strings.Builder
+	fmt.Fprintf(&result, "Chat: %s\n", backfill.ChatJID)
+	fmt.Fprintf(&result, "Status: %s\n", backfill.Status)
+
+	var targets []string
+	if backfill.TargetDate != nil {
+		targets = append(targets, "until "+m.formatDateTime(*backfill.TargetDate))
+	}
+	if backfill.TargetCount > 0 {
+		targets = append(targets, fmt.Sprintf("%d messages", backfill.TargetCount))
+	}
+	fmt.Fprintf(&result, "Target: %s\n", strings.Join(targets, " or "))
+
+	fmt.Fprintf(&result, "Progress: %s\n", m.backfillProgress(backfill))
+	if backfill.LastError != "" {
+		fmt.Fprintf(&result, "Last error: %s\n", backfill.LastError)
+	}
+	fmt.Fprintf(&result, "Started: %s, updated: %s", m.formatDateTime(backfill.StartedAt), m.formatDateTime(backfill.UpdatedAt))
+	return result.String()
+}
+
+// backfillProgress summarizes how far a backfill got.
+func (m *MCPServer) backfillProgress(backfill storage.Backfill) string {
+	progress := fmt.Sprintf("%d messages in %d pages", backfill.Fetched, backfill.Pages)
+	if backfill.OldestTimestamp != nil {
+		progress += ", back to " + m.formatDateTime(*backfill.OldestTimestamp)
+	}
+	return progress
+}
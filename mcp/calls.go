@@ -0,0 +1,61 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"whatsapp-mcp/storage"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// handleListCalls handles the list_calls tool request.
+func (m *MCPServer) handleListCalls(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	chatJID := m.canonicalJID(request.GetString("chat_jid", ""))
+
+	status := ""
+	if request.GetBool("missed_only", false) {
+		status = storage.CallMissed
+	}
+
+	limit := request.GetFloat("limit", 50.0)
+	if limit > 200 {
+		limit = 200
+	}
+
+	calls, err := m.store.ListCalls(chatJID, status, int(limit))
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to list calls: %v", err)), nil
+	}
+
+	var result strings.Builder
+	fmt.Fprintf(&result, "Found %d calls:\n\n", len(calls))
+
+	for i, call := range calls {
+		kind := "📞 Voice call"
+		if call.IsVideo {
+			kind = "📹 Video call"
+		}
+
+		fmt.Fprintf(&result, "%d. [%s] %s from %s", i+1, m.formatDateTime(call.OfferedAt), kind, m.callerName(call.CallerJID))
+		if call.IsGroup {
+			fmt.Fprintf(&result, " in %s", m.callerName(call.ChatJID))
+		}
+		fmt.Fprintf(&result, " - %s\n", call.Status)
+		if call.EndedAt != nil && call.Status == storage.CallAnswered {
+			fmt.Fprintf(&result, "   Duration: %s\n", call.EndedAt.Sub(call.OfferedAt))
+		}
+		fmt.Fprintf(&result, "   Call ID: %s\n\n", call.ID)
+	}
+
+	return mcp.NewToolResultText(result.String()), nil
+}
+
+// callerName names a caller or group by its stored chat, falling back to the JID.
+func (m *MCPServer) callerName(jid string) string {
+	if chat, err := m.store.GetChatByJID(jid); err == nil && chat != nil {
+		return getDisplayName(*chat)
+	}
+	return jid
+}
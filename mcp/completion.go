@@ -0,0 +1,165 @@
+package mcp
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// maxCompletionValues is the maximum number of values per completion response (MCP limit).
+const maxCompletionValues = 100
+
+// completionMethod is the JSON-RPC method of argument completion requests.
+const completionMethod = "completion/complete"
+
+// jsonrpcMessage is the part of a JSON-RPC request needed to route it.
+type jsonrpcMessage struct {
+	ID     json.RawMessage    `json:"id,omitempty"`
+	Method string             `json:"method"`
+	Params mcp.CompleteParams `json:"params"`
+}
+
+// completionRef is the prompt or resource template a completion request refers to.
+type completionRef struct {
+	Type string `json:"type"` // ref/prompt or ref/resource
+	Name string `json:"name"`
+	URI  string `json:"uri"`
+}
+
+// CompletionHandler wraps the MCP HTTP handler with argument completion support,
+// which the MCP server library doesn't implement yet. It answers completion/complete
+// requests and advertises the completions capability in the initialize result.
+func (m *MCPServer) CompletionHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, "failed to read request body", http.StatusBadRequest)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		// batches and invalid JSON are left to the MCP server
+		var msg jsonrpcMessage
+		if err := json.Unmarshal(body, &msg); err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		switch msg.Method {
+		case completionMethod:
+			m.handleCompletion(w, msg)
+		case string(mcp.MethodInitialize):
+			advertiseCompletions(w, r, next)
+		default:
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+// handleCompletion writes the JSON-RPC response of a completion request.
+func (m *MCPServer) handleCompletion(w http.ResponseWriter, msg jsonrpcMessage) {
+	var ref completionRef
+	if data, err := json.Marshal(msg.Params.Ref); err == nil {
+		json.Unmarshal(data, &ref)
+	}
+
+	values, err := m.completeArgument(ref, msg.Params.Argument.Name, msg.Params.Argument.Value)
+
+	var response any
+	if err != nil {
+		response = map[string]any{
+			"jsonrpc": mcp.JSONRPC_VERSION,
+			"id":      msg.ID,
+			"error":   mcp.NewJSONRPCErrorDetails(mcp.INTERNAL_ERROR, err.Error(), nil),
+		}
+	} else {
+		var result mcp.CompleteResult
+		result.Completion.Values = values
+		if result.Completion.Values == nil {
+			result.Completion.Values = []string{}
+		}
+		result.Completion.HasMore = len(values) == maxCompletionValues
+
+		response = map[string]any{
+			"jsonrpc": mcp.JSONRPC_VERSION,
+			"id":      msg.ID,
+			"result":  result,
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// completeArgument returns suggestions for a prompt or resource template argument.
+// JID arguments complete from chats, name arguments from contacts and push names.
+func (m *MCPServer) completeArgument(ref completionRef, argument, value string) ([]string, error) {
+	switch argument {
+	case "chat_jid", "jid", "group_jid":
+		var isGroup *bool
+		switch {
+		case argument == "group_jid" || strings.HasPrefix(ref.URI, "whatsapp://group/"):
+			isGroup = boolPtr(true)
+		case strings.HasPrefix(ref.URI, "whatsapp://contact/"):
+			isGroup = boolPtr(false)
+		}
+		return m.store.CompleteChatJIDs(value, isGroup, maxCompletionValues)
+	case "contact_name", "chat_name", "name":
+		return m.store.CompleteNames(value, maxCompletionValues)
+	}
+	return nil, nil
+}
+
+// boolPtr returns a pointer to b.
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+// bufferedResponseWriter captures a response so it can be modified before sending.
+type bufferedResponseWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (b *bufferedResponseWriter) Header() http.Header         { return b.header }
+func (b *bufferedResponseWriter) Write(p []byte) (int, error) { return b.body.Write(p) }
+func (b *bufferedResponseWriter) WriteHeader(status int)      { b.status = status }
+
+// advertiseCompletions serves an initialize request and adds the completions
+// capability to its result.
+func advertiseCompletions(w http.ResponseWriter, r *http.Request, next http.Handler) {
+	buf := &bufferedResponseWriter{header: make(http.Header), status: http.StatusOK}
+	next.ServeHTTP(buf, r)
+
+	body := buf.body.Bytes()
+
+	// only plain JSON responses are patched, anything else is passed through
+	var response map[string]any
+	if strings.HasPrefix(buf.header.Get("Content-Type"), "application/json") && json.Unmarshal(body, &response) == nil {
+		if result, ok := response["result"].(map[string]any); ok {
+			if capabilities, ok := result["capabilities"].(map[string]any); ok {
+				capabilities["completions"] = map[string]any{}
+				if patched, err := json.Marshal(response); err == nil {
+					body = patched
+				}
+			}
+		}
+	}
+
+	for key, values := range buf.header {
+		w.Header()[key] = values
+	}
+	w.Header().Del("Content-Length")
+	w.WriteHeader(buf.status)
+	w.Write(body)
+}
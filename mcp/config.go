@@ -0,0 +1,39 @@
+package mcp
+
+import (
+	"strings"
+	"whatsapp-mcp/config"
+)
+
+// ToolsConfig holds which tools are exposed to clients.
+type ToolsConfig struct {
+	Enabled  map[string]bool // if not empty, only these tools are registered
+	Disabled map[string]bool // never registered, even if listed in Enabled
+}
+
+// Allowed reports whether the tool with the given name should be registered.
+func (c ToolsConfig) Allowed(name string) bool {
+	if c.Disabled[name] {
+		return false
+	}
+	return len(c.Enabled) == 0 || c.Enabled[name]
+}
+
+// LoadToolsConfig loads the tool allow/deny lists from environment variables.
+func LoadToolsConfig() ToolsConfig {
+	return ToolsConfig{
+		Enabled:  parseToolList(config.GetEnv("MCP_TOOLS_ENABLED", "")),
+		Disabled: parseToolList(config.GetEnv("MCP_TOOLS_DISABLED", "")),
+	}
+}
+
+// parseToolList parses a comma-separated list of tool names.
+func parseToolList(list string) map[string]bool {
+	tools := make(map[string]bool)
+	for _, name := range strings.Split(list, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			tools[name] = true
+		}
+	}
+	return tools
+}
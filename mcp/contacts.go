@@ -0,0 +1,23 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// handleSyncContacts handles the sync_contacts tool request.
+func (m *MCPServer) handleSyncContacts(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// check WhatsApp connection
+	if err := m.wa.ConnectionError(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	result, err := m.wa.SyncContacts(ctx, true)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to sync contacts: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(fmt.Sprintf("Synced %d contacts, %d chats got a contact name", result.Contacts, result.ChatsNamed)), nil
+}
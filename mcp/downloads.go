@@ -0,0 +1,30 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// handleGetDownloadQueue handles the get_download_queue tool request.
+func (m *MCPServer) handleGetDownloadQueue(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	status := m.wa.DownloadQueueStatus()
+
+	var result strings.Builder
+	fmt.Fprintf(&result, "Media downloads (%d workers):\n\n", status.Workers)
+	fmt.Fprintf(&result, "Queued: %d\n", status.Queued)
+	fmt.Fprintf(&result, "Downloading: %d\n", status.Downloading)
+	fmt.Fprintf(&result, "\nSince startup: %d downloaded, %d failed, %d expired, %d skipped\n",
+		status.Downloaded, status.Failed, status.Expired, status.Skipped)
+
+	if len(status.RecentFailures) > 0 {
+		result.WriteString("\nRecent failures:\n")
+		for _, failure := range status.RecentFailures {
+			fmt.Fprintf(&result, "- [%s] %s (%s): %s\n", m.formatDateTime(failure.Time), failure.MessageID, failure.Status, failure.Error)
+		}
+	}
+
+	return mcp.NewToolResultText(result.String()), nil
+}
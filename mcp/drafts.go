@@ -0,0 +1,108 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// sendDraftTTL is how long a dry-run draft can be confirmed.
+const sendDraftTTL = 10 * time.Minute
+
+// sendDraft is an outgoing message waiting for confirm_send.
+type sendDraft struct {
+	ChatJID        string
+	Text           string
+	Mentions       []string
+	IdempotencyKey string
+	ExpiresAt      time.Time
+}
+
+// sendDraftOutput is the structured result of a dry-run send_message.
+type sendDraftOutput struct {
+	Token     string   `json:"confirmation_token"`
+	ChatJID   string   `json:"chat_jid"`
+	ChatName  string   `json:"chat_name,omitempty"`
+	Text      string   `json:"text"`
+	Mentions  []string `json:"mentions,omitempty"`
+	ExpiresAt string   `json:"expires_at"` // RFC 3339 in the configured timezone
+}
+
+// createSendDraft stores a draft message and returns a preview with its confirmation token.
+func (m *MCPServer) createSendDraft(chatJID, text string, mentions []string, idempotencyKey string) *mcp.CallToolResult {
+	draft := sendDraft{
+		ChatJID:        chatJID,
+		Text:           text,
+		Mentions:       mentions,
+		IdempotencyKey: idempotencyKey,
+		ExpiresAt:      time.Now().Add(sendDraftTTL),
+	}
+	token := uuid.New().String()
+
+	m.draftsMu.Lock()
+	// drop expired drafts while we hold the lock
+	for t, d := range m.drafts {
+		if time.Now().After(d.ExpiresAt) {
+			delete(m.drafts, t)
+		}
+	}
+	m.drafts[token] = draft
+	m.draftsMu.Unlock()
+
+	var chatName string
+	if chat, err := m.store.GetChatByJID(chatJID); err == nil && chat != nil {
+		chatName = getDisplayName(*chat)
+	}
+
+	var result strings.Builder
+	result.WriteString("Draft created (NOT sent yet)\n\n")
+	if chatName != "" {
+		fmt.Fprintf(&result, "To: %s (%s)\n", chatName, chatJID)
+	} else {
+		fmt.Fprintf(&result, "To: %s\n", chatJID)
+	}
+	fmt.Fprintf(&result, "Text: %s\n", text)
+	if len(mentions) > 0 {
+		fmt.Fprintf(&result, "Mentions: %s\n", strings.Join(mentions, ", "))
+	}
+	fmt.Fprintf(&result, "\nConfirmation token: %s\n", token)
+	fmt.Fprintf(&result, "Expires: %s\n", m.formatDateTime(draft.ExpiresAt))
+	result.WriteString("Call confirm_send with this token to send the message.")
+
+	return mcp.NewToolResultStructured(sendDraftOutput{
+		Token:     token,
+		ChatJID:   chatJID,
+		ChatName:  chatName,
+		Text:      text,
+		Mentions:  mentions,
+		ExpiresAt: m.formatRFC3339(draft.ExpiresAt),
+	}, result.String())
+}
+
+// handleConfirmSend handles the confirm_send tool request.
+func (m *MCPServer) handleConfirmSend(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// get required token
+	token, err := request.RequireString("confirmation_token")
+	if err != nil {
+		return mcp.NewToolResultError("confirmation_token parameter is required"), nil
+	}
+
+	// drafts are single use
+	m.draftsMu.Lock()
+	draft, ok := m.drafts[token]
+	delete(m.drafts, token)
+	m.draftsMu.Unlock()
+
+	if !ok {
+		return mcp.NewToolResultError("unknown or already used confirmation token"), nil
+	}
+	if time.Now().After(draft.ExpiresAt) {
+		return mcp.NewToolResultError("confirmation token expired, create a new draft with send_message dry_run=true"), nil
+	}
+
+	return m.sendText(ctx, draft.ChatJID, draft.Text, draft.Mentions, draft.IdempotencyKey), nil
+}
@@ -0,0 +1,341 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"whatsapp-mcp/paths"
+	"whatsapp-mcp/storage"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// exportFormats maps supported export formats to their file extension and MIME type.
+var exportFormats = map[string]struct {
+	ext      string
+	mimeType string
+}{
+	"json": {".json", "application/json"},
+	"txt":  {".txt", "text/plain"},
+	"html": {".html", "text/html"},
+}
+
+// exportFileNameUnsafe matches characters that are not allowed in export file names.
+var exportFileNameUnsafe = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// chatExporter writes a chat export in a specific format, one message at a time.
+type chatExporter interface {
+	begin(chat *storage.Chat) error
+	message(msg storage.MessageWithNames) error
+	end() error
+}
+
+// handleExportChat handles the export_chat tool request.
+func (m *MCPServer) handleExportChat(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// get required chat_jid
+	chatJID, err := request.RequireString("chat_jid")
+	if err != nil {
+		return mcp.NewToolResultError("chat_jid parameter is required"), nil
+	}
+	chatJID = m.canonicalJID(chatJID)
+
+	format := strings.ToLower(request.GetString("format", "txt"))
+	spec, ok := exportFormats[format]
+	if !ok {
+		return mcp.NewToolResultError(fmt.Sprintf("invalid format %q: must be json, txt or html", format)), nil
+	}
+
+	chat, err := m.store.GetChatByJID(chatJID)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to get chat: %v", err)), nil
+	}
+	if chat == nil {
+		return mcp.NewToolResultError(fmt.Sprintf("chat not found: %s", chatJID)), nil
+	}
+
+	if err := os.MkdirAll(paths.DataExportsDir, 0755); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to create exports directory: %v", err)), nil
+	}
+
+	fileName := fmt.Sprintf("%s_%s%s",
+		exportFileNameUnsafe.ReplaceAllString(chatJID, "_"),
+		time.Now().In(m.timezone).Format("20060102-150405"),
+		spec.ext)
+	filePath := paths.GetExportPath(fileName)
+
+	count, err := m.writeChatExport(ctx, filePath, format, chat)
+	if err != nil {
+		os.Remove(filePath)
+		return mcp.NewToolResultError(fmt.Sprintf("failed to export chat: %v", err)), nil
+	}
+
+	uri := "whatsapp://export/" + fileName
+
+	var result strings.Builder
+	fmt.Fprintf(&result, "Exported %d messages from %s (%s)\n\n", count, getDisplayName(*chat), chatJID)
+	fmt.Fprintf(&result, "Format: %s\n", format)
+	fmt.Fprintf(&result, "File: %s\n", filePath)
+	fmt.Fprintf(&result, "Resource: %s\n", uri)
+
+	return mcp.NewToolResultText(result.String()), nil
+}
+
+// writeChatExport streams all messages of a chat to filePath in the given format.
+// A cancelled ctx stops the export.
+func (m *MCPServer) writeChatExport(ctx context.Context, filePath, format string, chat *storage.Chat) (int, error) {
+	f, err := os.Create(filePath)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+
+	var exporter chatExporter
+	switch format {
+	case "json":
+		exporter = &jsonChatExporter{m: m, w: w}
+	case "html":
+		exporter = &htmlChatExporter{m: m, w: w}
+	default:
+		exporter = &textChatExporter{m: m, w: w}
+	}
+
+	if err := exporter.begin(chat); err != nil {
+		return 0, err
+	}
+
+	count := 0
+	err = m.store.ForEachChatMessageWithNames(ctx, chat.JID, func(msg storage.MessageWithNames) error {
+		count++
+		return exporter.message(msg)
+	})
+	if err != nil {
+		return count, err
+	}
+
+	if err := exporter.end(); err != nil {
+		return count, err
+	}
+	if err := w.Flush(); err != nil {
+		return count, err
+	}
+
+	return count, f.Close()
+}
+
+// exportSenderName returns the sender name shown in exports.
+func exportSenderName(msg storage.MessageWithNames) string {
+	if msg.IsFromMe {
+		return "You"
+	}
+	return getSenderDisplayName(msg)
+}
+
+// exportMediaPath returns the path of a downloaded media file relative to the exports directory.
+func exportMediaPath(meta *storage.MediaMetadata) string {
+	if meta == nil || meta.DownloadStatus != "downloaded" || meta.FilePath == "" {
+		return ""
+	}
+	return filepath.ToSlash(filepath.Join("..", filepath.Base(paths.DataMediaDir), meta.FilePath))
+}
+
+// jsonChatExporter writes a chat as a single JSON document.
+type jsonChatExporter struct {
+	m     *MCPServer
+	w     io.Writer
+	first bool
+}
+
+func (e *jsonChatExporter) begin(chat *storage.Chat) error {
+	header, err := json.Marshal(struct {
+		Chat       chatOutput `json:"chat"`
+		ExportedAt string     `json:"exported_at"`
+	}{
+		Chat:       e.m.toChatOutput(*chat),
+		ExportedAt: e.m.formatRFC3339(time.Now()),
+	})
+	if err != nil {
+		return err
+	}
+
+	// reopen the header object so messages can be streamed into it
+	e.first = true
+	_, err = fmt.Fprintf(e.w, "%s,\"messages\":[", header[:len(header)-1])
+	return err
+}
+
+func (e *jsonChatExporter) message(msg storage.MessageWithNames) error {
+	data, err := json.Marshal(e.m.toMessageOutput(msg))
+	if err != nil {
+		return err
+	}
+
+	if !e.first {
+		if _, err := io.WriteString(e.w, ","); err != nil {
+			return err
+		}
+	}
+	e.first = false
+
+	_, err = fmt.Fprintf(e.w, "\n%s", data)
+	return err
+}
+
+func (e *jsonChatExporter) end() error {
+	_, err := io.WriteString(e.w, "\n]}\n")
+	return err
+}
+
+// textChatExporter writes a chat in WhatsApp's own "Export chat" text format.
+type textChatExporter struct {
+	m *MCPServer
+	w io.Writer
+}
+
+func (e *textChatExporter) begin(chat *storage.Chat) error {
+	return nil
+}
+
+func (e *textChatExporter) message(msg storage.MessageWithNames) error {
+	text := msg.Text
+	if meta := msg.MediaMetadata; meta != nil {
+		attachment := "<Media omitted>"
+		if meta.DownloadStatus == "downloaded" {
+			attachment = meta.FileName + " (file attached)"
+		}
+		if text != "" {
+			text = attachment + "\n" + text
+		} else {
+			text = attachment
+		}
+	}
+
+	_, err := fmt.Fprintf(e.w, "%s - %s: %s\n",
+		e.m.toLocalTime(msg.Timestamp).Format("02/01/2006, 15:04"),
+		exportSenderName(msg),
+		text)
+	return err
+}
+
+func (e *textChatExporter) end() error {
+	return nil
+}
+
+// htmlChatExporter writes a chat as a standalone HTML page with media references.
+type htmlChatExporter struct {
+	m *MCPServer
+	w io.Writer
+}
+
+func (e *htmlChatExporter) begin(chat *storage.Chat) error {
+	title := html.EscapeString(getDisplayName(*chat))
+	_, err := fmt.Fprintf(e.w, `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>%s</title>
+<style>
+body { font-family: sans-serif; max-width: 800px; margin: 0 auto; padding: 1em; background: #efeae2; }
+.msg { background: #fff; border-radius: 8px; padding: 6px 10px; margin: 6px 0; max-width: 75%%; }
+.me { background: #d9fdd3; margin-left: auto; }
+.meta { color: #667781; font-size: 0.8em; }
+.text { white-space: pre-wrap; }
+img, video { max-width: 100%%; }
+</style>
+</head>
+<body>
+<h1>%s</h1>
+<p class="meta">%s &middot; exported %s</p>
+`, title, title, html.EscapeString(chat.JID), html.EscapeString(e.m.formatDateTime(time.Now())))
+	return err
+}
+
+func (e *htmlChatExporter) message(msg storage.MessageWithNames) error {
+	class := "msg"
+	if msg.IsFromMe {
+		class = "msg me"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "<div class=\"%s\" id=\"%s\">\n", class, html.EscapeString(msg.ID))
+	fmt.Fprintf(&b, "<div class=\"meta\">%s &middot; %s</div>\n",
+		html.EscapeString(exportSenderName(msg)),
+		html.EscapeString(e.m.formatDateTime(msg.Timestamp)))
+
+	if meta := msg.MediaMetadata; meta != nil {
+		src := html.EscapeString(exportMediaPath(meta))
+		name := html.EscapeString(meta.FileName)
+		switch {
+		case src == "":
+			fmt.Fprintf(&b, "<div class=\"meta\">&#128206; %s (not downloaded)</div>\n", name)
+		case strings.HasPrefix(meta.MimeType, "image/"):
+			fmt.Fprintf(&b, "<img src=\"%s\" alt=\"%s\">\n", src, name)
+		case strings.HasPrefix(meta.MimeType, "video/"):
+			fmt.Fprintf(&b, "<video controls src=\"%s\"></video>\n", src)
+		case strings.HasPrefix(meta.MimeType, "audio/"):
+			fmt.Fprintf(&b, "<audio controls src=\"%s\"></audio>\n", src)
+		default:
+			fmt.Fprintf(&b, "<a href=\"%s\">&#128206; %s</a>\n", src, name)
+		}
+	}
+
+	if msg.Text != "" {
+		fmt.Fprintf(&b, "<div class=\"text\">%s</div>\n", html.EscapeString(msg.Text))
+	}
+	b.WriteString("</div>\n")
+
+	_, err := io.WriteString(e.w, b.String())
+	return err
+}
+
+func (e *htmlChatExporter) end() error {
+	_, err := io.WriteString(e.w, "</body>\n</html>\n")
+	return err
+}
+
+// handleExportResource handles exported chat file resource requests.
+func (m *MCPServer) handleExportResource(ctx context.Context, req mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	var fileName string
+	if req.Params.Arguments != nil {
+		if fileNames, ok := req.Params.Arguments["file_name"].([]string); ok && len(fileNames) > 0 {
+			fileName = fileNames[0]
+		}
+	}
+
+	// export file names never contain path separators
+	if fileName == "" || fileName != filepath.Base(fileName) || strings.HasPrefix(fileName, ".") {
+		return nil, errors.New("invalid export file name")
+	}
+
+	spec, ok := exportFormats[strings.TrimPrefix(filepath.Ext(fileName), ".")]
+	if !ok {
+		return nil, errors.New("invalid export file name")
+	}
+
+	data, err := os.ReadFile(paths.GetExportPath(fileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("export not found: %s", fileName)
+		}
+		return nil, fmt.Errorf("failed to read export: %w", err)
+	}
+
+	return []mcp.ResourceContents{
+		mcp.TextResourceContents{
+			URI:      req.Params.URI,
+			MIMEType: spec.mimeType,
+			Text:     string(data),
+		},
+	}, nil
+}
@@ -3,6 +3,8 @@ package mcp
 import (
 	"context"
 	"fmt"
+	"regexp"
+	"slices"
 	"strings"
 	"time"
 
@@ -35,6 +37,20 @@ func getSenderDisplayName(msg storage.MessageWithNames) string {
 	return msg.SenderJID
 }
 
+// canonicalJID maps a JID given by a client to the JID its rows are stored
+// under, so a contact's LID finds the messages stored under their phone number.
+func (m *MCPServer) canonicalJID(jid string) string {
+	if jid == "" {
+		return jid
+	}
+	canonical, err := m.store.CanonicalJID(jid)
+	if err != nil {
+		m.log.Printf("Failed to resolve alias of %s: %v", jid, err)
+		return jid
+	}
+	return canonical
+}
+
 // toLocalTime converts a UTC timestamp to the configured timezone.
 func (m *MCPServer) toLocalTime(t time.Time) time.Time {
 	return t.In(m.timezone)
@@ -53,9 +69,16 @@ func (m *MCPServer) formatTime(t time.Time) string {
 // parseTimestamp converts an ISO 8601 timestamp string to time.Time in the server's timezone.
 // It supports the formats: "2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02".
 func (m *MCPServer) parseTimestamp(timestampStr string) (time.Time, error) {
+	// an explicit offset or Z wins over the configured timezone
+	if t, err := time.Parse(time.RFC3339Nano, timestampStr); err == nil {
+		return t, nil
+	}
+
 	formats := []string{
 		"2006-01-02T15:04:05",
 		"2006-01-02 15:04:05",
+		"2006-01-02T15:04",
+		"2006-01-02 15:04",
 		"2006-01-02",
 	}
 
@@ -65,7 +88,7 @@ func (m *MCPServer) parseTimestamp(timestampStr string) (time.Time, error) {
 		}
 	}
 
-	return time.Time{}, fmt.Errorf("invalid timestamp format: %s (expected ISO 8601 like '2006-01-02T15:04:05' or '2006-01-02')", timestampStr)
+	return time.Time{}, fmt.Errorf("invalid timestamp format: %s (expected ISO 8601 like '2006-01-02T15:04:05', '2006-01-02T15:04:05-03:00' or '2006-01-02')", timestampStr)
 }
 
 // detectPatternType determines whether a search query should use GLOB matching.
@@ -109,6 +132,61 @@ func formatDuration(seconds *int) string {
 	return fmt.Sprintf("%d:%02d", s/60, s%60)
 }
 
+// statusSuffix returns " (edited)" for edited messages and " (deleted)" for
+// messages deleted for everyone.
+func statusSuffix(msg storage.MessageWithNames) string {
+	var suffix string
+	if msg.EditedAt != nil {
+		suffix += " (edited)"
+	}
+	if msg.DeletedAt != nil {
+		suffix += " (deleted)"
+	}
+	return suffix
+}
+
+// formatReactions returns a reaction summary like "👍 2, ❤️ 1".
+func formatReactions(reactions []storage.ReactionCount) string {
+	parts := make([]string, 0, len(reactions))
+	for _, r := range reactions {
+		parts = append(parts, fmt.Sprintf("%s %d", r.Emoji, r.Count))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// disappearingTimers maps the supported disappearing messages options to their duration.
+var disappearingTimers = map[string]time.Duration{
+	"off": 0,
+	"24h": 24 * time.Hour,
+	"7d":  7 * 24 * time.Hour,
+	"90d": 90 * 24 * time.Hour,
+}
+
+// formatDisappearingTimer formats a disappearing messages timer in seconds as off/24h/7d/90d.
+func formatDisappearingTimer(seconds int) string {
+	for name, d := range disappearingTimers {
+		if int(d/time.Second) == seconds {
+			return name
+		}
+	}
+	return (time.Duration(seconds) * time.Second).String()
+}
+
+// lastSenderName names the sender of a chat's newest message: you, the other
+// party of a direct chat, or a group member's push name.
+func (m *MCPServer) lastSenderName(chat storage.Chat) string {
+	switch {
+	case slices.Contains(m.wa.OwnJIDs(), chat.LastSenderJID):
+		return "You"
+	case chat.LastSenderJID == chat.JID:
+		return getDisplayName(chat)
+	}
+	if name, err := m.store.GetPushName(chat.LastSenderJID); err == nil && name != "" {
+		return name
+	}
+	return chat.LastSenderJID
+}
+
 // handleListChats handles the list_chats tool request.
 func (m *MCPServer) handleListChats(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	// get limit parameter with default
@@ -117,15 +195,47 @@ func (m *MCPServer) handleListChats(ctx context.Context, request mcp.CallToolReq
 		limit = 100
 	}
 
+	cursor, err := cursorParam(request)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("invalid cursor: %v", err)), nil
+	}
+
 	// query database
-	chats, err := m.store.ListChats(int(limit))
+	tag := request.GetString("tag", "")
+	filter := storage.ChatFilter{
+		Tag:      tag,
+		Archived: optionalBool(request, "archived"),
+		Pinned:   optionalBool(request, "pinned"),
+		Muted:    optionalBool(request, "muted"),
+	}
+
+	chats, err := m.store.ListChats(int(limit), cursor, filter)
 	if err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf("failed to list chats: %v", err)), nil
 	}
 
+	jids := make([]string, 0, len(chats))
+	for _, chat := range chats {
+		jids = append(jids, chat.JID)
+	}
+	tags, err := m.store.GetChatTags(jids)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to get chat tags: %v", err)), nil
+	}
+
+	var nextPage string
+	if len(chats) > 0 && len(chats) == int(limit) {
+		last := chats[len(chats)-1]
+		nextPage = encodeCursor(last.LastMessageTime, last.JID, false)
+	}
+
 	// format response
 	var result strings.Builder
-	fmt.Fprintf(&result, "Found %d chats:\n\n", len(chats))
+	if tag != "" {
+		fmt.Fprintf(&result, "Found %d chats tagged '%s':\n\n", len(chats), tag)
+	} else {
+		fmt.Fprintf(&result, "Found %d chats:\n\n", len(chats))
+	}
 
 	for i, chat := range chats {
 		chatType := "DM"
@@ -140,14 +250,65 @@ func (m *MCPServer) handleListChats(ctx context.Context, request mcp.CallToolReq
 		if chat.ContactName != "" && chat.PushName != "" && chat.ContactName != chat.PushName {
 			fmt.Fprintf(&result, "   (Contact: %s, Push: %s)\n", chat.ContactName, chat.PushName)
 		}
+		if len(tags[chat.JID]) > 0 {
+			fmt.Fprintf(&result, "   Tags: %s\n", strings.Join(tags[chat.JID], ", "))
+		}
+		if chat.MessageCount > 0 {
+			fmt.Fprintf(&result, "   Messages: %d (last from %s)\n", chat.MessageCount, m.lastSenderName(chat))
+		}
 		fmt.Fprintf(&result, "   Last message: %s\n", m.formatDateTime(chat.LastMessageTime))
-		if chat.UnreadCount > 0 {
+		switch {
+		case chat.UnreadCount > 0 && chat.MarkedUnread:
+			fmt.Fprintf(&result, "   Unread: %d (marked unread)\n", chat.UnreadCount)
+		case chat.UnreadCount > 0:
 			fmt.Fprintf(&result, "   Unread: %d\n", chat.UnreadCount)
+		case chat.MarkedUnread:
+			result.WriteString("   Unread: marked unread\n")
+		}
+		if chat.DisappearingTimer > 0 {
+			fmt.Fprintf(&result, "   Disappearing messages: %s\n", formatDisappearingTimer(chat.DisappearingTimer))
+		}
+		if state := m.chatStateSummary(chat); state != "" {
+			fmt.Fprintf(&result, "   State: %s\n", state)
 		}
 		result.WriteString("\n")
 	}
+	writeNextPage(&result, nextPage)
 
-	return mcp.NewToolResultText(result.String()), nil
+	out := m.toChatListOutput(chats)
+	for i := range out.Chats {
+		out.Chats[i].Tags = tags[out.Chats[i].JID]
+	}
+	out.NextPage = nextPage
+	return mcp.NewToolResultStructured(out, result.String()), nil
+}
+
+// optionalBool reads an optional boolean tool parameter, nil when missing.
+func optionalBool(request mcp.CallToolRequest, name string) *bool {
+	value, ok := request.GetArguments()[name].(bool)
+	if !ok {
+		return nil
+	}
+	return &value
+}
+
+// chatStateSummary describes whether a chat is pinned, muted or archived on
+// the phone, or returns "" if it is none of them.
+func (m *MCPServer) chatStateSummary(chat storage.Chat) string {
+	var state []string
+	if !chat.PinnedAt.IsZero() {
+		state = append(state, "pinned")
+	}
+	switch {
+	case chat.Muted && chat.MutedUntil.IsZero():
+		state = append(state, "muted")
+	case chat.Muted:
+		state = append(state, "muted until "+m.formatDateTime(chat.MutedUntil))
+	}
+	if chat.Archived {
+		state = append(state, "archived")
+	}
+	return strings.Join(state, ", ")
 }
 
 // handleGetChatMessages handles the get_chat_messages tool request.
@@ -157,6 +318,7 @@ func (m *MCPServer) handleGetChatMessages(ctx context.Context, request mcp.CallT
 	if err != nil {
 		return mcp.NewToolResultError("chat_jid parameter is required"), nil
 	}
+	chatJID = m.canonicalJID(chatJID)
 
 	// get optional limit
 	limit := request.GetFloat("limit", 50.0)
@@ -187,30 +349,50 @@ func (m *MCPServer) handleGetChatMessages(ctx context.Context, request mcp.CallT
 	}
 
 	// get optional sender filter
-	senderJID := request.GetString("from", "")
+	senderJID := m.canonicalJID(request.GetString("from", ""))
 
-	// query database
-	var messages []storage.MessageWithNames
-
-	if beforeTime != nil || afterTime != nil || senderJID != "" {
-		// use new filtered method
-		messages, err = m.store.GetChatMessagesWithNamesFiltered(
-			chatJID,
-			int(limit),
-			beforeTime,
-			afterTime,
-			senderJID,
-		)
-	} else {
-		// backward compatibility: use offset if no timestamp filters
-		offset := request.GetFloat("offset", 0.0)
-		messages, err = m.store.GetChatMessagesWithNames(chatJID, int(limit), int(offset))
+	beforeCursor, afterCursor, err := messagePageParam(request)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("invalid cursor: %v", err)), nil
 	}
 
+	// deprecated: offsets are O(n) and shift as new messages arrive
+	offset := int(request.GetFloat("offset", 0.0))
+
+	includeDeleted := request.GetBool("include_deleted", false)
+
+	// query database
+	messages, err := m.store.GetChatMessagesWithNamesFiltered(
+		ctx,
+		chatJID,
+		int(limit),
+		beforeTime,
+		afterTime,
+		senderJID,
+		beforeCursor,
+		afterCursor,
+		offset,
+		includeDeleted,
+	)
 	if err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf("failed to get messages: %v", err)), nil
 	}
 
+	// get prior versions of edited messages if requested
+	var edits map[string][]storage.MessageEdit
+	if request.GetBool("include_edit_history", false) {
+		var editedIDs []string
+		for _, msg := range messages {
+			if msg.EditedAt != nil {
+				editedIDs = append(editedIDs, msg.ID)
+			}
+		}
+		edits, err = m.store.GetMessageEdits(editedIDs)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to get edit history: %v", err)), nil
+		}
+	}
+
 	// format response
 	var result strings.Builder
 	fmt.Fprintf(&result, "Retrieved %d messages from chat %s", len(messages), chatJID)
@@ -236,11 +418,20 @@ func (m *MCPServer) handleGetChatMessages(ctx context.Context, request mcp.CallT
 			sender = "You"
 		}
 
-		fmt.Fprintf(&result, "[%s] %s %s: %s\n",
+		fmt.Fprintf(&result, "[%s] %s %s: %s%s\n",
 			m.formatTime(msg.Timestamp),
 			direction,
 			sender,
-			msg.Text)
+			msg.Text,
+			statusSuffix(msg))
+
+		for _, edit := range edits[msg.ID] {
+			fmt.Fprintf(&result, "   ✏️ Before edit at %s: %s\n", m.formatDateTime(edit.ReplacedAt), edit.Text)
+		}
+
+		if msg.ReplyToID != "" {
+			fmt.Fprintf(&result, "   ↪ Reply to message %s (see get_thread)\n", msg.ReplyToID)
+		}
 
 		// show media metadata if present
 		if msg.MediaMetadata != nil {
@@ -272,9 +463,42 @@ func (m *MCPServer) handleGetChatMessages(ctx context.Context, request mcp.CallT
 			}
 			result.WriteString("\n")
 		}
+
+		if msg.Transcript != "" {
+			fmt.Fprintf(&result, "   🗣 Transcript: %s\n", msg.Transcript)
+		}
+
+		if len(msg.Reactions) > 0 {
+			fmt.Fprintf(&result, "   Reactions: %s\n", formatReactions(msg.Reactions))
+		}
+		if msg.Views > 0 {
+			fmt.Fprintf(&result, "   Views: %d\n", msg.Views)
+		}
 	}
 
-	return mcp.NewToolResultText(result.String()), nil
+	// the next page holds older messages, the previous page newer ones
+	nextPage, prevPage := messagePages(messages, int(limit), beforeCursor, afterCursor)
+	writeNextPage(&result, nextPage)
+	writePrevPage(&result, prevPage)
+
+	// structured output lists messages oldest first, matching the text output
+	ordered := make([]storage.MessageWithNames, 0, len(messages))
+	for i := len(messages) - 1; i >= 0; i-- {
+		ordered = append(ordered, messages[i])
+	}
+
+	out := m.toMessageListOutput(chatJID, ordered)
+	for i := range out.Messages {
+		for _, edit := range edits[out.Messages[i].ID] {
+			out.Messages[i].Edits = append(out.Messages[i].Edits, messageEditOutput{
+				Text:       edit.Text,
+				ReplacedAt: m.formatRFC3339(edit.ReplacedAt),
+			})
+		}
+	}
+	out.NextPage = nextPage
+	out.PrevPage = prevPage
+	return mcp.NewToolResultStructured(out, result.String()), nil
 }
 
 // handleSearchMessages handles the search_messages tool request.
@@ -289,28 +513,125 @@ func (m *MCPServer) handleSearchMessages(ctx context.Context, request mcp.CallTo
 	}
 
 	// get optional sender filter
-	senderJID := request.GetString("from", "")
+	senderJID := m.canonicalJID(request.GetString("from", ""))
+
+	// get optional timestamp filters
+	var beforeTime *time.Time
+	var afterTime *time.Time
 
-	// validate: must have either query or from
-	if query == "" && senderJID == "" {
-		return mcp.NewToolResultError("must provide either 'query' (text to search) or 'from' (sender JID) or both"), nil
+	beforeStr := request.GetString("before_timestamp", "")
+	if beforeStr != "" {
+		t, err := m.parseTimestamp(beforeStr)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("invalid before_timestamp: %v", err)), nil
+		}
+		beforeTime = &t
+	}
+
+	afterStr := request.GetString("after_timestamp", "")
+	if afterStr != "" {
+		t, err := m.parseTimestamp(afterStr)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("invalid after_timestamp: %v", err)), nil
+		}
+		afterTime = &t
+	}
+
+	// get optional chat scope
+	chatJIDs := request.GetStringSlice("chat_jids", nil)
+	for i, jid := range chatJIDs {
+		chatJIDs[i] = m.canonicalJID(jid)
+	}
+
+	// get optional message type filter ("link" is accepted as an alias for "url")
+	messageType := request.GetString("message_type", "")
+	if messageType == "link" {
+		messageType = "url"
+	}
+
+	// get optional mention filter ("me" means the logged-in user)
+	mentions := request.GetString("mentions", "")
+	var mentioned []string
+	if mentions == "me" {
+		mentioned = m.wa.OwnJIDs()
+		if len(mentioned) == 0 {
+			return mcp.NewToolResultError("WhatsApp is not connected"), nil
+		}
+	} else if mentions != "" {
+		mentioned = []string{m.canonicalJID(mentions)}
+	}
+
+	// get optional tag filter
+	tag := request.GetString("tag", "")
+
+	// validate: must have at least one filter
+	if query == "" && senderJID == "" && beforeTime == nil && afterTime == nil && messageType == "" && len(chatJIDs) == 0 && len(mentioned) == 0 && tag == "" {
+		return mcp.NewToolResultError("must provide at least one of 'query' (text to search), 'from' (sender JID), 'chat_jids', 'after_timestamp', 'before_timestamp', 'message_type', 'mentions' or 'tag'"), nil
+	}
+
+	beforeCursor, afterCursor, err := messagePageParam(request)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("invalid cursor: %v", err)), nil
 	}
 
 	// detect pattern type
 	useGlob := detectPatternType(query)
 
-	// search database
-	messages, err := m.store.SearchMessagesWithNamesFiltered(query, useGlob, senderJID, int(limit))
+	// search database, or the messages moved to the archive
+	archived := request.GetBool("archive", false)
+	filter := storage.SearchFilter{
+		Query:          query,
+		UseGlob:        useGlob,
+		SenderJID:      senderJID,
+		ChatJIDs:       chatJIDs,
+		After:          afterTime,
+		Before:         beforeTime,
+		MessageType:    messageType,
+		Mentioned:      mentioned,
+		Tag:            tag,
+		BeforeCursor:   beforeCursor,
+		AfterCursor:    afterCursor,
+		IncludeDeleted: request.GetBool("include_deleted", false),
+		Limit:          int(limit),
+	}
+	var messages []storage.MessageWithNames
+	if archived {
+		messages, err = m.store.SearchArchive(ctx, filter)
+	} else {
+		messages, err = m.store.SearchMessagesWithNamesFiltered(ctx, filter)
+	}
 	if err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf("search failed: %v", err)), nil
 	}
 
 	// format response
 	var result strings.Builder
-	fmt.Fprintf(&result, "Found %d messages matching '%s'", len(messages), query)
+	if archived {
+		fmt.Fprintf(&result, "Found %d archived messages matching '%s'", len(messages), query)
+	} else {
+		fmt.Fprintf(&result, "Found %d messages matching '%s'", len(messages), query)
+	}
 	if senderJID != "" {
 		fmt.Fprintf(&result, " from sender %s", senderJID)
 	}
+	if len(chatJIDs) > 0 {
+		fmt.Fprintf(&result, " in %d chats", len(chatJIDs))
+	}
+	if messageType != "" {
+		fmt.Fprintf(&result, " (type: %s)", messageType)
+	}
+	if mentions != "" {
+		fmt.Fprintf(&result, " (mentioning: %s)", mentions)
+	}
+	if tag != "" {
+		fmt.Fprintf(&result, " (tag: %s)", tag)
+	}
+	if afterTime != nil {
+		fmt.Fprintf(&result, " (after: %s)", m.formatDateTime(*afterTime))
+	}
+	if beforeTime != nil {
+		fmt.Fprintf(&result, " (before: %s)", m.formatDateTime(*beforeTime))
+	}
 	if useGlob {
 		result.WriteString(" (using pattern matching)")
 	}
@@ -328,7 +649,7 @@ func (m *MCPServer) handleSearchMessages(ctx context.Context, request mcp.CallTo
 			m.formatDateTime(msg.Timestamp),
 			sender,
 			msg.ChatJID)
-		fmt.Fprintf(&result, "   %s\n", msg.Text)
+		fmt.Fprintf(&result, "   %s%s\n", msg.Text, statusSuffix(msg))
 
 		// show media metadata if present
 		if msg.MediaMetadata != nil {
@@ -361,10 +682,35 @@ func (m *MCPServer) handleSearchMessages(ctx context.Context, request mcp.CallTo
 			result.WriteString("\n")
 		}
 
+		if msg.Transcript != "" {
+			fmt.Fprintf(&result, "   🗣 Transcript: %s\n", msg.Transcript)
+		}
+
+		if len(msg.Reactions) > 0 {
+			fmt.Fprintf(&result, "   Reactions: %s\n", formatReactions(msg.Reactions))
+		}
+		if msg.Views > 0 {
+			fmt.Fprintf(&result, "   Views: %d\n", msg.Views)
+		}
+
 		result.WriteString("\n")
 	}
 
-	return mcp.NewToolResultText(result.String()), nil
+	nextPage, prevPage := messagePages(messages, int(limit), beforeCursor, afterCursor)
+	writeNextPage(&result, nextPage)
+	writePrevPage(&result, prevPage)
+
+	// point at archived matches, which this search doesn't include
+	if !archived {
+		if count, err := m.store.CountArchivedMatches(ctx, filter); err == nil && count > 0 {
+			fmt.Fprintf(&result, "\n%d more matching messages are archived: repeat the search with archive=true to see them.\n", count)
+		}
+	}
+
+	out := m.toMessageListOutput("", messages)
+	out.NextPage = nextPage
+	out.PrevPage = prevPage
+	return mcp.NewToolResultStructured(out, result.String()), nil
 }
 
 // handleFindChat handles the find_chat tool request.
@@ -404,10 +750,13 @@ func (m *MCPServer) handleFindChat(ctx context.Context, request mcp.CallToolRequ
 		if chat.ContactName != "" && chat.PushName != "" && chat.ContactName != chat.PushName {
 			fmt.Fprintf(&result, "   (Contact: %s, Push: %s)\n", chat.ContactName, chat.PushName)
 		}
+		if chat.MessageCount > 0 {
+			fmt.Fprintf(&result, "   Messages: %d (last from %s)\n", chat.MessageCount, m.lastSenderName(chat))
+		}
 		result.WriteString("\n")
 	}
 
-	return mcp.NewToolResultText(result.String()), nil
+	return mcp.NewToolResultStructured(m.toChatListOutput(chats), result.String()), nil
 }
 
 // handleSendMessage handles the send_message tool request.
@@ -423,18 +772,68 @@ func (m *MCPServer) handleSendMessage(ctx context.Context, request mcp.CallToolR
 		return mcp.NewToolResultError("text parameter is required"), nil
 	}
 
+	// get optional mentions
+	mentions := request.GetStringSlice("mentions", nil)
+
+	// get optional idempotency key (client_message_id is accepted as an alias)
+	idempotencyKey := request.GetString("idempotency_key", request.GetString("client_message_id", ""))
+
+	// two-phase send: store a draft and return a confirmation token instead of sending
+	if request.GetBool("dry_run", false) {
+		return m.createSendDraft(chatJID, text, mentions, idempotencyKey), nil
+	}
+
+	return m.sendText(ctx, chatJID, text, mentions, idempotencyKey), nil
+}
+
+// sendText sends a text message, honoring an optional idempotency key.
+func (m *MCPServer) sendText(ctx context.Context, chatJID, text string, mentions []string, idempotencyKey string) *mcp.CallToolResult {
 	// check WhatsApp connection
-	if !m.wa.IsLoggedIn() {
-		return mcp.NewToolResultError("WhatsApp is not connected"), nil
+	if err := m.wa.ConnectionError(); err != nil {
+		return mcp.NewToolResultError(err.Error())
+	}
+
+	// replay the original result when the same key is retried
+	if idempotencyKey != "" {
+		existing, err := m.store.ReserveIdempotencyKey(idempotencyKey, chatJID)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to check idempotency key: %v", err))
+		}
+		if existing != nil {
+			switch {
+			case existing.ChatJID != chatJID:
+				return mcp.NewToolResultError(fmt.Sprintf("idempotency_key %q was already used for chat %s", idempotencyKey, existing.ChatJID))
+			case existing.MessageID == "":
+				return mcp.NewToolResultError(fmt.Sprintf("a send with idempotency_key %q is still in progress", idempotencyKey))
+			default:
+				return mcp.NewToolResultText(existing.Result)
+			}
+		}
 	}
 
 	// send message
-	err = m.wa.SendTextMessage(ctx, chatJID, text)
+	sent, err := m.wa.SendTextMessage(ctx, chatJID, text, mentions)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to send message: %v", err)), nil
+		if idempotencyKey != "" {
+			if err := m.store.ReleaseIdempotencyKey(idempotencyKey); err != nil {
+				m.log.Printf("Failed to release idempotency key %s: %v", idempotencyKey, err)
+			}
+		}
+		return mcp.NewToolResultError(fmt.Sprintf("failed to send message: %v", err))
+	}
+
+	result := fmt.Sprintf("Message sent successfully to %s (ID: %s)", chatJID, sent.ID)
+	if len(mentions) > 0 {
+		result += fmt.Sprintf("\nMentioned %d users\nText: %s", len(mentions), sent.Text)
+	}
+
+	if idempotencyKey != "" {
+		if err := m.store.CompleteIdempotencyKey(idempotencyKey, sent.ID, result); err != nil {
+			m.log.Printf("Failed to store idempotency key %s: %v", idempotencyKey, err)
+		}
 	}
 
-	return mcp.NewToolResultText(fmt.Sprintf("Message sent successfully to %s", chatJID)), nil
+	return mcp.NewToolResultText(result)
 }
 
 // handleLoadMoreMessages handles the load_more_messages tool request.
@@ -458,12 +857,22 @@ func (m *MCPServer) handleLoadMoreMessages(ctx context.Context, request mcp.Call
 	waitForSync := request.GetBool("wait_for_sync", true)
 
 	// check WhatsApp connection
-	if !m.wa.IsLoggedIn() {
-		return mcp.NewToolResultError("WhatsApp is not connected"), nil
+	if err := m.wa.ConnectionError(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	// report synced messages while waiting
+	var onProgress func(received int)
+	if waitForSync {
+		notify := m.progressNotifier(ctx, request)
+		onProgress = func(received int) {
+			notify(float64(received), float64(count),
+				fmt.Sprintf("Received %d of %d messages (%d%%)", received, count, received*100/count))
+		}
 	}
 
 	// request history sync
-	messages, err := m.wa.RequestHistorySync(ctx, chatJID, count, waitForSync)
+	messages, err := m.wa.RequestHistorySync(ctx, chatJID, count, waitForSync, onProgress)
 	if err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf("failed to load messages: %v", err)), nil
 	}
@@ -531,8 +940,8 @@ func (m *MCPServer) handleLoadMoreMessages(ctx context.Context, request mcp.Call
 // handleGetMyInfo handles the get_my_info tool request.
 func (m *MCPServer) handleGetMyInfo(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	// check WhatsApp connection
-	if !m.wa.IsLoggedIn() {
-		return mcp.NewToolResultError("WhatsApp is not connected"), nil
+	if err := m.wa.ConnectionError(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
 	}
 
 	// get user info
@@ -570,3 +979,394 @@ func (m *MCPServer) handleGetMyInfo(ctx context.Context, request mcp.CallToolReq
 
 	return mcp.NewToolResultText(result.String()), nil
 }
+
+// handleDisappearingMessages handles the disappearing_messages tool request.
+func (m *MCPServer) handleDisappearingMessages(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// get required chat_jid
+	chatJID, err := request.RequireString("chat_jid")
+	if err != nil {
+		return mcp.NewToolResultError("chat_jid parameter is required"), nil
+	}
+
+	// check WhatsApp connection
+	if err := m.wa.ConnectionError(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	// set the timer when requested, otherwise just query it
+	setting := request.GetString("set", "")
+	if setting != "" {
+		timer, ok := disappearingTimers[setting]
+		if !ok {
+			return mcp.NewToolResultError(fmt.Sprintf("invalid timer %q: must be off, 24h, 7d or 90d", setting)), nil
+		}
+
+		if err := m.wa.SetDisappearingTimer(ctx, chatJID, timer); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to set disappearing timer: %v", err)), nil
+		}
+
+		return mcp.NewToolResultText(fmt.Sprintf("Disappearing messages for %s set to %s", chatJID, setting)), nil
+	}
+
+	timer, err := m.wa.GetDisappearingTimer(ctx, chatJID)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to get disappearing timer: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(fmt.Sprintf("Disappearing messages for %s: %s",
+		chatJID, formatDisappearingTimer(int(timer/time.Second)))), nil
+}
+
+// handleSubscribePresence handles the subscribe_presence tool request.
+func (m *MCPServer) handleSubscribePresence(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// get required jid
+	jid, err := request.RequireString("jid")
+	if err != nil {
+		return mcp.NewToolResultError("jid parameter is required"), nil
+	}
+
+	// check WhatsApp connection
+	if err := m.wa.ConnectionError(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	if err := m.wa.SubscribePresence(ctx, jid); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to subscribe to presence: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(fmt.Sprintf("Subscribed to presence updates for %s. Use get_presence to read the latest status.", jid)), nil
+}
+
+// handleSetPresence handles the set_presence tool request.
+func (m *MCPServer) handleSetPresence(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	state, err := request.RequireString("state")
+	if err != nil {
+		return mcp.NewToolResultError("state parameter is required"), nil
+	}
+	if state != "available" && state != "unavailable" {
+		return mcp.NewToolResultError("state must be available or unavailable"), nil
+	}
+
+	// check WhatsApp connection
+	if err := m.wa.ConnectionError(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	if err := m.wa.SetPresence(ctx, state == "available"); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to set presence: %v", err)), nil
+	}
+
+	if state == "available" {
+		return mcp.NewToolResultText("You now appear online to your contacts."), nil
+	}
+	return mcp.NewToolResultText("You now appear offline to your contacts."), nil
+}
+
+// handleMarkChatRead handles the mark_chat_read tool request.
+func (m *MCPServer) handleMarkChatRead(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	chatJID, err := request.RequireString("chat_jid")
+	if err != nil {
+		return mcp.NewToolResultError("chat_jid parameter is required"), nil
+	}
+	chatJID = m.canonicalJID(chatJID)
+
+	// check WhatsApp connection
+	if err := m.wa.ConnectionError(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	count, err := m.wa.MarkChatRead(ctx, chatJID)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to mark chat as read: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(fmt.Sprintf("Marked %s as read (%d unread messages)", chatJID, count)), nil
+}
+
+// handleGetPresence handles the get_presence tool request.
+func (m *MCPServer) handleGetPresence(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// get required jid
+	jid, err := request.RequireString("jid")
+	if err != nil {
+		return mcp.NewToolResultError("jid parameter is required"), nil
+	}
+
+	presence, err := m.wa.GetPresence(jid)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to get presence: %v", err)), nil
+	}
+
+	if presence == nil {
+		return mcp.NewToolResultText(fmt.Sprintf("No presence known for %s. Call subscribe_presence first.", jid)), nil
+	}
+
+	if presence.UpdatedAt == nil {
+		return mcp.NewToolResultText(fmt.Sprintf("Subscribed to %s at %s but no presence update received yet.",
+			jid, m.formatDateTime(*presence.SubscribedAt))), nil
+	}
+
+	var result strings.Builder
+	fmt.Fprintf(&result, "Presence for %s:\n\n", jid)
+	if presence.IsOnline {
+		result.WriteString("Status: online\n")
+	} else {
+		result.WriteString("Status: offline\n")
+	}
+	if presence.LastSeen != nil {
+		fmt.Fprintf(&result, "Last seen: %s\n", m.formatDateTime(*presence.LastSeen))
+	} else if !presence.IsOnline {
+		result.WriteString("Last seen: hidden\n")
+	}
+	fmt.Fprintf(&result, "Updated: %s\n", m.formatDateTime(*presence.UpdatedAt))
+
+	return mcp.NewToolResultText(result.String()), nil
+}
+
+// receiptRank orders receipt statuses by progress.
+var receiptRank = map[string]int{
+	"sent":                   0,
+	storage.ReceiptDelivered: 1,
+	storage.ReceiptRead:      2,
+	storage.ReceiptPlayed:    3,
+}
+
+// handleGetMessageStatus handles the get_message_status tool request.
+func (m *MCPServer) handleGetMessageStatus(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// get required message_id
+	messageID, err := request.RequireString("message_id")
+	if err != nil {
+		return mcp.NewToolResultError("message_id parameter is required"), nil
+	}
+
+	msg, err := m.store.GetMessageByID(messageID)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to get message: %v", err)), nil
+	}
+	if msg == nil {
+		return mcp.NewToolResultError(fmt.Sprintf("message not found: %s", messageID)), nil
+	}
+	if !msg.IsFromMe {
+		return mcp.NewToolResultError("delivery status is only tracked for messages you sent"), nil
+	}
+
+	receipts, err := m.store.GetReceipts(messageID)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to get receipts: %v", err)), nil
+	}
+
+	// keep the most advanced receipt per participant
+	latest := make(map[string]storage.Receipt)
+	var participants []string
+	for _, r := range receipts {
+		prev, seen := latest[r.ParticipantJID]
+		if !seen {
+			participants = append(participants, r.ParticipantJID)
+		}
+		if !seen || receiptRank[r.Status] > receiptRank[prev.Status] {
+			latest[r.ParticipantJID] = r
+		}
+	}
+
+	status := "sent"
+	for _, r := range latest {
+		if receiptRank[r.Status] > receiptRank[status] {
+			status = r.Status
+		}
+	}
+
+	var result strings.Builder
+	fmt.Fprintf(&result, "Message %s in %s\n", messageID, msg.ChatJID)
+	fmt.Fprintf(&result, "Sent: %s\n", m.formatDateTime(msg.Timestamp))
+	fmt.Fprintf(&result, "Status: %s\n", status)
+
+	if strings.HasSuffix(msg.ChatJID, "@g.us") && len(participants) > 0 {
+		counts := make(map[string]int)
+		for _, r := range latest {
+			counts[r.Status]++
+		}
+		fmt.Fprintf(&result, "Delivered: %d, Read: %d, Played: %d\n",
+			counts[storage.ReceiptDelivered], counts[storage.ReceiptRead], counts[storage.ReceiptPlayed])
+
+		result.WriteString("\nParticipants:\n")
+		for _, jid := range participants {
+			r := latest[jid]
+			fmt.Fprintf(&result, "- %s: %s at %s\n", jid, r.Status, m.formatDateTime(r.Timestamp))
+		}
+	} else if len(participants) > 0 {
+		r := latest[participants[0]]
+		fmt.Fprintf(&result, "Updated: %s\n", m.formatDateTime(r.Timestamp))
+	}
+
+	return mcp.NewToolResultText(result.String()), nil
+}
+
+// urlPattern matches http(s) and www. links in message text.
+var urlPattern = regexp.MustCompile(`(?i)\b(?:https?://|www\.)[^\s<>"]+`)
+
+// extractURLs returns the distinct URLs found in text, without trailing punctuation.
+func extractURLs(text string) []string {
+	var urls []string
+	seen := make(map[string]bool)
+	for _, u := range urlPattern.FindAllString(text, -1) {
+		u = strings.TrimRight(u, ".,;:!?)]}'")
+		if u != "" && !seen[u] {
+			seen[u] = true
+			urls = append(urls, u)
+		}
+	}
+	return urls
+}
+
+// handleGetLinks handles the get_links tool request.
+func (m *MCPServer) handleGetLinks(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	chatJID := m.canonicalJID(request.GetString("chat_jid", ""))
+
+	limit := request.GetFloat("limit", 50.0)
+	if limit > 200 {
+		limit = 200
+	}
+
+	messages, err := m.store.GetLinkMessages(chatJID, int(limit))
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to get links: %v", err)), nil
+	}
+
+	ids := make([]string, 0, len(messages))
+	for _, msg := range messages {
+		ids = append(ids, msg.ID)
+	}
+	previews, err := m.store.GetLinkPreviews(ids)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to get link previews: %v", err)), nil
+	}
+
+	// format response
+	var result strings.Builder
+	if chatJID != "" {
+		fmt.Fprintf(&result, "Found %d messages with links in %s:\n\n", len(messages), chatJID)
+	} else {
+		fmt.Fprintf(&result, "Found %d messages with links across all chats:\n\n", len(messages))
+	}
+
+	for i, msg := range messages {
+		sender := getSenderDisplayName(msg)
+		if msg.IsFromMe {
+			sender = "You"
+		}
+
+		preview, hasPreview := previews[msg.ID]
+		urls := extractURLs(msg.Text)
+		if hasPreview && len(urls) == 0 {
+			urls = []string{preview.URL}
+		}
+
+		fmt.Fprintf(&result, "%d. [%s] %s in %s\n", i+1, m.formatDateTime(msg.Timestamp), sender, msg.ChatName)
+		for _, u := range urls {
+			fmt.Fprintf(&result, "   🔗 %s\n", u)
+		}
+		if hasPreview && preview.Title != "" {
+			fmt.Fprintf(&result, "   Title: %s\n", preview.Title)
+		}
+		if hasPreview && preview.Description != "" {
+			fmt.Fprintf(&result, "   Description: %s\n", preview.Description)
+		}
+		fmt.Fprintf(&result, "   Message ID: %s\n\n", msg.ID)
+	}
+
+	return mcp.NewToolResultText(result.String()), nil
+}
+
+// handleTranscribeMessage handles the transcribe_message tool request.
+func (m *MCPServer) handleTranscribeMessage(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// get required message_id
+	messageID, err := request.RequireString("message_id")
+	if err != nil {
+		return mcp.NewToolResultError("message_id parameter is required"), nil
+	}
+
+	text, err := m.wa.TranscribeMessage(ctx, messageID)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to transcribe message: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(fmt.Sprintf("Transcript of %s:\n\n%s", messageID, text)), nil
+}
+
+// handleGetThread handles the get_thread tool request.
+func (m *MCPServer) handleGetThread(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	messageID, err := request.RequireString("message_id")
+	if err != nil {
+		return mcp.NewToolResultError("message_id parameter is required"), nil
+	}
+
+	limit := request.GetFloat("limit", 50.0)
+	if limit > 200 {
+		limit = 200
+	}
+
+	messages, err := m.store.GetThread(ctx, messageID, int(limit))
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to get thread: %v", err)), nil
+	}
+	if len(messages) == 0 {
+		return mcp.NewToolResultError(fmt.Sprintf("message %s not found", messageID)), nil
+	}
+
+	// format response
+	var result strings.Builder
+	fmt.Fprintf(&result, "Thread of message %s in %s (%d messages):\n\n", messageID, messages[0].ChatName, len(messages))
+
+	for _, msg := range messages {
+		sender := getSenderDisplayName(msg)
+		if msg.IsFromMe {
+			sender = "You"
+		}
+
+		marker := ""
+		if msg.ID == messageID {
+			marker = " 👈"
+		}
+
+		fmt.Fprintf(&result, "[%s] %s: %s%s%s\n", m.formatDateTime(msg.Timestamp), sender, msg.Text, statusSuffix(msg), marker)
+		fmt.Fprintf(&result, "   Message ID: %s", msg.ID)
+		if msg.ReplyToID != "" {
+			fmt.Fprintf(&result, " · replying to %s", msg.ReplyToID)
+		}
+		result.WriteString("\n")
+	}
+
+	return mcp.NewToolResultStructured(m.toMessageListOutput(messages[0].ChatJID, messages), result.String()), nil
+}
+
+// handleGetGroupParticipants handles the get_group_participants tool request.
+func (m *MCPServer) handleGetGroupParticipants(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	groupJID, err := request.RequireString("group_jid")
+	if err != nil {
+		return mcp.NewToolResultError("group_jid parameter is required"), nil
+	}
+	if !strings.HasSuffix(groupJID, "@g.us") {
+		return mcp.NewToolResultError(fmt.Sprintf("%s is not a group JID", groupJID)), nil
+	}
+
+	out, err := m.groupParticipantList(ctx, groupJID)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	// format response
+	var result strings.Builder
+	fmt.Fprintf(&result, "%s has %d participants:\n\n", out.GroupName, out.Count)
+	if out.Description != "" {
+		fmt.Fprintf(&result, "Description: %s\n\n", out.Description)
+	}
+
+	for i, p := range out.Participants {
+		fmt.Fprintf(&result, "%d. %s", i+1, p.Name)
+		if p.IsAdmin {
+			result.WriteString(" (admin)")
+		}
+		fmt.Fprintf(&result, "\n   JID: %s\n", p.JID)
+	}
+
+	return mcp.NewToolResultStructured(out, result.String()), nil
+}
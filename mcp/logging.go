@@ -0,0 +1,41 @@
+package mcp
+
+import (
+	"context"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// onSessionRegistered remembers a new session so it receives log notifications.
+func (m *MCPServer) onSessionRegistered(ctx context.Context, session server.ClientSession) {
+	m.sessionsMu.Lock()
+	m.sessions[session.SessionID()] = struct{}{}
+	m.sessionsMu.Unlock()
+}
+
+// onSessionUnregistered forgets a closed session.
+func (m *MCPServer) onSessionUnregistered(ctx context.Context, session server.ClientSession) {
+	m.sessionsMu.Lock()
+	delete(m.sessions, session.SessionID())
+	m.sessionsMu.Unlock()
+}
+
+// LogToClients sends a log notification to every connected session whose
+// log level (set with logging/setLevel, default error) includes level.
+func (m *MCPServer) LogToClients(level, logger, message string) {
+	m.sessionsMu.Lock()
+	sessionIDs := make([]string, 0, len(m.sessions))
+	for sid := range m.sessions {
+		sessionIDs = append(sessionIDs, sid)
+	}
+	m.sessionsMu.Unlock()
+
+	notification := mcp.NewLoggingMessageNotification(mcp.LoggingLevel(level), logger, message)
+	for _, sid := range sessionIDs {
+		// best effort: the event is in the server log either way
+		if err := m.server.SendLogMessageToSpecificClient(sid, notification); err != nil {
+			m.log.Printf("Failed to send log notification to session %s: %v", sid, err)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// handleAddContactNote handles the add_contact_note tool request.
+func (m *MCPServer) handleAddContactNote(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	jid, err := request.RequireString("jid")
+	if err != nil {
+		return mcp.NewToolResultError("jid parameter is required"), nil
+	}
+	jid = m.canonicalJID(jid)
+
+	text, err := request.RequireString("note")
+	if err != nil {
+		return mcp.NewToolResultError("note parameter is required"), nil
+	}
+
+	note, err := m.store.AddContactNote(jid, text)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to add note: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(fmt.Sprintf("Note %d added to %s", note.ID, jid)), nil
+}
+
+// handleGetContactNotes handles the get_contact_notes tool request.
+func (m *MCPServer) handleGetContactNotes(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	jid, err := request.RequireString("jid")
+	if err != nil {
+		return mcp.NewToolResultError("jid parameter is required"), nil
+	}
+	jid = m.canonicalJID(jid)
+
+	notes, err := m.store.GetContactNotes(jid)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to get notes: %v", err)), nil
+	}
+
+	var result strings.Builder
+	fmt.Fprintf(&result, "Found %d notes on %s:\n\n", len(notes), jid)
+	for _, note := range notes {
+		fmt.Fprintf(&result, "[%d] %s: %s\n", note.ID, m.formatDateTime(note.CreatedAt), note.Text)
+	}
+
+	return mcp.NewToolResultText(result.String()), nil
+}
+
+// handleDeleteContactNote handles the delete_contact_note tool request.
+func (m *MCPServer) handleDeleteContactNote(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	id := request.GetFloat("note_id", 0)
+	if id <= 0 {
+		return mcp.NewToolResultError("note_id parameter is required"), nil
+	}
+
+	deleted, err := m.store.DeleteContactNote(int64(id))
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to delete note: %v", err)), nil
+	}
+	if !deleted {
+		return mcp.NewToolResultError(fmt.Sprintf("note not found: %d", int64(id))), nil
+	}
+
+	return mcp.NewToolResultText(fmt.Sprintf("Note %d deleted", int64(id))), nil
+}
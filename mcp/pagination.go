@@ -0,0 +1,113 @@
+package mcp
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"whatsapp-mcp/storage"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// pageCursor is the JSON payload of an opaque pagination cursor.
+type pageCursor struct {
+	Timestamp int64  `json:"t"`
+	Key       string `json:"k"`
+	Newer     bool   `json:"n,omitempty"` // page toward newer rows (prev_page)
+}
+
+// encodeCursor builds the opaque cursor pointing after the given row, or
+// before it if newer is set.
+func encodeCursor(timestamp time.Time, key string, newer bool) string {
+	data, _ := json.Marshal(pageCursor{Timestamp: timestamp.Unix(), Key: key, Newer: newer})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor parses a cursor produced by encodeCursor. newer reports whether
+// it pages toward newer rows.
+func decodeCursor(cursor string) (c *storage.PageCursor, newer bool, err error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, false, errors.New("malformed cursor")
+	}
+
+	var pc pageCursor
+	if err := json.Unmarshal(data, &pc); err != nil || pc.Key == "" {
+		return nil, false, errors.New("malformed cursor")
+	}
+
+	return &storage.PageCursor{Timestamp: time.Unix(pc.Timestamp, 0), Key: pc.Key}, pc.Newer, nil
+}
+
+// cursorParam reads the optional cursor tool parameter of a list that only
+// pages forward (list_chats).
+func cursorParam(request mcp.CallToolRequest) (*storage.PageCursor, error) {
+	cursor := request.GetString("cursor", "")
+	if cursor == "" {
+		return nil, nil
+	}
+	c, newer, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+	if newer {
+		return nil, errors.New("malformed cursor")
+	}
+	return c, nil
+}
+
+// messagePageParam reads the optional cursor tool parameter of a message list:
+// a next_page cursor continues toward older messages (before), a prev_page
+// cursor toward newer ones (after).
+func messagePageParam(request mcp.CallToolRequest) (before, after *storage.PageCursor, err error) {
+	cursor := request.GetString("cursor", "")
+	if cursor == "" {
+		return nil, nil, nil
+	}
+	c, newer, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, nil, err
+	}
+	if newer {
+		return nil, c, nil
+	}
+	return c, nil, nil
+}
+
+// messagePages returns the cursors of the pages around messages (newest
+// first): next_page holds older messages and prev_page newer ones. A cursor is
+// empty when there is nothing more to fetch in its direction.
+func messagePages(messages []storage.MessageWithNames, limit int, before, after *storage.PageCursor) (nextPage, prevPage string) {
+	if len(messages) == 0 {
+		return "", ""
+	}
+	first, last := messages[0], messages[len(messages)-1]
+
+	// after paging toward newer messages, the page we came from is older
+	if len(messages) == limit || after != nil {
+		nextPage = encodeCursor(last.Timestamp, last.ID, false)
+	}
+	// after paging toward older messages, the page we came from is newer
+	if before != nil || (after != nil && len(messages) == limit) {
+		prevPage = encodeCursor(first.Timestamp, first.ID, true)
+	}
+	return nextPage, prevPage
+}
+
+// writeNextPage appends the hint for fetching the next page to a text result.
+func writeNextPage(result *strings.Builder, nextPage string) {
+	if nextPage != "" {
+		fmt.Fprintf(result, "\nMore results available: call again with cursor=%q\n", nextPage)
+	}
+}
+
+// writePrevPage appends the hint for fetching newer messages to a text result.
+func writePrevPage(result *strings.Builder, prevPage string) {
+	if prevPage != "" {
+		fmt.Fprintf(result, "Newer results available: call again with cursor=%q\n", prevPage)
+	}
+}
@@ -0,0 +1,46 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// handleGetPollResults handles the get_poll_results tool request.
+func (m *MCPServer) handleGetPollResults(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	messageID, err := request.RequireString("message_id")
+	if err != nil {
+		return mcp.NewToolResultError("message_id parameter is required"), nil
+	}
+
+	results, err := m.store.GetPollResults(messageID)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to get poll results: %v", err)), nil
+	}
+	if results == nil {
+		return mcp.NewToolResultError(fmt.Sprintf("no poll found with message ID %s", messageID)), nil
+	}
+
+	var result strings.Builder
+	fmt.Fprintf(&result, "📊 %s\n", results.Poll.Question)
+	fmt.Fprintf(&result, "Created %s in %s", m.formatDateTime(results.Poll.CreatedAt), m.callerName(results.Poll.ChatJID))
+	if results.Poll.SelectableCount == 1 {
+		result.WriteString(" (single choice)")
+	}
+	fmt.Fprintf(&result, "\n%d voters\n\n", results.Voters)
+
+	for i, count := range results.Counts {
+		fmt.Fprintf(&result, "%d. %s - %d votes\n", i+1, count.Option, count.Votes)
+		if len(count.Voters) > 0 {
+			names := make([]string, 0, len(count.Voters))
+			for _, voter := range count.Voters {
+				names = append(names, m.callerName(voter))
+			}
+			fmt.Fprintf(&result, "   %s\n", strings.Join(names, ", "))
+		}
+	}
+
+	return mcp.NewToolResultText(result.String()), nil
+}
@@ -0,0 +1,35 @@
+package mcp
+
+import (
+	"context"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// progressNotificationMethod is the MCP notification for long-running request progress.
+const progressNotificationMethod = "notifications/progress"
+
+// progressNotifier returns a function that reports progress of a tool call to the client.
+// It is a no-op when the client didn't send a progress token with the request.
+// A total of 0 means it isn't known and is left out.
+func (m *MCPServer) progressNotifier(ctx context.Context, request mcp.CallToolRequest) func(progress, total float64, message string) {
+	if request.Params.Meta == nil || request.Params.Meta.ProgressToken == nil {
+		return func(float64, float64, string) {}
+	}
+
+	token := request.Params.Meta.ProgressToken
+	return func(progress, total float64, message string) {
+		params := map[string]any{
+			"progressToken": token,
+			"progress":      progress,
+			"message":       message,
+		}
+		if total > 0 {
+			params["total"] = total
+		}
+		// best effort: the tool result is returned either way
+		if err := m.server.SendNotificationToClient(ctx, progressNotificationMethod, params); err != nil {
+			m.log.Printf("Failed to send progress notification: %v", err)
+		}
+	}
+}
@@ -3,12 +3,18 @@ package mcp
 import (
 	"context"
 	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 	"whatsapp-mcp/paths"
+	"whatsapp-mcp/storage"
 
 	"github.com/mark3labs/mcp-go/mcp"
 )
@@ -59,6 +65,28 @@ func (m *MCPServer) registerResources() {
 		m.handleSearchPatternsGuide,
 	)
 
+	// most recently active chats, read from the database on every request
+	m.server.AddResource(
+		mcp.NewResource(
+			"whatsapp://chats/recent",
+			"Recent WhatsApp Chats",
+			mcp.WithResourceDescription("The 50 most recently active chats with unread counts"),
+			mcp.WithMIMEType("application/json"),
+		),
+		m.handleRecentChatsResource,
+	)
+
+	// today's activity digest
+	m.server.AddResource(
+		mcp.NewResource(
+			"whatsapp://digest/today",
+			"WhatsApp Daily Digest",
+			mcp.WithResourceDescription("Today's activity: per-chat message counts, chats awaiting a reply and mentions of you"),
+			mcp.WithMIMEType("text/markdown"),
+		),
+		m.handleDigestResource,
+	)
+
 	// dynamic media resource template
 	m.server.AddResourceTemplate(
 		mcp.NewResourceTemplate(
@@ -68,6 +96,169 @@ func (m *MCPServer) registerResources() {
 		),
 		m.handleMediaResource,
 	)
+
+	// embedded media thumbnails
+	m.server.AddResourceTemplate(
+		mcp.NewResourceTemplate(
+			"whatsapp://media/{message_id}/thumbnail",
+			"WhatsApp Media Thumbnail",
+			mcp.WithTemplateDescription("Small JPEG preview embedded in an image, video or document message (available without downloading the file)"),
+			mcp.WithTemplateMIMEType("image/jpeg"),
+		),
+		m.handleMediaThumbnailResource,
+	)
+
+	// exported chat files from export_chat
+	m.server.AddResourceTemplate(
+		mcp.NewResourceTemplate(
+			"whatsapp://export/{file_name}",
+			"WhatsApp Chat Export",
+			mcp.WithTemplateDescription("Read a chat export file produced by the export_chat tool"),
+		),
+		m.handleExportResource,
+	)
+
+	// latest messages of a chat
+	m.server.AddResourceTemplate(
+		mcp.NewResourceTemplate(
+			"whatsapp://chat/{+jid}/messages{?limit,format}",
+			"WhatsApp Chat History",
+			mcp.WithTemplateDescription("Latest messages of a chat (default 50, max 200) as markdown or JSON (?format=json)"),
+		),
+		m.handleChatMessagesResource,
+	)
+
+	// contact card
+	m.server.AddResourceTemplate(
+		mcp.NewResourceTemplate(
+			"whatsapp://contact/{+jid}",
+			"WhatsApp Contact Card",
+			mcp.WithTemplateDescription("Everything known about a contact: names, phone, shared groups, last activity, message counts and your notes"),
+			mcp.WithTemplateMIMEType("text/markdown"),
+		),
+		m.handleContactResource,
+	)
+
+	// group roster
+	m.server.AddResourceTemplate(
+		mcp.NewResourceTemplate(
+			"whatsapp://group/{+jid}/participants",
+			"WhatsApp Group Participants",
+			mcp.WithTemplateDescription("Synced participant list of a group with display names, admin flags and join dates"),
+			mcp.WithTemplateMIMEType("application/json"),
+		),
+		m.handleGroupParticipantsResource,
+	)
+}
+
+// resourceArg returns the first value of a URI template variable, or empty if it is missing.
+func resourceArg(req mcp.ReadResourceRequest, name string) string {
+	if req.Params.Arguments == nil {
+		return ""
+	}
+
+	var value string
+	switch v := req.Params.Arguments[name].(type) {
+	case []string:
+		if len(v) > 0 {
+			value = v[0]
+		}
+	case string:
+		value = v
+	}
+
+	// clients may percent-encode JIDs ("@" -> "%40")
+	if unescaped, err := url.PathUnescape(value); err == nil {
+		value = unescaped
+	}
+	return value
+}
+
+// truncateText shortens text to maxLen characters on a single line.
+func truncateText(text string, maxLen int) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if runes := []rune(text); len(runes) > maxLen {
+		return string(runes[:maxLen-1]) + "…"
+	}
+	return text
+}
+
+// handleChatMessagesResource handles chat history resource requests.
+func (m *MCPServer) handleChatMessagesResource(ctx context.Context, req mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	chatJID := resourceArg(req, "jid")
+	if chatJID == "" {
+		return nil, errors.New("invalid chat jid")
+	}
+
+	limit := 50
+	if l, err := strconv.Atoi(resourceArg(req, "limit")); err == nil && l > 0 {
+		limit = min(l, 200)
+	}
+
+	messages, err := m.store.GetChatMessagesWithNames(chatJID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get messages: %w", err)
+	}
+
+	// oldest first, like a transcript
+	slices.Reverse(messages)
+
+	if resourceArg(req, "format") == "json" {
+		data, err := json.Marshal(m.toMessageListOutput(chatJID, messages))
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode messages: %w", err)
+		}
+		return []mcp.ResourceContents{
+			mcp.TextResourceContents{
+				URI:      req.Params.URI,
+				MIMEType: "application/json",
+				Text:     string(data),
+			},
+		}, nil
+	}
+
+	chatName := chatJID
+	if chat, err := m.store.GetChatByJID(chatJID); err == nil && chat != nil {
+		chatName = getDisplayName(*chat)
+	}
+
+	var doc strings.Builder
+	fmt.Fprintf(&doc, "# %s\n\n", chatName)
+	fmt.Fprintf(&doc, "Chat JID: `%s` · last %d messages\n\n", chatJID, len(messages))
+
+	var lastDay string
+	for _, msg := range messages {
+		local := m.toLocalTime(msg.Timestamp)
+		if day := local.Format("2006-01-02"); day != lastDay {
+			fmt.Fprintf(&doc, "## %s\n\n", day)
+			lastDay = day
+		}
+
+		sender := getSenderDisplayName(msg)
+		if msg.IsFromMe {
+			sender = "You"
+		}
+
+		fmt.Fprintf(&doc, "- **%s** %s: %s%s", local.Format("15:04"), sender, msg.Text, statusSuffix(msg))
+		if meta := msg.MediaMetadata; meta != nil {
+			fmt.Fprintf(&doc, " _(📎 %s)_", meta.FileName)
+		}
+		if msg.Transcript != "" {
+			fmt.Fprintf(&doc, " _(🗣 %s)_", msg.Transcript)
+		}
+		if len(msg.Reactions) > 0 {
+			fmt.Fprintf(&doc, " · %s", formatReactions(msg.Reactions))
+		}
+		doc.WriteString("\n")
+	}
+
+	return []mcp.ResourceContents{
+		mcp.TextResourceContents{
+			URI:      req.Params.URI,
+			MIMEType: "text/markdown",
+			Text:     doc.String(),
+		},
+	}, nil
 }
 
 // handleCrossChatSearchGuide handles the cross-chat search guide resource request.
@@ -170,8 +361,8 @@ Get more messages:
 # First batch
 search_messages(from="558293093900@s.whatsapp.net", limit=100)
 
-# Next batch
-search_messages(from="558293093900@s.whatsapp.net", limit=100, offset=100)
+# Next batch (pass the next_page value from the previous result)
+search_messages(from="558293093900@s.whatsapp.net", limit=100, cursor="<next_page>")
 ` + "```" + `
 
 ## Common Mistakes
@@ -195,7 +386,7 @@ search_messages(from="558293093900@s.whatsapp.net", limit=100, offset=100)
 
 1. **Limit results**: Use ` + "`limit`" + ` parameter for faster responses
 2. **Specific searches**: Add ` + "`query`" + ` if you know what you're looking for
-3. **Pagination**: Use ` + "`offset`" + ` for large result sets
+3. **Pagination**: Pass the ` + "`next_page`" + ` cursor back as ` + "`cursor`" + ` for large result sets
 
 ## Quick Reference
 
@@ -376,7 +567,7 @@ Start with reasonable limits (50-100) to avoid overwhelming results.
 - ` + "`search_messages`" + `: ALL chats
 
 ### 4. Pagination for Large Results
-Use ` + "`offset`" + ` or timestamp-based pagination for large datasets.
+Pass the ` + "`next_page`" + ` value of a result back as ` + "`cursor`" + ` to fetch older messages, or ` + "`prev_page`" + ` to fetch newer ones. Cursors stay stable while new messages arrive, unlike offsets.
 
 ### 5. Check Timezone Settings
 Timestamps are shown in server timezone (` + m.timezone.String() + `).
@@ -901,6 +1092,27 @@ func (m *MCPServer) handleMediaResource(ctx context.Context, req mcp.ReadResourc
 		return nil, fmt.Errorf("media not downloaded (status: %s). Enable auto-download or download manually.", meta.DownloadStatus)
 	}
 
+	fileData, err := m.readMediaFile(meta)
+	if err != nil {
+		return nil, err
+	}
+
+	// encode to base64 for transmission
+	encodedData := base64.StdEncoding.EncodeToString(fileData)
+
+	// return the file as a blob so AI assistants can view it
+	return []mcp.ResourceContents{
+		mcp.BlobResourceContents{
+			URI:      uri,
+			MIMEType: meta.MimeType,
+			Blob:     encodedData,
+		},
+	}, nil
+}
+
+// readMediaFile reads a downloaded media file, making sure its stored path
+// stays inside the media directory.
+func (m *MCPServer) readMediaFile(meta *storage.MediaMetadata) ([]byte, error) {
 	// sanitize and validate file path to prevent directory traversal
 	cleanPath := filepath.Clean(meta.FilePath)
 	if strings.Contains(cleanPath, "..") {
@@ -937,16 +1149,317 @@ func (m *MCPServer) handleMediaResource(ctx context.Context, req mcp.ReadResourc
 	if err != nil {
 		return nil, fmt.Errorf("failed to read media file: %w", err)
 	}
+	return fileData, nil
+}
 
-	// encode to base64 for transmission
-	encodedData := base64.StdEncoding.EncodeToString(fileData)
+// handleContactResource handles contact card resource requests.
+func (m *MCPServer) handleContactResource(ctx context.Context, req mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	jid := m.canonicalJID(resourceArg(req, "jid"))
+	if jid == "" {
+		return nil, errors.New("invalid contact jid")
+	}
+
+	chat, err := m.store.GetChatByJID(jid)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chat: %w", err)
+	}
+
+	if chat != nil && chat.IsGroup {
+		return nil, fmt.Errorf("%s is a group, not a contact", jid)
+	}
+
+	pushName, err := m.store.GetPushName(jid)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get push name: %w", err)
+	}
+
+	stats, err := m.store.GetContactStats(jid)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get contact stats: %w", err)
+	}
+
+	groups, err := m.store.GetSharedGroups(jid)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get shared groups: %w", err)
+	}
+
+	notes, err := m.store.GetContactNotes(jid)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notes: %w", err)
+	}
+
+	name := jid
+	var contactName string
+	if chat != nil {
+		name = getDisplayName(*chat)
+		contactName = chat.ContactName
+	}
+	if name == jid && pushName != "" {
+		name = pushName
+	}
+
+	var card strings.Builder
+	fmt.Fprintf(&card, "# %s\n\n", name)
+	fmt.Fprintf(&card, "- **JID:** `%s`\n", jid)
+
+	// only phone number JIDs carry a phone, LIDs are opaque
+	if user, ok := strings.CutSuffix(jid, "@s.whatsapp.net"); ok {
+		fmt.Fprintf(&card, "- **Phone:** +%s\n", user)
+	}
+	if contactName != "" {
+		fmt.Fprintf(&card, "- **Contact name:** %s\n", contactName)
+	}
+	if pushName != "" {
+		fmt.Fprintf(&card, "- **WhatsApp name:** %s\n", pushName)
+	}
+
+	card.WriteString("\n## Activity\n\n")
+	fmt.Fprintf(&card, "- **Messages from them:** %d\n", stats.SentCount)
+	fmt.Fprintf(&card, "- **Messages from you (direct chat):** %d\n", stats.FromMeCount)
+	if stats.LastActivity != nil {
+		fmt.Fprintf(&card, "- **Last message from them:** %s\n", m.formatDateTime(*stats.LastActivity))
+	}
+	if chat != nil && !chat.LastMessageTime.IsZero() {
+		fmt.Fprintf(&card, "- **Last direct chat activity:** %s\n", m.formatDateTime(chat.LastMessageTime))
+	}
+
+	if presence, err := m.store.GetPresence(jid); err == nil && presence != nil && presence.LastSeen != nil {
+		fmt.Fprintf(&card, "- **Last seen online:** %s\n", m.formatDateTime(*presence.LastSeen))
+	}
+
+	fmt.Fprintf(&card, "\n## Shared Groups (%d)\n\n", len(groups))
+	for _, group := range groups {
+		fmt.Fprintf(&card, "- %s (`%s`)\n", getDisplayName(group), group.JID)
+	}
+
+	if len(notes) > 0 {
+		card.WriteString("\n## Notes\n\n")
+		for _, note := range notes {
+			fmt.Fprintf(&card, "- %s (%s, note %d)\n", note.Text, m.formatDateTime(note.CreatedAt), note.ID)
+		}
+	}
+
+	return []mcp.ResourceContents{
+		mcp.TextResourceContents{
+			URI:      req.Params.URI,
+			MIMEType: "text/markdown",
+			Text:     card.String(),
+		},
+	}, nil
+}
+
+// handleRecentChatsResource handles recent chats resource requests.
+func (m *MCPServer) handleRecentChatsResource(ctx context.Context, req mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	chats, err := m.store.ListChats(50, nil, storage.ChatFilter{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list chats: %w", err)
+	}
+
+	data, err := json.Marshal(m.toChatListOutput(chats))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode chats: %w", err)
+	}
+
+	return []mcp.ResourceContents{
+		mcp.TextResourceContents{
+			URI:      req.Params.URI,
+			MIMEType: "application/json",
+			Text:     string(data),
+		},
+	}, nil
+}
+
+// handleGroupParticipantsResource handles group roster resource requests.
+func (m *MCPServer) handleGroupParticipantsResource(ctx context.Context, req mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	groupJID := resourceArg(req, "jid")
+	if !strings.HasSuffix(groupJID, "@g.us") {
+		return nil, errors.New("invalid group jid")
+	}
+
+	out, err := m.groupParticipantList(ctx, groupJID)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(out)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode participants: %w", err)
+	}
+
+	return []mcp.ResourceContents{
+		mcp.TextResourceContents{
+			URI:      req.Params.URI,
+			MIMEType: "application/json",
+			Text:     string(data),
+		},
+	}, nil
+}
+
+// groupParticipantList returns the stored participants of a group. The list
+// is synced from WhatsApp first if it was never stored.
+func (m *MCPServer) groupParticipantList(ctx context.Context, groupJID string) (groupParticipantListOutput, error) {
+	participants, err := m.store.GetGroupParticipants(groupJID)
+	if err != nil {
+		return groupParticipantListOutput{}, fmt.Errorf("failed to get participants: %w", err)
+	}
+
+	// not synced yet (e.g. read right after startup), fetch it now
+	if len(participants) == 0 {
+		if err := m.wa.SyncGroupParticipants(ctx, groupJID); err != nil {
+			return groupParticipantListOutput{}, fmt.Errorf("failed to sync participants: %w", err)
+		}
+		if participants, err = m.store.GetGroupParticipants(groupJID); err != nil {
+			return groupParticipantListOutput{}, fmt.Errorf("failed to get participants: %w", err)
+		}
+	}
+
+	out := groupParticipantListOutput{
+		GroupJID:     groupJID,
+		GroupName:    groupJID,
+		Count:        len(participants),
+		Participants: make([]groupParticipantOutput, 0, len(participants)),
+	}
+	if chat, err := m.store.GetChatByJID(groupJID); err == nil && chat != nil {
+		out.GroupName = getDisplayName(*chat)
+	}
+	if description, err := m.store.GetGroupDescription(groupJID); err == nil {
+		out.Description = description
+	}
+
+	for _, p := range participants {
+		name := p.ContactName
+		if name == "" {
+			name = p.PushName
+		}
+		if name == "" {
+			name = p.ParticipantJID
+		}
+
+		participant := groupParticipantOutput{
+			JID:         p.ParticipantJID,
+			Name:        name,
+			PushName:    p.PushName,
+			ContactName: p.ContactName,
+			IsAdmin:     p.IsAdmin,
+		}
+		if p.JoinedAt != nil {
+			participant.JoinedAt = m.formatRFC3339(*p.JoinedAt)
+		}
+		out.Participants = append(out.Participants, participant)
+	}
+
+	return out, nil
+}
+
+// handleMediaThumbnailResource handles media thumbnail resource requests.
+func (m *MCPServer) handleMediaThumbnailResource(ctx context.Context, req mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	messageID := resourceArg(req, "message_id")
+	if messageID == "" {
+		return nil, errors.New("invalid message id")
+	}
+
+	thumbnail, err := m.mediaStore.GetMediaThumbnail(messageID)
+	if err != nil {
+		return nil, err
+	}
+	if len(thumbnail) == 0 {
+		return nil, fmt.Errorf("no thumbnail for message: %s", messageID)
+	}
 
-	// return the file as a blob so AI assistants can view it
 	return []mcp.ResourceContents{
 		mcp.BlobResourceContents{
-			URI:      uri,
-			MIMEType: meta.MimeType,
-			Blob:     encodedData,
+			URI:      req.Params.URI,
+			MIMEType: "image/jpeg",
+			Blob:     base64.StdEncoding.EncodeToString(thumbnail),
+		},
+	}, nil
+}
+
+// handleDigestResource handles daily digest resource requests.
+func (m *MCPServer) handleDigestResource(ctx context.Context, req mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	now := time.Now().In(m.timezone)
+	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, m.timezone)
+
+	activity, err := m.store.GetChatActivity(ctx, startOfDay, 50)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chat activity: %w", err)
+	}
+
+	var mentions []storage.MessageWithNames
+	if ownJIDs := m.wa.OwnJIDs(); len(ownJIDs) > 0 {
+		after := startOfDay.Add(-time.Second)
+		mentions, err = m.store.SearchMessagesWithNamesFiltered(ctx, storage.SearchFilter{
+			After:     &after,
+			Mentioned: ownJIDs,
+			Limit:     20,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get mentions: %w", err)
+		}
+	}
+
+	var total, fromMe int
+	for _, a := range activity {
+		total += a.MessageCount
+		fromMe += a.FromMeCount
+	}
+
+	var digest strings.Builder
+	fmt.Fprintf(&digest, "# WhatsApp Digest: %s\n\n", startOfDay.Format("Monday, 2006-01-02"))
+	if len(activity) == 0 {
+		digest.WriteString("No messages today.\n")
+	} else {
+		fmt.Fprintf(&digest, "%d messages in %d chats (%d sent by you).\n", total, len(activity), fromMe)
+	}
+
+	// chats with incoming messages after my last reply
+	var waiting []storage.ChatActivity
+	for _, a := range activity {
+		if a.UnansweredCount > 0 || a.UnreadCount > 0 {
+			waiting = append(waiting, a)
+		}
+	}
+	if len(waiting) > 0 {
+		fmt.Fprintf(&digest, "\n## Awaiting Reply (%d)\n\n", len(waiting))
+		for _, a := range waiting {
+			fmt.Fprintf(&digest, "- **%s**: %d new", a.ChatName, a.UnansweredCount)
+			if a.UnreadCount > 0 {
+				fmt.Fprintf(&digest, ", %d unread", a.UnreadCount)
+			}
+			if a.LastIncoming != "" {
+				fmt.Fprintf(&digest, ". Latest: \"%s\"", truncateText(a.LastIncoming, 80))
+			}
+			digest.WriteString("\n")
+		}
+	}
+
+	if len(mentions) > 0 {
+		fmt.Fprintf(&digest, "\n## Mentions of You (%d)\n\n", len(mentions))
+		for _, msg := range mentions {
+			fmt.Fprintf(&digest, "- %s **%s** in %s: %s\n",
+				m.formatTime(msg.Timestamp), getSenderDisplayName(msg), msg.ChatName, truncateText(msg.Text, 80))
+		}
+	}
+
+	if len(activity) > 0 {
+		digest.WriteString("\n## Activity by Chat\n\n")
+		digest.WriteString("| Chat | Messages | From you | Last message |\n")
+		digest.WriteString("|------|----------|----------|--------------|\n")
+		for _, a := range activity {
+			name := a.ChatName
+			if a.IsGroup {
+				name += " 👥"
+			}
+			fmt.Fprintf(&digest, "| %s | %d | %d | %s |\n",
+				strings.ReplaceAll(name, "|", "\\|"), a.MessageCount, a.FromMeCount, m.formatTime(a.LastMessageTime))
+		}
+	}
+
+	return []mcp.ResourceContents{
+		mcp.TextResourceContents{
+			URI:      req.Params.URI,
+			MIMEType: "text/markdown",
+			Text:     digest.String(),
 		},
 	}, nil
 }
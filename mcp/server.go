@@ -2,9 +2,11 @@ package mcp
 
 import (
 	"log"
+	"sync"
 	"time"
 
 	"whatsapp-mcp/storage"
+	"whatsapp-mcp/webhook"
 	"whatsapp-mcp/whatsapp"
 
 	"github.com/mark3labs/mcp-go/server"
@@ -18,11 +20,41 @@ type MCPServer struct {
 	mediaStore *storage.MediaStore
 	log        *log.Logger
 	timezone   *time.Location
+	drafts     map[string]sendDraft // pending dry-run sends by confirmation token
+	draftsMu   sync.Mutex
+	watches    map[string]*watchSession // watched chats by MCP session ID
+	watchMu    sync.Mutex
+	sessions   map[string]struct{} // connected MCP session IDs, for log notifications
+	sessionsMu sync.Mutex
+	tools      ToolsConfig
+	adminTools map[string]bool         // tools that need the admin scope, see addAdminTool
+	webhooks   *webhook.WebhookManager // optional, enables the webhook admin tools
 }
 
-// NewMCPServer creates a new MCP server with the provided WhatsApp client and storage.
-func NewMCPServer(wa *whatsapp.Client, store *storage.MessageStore, mediaStore *storage.MediaStore, timezone *time.Location) *MCPServer {
-	s := server.NewMCPServer(
+// NewMCPServer creates a new MCP server with the provided WhatsApp client and
+// storage. webhooks may be nil, which leaves out the webhook admin tools.
+func NewMCPServer(wa *whatsapp.Client, store *storage.MessageStore, mediaStore *storage.MediaStore, webhooks *webhook.WebhookManager, timezone *time.Location) *MCPServer {
+	m := &MCPServer{
+		wa:         wa,
+		store:      store,
+		mediaStore: mediaStore,
+		log:        log.Default(),
+		timezone:   timezone,
+		drafts:     make(map[string]sendDraft),
+		watches:    make(map[string]*watchSession),
+		sessions:   make(map[string]struct{}),
+		tools:      LoadToolsConfig(),
+		adminTools: make(map[string]bool),
+		webhooks:   webhooks,
+	}
+
+	// forget per-session state when a session goes away
+	hooks := &server.Hooks{}
+	hooks.AddOnRegisterSession(m.onSessionRegistered)
+	hooks.AddOnUnregisterSession(m.onSessionClosed)
+	hooks.AddOnUnregisterSession(m.onSessionUnregistered)
+
+	m.server = server.NewMCPServer(
 		"WhatsApp MCP",
 		"1.0.0",
 		server.WithInstructions(`WhatsApp integration for messaging operations.
@@ -35,17 +67,19 @@ Use prompts for common workflows or resources for detailed guides.`),
 		server.WithToolCapabilities(true),
 		server.WithPromptCapabilities(true),
 		server.WithResourceCapabilities(true, true),
+		server.WithLogging(),
 		server.WithRecovery(),
+		server.WithHooks(hooks),
+		server.WithToolFilter(m.filterAdminTools),
 	)
 
-	m := &MCPServer{
-		server:     s,
-		wa:         wa,
-		store:      store,
-		mediaStore: mediaStore,
-		log:        log.Default(),
-		timezone:   timezone,
-	}
+	// stream new messages to sessions watching their chat
+	wa.AddMessageListener(m.onNewMessage)
+
+	// forward important WhatsApp events as MCP log notifications
+	wa.AddLogListener(func(level, message string) {
+		m.LogToClients(level, "whatsapp", message)
+	})
 
 	// register all capabilities
 	m.registerTools()
@@ -0,0 +1,124 @@
+package mcp
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"whatsapp-mcp/storage"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// handleListStatuses handles the list_statuses tool request.
+func (m *MCPServer) handleListStatuses(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	senderJID := m.canonicalJID(request.GetString("sender_jid", ""))
+	includeExpired := request.GetBool("include_expired", false)
+
+	limit := request.GetFloat("limit", 50.0)
+	if limit > 200 {
+		limit = 200
+	}
+
+	statuses, err := m.store.ListStatuses(ctx, senderJID, includeExpired, int(limit))
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to list statuses: %v", err)), nil
+	}
+
+	var result strings.Builder
+	fmt.Fprintf(&result, "Found %d status updates:\n\n", len(statuses))
+
+	for i, status := range statuses {
+		fmt.Fprintf(&result, "%d. [%s] %s posted a %s status", i+1, m.formatDateTime(status.Timestamp), getSenderDisplayName(status.MessageWithNames), status.StatusType)
+		if status.Expired() {
+			result.WriteString(" (expired)\n")
+		} else {
+			fmt.Fprintf(&result, " (until %s)\n", m.formatDateTime(status.ExpiresAt))
+		}
+
+		if caption := statusCaption(status); caption != "" {
+			fmt.Fprintf(&result, "   %s\n", caption)
+		}
+		if meta := status.MediaMetadata; meta != nil {
+			fmt.Fprintf(&result, "   Media: %s (%s)", meta.MimeType, meta.DownloadStatus)
+			if meta.DownloadStatus == "downloaded" {
+				fmt.Fprintf(&result, " - whatsapp://media/%s", status.ID)
+			}
+			result.WriteString("\n")
+		}
+		fmt.Fprintf(&result, "   Message ID: %s\n\n", status.ID)
+	}
+
+	return mcp.NewToolResultText(result.String()), nil
+}
+
+// handleGetStatusMedia handles the get_status_media tool request.
+func (m *MCPServer) handleGetStatusMedia(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	messageID, err := request.RequireString("message_id")
+	if err != nil {
+		return mcp.NewToolResultError("message_id parameter is required"), nil
+	}
+
+	status, err := m.store.GetStatus(ctx, messageID)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to get status: %v", err)), nil
+	}
+	if status == nil {
+		return mcp.NewToolResultError(fmt.Sprintf("no status update with message ID %s", messageID)), nil
+	}
+
+	sender := getSenderDisplayName(status.MessageWithNames)
+	if status.StatusType == storage.StatusText {
+		return mcp.NewToolResultText(fmt.Sprintf("Text status from %s: %s", sender, status.Text)), nil
+	}
+
+	meta := status.MediaMetadata
+	if meta == nil {
+		return mcp.NewToolResultError("status media metadata not found"), nil
+	}
+
+	// download on demand, WhatsApp keeps status media only while the status is shown
+	if meta.DownloadStatus != "downloaded" {
+		if err := m.wa.ConnectionError(); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		meta, err = m.wa.DownloadMedia(ctx, messageID)
+		if err != nil {
+			if status.Expired() {
+				return mcp.NewToolResultError(fmt.Sprintf("status expired on %s and its media is no longer available: %v", m.formatDateTime(status.ExpiresAt), err)), nil
+			}
+			return mcp.NewToolResultError(fmt.Sprintf("failed to download status media: %v", err)), nil
+		}
+	}
+
+	text := fmt.Sprintf("%s status from %s, posted %s", strings.ToUpper(status.StatusType[:1])+status.StatusType[1:], sender, m.formatDateTime(status.Timestamp))
+	if caption := statusCaption(*status); caption != "" {
+		text += "\nCaption: " + caption
+	}
+	text += "\nResource: whatsapp://media/" + messageID
+
+	if status.StatusType == storage.StatusImage {
+		data, err := m.readMediaFile(meta)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		return mcp.NewToolResultImage(text, base64.StdEncoding.EncodeToString(data), meta.MimeType), nil
+	}
+
+	// videos are too large to inline, show their preview instead
+	thumbnail, err := m.mediaStore.GetMediaThumbnail(messageID)
+	if err != nil || len(thumbnail) == 0 {
+		return mcp.NewToolResultText(text), nil
+	}
+	return mcp.NewToolResultImage(text, base64.StdEncoding.EncodeToString(thumbnail), "image/jpeg"), nil
+}
+
+// statusCaption returns the text of a status, or the caption of an image or
+// video status, empty when it has none.
+func statusCaption(status storage.Status) string {
+	if status.Text == "[Image]" || status.Text == "[Video]" {
+		return ""
+	}
+	return status.Text
+}
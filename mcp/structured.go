@@ -0,0 +1,222 @@
+package mcp
+
+import (
+	"time"
+
+	"whatsapp-mcp/storage"
+)
+
+// chatOutput is the structured (JSON) representation of a chat in tool results.
+type chatOutput struct {
+	JID               string   `json:"jid"`
+	Name              string   `json:"name"`
+	PushName          string   `json:"push_name,omitempty"`
+	ContactName       string   `json:"contact_name,omitempty"`
+	IsGroup           bool     `json:"is_group"`
+	LastMessageTime   string   `json:"last_message_time"` // RFC 3339 in the configured timezone
+	UnreadCount       int      `json:"unread_count"`
+	MarkedUnread      bool     `json:"marked_unread,omitempty"`
+	DisappearingTimer int      `json:"disappearing_timer"` // seconds, 0 = off
+	MessageCount      int      `json:"message_count"`      // stored messages
+	LastSenderJID     string   `json:"last_sender_jid,omitempty"`
+	Muted             bool     `json:"muted,omitempty"`
+	MutedUntil        string   `json:"muted_until,omitempty"` // RFC 3339, not set when muted forever
+	Archived          bool     `json:"archived,omitempty"`
+	PinnedAt          string   `json:"pinned_at,omitempty"` // RFC 3339
+	Tags              []string `json:"tags,omitempty"`      // list_chats only
+}
+
+// chatListOutput is the structured result of list_chats and find_chat.
+type chatListOutput struct {
+	Count    int          `json:"count"`
+	Chats    []chatOutput `json:"chats"`
+	NextPage string       `json:"next_page,omitempty"` // pass as cursor to get the next page
+}
+
+// groupParticipantOutput is the structured representation of a group member.
+type groupParticipantOutput struct {
+	JID         string `json:"jid"`
+	Name        string `json:"name"`
+	PushName    string `json:"push_name,omitempty"`
+	ContactName string `json:"contact_name,omitempty"`
+	IsAdmin     bool   `json:"is_admin"`
+	JoinedAt    string `json:"joined_at,omitempty"` // RFC 3339, unknown for members present at the first sync
+}
+
+// groupParticipantListOutput is the structured list of members of a group.
+type groupParticipantListOutput struct {
+	GroupJID     string                   `json:"group_jid"`
+	GroupName    string                   `json:"group_name"`
+	Description  string                   `json:"description,omitempty"`
+	Count        int                      `json:"count"`
+	Participants []groupParticipantOutput `json:"participants"`
+}
+
+// mediaOutput is the structured representation of a message attachment.
+type mediaOutput struct {
+	FileName       string `json:"file_name"`
+	MimeType       string `json:"mime_type"`
+	FileSize       int64  `json:"file_size"`
+	Width          *int   `json:"width,omitempty"`
+	Height         *int   `json:"height,omitempty"`
+	Duration       *int   `json:"duration,omitempty"` // seconds
+	DownloadStatus string `json:"download_status"`
+	ResourceURI    string `json:"resource_uri,omitempty"` // set once the file is downloaded
+}
+
+// messageOutput is the structured (JSON) representation of a message in tool results.
+type messageOutput struct {
+	ID          string                  `json:"id"`
+	ChatJID     string                  `json:"chat_jid"`
+	ChatName    string                  `json:"chat_name,omitempty"`
+	SenderJID   string                  `json:"sender_jid"`
+	SenderName  string                  `json:"sender_name"`
+	Text        string                  `json:"text"`
+	Timestamp   string                  `json:"timestamp"` // RFC 3339 in the configured timezone
+	IsFromMe    bool                    `json:"is_from_me"`
+	MessageType string                  `json:"message_type"`
+	ReplyToID   string                  `json:"reply_to_id,omitempty"`
+	Media       *mediaOutput            `json:"media,omitempty"`
+	Transcript  string                  `json:"transcript,omitempty"` // voice note transcript
+	Reactions   []reactionOutput        `json:"reactions,omitempty"`
+	Views       int                     `json:"views,omitempty"`             // channel posts only
+	EditedAt    string                  `json:"edited_at,omitempty"`         // set if the message was edited
+	Edits       []messageEditOutput     `json:"previous_versions,omitempty"` // text before each edit, oldest first
+	DeletedAt   string                  `json:"deleted_at,omitempty"`        // set if the message was deleted for everyone
+	Location    *storage.Location       `json:"location,omitempty"`
+	Contacts    []storage.SharedContact `json:"contacts,omitempty"` // shared contact cards
+	Referral    *storage.ReferralInfo   `json:"referral,omitempty"`
+}
+
+// reactionOutput is the number of reactions with one emoji to a message.
+type reactionOutput struct {
+	Emoji string `json:"emoji"`
+	Count int    `json:"count"`
+}
+
+// messageEditOutput is a prior version of an edited message.
+type messageEditOutput struct {
+	Text       string `json:"text"`
+	ReplacedAt string `json:"replaced_at"` // RFC 3339 time of the edit that replaced this text
+}
+
+// messageListOutput is the structured result of get_chat_messages and search_messages.
+type messageListOutput struct {
+	Count    int             `json:"count"`
+	ChatJID  string          `json:"chat_jid,omitempty"`
+	Messages []messageOutput `json:"messages"`
+	NextPage string          `json:"next_page,omitempty"` // pass as cursor to get older messages
+	PrevPage string          `json:"prev_page,omitempty"` // pass as cursor to get newer messages
+}
+
+// formatRFC3339 formats a timestamp in the configured timezone for structured output.
+func (m *MCPServer) formatRFC3339(t time.Time) string {
+	return m.toLocalTime(t).Format(time.RFC3339)
+}
+
+// toChatOutput converts a stored chat into its structured representation.
+func (m *MCPServer) toChatOutput(chat storage.Chat) chatOutput {
+	out := chatOutput{
+		JID:               chat.JID,
+		Name:              getDisplayName(chat),
+		PushName:          chat.PushName,
+		ContactName:       chat.ContactName,
+		IsGroup:           chat.IsGroup,
+		LastMessageTime:   m.formatRFC3339(chat.LastMessageTime),
+		UnreadCount:       chat.UnreadCount,
+		MarkedUnread:      chat.MarkedUnread,
+		DisappearingTimer: chat.DisappearingTimer,
+		MessageCount:      chat.MessageCount,
+		LastSenderJID:     chat.LastSenderJID,
+		Muted:             chat.Muted,
+		Archived:          chat.Archived,
+	}
+	if !chat.MutedUntil.IsZero() {
+		out.MutedUntil = m.formatRFC3339(chat.MutedUntil)
+	}
+	if !chat.PinnedAt.IsZero() {
+		out.PinnedAt = m.formatRFC3339(chat.PinnedAt)
+	}
+	return out
+}
+
+// toChatListOutput converts stored chats into a structured chat list.
+func (m *MCPServer) toChatListOutput(chats []storage.Chat) chatListOutput {
+	out := chatListOutput{
+		Count: len(chats),
+		Chats: make([]chatOutput, 0, len(chats)),
+	}
+	for _, chat := range chats {
+		out.Chats = append(out.Chats, m.toChatOutput(chat))
+	}
+	return out
+}
+
+// toMessageOutput converts a stored message into its structured representation.
+func (m *MCPServer) toMessageOutput(msg storage.MessageWithNames) messageOutput {
+	sender := getSenderDisplayName(msg)
+	if msg.IsFromMe {
+		sender = "You"
+	}
+
+	out := messageOutput{
+		ID:          msg.ID,
+		ChatJID:     msg.ChatJID,
+		ChatName:    msg.ChatName,
+		SenderJID:   msg.SenderJID,
+		SenderName:  sender,
+		Text:        msg.Text,
+		Timestamp:   m.formatRFC3339(msg.Timestamp),
+		IsFromMe:    msg.IsFromMe,
+		MessageType: msg.MessageType,
+		ReplyToID:   msg.ReplyToID,
+		Referral:    msg.Referral,
+		Transcript:  msg.Transcript,
+	}
+
+	if msg.EditedAt != nil {
+		out.EditedAt = m.formatRFC3339(*msg.EditedAt)
+	}
+	if msg.DeletedAt != nil {
+		out.DeletedAt = m.formatRFC3339(*msg.DeletedAt)
+	}
+	if msg.Payload != nil {
+		out.Location = msg.Payload.Location
+		out.Contacts = msg.Payload.Contacts
+	}
+
+	for _, r := range msg.Reactions {
+		out.Reactions = append(out.Reactions, reactionOutput{Emoji: r.Emoji, Count: r.Count})
+	}
+	out.Views = msg.Views
+
+	if meta := msg.MediaMetadata; meta != nil {
+		out.Media = &mediaOutput{
+			FileName:       meta.FileName,
+			MimeType:       meta.MimeType,
+			FileSize:       meta.FileSize,
+			Width:          meta.Width,
+			Height:         meta.Height,
+			Duration:       meta.Duration,
+			DownloadStatus: meta.DownloadStatus,
+		}
+		if meta.DownloadStatus == "downloaded" {
+			out.Media.ResourceURI = "whatsapp://media/" + msg.ID
+		}
+	}
+
+	return out
+}
+
+// toMessageListOutput converts stored messages into a structured message list, preserving order.
+func (m *MCPServer) toMessageListOutput(chatJID string, messages []storage.MessageWithNames) messageListOutput {
+	out := messageListOutput{
+		Count:    len(messages),
+		ChatJID:  chatJID,
+		Messages: make([]messageOutput, 0, len(messages)),
+	}
+	for _, msg := range messages {
+		out.Messages = append(out.Messages, m.toMessageOutput(msg))
+	}
+	return out
+}
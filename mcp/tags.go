@@ -0,0 +1,100 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// tagTarget reads the chat_jid or message_id a tag tool applies to; exactly
+// one of them must be given.
+func (m *MCPServer) tagTarget(request mcp.CallToolRequest) (chatJID, messageID string, err error) {
+	chatJID = m.canonicalJID(request.GetString("chat_jid", ""))
+	messageID = request.GetString("message_id", "")
+	if (chatJID == "") == (messageID == "") {
+		return "", "", fmt.Errorf("provide either chat_jid or message_id")
+	}
+	return chatJID, messageID, nil
+}
+
+// handleAddTag handles the add_tag tool request.
+func (m *MCPServer) handleAddTag(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	tag, err := request.RequireString("tag")
+	if err != nil {
+		return mcp.NewToolResultError("tag parameter is required"), nil
+	}
+
+	chatJID, messageID, err := m.tagTarget(request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	if chatJID != "" {
+		if err := m.store.TagChat(tag, chatJID); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to tag chat: %v", err)), nil
+		}
+		return mcp.NewToolResultText(fmt.Sprintf("Tagged chat %s with '%s'", chatJID, tag)), nil
+	}
+
+	found, err := m.store.TagMessage(tag, messageID)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to tag message: %v", err)), nil
+	}
+	if !found {
+		return mcp.NewToolResultError(fmt.Sprintf("message not found: %s", messageID)), nil
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Tagged message %s with '%s'", messageID, tag)), nil
+}
+
+// handleRemoveTag handles the remove_tag tool request.
+func (m *MCPServer) handleRemoveTag(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	tag, err := request.RequireString("tag")
+	if err != nil {
+		return mcp.NewToolResultError("tag parameter is required"), nil
+	}
+
+	chatJID, messageID, err := m.tagTarget(request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	var removed bool
+	target := "chat " + chatJID
+	if chatJID != "" {
+		removed, err = m.store.UntagChat(tag, chatJID)
+	} else {
+		target = "message " + messageID
+		removed, err = m.store.UntagMessage(tag, messageID)
+	}
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to remove tag: %v", err)), nil
+	}
+	if !removed {
+		return mcp.NewToolResultError(fmt.Sprintf("%s is not tagged with '%s'", target, tag)), nil
+	}
+
+	return mcp.NewToolResultText(fmt.Sprintf("Removed tag '%s' from %s", tag, target)), nil
+}
+
+// handleListTags handles the list_tags tool request.
+func (m *MCPServer) handleListTags(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	tags, err := m.store.ListTags()
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to list tags: %v", err)), nil
+	}
+
+	var result strings.Builder
+	fmt.Fprintf(&result, "Found %d tags:\n\n", len(tags))
+
+	for i, t := range tags {
+		fmt.Fprintf(&result, "%d. %s: %d chats, %d messages", i+1, t.Name, t.Chats, t.Messages)
+		if t.LabelID != "" {
+			result.WriteString(" (WhatsApp Business label)")
+		}
+		result.WriteString("\n")
+	}
+
+	return mcp.NewToolResultText(result.String()), nil
+}
@@ -0,0 +1,155 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// templatePlaceholder matches {{name}} placeholders in templates.
+var templatePlaceholder = regexp.MustCompile(`\{\{\s*([A-Za-z0-9_]+)\s*\}\}`)
+
+// templatePlaceholders returns the distinct placeholder names used in a template, in order of appearance.
+func templatePlaceholders(text string) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, match := range templatePlaceholder.FindAllStringSubmatch(text, -1) {
+		if !seen[match[1]] {
+			seen[match[1]] = true
+			names = append(names, match[1])
+		}
+	}
+	return names
+}
+
+// renderTemplate replaces placeholders with variables.
+// It returns an error listing every placeholder without a value.
+func renderTemplate(text string, variables map[string]any) (string, error) {
+	var missing []string
+	for _, name := range templatePlaceholders(text) {
+		if _, ok := variables[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return "", fmt.Errorf("missing variables: %s", strings.Join(missing, ", "))
+	}
+
+	return templatePlaceholder.ReplaceAllStringFunc(text, func(match string) string {
+		name := templatePlaceholder.FindStringSubmatch(match)[1]
+		return fmt.Sprint(variables[name])
+	}), nil
+}
+
+// handleCreateTemplate handles the create_template tool request.
+func (m *MCPServer) handleCreateTemplate(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	name, err := request.RequireString("name")
+	if err != nil {
+		return mcp.NewToolResultError("name parameter is required"), nil
+	}
+
+	text, err := request.RequireString("text")
+	if err != nil {
+		return mcp.NewToolResultError("text parameter is required"), nil
+	}
+
+	description := request.GetString("description", "")
+
+	if err := m.store.SaveTemplate(name, text, description); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to save template: %v", err)), nil
+	}
+
+	placeholders := templatePlaceholders(text)
+	if len(placeholders) == 0 {
+		return mcp.NewToolResultText(fmt.Sprintf("Template '%s' saved (no placeholders)", name)), nil
+	}
+
+	return mcp.NewToolResultText(fmt.Sprintf("Template '%s' saved with placeholders: %s",
+		name, strings.Join(placeholders, ", "))), nil
+}
+
+// handleListTemplates handles the list_templates tool request.
+func (m *MCPServer) handleListTemplates(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	templates, err := m.store.ListTemplates()
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to list templates: %v", err)), nil
+	}
+
+	var result strings.Builder
+	fmt.Fprintf(&result, "Found %d templates:\n\n", len(templates))
+
+	for i, t := range templates {
+		fmt.Fprintf(&result, "%d. %s\n", i+1, t.Name)
+		if t.Description != "" {
+			fmt.Fprintf(&result, "   Description: %s\n", t.Description)
+		}
+		if placeholders := templatePlaceholders(t.Text); len(placeholders) > 0 {
+			fmt.Fprintf(&result, "   Variables: %s\n", strings.Join(placeholders, ", "))
+		}
+		fmt.Fprintf(&result, "   Text: %s\n\n", t.Text)
+	}
+
+	return mcp.NewToolResultText(result.String()), nil
+}
+
+// handleDeleteTemplate handles the delete_template tool request.
+func (m *MCPServer) handleDeleteTemplate(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	name, err := request.RequireString("name")
+	if err != nil {
+		return mcp.NewToolResultError("name parameter is required"), nil
+	}
+
+	deleted, err := m.store.DeleteTemplate(name)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to delete template: %v", err)), nil
+	}
+	if !deleted {
+		return mcp.NewToolResultError(fmt.Sprintf("template not found: %s", name)), nil
+	}
+
+	return mcp.NewToolResultText(fmt.Sprintf("Template '%s' deleted", name)), nil
+}
+
+// handleSendTemplate handles the send_template tool request.
+func (m *MCPServer) handleSendTemplate(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	chatJID, err := request.RequireString("chat_jid")
+	if err != nil {
+		return mcp.NewToolResultError("chat_jid parameter is required"), nil
+	}
+
+	name, err := request.RequireString("template_name")
+	if err != nil {
+		return mcp.NewToolResultError("template_name parameter is required"), nil
+	}
+
+	variables, _ := request.GetArguments()["variables"].(map[string]any)
+
+	tmpl, err := m.store.GetTemplate(name)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to get template: %v", err)), nil
+	}
+	if tmpl == nil {
+		templates, _ := m.store.ListTemplates()
+		names := make([]string, 0, len(templates))
+		for _, t := range templates {
+			names = append(names, t.Name)
+		}
+		return mcp.NewToolResultError(fmt.Sprintf("template not found: %s (available: %s)", name, strings.Join(names, ", "))), nil
+	}
+
+	text, err := renderTemplate(tmpl.Text, variables)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to render template '%s': %v", name, err)), nil
+	}
+
+	idempotencyKey := request.GetString("idempotency_key", "")
+
+	if request.GetBool("dry_run", false) {
+		return m.createSendDraft(chatJID, text, nil, idempotencyKey), nil
+	}
+
+	return m.sendText(ctx, chatJID, text, nil, idempotencyKey), nil
+}
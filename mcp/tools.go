@@ -2,25 +2,59 @@ package mcp
 
 import (
 	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
 )
 
+// addTool registers a tool unless it is excluded by MCP_TOOLS_ENABLED / MCP_TOOLS_DISABLED.
+func (m *MCPServer) addTool(tool mcp.Tool, handler server.ToolHandlerFunc) {
+	if !m.tools.Allowed(tool.Name) {
+		m.log.Printf("Tool %s disabled by configuration", tool.Name)
+		return
+	}
+	m.server.AddTool(tool, handler)
+}
+
 // registerTools defines all MCP tools available to clients.
 func (m *MCPServer) registerTools() {
 	// 1. list all chats
-	m.server.AddTool(
+	m.addTool(
 		mcp.NewTool("list_chats",
-			mcp.WithDescription("List WhatsApp conversations ordered by most recent activity. Returns chat details including JID, name, last message timestamp, and unread count."),
+			mcp.WithDescription("List WhatsApp conversations ordered by most recent activity. Returns chat details including JID, name, last message timestamp, unread count (incoming messages after the last one read or answered on any device, or marked unread) and whether the chat is muted, archived or pinned on the phone."),
+			mcp.WithReadOnlyHintAnnotation(true),
+			mcp.WithDestructiveHintAnnotation(false),
+			mcp.WithIdempotentHintAnnotation(true),
+			mcp.WithOpenWorldHintAnnotation(false),
 			mcp.WithNumber("limit",
 				mcp.Description("maximum number of chats to return (default: 50, max: 100)"),
 			),
+			mcp.WithString("cursor",
+				mcp.Description("next_page cursor from a previous call to continue where it stopped"),
+			),
+			mcp.WithString("tag",
+				mcp.Description("only chats with this tag (see list_tags)"),
+			),
+			mcp.WithBoolean("archived",
+				mcp.Description("true for only archived chats, false to leave them out"),
+			),
+			mcp.WithBoolean("pinned",
+				mcp.Description("true for only pinned chats, false to leave them out"),
+			),
+			mcp.WithBoolean("muted",
+				mcp.Description("true for only muted chats, false to leave them out"),
+			),
+			mcp.WithOutputSchema[chatListOutput](),
 		),
 		m.handleListChats,
 	)
 
 	// 2. get messages from specific chat
-	m.server.AddTool(
+	m.addTool(
 		mcp.NewTool("get_chat_messages",
-			mcp.WithDescription("Retrieve message history from a specific WhatsApp chat. Supports pagination via timestamps or offset, and can filter by sender."),
+			mcp.WithDescription("Retrieve message history from a specific WhatsApp chat. Supports pagination via cursors or timestamps, and can filter by sender."),
+			mcp.WithReadOnlyHintAnnotation(true),
+			mcp.WithDestructiveHintAnnotation(false),
+			mcp.WithIdempotentHintAnnotation(true),
+			mcp.WithOpenWorldHintAnnotation(false),
 			mcp.WithString("chat_jid",
 				mcp.Required(),
 				mcp.Description("chat JID (WhatsApp identifier) from find_chat or list_chats"),
@@ -29,54 +63,106 @@ func (m *MCPServer) registerTools() {
 				mcp.Description("maximum number of messages to return (default: 50, max: 200)"),
 			),
 			mcp.WithString("before_timestamp",
-				mcp.Description("get messages before this timestamp (ISO 8601 format)"),
+				mcp.Description("get messages before this timestamp (ISO 8601, read in the configured timezone unless it has an offset)"),
 			),
 			mcp.WithString("after_timestamp",
-				mcp.Description("get messages after this timestamp (ISO 8601 format)"),
+				mcp.Description("get messages after this timestamp (ISO 8601, read in the configured timezone unless it has an offset)"),
 			),
 			mcp.WithString("from",
 				mcp.Description("filter messages by sender JID (e.g., for filtering one person's messages in a group chat)"),
 			),
 			mcp.WithNumber("offset",
-				mcp.Description("number of messages to skip for pagination (default: 0)"),
+				mcp.Description("deprecated: number of messages to skip (default: 0). Use cursor instead, offsets are slow on deep pages and shift as new messages arrive"),
+			),
+			mcp.WithString("cursor",
+				mcp.Description("next_page (older messages) or prev_page (newer messages) cursor from a previous call to continue from there"),
+			),
+			mcp.WithBoolean("include_edit_history",
+				mcp.Description("also show the prior versions of edited messages (default: false)"),
 			),
+			mcp.WithBoolean("include_deleted",
+				mcp.Description("also return messages deleted for everyone, with their original content (default: false)"),
+			),
+			mcp.WithOutputSchema[messageListOutput](),
 		),
 		m.handleGetChatMessages,
 	)
 
 	// 3. search messages by text
-	m.server.AddTool(
+	m.addTool(
 		mcp.NewTool("search_messages",
-			mcp.WithDescription("Search for messages across all WhatsApp chats by text content or sender. Supports pattern matching with wildcards (*, ?, [abc])."),
+			mcp.WithDescription("Search for messages across all WhatsApp chats by text content or sender, optionally restricted to a date range or message type. Supports pattern matching with wildcards (*, ?, [abc])."),
+			mcp.WithReadOnlyHintAnnotation(true),
+			mcp.WithDestructiveHintAnnotation(false),
+			mcp.WithIdempotentHintAnnotation(true),
+			mcp.WithOpenWorldHintAnnotation(false),
 			mcp.WithString("query",
 				mcp.Description("text pattern to search for (optional: can be omitted when using only 'from' parameter)"),
 			),
 			mcp.WithString("from",
 				mcp.Description("filter by sender JID to find all messages from a specific person across all chats"),
 			),
+			mcp.WithArray("chat_jids",
+				mcp.Description("limit the search to these chat JIDs (e.g., a few work groups). Omit to search all chats"),
+				mcp.WithStringItems(),
+			),
+			mcp.WithString("after_timestamp",
+				mcp.Description("only messages after this timestamp (ISO 8601, read in the configured timezone unless it has an offset)"),
+			),
+			mcp.WithString("before_timestamp",
+				mcp.Description("only messages before this timestamp (ISO 8601, read in the configured timezone unless it has an offset)"),
+			),
+			mcp.WithString("mentions",
+				mcp.Description("only messages that mention this JID. Use 'me' for messages where you were mentioned"),
+			),
+			mcp.WithString("message_type",
+				mcp.Description("only messages of this type (e.g., text, image, video, audio, ptt, document, sticker, url, location, live_location, vcard, contact_array). 'link' is an alias for 'url'"),
+			),
+			mcp.WithString("tag",
+				mcp.Description("only messages with this tag or in a chat with this tag (see list_tags)"),
+			),
 			mcp.WithNumber("limit",
 				mcp.Description("maximum number of results to return (default: 50, max: 200)"),
 			),
+			mcp.WithString("cursor",
+				mcp.Description("next_page (older messages) or prev_page (newer messages) cursor from a previous call with the same filters to continue from there"),
+			),
+			mcp.WithBoolean("include_deleted",
+				mcp.Description("also return messages deleted for everyone, with their original content (default: false)"),
+			),
+			mcp.WithBoolean("archive",
+				mcp.Description("search the old messages moved to the archive database instead (default: false). Results say when archived messages match"),
+			),
+			mcp.WithOutputSchema[messageListOutput](),
 		),
 		m.handleSearchMessages,
 	)
 
 	// 4. find chat by name or JID
-	m.server.AddTool(
+	m.addTool(
 		mcp.NewTool("find_chat",
 			mcp.WithDescription("Find WhatsApp chats by searching names or JIDs. Supports pattern matching with wildcards. Returns matching chats with their JIDs."),
+			mcp.WithReadOnlyHintAnnotation(true),
+			mcp.WithDestructiveHintAnnotation(false),
+			mcp.WithIdempotentHintAnnotation(true),
+			mcp.WithOpenWorldHintAnnotation(false),
 			mcp.WithString("search",
 				mcp.Required(),
 				mcp.Description("search pattern (supports wildcards: *, ?, [abc])"),
 			),
+			mcp.WithOutputSchema[chatListOutput](),
 		),
 		m.handleFindChat,
 	)
 
 	// 5. send message
-	m.server.AddTool(
+	m.addTool(
 		mcp.NewTool("send_message",
-			mcp.WithDescription("Send a text message to a WhatsApp chat (DM or group)."),
+			mcp.WithDescription("Send a text message to a WhatsApp chat (DM or group), optionally @-mentioning group members."),
+			mcp.WithReadOnlyHintAnnotation(false),
+			mcp.WithDestructiveHintAnnotation(true),
+			mcp.WithIdempotentHintAnnotation(false),
+			mcp.WithOpenWorldHintAnnotation(true),
 			mcp.WithString("chat_jid",
 				mcp.Required(),
 				mcp.Description("recipient chat JID from find_chat or list_chats"),
@@ -85,14 +171,31 @@ func (m *MCPServer) registerTools() {
 				mcp.Required(),
 				mcp.Description("message text to send"),
 			),
+			mcp.WithArray("mentions",
+				mcp.Description("JIDs of users to @-mention (groups). Write '@Name' in the text to place a mention; missing mentions are prepended"),
+				mcp.WithStringItems(),
+			),
+			mcp.WithString("idempotency_key",
+				mcp.Description("optional unique key for this send. Retrying with the same key returns the original result instead of sending again (kept for 24h)"),
+			),
+			mcp.WithString("client_message_id",
+				mcp.Description("alias for idempotency_key"),
+			),
+			mcp.WithBoolean("dry_run",
+				mcp.Description("if true, nothing is sent: returns a preview and a confirmation_token for confirm_send (valid for 10 minutes)"),
+			),
 		),
 		m.handleSendMessage,
 	)
 
 	// 6. load more messages on-demand
-	m.server.AddTool(
+	m.addTool(
 		mcp.NewTool("load_more_messages",
 			mcp.WithDescription("Fetch additional message history from WhatsApp servers for a specific chat. Use when you need older messages not yet in the database."),
+			mcp.WithReadOnlyHintAnnotation(false),
+			mcp.WithDestructiveHintAnnotation(false),
+			mcp.WithIdempotentHintAnnotation(true),
+			mcp.WithOpenWorldHintAnnotation(true),
 			mcp.WithString("chat_jid",
 				mcp.Required(),
 				mcp.Description("chat JID to fetch history for"),
@@ -108,10 +211,776 @@ func (m *MCPServer) registerTools() {
 	)
 
 	// 7. get my info
-	m.server.AddTool(
+	m.addTool(
 		mcp.NewTool("get_my_info",
 			mcp.WithDescription("Get your own WhatsApp profile information including JID, display name, status/bio, and profile picture URL."),
+			mcp.WithReadOnlyHintAnnotation(true),
+			mcp.WithDestructiveHintAnnotation(false),
+			mcp.WithIdempotentHintAnnotation(true),
+			mcp.WithOpenWorldHintAnnotation(true),
 		),
 		m.handleGetMyInfo,
 	)
+
+	// 8. export a full conversation to a file
+	m.addTool(
+		mcp.NewTool("export_chat",
+			mcp.WithDescription("Export the full stored history of a chat to a file under data/exports. Returns a whatsapp://export/ resource URI to read the file."),
+			mcp.WithReadOnlyHintAnnotation(false),
+			mcp.WithDestructiveHintAnnotation(false),
+			mcp.WithIdempotentHintAnnotation(true),
+			mcp.WithOpenWorldHintAnnotation(false),
+			mcp.WithString("chat_jid",
+				mcp.Required(),
+				mcp.Description("chat JID to export"),
+			),
+			mcp.WithString("format",
+				mcp.Description("export format: 'txt' (WhatsApp export format, default), 'json', or 'html' (with media references)"),
+				mcp.Enum("txt", "json", "html"),
+			),
+		),
+		m.handleExportChat,
+	)
+
+	// 9. query or set the disappearing messages timer
+	m.addTool(
+		mcp.NewTool("disappearing_messages",
+			mcp.WithDescription("Get or set the disappearing messages timer of a chat. Omit 'set' to query the current timer. For DMs the last known timer is returned."),
+			mcp.WithReadOnlyHintAnnotation(false),
+			mcp.WithDestructiveHintAnnotation(true),
+			mcp.WithIdempotentHintAnnotation(true),
+			mcp.WithOpenWorldHintAnnotation(true),
+			mcp.WithString("chat_jid",
+				mcp.Required(),
+				mcp.Description("chat JID from find_chat or list_chats"),
+			),
+			mcp.WithString("set",
+				mcp.Description("new timer: 'off', '24h', '7d' or '90d'"),
+				mcp.Enum("off", "24h", "7d", "90d"),
+			),
+		),
+		m.handleDisappearingMessages,
+	)
+
+	// 10. subscribe to presence updates
+	m.addTool(
+		mcp.NewTool("subscribe_presence",
+			mcp.WithDescription("Subscribe to online/last seen updates of a contact. WhatsApp only sends presence while you are online, and users can hide it in their privacy settings."),
+			mcp.WithReadOnlyHintAnnotation(false),
+			mcp.WithDestructiveHintAnnotation(false),
+			mcp.WithIdempotentHintAnnotation(true),
+			mcp.WithOpenWorldHintAnnotation(true),
+			mcp.WithString("jid",
+				mcp.Required(),
+				mcp.Description("user JID (e.g., 5511999999999@s.whatsapp.net)"),
+			),
+		),
+		m.handleSubscribePresence,
+	)
+
+	// 11. get last known presence
+	m.addTool(
+		mcp.NewTool("get_presence",
+			mcp.WithDescription("Get the last known presence of a contact (online now, or last seen time). Requires a prior subscribe_presence call."),
+			mcp.WithReadOnlyHintAnnotation(true),
+			mcp.WithDestructiveHintAnnotation(false),
+			mcp.WithIdempotentHintAnnotation(true),
+			mcp.WithOpenWorldHintAnnotation(false),
+			mcp.WithString("jid",
+				mcp.Required(),
+				mcp.Description("user JID (e.g., 5511999999999@s.whatsapp.net)"),
+			),
+		),
+		m.handleGetPresence,
+	)
+
+	// 12. get delivery status of a sent message
+	m.addTool(
+		mcp.NewTool("get_message_status",
+			mcp.WithDescription("Get the delivery status (sent, delivered, read, played) of a message you sent. For groups, includes per-participant receipts."),
+			mcp.WithReadOnlyHintAnnotation(true),
+			mcp.WithDestructiveHintAnnotation(false),
+			mcp.WithIdempotentHintAnnotation(true),
+			mcp.WithOpenWorldHintAnnotation(false),
+			mcp.WithString("message_id",
+				mcp.Required(),
+				mcp.Description("ID of a message you sent (returned by send_message)"),
+			),
+		),
+		m.handleGetMessageStatus,
+	)
+
+	// 13. confirm a dry-run send
+	m.addTool(
+		mcp.NewTool("confirm_send",
+			mcp.WithDescription("Send a message previously drafted with send_message dry_run=true. Use after the user approved the preview."),
+			mcp.WithReadOnlyHintAnnotation(false),
+			mcp.WithDestructiveHintAnnotation(true),
+			mcp.WithIdempotentHintAnnotation(false),
+			mcp.WithOpenWorldHintAnnotation(true),
+			mcp.WithString("confirmation_token",
+				mcp.Required(),
+				mcp.Description("confirmation_token returned by send_message with dry_run=true"),
+			),
+		),
+		m.handleConfirmSend,
+	)
+
+	// 14. create or update a message template
+	m.addTool(
+		mcp.NewTool("create_template",
+			mcp.WithDescription("Create or update a named message template. Use {{name}} placeholders for values filled in by send_template."),
+			mcp.WithReadOnlyHintAnnotation(false),
+			mcp.WithDestructiveHintAnnotation(true),
+			mcp.WithIdempotentHintAnnotation(true),
+			mcp.WithOpenWorldHintAnnotation(false),
+			mcp.WithString("name",
+				mcp.Required(),
+				mcp.Description("unique template name (creating an existing name replaces it)"),
+			),
+			mcp.WithString("text",
+				mcp.Required(),
+				mcp.Description("template text, e.g. 'Hi {{name}}, your order {{order_id}} has shipped'"),
+			),
+			mcp.WithString("description",
+				mcp.Description("what the template is for"),
+			),
+		),
+		m.handleCreateTemplate,
+	)
+
+	// 15. list message templates
+	m.addTool(
+		mcp.NewTool("list_templates",
+			mcp.WithDescription("List saved message templates with their placeholders."),
+			mcp.WithReadOnlyHintAnnotation(true),
+			mcp.WithDestructiveHintAnnotation(false),
+			mcp.WithIdempotentHintAnnotation(true),
+			mcp.WithOpenWorldHintAnnotation(false),
+		),
+		m.handleListTemplates,
+	)
+
+	// 16. delete a message template
+	m.addTool(
+		mcp.NewTool("delete_template",
+			mcp.WithDescription("Delete a saved message template."),
+			mcp.WithReadOnlyHintAnnotation(false),
+			mcp.WithDestructiveHintAnnotation(true),
+			mcp.WithIdempotentHintAnnotation(true),
+			mcp.WithOpenWorldHintAnnotation(false),
+			mcp.WithString("name",
+				mcp.Required(),
+				mcp.Description("template name"),
+			),
+		),
+		m.handleDeleteTemplate,
+	)
+
+	// 17. render and send a template
+	m.addTool(
+		mcp.NewTool("send_template",
+			mcp.WithDescription("Render a saved template with variables and send it to a chat. Fails if any placeholder has no value."),
+			mcp.WithReadOnlyHintAnnotation(false),
+			mcp.WithDestructiveHintAnnotation(true),
+			mcp.WithIdempotentHintAnnotation(false),
+			mcp.WithOpenWorldHintAnnotation(true),
+			mcp.WithString("chat_jid",
+				mcp.Required(),
+				mcp.Description("recipient chat JID from find_chat or list_chats"),
+			),
+			mcp.WithString("template_name",
+				mcp.Required(),
+				mcp.Description("name of the template to send"),
+			),
+			mcp.WithObject("variables",
+				mcp.Description("placeholder values, e.g. {\"name\": \"Maria\", \"order_id\": \"1234\"}"),
+				mcp.AdditionalProperties(map[string]any{"type": "string"}),
+			),
+			mcp.WithString("idempotency_key",
+				mcp.Description("optional unique key for this send (see send_message)"),
+			),
+			mcp.WithBoolean("dry_run",
+				mcp.Description("if true, returns the rendered preview and a confirmation_token for confirm_send instead of sending"),
+			),
+		),
+		m.handleSendTemplate,
+	)
+
+	// 18. watch a chat for new messages
+	m.addTool(
+		mcp.NewTool("watch_chat",
+			mcp.WithDescription("Watch a chat for new messages in this session. New messages are pushed as notifications/whatsapp/message notifications and buffered for poll_updates, so you don't need to call get_chat_messages in a loop."),
+			mcp.WithReadOnlyHintAnnotation(false),
+			mcp.WithDestructiveHintAnnotation(false),
+			mcp.WithIdempotentHintAnnotation(true),
+			mcp.WithOpenWorldHintAnnotation(false),
+			mcp.WithString("chat_jid",
+				mcp.Required(),
+				mcp.Description("chat JID to watch"),
+			),
+		),
+		m.handleWatchChat,
+	)
+
+	// 19. stop watching chats
+	m.addTool(
+		mcp.NewTool("unwatch_chat",
+			mcp.WithDescription("Stop watching a chat in this session. Omit chat_jid to stop watching all chats."),
+			mcp.WithReadOnlyHintAnnotation(false),
+			mcp.WithDestructiveHintAnnotation(false),
+			mcp.WithIdempotentHintAnnotation(true),
+			mcp.WithOpenWorldHintAnnotation(false),
+			mcp.WithString("chat_jid",
+				mcp.Description("chat JID to stop watching"),
+			),
+		),
+		m.handleUnwatchChat,
+	)
+
+	// 20. fetch buffered messages from watched chats
+	m.addTool(
+		mcp.NewTool("poll_updates",
+			mcp.WithDescription("Return new messages received in chats watched by this session since the last poll (oldest first)."),
+			mcp.WithReadOnlyHintAnnotation(false),
+			mcp.WithDestructiveHintAnnotation(false),
+			mcp.WithIdempotentHintAnnotation(false),
+			mcp.WithOpenWorldHintAnnotation(false),
+			mcp.WithNumber("limit",
+				mcp.Description("maximum number of messages to return (default: 100, max: 500)"),
+			),
+			mcp.WithOutputSchema[messageListOutput](),
+		),
+		m.handlePollUpdates,
+	)
+
+	// 21. list shared links
+	m.addTool(
+		mcp.NewTool("get_links",
+			mcp.WithDescription("List messages containing links (URLs) in a chat or across all chats, newest first, with the page title from the link preview when available."),
+			mcp.WithReadOnlyHintAnnotation(true),
+			mcp.WithDestructiveHintAnnotation(false),
+			mcp.WithIdempotentHintAnnotation(true),
+			mcp.WithOpenWorldHintAnnotation(false),
+			mcp.WithString("chat_jid",
+				mcp.Description("only links from this chat (omit to search all chats)"),
+			),
+			mcp.WithNumber("limit",
+				mcp.Description("maximum number of messages to return (default: 50, max: 200)"),
+			),
+		),
+		m.handleGetLinks,
+	)
+
+	// 22. transcribe a voice note on demand
+	m.addTool(
+		mcp.NewTool("transcribe_message",
+			mcp.WithDescription("Transcribe a downloaded voice note or audio message and store the transcript so search_messages can match it. Requires TRANSCRIPTION_URL or TRANSCRIPTION_COMMAND."),
+			mcp.WithReadOnlyHintAnnotation(false),
+			mcp.WithDestructiveHintAnnotation(false),
+			mcp.WithIdempotentHintAnnotation(true),
+			mcp.WithOpenWorldHintAnnotation(true),
+			mcp.WithString("message_id",
+				mcp.Required(),
+				mcp.Description("ID of an audio/voice note message"),
+			),
+		),
+		m.handleTranscribeMessage,
+	)
+
+	// 23. reply thread of a message
+	m.addTool(
+		mcp.NewTool("get_thread",
+			mcp.WithDescription("Get the reply thread of a message: the chain of quoted messages it replies to and every reply in the thread, oldest first. Use it to follow a conversation inside a busy group."),
+			mcp.WithReadOnlyHintAnnotation(true),
+			mcp.WithDestructiveHintAnnotation(false),
+			mcp.WithIdempotentHintAnnotation(true),
+			mcp.WithOpenWorldHintAnnotation(false),
+			mcp.WithString("message_id",
+				mcp.Required(),
+				mcp.Description("ID of any message in the thread"),
+			),
+			mcp.WithNumber("limit",
+				mcp.Description("maximum number of messages to return (default: 50, max: 200)"),
+			),
+			mcp.WithOutputSchema[messageListOutput](),
+		),
+		m.handleGetThread,
+	)
+
+	// 24. group members
+	m.addTool(
+		mcp.NewTool("get_group_participants",
+			mcp.WithDescription("List the members of a group with their names and admin status. Reads the locally synced list, so it works while offline once the group was synced."),
+			mcp.WithReadOnlyHintAnnotation(true),
+			mcp.WithDestructiveHintAnnotation(false),
+			mcp.WithIdempotentHintAnnotation(true),
+			mcp.WithOpenWorldHintAnnotation(false),
+			mcp.WithString("group_jid",
+				mcp.Required(),
+				mcp.Description("group JID (ends with @g.us) from find_chat or list_chats"),
+			),
+			mcp.WithOutputSchema[groupParticipantListOutput](),
+		),
+		m.handleGetGroupParticipants,
+	)
+
+	// 25. aggregated activity
+	m.addTool(
+		mcp.NewTool("get_activity_report",
+			mcp.WithDescription("Summarize messaging activity over a period: messages per day, busiest chats, top senders, media counts and your reply times in direct chats. Prefer it over reading raw messages to answer \"how active\" or \"who writes most\" questions."),
+			mcp.WithReadOnlyHintAnnotation(true),
+			mcp.WithDestructiveHintAnnotation(false),
+			mcp.WithIdempotentHintAnnotation(true),
+			mcp.WithOpenWorldHintAnnotation(false),
+			mcp.WithString("period",
+				mcp.Description("period ending now, in the configured timezone: today, week (last 7 days), month (last 30 days) or year (last 365 days). Default: week"),
+				mcp.Enum("today", "week", "month", "year"),
+			),
+			mcp.WithString("chat_jid",
+				mcp.Description("only report on this chat (omit for all chats)"),
+			),
+			mcp.WithNumber("limit",
+				mcp.Description("maximum number of chats and senders to list (default: 10, max: 50)"),
+			),
+		),
+		m.handleGetActivityReport,
+	)
+
+	// 26. tag a chat or message
+	m.addTool(
+		mcp.NewTool("add_tag",
+			mcp.WithDescription("Tag a chat or a message with a local label such as \"client\" or \"follow-up\". Tags are created on first use; filter list_chats and search_messages by tag. WhatsApp Business labels show up as tags too."),
+			mcp.WithReadOnlyHintAnnotation(false),
+			mcp.WithDestructiveHintAnnotation(false),
+			mcp.WithIdempotentHintAnnotation(true),
+			mcp.WithOpenWorldHintAnnotation(false),
+			mcp.WithString("tag",
+				mcp.Required(),
+				mcp.Description("tag name (case-insensitive)"),
+			),
+			mcp.WithString("chat_jid",
+				mcp.Description("chat to tag (give either chat_jid or message_id)"),
+			),
+			mcp.WithString("message_id",
+				mcp.Description("message to tag (give either chat_jid or message_id)"),
+			),
+		),
+		m.handleAddTag,
+	)
+
+	// 27. untag a chat or message
+	m.addTool(
+		mcp.NewTool("remove_tag",
+			mcp.WithDescription("Remove a tag from a chat or a message."),
+			mcp.WithReadOnlyHintAnnotation(false),
+			mcp.WithDestructiveHintAnnotation(true),
+			mcp.WithIdempotentHintAnnotation(true),
+			mcp.WithOpenWorldHintAnnotation(false),
+			mcp.WithString("tag",
+				mcp.Required(),
+				mcp.Description("tag name (case-insensitive)"),
+			),
+			mcp.WithString("chat_jid",
+				mcp.Description("chat to untag (give either chat_jid or message_id)"),
+			),
+			mcp.WithString("message_id",
+				mcp.Description("message to untag (give either chat_jid or message_id)"),
+			),
+		),
+		m.handleRemoveTag,
+	)
+
+	// 28. list tags
+	m.addTool(
+		mcp.NewTool("list_tags",
+			mcp.WithDescription("List all tags with the number of chats and messages that have them."),
+			mcp.WithReadOnlyHintAnnotation(true),
+			mcp.WithDestructiveHintAnnotation(false),
+			mcp.WithIdempotentHintAnnotation(true),
+			mcp.WithOpenWorldHintAnnotation(false),
+		),
+		m.handleListTags,
+	)
+
+	// 29. note on a contact
+	m.addTool(
+		mcp.NewTool("add_contact_note",
+			mcp.WithDescription("Attach a free-form local note to a contact (e.g., \"met at conference, prefers email\"). Notes are never sent to WhatsApp and show up in the whatsapp://contact/{jid} card."),
+			mcp.WithReadOnlyHintAnnotation(false),
+			mcp.WithDestructiveHintAnnotation(false),
+			mcp.WithIdempotentHintAnnotation(false),
+			mcp.WithOpenWorldHintAnnotation(false),
+			mcp.WithString("jid",
+				mcp.Required(),
+				mcp.Description("contact JID"),
+			),
+			mcp.WithString("note",
+				mcp.Required(),
+				mcp.Description("note text"),
+			),
+		),
+		m.handleAddContactNote,
+	)
+
+	// 30. notes on a contact
+	m.addTool(
+		mcp.NewTool("get_contact_notes",
+			mcp.WithDescription("Get the local notes on a contact, oldest first, with their IDs."),
+			mcp.WithReadOnlyHintAnnotation(true),
+			mcp.WithDestructiveHintAnnotation(false),
+			mcp.WithIdempotentHintAnnotation(true),
+			mcp.WithOpenWorldHintAnnotation(false),
+			mcp.WithString("jid",
+				mcp.Required(),
+				mcp.Description("contact JID"),
+			),
+		),
+		m.handleGetContactNotes,
+	)
+
+	// 31. delete a note
+	m.addTool(
+		mcp.NewTool("delete_contact_note",
+			mcp.WithDescription("Delete a contact note by ID (from get_contact_notes)."),
+			mcp.WithReadOnlyHintAnnotation(false),
+			mcp.WithDestructiveHintAnnotation(true),
+			mcp.WithIdempotentHintAnnotation(true),
+			mcp.WithOpenWorldHintAnnotation(false),
+			mcp.WithNumber("note_id",
+				mcp.Required(),
+				mcp.Description("ID of the note to delete"),
+			),
+		),
+		m.handleDeleteContactNote,
+	)
+
+	// 32. mark chat read
+	m.addTool(
+		mcp.NewTool("mark_chat_read",
+			mcp.WithDescription("Mark a chat as read: sends read receipts for its unread messages (senders will see blue ticks if they have read receipts on) and clears \"marked unread\"."),
+			mcp.WithReadOnlyHintAnnotation(false),
+			mcp.WithDestructiveHintAnnotation(false),
+			mcp.WithIdempotentHintAnnotation(true),
+			mcp.WithOpenWorldHintAnnotation(true),
+			mcp.WithString("chat_jid",
+				mcp.Required(),
+				mcp.Description("chat JID"),
+			),
+		),
+		m.handleMarkChatRead,
+	)
+
+	// 33. list incoming calls
+	m.addTool(
+		mcp.NewTool("list_calls",
+			mcp.WithDescription("List incoming voice and video calls, newest first, with who called and whether the call was answered, rejected or missed."),
+			mcp.WithReadOnlyHintAnnotation(true),
+			mcp.WithDestructiveHintAnnotation(false),
+			mcp.WithIdempotentHintAnnotation(true),
+			mcp.WithOpenWorldHintAnnotation(false),
+			mcp.WithString("chat_jid",
+				mcp.Description("only calls from this contact or group (omit for all calls)"),
+			),
+			mcp.WithBoolean("missed_only",
+				mcp.Description("only missed calls (default: false)"),
+			),
+			mcp.WithNumber("limit",
+				mcp.Description("maximum number of calls to return (default: 50, max: 200)"),
+			),
+		),
+		m.handleListCalls,
+	)
+
+	// 34. set own presence
+	m.addTool(
+		mcp.NewTool("set_presence",
+			mcp.WithDescription("Set whether you appear online (available) or offline (unavailable) to your contacts. While offline, WhatsApp may not send you others' presence either. PRESENCE_MODE decides what is sent automatically on connect and when sending."),
+			mcp.WithReadOnlyHintAnnotation(false),
+			mcp.WithDestructiveHintAnnotation(false),
+			mcp.WithIdempotentHintAnnotation(true),
+			mcp.WithOpenWorldHintAnnotation(true),
+			mcp.WithString("state",
+				mcp.Required(),
+				mcp.Description("available (online) or unavailable (offline)"),
+				mcp.Enum("available", "unavailable"),
+			),
+		),
+		m.handleSetPresence,
+	)
+
+	// 35. poll results
+	m.addTool(
+		mcp.NewTool("get_poll_results",
+			mcp.WithDescription("Get the live results of a poll: the votes for each option and who cast them. Use the message ID of the poll message (message type \"poll\")."),
+			mcp.WithReadOnlyHintAnnotation(true),
+			mcp.WithDestructiveHintAnnotation(false),
+			mcp.WithIdempotentHintAnnotation(true),
+			mcp.WithOpenWorldHintAnnotation(false),
+			mcp.WithString("message_id",
+				mcp.Required(),
+				mcp.Description("ID of the poll message"),
+			),
+		),
+		m.handleGetPollResults,
+	)
+
+	// 36. media download queue
+	m.addTool(
+		mcp.NewTool("get_download_queue",
+			mcp.WithDescription("Show the background media downloads: how many are queued or in progress, totals since startup and the most recent failures."),
+			mcp.WithReadOnlyHintAnnotation(true),
+			mcp.WithDestructiveHintAnnotation(false),
+			mcp.WithIdempotentHintAnnotation(true),
+			mcp.WithOpenWorldHintAnnotation(false),
+		),
+		m.handleGetDownloadQueue,
+	)
+
+	// 37. send voice note
+	m.addTool(
+		mcp.NewTool("send_voice_note",
+			mcp.WithDescription("Send an audio file as a voice note. mp3, m4a, wav, ogg and other formats are converted to ogg/opus with ffmpeg, which must be installed on the server."),
+			mcp.WithReadOnlyHintAnnotation(false),
+			mcp.WithDestructiveHintAnnotation(true),
+			mcp.WithIdempotentHintAnnotation(false),
+			mcp.WithOpenWorldHintAnnotation(true),
+			mcp.WithString("chat_jid",
+				mcp.Required(),
+				mcp.Description("recipient chat JID from find_chat or list_chats"),
+			),
+			mcp.WithString("file_path",
+				mcp.Required(),
+				mcp.Description("path of the audio file in the server's upload directory (MEDIA_UPLOAD_DIR, data/uploads by default), relative to it or absolute"),
+			),
+		),
+		m.handleSendVoiceNote,
+	)
+
+	// 38. send video
+	m.addTool(
+		mcp.NewTool("send_video",
+			mcp.WithDescription("Send a video file with an optional caption. The preview, duration and size are filled in with ffmpeg/ffprobe; videos that aren't mp4 or are large are re-encoded to a smaller mp4."),
+			mcp.WithReadOnlyHintAnnotation(false),
+			mcp.WithDestructiveHintAnnotation(true),
+			mcp.WithIdempotentHintAnnotation(false),
+			mcp.WithOpenWorldHintAnnotation(true),
+			mcp.WithString("chat_jid",
+				mcp.Required(),
+				mcp.Description("recipient chat JID from find_chat or list_chats"),
+			),
+			mcp.WithString("file_path",
+				mcp.Required(),
+				mcp.Description("path of the video file in the server's upload directory (MEDIA_UPLOAD_DIR, data/uploads by default), relative to it or absolute"),
+			),
+			mcp.WithString("caption",
+				mcp.Description("optional caption shown below the video"),
+			),
+		),
+		m.handleSendVideo,
+	)
+
+	// 39. status updates of contacts
+	m.addTool(
+		mcp.NewTool("list_statuses",
+			mcp.WithDescription("List the Status updates (stories) your contacts posted, newest first: text, image and video statuses with their caption and when they expire. Statuses are shown for 24 hours; expired ones stay stored."),
+			mcp.WithReadOnlyHintAnnotation(true),
+			mcp.WithDestructiveHintAnnotation(false),
+			mcp.WithIdempotentHintAnnotation(true),
+			mcp.WithOpenWorldHintAnnotation(false),
+			mcp.WithString("sender_jid",
+				mcp.Description("only statuses of this contact (omit for all contacts)"),
+			),
+			mcp.WithBoolean("include_expired",
+				mcp.Description("also list statuses older than 24 hours (default: false)"),
+			),
+			mcp.WithNumber("limit",
+				mcp.Description("maximum number of statuses to return (default: 50, max: 200)"),
+			),
+		),
+		m.handleListStatuses,
+	)
+
+	// 40. media of a status update
+	m.addTool(
+		mcp.NewTool("get_status_media",
+			mcp.WithDescription("Get the image or video of a Status update from list_statuses, downloading it if needed. Images are returned inline, videos as their preview with a whatsapp://media resource. Status media can usually only be downloaded while the status is shown."),
+			mcp.WithReadOnlyHintAnnotation(true),
+			mcp.WithDestructiveHintAnnotation(false),
+			mcp.WithIdempotentHintAnnotation(true),
+			mcp.WithOpenWorldHintAnnotation(true),
+			mcp.WithString("message_id",
+				mcp.Required(),
+				mcp.Description("message ID of the status from list_statuses"),
+			),
+		),
+		m.handleGetStatusMedia,
+	)
+
+	// 41. full contact sync
+	m.addTool(
+		mcp.NewTool("sync_contacts",
+			mcp.WithDescription("Fetch your complete contact list from WhatsApp again and store it, naming the chats of saved contacts. Use it after a fresh install, when most chats show only phone numbers."),
+			mcp.WithReadOnlyHintAnnotation(false),
+			mcp.WithDestructiveHintAnnotation(false),
+			mcp.WithIdempotentHintAnnotation(true),
+			mcp.WithOpenWorldHintAnnotation(true),
+		),
+		m.handleSyncContacts,
+	)
+
+	// 42. login and connection state
+	m.addTool(
+		mcp.NewTool("auth_status",
+			mcp.WithDescription("Report whether WhatsApp is logged in and connected: account JID and phone, linked device platform, last connect time, reconnect attempts, whether a QR code is waiting to be scanned, and history sync progress. Use it to explain why other tools fail."),
+			mcp.WithReadOnlyHintAnnotation(true),
+			mcp.WithDestructiveHintAnnotation(false),
+			mcp.WithIdempotentHintAnnotation(true),
+			mcp.WithOpenWorldHintAnnotation(false),
+		),
+		m.handleAuthStatus,
+	)
+
+	// 43. deep history backfill of a chat
+	m.addTool(
+		mcp.NewTool("backfill_chat",
+			mcp.WithDescription("Load a chat's older history from WhatsApp page by page in the background, until a date or message count is reached, instead of calling load_more_messages over and over. Progress is saved and resumes after a restart. With wait=true, reports progress until done. Call again with cancel=true to stop, or see list_backfills."),
+			mcp.WithReadOnlyHintAnnotation(false),
+			mcp.WithDestructiveHintAnnotation(false),
+			mcp.WithIdempotentHintAnnotation(false),
+			mcp.WithOpenWorldHintAnnotation(true),
+			mcp.WithString("chat_jid",
+				mcp.Required(),
+				mcp.Description("chat JID to backfill"),
+			),
+			mcp.WithString("until",
+				mcp.Description("go back until messages are older than this date (ISO 8601, e.g. 2025-01-01)"),
+			),
+			mcp.WithNumber("count",
+				mcp.Description("stop after fetching this many messages (max: 10000)"),
+			),
+			mcp.WithBoolean("wait",
+				mcp.Description("if true, waits and reports progress until the backfill ends (default: false, returns right away)"),
+			),
+			mcp.WithBoolean("cancel",
+				mcp.Description("stop the chat's running backfill instead of starting one"),
+			),
+		),
+		m.handleBackfillChat,
+	)
+
+	// 44. backfill progress
+	m.addTool(
+		mcp.NewTool("list_backfills",
+			mcp.WithDescription("List chat history backfills started with backfill_chat, with their target, progress and status (running, done, failed or cancelled)."),
+			mcp.WithReadOnlyHintAnnotation(true),
+			mcp.WithDestructiveHintAnnotation(false),
+			mcp.WithIdempotentHintAnnotation(true),
+			mcp.WithOpenWorldHintAnnotation(false),
+			mcp.WithString("status",
+				mcp.Description("only list backfills in this state"),
+				mcp.Enum("running", "done", "failed", "cancelled"),
+			),
+		),
+		m.handleListBackfills,
+	)
+
+	// 45. log out and pair again
+	m.addAdminTool(
+		mcp.NewTool("logout",
+			mcp.WithDescription("Unlink this device from the WhatsApp account, delete its keys and start pairing a new device without restarting the server. The new QR code is shown at /qr in a browser, and the QR code or pairing code is also served at GET /api/auth/pairing and printed to the server logs. Stored messages are kept. Requires the admin API key."),
+			mcp.WithReadOnlyHintAnnotation(false),
+			mcp.WithDestructiveHintAnnotation(true),
+			mcp.WithIdempotentHintAnnotation(false),
+			mcp.WithOpenWorldHintAnnotation(true),
+			mcp.WithString("phone",
+				mcp.Description("phone number to pair with a pairing code instead of a QR code, e.g. +5511999999999"),
+			),
+		),
+		m.handleLogout,
+	)
+
+	// webhook administration, only for requests with the admin API key
+	if m.webhooks == nil {
+		return
+	}
+
+	// 46. register a webhook
+	m.addAdminTool(
+		mcp.NewTool("register_webhook",
+			mcp.WithDescription("Register a webhook that receives WhatsApp events as HTTP POSTs. Requires the admin API key. Client certificates, OAuth and custom headers can only be set through the /api/webhooks REST API."),
+			mcp.WithReadOnlyHintAnnotation(false),
+			mcp.WithDestructiveHintAnnotation(false),
+			mcp.WithIdempotentHintAnnotation(false),
+			mcp.WithOpenWorldHintAnnotation(true),
+			mcp.WithString("url",
+				mcp.Required(),
+				mcp.Description("http or https URL events are POSTed to"),
+			),
+			mcp.WithArray("event_types",
+				mcp.Description("event types to receive, e.g. message, message.edited, group.created, call.missed (default: message)"),
+				mcp.WithStringItems(),
+			),
+			mcp.WithString("secret",
+				mcp.Description("secret for the X-Webhook-Signature HMAC of each delivery (recommended)"),
+			),
+			mcp.WithArray("fields",
+				mcp.Description("only send these data fields, e.g. chat_jid, text (omit for all)"),
+				mcp.WithStringItems(),
+			),
+			mcp.WithString("payload_format",
+				mcp.Description("native (default) or cloudevents"),
+				mcp.Enum("native", "cloudevents"),
+			),
+			mcp.WithString("media_delivery",
+				mcp.Description("include media content in message events: base64 (inline), url (signed link) or thumbnail (inline JPEG preview only). Omit to send metadata only"),
+				mcp.Enum("base64", "url", "thumbnail"),
+			),
+		),
+		m.handleRegisterWebhook,
+	)
+
+	// 47. list webhooks
+	m.addAdminTool(
+		mcp.NewTool("list_webhooks",
+			mcp.WithDescription("List registered webhooks with their IDs, event types and delivery settings. Requires the admin API key."),
+			mcp.WithReadOnlyHintAnnotation(true),
+			mcp.WithDestructiveHintAnnotation(false),
+			mcp.WithIdempotentHintAnnotation(true),
+			mcp.WithOpenWorldHintAnnotation(false),
+		),
+		m.handleListWebhooks,
+	)
+
+	// 48. delete a webhook
+	m.addAdminTool(
+		mcp.NewTool("delete_webhook",
+			mcp.WithDescription("Delete a webhook by ID (from list_webhooks). It stops receiving events right away. Requires the admin API key."),
+			mcp.WithReadOnlyHintAnnotation(false),
+			mcp.WithDestructiveHintAnnotation(true),
+			mcp.WithIdempotentHintAnnotation(true),
+			mcp.WithOpenWorldHintAnnotation(false),
+			mcp.WithString("webhook_id",
+				mcp.Required(),
+				mcp.Description("ID of the webhook to delete"),
+			),
+		),
+		m.handleDeleteWebhook,
+	)
+
+	// 49. send a test event
+	m.addAdminTool(
+		mcp.NewTool("test_webhook",
+			mcp.WithDescription("Send a sample message.received event to a webhook and report whether it was accepted. Requires the admin API key."),
+			mcp.WithReadOnlyHintAnnotation(false),
+			mcp.WithDestructiveHintAnnotation(false),
+			mcp.WithIdempotentHintAnnotation(false),
+			mcp.WithOpenWorldHintAnnotation(true),
+			mcp.WithString("webhook_id",
+				mcp.Required(),
+				mcp.Description("ID of the webhook to test"),
+			),
+		),
+		m.handleTestWebhook,
+	)
 }
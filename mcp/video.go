@@ -0,0 +1,35 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// handleSendVideo handles the send_video tool request.
+func (m *MCPServer) handleSendVideo(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	chatJID, err := request.RequireString("chat_jid")
+	if err != nil {
+		return mcp.NewToolResultError("chat_jid parameter is required"), nil
+	}
+
+	filePath, err := request.RequireString("file_path")
+	if err != nil {
+		return mcp.NewToolResultError("file_path parameter is required"), nil
+	}
+
+	caption := request.GetString("caption", "")
+
+	// check WhatsApp connection
+	if err := m.wa.ConnectionError(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	sent, err := m.wa.SendVideo(ctx, chatJID, filePath, caption)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to send video: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(fmt.Sprintf("Video sent successfully to %s (ID: %s)", chatJID, sent.ID)), nil
+}
@@ -0,0 +1,33 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// handleSendVoiceNote handles the send_voice_note tool request.
+func (m *MCPServer) handleSendVoiceNote(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	chatJID, err := request.RequireString("chat_jid")
+	if err != nil {
+		return mcp.NewToolResultError("chat_jid parameter is required"), nil
+	}
+
+	filePath, err := request.RequireString("file_path")
+	if err != nil {
+		return mcp.NewToolResultError("file_path parameter is required"), nil
+	}
+
+	// check WhatsApp connection
+	if err := m.wa.ConnectionError(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	sent, err := m.wa.SendVoiceNote(ctx, chatJID, filePath)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to send voice note: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(fmt.Sprintf("Voice note sent successfully to %s (ID: %s)", chatJID, sent.ID)), nil
+}
@@ -0,0 +1,193 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"whatsapp-mcp/storage"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// maxWatchBuffer is the maximum number of buffered messages per session.
+// Older messages are dropped when a session does not poll often enough.
+const maxWatchBuffer = 500
+
+// watchNotificationMethod is the notification sent to sessions for new messages in watched chats.
+const watchNotificationMethod = "notifications/whatsapp/message"
+
+// watchSession holds the watched chats and undelivered messages of an MCP session.
+type watchSession struct {
+	chats   map[string]bool
+	buffer  []storage.MessageWithNames
+	dropped int // messages dropped since the last poll because the buffer was full
+}
+
+// sessionID returns the MCP session ID of the request, or empty if there is none.
+func sessionID(ctx context.Context) string {
+	if session := server.ClientSessionFromContext(ctx); session != nil {
+		return session.SessionID()
+	}
+	return ""
+}
+
+// onNewMessage buffers a new message for every session watching its chat and notifies them.
+func (m *MCPServer) onNewMessage(msg storage.MessageWithNames) {
+	var notify []string
+
+	m.watchMu.Lock()
+	for sid, ws := range m.watches {
+		if !ws.chats[msg.ChatJID] {
+			continue
+		}
+		ws.buffer = append(ws.buffer, msg)
+		if len(ws.buffer) > maxWatchBuffer {
+			ws.dropped += len(ws.buffer) - maxWatchBuffer
+			ws.buffer = ws.buffer[len(ws.buffer)-maxWatchBuffer:]
+		}
+		notify = append(notify, sid)
+	}
+	m.watchMu.Unlock()
+
+	if len(notify) == 0 {
+		return
+	}
+
+	params := map[string]any{
+		"chat_jid": msg.ChatJID,
+		"message":  m.toMessageOutput(msg),
+	}
+	for _, sid := range notify {
+		// best effort: messages stay buffered for poll_updates either way
+		if err := m.server.SendNotificationToSpecificClient(sid, watchNotificationMethod, params); err != nil {
+			m.log.Printf("Failed to notify session %s of new message: %v", sid, err)
+		}
+	}
+}
+
+// onSessionClosed forgets the watches of a closed session.
+func (m *MCPServer) onSessionClosed(ctx context.Context, session server.ClientSession) {
+	m.watchMu.Lock()
+	delete(m.watches, session.SessionID())
+	m.watchMu.Unlock()
+}
+
+// handleWatchChat handles the watch_chat tool request.
+func (m *MCPServer) handleWatchChat(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	chatJID, err := request.RequireString("chat_jid")
+	if err != nil {
+		return mcp.NewToolResultError("chat_jid parameter is required"), nil
+	}
+	chatJID = m.canonicalJID(chatJID)
+
+	sid := sessionID(ctx)
+	if sid == "" {
+		return mcp.NewToolResultError("watch_chat requires an MCP session"), nil
+	}
+
+	m.watchMu.Lock()
+	ws, ok := m.watches[sid]
+	if !ok {
+		ws = &watchSession{chats: make(map[string]bool)}
+		m.watches[sid] = ws
+	}
+	ws.chats[chatJID] = true
+	count := len(ws.chats)
+	m.watchMu.Unlock()
+
+	return mcp.NewToolResultText(fmt.Sprintf(
+		"Watching %s (%d chats watched by this session). New messages are sent as %s notifications and buffered for poll_updates.",
+		chatJID, count, watchNotificationMethod)), nil
+}
+
+// handleUnwatchChat handles the unwatch_chat tool request.
+func (m *MCPServer) handleUnwatchChat(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	chatJID := m.canonicalJID(request.GetString("chat_jid", ""))
+
+	sid := sessionID(ctx)
+	if sid == "" {
+		return mcp.NewToolResultError("unwatch_chat requires an MCP session"), nil
+	}
+
+	m.watchMu.Lock()
+	defer m.watchMu.Unlock()
+
+	ws, ok := m.watches[sid]
+	if !ok {
+		return mcp.NewToolResultText("This session is not watching any chats"), nil
+	}
+
+	// no chat_jid stops watching everything
+	if chatJID == "" {
+		delete(m.watches, sid)
+		return mcp.NewToolResultText("Stopped watching all chats"), nil
+	}
+
+	delete(ws.chats, chatJID)
+	if len(ws.chats) == 0 {
+		delete(m.watches, sid)
+	}
+
+	return mcp.NewToolResultText(fmt.Sprintf("Stopped watching %s", chatJID)), nil
+}
+
+// handlePollUpdates handles the poll_updates tool request.
+func (m *MCPServer) handlePollUpdates(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	limit := int(request.GetFloat("limit", 100.0))
+	if limit <= 0 || limit > maxWatchBuffer {
+		limit = maxWatchBuffer
+	}
+
+	sid := sessionID(ctx)
+	if sid == "" {
+		return mcp.NewToolResultError("poll_updates requires an MCP session"), nil
+	}
+
+	m.watchMu.Lock()
+	ws, ok := m.watches[sid]
+	if !ok {
+		m.watchMu.Unlock()
+		return mcp.NewToolResultError("this session is not watching any chats, call watch_chat first"), nil
+	}
+
+	// take the oldest buffered messages, leaving the rest for the next poll
+	n := min(limit, len(ws.buffer))
+	messages := make([]storage.MessageWithNames, n)
+	copy(messages, ws.buffer[:n])
+	ws.buffer = ws.buffer[n:]
+	remaining := len(ws.buffer)
+	dropped := ws.dropped
+	ws.dropped = 0
+
+	chats := make([]string, 0, len(ws.chats))
+	for jid := range ws.chats {
+		chats = append(chats, jid)
+	}
+	m.watchMu.Unlock()
+
+	sort.Strings(chats)
+
+	var result strings.Builder
+	fmt.Fprintf(&result, "%d new messages in watched chats (%s)", len(messages), strings.Join(chats, ", "))
+	if remaining > 0 {
+		fmt.Fprintf(&result, ", %d more pending", remaining)
+	}
+	if dropped > 0 {
+		fmt.Fprintf(&result, ", %d dropped (buffer full)", dropped)
+	}
+	result.WriteString(":\n\n")
+
+	for _, msg := range messages {
+		sender := getSenderDisplayName(msg)
+		if msg.IsFromMe {
+			sender = "You"
+		}
+		fmt.Fprintf(&result, "[%s] %s in %s: %s\n", m.formatDateTime(msg.Timestamp), sender, msg.ChatName, msg.Text)
+		fmt.Fprintf(&result, "   ID: %s\n", msg.ID)
+	}
+
+	return mcp.NewToolResultStructured(m.toMessageListOutput("", messages), result.String()), nil
+}
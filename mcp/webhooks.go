@@ -0,0 +1,110 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"whatsapp-mcp/webhook"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// handleRegisterWebhook handles the register_webhook tool request.
+func (m *MCPServer) handleRegisterWebhook(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	url, err := request.RequireString("url")
+	if err != nil {
+		return mcp.NewToolResultError("url parameter is required"), nil
+	}
+
+	wh, err := m.webhooks.Register(webhook.CreateWebhookRequest{
+		URL:        url,
+		Secret:     request.GetString("secret", ""),
+		EventTypes: request.GetStringSlice("event_types", nil),
+		Fields:     request.GetStringSlice("fields", nil),
+		Format:     request.GetString("payload_format", ""),
+		Media:      request.GetString("media_delivery", ""),
+	})
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to register webhook: %v", err)), nil
+	}
+
+	var result strings.Builder
+	result.WriteString("Webhook registered:\n\n")
+	writeWebhook(&result, *wh)
+	result.WriteString("\nUse test_webhook to send it a test event.")
+
+	return mcp.NewToolResultText(result.String()), nil
+}
+
+// handleListWebhooks handles the list_webhooks tool request.
+func (m *MCPServer) handleListWebhooks(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	webhooks, err := m.webhooks.Webhooks()
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to list webhooks: %v", err)), nil
+	}
+
+	var result strings.Builder
+	fmt.Fprintf(&result, "Found %d webhooks:\n\n", len(webhooks))
+	for i, wh := range webhooks {
+		fmt.Fprintf(&result, "%d. ", i+1)
+		writeWebhook(&result, wh)
+		result.WriteString("\n")
+	}
+
+	return mcp.NewToolResultText(result.String()), nil
+}
+
+// handleDeleteWebhook handles the delete_webhook tool request.
+func (m *MCPServer) handleDeleteWebhook(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	webhookID, err := request.RequireString("webhook_id")
+	if err != nil {
+		return mcp.NewToolResultError("webhook_id parameter is required"), nil
+	}
+
+	if err := m.webhooks.Unregister(webhookID); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to delete webhook: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(fmt.Sprintf("Deleted webhook %s", webhookID)), nil
+}
+
+// handleTestWebhook handles the test_webhook tool request.
+func (m *MCPServer) handleTestWebhook(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	webhookID, err := request.RequireString("webhook_id")
+	if err != nil {
+		return mcp.NewToolResultError("webhook_id parameter is required"), nil
+	}
+
+	payloadID, err := m.webhooks.SendTestEvent(webhookID)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("test delivery failed: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(fmt.Sprintf("Test event %s delivered to webhook %s", payloadID, webhookID)), nil
+}
+
+// writeWebhook writes a webhook's settings, one per line.
+func writeWebhook(result *strings.Builder, wh webhook.WebhookResponse) {
+	status := "active"
+	if !wh.Active {
+		status = "inactive"
+	}
+
+	fmt.Fprintf(result, "%s (%s)\n", wh.URL, status)
+	fmt.Fprintf(result, "   ID: %s\n", wh.ID)
+	fmt.Fprintf(result, "   Events: %s\n", strings.Join(wh.EventTypes, ", "))
+	if len(wh.Fields) > 0 {
+		fmt.Fprintf(result, "   Fields: %s\n", strings.Join(wh.Fields, ", "))
+	}
+	fmt.Fprintf(result, "   Payload format: %s\n", wh.Format)
+	if wh.Media != "" {
+		fmt.Fprintf(result, "   Media delivery: %s\n", wh.Media)
+	}
+	if wh.OAuth != nil {
+		fmt.Fprintf(result, "   OAuth: client %s at %s\n", wh.OAuth.ClientID, wh.OAuth.TokenURL)
+	}
+	if len(wh.Headers) > 0 {
+		fmt.Fprintf(result, "   Custom headers: %s\n", strings.Join(wh.Headers, ", "))
+	}
+}
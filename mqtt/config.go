@@ -0,0 +1,37 @@
+package mqtt
+
+import (
+	"strings"
+	"whatsapp-mcp/config"
+)
+
+// Config holds the MQTT publisher configuration.
+type Config struct {
+	URL           string   // mqtt://[user:pass@]host:port or mqtts:// for TLS, empty disables publishing
+	ClientID      string   // MQTT client identifier
+	TopicTemplate string   // topic with {chat_jid} and {event_type} placeholders
+	EventTypes    []string // event types published, e.g. message.received
+	QoS           byte     // 0 (at most once) or 1 (at least once)
+	Retain        bool     // ask the broker to keep the last message of each topic
+	QueueSize     int      // events buffered while disconnected
+}
+
+// LoadConfig loads MQTT configuration from environment variables.
+func LoadConfig() *Config {
+	cfg := &Config{
+		URL:           config.GetEnv("MQTT_URL", ""),
+		ClientID:      config.GetEnv("MQTT_CLIENT_ID", "whatsapp-mcp"),
+		TopicTemplate: config.GetEnv("MQTT_TOPIC", "whatsapp/{chat_jid}/{event_type}"),
+		Retain:        config.GetEnvBool("MQTT_RETAIN", false),
+		QueueSize:     config.GetEnvInt("MQTT_QUEUE_SIZE", 1000),
+	}
+	if config.GetEnvInt("MQTT_QOS", 0) > 0 {
+		cfg.QoS = 1
+	}
+	for _, eventType := range strings.Split(config.GetEnv("MQTT_EVENT_TYPES", "message.received"), ",") {
+		if eventType = strings.TrimSpace(eventType); eventType != "" {
+			cfg.EventTypes = append(cfg.EventTypes, eventType)
+		}
+	}
+	return cfg
+}
@@ -0,0 +1,205 @@
+// Package mqtt publishes webhook events to an MQTT broker, so home automation
+// setups such as Home Assistant or Node-RED can react to WhatsApp messages
+// without running an HTTP receiver.
+//
+// Events of the configured types are published as their webhook JSON payload
+// to a topic built from a template, by default
+// "whatsapp/{chat_jid}/{event_type}". The publisher uses the Eclipse Paho
+// client (MQTT 3.1.1) with QoS 0 or 1, which reconnects when the connection
+// drops; events are queued in memory meanwhile and dropped when the queue is
+// full or on shutdown.
+package mqtt
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+
+	"whatsapp-mcp/webhook"
+)
+
+const (
+	keepAlive        = 60 * time.Second
+	dialTimeout      = 10 * time.Second
+	ackTimeout       = 10 * time.Second
+	maxReconnectWait = 30 * time.Second
+	disconnectWait   = 250 // milliseconds to finish sending on Stop
+	maxBatch         = 100
+)
+
+// errNotConnected is returned while the client is reconnecting.
+var errNotConnected = errors.New("not connected")
+
+// Publisher publishes events to MQTT. It implements webhook.Publisher.
+type Publisher struct {
+	config *Config
+	log    webhook.Logger
+	queue  *webhook.PublishQueue
+	client paho.Client
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewPublisher creates a new MQTT publisher.
+func NewPublisher(config *Config, logger webhook.Logger) *Publisher {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &Publisher{
+		config: config,
+		log:    logger,
+		queue:  webhook.NewPublishQueue("MQTT", config.QueueSize, logger),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// Enabled reports whether a broker is configured.
+func (p *Publisher) Enabled() bool {
+	return p.config.URL != ""
+}
+
+// Start connects to the broker and publishes queued events in the background.
+// It does nothing if no broker is configured.
+func (p *Publisher) Start() {
+	if !p.Enabled() {
+		return
+	}
+
+	options, err := p.clientOptions()
+	if err != nil {
+		p.log.Printf("Warning: not publishing to MQTT: %v", err)
+		return
+	}
+	p.client = paho.NewClient(options)
+	p.client.Connect() // retries in the background until it connects
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		p.queue.Run(p.ctx, maxBatch, p.publish)
+	}()
+
+	p.log.Printf("Publishing %s events to MQTT topics %s", strings.Join(p.config.EventTypes, ", "), p.config.TopicTemplate)
+}
+
+// clientOptions builds the client options from the URL: mqtt:// or mqtts://
+// for TLS, with the credentials in it.
+func (p *Publisher) clientOptions() (*paho.ClientOptions, error) {
+	u, err := url.Parse(p.config.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid MQTT_URL: %w", err)
+	}
+	if u.Scheme != "mqtt" && u.Scheme != "mqtts" {
+		return nil, fmt.Errorf("invalid MQTT_URL: use mqtt:// or mqtts://")
+	}
+	addr := u.Host
+	if u.Port() == "" {
+		port := "1883"
+		if u.Scheme == "mqtts" {
+			port = "8883"
+		}
+		addr = net.JoinHostPort(u.Hostname(), port)
+	}
+
+	options := paho.NewClientOptions().
+		AddBroker(u.Scheme + "://" + addr).
+		SetClientID(p.config.ClientID).
+		SetCleanSession(true).
+		SetKeepAlive(keepAlive).
+		SetConnectTimeout(dialTimeout).
+		SetWriteTimeout(ackTimeout).
+		SetConnectRetry(true).
+		SetConnectRetryInterval(time.Second).
+		SetAutoReconnect(true).
+		SetMaxReconnectInterval(maxReconnectWait).
+		SetOnConnectHandler(func(paho.Client) {
+			p.log.Printf("Connected to MQTT broker at %s", addr)
+		}).
+		SetConnectionLostHandler(func(_ paho.Client, err error) {
+			p.log.Printf("Disconnected from MQTT broker: %v", err)
+		})
+	if u.Scheme == "mqtts" {
+		options.SetTLSConfig(&tls.Config{ServerName: u.Hostname(), MinVersion: tls.VersionTLS12})
+	}
+	if u.User != nil {
+		options.SetUsername(u.User.Username())
+		if password, ok := u.User.Password(); ok {
+			options.SetPassword(password)
+		}
+	}
+	return options, nil
+}
+
+// Stop disconnects from the broker.
+func (p *Publisher) Stop() {
+	p.cancel()
+	p.wg.Wait()
+
+	if p.client != nil {
+		p.client.Disconnect(disconnectWait)
+	}
+}
+
+// Publish queues an event of the configured types for publishing without
+// blocking.
+func (p *Publisher) Publish(payload webhook.WebhookPayload) {
+	if !p.Enabled() || !p.wants(payload.EventType) {
+		return
+	}
+	p.queue.Push(payload)
+}
+
+// wants reports whether events of a type are published.
+func (p *Publisher) wants(eventType string) bool {
+	for _, t := range p.config.EventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// topic fills in the topic template for an event.
+func (p *Publisher) topic(payload webhook.WebhookPayload) string {
+	return strings.NewReplacer(
+		"{chat_jid}", payload.ChatJID(),
+		"{event_type}", payload.EventType,
+	).Replace(p.config.TopicTemplate)
+}
+
+// publish publishes a batch of events one at a time, waiting for the broker's
+// PUBACK with QoS 1, and returns the events that weren't published. While the
+// client is reconnecting nothing is handed to it: it would keep QoS 1 events
+// in an unbounded store and silently drop QoS 0 ones.
+func (p *Publisher) publish(batch []webhook.WebhookPayload) ([]webhook.WebhookPayload, error) {
+	for i, payload := range batch {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			p.log.Printf("Failed to marshal event %s: %v", payload.ID, err)
+			continue
+		}
+
+		if !p.client.IsConnectionOpen() {
+			return batch[i:], errNotConnected
+		}
+		token := p.client.Publish(p.topic(payload), p.config.QoS, p.config.Retain, data)
+		if !token.WaitTimeout(ackTimeout) {
+			return batch[i:], fmt.Errorf("no PUBACK for event %s", payload.ID)
+		}
+		if err := token.Error(); err != nil {
+			return batch[i:], err
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,23 @@
+package nats
+
+import (
+	"whatsapp-mcp/config"
+)
+
+// Config holds the NATS publisher configuration.
+type Config struct {
+	URL           string // nats://[user:pass@]host:port or tls://..., empty disables publishing
+	Token         string // auth token, if the server uses token authentication
+	SubjectPrefix string // subjects are <prefix>.<event type>, e.g. whatsapp.message.received
+	QueueSize     int    // events buffered while disconnected
+}
+
+// LoadConfig loads NATS configuration from environment variables.
+func LoadConfig() *Config {
+	return &Config{
+		URL:           config.GetEnv("NATS_URL", ""),
+		Token:         config.GetEnv("NATS_TOKEN", ""),
+		SubjectPrefix: config.GetEnv("NATS_SUBJECT_PREFIX", "whatsapp"),
+		QueueSize:     config.GetEnvInt("NATS_QUEUE_SIZE", 1000),
+	}
+}
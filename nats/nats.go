@@ -0,0 +1,167 @@
+// Package nats mirrors webhook events to a NATS server.
+//
+// Each event is published as its webhook JSON payload to a subject named
+// after its type, e.g. "whatsapp.message.received" or "whatsapp.group.created",
+// so consumers can subscribe to "whatsapp.>" or "whatsapp.group.*". The
+// publisher uses the nats.go client, which reconnects when the connection
+// drops and buffers what is published meanwhile. Like core NATS itself,
+// delivery is at most once: events are queued in memory while the client's
+// buffer is full and dropped when the queue is full or on shutdown.
+package nats
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+
+	"whatsapp-mcp/webhook"
+)
+
+const (
+	dialTimeout      = 10 * time.Second
+	flushTimeout     = 10 * time.Second
+	maxReconnectWait = 30 * time.Second
+	maxBatch         = 100
+)
+
+// Publisher publishes events to NATS. It implements webhook.Publisher.
+type Publisher struct {
+	config *Config
+	log    webhook.Logger
+	queue  *webhook.PublishQueue
+	conn   *nats.Conn
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewPublisher creates a new NATS publisher.
+func NewPublisher(config *Config, logger webhook.Logger) *Publisher {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &Publisher{
+		config: config,
+		log:    logger,
+		queue:  webhook.NewPublishQueue("NATS", config.QueueSize, logger),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// Enabled reports whether a NATS server is configured.
+func (p *Publisher) Enabled() bool {
+	return p.config.URL != ""
+}
+
+// Start connects to the server and publishes queued events in the background.
+// It does nothing if no server is configured.
+func (p *Publisher) Start() {
+	if !p.Enabled() {
+		return
+	}
+
+	options := []nats.Option{
+		nats.Name("whatsapp-mcp"),
+		nats.Timeout(dialTimeout),
+		nats.RetryOnFailedConnect(true),
+		nats.MaxReconnects(-1),
+		nats.CustomReconnectDelay(reconnectDelay),
+		nats.ConnectHandler(func(c *nats.Conn) {
+			p.log.Printf("Connected to NATS at %s", c.ConnectedAddr())
+		}),
+		nats.ReconnectHandler(func(c *nats.Conn) {
+			p.log.Printf("Reconnected to NATS at %s", c.ConnectedAddr())
+		}),
+		nats.DisconnectErrHandler(func(_ *nats.Conn, err error) {
+			if err != nil {
+				p.log.Printf("Disconnected from NATS: %v", err)
+			}
+		}),
+	}
+	if p.config.Token != "" {
+		options = append(options, nats.Token(p.config.Token))
+	}
+
+	conn, err := nats.Connect(p.config.URL, options...)
+	if err != nil {
+		p.log.Printf("Warning: not publishing to NATS: %v", err)
+		return
+	}
+	p.conn = conn
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		p.queue.Run(p.ctx, maxBatch, p.publish)
+	}()
+
+	p.log.Printf("Publishing events to NATS subjects %s.*", p.config.SubjectPrefix)
+}
+
+// reconnectDelay doubles the wait between reconnection attempts, from one
+// second up to 30.
+func reconnectDelay(attempts int) time.Duration {
+	wait := time.Second
+	for i := 1; i < attempts && wait < maxReconnectWait; i++ {
+		wait *= 2
+	}
+	return min(wait, maxReconnectWait)
+}
+
+// Stop closes the connection, after flushing what has been published.
+func (p *Publisher) Stop() {
+	p.cancel()
+	p.wg.Wait()
+
+	if p.conn == nil {
+		return
+	}
+	if err := p.conn.FlushTimeout(flushTimeout); err != nil {
+		p.log.Printf("Warning: failed to flush events to NATS: %v", err)
+	}
+	p.conn.Close()
+}
+
+// Publish queues an event for publishing without blocking.
+func (p *Publisher) Publish(payload webhook.WebhookPayload) {
+	if !p.Enabled() {
+		return
+	}
+	p.queue.Push(payload)
+}
+
+// subject returns the subject an event is published to.
+func (p *Publisher) subject(payload webhook.WebhookPayload) string {
+	if p.config.SubjectPrefix == "" {
+		return payload.EventType
+	}
+	return p.config.SubjectPrefix + "." + payload.EventType
+}
+
+// publish hands a batch of events to the client, which sends them in the
+// background. It returns the events the client couldn't take, such as when
+// its reconnect buffer is full.
+func (p *Publisher) publish(batch []webhook.WebhookPayload) ([]webhook.WebhookPayload, error) {
+	for i, payload := range batch {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			p.log.Printf("Failed to marshal event %s: %v", payload.ID, err)
+			continue
+		}
+
+		err = p.conn.Publish(p.subject(payload), data)
+		if errors.Is(err, nats.ErrMaxPayload) {
+			p.log.Printf("Event %s is larger than the server's max payload (%d bytes), dropped", payload.ID, p.conn.MaxPayload())
+			continue
+		}
+		if err != nil {
+			return batch[i:], err
+		}
+	}
+	return nil, nil
+}
@@ -10,8 +10,10 @@ const DataDir = "./data"
 
 // Data subdirectories for organizing different types of data.
 const (
-	DataDBDir    = DataDir + "/db"
-	DataMediaDir = DataDir + "/media"
+	DataDBDir      = DataDir + "/db"
+	DataMediaDir   = DataDir + "/media"
+	DataExportsDir = DataDir + "/exports"
+	DataUploadsDir = DataDir + "/uploads"
 )
 
 // Storage paths for migrations and other persistent data.
@@ -22,9 +24,9 @@ const (
 // File paths for databases, logs, and other files.
 const (
 	MessagesDBPath     = DataDBDir + "/messages.db"
+	ArchiveDBPath      = DataDBDir + "/messages_archive.db"
 	WhatsAppAuthDBPath = DataDBDir + "/whatsapp_auth.db"
 	WhatsAppLogPath    = DataDir + "/whatsapp.log"
-	QRCodePath         = "./qr.png"
 )
 
 // EnsureDataDirectories ensures that all required data directories exist.
@@ -33,6 +35,8 @@ func EnsureDataDirectories() error {
 		DataDir,
 		DataDBDir,
 		DataMediaDir,
+		DataExportsDir,
+		DataUploadsDir,
 	}
 
 	for _, dir := range dirs {
@@ -48,3 +52,8 @@ func EnsureDataDirectories() error {
 func GetMediaPath(relativePath string) string {
 	return filepath.Join(DataMediaDir, relativePath)
 }
+
+// GetExportPath returns the full path for an exported file given its file name.
+func GetExportPath(fileName string) string {
+	return filepath.Join(DataExportsDir, fileName)
+}
@@ -0,0 +1,33 @@
+package redis
+
+import (
+	"whatsapp-mcp/config"
+)
+
+// Modes of publishing events.
+const (
+	ModeStream = "stream" // XADD to a stream
+	ModePubSub = "pubsub" // PUBLISH to a channel per event type
+)
+
+// Config holds the Redis publisher configuration.
+type Config struct {
+	URL           string // redis://[[user]:password@]host:port[/db] or rediss:// for TLS, empty disables publishing
+	Mode          string // ModeStream or ModePubSub
+	Stream        string // stream key events are added to
+	StreamMaxLen  int    // approximate maximum length of the stream (0 = unbounded)
+	ChannelPrefix string // channels are <prefix><event type>, e.g. whatsapp:message.received
+	QueueSize     int    // events buffered while disconnected
+}
+
+// LoadConfig loads Redis configuration from environment variables.
+func LoadConfig() *Config {
+	return &Config{
+		URL:           config.GetEnv("REDIS_URL", ""),
+		Mode:          config.GetEnv("REDIS_MODE", ModeStream),
+		Stream:        config.GetEnv("REDIS_STREAM", "whatsapp:events"),
+		StreamMaxLen:  config.GetEnvInt("REDIS_STREAM_MAXLEN", 10000),
+		ChannelPrefix: config.GetEnv("REDIS_CHANNEL_PREFIX", "whatsapp:"),
+		QueueSize:     config.GetEnvInt("REDIS_QUEUE_SIZE", 1000),
+	}
+}
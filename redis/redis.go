@@ -0,0 +1,186 @@
+// Package redis publishes webhook events to Redis, for local consumers that
+// would rather read a stream than run an HTTP receiver.
+//
+// In stream mode each event is added to one stream with XADD, trimmed to
+// about REDIS_STREAM_MAXLEN entries, with the fields event_type, event_id,
+// chat_jid and payload (the webhook JSON). Consumers read it with XREAD or
+// consumer groups and can catch up after a restart. In pubsub mode the
+// payload is PUBLISHed to a channel per event type, e.g.
+// "whatsapp:message.received", which only reaches connected subscribers.
+// The publisher uses the go-redis client, which reconnects when the
+// connection drops; events are queued in memory meanwhile and dropped when
+// the queue is full or on shutdown.
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"whatsapp-mcp/webhook"
+)
+
+const (
+	dialTimeout    = 10 * time.Second
+	requestTimeout = 10 * time.Second
+	maxPipeline    = 100
+)
+
+// Publisher publishes events to Redis. It implements webhook.Publisher.
+type Publisher struct {
+	config *Config
+	log    webhook.Logger
+	queue  *webhook.PublishQueue
+	client *redis.Client
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewPublisher creates a new Redis publisher.
+func NewPublisher(config *Config, logger webhook.Logger) *Publisher {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &Publisher{
+		config: config,
+		log:    logger,
+		queue:  webhook.NewPublishQueue("Redis", config.QueueSize, logger),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// Enabled reports whether a Redis server is configured.
+func (p *Publisher) Enabled() bool {
+	return p.config.URL != ""
+}
+
+// Start publishes queued events in the background, connecting to the server
+// when needed. It does nothing if no server is configured.
+func (p *Publisher) Start() {
+	if !p.Enabled() {
+		return
+	}
+	if p.config.Mode != ModeStream && p.config.Mode != ModePubSub {
+		p.log.Printf("Warning: unknown REDIS_MODE %q, not publishing (use %s or %s)", p.config.Mode, ModeStream, ModePubSub)
+		return
+	}
+
+	// the URL gives the address, TLS (rediss://), credentials and database
+	options, err := redis.ParseURL(p.config.URL)
+	if err != nil {
+		p.log.Printf("Warning: invalid REDIS_URL, not publishing: %v", err)
+		return
+	}
+	options.DialTimeout = dialTimeout
+	options.ReadTimeout = requestTimeout
+	options.WriteTimeout = requestTimeout
+	options.PoolSize = 1    // events are sent from one goroutine
+	options.MaxRetries = -1 // the queue retries, with backoff
+	options.OnConnect = func(context.Context, *redis.Conn) error {
+		p.log.Printf("Connected to Redis at %s", options.Addr)
+		return nil
+	}
+	p.client = redis.NewClient(options)
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		p.queue.Run(p.ctx, maxPipeline, p.publish)
+	}()
+
+	if p.config.Mode == ModeStream {
+		p.log.Printf("Adding events to Redis stream %s", p.config.Stream)
+	} else {
+		p.log.Printf("Publishing events to Redis channels %s*", p.config.ChannelPrefix)
+	}
+}
+
+// Stop closes the connection, after the commands in flight.
+func (p *Publisher) Stop() {
+	p.cancel()
+	p.wg.Wait()
+
+	if p.client != nil {
+		p.client.Close()
+	}
+}
+
+// Publish queues an event for publishing without blocking.
+func (p *Publisher) Publish(payload webhook.WebhookPayload) {
+	if !p.Enabled() {
+		return
+	}
+	p.queue.Push(payload)
+}
+
+// publish sends a batch of events in one pipeline and returns the events whose
+// commands didn't reach the server. Commands the server rejects, such as XADD
+// to a key of another type, won't succeed by retrying and are logged instead.
+func (p *Publisher) publish(batch []webhook.WebhookPayload) ([]webhook.WebhookPayload, error) {
+	// commands in flight finish on Stop, within the request timeout
+	ctx := context.Background()
+
+	pipe := p.client.Pipeline()
+	var sent []webhook.WebhookPayload
+	for _, payload := range batch {
+		if err := p.command(ctx, pipe, payload); err != nil {
+			p.log.Printf("Failed to marshal event %s: %v", payload.ID, err)
+			continue
+		}
+		sent = append(sent, payload)
+	}
+	if len(sent) == 0 {
+		return nil, nil
+	}
+
+	cmds, _ := pipe.Exec(ctx)
+	var failed []webhook.WebhookPayload
+	var firstErr error
+	for i, cmd := range cmds {
+		err := cmd.Err()
+		var replyErr redis.Error
+		switch {
+		case err == nil:
+		case errors.As(err, &replyErr):
+			p.log.Printf("Redis rejected %s: %v", cmd.Name(), err)
+		default:
+			failed = append(failed, sent[i])
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return failed, firstErr
+}
+
+// command adds the command that publishes an event to a pipeline.
+func (p *Publisher) command(ctx context.Context, pipe redis.Pipeliner, payload webhook.WebhookPayload) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	if p.config.Mode == ModePubSub {
+		pipe.Publish(ctx, p.config.ChannelPrefix+payload.EventType, data)
+		return nil
+	}
+
+	pipe.XAdd(ctx, &redis.XAddArgs{
+		Stream: p.config.Stream,
+		MaxLen: int64(p.config.StreamMaxLen),
+		Approx: true,
+		Values: []any{
+			"event_type", payload.EventType,
+			"event_id", payload.ID,
+			"chat_jid", payload.ChatJID(),
+			"payload", data,
+		},
+	})
+	return nil
+}
@@ -0,0 +1,148 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// aliasStatements move the rows stored under an alias JID (?2) to its
+// canonical JID (?1). Where the canonical JID already has a row for the same
+// key, the canonical row wins and the alias row is dropped.
+var aliasStatements = []struct {
+	table string
+	query string
+}{
+	{"chats", `
+		INSERT INTO chats (jid, push_name, contact_name, last_message_time, is_group, created_at, disappearing_timer, read_at, marked_unread, muted_until, archived, pinned_at)
+		SELECT ?1, push_name, contact_name, last_message_time, is_group, created_at, disappearing_timer, read_at, marked_unread, muted_until, archived, pinned_at
+		FROM chats WHERE jid = ?2
+		ON CONFLICT(jid) DO UPDATE SET
+		    push_name = COALESCE(NULLIF(chats.push_name, ''), excluded.push_name),
+		    contact_name = COALESCE(NULLIF(chats.contact_name, ''), excluded.contact_name),
+		    last_message_time = MAX(COALESCE(chats.last_message_time, 0), COALESCE(excluded.last_message_time, 0)),
+		    read_at = MAX(COALESCE(chats.read_at, 0), COALESCE(excluded.read_at, 0)),
+		    marked_unread = chats.marked_unread OR excluded.marked_unread,
+		    muted_until = CASE WHEN chats.muted_until = 0 THEN excluded.muted_until ELSE chats.muted_until END,
+		    archived = chats.archived OR excluded.archived,
+		    pinned_at = MAX(chats.pinned_at, excluded.pinned_at)
+	`},
+	{"messages", `UPDATE messages SET chat_jid = ?1 WHERE chat_jid = ?2`},
+	{"messages", `UPDATE messages SET sender_jid = ?1 WHERE sender_jid = ?2`},
+	{"backfills", `UPDATE OR IGNORE backfills SET chat_jid = ?1 WHERE chat_jid = ?2`},
+	{"chats", `DELETE FROM chats WHERE jid = ?2`},
+	{"reactions", `UPDATE reactions SET chat_jid = ?1 WHERE chat_jid = ?2`},
+	{"reactions", `UPDATE OR IGNORE reactions SET sender_jid = ?1 WHERE sender_jid = ?2`},
+	{"reactions", `DELETE FROM reactions WHERE sender_jid = ?2`},
+	{"receipts", `UPDATE receipts SET chat_jid = ?1 WHERE chat_jid = ?2`},
+	{"receipts", `UPDATE OR IGNORE receipts SET participant_jid = ?1 WHERE participant_jid = ?2`},
+	{"receipts", `DELETE FROM receipts WHERE participant_jid = ?2`},
+	{"message_mentions", `UPDATE OR IGNORE message_mentions SET mentioned_jid = ?1 WHERE mentioned_jid = ?2`},
+	{"message_mentions", `DELETE FROM message_mentions WHERE mentioned_jid = ?2`},
+	{"group_participants", `UPDATE OR IGNORE group_participants SET participant_jid = ?1 WHERE participant_jid = ?2`},
+	{"group_participants", `DELETE FROM group_participants WHERE participant_jid = ?2`},
+	{"push_names", `UPDATE OR IGNORE push_names SET jid = ?1 WHERE jid = ?2`},
+	{"push_names", `DELETE FROM push_names WHERE jid = ?2`},
+	{"contacts", `UPDATE OR IGNORE contacts SET jid = ?1 WHERE jid = ?2`},
+	{"contacts", `DELETE FROM contacts WHERE jid = ?2`},
+	{"chat_tags", `UPDATE OR IGNORE chat_tags SET chat_jid = ?1 WHERE chat_jid = ?2`},
+	{"chat_tags", `DELETE FROM chat_tags WHERE chat_jid = ?2`},
+	{"contact_notes", `UPDATE contact_notes SET jid = ?1 WHERE jid = ?2`},
+	{"calls", `UPDATE calls SET caller_jid = ?1 WHERE caller_jid = ?2`},
+	{"calls", `UPDATE calls SET chat_jid = ?1 WHERE chat_jid = ?2`},
+	{"statuses", `UPDATE statuses SET sender_jid = ?1 WHERE sender_jid = ?2`},
+	{"polls", `UPDATE polls SET chat_jid = ?1 WHERE chat_jid = ?2`},
+	{"poll_votes", `UPDATE poll_votes SET chat_jid = ?1 WHERE chat_jid = ?2`},
+	{"poll_votes", `UPDATE OR IGNORE poll_votes SET voter_jid = ?1 WHERE voter_jid = ?2`},
+	{"poll_votes", `DELETE FROM poll_votes WHERE voter_jid = ?2`},
+	{"presence", `UPDATE OR IGNORE presence SET jid = ?1 WHERE jid = ?2`},
+	{"presence", `DELETE FROM presence WHERE jid = ?2`},
+}
+
+// SaveJIDAlias records that alias (a LID JID) and canonical (a phone number
+// JID) are the same account.
+func (s *MessageStore) SaveJIDAlias(alias, canonical string) error {
+	return s.SaveJIDAliases(map[string]string{alias: canonical})
+}
+
+// SaveJIDAliases records alias -> canonical JID mappings in a single
+// transaction. Messages, chats and other rows stored under an alias before its
+// mapping was known are moved to the canonical JID, so a contact's history is
+// unified in chat views and search. Aliases already saved with the same
+// canonical JID are skipped.
+func (s *MessageStore) SaveJIDAliases(aliases map[string]string) error {
+	if len(aliases) == 0 {
+		return nil
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now().Unix()
+	for alias, canonical := range aliases {
+		if alias == "" || canonical == "" || alias == canonical {
+			continue
+		}
+
+		// rows are stored under the canonical JID once the alias is known, so
+		// there is nothing to move for an alias that is already saved
+		var existing string
+		err := tx.QueryRow("SELECT canonical_jid FROM jid_aliases WHERE alias_jid = ?", alias).Scan(&existing)
+		if err == nil && existing == canonical {
+			continue
+		}
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("failed to look up alias %s: %w", alias, err)
+		}
+
+		_, err = tx.Exec(`
+			INSERT INTO jid_aliases (alias_jid, canonical_jid, updated_at)
+			VALUES (?, ?, ?)
+			ON CONFLICT(alias_jid) DO UPDATE SET
+				canonical_jid = excluded.canonical_jid,
+				updated_at = excluded.updated_at
+		`, alias, canonical, now)
+		if err != nil {
+			return fmt.Errorf("failed to save alias %s: %w", alias, err)
+		}
+
+		for _, stmt := range aliasStatements {
+			if _, err := tx.Exec(stmt.query, canonical, alias); err != nil {
+				return fmt.Errorf("failed to move %s of %s to %s: %w", stmt.table, alias, canonical, err)
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+// CanonicalJID returns the canonical JID of an alias, or jid itself if it
+// isn't a known alias.
+func (s *MessageStore) CanonicalJID(jid string) (string, error) {
+	var canonical string
+	err := s.db.QueryRow("SELECT canonical_jid FROM jid_aliases WHERE alias_jid = ?", jid).Scan(&canonical)
+	if errors.Is(err, sql.ErrNoRows) {
+		return jid, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return canonical, nil
+}
+
+// CanonicalJIDs returns the canonical JIDs of a list of JIDs, see CanonicalJID.
+func (s *MessageStore) CanonicalJIDs(jids []string) ([]string, error) {
+	canonical := make([]string, 0, len(jids))
+	for _, jid := range jids {
+		c, err := s.CanonicalJID(jid)
+		if err != nil {
+			return nil, err
+		}
+		canonical = append(canonical, c)
+	}
+	return canonical, nil
+}
@@ -0,0 +1,215 @@
+package storage
+
+import (
+	"context"
+	"slices"
+	"time"
+)
+
+// ActivityReport aggregates the messages of a period. Counts come from the
+// message_stats table, which triggers keep up to date on every write.
+type ActivityReport struct {
+	Total    int
+	FromMe   int
+	Days     []DayActivity     // oldest first, days without messages omitted
+	Chats    []ActivityCount   // busiest first
+	Senders  []ActivityCount   // busiest first, my own messages excluded
+	Types    map[string]int    // message count by type (text, image, ...)
+	Response ResponseTimeStats // my replies in direct chats
+}
+
+// DayActivity is the message count of one day.
+type DayActivity struct {
+	Day    string // YYYY-MM-DD in the report's time zone
+	Count  int
+	FromMe int
+}
+
+// ActivityCount is the message count of a chat or sender.
+type ActivityCount struct {
+	JID   string
+	Name  string
+	Count int
+}
+
+// ResponseTimeStats describes how long I took to reply in direct chats: the
+// time from the first message of an unanswered run of incoming messages to my
+// next message.
+type ResponseTimeStats struct {
+	Replies int
+	Median  time.Duration
+	Average time.Duration
+	Longest time.Duration
+}
+
+// GetActivityReport aggregates the messages in [since, until), optionally of a
+// single chat. Days are counted in loc, using its offset at since (whole-hour
+// offsets only, like the hourly buckets). limit caps the chat and sender lists.
+func (s *MessageStore) GetActivityReport(ctx context.Context, since, until time.Time, chatJID string, loc *time.Location, limit int) (*ActivityReport, error) {
+	_, offset := since.In(loc).Zone()
+
+	// buckets are hours, so the bounds are rounded to whole hours
+	scope := " WHERE hour >= ? AND hour < ?"
+	args := []any{since.Unix() - since.Unix()%3600, until.Unix()}
+	if chatJID != "" {
+		scope += " AND chat_jid = ?"
+		args = append(args, chatJID)
+	}
+
+	report := &ActivityReport{Types: make(map[string]int)}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT date(hour + ?, 'unixepoch'), SUM(message_count),
+		       SUM(CASE WHEN is_from_me THEN message_count ELSE 0 END)
+		FROM message_stats`+scope+`
+		GROUP BY 1
+		ORDER BY 1
+	`, append([]any{offset}, args...)...)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var day DayActivity
+		if err := rows.Scan(&day.Day, &day.Count, &day.FromMe); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		report.Days = append(report.Days, day)
+		report.Total += day.Count
+		report.FromMe += day.FromMe
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = s.db.QueryContext(ctx, `
+		SELECT message_type, SUM(message_count)
+		FROM message_stats`+scope+`
+		GROUP BY message_type
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var messageType string
+		var count int
+		if err := rows.Scan(&messageType, &count); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		report.Types[messageType] = count
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	report.Chats, err = s.activityCounts(ctx, `
+		SELECT st.chat_jid, COALESCE(NULLIF(c.contact_name, ''), NULLIF(c.push_name, ''), st.chat_jid), SUM(st.message_count)
+		FROM message_stats st
+		LEFT JOIN chats c ON c.jid = st.chat_jid`+scope+`
+		GROUP BY st.chat_jid
+		ORDER BY 3 DESC
+		LIMIT ?
+	`, append(args, limit)...)
+	if err != nil {
+		return nil, err
+	}
+
+	report.Senders, err = s.activityCounts(ctx, `
+		SELECT st.sender_jid,
+		       COALESCE(NULLIF(c.contact_name, ''), NULLIF(ct.full_name, ''), NULLIF(p.push_name, ''), st.sender_jid),
+		       SUM(st.message_count)
+		FROM message_stats st
+		LEFT JOIN chats c ON c.jid = st.sender_jid
+		LEFT JOIN contacts ct ON ct.jid = st.sender_jid
+		LEFT JOIN push_names p ON p.jid = st.sender_jid`+scope+` AND NOT st.is_from_me
+		GROUP BY st.sender_jid
+		ORDER BY 3 DESC
+		LIMIT ?
+	`, append(args, limit)...)
+	if err != nil {
+		return nil, err
+	}
+
+	report.Response, err = s.responseTimes(ctx, since, until, chatJID)
+	if err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// activityCounts runs a query returning (jid, name, count) rows.
+func (s *MessageStore) activityCounts(ctx context.Context, query string, args ...any) ([]ActivityCount, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var counts []ActivityCount
+	for rows.Next() {
+		var c ActivityCount
+		if err := rows.Scan(&c.JID, &c.Name, &c.Count); err != nil {
+			return nil, err
+		}
+		counts = append(counts, c)
+	}
+	return counts, rows.Err()
+}
+
+// responseTimes computes my reply times in direct chats. Unlike the counts it
+// reads the messages of the period, through the (chat_jid, timestamp) index.
+func (s *MessageStore) responseTimes(ctx context.Context, since, until time.Time, chatJID string) (ResponseTimeStats, error) {
+	scope := " WHERE timestamp >= ? AND timestamp < ? AND chat_jid NOT LIKE '%@g.us'"
+	args := []any{since.Unix(), until.Unix()}
+	if chatJID != "" {
+		scope += " AND chat_jid = ?"
+		args = append(args, chatJID)
+	}
+
+	// a run of incoming messages starts at one whose previous message is mine
+	rows, err := s.db.QueryContext(ctx, `
+		WITH ordered AS (
+		    SELECT chat_jid, id, timestamp, is_from_me,
+		           LAG(is_from_me) OVER (PARTITION BY chat_jid ORDER BY timestamp, id) AS prev_from_me
+		    FROM messages`+scope+`
+		), runs AS (
+		    SELECT is_from_me, prev_from_me, timestamp,
+		           MAX(CASE WHEN NOT is_from_me AND (prev_from_me IS NULL OR prev_from_me) THEN timestamp END)
+		               OVER (PARTITION BY chat_jid ORDER BY timestamp, id ROWS UNBOUNDED PRECEDING) AS run_start
+		    FROM ordered
+		)
+		SELECT timestamp - run_start
+		FROM runs
+		WHERE is_from_me AND NOT prev_from_me AND run_start IS NOT NULL
+	`, args...)
+	if err != nil {
+		return ResponseTimeStats{}, err
+	}
+	defer rows.Close()
+
+	var delays []int64
+	var total int64
+	for rows.Next() {
+		var delay int64
+		if err := rows.Scan(&delay); err != nil {
+			return ResponseTimeStats{}, err
+		}
+		delays = append(delays, delay)
+		total += delay
+	}
+	if err := rows.Err(); err != nil || len(delays) == 0 {
+		return ResponseTimeStats{}, err
+	}
+
+	slices.Sort(delays)
+	return ResponseTimeStats{
+		Replies: len(delays),
+		Median:  time.Duration(delays[len(delays)/2]) * time.Second,
+		Average: time.Duration(total/int64(len(delays))) * time.Second,
+		Longest: time.Duration(delays[len(delays)-1]) * time.Second,
+	}, nil
+}
@@ -0,0 +1,538 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"whatsapp-mcp/paths"
+)
+
+// ArchivedChat summarizes the messages of a chat moved to the archive.
+type ArchivedChat struct {
+	ChatJID      string
+	MessageCount int
+	Oldest       time.Time
+	Newest       time.Time
+	ArchivedAt   time.Time
+}
+
+// archiveSchema creates the tables of the archive database. Archived messages
+// take everything stored about them along: media metadata, transcripts,
+// edits, mentions, link previews, tags, raw protobufs, channel post counts,
+// statuses, reactions, receipts and polls. Names stay in the main database,
+// where searches join them.
+const archiveSchema = `
+	CREATE TABLE IF NOT EXISTS archive.messages (
+	    id TEXT PRIMARY KEY,
+	    chat_jid TEXT NOT NULL,
+	    sender_jid TEXT NOT NULL,
+	    text TEXT,
+	    timestamp INTEGER NOT NULL,
+	    is_from_me BOOLEAN NOT NULL,
+	    message_type TEXT,
+	    created_at DATETIME,
+	    reply_to_id TEXT,
+	    edited_at INTEGER,
+	    deleted_at INTEGER,
+	    payload TEXT
+	);
+	CREATE INDEX IF NOT EXISTS archive.idx_archive_chat_timestamp ON messages(chat_jid, timestamp);
+	CREATE TABLE IF NOT EXISTS archive.media_metadata (
+	    message_id TEXT PRIMARY KEY,
+	    file_path TEXT, file_name TEXT, file_size INTEGER, mime_type TEXT,
+	    width INTEGER, height INTEGER, duration INTEGER,
+	    media_key BLOB, direct_path TEXT, file_sha256 BLOB, file_enc_sha256 BLOB,
+	    download_status TEXT, download_timestamp INTEGER, download_error TEXT,
+	    created_at DATETIME, thumbnail BLOB, thumbnail_path TEXT
+	);
+	CREATE TABLE IF NOT EXISTS archive.transcripts (
+	    message_id TEXT PRIMARY KEY,
+	    text TEXT NOT NULL,
+	    source TEXT,
+	    created_at INTEGER
+	);
+	CREATE TABLE IF NOT EXISTS archive.message_edits (
+	    id INTEGER PRIMARY KEY,
+	    message_id TEXT NOT NULL,
+	    text TEXT NOT NULL,
+	    replaced_at INTEGER NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS archive.idx_archive_message_edits ON message_edits(message_id, replaced_at);
+	CREATE TABLE IF NOT EXISTS archive.message_mentions (
+	    message_id TEXT NOT NULL,
+	    mentioned_jid TEXT NOT NULL,
+	    PRIMARY KEY (message_id, mentioned_jid)
+	);
+	CREATE TABLE IF NOT EXISTS archive.link_previews (
+	    message_id TEXT PRIMARY KEY,
+	    url TEXT NOT NULL,
+	    title TEXT NOT NULL DEFAULT '',
+	    description TEXT NOT NULL DEFAULT ''
+	);
+	CREATE TABLE IF NOT EXISTS archive.message_tags (
+	    tag TEXT NOT NULL COLLATE NOCASE,
+	    message_id TEXT NOT NULL,
+	    created_at INTEGER NOT NULL,
+	    PRIMARY KEY (tag, message_id)
+	);
+	CREATE TABLE IF NOT EXISTS archive.raw_messages (
+	    message_id TEXT PRIMARY KEY,
+	    data BLOB NOT NULL,
+	    created_at INTEGER NOT NULL
+	);
+	CREATE TABLE IF NOT EXISTS archive.newsletter_posts (
+	    message_id TEXT PRIMARY KEY,
+	    chat_jid TEXT NOT NULL,
+	    server_id INTEGER NOT NULL,
+	    views INTEGER NOT NULL DEFAULT 0,
+	    reactions TEXT NOT NULL DEFAULT '{}',
+	    updated_at INTEGER NOT NULL
+	);
+	CREATE TABLE IF NOT EXISTS archive.statuses (
+	    message_id TEXT PRIMARY KEY,
+	    sender_jid TEXT NOT NULL,
+	    status_type TEXT NOT NULL,
+	    posted_at INTEGER NOT NULL,
+	    expires_at INTEGER NOT NULL
+	);
+	CREATE TABLE IF NOT EXISTS archive.reactions (
+	    target_message_id TEXT NOT NULL,
+	    sender_jid TEXT NOT NULL,
+	    chat_jid TEXT NOT NULL,
+	    emoji TEXT NOT NULL,
+	    timestamp INTEGER NOT NULL,
+	    PRIMARY KEY (target_message_id, sender_jid)
+	);
+	CREATE TABLE IF NOT EXISTS archive.receipts (
+	    message_id TEXT NOT NULL,
+	    chat_jid TEXT NOT NULL,
+	    participant_jid TEXT NOT NULL,
+	    status TEXT NOT NULL,
+	    timestamp INTEGER NOT NULL,
+	    PRIMARY KEY (message_id, participant_jid, status)
+	);
+	CREATE TABLE IF NOT EXISTS archive.polls (
+	    message_id TEXT PRIMARY KEY,
+	    chat_jid TEXT NOT NULL,
+	    question TEXT NOT NULL,
+	    options TEXT NOT NULL,
+	    selectable_count INTEGER NOT NULL DEFAULT 0,
+	    created_at INTEGER NOT NULL
+	);
+	CREATE TABLE IF NOT EXISTS archive.poll_votes (
+	    poll_message_id TEXT NOT NULL,
+	    voter_jid TEXT NOT NULL,
+	    chat_jid TEXT NOT NULL,
+	    options TEXT NOT NULL,
+	    timestamp INTEGER NOT NULL,
+	    PRIMARY KEY (poll_message_id, voter_jid)
+	);
+`
+
+// archivedMessagesView is messages_with_names over the archive database. It
+// is a temporary view, the only kind that can span attached databases.
+const archivedMessagesView = `
+	CREATE TEMP VIEW IF NOT EXISTS archived_messages_with_names AS
+	SELECT
+	    m.id,
+	    m.chat_jid,
+	    m.sender_jid,
+	    COALESCE(p.push_name, NULLIF(ct.push_name, ''), '') as sender_push_name,
+	    COALESCE(NULLIF(c_sender.contact_name, ''), NULLIF(ct.full_name, ''), NULLIF(ct.first_name, ''), NULLIF(ct.business_name, ''), '') as sender_contact_name,
+	    COALESCE(c_chat.contact_name, c_chat.push_name, m.chat_jid) as chat_name,
+	    m.text,
+	    m.timestamp,
+	    m.is_from_me,
+	    m.message_type,
+	    m.created_at,
+	    m.reply_to_id,
+	    m.edited_at,
+	    m.deleted_at,
+	    m.payload,
+	    media.file_path as media_file_path,
+	    media.file_name as media_file_name,
+	    media.file_size as media_file_size,
+	    media.mime_type as media_mime_type,
+	    media.width as media_width,
+	    media.height as media_height,
+	    media.duration as media_duration,
+	    media.download_status as media_download_status,
+	    media.download_timestamp as media_download_timestamp,
+	    media.download_error as media_download_error,
+	    t.text as transcript,
+	    (SELECT GROUP_CONCAT(r.emoji, ' ') FROM archive.reactions r WHERE r.target_message_id = m.id) as reactions,
+	    np.views as views,
+	    np.reactions as newsletter_reactions
+	FROM archive.messages m
+	LEFT JOIN main.push_names p ON m.sender_jid = p.jid
+	LEFT JOIN main.chats c_sender ON m.sender_jid = c_sender.jid
+	LEFT JOIN main.contacts ct ON m.sender_jid = ct.jid
+	LEFT JOIN main.chats c_chat ON m.chat_jid = c_chat.jid
+	LEFT JOIN archive.media_metadata media ON m.id = media.message_id
+	LEFT JOIN archive.transcripts t ON m.id = t.message_id
+	LEFT JOIN archive.newsletter_posts np ON m.id = np.message_id
+`
+
+// ArchiveMessages moves the messages older than before of the given chats
+// (all chats if none are given) to the archive database, with every row that
+// refers to them (see archiveSchema), and records them in archived_chats.
+// SQLite in WAL mode doesn't commit attached databases atomically, so the copy
+// is committed first, and a second transaction then deletes from the main
+// database only the messages that made it into the archive. If that fails, the
+// messages are in both until the next run, which copies nothing twice. Media
+// files stay where they are. Archived messages no longer count in chat
+// statistics and are only found by SearchArchive. It returns the number of
+// messages moved.
+func (s *MessageStore) ArchiveMessages(ctx context.Context, chatJIDs []string, before time.Time) (int, error) {
+	path := paths.ArchiveDBPath
+
+	// ATTACH only applies to one connection, and can't run inside a transaction
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "ATTACH DATABASE ? AS archive", "file:"+path); err != nil {
+		return 0, fmt.Errorf("failed to attach %s: %w", path, err)
+	}
+	defer conn.ExecContext(context.Background(), "DETACH DATABASE archive")
+
+	if _, err := conn.ExecContext(ctx, archiveSchema); err != nil {
+		return 0, fmt.Errorf("failed to create archive tables: %w", err)
+	}
+	// archives created before thumbnails were generated lack their path
+	hasThumbnailPath, err := archiveHasThumbnailPath(ctx, conn)
+	if err != nil {
+		return 0, err
+	}
+	if !hasThumbnailPath {
+		if _, err := conn.ExecContext(ctx, "ALTER TABLE archive.media_metadata ADD COLUMN thumbnail_path TEXT"); err != nil {
+			return 0, fmt.Errorf("failed to upgrade archive tables: %w", err)
+		}
+	}
+
+	scope := " WHERE timestamp < ?"
+	args := []any{before.Unix()}
+	if len(chatJIDs) > 0 {
+		scope += " AND chat_jid IN (" + strings.TrimSuffix(strings.Repeat("?, ", len(chatJIDs)), ", ") + ")"
+		for _, jid := range chatJIDs {
+			args = append(args, jid)
+		}
+	}
+	scopedIDs := "SELECT id FROM main.messages" + scope
+
+	if err := copyToArchive(ctx, conn, scope, scopedIDs, args); err != nil {
+		return 0, err
+	}
+
+	// from here on, only what the archive holds
+	scope += " AND id IN (SELECT id FROM archive.messages)"
+	scopedIDs = "SELECT id FROM main.messages" + scope
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO main.archived_chats (chat_jid, message_count, oldest, newest, archived_at)
+		SELECT chat_jid, COUNT(*), MIN(timestamp), MAX(timestamp), ?
+		FROM main.messages`+scope+`
+		GROUP BY chat_jid
+		ON CONFLICT(chat_jid) DO UPDATE SET
+		    message_count = message_count + excluded.message_count,
+		    oldest = MIN(oldest, excluded.oldest),
+		    newest = MAX(newest, excluded.newest),
+		    archived_at = excluded.archived_at`, append([]any{time.Now().Unix()}, args...)...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to archive archived_chats: %w", err)
+	}
+
+	// these refer to messages without a foreign key, so deleting the messages
+	// doesn't cascade to them
+	unlinked := []struct {
+		table  string
+		column string
+	}{
+		{"reactions", "target_message_id"},
+		{"receipts", "message_id"},
+		{"polls", "message_id"},
+		{"poll_votes", "poll_message_id"},
+	}
+	for _, t := range unlinked {
+		query := "DELETE FROM main." + t.table + " WHERE " + t.column + " IN (" + scopedIDs + ")"
+		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+			return 0, fmt.Errorf("failed to delete archived %s: %w", t.table, err)
+		}
+	}
+
+	result, err := tx.ExecContext(ctx, "DELETE FROM main.messages"+scope, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete archived messages: %w", err)
+	}
+	n, _ := result.RowsAffected()
+
+	return int(n), tx.Commit()
+}
+
+// archiveHasThumbnailPath reports whether the attached archive's media
+// table has the thumbnail_path column.
+func archiveHasThumbnailPath(ctx context.Context, conn *sql.Conn) (bool, error) {
+	var exists bool
+	err := conn.QueryRowContext(ctx, `
+		SELECT EXISTS(SELECT 1 FROM pragma_table_info('media_metadata', 'archive') WHERE name = 'thumbnail_path')
+	`).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("failed to check archive tables: %w", err)
+	}
+	return exists, nil
+}
+
+// copyToArchive copies the messages of the main database matched by scope,
+// and every row referring to them, to the attached archive database in one
+// transaction. Rows already in the archive are kept.
+func copyToArchive(ctx context.Context, conn *sql.Conn, scope, scopedIDs string, args []any) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	statements := []struct {
+		table string
+		query string
+		args  []any
+	}{
+		{"messages", `
+			INSERT OR IGNORE INTO archive.messages
+			(id, chat_jid, sender_jid, text, timestamp, is_from_me, message_type, created_at, reply_to_id, edited_at, deleted_at, payload)
+			SELECT id, chat_jid, sender_jid, text, timestamp, is_from_me, message_type, created_at, reply_to_id, edited_at, deleted_at, payload
+			FROM main.messages` + scope, args},
+		{"media_metadata", `
+			INSERT OR IGNORE INTO archive.media_metadata
+			(message_id, file_path, file_name, file_size, mime_type, width, height, duration, media_key, direct_path,
+			 file_sha256, file_enc_sha256, download_status, download_timestamp, download_error, created_at, thumbnail, thumbnail_path)
+			SELECT message_id, file_path, file_name, file_size, mime_type, width, height, duration, media_key, direct_path,
+			       file_sha256, file_enc_sha256, download_status, download_timestamp, download_error, created_at, thumbnail, thumbnail_path
+			FROM main.media_metadata
+			WHERE message_id IN (` + scopedIDs + `)`, args},
+		{"transcripts", `
+			INSERT OR IGNORE INTO archive.transcripts (message_id, text, source, created_at)
+			SELECT message_id, text, source, created_at FROM main.transcripts
+			WHERE message_id IN (` + scopedIDs + `)`, args},
+		{"message_edits", `
+			INSERT OR IGNORE INTO archive.message_edits (id, message_id, text, replaced_at)
+			SELECT id, message_id, text, replaced_at FROM main.message_edits
+			WHERE message_id IN (` + scopedIDs + `)`, args},
+		{"message_mentions", `
+			INSERT OR IGNORE INTO archive.message_mentions (message_id, mentioned_jid)
+			SELECT message_id, mentioned_jid FROM main.message_mentions
+			WHERE message_id IN (` + scopedIDs + `)`, args},
+		{"link_previews", `
+			INSERT OR IGNORE INTO archive.link_previews (message_id, url, title, description)
+			SELECT message_id, url, title, description FROM main.link_previews
+			WHERE message_id IN (` + scopedIDs + `)`, args},
+		{"message_tags", `
+			INSERT OR IGNORE INTO archive.message_tags (tag, message_id, created_at)
+			SELECT tag, message_id, created_at FROM main.message_tags
+			WHERE message_id IN (` + scopedIDs + `)`, args},
+		{"raw_messages", `
+			INSERT OR IGNORE INTO archive.raw_messages (message_id, data, created_at)
+			SELECT message_id, data, created_at FROM main.raw_messages
+			WHERE message_id IN (` + scopedIDs + `)`, args},
+		{"newsletter_posts", `
+			INSERT OR IGNORE INTO archive.newsletter_posts (message_id, chat_jid, server_id, views, reactions, updated_at)
+			SELECT message_id, chat_jid, server_id, views, reactions, updated_at FROM main.newsletter_posts
+			WHERE message_id IN (` + scopedIDs + `)`, args},
+		{"statuses", `
+			INSERT OR IGNORE INTO archive.statuses (message_id, sender_jid, status_type, posted_at, expires_at)
+			SELECT message_id, sender_jid, status_type, posted_at, expires_at FROM main.statuses
+			WHERE message_id IN (` + scopedIDs + `)`, args},
+		{"reactions", `
+			INSERT OR REPLACE INTO archive.reactions (target_message_id, sender_jid, chat_jid, emoji, timestamp)
+			SELECT target_message_id, sender_jid, chat_jid, emoji, timestamp FROM main.reactions
+			WHERE target_message_id IN (` + scopedIDs + `)`, args},
+		{"receipts", `
+			INSERT OR IGNORE INTO archive.receipts (message_id, chat_jid, participant_jid, status, timestamp)
+			SELECT message_id, chat_jid, participant_jid, status, timestamp FROM main.receipts
+			WHERE message_id IN (` + scopedIDs + `)`, args},
+		{"polls", `
+			INSERT OR IGNORE INTO archive.polls (message_id, chat_jid, question, options, selectable_count, created_at)
+			SELECT message_id, chat_jid, question, options, selectable_count, created_at FROM main.polls
+			WHERE message_id IN (` + scopedIDs + `)`, args},
+		{"poll_votes", `
+			INSERT OR REPLACE INTO archive.poll_votes (poll_message_id, voter_jid, chat_jid, options, timestamp)
+			SELECT poll_message_id, voter_jid, chat_jid, options, timestamp FROM main.poll_votes
+			WHERE poll_message_id IN (` + scopedIDs + `)`, args},
+	}
+	for _, stmt := range statements {
+		if _, err := tx.ExecContext(ctx, stmt.query, stmt.args...); err != nil {
+			return fmt.Errorf("failed to archive %s: %w", stmt.table, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// ListArchivedChats returns the chats with archived messages, most archived first.
+func (s *MessageStore) ListArchivedChats() ([]ArchivedChat, error) {
+	rows, err := s.db.Query(`
+		SELECT chat_jid, message_count, oldest, newest, archived_at
+		FROM archived_chats
+		ORDER BY message_count DESC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var chats []ArchivedChat
+	for rows.Next() {
+		var c ArchivedChat
+		var oldest, newest, archivedAt int64
+		if err := rows.Scan(&c.ChatJID, &c.MessageCount, &oldest, &newest, &archivedAt); err != nil {
+			return nil, err
+		}
+		c.Oldest = time.Unix(oldest, 0)
+		c.Newest = time.Unix(newest, 0)
+		c.ArchivedAt = time.Unix(archivedAt, 0)
+		chats = append(chats, c)
+	}
+
+	return chats, rows.Err()
+}
+
+// SearchArchive searches the archived messages like SearchMessagesWithNamesFiltered
+// searches the stored ones. Mention and message tag filters look at the main
+// database, so they match nothing here. It returns nil if nothing was archived.
+func (s *MessageStore) SearchArchive(ctx context.Context, filter SearchFilter) ([]MessageWithNames, error) {
+	var messages []MessageWithNames
+	err := s.withArchive(ctx, filter.ChatJIDs, func(conn *sql.Conn) error {
+		conditions, args := searchConditions(filter)
+		query := `
+		SELECT ` + messageWithNamesColumns + `
+		FROM archived_messages_with_names
+		WHERE 1 = 1` + conditions
+
+		cursorConditions, cursorArgs, order, ascending := keysetClause(filter.BeforeCursor, filter.AfterCursor)
+		query += cursorConditions + order + " LIMIT ?"
+		args = append(append(args, cursorArgs...), filter.Limit)
+
+		rows, err := conn.QueryContext(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		if messages, err = s.scanMessagesWithNames(rows); err != nil {
+			return err
+		}
+		if ascending {
+			slices.Reverse(messages)
+		}
+		return nil
+	})
+
+	return messages, err
+}
+
+// ArchivedMessageIDs returns which of ids, messages of chatJIDs, are in the
+// archive database.
+func (s *MessageStore) ArchivedMessageIDs(ctx context.Context, chatJIDs []string, ids []string) (map[string]bool, error) {
+	archived := make(map[string]bool)
+	if len(ids) == 0 {
+		return archived, nil
+	}
+
+	err := s.withArchive(ctx, chatJIDs, func(conn *sql.Conn) error {
+		args := make([]any, len(ids))
+		for i, id := range ids {
+			args[i] = id
+		}
+		rows, err := conn.QueryContext(ctx,
+			"SELECT id FROM archive.messages WHERE id IN ("+strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", ")+")", args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var id string
+			if err := rows.Scan(&id); err != nil {
+				return err
+			}
+			archived[id] = true
+		}
+		return rows.Err()
+	})
+
+	return archived, err
+}
+
+// CountArchivedMatches returns how many archived messages match filter. The
+// cursors and limit are ignored.
+func (s *MessageStore) CountArchivedMatches(ctx context.Context, filter SearchFilter) (int, error) {
+	var count int
+	err := s.withArchive(ctx, filter.ChatJIDs, func(conn *sql.Conn) error {
+		conditions, args := searchConditions(filter)
+		return conn.QueryRowContext(ctx, `
+			SELECT COUNT(*) FROM archived_messages_with_names
+			WHERE 1 = 1`+conditions, args...).Scan(&count)
+	})
+
+	return count, err
+}
+
+// withArchive calls fn with a connection where the archive database is
+// attached read-only and archived_messages_with_names is defined. fn isn't
+// called if none of chatJIDs (or no chat at all) has archived messages.
+func (s *MessageStore) withArchive(ctx context.Context, chatJIDs []string, fn func(conn *sql.Conn) error) error {
+	query := "SELECT EXISTS(SELECT 1 FROM archived_chats"
+	var args []any
+	if len(chatJIDs) > 0 {
+		query += " WHERE chat_jid IN (" + strings.TrimSuffix(strings.Repeat("?, ", len(chatJIDs)), ", ") + ")"
+		for _, jid := range chatJIDs {
+			args = append(args, jid)
+		}
+	}
+
+	var archived bool
+	if err := s.db.QueryRowContext(ctx, query+")", args...).Scan(&archived); err != nil {
+		return err
+	}
+	if !archived {
+		return nil
+	}
+
+	path := paths.ArchiveDBPath
+	if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("archive database %s is missing", path)
+	}
+
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "ATTACH DATABASE ? AS archive", "file:"+path+"?mode=ro"); err != nil {
+		return fmt.Errorf("failed to attach %s: %w", path, err)
+	}
+	defer conn.ExecContext(context.Background(), "DETACH DATABASE archive")
+
+	if _, err := conn.ExecContext(ctx, archivedMessagesView); err != nil {
+		return err
+	}
+	defer conn.ExecContext(context.Background(), "DROP VIEW IF EXISTS temp.archived_messages_with_names")
+
+	return fn(conn)
+}
@@ -0,0 +1,138 @@
+package storage
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Backfill states.
+const (
+	BackfillRunning   = "running"
+	BackfillDone      = "done"
+	BackfillFailed    = "failed"
+	BackfillCancelled = "cancelled"
+)
+
+// Backfill is a job requesting older history of a chat page by page, until a
+// target date or message count is reached.
+type Backfill struct {
+	ChatJID         string
+	TargetDate      *time.Time // go back to this date, nil for no date target
+	TargetCount     int        // messages to fetch, 0 for no count target
+	Fetched         int        // messages fetched so far
+	Pages           int        // history requests answered so far
+	OldestTimestamp *time.Time // oldest stored message of the chat, nil if unknown
+	Status          string     // running, done, failed or cancelled
+	LastError       string
+	StartedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+// TargetReached reports whether the backfill fetched enough messages or went
+// back far enough.
+func (b Backfill) TargetReached() bool {
+	if b.TargetCount > 0 && b.Fetched >= b.TargetCount {
+		return true
+	}
+	return b.TargetDate != nil && b.OldestTimestamp != nil && !b.OldestTimestamp.After(*b.TargetDate)
+}
+
+// backfillColumns is the column list selected from backfills. It must stay in
+// sync with scanBackfill.
+const backfillColumns = "chat_jid, target_date, target_count, fetched, pages, oldest_timestamp, status, last_error, started_at, updated_at"
+
+// SaveBackfill stores a backfill job, replacing the chat's previous one. Jobs
+// of chats that aren't stored are ignored.
+func (s *MessageStore) SaveBackfill(b Backfill) error {
+	_, err := s.db.Exec(`
+		INSERT INTO backfills (`+backfillColumns+`)
+		SELECT ?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8, ?9, ?10
+		WHERE EXISTS (SELECT 1 FROM chats WHERE jid = ?1)
+		ON CONFLICT(chat_jid) DO UPDATE SET
+		    target_date = excluded.target_date,
+		    target_count = excluded.target_count,
+		    fetched = excluded.fetched,
+		    pages = excluded.pages,
+		    oldest_timestamp = excluded.oldest_timestamp,
+		    status = excluded.status,
+		    last_error = excluded.last_error,
+		    started_at = excluded.started_at,
+		    updated_at = excluded.updated_at
+	`, b.ChatJID, unixOrNull(b.TargetDate), b.TargetCount, b.Fetched, b.Pages, unixOrNull(b.OldestTimestamp),
+		b.Status, b.LastError, b.StartedAt.Unix(), b.UpdatedAt.Unix())
+	return err
+}
+
+// GetBackfill returns the backfill job of a chat, or nil if it has none.
+func (s *MessageStore) GetBackfill(chatJID string) (*Backfill, error) {
+	b, err := scanBackfill(s.db.QueryRow("SELECT "+backfillColumns+" FROM backfills WHERE chat_jid = ?", chatJID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// ListBackfills returns backfill jobs, most recently updated first, optionally
+// only those in a state.
+func (s *MessageStore) ListBackfills(status string) ([]Backfill, error) {
+	rows, err := s.db.Query(`
+		SELECT `+backfillColumns+`
+		FROM backfills
+		WHERE ?1 = '' OR status = ?1
+		ORDER BY updated_at DESC, chat_jid
+	`, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var backfills []Backfill
+	for rows.Next() {
+		b, err := scanBackfill(rows)
+		if err != nil {
+			return nil, err
+		}
+		backfills = append(backfills, b)
+	}
+
+	return backfills, rows.Err()
+}
+
+// scanBackfill scans a row selected with backfillColumns.
+func scanBackfill(row interface{ Scan(dest ...any) error }) (Backfill, error) {
+	var b Backfill
+	var targetDate, oldest sql.NullInt64
+	var startedAt, updatedAt int64
+
+	err := row.Scan(&b.ChatJID, &targetDate, &b.TargetCount, &b.Fetched, &b.Pages, &oldest,
+		&b.Status, &b.LastError, &startedAt, &updatedAt)
+	if err != nil {
+		return Backfill{}, err
+	}
+
+	b.TargetDate = timeOrNil(targetDate)
+	b.OldestTimestamp = timeOrNil(oldest)
+	b.StartedAt = time.Unix(startedAt, 0)
+	b.UpdatedAt = time.Unix(updatedAt, 0)
+	return b, nil
+}
+
+// unixOrNull returns t as a Unix timestamp, or nil for a NULL column.
+func unixOrNull(t *time.Time) any {
+	if t == nil {
+		return nil
+	}
+	return t.Unix()
+}
+
+// timeOrNil returns a nullable Unix timestamp column as a time.
+func timeOrNil(unix sql.NullInt64) *time.Time {
+	if !unix.Valid {
+		return nil
+	}
+	t := time.Unix(unix.Int64, 0)
+	return &t
+}
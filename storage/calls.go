@@ -0,0 +1,122 @@
+package storage
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Call statuses.
+const (
+	CallRinging  = "ringing"
+	CallAnswered = "answered"
+	CallRejected = "rejected"
+	CallMissed   = "missed"
+)
+
+// Call represents an incoming voice or video call.
+type Call struct {
+	ID        string
+	CallerJID string
+	ChatJID   string // the caller, or the group of a group call
+	IsVideo   bool
+	IsGroup   bool
+	Status    string // ringing, answered, rejected or missed
+	OfferedAt time.Time
+	EndedAt   *time.Time // nil while the call is going on
+	EndReason string
+}
+
+// callColumns is the column list selected from calls. It must stay in sync
+// with scanCall.
+const callColumns = "id, caller_jid, chat_jid, is_video, is_group, status, offered_at, ended_at, end_reason"
+
+// SaveCallOffer stores an incoming call. Offers of a call already stored are
+// ignored.
+func (s *MessageStore) SaveCallOffer(call Call) error {
+	_, err := s.db.Exec(`
+		INSERT OR IGNORE INTO calls (id, caller_jid, chat_jid, is_video, is_group, status, offered_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, call.ID, call.CallerJID, call.ChatJID, call.IsVideo, call.IsGroup, CallRinging, call.OfferedAt.Unix())
+
+	return err
+}
+
+// SetCallStatus records that a ringing call was answered or rejected.
+func (s *MessageStore) SetCallStatus(id, status string) error {
+	_, err := s.db.Exec("UPDATE calls SET status = ? WHERE id = ? AND status = ?", status, id, CallRinging)
+	return err
+}
+
+// EndCall records the end of a call, which is missed if it was still ringing,
+// and returns it. It returns nil if the call is not stored.
+func (s *MessageStore) EndCall(id string, endedAt time.Time, reason string) (*Call, error) {
+	_, err := s.db.Exec(`
+		UPDATE calls SET
+		    status = CASE WHEN status = ? THEN ? ELSE status END,
+		    ended_at = COALESCE(ended_at, ?),
+		    end_reason = COALESCE(end_reason, NULLIF(?, ''))
+		WHERE id = ?
+	`, CallRinging, CallMissed, endedAt.Unix(), reason, id)
+	if err != nil {
+		return nil, err
+	}
+
+	call, err := scanCall(s.db.QueryRow("SELECT "+callColumns+" FROM calls WHERE id = ?", id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &call, nil
+}
+
+// ListCalls returns calls newest first, optionally only those of a chat or
+// with a status.
+func (s *MessageStore) ListCalls(chatJID, status string, limit int) ([]Call, error) {
+	rows, err := s.db.Query(`
+		SELECT `+callColumns+`
+		FROM calls
+		WHERE (?1 = '' OR chat_jid = ?1) AND (?2 = '' OR status = ?2)
+		ORDER BY offered_at DESC, id
+		LIMIT ?3
+	`, chatJID, status, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var calls []Call
+	for rows.Next() {
+		call, err := scanCall(rows)
+		if err != nil {
+			return nil, err
+		}
+		calls = append(calls, call)
+	}
+
+	return calls, rows.Err()
+}
+
+// scanCall scans a row selected with callColumns.
+func scanCall(row interface{ Scan(dest ...any) error }) (Call, error) {
+	var call Call
+	var offeredAt int64
+	var endedAt sql.NullInt64
+	var endReason sql.NullString
+
+	err := row.Scan(&call.ID, &call.CallerJID, &call.ChatJID, &call.IsVideo, &call.IsGroup,
+		&call.Status, &offeredAt, &endedAt, &endReason)
+	if err != nil {
+		return Call{}, err
+	}
+
+	call.OfferedAt = time.Unix(offeredAt, 0)
+	if endedAt.Valid {
+		t := time.Unix(endedAt.Int64, 0)
+		call.EndedAt = &t
+	}
+	call.EndReason = endReason.String
+
+	return call, nil
+}
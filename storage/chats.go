@@ -1,34 +1,47 @@
 package storage
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"strings"
 	"time"
 )
 
 // Chat represents a WhatsApp conversation.
 type Chat struct {
-	JID             string // canonical JID (required)
-	PushName        string // sender's WhatsApp display name (from PushName in messages)
-	ContactName     string // saved contact name (from WhatsApp contact store)
-	LastMessageTime time.Time
-	UnreadCount     int
-	IsGroup         bool
+	JID               string // canonical JID (required)
+	PushName          string // sender's WhatsApp display name (from PushName in messages)
+	ContactName       string // saved contact name (from WhatsApp contact store)
+	LastMessageTime   time.Time
+	UnreadCount       int  // incoming messages after the last one I read or sent (derived, not saved)
+	MarkedUnread      bool // marked unread on one of my devices (not saved)
+	IsGroup           bool
+	DisappearingTimer int       // disappearing messages timer in seconds (0 = off)
+	MessageCount      int       // stored messages (maintained by triggers, not saved)
+	LastSenderJID     string    // sender of the newest stored message (maintained by triggers, not saved)
+	Muted             bool      // muted on my devices (not saved, see SetChatMuted)
+	MutedUntil        time.Time // end of the mute, zero when muted forever
+	Archived          bool      // archived on my devices (not saved, see SetChatArchived)
+	PinnedAt          time.Time // when the chat was pinned, zero if not pinned (not saved, see SetChatPinned)
 }
 
-// GetChatByJID retrieves a chat by its canonical JID.
-// It returns nil if the chat is not found.
-func (s *MessageStore) GetChatByJID(jid string) (*Chat, error) {
-	query := `
-	SELECT jid, push_name, contact_name, last_message_time, unread_count, is_group
-	FROM chats
-	WHERE jid = ?
-	`
+// ChatFilter narrows down ListChats. Nil fields don't filter.
+type ChatFilter struct {
+	Tag      string // only chats with this tag
+	Archived *bool
+	Pinned   *bool
+	Muted    *bool
+}
 
-	row := s.db.QueryRow(query, jid)
+// chatColumns is the column list selected from the chats_with_unread view.
+// It must stay in sync with scanChat.
+const chatColumns = `jid, push_name, contact_name, last_message_time, unread_count, is_group, disappearing_timer, message_count, COALESCE(last_sender_jid, ''), marked_unread, muted_until, archived, pinned_at`
 
+// scanChat scans a single chats row selected with chatColumns.
+func scanChat(row interface{ Scan(dest ...any) error }) (Chat, error) {
 	var chat Chat
-	var lastMsgUnix int64
+	var lastMsgUnix, mutedUntil, pinnedAt int64
 
 	err := row.Scan(
 		&chat.JID,
@@ -37,8 +50,42 @@ func (s *MessageStore) GetChatByJID(jid string) (*Chat, error) {
 		&lastMsgUnix,
 		&chat.UnreadCount,
 		&chat.IsGroup,
+		&chat.DisappearingTimer,
+		&chat.MessageCount,
+		&chat.LastSenderJID,
+		&chat.MarkedUnread,
+		&mutedUntil,
+		&chat.Archived,
+		&pinnedAt,
 	)
+	if err != nil {
+		return chat, err
+	}
+
+	chat.LastMessageTime = time.Unix(lastMsgUnix, 0)
+	switch {
+	case mutedUntil == -1:
+		chat.Muted = true
+	case mutedUntil > time.Now().Unix():
+		chat.Muted = true
+		chat.MutedUntil = time.Unix(mutedUntil, 0)
+	}
+	if pinnedAt > 0 {
+		chat.PinnedAt = time.Unix(pinnedAt, 0)
+	}
+	return chat, nil
+}
 
+// GetChatByJID retrieves a chat by its canonical JID.
+// It returns nil if the chat is not found.
+func (s *MessageStore) GetChatByJID(jid string) (*Chat, error) {
+	query := `
+	SELECT ` + chatColumns + `
+	FROM chats_with_unread
+	WHERE jid = ?
+	`
+
+	chat, err := scanChat(s.db.QueryRow(query, jid))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -47,50 +94,86 @@ func (s *MessageStore) GetChatByJID(jid string) (*Chat, error) {
 		return nil, err
 	}
 
-	chat.LastMessageTime = time.Unix(lastMsgUnix, 0)
 	return &chat, nil
 }
 
-// SaveChat saves or updates chat information in the database.
-func (s *MessageStore) SaveChat(chat Chat) error {
-	if chat.JID == "" {
-		return fmt.Errorf("chat JID cannot be empty")
-	}
-
-	query := `
-	INSERT INTO chats (jid, push_name, contact_name, last_message_time, unread_count, is_group)
-	VALUES (?, ?, ?, ?, ?, ?)
+// saveChatQuery inserts or updates a chat, keeping stored names when the new
+// ones are empty.
+const saveChatQuery = `
+	INSERT INTO chats (jid, push_name, contact_name, last_message_time, is_group)
+	VALUES (?, ?, ?, ?, ?)
 	ON CONFLICT(jid) DO UPDATE SET
 	    push_name = COALESCE(NULLIF(excluded.push_name, ''), chats.push_name),
 	    contact_name = COALESCE(NULLIF(excluded.contact_name, ''), chats.contact_name),
 	    last_message_time = excluded.last_message_time,
-	    unread_count = excluded.unread_count,
 	    is_group = excluded.is_group
 	`
 
+// SaveChat saves or updates chat information in the database.
+func (s *MessageStore) SaveChat(chat Chat) error {
+	if chat.JID == "" {
+		return fmt.Errorf("chat JID cannot be empty")
+	}
+
 	_, err := s.db.Exec(
-		query,
+		saveChatQuery,
 		chat.JID,
 		chat.PushName,
 		chat.ContactName,
 		chat.LastMessageTime.Unix(),
-		chat.UnreadCount,
 		chat.IsGroup,
 	)
 
 	return err
 }
 
-// ListChats returns all chats ordered by last message timestamp.
-func (s *MessageStore) ListChats(limit int) ([]Chat, error) {
+// ListChats returns chats ordered by last message timestamp, newest first.
+// If cursor is not nil, only chats after it (the last chat of the previous page) are returned.
+// Only chats matching filter are returned.
+func (s *MessageStore) ListChats(limit int, cursor *PageCursor, filter ChatFilter) ([]Chat, error) {
 	query := `
-	SELECT jid, push_name, contact_name, last_message_time, unread_count, is_group
-	FROM chats
-	ORDER BY last_message_time DESC
-	LIMIT ?
+	SELECT ` + chatColumns + `
+	FROM chats_with_unread
+	WHERE 1 = 1
 	`
+	var args []any
+
+	if filter.Tag != "" {
+		query += " AND jid IN (SELECT chat_jid FROM chat_tags WHERE tag = ?)"
+		args = append(args, filter.Tag)
+	}
 
-	rows, err := s.db.Query(query, limit)
+	if filter.Archived != nil {
+		query += " AND archived = ?"
+		args = append(args, *filter.Archived)
+	}
+
+	if filter.Pinned != nil {
+		if *filter.Pinned {
+			query += " AND pinned_at > 0"
+		} else {
+			query += " AND pinned_at = 0"
+		}
+	}
+
+	if filter.Muted != nil {
+		if *filter.Muted {
+			query += " AND (muted_until = -1 OR muted_until > ?)"
+		} else {
+			query += " AND muted_until != -1 AND muted_until <= ?"
+		}
+		args = append(args, time.Now().Unix())
+	}
+
+	if cursor != nil {
+		query += " AND (last_message_time < ? OR (last_message_time = ? AND jid < ?))"
+		args = append(args, cursor.Timestamp.Unix(), cursor.Timestamp.Unix(), cursor.Key)
+	}
+
+	query += " ORDER BY last_message_time DESC, jid DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := s.db.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -98,28 +181,55 @@ func (s *MessageStore) ListChats(limit int) ([]Chat, error) {
 
 	var chats []Chat
 	for rows.Next() {
-		var chat Chat
-		var lastMsgUnix int64
-
-		err := rows.Scan(
-			&chat.JID,
-			&chat.PushName,
-			&chat.ContactName,
-			&lastMsgUnix,
-			&chat.UnreadCount,
-			&chat.IsGroup,
-		)
+		chat, err := scanChat(rows)
 		if err != nil {
 			return nil, err
 		}
-
-		chat.LastMessageTime = time.Unix(lastMsgUnix, 0)
 		chats = append(chats, chat)
 	}
 
 	return chats, rows.Err()
 }
 
+// ForEachChat streams chats, oldest activity first, to fn. If jids is not
+// empty only those chats are returned.
+// Iteration stops at the first error returned by fn or when ctx is cancelled.
+func (s *MessageStore) ForEachChat(ctx context.Context, jids []string, fn func(Chat) error) error {
+	query := `
+	SELECT ` + chatColumns + `
+	FROM chats_with_unread
+	`
+	var args []any
+
+	if len(jids) > 0 {
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(jids)), ", ")
+		query += " WHERE jid IN (" + placeholders + ")"
+		for _, jid := range jids {
+			args = append(args, jid)
+		}
+	}
+
+	query += " ORDER BY last_message_time ASC, jid ASC"
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		chat, err := scanChat(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(chat); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
 // SearchChatsFiltered searches chats with pattern matching.
 // It uses GLOB patterns if useGlob is true, otherwise uses LIKE for fuzzy matching.
 func (s *MessageStore) SearchChatsFiltered(search string, useGlob bool, limit int) ([]Chat, error) {
@@ -129,8 +239,8 @@ func (s *MessageStore) SearchChatsFiltered(search string, useGlob bool, limit in
 	// choose LIKE or GLOB based on pattern type
 	if useGlob {
 		query = `
-		SELECT jid, push_name, contact_name, last_message_time, unread_count, is_group
-		FROM chats
+		SELECT ` + chatColumns + `
+		FROM chats_with_unread
 		WHERE push_name GLOB ? OR contact_name GLOB ? OR jid GLOB ?
 		ORDER BY last_message_time DESC
 		LIMIT ?
@@ -138,8 +248,8 @@ func (s *MessageStore) SearchChatsFiltered(search string, useGlob bool, limit in
 		searchPattern = search
 	} else {
 		query = `
-		SELECT jid, push_name, contact_name, last_message_time, unread_count, is_group
-		FROM chats
+		SELECT ` + chatColumns + `
+		FROM chats_with_unread
 		WHERE push_name LIKE ? OR contact_name LIKE ? OR jid LIKE ?
 		ORDER BY last_message_time DESC
 		LIMIT ?
@@ -155,22 +265,10 @@ func (s *MessageStore) SearchChatsFiltered(search string, useGlob bool, limit in
 
 	var chats []Chat
 	for rows.Next() {
-		var chat Chat
-		var lastMsgUnix int64
-
-		err := rows.Scan(
-			&chat.JID,
-			&chat.PushName,
-			&chat.ContactName,
-			&lastMsgUnix,
-			&chat.UnreadCount,
-			&chat.IsGroup,
-		)
+		chat, err := scanChat(rows)
 		if err != nil {
 			return nil, err
 		}
-
-		chat.LastMessageTime = time.Unix(lastMsgUnix, 0)
 		chats = append(chats, chat)
 	}
 
@@ -180,8 +278,8 @@ func (s *MessageStore) SearchChatsFiltered(search string, useGlob bool, limit in
 // SearchChats searches chats by name or JID with fuzzy matching.
 func (s *MessageStore) SearchChats(search string, limit int) ([]Chat, error) {
 	query := `
-	SELECT jid, push_name, contact_name, last_message_time, unread_count, is_group
-	FROM chats
+	SELECT ` + chatColumns + `
+	FROM chats_with_unread
 	WHERE push_name LIKE ? OR contact_name LIKE ? OR jid LIKE ?
 	ORDER BY last_message_time DESC
 	LIMIT ?
@@ -196,24 +294,106 @@ func (s *MessageStore) SearchChats(search string, limit int) ([]Chat, error) {
 
 	var chats []Chat
 	for rows.Next() {
-		var chat Chat
-		var lastMsgUnix int64
-
-		err := rows.Scan(
-			&chat.JID,
-			&chat.PushName,
-			&chat.ContactName,
-			&lastMsgUnix,
-			&chat.UnreadCount,
-			&chat.IsGroup,
-		)
+		chat, err := scanChat(rows)
 		if err != nil {
 			return nil, err
 		}
-
-		chat.LastMessageTime = time.Unix(lastMsgUnix, 0)
 		chats = append(chats, chat)
 	}
 
 	return chats, rows.Err()
 }
+
+// SetDisappearingTimer stores the disappearing messages timer (in seconds) of a chat.
+func (s *MessageStore) SetDisappearingTimer(jid string, seconds int) error {
+	_, err := s.db.Exec("UPDATE chats SET disappearing_timer = ? WHERE jid = ?", seconds, jid)
+	return err
+}
+
+// MarkChatRead records that a chat was read up to readAt, which also clears
+// "marked unread". Incoming messages after readAt count as unread.
+func (s *MessageStore) MarkChatRead(jid string, readAt time.Time) error {
+	_, err := s.db.Exec(`
+		UPDATE chats SET read_at = MAX(COALESCE(read_at, 0), ?), marked_unread = FALSE
+		WHERE jid = ?
+	`, readAt.Unix(), jid)
+	return err
+}
+
+// SetChatMarkedUnread stores WhatsApp's "marked unread" state of a chat.
+func (s *MessageStore) SetChatMarkedUnread(jid string, markedUnread bool) error {
+	_, err := s.db.Exec("UPDATE chats SET marked_unread = ? WHERE jid = ?", markedUnread, jid)
+	return err
+}
+
+// SetChatMuted stores until when a chat is muted: a zero time unmutes it,
+// forever mutes it without an end.
+func (s *MessageStore) SetChatMuted(jid string, muted, forever bool, until time.Time) error {
+	var mutedUntil int64
+	switch {
+	case !muted:
+	case forever:
+		mutedUntil = -1
+	default:
+		mutedUntil = until.Unix()
+	}
+	_, err := s.db.Exec("UPDATE chats SET muted_until = ? WHERE jid = ?", mutedUntil, jid)
+	return err
+}
+
+// SetChatArchived stores whether a chat is archived.
+func (s *MessageStore) SetChatArchived(jid string, archived bool) error {
+	_, err := s.db.Exec("UPDATE chats SET archived = ? WHERE jid = ?", archived, jid)
+	return err
+}
+
+// SetChatPinned stores when a chat was pinned, a zero time unpins it.
+func (s *MessageStore) SetChatPinned(jid string, pinnedAt time.Time) error {
+	var ts int64
+	if !pinnedAt.IsZero() {
+		ts = pinnedAt.Unix()
+	}
+	_, err := s.db.Exec("UPDATE chats SET pinned_at = ? WHERE jid = ?", ts, jid)
+	return err
+}
+
+// GetUnreadMessages returns the IDs and senders of the incoming messages of a
+// chat that are counted as unread, oldest first.
+func (s *MessageStore) GetUnreadMessages(jid string) ([]Message, error) {
+	rows, err := s.db.Query(`
+		SELECT m.id, m.sender_jid, m.timestamp
+		FROM messages m
+		JOIN chats c ON c.jid = m.chat_jid
+		WHERE m.chat_jid = ?1
+		  AND m.is_from_me = FALSE
+		  AND m.deleted_at IS NULL
+		  AND m.timestamp > MAX(
+		      COALESCE((SELECT MAX(timestamp) FROM messages WHERE chat_jid = ?1 AND is_from_me = TRUE), 0),
+		      COALESCE((
+		          SELECT MAX(rm.timestamp)
+		          FROM receipts r
+		          JOIN messages rm ON rm.id = r.message_id
+		          WHERE r.chat_jid = ?1 AND r.status = 'read' AND rm.is_from_me = FALSE
+		      ), 0),
+		      COALESCE(c.read_at, 0)
+		  )
+		ORDER BY m.timestamp, m.id
+	`, jid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var messages []Message
+	for rows.Next() {
+		msg := Message{ChatJID: jid}
+		var ts int64
+		if err := rows.Scan(&msg.ID, &msg.SenderJID, &ts); err != nil {
+			return nil, err
+		}
+		msg.Timestamp = time.Unix(ts, 0)
+		messages = append(messages, msg)
+	}
+
+	return messages, rows.Err()
+}
@@ -0,0 +1,65 @@
+package storage
+
+// CompleteChatJIDs returns chat JIDs starting with value or whose chat name
+// contains a word starting with value, most recently active first.
+// isGroup restricts the results to groups or direct chats when not nil.
+func (s *MessageStore) CompleteChatJIDs(value string, isGroup *bool, limit int) ([]string, error) {
+	query := `
+	SELECT jid
+	FROM chats
+	WHERE (jid LIKE ? OR contact_name LIKE ? OR contact_name LIKE ? OR push_name LIKE ? OR push_name LIKE ?)
+	`
+	prefix, wordPrefix := value+"%", "% "+value+"%"
+	args := []any{prefix, prefix, wordPrefix, prefix, wordPrefix}
+
+	if isGroup != nil {
+		query += " AND is_group = ?"
+		args = append(args, *isGroup)
+	}
+
+	query += " ORDER BY last_message_time DESC LIMIT ?"
+	args = append(args, limit)
+
+	return s.queryStrings(query, args...)
+}
+
+// CompleteNames returns contact and push names with a word starting with value,
+// most recently active first.
+func (s *MessageStore) CompleteNames(value string, limit int) ([]string, error) {
+	query := `
+	SELECT name
+	FROM (
+	    SELECT COALESCE(NULLIF(contact_name, ''), push_name) AS name, last_message_time AS ts
+	    FROM chats
+	    WHERE is_group = FALSE
+	    UNION ALL
+	    SELECT push_name, updated_at FROM push_names
+	)
+	WHERE name != '' AND (name LIKE ? OR name LIKE ?)
+	GROUP BY name
+	ORDER BY MAX(ts) DESC
+	LIMIT ?
+	`
+
+	return s.queryStrings(query, value+"%", "% "+value+"%", limit)
+}
+
+// queryStrings runs a query selecting a single text column.
+func (s *MessageStore) queryStrings(query string, args ...any) ([]string, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var values []string
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+
+	return values, rows.Err()
+}
@@ -0,0 +1,226 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Contact is the local copy of a WhatsApp contact.
+type Contact struct {
+	JID          string // Canonical JID
+	PhoneNumber  string // E.164 phone number (empty if unknown)
+	FullName     string // saved contact name
+	FirstName    string
+	PushName     string // WhatsApp display name
+	BusinessName string // verified business name
+}
+
+// DisplayName returns the saved contact name, falling back to the business name.
+// It returns an empty string if the contact isn't saved.
+func (c Contact) DisplayName() string {
+	switch {
+	case c.FullName != "":
+		return c.FullName
+	case c.FirstName != "":
+		return c.FirstName
+	default:
+		return c.BusinessName
+	}
+}
+
+// SaveContact stores a contact. Empty fields don't overwrite known values.
+func (s *MessageStore) SaveContact(contact Contact) error {
+	return s.SaveContacts([]Contact{contact})
+}
+
+// SaveContacts stores multiple contacts in a single transaction.
+// Empty fields don't overwrite known values.
+func (s *MessageStore) SaveContacts(contacts []Contact) error {
+	if len(contacts) == 0 {
+		return nil
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO contacts (jid, phone_number, full_name, first_name, push_name, business_name, updated_at)
+		VALUES (?, NULLIF(?, ''), NULLIF(?, ''), NULLIF(?, ''), NULLIF(?, ''), NULLIF(?, ''), ?)
+		ON CONFLICT(jid) DO UPDATE SET
+			phone_number = COALESCE(excluded.phone_number, contacts.phone_number),
+			full_name = COALESCE(excluded.full_name, contacts.full_name),
+			first_name = COALESCE(excluded.first_name, contacts.first_name),
+			push_name = COALESCE(excluded.push_name, contacts.push_name),
+			business_name = COALESCE(excluded.business_name, contacts.business_name),
+			updated_at = excluded.updated_at
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	now := time.Now().Unix()
+	for _, c := range contacts {
+		_, err := stmt.Exec(c.JID, c.PhoneNumber, c.FullName, c.FirstName, c.PushName, c.BusinessName, now)
+		if err != nil {
+			return fmt.Errorf("failed to save contact %s: %w", c.JID, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetContact returns a stored contact by canonical JID.
+// It returns nil if the contact is not stored.
+func (s *MessageStore) GetContact(jid string) (*Contact, error) {
+	var c Contact
+	var phone, fullName, firstName, pushName, businessName sql.NullString
+
+	err := s.db.QueryRow(`
+		SELECT jid, phone_number, full_name, first_name, push_name, business_name
+		FROM contacts
+		WHERE jid = ?
+	`, jid).Scan(&c.JID, &phone, &fullName, &firstName, &pushName, &businessName)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	c.PhoneNumber = phone.String
+	c.FullName = fullName.String
+	c.FirstName = firstName.String
+	c.PushName = pushName.String
+	c.BusinessName = businessName.String
+	return &c, nil
+}
+
+// FillChatContactNames sets the contact name of direct chats to the name of
+// their stored contact (full name, first name or business name), for chats
+// created before the contact was known. It returns how many chats changed.
+func (s *MessageStore) FillChatContactNames() (int, error) {
+	result, err := s.db.Exec(`
+		UPDATE chats SET contact_name = (
+		    SELECT COALESCE(NULLIF(ct.full_name, ''), NULLIF(ct.first_name, ''), ct.business_name)
+		    FROM contacts ct WHERE ct.jid = chats.jid
+		)
+		WHERE is_group = 0 AND EXISTS (
+		    SELECT 1 FROM contacts ct
+		    WHERE ct.jid = chats.jid
+		      AND COALESCE(NULLIF(ct.full_name, ''), NULLIF(ct.first_name, ''), ct.business_name, '') != ''
+		      AND COALESCE(NULLIF(ct.full_name, ''), NULLIF(ct.first_name, ''), ct.business_name) != COALESCE(chats.contact_name, '')
+		)
+	`)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := result.RowsAffected()
+	return int(n), err
+}
+
+// FindContactJIDsByName returns the JIDs whose saved contact name, WhatsApp
+// display name or business name equals name, ignoring case.
+func (s *MessageStore) FindContactJIDsByName(name string) ([]string, error) {
+	rows, err := s.db.Query(`
+		SELECT jid FROM contacts
+		WHERE full_name = ?1 COLLATE NOCASE OR push_name = ?1 COLLATE NOCASE OR business_name = ?1 COLLATE NOCASE
+		UNION
+		SELECT jid FROM chats
+		WHERE is_group = 0 AND (contact_name = ?1 COLLATE NOCASE OR push_name = ?1 COLLATE NOCASE)
+		UNION
+		SELECT jid FROM push_names
+		WHERE push_name = ?1 COLLATE NOCASE
+	`, name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jids []string
+	for rows.Next() {
+		var jid string
+		if err := rows.Scan(&jid); err != nil {
+			return nil, err
+		}
+		jids = append(jids, jid)
+	}
+
+	return jids, rows.Err()
+}
+
+// ContactStats aggregates the message activity of a contact across all chats.
+type ContactStats struct {
+	JID          string
+	SentCount    int        // messages sent by the contact (DMs and groups)
+	FromMeCount  int        // messages I sent in the direct chat with the contact
+	LastActivity *time.Time // last message sent by the contact (nil if none)
+}
+
+// GetContactStats returns message counts and last activity for a contact.
+func (s *MessageStore) GetContactStats(jid string) (*ContactStats, error) {
+	stats := &ContactStats{JID: jid}
+
+	var lastActivity sql.NullInt64
+	err := s.db.QueryRow(`
+		SELECT COUNT(*), MAX(timestamp)
+		FROM messages
+		WHERE sender_jid = ? AND is_from_me = 0
+	`, jid).Scan(&stats.SentCount, &lastActivity)
+	if err != nil {
+		return nil, err
+	}
+
+	if lastActivity.Valid {
+		t := time.Unix(lastActivity.Int64, 0)
+		stats.LastActivity = &t
+	}
+
+	err = s.db.QueryRow(`
+		SELECT COUNT(*)
+		FROM messages
+		WHERE chat_jid = ? AND is_from_me = 1
+	`, jid).Scan(&stats.FromMeCount)
+	if err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+// GetSharedGroups returns the groups a contact is known to be in, either from
+// the synced participant list or because they sent a message there.
+func (s *MessageStore) GetSharedGroups(jid string) ([]Chat, error) {
+	query := `
+	SELECT ` + chatColumns + `
+	FROM chats_with_unread
+	WHERE is_group = 1 AND jid IN (
+		SELECT group_jid FROM group_participants WHERE participant_jid = ?
+		UNION
+		SELECT DISTINCT chat_jid FROM messages WHERE sender_jid = ?
+	)
+	ORDER BY last_message_time DESC
+	`
+
+	rows, err := s.db.Query(query, jid, jid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var groups []Chat
+	for rows.Next() {
+		chat, err := scanChat(rows)
+		if err != nil {
+			return nil, err
+		}
+		groups = append(groups, chat)
+	}
+
+	return groups, rows.Err()
+}
@@ -0,0 +1,88 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// ChatActivity summarizes the messages of a chat within a time window.
+type ChatActivity struct {
+	ChatJID         string
+	ChatName        string
+	IsGroup         bool
+	MessageCount    int
+	FromMeCount     int
+	UnreadCount     int
+	UnansweredCount int    // incoming messages after my last message in the window
+	LastIncoming    string // text of the latest incoming message in the window
+	LastMessageTime time.Time
+}
+
+// GetChatActivity returns per-chat message counts since the given time,
+// busiest chats first. It is computed in SQL so no messages are loaded.
+func (s *MessageStore) GetChatActivity(ctx context.Context, since time.Time, limit int) ([]ChatActivity, error) {
+	query := `
+	SELECT
+	    m.chat_jid,
+	    COALESCE(NULLIF(c.contact_name, ''), NULLIF(c.push_name, ''), m.chat_jid),
+	    COALESCE(c.is_group, FALSE),
+	    COUNT(*),
+	    SUM(CASE WHEN m.is_from_me THEN 1 ELSE 0 END),
+	    COALESCE(c.unread_count, 0),
+	    SUM(CASE WHEN NOT m.is_from_me AND m.timestamp > COALESCE(r.last_reply, 0) THEN 1 ELSE 0 END),
+	    (
+	        SELECT text FROM messages
+	        WHERE chat_jid = m.chat_jid AND is_from_me = FALSE AND timestamp >= ? AND text != ''
+	        ORDER BY timestamp DESC LIMIT 1
+	    ),
+	    MAX(m.timestamp)
+	FROM messages m
+	LEFT JOIN chats_with_unread c ON c.jid = m.chat_jid
+	LEFT JOIN (
+	    SELECT chat_jid, MAX(timestamp) AS last_reply
+	    FROM messages
+	    WHERE is_from_me = TRUE AND timestamp >= ?
+	    GROUP BY chat_jid
+	) r ON r.chat_jid = m.chat_jid
+	WHERE m.timestamp >= ?
+	GROUP BY m.chat_jid
+	ORDER BY COUNT(*) DESC
+	LIMIT ?
+	`
+
+	sinceUnix := since.Unix()
+	rows, err := s.db.QueryContext(ctx, query, sinceUnix, sinceUnix, sinceUnix, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var activity []ChatActivity
+	for rows.Next() {
+		var a ChatActivity
+		var lastIncoming sql.NullString
+		var lastMsgUnix int64
+
+		err := rows.Scan(
+			&a.ChatJID,
+			&a.ChatName,
+			&a.IsGroup,
+			&a.MessageCount,
+			&a.FromMeCount,
+			&a.UnreadCount,
+			&a.UnansweredCount,
+			&lastIncoming,
+			&lastMsgUnix,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		a.LastIncoming = lastIncoming.String
+		a.LastMessageTime = time.Unix(lastMsgUnix, 0)
+		activity = append(activity, a)
+	}
+
+	return activity, rows.Err()
+}
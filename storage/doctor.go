@@ -0,0 +1,332 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"whatsapp-mcp/paths"
+)
+
+// DoctorReport lists the problems found by Doctor.
+type DoctorReport struct {
+	Integrity      []string       // integrity_check errors (empty if the file is sound)
+	MissingChats   []string       // chats referenced by messages but not stored
+	Orphans        map[string]int // other rows whose parent row is missing, by table
+	MissingFiles   []string       // message IDs of downloaded media whose file is gone
+	UntrackedFiles []string       // files in the media directory no media row points at
+	CountDrift     int            // chats whose cached message count is wrong
+	Fixed          bool           // the repairable problems were repaired
+}
+
+// OK reports whether no problem was found.
+func (r *DoctorReport) OK() bool {
+	return len(r.Integrity) == 0 && len(r.MissingChats) == 0 && len(r.Orphans) == 0 &&
+		len(r.MissingFiles) == 0 && len(r.UntrackedFiles) == 0 && r.CountDrift == 0
+}
+
+// Doctor checks the database for corruption and for rows and media files that
+// lost their counterpart. With fix, everything but corruption is repaired:
+// missing chats are recreated from their messages, other orphaned rows are
+// deleted, media whose file is gone goes back to pending so it's downloaded
+// again, untracked media files are deleted and cached message counts are
+// recomputed. Corruption can only be fixed by restoring a backup.
+func Doctor(ctx context.Context, db *sql.DB, mediaDir string, fix bool) (*DoctorReport, error) {
+	report := &DoctorReport{Orphans: make(map[string]int)}
+
+	integrity, err := integrityErrors(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	report.Integrity = integrity
+
+	if report.MissingChats, err = queryStrings(ctx, db, `
+		SELECT DISTINCT chat_jid FROM messages
+		WHERE chat_jid NOT IN (SELECT jid FROM chats)
+		ORDER BY chat_jid
+	`); err != nil {
+		return nil, fmt.Errorf("failed to check chats: %w", err)
+	}
+
+	orphans, err := foreignKeyOrphans(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	for _, o := range orphans {
+		if o.table != "messages" {
+			report.Orphans[o.table]++
+		}
+	}
+
+	tracked, err := mediaFiles(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	for messageID, path := range tracked {
+		if _, err := os.Stat(filepath.Join(mediaDir, filepath.Clean(path))); errors.Is(err, fs.ErrNotExist) {
+			report.MissingFiles = append(report.MissingFiles, messageID)
+		}
+	}
+
+	// any file a media row points at is in use, whatever its download
+	// status (an interrupted download may be retried into it), as are
+	// generated thumbnails and the files of archived media
+	inUse, err := queryStrings(ctx, db, `
+		SELECT file_path FROM media_metadata WHERE COALESCE(file_path, '') != ''
+		UNION ALL
+		SELECT thumbnail_path FROM media_metadata WHERE COALESCE(thumbnail_path, '') != ''
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check media: %w", err)
+	}
+	archived, err := archivedMediaFiles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if report.UntrackedFiles, err = untrackedFiles(mediaDir, append(inUse, archived...)); err != nil {
+		return nil, fmt.Errorf("failed to scan %s: %w", mediaDir, err)
+	}
+
+	err = db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM chats
+		WHERE message_count != (SELECT COUNT(*) FROM messages WHERE chat_jid = chats.jid)
+	`).Scan(&report.CountDrift)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check message counts: %w", err)
+	}
+
+	if !fix || report.OK() {
+		return report, nil
+	}
+
+	if err := repair(ctx, db, mediaDir, report, orphans); err != nil {
+		return report, err
+	}
+	report.Fixed = true
+	return report, nil
+}
+
+// fkOrphan is a row reported by foreign_key_check.
+type fkOrphan struct {
+	table string
+	rowid int64
+}
+
+// integrityErrors runs integrity_check and returns its errors.
+func integrityErrors(ctx context.Context, db *sql.DB) ([]string, error) {
+	results, err := queryStrings(ctx, db, "PRAGMA integrity_check")
+	if err != nil {
+		return nil, fmt.Errorf("failed to check integrity: %w", err)
+	}
+	if len(results) == 1 && results[0] == "ok" {
+		return nil, nil
+	}
+	return results, nil
+}
+
+// foreignKeyOrphans returns the rows whose foreign key points at a missing row.
+func foreignKeyOrphans(ctx context.Context, db *sql.DB) ([]fkOrphan, error) {
+	rows, err := db.QueryContext(ctx, "PRAGMA foreign_key_check")
+	if err != nil {
+		return nil, fmt.Errorf("failed to check foreign keys: %w", err)
+	}
+	defer rows.Close()
+
+	var orphans []fkOrphan
+	for rows.Next() {
+		var o fkOrphan
+		var rowid sql.NullInt64
+		var parent string
+		var fkid int
+		if err := rows.Scan(&o.table, &rowid, &parent, &fkid); err != nil {
+			return nil, err
+		}
+		o.rowid = rowid.Int64
+		orphans = append(orphans, o)
+	}
+
+	return orphans, rows.Err()
+}
+
+// mediaFiles returns the file paths of downloaded media by message ID.
+func mediaFiles(ctx context.Context, db *sql.DB) (map[string]string, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT message_id, file_path FROM media_metadata
+		WHERE download_status = 'downloaded' AND COALESCE(file_path, '') != ''
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check media: %w", err)
+	}
+	defer rows.Close()
+
+	files := make(map[string]string)
+	for rows.Next() {
+		var messageID, path string
+		if err := rows.Scan(&messageID, &path); err != nil {
+			return nil, err
+		}
+		files[messageID] = path
+	}
+
+	return files, rows.Err()
+}
+
+// archivedMediaFiles returns the file and thumbnail paths of the media in the
+// archive database, if there is one.
+func archivedMediaFiles(ctx context.Context) ([]string, error) {
+	path := paths.ArchiveDBPath
+	if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+
+	archive, err := sql.Open("sqlite", "file:"+path+"?mode=ro")
+	if err != nil {
+		return nil, err
+	}
+	defer archive.Close()
+
+	files, err := queryStrings(ctx, archive, "SELECT file_path FROM media_metadata WHERE COALESCE(file_path, '') != ''")
+	if err != nil {
+		return nil, fmt.Errorf("failed to check archived media: %w", err)
+	}
+
+	// archives written before thumbnails were generated have no paths for them
+	var hasThumbnailPath bool
+	err = archive.QueryRowContext(ctx, `
+		SELECT EXISTS(SELECT 1 FROM pragma_table_info('media_metadata') WHERE name = 'thumbnail_path')
+	`).Scan(&hasThumbnailPath)
+	if err != nil || !hasThumbnailPath {
+		return files, err
+	}
+
+	thumbnails, err := queryStrings(ctx, archive, "SELECT thumbnail_path FROM media_metadata WHERE COALESCE(thumbnail_path, '') != ''")
+	if err != nil {
+		return nil, fmt.Errorf("failed to check archived thumbnails: %w", err)
+	}
+	return append(files, thumbnails...), nil
+}
+
+// untrackedFiles returns the files under mediaDir, relative to it, that aren't
+// in inUse.
+func untrackedFiles(mediaDir string, inUse []string) ([]string, error) {
+	known := make(map[string]bool, len(inUse))
+	for _, path := range inUse {
+		known[filepath.ToSlash(filepath.Clean(path))] = true
+	}
+
+	var untracked []string
+	err := filepath.WalkDir(mediaDir, func(path string, d fs.DirEntry, err error) error {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		rel, err := filepath.Rel(mediaDir, path)
+		if err != nil {
+			return err
+		}
+		if rel = filepath.ToSlash(rel); !known[rel] {
+			untracked = append(untracked, rel)
+		}
+		return nil
+	})
+
+	return untracked, err
+}
+
+// repair fixes the problems in report, except for corruption.
+func repair(ctx context.Context, db *sql.DB, mediaDir string, report *DoctorReport, orphans []fkOrphan) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// the chat name is lost, but the messages are kept
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO chats (jid, last_message_time, is_group)
+		SELECT chat_jid, MAX(timestamp), chat_jid LIKE '%@g.us'
+		FROM messages
+		WHERE chat_jid NOT IN (SELECT jid FROM chats)
+		GROUP BY chat_jid
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to recreate chats: %w", err)
+	}
+
+	for _, o := range orphans {
+		if o.table == "messages" {
+			continue
+		}
+		query := fmt.Sprintf(`DELETE FROM "%s" WHERE rowid = ?`, strings.ReplaceAll(o.table, `"`, `""`))
+		if _, err := tx.ExecContext(ctx, query, o.rowid); err != nil {
+			return fmt.Errorf("failed to delete orphaned %s row: %w", o.table, err)
+		}
+	}
+
+	for _, messageID := range report.MissingFiles {
+		_, err := tx.ExecContext(ctx, `
+			UPDATE media_metadata
+			SET download_status = 'pending', file_path = NULL, download_error = 'file missing'
+			WHERE message_id = ?
+		`, messageID)
+		if err != nil {
+			return fmt.Errorf("failed to reset media of %s: %w", messageID, err)
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE chats SET
+		    message_count = (SELECT COUNT(*) FROM messages WHERE chat_jid = chats.jid),
+		    last_sender_jid = (
+		        SELECT sender_jid FROM messages
+		        WHERE chat_jid = chats.jid
+		        ORDER BY timestamp DESC, id DESC
+		        LIMIT 1
+		    )
+		WHERE message_count != (SELECT COUNT(*) FROM messages WHERE chat_jid = chats.jid)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to recompute message counts: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	// files are deleted last, once the database no longer needs them
+	for _, rel := range report.UntrackedFiles {
+		if err := os.Remove(filepath.Join(mediaDir, filepath.FromSlash(rel))); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// queryStrings returns the first column of every row of a query.
+func queryStrings(ctx context.Context, db *sql.DB, query string) ([]string, error) {
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var values []string
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+
+	return values, rows.Err()
+}
@@ -0,0 +1,92 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+)
+
+// MessageEdit is a prior version of an edited message.
+type MessageEdit struct {
+	MessageID  string
+	Text       string    // text before the edit
+	ReplacedAt time.Time // when the edit that replaced this text was made
+}
+
+// EditMessage replaces the text of a stored message and keeps the previous text
+// in message_edits. It returns false if the message is not stored.
+func (s *MessageStore) EditMessage(messageID, newText string, editedAt time.Time) (bool, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	var oldText sql.NullString
+	err = tx.QueryRow("SELECT text FROM messages WHERE id = ?", messageID).Scan(&oldText)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	// the same edit can be delivered more than once
+	if oldText.String == newText {
+		return true, nil
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO message_edits (message_id, text, replaced_at)
+		VALUES (?, ?, ?)
+	`, messageID, oldText.String, editedAt.Unix())
+	if err != nil {
+		return false, err
+	}
+
+	_, err = tx.Exec("UPDATE messages SET text = ?, edited_at = ? WHERE id = ?", newText, editedAt.Unix(), messageID)
+	if err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+// GetMessageEdits returns the prior versions of the given messages, oldest first,
+// grouped by message ID.
+func (s *MessageStore) GetMessageEdits(messageIDs []string) (map[string][]MessageEdit, error) {
+	edits := make(map[string][]MessageEdit)
+	if len(messageIDs) == 0 {
+		return edits, nil
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(messageIDs)), ", ")
+	args := make([]any, 0, len(messageIDs))
+	for _, id := range messageIDs {
+		args = append(args, id)
+	}
+
+	rows, err := s.db.Query(`
+		SELECT message_id, text, replaced_at
+		FROM message_edits
+		WHERE message_id IN (`+placeholders+`)
+		ORDER BY replaced_at ASC, id ASC
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var e MessageEdit
+		var replacedAt int64
+		if err := rows.Scan(&e.MessageID, &e.Text, &replacedAt); err != nil {
+			return nil, err
+		}
+		e.ReplacedAt = time.Unix(replacedAt, 0)
+		edits[e.MessageID] = append(edits[e.MessageID], e)
+	}
+
+	return edits, rows.Err()
+}
@@ -0,0 +1,197 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// GroupParticipant represents a member of a group chat.
+type GroupParticipant struct {
+	GroupJID       string
+	ParticipantJID string
+	PushName       string // participant's WhatsApp display name (empty if unknown)
+	ContactName    string // participant's saved contact name (empty if unknown)
+	IsAdmin        bool
+	JoinedAt       *time.Time // nil if the participant was already in the group when first synced
+}
+
+// ensureGroupChat creates the chats row for a group if it doesn't exist yet,
+// since group_participants references it.
+func ensureGroupChat(tx *sql.Tx, groupJID, name string) error {
+	_, err := tx.Exec(`
+		INSERT INTO chats (jid, push_name, contact_name, last_message_time, is_group)
+		VALUES (?, ?, '', 0, 1)
+		ON CONFLICT(jid) DO UPDATE SET
+		    push_name = COALESCE(NULLIF(excluded.push_name, ''), chats.push_name),
+		    is_group = 1
+	`, groupJID, name)
+	return err
+}
+
+// SetGroupParticipants replaces the participant list of a group with a full sync result.
+// Join dates of participants that are still in the group are kept.
+func (s *MessageStore) SetGroupParticipants(groupJID, groupName string, participants []GroupParticipant) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := ensureGroupChat(tx, groupJID, groupName); err != nil {
+		return err
+	}
+
+	keep := make([]any, 0, len(participants)+1)
+	keep = append(keep, groupJID)
+
+	for _, p := range participants {
+		_, err := tx.Exec(`
+			INSERT INTO group_participants (group_jid, participant_jid, is_admin)
+			VALUES (?, ?, ?)
+			ON CONFLICT(group_jid, participant_jid) DO UPDATE SET
+			    is_admin = excluded.is_admin
+		`, groupJID, p.ParticipantJID, p.IsAdmin)
+		if err != nil {
+			return fmt.Errorf("failed to save participant %s: %w", p.ParticipantJID, err)
+		}
+		keep = append(keep, p.ParticipantJID)
+	}
+
+	// drop participants that are no longer in the group
+	query := "DELETE FROM group_participants WHERE group_jid = ?"
+	if len(participants) > 0 {
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(participants)), ", ")
+		query += " AND participant_jid NOT IN (" + placeholders + ")"
+	}
+	if _, err := tx.Exec(query, keep...); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// AddGroupParticipants records participants that joined a group.
+func (s *MessageStore) AddGroupParticipants(groupJID string, participantJIDs []string, joinedAt time.Time) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := ensureGroupChat(tx, groupJID, ""); err != nil {
+		return err
+	}
+
+	for _, jid := range participantJIDs {
+		_, err := tx.Exec(`
+			INSERT INTO group_participants (group_jid, participant_jid, is_admin, joined_at)
+			VALUES (?, ?, FALSE, ?)
+			ON CONFLICT(group_jid, participant_jid) DO UPDATE SET
+			    joined_at = excluded.joined_at
+		`, groupJID, jid, joinedAt.Unix())
+		if err != nil {
+			return fmt.Errorf("failed to add participant %s: %w", jid, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// RemoveGroupParticipants removes participants that left or were removed from a group.
+func (s *MessageStore) RemoveGroupParticipants(groupJID string, participantJIDs []string) error {
+	for _, jid := range participantJIDs {
+		_, err := s.db.Exec("DELETE FROM group_participants WHERE group_jid = ? AND participant_jid = ?", groupJID, jid)
+		if err != nil {
+			return fmt.Errorf("failed to remove participant %s: %w", jid, err)
+		}
+	}
+	return nil
+}
+
+// SetGroupAdmins updates the admin flag of group participants.
+func (s *MessageStore) SetGroupAdmins(groupJID string, participantJIDs []string, isAdmin bool) error {
+	for _, jid := range participantJIDs {
+		_, err := s.db.Exec(`
+			UPDATE group_participants SET is_admin = ?
+			WHERE group_jid = ? AND participant_jid = ?
+		`, isAdmin, groupJID, jid)
+		if err != nil {
+			return fmt.Errorf("failed to update admin flag of %s: %w", jid, err)
+		}
+	}
+	return nil
+}
+
+// SetGroupDescription stores the description of a group. setAt is when the
+// description was last changed, zero if unknown; an older description doesn't
+// replace a newer one. Groups without a stored chat are ignored.
+func (s *MessageStore) SetGroupDescription(groupJID, description string, setAt time.Time) error {
+	var setAtUnix any
+	if !setAt.IsZero() {
+		setAtUnix = setAt.Unix()
+	}
+
+	_, err := s.db.Exec(`
+		INSERT INTO group_metadata (group_jid, description, description_set_at, refreshed_at)
+		SELECT ?1, ?2, ?3, ?4
+		WHERE EXISTS (SELECT 1 FROM chats WHERE jid = ?1)
+		ON CONFLICT(group_jid) DO UPDATE SET
+		    description = CASE WHEN COALESCE(excluded.description_set_at, 0) >= COALESCE(group_metadata.description_set_at, 0)
+		        THEN excluded.description ELSE group_metadata.description END,
+		    description_set_at = NULLIF(MAX(COALESCE(excluded.description_set_at, 0), COALESCE(group_metadata.description_set_at, 0)), 0),
+		    refreshed_at = excluded.refreshed_at
+	`, groupJID, description, setAtUnix, time.Now().Unix())
+	return err
+}
+
+// GetGroupDescription returns the stored description of a group, empty if
+// it has none or it isn't known.
+func (s *MessageStore) GetGroupDescription(groupJID string) (string, error) {
+	var description string
+	err := s.db.QueryRow("SELECT description FROM group_metadata WHERE group_jid = ?", groupJID).Scan(&description)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return description, err
+}
+
+// GetGroupParticipants returns the synced participants of a group with their current names.
+// Admins come first, then participants ordered by name.
+func (s *MessageStore) GetGroupParticipants(groupJID string) ([]GroupParticipant, error) {
+	rows, err := s.db.Query(`
+		SELECT
+		    gp.group_jid,
+		    gp.participant_jid,
+		    COALESCE(p.push_name, ''),
+		    COALESCE(c.contact_name, ''),
+		    gp.is_admin,
+		    gp.joined_at
+		FROM group_participants gp
+		LEFT JOIN push_names p ON p.jid = gp.participant_jid
+		LEFT JOIN chats c ON c.jid = gp.participant_jid
+		WHERE gp.group_jid = ?
+		ORDER BY gp.is_admin DESC, COALESCE(c.contact_name, p.push_name, gp.participant_jid)
+	`, groupJID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var participants []GroupParticipant
+	for rows.Next() {
+		var p GroupParticipant
+		var joinedAt sql.NullInt64
+		if err := rows.Scan(&p.GroupJID, &p.ParticipantJID, &p.PushName, &p.ContactName, &p.IsAdmin, &joinedAt); err != nil {
+			return nil, err
+		}
+		if joinedAt.Valid {
+			t := time.Unix(joinedAt.Int64, 0)
+			p.JoinedAt = &t
+		}
+		participants = append(participants, p)
+	}
+
+	return participants, rows.Err()
+}
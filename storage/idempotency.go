@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"database/sql"
+	"time"
+)
+
+// IdempotencyKeyTTL is how long idempotency keys are remembered.
+const IdempotencyKeyTTL = 24 * time.Hour
+
+// IdempotencyRecord is a stored idempotency key and the result of its send.
+type IdempotencyRecord struct {
+	Key       string
+	ChatJID   string
+	MessageID string // empty while the send is in progress
+	Result    string // original result returned to the client
+	CreatedAt time.Time
+}
+
+// ReserveIdempotencyKey claims an idempotency key for a send to chatJID.
+// It returns nil if the key was claimed, or the existing record if it was already used.
+func (s *MessageStore) ReserveIdempotencyKey(key, chatJID string) (*IdempotencyRecord, error) {
+	now := time.Now()
+
+	// forget expired keys so they can be reused
+	if _, err := s.db.Exec("DELETE FROM idempotency_keys WHERE created_at < ?", now.Add(-IdempotencyKeyTTL).Unix()); err != nil {
+		return nil, err
+	}
+
+	res, err := s.db.Exec(`
+		INSERT OR IGNORE INTO idempotency_keys (key, chat_jid, created_at)
+		VALUES (?, ?, ?)
+	`, key, chatJID, now.Unix())
+	if err != nil {
+		return nil, err
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		return nil, err
+	} else if n == 1 {
+		return nil, nil
+	}
+
+	var rec IdempotencyRecord
+	var messageID, result sql.NullString
+	var createdAt int64
+
+	err = s.db.QueryRow(`
+		SELECT key, chat_jid, message_id, result, created_at
+		FROM idempotency_keys
+		WHERE key = ?
+	`, key).Scan(&rec.Key, &rec.ChatJID, &messageID, &result, &createdAt)
+	if err != nil {
+		return nil, err
+	}
+
+	rec.MessageID = messageID.String
+	rec.Result = result.String
+	rec.CreatedAt = time.Unix(createdAt, 0)
+
+	return &rec, nil
+}
+
+// CompleteIdempotencyKey stores the outcome of a send made under an idempotency key.
+func (s *MessageStore) CompleteIdempotencyKey(key, messageID, result string) error {
+	_, err := s.db.Exec("UPDATE idempotency_keys SET message_id = ?, result = ? WHERE key = ?", messageID, result, key)
+	return err
+}
+
+// ReleaseIdempotencyKey forgets a reserved key after a failed send so it can be retried.
+func (s *MessageStore) ReleaseIdempotencyKey(key string) error {
+	_, err := s.db.Exec("DELETE FROM idempotency_keys WHERE key = ? AND message_id IS NULL", key)
+	return err
+}
@@ -0,0 +1,103 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// ImportedIDPrefix prefixes the IDs of messages imported from chat export files.
+const ImportedIDPrefix = "import-"
+
+// importTimestampTolerance is how far apart (in seconds) an imported message and
+// a synced one may be and still be considered the same message. Chat exports
+// often only have minute precision.
+const importTimestampTolerance = 60
+
+// ImportMessages merges messages imported from a chat export into chat. The
+// chat is created if it doesn't exist. A message is skipped if its ID is already
+// stored (the file was imported before) or if a synced message of the same
+// direction with the same text exists within importTimestampTolerance.
+// It returns the number of messages stored.
+func (s *MessageStore) ImportMessages(chat Chat, messages []Message) (int, error) {
+	if len(messages) == 0 {
+		return 0, nil
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	lastMessageTime := messages[0].Timestamp
+	for _, msg := range messages {
+		if msg.Timestamp.After(lastMessageTime) {
+			lastMessageTime = msg.Timestamp
+		}
+	}
+
+	// never move last_message_time back or replace synced names
+	_, err = tx.Exec(`
+		INSERT INTO chats (jid, push_name, contact_name, last_message_time, is_group)
+		VALUES (?, NULLIF(?, ''), NULLIF(?, ''), ?, ?)
+		ON CONFLICT(jid) DO UPDATE SET
+		    push_name = COALESCE(chats.push_name, excluded.push_name),
+		    contact_name = COALESCE(chats.contact_name, excluded.contact_name),
+		    last_message_time = MAX(COALESCE(chats.last_message_time, 0), excluded.last_message_time)
+	`, chat.JID, chat.PushName, chat.ContactName, lastMessageTime.Unix(), chat.IsGroup)
+	if err != nil {
+		return 0, fmt.Errorf("failed to save chat: %w", err)
+	}
+
+	imported := 0
+	for _, msg := range messages {
+		var exists bool
+		err := tx.QueryRow(`
+			SELECT EXISTS(
+				SELECT 1 FROM messages
+				WHERE chat_jid = ? AND is_from_me = ? AND COALESCE(text, '') = ?
+				  AND timestamp BETWEEN ? AND ?
+				  AND id NOT LIKE ?
+			)
+		`, chat.JID, msg.IsFromMe, msg.Text,
+			msg.Timestamp.Unix()-importTimestampTolerance, msg.Timestamp.Unix()+importTimestampTolerance,
+			ImportedIDPrefix+"%",
+		).Scan(&exists)
+		if err != nil {
+			return imported, err
+		}
+		if exists {
+			continue
+		}
+
+		result, err := tx.Exec(`
+			INSERT OR IGNORE INTO messages (id, chat_jid, sender_jid, text, timestamp, is_from_me, message_type)
+			VALUES (?, ?, ?, ?, ?, ?, ?)
+		`, msg.ID, chat.JID, msg.SenderJID, msg.Text, msg.Timestamp.Unix(), msg.IsFromMe, msg.MessageType)
+		if err != nil {
+			return imported, fmt.Errorf("failed to import message %s: %w", msg.ID, err)
+		}
+		if n, _ := result.RowsAffected(); n > 0 {
+			imported++
+		}
+	}
+
+	return imported, tx.Commit()
+}
+
+// GetOwnJID returns the sender JID of my most recent stored message, or an
+// empty string if I haven't sent any.
+func (s *MessageStore) GetOwnJID() (string, error) {
+	var jid string
+	err := s.db.QueryRow(`
+		SELECT sender_jid FROM messages
+		WHERE is_from_me = 1 AND sender_jid != '' AND id NOT LIKE ?
+		ORDER BY timestamp DESC
+		LIMIT 1
+	`, ImportedIDPrefix+"%").Scan(&jid)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	return jid, err
+}
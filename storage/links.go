@@ -0,0 +1,85 @@
+package storage
+
+import (
+	"strings"
+)
+
+// LinkPreview holds the link preview WhatsApp attaches to messages containing a URL.
+type LinkPreview struct {
+	MessageID   string
+	URL         string
+	Title       string
+	Description string
+}
+
+// SaveLinkPreview saves the link preview of a message.
+func (s *MessageStore) SaveLinkPreview(preview LinkPreview) error {
+	_, err := s.db.Exec(`
+		INSERT OR REPLACE INTO link_previews (message_id, url, title, description)
+		VALUES (?, ?, ?, ?)
+	`, preview.MessageID, preview.URL, preview.Title, preview.Description)
+
+	return err
+}
+
+// GetLinkPreviews returns the link previews of the given messages keyed by message ID.
+func (s *MessageStore) GetLinkPreviews(messageIDs []string) (map[string]LinkPreview, error) {
+	previews := make(map[string]LinkPreview)
+	if len(messageIDs) == 0 {
+		return previews, nil
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(messageIDs)), ", ")
+	args := make([]any, 0, len(messageIDs))
+	for _, id := range messageIDs {
+		args = append(args, id)
+	}
+
+	rows, err := s.db.Query(`
+		SELECT message_id, url, title, description
+		FROM link_previews
+		WHERE message_id IN (`+placeholders+`)
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var p LinkPreview
+		if err := rows.Scan(&p.MessageID, &p.URL, &p.Title, &p.Description); err != nil {
+			return nil, err
+		}
+		previews[p.MessageID] = p
+	}
+
+	return previews, rows.Err()
+}
+
+// GetLinkMessages returns messages that contain links, newest first.
+// Messages are matched by the "url" type or by URL-looking text. An empty chatJID searches all chats.
+func (s *MessageStore) GetLinkMessages(chatJID string, limit int) ([]MessageWithNames, error) {
+	query := `
+	SELECT ` + messageWithNamesColumns + `
+	FROM messages_with_names
+	WHERE (message_type = 'url' OR text LIKE '%http://%' OR text LIKE '%https://%' OR text LIKE '%www.%')
+	  AND deleted_at IS NULL
+	`
+	var args []any
+
+	if chatJID != "" {
+		query += " AND chat_jid = ?"
+		args = append(args, chatJID)
+	}
+
+	query += " ORDER BY timestamp DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return s.scanMessagesWithNames(rows)
+}
@@ -0,0 +1,180 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+)
+
+// MaintenanceReport describes what a maintenance run did and how much space
+// the database and media use.
+type MaintenanceReport struct {
+	WALFrames        int          `json:"wal_frames"`        // frames in the WAL before the checkpoint
+	Checkpointed     int          `json:"checkpointed"`      // frames moved into the database
+	WALBusy          bool         `json:"wal_busy"`          // the WAL couldn't be truncated (readers were active)
+	FreedPages       int          `json:"freed_pages"`       // pages returned to the file system by vacuuming
+	FreePages        int          `json:"free_pages"`        // unused pages left in the file
+	IncrementalReady bool         `json:"incremental_ready"` // auto_vacuum is INCREMENTAL, so free pages can be released without a full VACUUM
+	PageSize         int64        `json:"page_size"`
+	DatabaseSize     int64        `json:"database_size"` // bytes, page size times page count
+	Objects          []ObjectSize `json:"objects,omitempty"`
+	MediaFiles       int          `json:"media_files"`
+	MediaSize        int64        `json:"media_size"` // bytes
+}
+
+// ObjectSize is the space used by a table or index.
+type ObjectSize struct {
+	Name  string `json:"name"`
+	Type  string `json:"type"`  // table or index
+	Table string `json:"table"` // table the index belongs to
+	Size  int64  `json:"size"`  // bytes
+}
+
+// Maintain runs ANALYZE, releases free pages and checkpoints the WAL, then
+// reports table, index and media sizes. Free pages are released with
+// incremental_vacuum when the database uses incremental auto-vacuum; with
+// full, the database is rebuilt with VACUUM instead and switched to
+// incremental auto-vacuum, which blocks writers until it finishes.
+// mediaDir may be empty to skip the media report.
+func Maintain(ctx context.Context, db *sql.DB, full bool, mediaDir string) (*MaintenanceReport, error) {
+	// auto_vacuum only takes effect through a VACUUM on the same connection
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	report := &MaintenanceReport{}
+
+	if _, err := conn.ExecContext(ctx, "ANALYZE"); err != nil {
+		return nil, fmt.Errorf("failed to analyze: %w", err)
+	}
+
+	freeBefore, err := pragmaInt(ctx, conn, "freelist_count")
+	if err != nil {
+		return nil, err
+	}
+
+	if full {
+		if _, err := conn.ExecContext(ctx, "PRAGMA auto_vacuum = INCREMENTAL"); err != nil {
+			return nil, err
+		}
+		if _, err := conn.ExecContext(ctx, "VACUUM"); err != nil {
+			return nil, fmt.Errorf("failed to vacuum: %w", err)
+		}
+	}
+
+	autoVacuum, err := pragmaInt(ctx, conn, "auto_vacuum")
+	if err != nil {
+		return nil, err
+	}
+	report.IncrementalReady = autoVacuum == 2
+	if report.IncrementalReady && !full {
+		if _, err := conn.ExecContext(ctx, "PRAGMA incremental_vacuum"); err != nil {
+			return nil, fmt.Errorf("failed to vacuum: %w", err)
+		}
+	}
+
+	if report.FreePages, err = pragmaInt(ctx, conn, "freelist_count"); err != nil {
+		return nil, err
+	}
+	report.FreedPages = max(freeBefore-report.FreePages, 0)
+
+	var busy int
+	err = conn.QueryRowContext(ctx, "PRAGMA wal_checkpoint(TRUNCATE)").Scan(&busy, &report.WALFrames, &report.Checkpointed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to checkpoint: %w", err)
+	}
+	report.WALBusy = busy != 0
+
+	pageSize, err := pragmaInt(ctx, conn, "page_size")
+	if err != nil {
+		return nil, err
+	}
+	pageCount, err := pragmaInt(ctx, conn, "page_count")
+	if err != nil {
+		return nil, err
+	}
+	report.PageSize = int64(pageSize)
+	report.DatabaseSize = int64(pageSize) * int64(pageCount)
+
+	// dbstat is an optional SQLite extension; without it only the total is reported
+	if objects, err := objectSizes(ctx, conn); err == nil {
+		report.Objects = objects
+	}
+
+	if mediaDir != "" {
+		if report.MediaFiles, report.MediaSize, err = DirUsage(mediaDir); err != nil {
+			return nil, fmt.Errorf("failed to measure %s: %w", mediaDir, err)
+		}
+	}
+
+	return report, nil
+}
+
+// pragmaInt reads an integer PRAGMA.
+func pragmaInt(ctx context.Context, conn *sql.Conn, name string) (int, error) {
+	var value int
+	if err := conn.QueryRowContext(ctx, "PRAGMA "+name).Scan(&value); err != nil {
+		return 0, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	return value, nil
+}
+
+// objectSizes returns the size of every table and index, largest first.
+func objectSizes(ctx context.Context, conn *sql.Conn) ([]ObjectSize, error) {
+	rows, err := conn.QueryContext(ctx, `
+		SELECT s.name, COALESCE(m.type, 'table'), COALESCE(m.tbl_name, s.name), SUM(s.pgsize) AS size
+		FROM dbstat s
+		LEFT JOIN sqlite_master m ON m.name = s.name
+		GROUP BY s.name
+		ORDER BY size DESC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var objects []ObjectSize
+	for rows.Next() {
+		var o ObjectSize
+		if err := rows.Scan(&o.Name, &o.Type, &o.Table, &o.Size); err != nil {
+			return nil, err
+		}
+		objects = append(objects, o)
+	}
+
+	return objects, rows.Err()
+}
+
+// DirUsage returns the number of files under dir and their total size. A
+// missing directory counts as empty.
+func DirUsage(dir string) (int, int64, error) {
+	var files int
+	var size int64
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == dir && errors.Is(err, fs.ErrNotExist) {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		files++
+		size += info.Size()
+		return nil
+	})
+
+	return files, size, err
+}
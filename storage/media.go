@@ -3,7 +3,10 @@ package storage
 import (
 	"database/sql"
 	"fmt"
+	"os"
 	"time"
+
+	"whatsapp-mcp/paths"
 )
 
 // MediaMetadata represents metadata for a media file attached to a message.
@@ -23,6 +26,8 @@ type MediaMetadata struct {
 	DownloadStatus    string // pending, downloaded, failed, expired
 	DownloadTimestamp *time.Time
 	DownloadError     string
+	Thumbnail         []byte // embedded JPEG preview, only set when saving (see GetMediaThumbnail)
+	ThumbnailPath     string // relative path from data/media/ of the generated preview (empty if none)
 	CreatedAt         time.Time
 }
 
@@ -37,12 +42,34 @@ func NewMediaStore(db *sql.DB) *MediaStore {
 }
 
 // SaveMediaMetadata inserts or updates media metadata in the database.
+// The download state of an already downloaded file is kept, so a message
+// delivered again by history sync doesn't go back to pending.
 func (s *MediaStore) SaveMediaMetadata(meta MediaMetadata) error {
 	query := `
-	INSERT OR REPLACE INTO media_metadata
+	INSERT INTO media_metadata
 	(message_id, file_path, file_name, file_size, mime_type, width, height, duration,
-	 media_key, direct_path, file_sha256, file_enc_sha256, download_status, download_timestamp, download_error)
-	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	 media_key, direct_path, file_sha256, file_enc_sha256, download_status, download_timestamp, download_error, thumbnail)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	ON CONFLICT(message_id) DO UPDATE SET
+		file_name = excluded.file_name,
+		file_size = excluded.file_size,
+		mime_type = excluded.mime_type,
+		width = excluded.width,
+		height = excluded.height,
+		duration = excluded.duration,
+		media_key = excluded.media_key,
+		direct_path = excluded.direct_path,
+		file_sha256 = excluded.file_sha256,
+		file_enc_sha256 = excluded.file_enc_sha256,
+		thumbnail = COALESCE(excluded.thumbnail, media_metadata.thumbnail),
+		file_path = CASE WHEN media_metadata.download_status = 'downloaded'
+			THEN media_metadata.file_path ELSE excluded.file_path END,
+		download_status = CASE WHEN media_metadata.download_status = 'downloaded'
+			THEN media_metadata.download_status ELSE excluded.download_status END,
+		download_timestamp = CASE WHEN media_metadata.download_status = 'downloaded'
+			THEN media_metadata.download_timestamp ELSE excluded.download_timestamp END,
+		download_error = CASE WHEN media_metadata.download_status = 'downloaded'
+			THEN media_metadata.download_error ELSE excluded.download_error END
 	`
 
 	var downloadTimestampUnix *int64
@@ -68,18 +95,48 @@ func (s *MediaStore) SaveMediaMetadata(meta MediaMetadata) error {
 		meta.DownloadStatus,
 		downloadTimestampUnix,
 		meta.DownloadError,
+		meta.Thumbnail,
 	)
 
 	return err
 }
 
+// GetMediaThumbnail returns the JPEG thumbnail of a media message: the one
+// generated from the downloaded file if there is one, the small preview
+// embedded in the message otherwise. It returns nil if the message has no
+// thumbnail.
+func (s *MediaStore) GetMediaThumbnail(messageID string) ([]byte, error) {
+	var thumbnail []byte
+	var thumbnailPath sql.NullString
+	err := s.db.QueryRow("SELECT thumbnail, thumbnail_path FROM media_metadata WHERE message_id = ?", messageID).Scan(&thumbnail, &thumbnailPath)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get media thumbnail: %w", err)
+	}
+
+	if thumbnailPath.String != "" {
+		if generated, err := os.ReadFile(paths.GetMediaPath(thumbnailPath.String)); err == nil {
+			return generated, nil
+		}
+	}
+	return thumbnail, nil
+}
+
+// SetThumbnailPath records the preview generated for a downloaded media file.
+func (s *MediaStore) SetThumbnailPath(messageID, thumbnailPath string) error {
+	_, err := s.db.Exec("UPDATE media_metadata SET thumbnail_path = ? WHERE message_id = ?", thumbnailPath, messageID)
+	return err
+}
+
 // GetMediaMetadata retrieves media metadata by message ID.
 // It returns nil if the metadata is not found.
 func (s *MediaStore) GetMediaMetadata(messageID string) (*MediaMetadata, error) {
 	query := `
 	SELECT message_id, file_path, file_name, file_size, mime_type, width, height, duration,
 	       media_key, direct_path, file_sha256, file_enc_sha256, download_status,
-	       download_timestamp, download_error, created_at
+	       download_timestamp, download_error, created_at, thumbnail_path
 	FROM media_metadata
 	WHERE message_id = ?
 	`
@@ -88,7 +145,7 @@ func (s *MediaStore) GetMediaMetadata(messageID string) (*MediaMetadata, error)
 	var filePath sql.NullString
 	var width, height, duration sql.NullInt64
 	var mediaKey, fileSHA256, fileEncSHA256 []byte
-	var directPath, downloadError sql.NullString
+	var directPath, downloadError, thumbnailPath sql.NullString
 	var downloadTimestampUnix sql.NullInt64
 	var createdAtStr string
 
@@ -109,6 +166,7 @@ func (s *MediaStore) GetMediaMetadata(messageID string) (*MediaMetadata, error)
 		&downloadTimestampUnix,
 		&downloadError,
 		&createdAtStr,
+		&thumbnailPath,
 	)
 
 	if err == sql.ErrNoRows {
@@ -145,6 +203,7 @@ func (s *MediaStore) GetMediaMetadata(messageID string) (*MediaMetadata, error)
 		meta.DownloadTimestamp = &ts
 	}
 
+	meta.ThumbnailPath = thumbnailPath.String
 	meta.MediaKey = mediaKey
 	meta.FileSHA256 = fileSHA256
 	meta.FileEncSHA256 = fileEncSHA256
@@ -191,59 +250,28 @@ func (s *MediaStore) ListMediaByType(mimeTypePrefix string, limit int) ([]MediaM
 	}
 	defer rows.Close()
 
-	var results []MediaMetadata
-	for rows.Next() {
-		var meta MediaMetadata
-		var filePath sql.NullString
-		var width, height, duration sql.NullInt64
-		var downloadTimestampUnix sql.NullInt64
-		var downloadError sql.NullString
-
-		err := rows.Scan(
-			&meta.MessageID,
-			&filePath,
-			&meta.FileName,
-			&meta.FileSize,
-			&meta.MimeType,
-			&width,
-			&height,
-			&duration,
-			&meta.DownloadStatus,
-			&downloadTimestampUnix,
-			&downloadError,
-		)
-		if err != nil {
-			return nil, err
-		}
+	return scanMediaList(rows)
+}
 
-		// handle nullable fields
-		if filePath.Valid {
-			meta.FilePath = filePath.String
-		}
-		if width.Valid {
-			w := int(width.Int64)
-			meta.Width = &w
-		}
-		if height.Valid {
-			h := int(height.Int64)
-			meta.Height = &h
-		}
-		if duration.Valid {
-			d := int(duration.Int64)
-			meta.Duration = &d
-		}
-		if downloadTimestampUnix.Valid {
-			ts := time.Unix(downloadTimestampUnix.Int64, 0)
-			meta.DownloadTimestamp = &ts
-		}
-		if downloadError.Valid {
-			meta.DownloadError = downloadError.String
-		}
+// ListMediaByStatus returns media with the given download status (pending, failed, ...),
+// oldest first so retries follow arrival order.
+func (s *MediaStore) ListMediaByStatus(status string, limit int) ([]MediaMetadata, error) {
+	query := `
+	SELECT message_id, file_path, file_name, file_size, mime_type, width, height, duration,
+	       download_status, download_timestamp, download_error
+	FROM media_metadata
+	WHERE download_status = ?
+	ORDER BY created_at ASC
+	LIMIT ?
+	`
 
-		results = append(results, meta)
+	rows, err := s.db.Query(query, status, limit)
+	if err != nil {
+		return nil, err
 	}
+	defer rows.Close()
 
-	return results, rows.Err()
+	return scanMediaList(rows)
 }
 
 // GetMediaByChat returns all media from a specific chat.
@@ -264,6 +292,12 @@ func (s *MediaStore) GetMediaByChat(chatJID string, limit int) ([]MediaMetadata,
 	}
 	defer rows.Close()
 
+	return scanMediaList(rows)
+}
+
+// scanMediaList scans the rows of the media list queries. The download keys and
+// thumbnail are not selected by them; use GetMediaMetadata for a full record.
+func scanMediaList(rows *sql.Rows) ([]MediaMetadata, error) {
 	var results []MediaMetadata
 	for rows.Next() {
 		var meta MediaMetadata
@@ -0,0 +1,48 @@
+package storage
+
+import "fmt"
+
+// SaveMentions replaces the stored mentions of a message with the given JIDs.
+func (s *MessageStore) SaveMentions(messageID string, mentionedJIDs []string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM message_mentions WHERE message_id = ?", messageID); err != nil {
+		return err
+	}
+
+	for _, jid := range mentionedJIDs {
+		_, err := tx.Exec(`
+			INSERT OR IGNORE INTO message_mentions (message_id, mentioned_jid)
+			VALUES (?, ?)
+		`, messageID, jid)
+		if err != nil {
+			return fmt.Errorf("failed to save mention of %s: %w", jid, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetMentions returns the JIDs mentioned in a message.
+func (s *MessageStore) GetMentions(messageID string) ([]string, error) {
+	rows, err := s.db.Query("SELECT mentioned_jid FROM message_mentions WHERE message_id = ? ORDER BY mentioned_jid", messageID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jids []string
+	for rows.Next() {
+		var jid string
+		if err := rows.Scan(&jid); err != nil {
+			return nil, err
+		}
+		jids = append(jids, jid)
+	}
+
+	return jids, rows.Err()
+}
@@ -0,0 +1,238 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// MergeStats counts the rows a merge added or updated.
+type MergeStats struct {
+	Chats    int // chats added or updated
+	Messages int // messages added
+	Media    int // media metadata rows added
+}
+
+// mergeStatements copy the rows of the attached "other" database, in an order
+// that satisfies the foreign keys. Rows already stored win, except for names,
+// which come from whichever side is newer. Webhooks, templates, presence,
+// idempotency keys, archive stubs and backfill jobs belong to an instance and
+// are not merged.
+var mergeStatements = []struct {
+	table string
+	query string
+}{
+	{"chats", `
+		INSERT INTO chats (jid, push_name, contact_name, last_message_time, is_group, created_at, disappearing_timer, read_at, marked_unread, muted_until, archived, pinned_at)
+		SELECT jid, push_name, contact_name, last_message_time, is_group, created_at, disappearing_timer, read_at, marked_unread, muted_until, archived, pinned_at
+		FROM other.chats WHERE true
+		ON CONFLICT(jid) DO UPDATE SET
+		    push_name = CASE WHEN COALESCE(excluded.last_message_time, 0) > COALESCE(chats.last_message_time, 0)
+		        THEN COALESCE(NULLIF(excluded.push_name, ''), chats.push_name)
+		        ELSE COALESCE(NULLIF(chats.push_name, ''), excluded.push_name) END,
+		    contact_name = CASE WHEN COALESCE(excluded.last_message_time, 0) > COALESCE(chats.last_message_time, 0)
+		        THEN COALESCE(NULLIF(excluded.contact_name, ''), chats.contact_name)
+		        ELSE COALESCE(NULLIF(chats.contact_name, ''), excluded.contact_name) END,
+		    disappearing_timer = CASE WHEN COALESCE(excluded.last_message_time, 0) > COALESCE(chats.last_message_time, 0)
+		        THEN excluded.disappearing_timer ELSE chats.disappearing_timer END,
+		    marked_unread = CASE WHEN COALESCE(excluded.last_message_time, 0) > COALESCE(chats.last_message_time, 0)
+		        THEN excluded.marked_unread ELSE chats.marked_unread END,
+		    muted_until = CASE WHEN COALESCE(excluded.last_message_time, 0) > COALESCE(chats.last_message_time, 0)
+		        THEN excluded.muted_until ELSE chats.muted_until END,
+		    archived = CASE WHEN COALESCE(excluded.last_message_time, 0) > COALESCE(chats.last_message_time, 0)
+		        THEN excluded.archived ELSE chats.archived END,
+		    pinned_at = CASE WHEN COALESCE(excluded.last_message_time, 0) > COALESCE(chats.last_message_time, 0)
+		        THEN excluded.pinned_at ELSE chats.pinned_at END,
+		    read_at = MAX(COALESCE(chats.read_at, 0), COALESCE(excluded.read_at, 0)),
+		    last_message_time = MAX(COALESCE(chats.last_message_time, 0), COALESCE(excluded.last_message_time, 0))
+	`},
+	{"messages", `
+		INSERT OR IGNORE INTO messages
+		(id, chat_jid, sender_jid, text, timestamp, is_from_me, message_type, created_at, reply_to_id, edited_at, deleted_at, payload)
+		SELECT id, chat_jid, sender_jid, text, timestamp, is_from_me, message_type, created_at, reply_to_id, edited_at, deleted_at, payload
+		FROM other.messages
+	`},
+	{"media_metadata", `
+		INSERT OR IGNORE INTO media_metadata
+		(message_id, file_path, file_name, file_size, mime_type, width, height, duration, media_key, direct_path,
+		 file_sha256, file_enc_sha256, download_status, download_timestamp, download_error, created_at, thumbnail, thumbnail_path)
+		SELECT message_id, file_path, file_name, file_size, mime_type, width, height, duration, media_key, direct_path,
+		       file_sha256, file_enc_sha256, download_status, download_timestamp, download_error, created_at, thumbnail, thumbnail_path
+		FROM other.media_metadata
+	`},
+	{"message_edits", `
+		INSERT INTO message_edits (message_id, text, replaced_at)
+		SELECT e.message_id, e.text, e.replaced_at
+		FROM other.message_edits e
+		WHERE NOT EXISTS (
+		    SELECT 1 FROM message_edits x
+		    WHERE x.message_id = e.message_id AND x.replaced_at = e.replaced_at AND x.text = e.text
+		)
+		ORDER BY e.id
+	`},
+	{"message_mentions", `INSERT OR IGNORE INTO message_mentions (message_id, mentioned_jid) SELECT message_id, mentioned_jid FROM other.message_mentions`},
+	{"link_previews", `INSERT OR IGNORE INTO link_previews (message_id, url, title, description) SELECT message_id, url, title, description FROM other.link_previews`},
+	{"transcripts", `INSERT OR IGNORE INTO transcripts (message_id, text, source, created_at) SELECT message_id, text, source, created_at FROM other.transcripts`},
+	{"raw_messages", `INSERT OR IGNORE INTO raw_messages (message_id, data, created_at) SELECT message_id, data, created_at FROM other.raw_messages`},
+	{"newsletter_posts", `
+		INSERT INTO newsletter_posts (message_id, chat_jid, server_id, views, reactions, updated_at)
+		SELECT message_id, chat_jid, server_id, views, reactions, updated_at FROM other.newsletter_posts WHERE true
+		ON CONFLICT(message_id) DO UPDATE SET
+		    views = excluded.views,
+		    reactions = excluded.reactions,
+		    updated_at = excluded.updated_at
+		WHERE excluded.updated_at > newsletter_posts.updated_at
+	`},
+	{"statuses", `
+		INSERT OR IGNORE INTO statuses (message_id, sender_jid, status_type, posted_at, expires_at)
+		SELECT message_id, sender_jid, status_type, posted_at, expires_at FROM other.statuses
+	`},
+	{"polls", `
+		INSERT OR IGNORE INTO polls (message_id, chat_jid, question, options, selectable_count, created_at)
+		SELECT message_id, chat_jid, question, options, selectable_count, created_at FROM other.polls
+	`},
+	{"poll_votes", `
+		INSERT INTO poll_votes (poll_message_id, voter_jid, chat_jid, options, timestamp)
+		SELECT poll_message_id, voter_jid, chat_jid, options, timestamp FROM other.poll_votes WHERE true
+		ON CONFLICT(poll_message_id, voter_jid) DO UPDATE SET
+		    options = excluded.options,
+		    timestamp = excluded.timestamp
+		WHERE excluded.timestamp > poll_votes.timestamp
+	`},
+	{"group_metadata", `
+		INSERT INTO group_metadata (group_jid, description, description_set_at, refreshed_at)
+		SELECT group_jid, description, description_set_at, refreshed_at FROM other.group_metadata WHERE true
+		ON CONFLICT(group_jid) DO UPDATE SET
+		    description = excluded.description,
+		    description_set_at = excluded.description_set_at,
+		    refreshed_at = excluded.refreshed_at
+		WHERE excluded.refreshed_at > group_metadata.refreshed_at
+	`},
+	{"receipts", `
+		INSERT OR IGNORE INTO receipts (message_id, chat_jid, participant_jid, status, timestamp)
+		SELECT message_id, chat_jid, participant_jid, status, timestamp FROM other.receipts
+	`},
+	{"reactions", `
+		INSERT INTO reactions (target_message_id, sender_jid, chat_jid, emoji, timestamp)
+		SELECT target_message_id, sender_jid, chat_jid, emoji, timestamp FROM other.reactions WHERE true
+		ON CONFLICT(target_message_id, sender_jid) DO UPDATE SET
+		    emoji = excluded.emoji,
+		    timestamp = excluded.timestamp
+		WHERE excluded.timestamp > reactions.timestamp
+	`},
+	{"group_participants", `
+		INSERT OR IGNORE INTO group_participants (group_jid, participant_jid, is_admin, joined_at)
+		SELECT group_jid, participant_jid, is_admin, joined_at FROM other.group_participants
+	`},
+	{"push_names", `
+		INSERT INTO push_names (jid, push_name, updated_at)
+		SELECT jid, push_name, updated_at FROM other.push_names WHERE true
+		ON CONFLICT(jid) DO UPDATE SET
+		    push_name = excluded.push_name,
+		    updated_at = excluded.updated_at
+		WHERE excluded.updated_at > push_names.updated_at
+	`},
+	{"tags", `INSERT OR IGNORE INTO tags (name, label_id, created_at) SELECT name, label_id, created_at FROM other.tags`},
+	{"chat_tags", `
+		INSERT OR IGNORE INTO chat_tags (tag, chat_jid, created_at)
+		SELECT tag, chat_jid, created_at FROM other.chat_tags
+		WHERE tag IN (SELECT name FROM tags)
+	`},
+	{"message_tags", `
+		INSERT OR IGNORE INTO message_tags (tag, message_id, created_at)
+		SELECT tag, message_id, created_at FROM other.message_tags
+		WHERE tag IN (SELECT name FROM tags) AND message_id IN (SELECT id FROM messages)
+	`},
+	{"contact_notes", `
+		INSERT INTO contact_notes (jid, text, created_at)
+		SELECT n.jid, n.text, n.created_at
+		FROM other.contact_notes n
+		WHERE NOT EXISTS (
+		    SELECT 1 FROM contact_notes x
+		    WHERE x.jid = n.jid AND x.text = n.text AND x.created_at = n.created_at
+		)
+		ORDER BY n.id
+	`},
+	{"calls", `
+		INSERT OR IGNORE INTO calls (id, caller_jid, chat_jid, is_video, is_group, status, offered_at, ended_at, end_reason)
+		SELECT id, caller_jid, chat_jid, is_video, is_group, status, offered_at, ended_at, end_reason FROM other.calls
+	`},
+	{"contacts", `
+		INSERT INTO contacts (jid, phone_number, full_name, first_name, push_name, business_name, updated_at)
+		SELECT jid, phone_number, full_name, first_name, push_name, business_name, updated_at FROM other.contacts WHERE true
+		ON CONFLICT(jid) DO UPDATE SET
+		    phone_number = CASE WHEN excluded.updated_at > contacts.updated_at
+		        THEN COALESCE(excluded.phone_number, contacts.phone_number) ELSE COALESCE(contacts.phone_number, excluded.phone_number) END,
+		    full_name = CASE WHEN excluded.updated_at > contacts.updated_at
+		        THEN COALESCE(excluded.full_name, contacts.full_name) ELSE COALESCE(contacts.full_name, excluded.full_name) END,
+		    first_name = CASE WHEN excluded.updated_at > contacts.updated_at
+		        THEN COALESCE(excluded.first_name, contacts.first_name) ELSE COALESCE(contacts.first_name, excluded.first_name) END,
+		    push_name = CASE WHEN excluded.updated_at > contacts.updated_at
+		        THEN COALESCE(excluded.push_name, contacts.push_name) ELSE COALESCE(contacts.push_name, excluded.push_name) END,
+		    business_name = CASE WHEN excluded.updated_at > contacts.updated_at
+		        THEN COALESCE(excluded.business_name, contacts.business_name) ELSE COALESCE(contacts.business_name, excluded.business_name) END,
+		    updated_at = MAX(contacts.updated_at, excluded.updated_at)
+	`},
+}
+
+// MergeDatabase imports the chats, messages, media metadata and related rows
+// of another whatsapp-mcp database at otherPath into db. Messages are
+// deduplicated by ID. Both databases must be at the same schema version.
+// Media files are not copied.
+func MergeDatabase(ctx context.Context, db *sql.DB, otherPath string) (*MergeStats, error) {
+	version, err := NewMigrator(db).Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	other, err := sql.Open("sqlite", "file:"+otherPath+"?mode=ro")
+	if err != nil {
+		return nil, err
+	}
+	otherVersion, err := NewMigrator(other).Validate()
+	other.Close()
+	if err != nil {
+		return nil, fmt.Errorf("invalid database %s: %w", otherPath, err)
+	}
+	if otherVersion != version {
+		return nil, fmt.Errorf("%s is at schema version %d but this database is at %d: upgrade the older one first by running the newer version on it", otherPath, otherVersion, version)
+	}
+
+	// ATTACH only applies to one connection, and can't run inside a transaction
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "ATTACH DATABASE ? AS other", "file:"+otherPath+"?mode=ro"); err != nil {
+		return nil, fmt.Errorf("failed to attach %s: %w", otherPath, err)
+	}
+	defer conn.ExecContext(context.Background(), "DETACH DATABASE other")
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	stats := &MergeStats{}
+	for _, stmt := range mergeStatements {
+		result, err := tx.ExecContext(ctx, stmt.query)
+		if err != nil {
+			return nil, fmt.Errorf("failed to merge %s: %w", stmt.table, err)
+		}
+
+		n, _ := result.RowsAffected()
+		switch stmt.table {
+		case "chats":
+			stats.Chats = int(n)
+		case "messages":
+			stats.Messages = int(n)
+		case "media_metadata":
+			stats.Media = int(n)
+		}
+	}
+
+	return stats, tx.Commit()
+}
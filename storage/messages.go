@@ -1,8 +1,11 @@
 package storage
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"slices"
+	"strings"
 	"time"
 )
 
@@ -15,7 +18,8 @@ type Message struct {
 	Timestamp   time.Time
 	IsFromMe    bool
 	MessageType string
-	ReplyToID   string // ID of the message this is replying to or reacting to (optional)
+	ReplyToID   string          // ID of the message this is replying to or reacting to (optional)
+	Payload     *MessagePayload // Structured location/contact content (optional)
 }
 
 // ReferralInfo holds Click-to-WhatsApp (CTWA) ad referral metadata extracted from
@@ -31,13 +35,54 @@ type ReferralInfo struct {
 // MessageWithNames represents a message with sender and chat names from the database view.
 type MessageWithNames struct {
 	Message
-	SenderPushName    string         // Current WhatsApp display name (from push_names table)
-	SenderContactName string         // Current saved contact name (from chats table)
-	ChatName          string         // Current chat name (for display)
-	MediaMetadata     *MediaMetadata // Associated media metadata (null if no media)
-	Referral          *ReferralInfo  // CTWA ad referral metadata (null if no ad referral)
+	SenderPushName    string          // Current WhatsApp display name (from push_names table)
+	SenderContactName string          // Current saved contact name (from chats table)
+	ChatName          string          // Current chat name (for display)
+	MediaMetadata     *MediaMetadata  // Associated media metadata (null if no media)
+	Referral          *ReferralInfo   // CTWA ad referral metadata (null if no ad referral)
+	Transcript        string          // Voice note transcript (empty if not transcribed)
+	Reactions         []ReactionCount // Reactions to this message, most frequent first
+	Views             int             // Views of a channel post (0 for other messages)
+	EditedAt          *time.Time      // When the message was last edited (nil if never edited)
+	DeletedAt         *time.Time      // When the message was deleted for everyone (nil if not deleted)
 }
 
+// messageWithNamesColumns is the column list selected from the messages_with_names view.
+// It must stay in sync with scanMessagesWithNames.
+const messageWithNamesColumns = `id, chat_jid, sender_jid, sender_push_name, sender_contact_name, chat_name,
+	       text, timestamp, is_from_me, message_type,
+	       media_file_path, media_file_name, media_file_size, media_mime_type,
+	       media_width, media_height, media_duration, media_download_status,
+	       media_download_timestamp, media_download_error,
+	       reply_to_id, transcript, reactions, edited_at, deleted_at, payload,
+	       views, newsletter_reactions`
+
+// UnknownMessageText is the text stored for messages whose content couldn't be
+// extracted (unsupported media or message types).
+const UnknownMessageText = "[Media or unknown]"
+
+// saveMessageQuery inserts a message or fills in the fields of a stored one.
+// It updates the row in place (instead of INSERT OR REPLACE) so created_at and
+// rows referencing the message are kept. A message delivered again, as history
+// sync does, never replaces known content with a placeholder or an empty
+// value, and the text of edited messages is kept.
+const saveMessageQuery = `
+	INSERT INTO messages
+	(id, chat_jid, sender_jid, text, timestamp, is_from_me, message_type, reply_to_id, payload)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	ON CONFLICT(id) DO UPDATE SET
+	    chat_jid = COALESCE(NULLIF(messages.chat_jid, ''), excluded.chat_jid),
+	    sender_jid = COALESCE(NULLIF(messages.sender_jid, ''), excluded.sender_jid),
+	    text = CASE
+	        WHEN messages.edited_at IS NOT NULL THEN messages.text
+	        WHEN COALESCE(excluded.text, '') IN ('', '` + UnknownMessageText + `') THEN COALESCE(NULLIF(messages.text, ''), excluded.text)
+	        ELSE excluded.text END,
+	    timestamp = COALESCE(NULLIF(messages.timestamp, 0), excluded.timestamp),
+	    message_type = CASE WHEN excluded.message_type IN ('', 'unknown') THEN messages.message_type ELSE excluded.message_type END,
+	    reply_to_id = COALESCE(excluded.reply_to_id, messages.reply_to_id),
+	    payload = COALESCE(excluded.payload, messages.payload)
+	`
+
 // MessageStore handles message operations on the database.
 type MessageStore struct {
 	db *sql.DB
@@ -50,20 +95,19 @@ func NewMessageStore(db *sql.DB) *MessageStore {
 
 // SaveMessage saves a WhatsApp message to the database.
 func (s *MessageStore) SaveMessage(msg Message) error {
-	query := `
-	INSERT OR REPLACE INTO messages
-	(id, chat_jid, sender_jid, text, timestamp, is_from_me, message_type, reply_to_id)
-	VALUES (?, ?, ?, ?, ?, ?, ?, ?)
-	`
-
 	// Use nil for empty reply_to_id
 	var replyToID interface{}
 	if msg.ReplyToID != "" {
 		replyToID = msg.ReplyToID
 	}
 
-	_, err := s.db.Exec(
-		query,
+	payload, err := encodePayload(msg.Payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode payload: %w", err)
+	}
+
+	_, err = s.db.Exec(
+		saveMessageQuery,
 		msg.ID,
 		msg.ChatJID,
 		msg.SenderJID,
@@ -72,6 +116,7 @@ func (s *MessageStore) SaveMessage(msg Message) error {
 		msg.IsFromMe,
 		msg.MessageType,
 		replyToID,
+		payload,
 	)
 
 	if err != nil {
@@ -92,11 +137,43 @@ func (s *MessageStore) SaveBulk(messages []Message) error {
 
 	defer tx.Rollback()
 
-	stmt, err := tx.Prepare(`
-	INSERT OR REPLACE INTO messages
-	(id, chat_jid, sender_jid, text, timestamp, is_from_me, message_type, reply_to_id)
-	VALUES (?, ?, ?, ?, ?, ?, ?, ?)
-	`)
+	if err := saveMessagesTx(tx, messages); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+
+}
+
+// SaveMessageBatch saves chats and then their messages in a single
+// transaction, for batches of received messages.
+func (s *MessageStore) SaveMessageBatch(chats []Chat, messages []Message) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, chat := range chats {
+		if chat.JID == "" {
+			return fmt.Errorf("chat JID cannot be empty")
+		}
+		_, err := tx.Exec(saveChatQuery, chat.JID, chat.PushName, chat.ContactName, chat.LastMessageTime.Unix(), chat.IsGroup)
+		if err != nil {
+			return fmt.Errorf("failed to save chat %s: %w", chat.JID, err)
+		}
+	}
+
+	if err := saveMessagesTx(tx, messages); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// saveMessagesTx inserts messages within a transaction.
+func saveMessagesTx(tx *sql.Tx, messages []Message) error {
+	stmt, err := tx.Prepare(saveMessageQuery)
 	if err != nil {
 		return err
 	}
@@ -110,7 +187,12 @@ func (s *MessageStore) SaveBulk(messages []Message) error {
 			replyToID = msg.ReplyToID
 		}
 
-		_, err := stmt.Exec(
+		payload, err := encodePayload(msg.Payload)
+		if err != nil {
+			return fmt.Errorf("failed to encode payload of %s: %w", msg.ID, err)
+		}
+
+		_, err = stmt.Exec(
 			msg.ID,
 			msg.ChatJID,
 			msg.SenderJID,
@@ -119,6 +201,7 @@ func (s *MessageStore) SaveBulk(messages []Message) error {
 			msg.IsFromMe,
 			msg.MessageType,
 			replyToID,
+			payload,
 		)
 
 		if err != nil {
@@ -126,47 +209,7 @@ func (s *MessageStore) SaveBulk(messages []Message) error {
 		}
 	}
 
-	return tx.Commit()
-
-}
-
-// SearchMessages searches messages by text content.
-func (s *MessageStore) SearchMessages(q string, limit int) ([]Message, error) {
-	query := `
-	SELECT id, chat_jid, sender_jid, text, timestamp, is_from_me, message_type
-	FROM messages
-	WHERE text LIKE ?
-	ORDER BY timestamp DESC
-	LIMIT ?
-	`
-
-	rows, err := s.db.Query(query, "%"+q+"%", limit)
-	if err != nil {
-		return nil, err
-	}
-
-	defer rows.Close()
-
-	return s.scanMessages(rows)
-}
-
-// GetChatMessages retrieves messages from a specific chat.
-func (s *MessageStore) GetChatMessages(chatJID string, limit int, offset int) ([]Message, error) {
-	query := `
-	SELECT id, chat_jid, sender_jid, text, timestamp, is_from_me, message_type
-	FROM messages
-	WHERE chat_jid = ?
-	ORDER BY timestamp DESC
-	LIMIT ? OFFSET ?
-	`
-
-	rows, err := s.db.Query(query, chatJID, limit, offset)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	return s.scanMessages(rows)
+	return nil
 }
 
 // GetMessageByID retrieves a message by its ID.
@@ -206,6 +249,37 @@ func (s *MessageStore) GetMessageByID(messageID string) (*Message, error) {
 	return &msg, nil
 }
 
+// GetMessageWithNames retrieves a message with its names, media and
+// reactions by ID. It returns nil if the message is not found.
+func (s *MessageStore) GetMessageWithNames(ctx context.Context, messageID string) (*MessageWithNames, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+messageWithNamesColumns+` FROM messages_with_names WHERE id = ?`, messageID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	messages, err := s.scanMessagesWithNames(rows)
+	if err != nil || len(messages) == 0 {
+		return nil, err
+	}
+	return &messages[0], nil
+}
+
+// MarkMessageDeleted marks a message as deleted for everyone, keeping its content.
+// It returns false if the message is not stored.
+func (s *MessageStore) MarkMessageDeleted(messageID string, deletedAt time.Time) (bool, error) {
+	result, err := s.db.Exec(
+		"UPDATE messages SET deleted_at = COALESCE(deleted_at, ?) WHERE id = ?",
+		deletedAt.Unix(), messageID,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
 // GetOldestMessage retrieves the oldest message from a specific chat.
 // This is used for history sync requests.
 func (s *MessageStore) GetOldestMessage(chatJID string) (*Message, error) {
@@ -247,20 +321,16 @@ func (s *MessageStore) GetOldestMessage(chatJID string) (*Message, error) {
 
 // GetChatMessagesOlderThan retrieves messages older than a specific timestamp.
 // This is used for retrieving newly loaded messages from history sync.
-func (s *MessageStore) GetChatMessagesOlderThan(chatJID string, timestamp time.Time, limit int) ([]MessageWithNames, error) {
+func (s *MessageStore) GetChatMessagesOlderThan(ctx context.Context, chatJID string, timestamp time.Time, limit int) ([]MessageWithNames, error) {
 	query := `
-	SELECT id, chat_jid, sender_jid, sender_push_name, sender_contact_name, chat_name,
-	       text, timestamp, is_from_me, message_type,
-	       media_file_path, media_file_name, media_file_size, media_mime_type,
-	       media_width, media_height, media_duration, media_download_status,
-	       media_download_timestamp, media_download_error
+	SELECT ` + messageWithNamesColumns + `
 	FROM messages_with_names
-	WHERE chat_jid = ? AND timestamp < ?
+	WHERE chat_jid = ? AND timestamp < ? AND deleted_at IS NULL
 	ORDER BY timestamp DESC
 	LIMIT ?
 	`
 
-	rows, err := s.db.Query(query, chatJID, timestamp.Unix(), limit)
+	rows, err := s.db.QueryContext(ctx, query, chatJID, timestamp.Unix(), limit)
 	if err != nil {
 		return nil, err
 	}
@@ -269,26 +339,46 @@ func (s *MessageStore) GetChatMessagesOlderThan(chatJID string, timestamp time.T
 	return s.scanMessagesWithNames(rows)
 }
 
-// GetChatMessagesWithNamesFiltered retrieves chat messages with advanced filtering.
+// CountChatMessagesOlderThan counts the messages of a chat older than a specific timestamp.
+// This is used to report progress while a history sync is loading messages.
+func (s *MessageStore) CountChatMessagesOlderThan(chatJID string, timestamp time.Time) (int, error) {
+	var count int
+	err := s.db.QueryRow(
+		"SELECT COUNT(*) FROM messages WHERE chat_jid = ? AND timestamp < ?",
+		chatJID, timestamp.Unix(),
+	).Scan(&count)
+	return count, err
+}
+
+// GetChatMessagesWithNamesFiltered retrieves chat messages with advanced filtering,
+// newest first. Pages are selected by keyset: beforeCursor continues toward
+// older messages and afterCursor toward newer ones.
+// offset is only kept for callers that still page by offset, which is O(n).
 func (s *MessageStore) GetChatMessagesWithNamesFiltered(
+	ctx context.Context,
 	chatJID string,
 	limit int,
 	beforeTimestamp *time.Time,
 	afterTimestamp *time.Time,
 	senderJID string,
+	beforeCursor *PageCursor,
+	afterCursor *PageCursor,
+	offset int,
+	includeDeleted bool,
 ) ([]MessageWithNames, error) {
 	query := `
-	SELECT id, chat_jid, sender_jid, sender_push_name, sender_contact_name, chat_name,
-	       text, timestamp, is_from_me, message_type,
-	       media_file_path, media_file_name, media_file_size, media_mime_type,
-	       media_width, media_height, media_duration, media_download_status,
-	       media_download_timestamp, media_download_error
+	SELECT ` + messageWithNamesColumns + `
 	FROM messages_with_names
 	WHERE chat_jid = ?
 	`
 
 	args := []any{chatJID}
 
+	// deleted messages are hidden unless requested
+	if !includeDeleted {
+		query += " AND deleted_at IS NULL"
+	}
+
 	// add timestamp filters
 	if beforeTimestamp != nil {
 		query += " AND timestamp < ?"
@@ -306,145 +396,193 @@ func (s *MessageStore) GetChatMessagesWithNamesFiltered(
 		args = append(args, senderJID)
 	}
 
-	query += " ORDER BY timestamp DESC LIMIT ?"
-	args = append(args, limit)
+	// continue from the previous page
+	conditions, cursorArgs, order, ascending := keysetClause(beforeCursor, afterCursor)
+	query += conditions + order + " LIMIT ?"
+	args = append(append(args, cursorArgs...), limit)
 
-	rows, err := s.db.Query(query, args...)
+	if offset > 0 {
+		query += " OFFSET ?"
+		args = append(args, offset)
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	return s.scanMessagesWithNames(rows)
+	messages, err := s.scanMessagesWithNames(rows)
+	if ascending {
+		slices.Reverse(messages)
+	}
+	return messages, err
+}
+
+// SearchFilter holds the optional criteria for SearchMessagesWithNamesFiltered.
+// Zero values mean "no filter" for every field except Limit.
+type SearchFilter struct {
+	Query          string      // text pattern (empty matches any text)
+	UseGlob        bool        // use GLOB instead of LIKE for Query
+	SenderJID      string      // only messages from this sender
+	ChatJIDs       []string    // only messages from these chats (empty means all chats)
+	After          *time.Time  // only messages strictly after this time
+	Before         *time.Time  // only messages strictly before this time
+	MessageType    string      // only messages of this type (text, image, url, ...)
+	Mentioned      []string    // only messages mentioning any of these JIDs
+	Tag            string      // only messages with this tag or in a chat with it
+	BeforeCursor   *PageCursor // only messages older than this one (the last message of the previous page)
+	AfterCursor    *PageCursor // only messages newer than this one (the first message of the previous page)
+	IncludeDeleted bool        // also return messages deleted for everyone
+	Limit          int
+}
+
+// SearchMessagesWithNamesFiltered searches messages with pattern matching and optional filters.
+// It uses GLOB patterns if filter.UseGlob is true, otherwise uses LIKE for fuzzy matching.
+// All filters are pushed down into the SQL query.
+func (s *MessageStore) SearchMessagesWithNamesFiltered(ctx context.Context, filter SearchFilter) ([]MessageWithNames, error) {
+	conditions, args := searchConditions(filter)
+	sqlQuery := `
+	SELECT ` + messageWithNamesColumns + `
+	FROM messages_with_names
+	WHERE 1 = 1` + conditions
+
+	// continue from the previous page
+	cursorConditions, cursorArgs, order, ascending := keysetClause(filter.BeforeCursor, filter.AfterCursor)
+	sqlQuery += cursorConditions + order + " LIMIT ?"
+	args = append(append(args, cursorArgs...), filter.Limit)
+
+	rows, err := s.db.QueryContext(ctx, sqlQuery, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	messages, err := s.scanMessagesWithNames(rows)
+	if ascending {
+		slices.Reverse(messages)
+	}
+	return messages, err
 }
 
-// scanMessages converts SQL rows into Message objects.
-func (s *MessageStore) scanMessages(rows *sql.Rows) ([]Message, error) {
-	var messages []Message
+// ForEachMessageWithNames streams the messages matching filter, oldest first, to fn.
+// The cursors are ignored and a zero filter.Limit means no limit.
+// Iteration stops at the first error returned by fn or when ctx is cancelled.
+func (s *MessageStore) ForEachMessageWithNames(ctx context.Context, filter SearchFilter, fn func(MessageWithNames) error) error {
+	conditions, args := searchConditions(filter)
+	sqlQuery := `
+	SELECT ` + messageWithNamesColumns + `
+	FROM messages_with_names
+	WHERE 1 = 1` + conditions + `
+	ORDER BY timestamp ASC, id ASC`
+
+	if filter.Limit > 0 {
+		sqlQuery += " LIMIT ?"
+		args = append(args, filter.Limit)
+	}
+
+	rows, err := s.db.QueryContext(ctx, sqlQuery, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
 
 	for rows.Next() {
-		var msg Message
-		var timestampUnix int64
-
-		err := rows.Scan(
-			&msg.ID,
-			&msg.ChatJID,
-			&msg.SenderJID,
-			&msg.Text,
-			&timestampUnix,
-			&msg.IsFromMe,
-			&msg.MessageType,
-		)
+		msg, err := s.scanMessageWithNames(rows)
 		if err != nil {
-			return nil, err
+			return err
+		}
+		if err := fn(msg); err != nil {
+			return err
 		}
-
-		msg.Timestamp = time.Unix(timestampUnix, 0)
-		messages = append(messages, msg)
 	}
 
-	return messages, rows.Err()
+	return rows.Err()
 }
 
-// SearchMessagesWithNamesFiltered searches messages with pattern matching and sender filtering.
-// It uses GLOB patterns if useGlob is true, otherwise uses LIKE for fuzzy matching.
-func (s *MessageStore) SearchMessagesWithNamesFiltered(
-	query string,
-	useGlob bool,
-	senderJID string,
-	limit int,
-) ([]MessageWithNames, error) {
-	var sqlQuery string
+// searchConditions returns the SQL conditions (each prefixed with AND) and
+// arguments for the filters of a SearchFilter, except the cursors and Limit.
+func searchConditions(filter SearchFilter) (string, []any) {
+	var conditions strings.Builder
 	var args []any
 
+	// deleted messages are hidden unless requested
+	if !filter.IncludeDeleted {
+		conditions.WriteString(" AND deleted_at IS NULL")
+	}
+
 	// choose LIKE or GLOB based on pattern type
-	if useGlob {
-		sqlQuery = `
-		SELECT id, chat_jid, sender_jid, sender_push_name, sender_contact_name, chat_name,
-		       text, timestamp, is_from_me, message_type,
-		       media_file_path, media_file_name, media_file_size, media_mime_type,
-		       media_width, media_height, media_duration, media_download_status,
-		       media_download_timestamp, media_download_error
-		FROM messages_with_names
-		WHERE text GLOB ?
-		`
-		args = append(args, query)
-	} else {
-		sqlQuery = `
-		SELECT id, chat_jid, sender_jid, sender_push_name, sender_contact_name, chat_name,
-		       text, timestamp, is_from_me, message_type,
-		       media_file_path, media_file_name, media_file_size, media_mime_type,
-		       media_width, media_height, media_duration, media_download_status,
-		       media_download_timestamp, media_download_error
-		FROM messages_with_names
-		WHERE text LIKE ?
-		`
-		args = append(args, "%"+query+"%")
+	if filter.Query != "" {
+		// voice note transcripts are searched like text
+		if filter.UseGlob {
+			conditions.WriteString(" AND (text GLOB ? OR transcript GLOB ?)")
+			args = append(args, filter.Query, filter.Query)
+		} else {
+			conditions.WriteString(" AND (text LIKE ? OR transcript LIKE ?)")
+			args = append(args, "%"+filter.Query+"%", "%"+filter.Query+"%")
+		}
 	}
 
 	// add sender filter
-	if senderJID != "" {
-		sqlQuery += " AND sender_jid = ?"
-		args = append(args, senderJID)
+	if filter.SenderJID != "" {
+		conditions.WriteString(" AND sender_jid = ?")
+		args = append(args, filter.SenderJID)
 	}
 
-	sqlQuery += " ORDER BY timestamp DESC LIMIT ?"
-	args = append(args, limit)
+	// add chat scope filter
+	if len(filter.ChatJIDs) > 0 {
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(filter.ChatJIDs)), ", ")
+		conditions.WriteString(" AND chat_jid IN (" + placeholders + ")")
+		for _, jid := range filter.ChatJIDs {
+			args = append(args, jid)
+		}
+	}
 
-	rows, err := s.db.Query(sqlQuery, args...)
-	if err != nil {
-		return nil, err
+	// add timestamp filters
+	if filter.After != nil {
+		conditions.WriteString(" AND timestamp > ?")
+		args = append(args, filter.After.Unix())
 	}
-	defer rows.Close()
 
-	return s.scanMessagesWithNames(rows)
-}
+	if filter.Before != nil {
+		conditions.WriteString(" AND timestamp < ?")
+		args = append(args, filter.Before.Unix())
+	}
 
-// SearchMessagesWithNames searches messages and includes sender names from view
-func (s *MessageStore) SearchMessagesWithNames(q string, limit int) ([]MessageWithNames, error) {
-	query := `
-	SELECT id, chat_jid, sender_jid, sender_push_name, sender_contact_name, chat_name,
-	       text, timestamp, is_from_me, message_type,
-	       media_file_path, media_file_name, media_file_size, media_mime_type,
-	       media_width, media_height, media_duration, media_download_status,
-	       media_download_timestamp, media_download_error
-	FROM messages_with_names
-	WHERE text LIKE ?
-	ORDER BY timestamp DESC
-	LIMIT ?
-	`
+	// add message type filter
+	if filter.MessageType != "" {
+		conditions.WriteString(" AND message_type = ?")
+		args = append(args, filter.MessageType)
+	}
 
-	rows, err := s.db.Query(query, "%"+q+"%", limit)
-	if err != nil {
-		return nil, err
+	// add mention filter
+	if len(filter.Mentioned) > 0 {
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(filter.Mentioned)), ", ")
+		conditions.WriteString(" AND id IN (SELECT message_id FROM message_mentions WHERE mentioned_jid IN (" + placeholders + "))")
+		for _, jid := range filter.Mentioned {
+			args = append(args, jid)
+		}
 	}
 
-	defer rows.Close()
+	// add tag filter
+	if filter.Tag != "" {
+		conditions.WriteString(" AND (id IN (SELECT message_id FROM message_tags WHERE tag = ?) OR chat_jid IN (SELECT chat_jid FROM chat_tags WHERE tag = ?))")
+		args = append(args, filter.Tag, filter.Tag)
+	}
 
-	return s.scanMessagesWithNames(rows)
+	return conditions.String(), args
 }
 
-// GetChatMessagesWithNames gets chat messages and includes sender names from view
-func (s *MessageStore) GetChatMessagesWithNames(chatJID string, limit int, offset int) ([]MessageWithNames, error) {
-	query := `
-	SELECT id, chat_jid, sender_jid, sender_push_name, sender_contact_name, chat_name,
-	       text, timestamp, is_from_me, message_type,
-	       media_file_path, media_file_name, media_file_size, media_mime_type,
-	       media_width, media_height, media_duration, media_download_status,
-	       media_download_timestamp, media_download_error
-	FROM messages_with_names
-	WHERE chat_jid = ?
-	ORDER BY timestamp DESC
-	LIMIT ? OFFSET ?
-	`
-
-	rows, err := s.db.Query(query, chatJID, limit, offset)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
+// GetChatMessagesWithNames gets the newest messages of a chat, newest first,
+// and includes sender names from view.
+func (s *MessageStore) GetChatMessagesWithNames(chatJID string, limit int) ([]MessageWithNames, error) {
+	return s.GetChatMessagesWithNamesFiltered(context.Background(), chatJID, limit, nil, nil, "", nil, nil, 0, false)
+}
 
-	return s.scanMessagesWithNames(rows)
+// ForEachChatMessageWithNames streams every message of a chat, oldest first, to fn.
+// Iteration stops at the first error returned by fn or when ctx is cancelled.
+func (s *MessageStore) ForEachChatMessageWithNames(ctx context.Context, chatJID string, fn func(MessageWithNames) error) error {
+	return s.ForEachMessageWithNames(ctx, SearchFilter{ChatJIDs: []string{chatJID}, IncludeDeleted: true}, fn)
 }
 
 // scanMessagesWithNames converts SQL rows into MessageWithNames objects.
@@ -452,86 +590,121 @@ func (s *MessageStore) scanMessagesWithNames(rows *sql.Rows) ([]MessageWithNames
 	var messages []MessageWithNames
 
 	for rows.Next() {
-		var msg MessageWithNames
-		var timestampUnix int64
-
-		// media metadata fields (nullable)
-		var mediaFilePath, mediaFileName, mediaMimeType sql.NullString
-		var mediaFileSize sql.NullInt64
-		var mediaWidth, mediaHeight, mediaDuration sql.NullInt64
-		var mediaDownloadStatus, mediaDownloadError sql.NullString
-		var mediaDownloadTimestamp sql.NullInt64
-
-		err := rows.Scan(
-			&msg.ID,
-			&msg.ChatJID,
-			&msg.SenderJID,
-			&msg.SenderPushName,
-			&msg.SenderContactName,
-			&msg.ChatName,
-			&msg.Text,
-			&timestampUnix,
-			&msg.IsFromMe,
-			&msg.MessageType,
-			// media metadata fields
-			&mediaFilePath,
-			&mediaFileName,
-			&mediaFileSize,
-			&mediaMimeType,
-			&mediaWidth,
-			&mediaHeight,
-			&mediaDuration,
-			&mediaDownloadStatus,
-			&mediaDownloadTimestamp,
-			&mediaDownloadError,
-		)
+		msg, err := s.scanMessageWithNames(rows)
 		if err != nil {
 			return nil, err
 		}
+		messages = append(messages, msg)
+	}
+
+	return messages, rows.Err()
+}
+
+// scanMessageWithNames scans the current row into a MessageWithNames.
+func (s *MessageStore) scanMessageWithNames(rows *sql.Rows) (MessageWithNames, error) {
+	var msg MessageWithNames
+	var timestampUnix int64
+
+	// media metadata fields (nullable)
+	var mediaFilePath, mediaFileName, mediaMimeType sql.NullString
+	var mediaFileSize sql.NullInt64
+	var mediaWidth, mediaHeight, mediaDuration sql.NullInt64
+	var mediaDownloadStatus, mediaDownloadError sql.NullString
+	var mediaDownloadTimestamp, editedAt, deletedAt sql.NullInt64
+	var replyToID, transcript, reactions, payload, newsletterReactions sql.NullString
+	var views sql.NullInt64
+
+	err := rows.Scan(
+		&msg.ID,
+		&msg.ChatJID,
+		&msg.SenderJID,
+		&msg.SenderPushName,
+		&msg.SenderContactName,
+		&msg.ChatName,
+		&msg.Text,
+		&timestampUnix,
+		&msg.IsFromMe,
+		&msg.MessageType,
+		// media metadata fields
+		&mediaFilePath,
+		&mediaFileName,
+		&mediaFileSize,
+		&mediaMimeType,
+		&mediaWidth,
+		&mediaHeight,
+		&mediaDuration,
+		&mediaDownloadStatus,
+		&mediaDownloadTimestamp,
+		&mediaDownloadError,
+		&replyToID,
+		&transcript,
+		&reactions,
+		&editedAt,
+		&deletedAt,
+		&payload,
+		&views,
+		&newsletterReactions,
+	)
+	if err != nil {
+		return msg, err
+	}
+
+	msg.Timestamp = time.Unix(timestampUnix, 0)
+	msg.ReplyToID = replyToID.String
+	msg.Transcript = transcript.String
+	msg.Reactions = parseReactionSummary(reactions.String)
+	if newsletterReactions.Valid {
+		msg.Reactions = parseReactionCounts(newsletterReactions.String)
+	}
+	msg.Views = int(views.Int64)
+	msg.Payload = decodePayload(payload.String)
+	if editedAt.Valid {
+		t := time.Unix(editedAt.Int64, 0)
+		msg.EditedAt = &t
+	}
+	if deletedAt.Valid {
+		t := time.Unix(deletedAt.Int64, 0)
+		msg.DeletedAt = &t
+	}
+
+	// populate media metadata if present
+	if mediaFileName.Valid && mediaMimeType.Valid {
+		meta := &MediaMetadata{
+			MessageID:      msg.ID,
+			FileName:       mediaFileName.String,
+			FileSize:       mediaFileSize.Int64,
+			MimeType:       mediaMimeType.String,
+			DownloadStatus: "pending",
+		}
 
-		msg.Timestamp = time.Unix(timestampUnix, 0)
-
-		// populate media metadata if present
-		if mediaFileName.Valid && mediaMimeType.Valid {
-			meta := &MediaMetadata{
-				MessageID:      msg.ID,
-				FileName:       mediaFileName.String,
-				FileSize:       mediaFileSize.Int64,
-				MimeType:       mediaMimeType.String,
-				DownloadStatus: "pending",
-			}
-
-			if mediaFilePath.Valid {
-				meta.FilePath = mediaFilePath.String
-			}
-			if mediaWidth.Valid {
-				w := int(mediaWidth.Int64)
-				meta.Width = &w
-			}
-			if mediaHeight.Valid {
-				h := int(mediaHeight.Int64)
-				meta.Height = &h
-			}
-			if mediaDuration.Valid {
-				d := int(mediaDuration.Int64)
-				meta.Duration = &d
-			}
-			if mediaDownloadStatus.Valid {
-				meta.DownloadStatus = mediaDownloadStatus.String
-			}
-			if mediaDownloadTimestamp.Valid {
-				ts := time.Unix(mediaDownloadTimestamp.Int64, 0)
-				meta.DownloadTimestamp = &ts
-			}
-			if mediaDownloadError.Valid {
-				meta.DownloadError = mediaDownloadError.String
-			}
-
-			msg.MediaMetadata = meta
+		if mediaFilePath.Valid {
+			meta.FilePath = mediaFilePath.String
+		}
+		if mediaWidth.Valid {
+			w := int(mediaWidth.Int64)
+			meta.Width = &w
+		}
+		if mediaHeight.Valid {
+			h := int(mediaHeight.Int64)
+			meta.Height = &h
+		}
+		if mediaDuration.Valid {
+			d := int(mediaDuration.Int64)
+			meta.Duration = &d
+		}
+		if mediaDownloadStatus.Valid {
+			meta.DownloadStatus = mediaDownloadStatus.String
+		}
+		if mediaDownloadTimestamp.Valid {
+			ts := time.Unix(mediaDownloadTimestamp.Int64, 0)
+			meta.DownloadTimestamp = &ts
+		}
+		if mediaDownloadError.Valid {
+			meta.DownloadError = mediaDownloadError.String
 		}
 
-		messages = append(messages, msg)
+		msg.MediaMetadata = meta
 	}
 
-	return messages, rows.Err()
+	return msg, nil
 }
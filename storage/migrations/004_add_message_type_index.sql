@@ -0,0 +1,8 @@
+-- Migration: 004_add_message_type_index
+-- Description: Index message_type for type-filtered searches
+-- Previous: 003_add_reply_to_id
+-- Version: 004
+-- Created: 2026-10-16
+
+-- Speeds up search_messages filtered by message_type (newest first)
+CREATE INDEX IF NOT EXISTS idx_message_type_timestamp ON messages(message_type, timestamp DESC);
@@ -0,0 +1,17 @@
+-- Migration: 005_add_message_mentions
+-- Description: Store @-mentions from messages
+-- Previous: 004_add_message_type_index
+-- Version: 005
+-- Created: 2026-10-16
+
+-- One row per JID mentioned in a message (from ContextInfo.MentionedJID)
+CREATE TABLE IF NOT EXISTS message_mentions (
+    message_id TEXT NOT NULL,
+    mentioned_jid TEXT NOT NULL, -- Canonical JID of the mentioned user
+
+    PRIMARY KEY (message_id, mentioned_jid),
+    FOREIGN KEY (message_id) REFERENCES messages(id) ON DELETE CASCADE
+);
+
+-- Index for "messages where X was mentioned"
+CREATE INDEX IF NOT EXISTS idx_mentions_jid ON message_mentions(mentioned_jid);
@@ -0,0 +1,8 @@
+-- Migration: 006_add_disappearing_timer
+-- Description: Track the disappearing messages timer per chat
+-- Previous: 005_add_message_mentions
+-- Version: 006
+-- Created: 2026-10-16
+
+-- Disappearing messages timer in seconds (0 = off, 86400 = 24h, 604800 = 7d, 7776000 = 90d)
+ALTER TABLE chats ADD COLUMN disappearing_timer INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,14 @@
+-- Migration: 007_add_presence
+-- Description: Store last known presence (online/last seen) per user
+-- Previous: 006_add_disappearing_timer
+-- Version: 007
+-- Created: 2026-10-16
+
+-- Last presence update received for each subscribed user
+CREATE TABLE IF NOT EXISTS presence (
+    jid TEXT PRIMARY KEY, -- Canonical user JID
+    is_online BOOLEAN NOT NULL DEFAULT FALSE,
+    last_seen INTEGER, -- Unix timestamp (null if hidden by privacy settings)
+    subscribed_at INTEGER, -- When the presence subscription was last made
+    updated_at INTEGER -- When the last presence event was received (null if none yet)
+);
@@ -0,0 +1,16 @@
+-- Migration: 008_add_idempotency_keys
+-- Description: Client-supplied idempotency keys for send_message retries
+-- Previous: 007_add_presence
+-- Version: 008
+-- Created: 2026-10-16
+
+-- One row per idempotency key; result is set once the send completes
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    key TEXT PRIMARY KEY, -- Client-supplied idempotency key
+    chat_jid TEXT NOT NULL, -- Chat the message was sent to
+    message_id TEXT, -- WhatsApp message ID (null while the send is in progress)
+    result TEXT, -- Original tool result returned to the client
+    created_at INTEGER NOT NULL -- Unix timestamp
+);
+
+CREATE INDEX IF NOT EXISTS idx_idempotency_created ON idempotency_keys(created_at);
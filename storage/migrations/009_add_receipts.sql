@@ -0,0 +1,18 @@
+-- Migration: 009_add_receipts
+-- Description: Delivery/read receipts for sent messages
+-- Previous: 008_add_idempotency_keys
+-- Version: 009
+-- Created: 2026-10-16
+
+-- One row per message, participant and receipt status (first time each status was reached)
+CREATE TABLE IF NOT EXISTS receipts (
+    message_id TEXT NOT NULL,
+    chat_jid TEXT NOT NULL, -- Canonical chat JID
+    participant_jid TEXT NOT NULL, -- Canonical JID of the recipient that sent the receipt
+    status TEXT NOT NULL, -- 'delivered', 'read', 'played'
+    timestamp INTEGER NOT NULL, -- Unix timestamp
+
+    PRIMARY KEY (message_id, participant_jid, status)
+);
+
+CREATE INDEX IF NOT EXISTS idx_receipts_chat ON receipts(chat_jid, timestamp DESC);
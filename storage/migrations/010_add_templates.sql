@@ -0,0 +1,13 @@
+-- Migration: 010_add_templates
+-- Description: Named message templates with {{placeholders}}
+-- Previous: 009_add_receipts
+-- Version: 010
+-- Created: 2026-10-16
+
+CREATE TABLE IF NOT EXISTS templates (
+    name TEXT PRIMARY KEY, -- Unique template name
+    text TEXT NOT NULL, -- Template body with {{placeholders}}
+    description TEXT NOT NULL DEFAULT '', -- What the template is for
+    created_at INTEGER NOT NULL, -- Unix timestamp
+    updated_at INTEGER NOT NULL -- Unix timestamp
+);
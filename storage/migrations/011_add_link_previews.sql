@@ -0,0 +1,14 @@
+-- Migration: 011_add_link_previews
+-- Description: Store link preview data (URL, page title) from ExtendedTextMessage
+-- Previous: 010_add_templates
+-- Version: 011
+-- Created: 2026-10-16
+
+CREATE TABLE IF NOT EXISTS link_previews (
+    message_id TEXT PRIMARY KEY,
+    url TEXT NOT NULL, -- Matched URL the preview belongs to
+    title TEXT NOT NULL DEFAULT '', -- Page title
+    description TEXT NOT NULL DEFAULT '', -- Page description
+
+    FOREIGN KEY (message_id) REFERENCES messages(id) ON DELETE CASCADE
+);
@@ -0,0 +1,66 @@
+-- Migration: 012_add_transcripts
+-- Description: Store voice note transcripts and expose them (and reply_to_id) in messages_with_names
+-- Previous: 011_add_link_previews
+-- Version: 012
+-- Created: 2026-10-16
+
+-- Transcripts live in their own table so re-saving a message (history sync) keeps them
+CREATE TABLE IF NOT EXISTS transcripts (
+    message_id TEXT PRIMARY KEY,
+    text TEXT NOT NULL, -- Transcribed speech
+    source TEXT NOT NULL, -- 'http' or 'command'
+    created_at INTEGER NOT NULL, -- Unix timestamp
+
+    FOREIGN KEY (message_id) REFERENCES messages(id) ON DELETE CASCADE
+);
+
+-- Recreate the view with the new columns
+DROP VIEW IF EXISTS messages_with_names;
+
+CREATE VIEW messages_with_names AS
+SELECT
+    m.id,
+    m.chat_jid,
+    m.sender_jid,
+
+    -- Get sender's current push name (WhatsApp display name)
+    COALESCE(p.push_name, '') as sender_push_name,
+
+    -- Get sender's current contact name (saved contact)
+    COALESCE(c_sender.contact_name, '') as sender_contact_name,
+
+    -- Get chat name (for display)
+    COALESCE(
+        c_chat.contact_name,  -- Saved contact name for DMs
+        c_chat.push_name,     -- Push name for DMs or group name for groups
+        m.chat_jid            -- Fallback to JID
+    ) as chat_name,
+
+    -- Original message fields
+    m.text,
+    m.timestamp,
+    m.is_from_me,
+    m.message_type,
+    m.created_at,
+    m.reply_to_id,
+
+    -- Media metadata fields (nullable)
+    media.file_path as media_file_path,
+    media.file_name as media_file_name,
+    media.file_size as media_file_size,
+    media.mime_type as media_mime_type,
+    media.width as media_width,
+    media.height as media_height,
+    media.duration as media_duration,
+    media.download_status as media_download_status,
+    media.download_timestamp as media_download_timestamp,
+    media.download_error as media_download_error,
+
+    -- Voice note transcript (nullable)
+    t.text as transcript
+FROM messages m
+LEFT JOIN push_names p ON m.sender_jid = p.jid
+LEFT JOIN chats c_sender ON m.sender_jid = c_sender.jid
+LEFT JOIN chats c_chat ON m.chat_jid = c_chat.jid
+LEFT JOIN media_metadata media ON m.id = media.message_id
+LEFT JOIN transcripts t ON m.id = t.message_id;
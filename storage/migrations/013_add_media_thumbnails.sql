@@ -0,0 +1,7 @@
+-- Migration: 013_add_media_thumbnails
+-- Description: Store the JPEG thumbnails embedded in image, video and document messages
+-- Previous: 012_add_transcripts
+-- Version: 013
+-- Created: 2026-10-16
+
+ALTER TABLE media_metadata ADD COLUMN thumbnail BLOB; -- JPEG preview (null if the message had none)
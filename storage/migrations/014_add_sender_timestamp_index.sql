@@ -0,0 +1,9 @@
+-- Migration: 014_add_sender_timestamp_index
+-- Description: Index messages by sender and time for sender-only searches (search_messages from=...)
+-- Previous: 013_add_media_thumbnails
+-- Version: 014
+-- Created: 2026-10-16
+
+-- Replaces the sender-only index: the composite index also serves ORDER BY timestamp
+DROP INDEX IF EXISTS idx_sender;
+CREATE INDEX IF NOT EXISTS idx_sender_timestamp ON messages(sender_jid, timestamp DESC);
@@ -0,0 +1,82 @@
+-- Migration: 015_add_reactions
+-- Description: Store reactions by target message and expose a reaction summary in messages_with_names
+-- Previous: 014_add_sender_timestamp_index
+-- Version: 015
+-- Created: 2026-10-16
+
+-- One reaction per sender and target message (a new reaction replaces the previous one)
+-- No foreign key: reactions can arrive for messages that are not stored (yet)
+CREATE TABLE IF NOT EXISTS reactions (
+    target_message_id TEXT NOT NULL, -- ID of the message reacted to
+    sender_jid TEXT NOT NULL, -- Canonical JID of who reacted
+    chat_jid TEXT NOT NULL, -- Canonical chat JID
+    emoji TEXT NOT NULL,
+    timestamp INTEGER NOT NULL, -- Unix timestamp
+
+    PRIMARY KEY (target_message_id, sender_jid)
+);
+
+CREATE INDEX IF NOT EXISTS idx_reactions_chat ON reactions(chat_jid, timestamp DESC);
+
+-- Move reactions previously stored as standalone messages (latest reaction per sender wins)
+INSERT OR REPLACE INTO reactions (target_message_id, sender_jid, chat_jid, emoji, timestamp)
+SELECT reply_to_id, sender_jid, chat_jid, text, timestamp
+FROM messages
+WHERE message_type = 'reaction' AND reply_to_id IS NOT NULL AND text != '' AND text != '[Reaction]'
+ORDER BY timestamp ASC;
+
+DELETE FROM messages WHERE message_type = 'reaction';
+
+-- Recreate the view with the reaction summary
+DROP VIEW IF EXISTS messages_with_names;
+
+CREATE VIEW messages_with_names AS
+SELECT
+    m.id,
+    m.chat_jid,
+    m.sender_jid,
+
+    -- Get sender's current push name (WhatsApp display name)
+    COALESCE(p.push_name, '') as sender_push_name,
+
+    -- Get sender's current contact name (saved contact)
+    COALESCE(c_sender.contact_name, '') as sender_contact_name,
+
+    -- Get chat name (for display)
+    COALESCE(
+        c_chat.contact_name,  -- Saved contact name for DMs
+        c_chat.push_name,     -- Push name for DMs or group name for groups
+        m.chat_jid            -- Fallback to JID
+    ) as chat_name,
+
+    -- Original message fields
+    m.text,
+    m.timestamp,
+    m.is_from_me,
+    m.message_type,
+    m.created_at,
+    m.reply_to_id,
+
+    -- Media metadata fields (nullable)
+    media.file_path as media_file_path,
+    media.file_name as media_file_name,
+    media.file_size as media_file_size,
+    media.mime_type as media_mime_type,
+    media.width as media_width,
+    media.height as media_height,
+    media.duration as media_duration,
+    media.download_status as media_download_status,
+    media.download_timestamp as media_download_timestamp,
+    media.download_error as media_download_error,
+
+    -- Voice note transcript (nullable)
+    t.text as transcript,
+
+    -- Space-separated emojis of the reactions to this message (nullable)
+    (SELECT GROUP_CONCAT(r.emoji, ' ') FROM reactions r WHERE r.target_message_id = m.id) as reactions
+FROM messages m
+LEFT JOIN push_names p ON m.sender_jid = p.jid
+LEFT JOIN chats c_sender ON m.sender_jid = c_sender.jid
+LEFT JOIN chats c_chat ON m.chat_jid = c_chat.jid
+LEFT JOIN media_metadata media ON m.id = media.message_id
+LEFT JOIN transcripts t ON m.id = t.message_id;
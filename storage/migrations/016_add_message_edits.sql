@@ -0,0 +1,75 @@
+-- Migration: 016_add_message_edits
+-- Description: Keep prior versions of edited messages and expose edited_at in messages_with_names
+-- Previous: 015_add_reactions
+-- Version: 016
+-- Created: 2026-10-16
+
+-- When the message was last edited (null if never edited)
+ALTER TABLE messages ADD COLUMN edited_at INTEGER;
+
+-- One row per replaced version of a message
+CREATE TABLE IF NOT EXISTS message_edits (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    message_id TEXT NOT NULL,
+    text TEXT NOT NULL, -- Text before the edit
+    replaced_at INTEGER NOT NULL, -- Unix timestamp of the edit that replaced this text
+
+    FOREIGN KEY (message_id) REFERENCES messages(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_message_edits_message ON message_edits(message_id, replaced_at);
+
+-- Recreate the view with the new column
+DROP VIEW IF EXISTS messages_with_names;
+
+CREATE VIEW messages_with_names AS
+SELECT
+    m.id,
+    m.chat_jid,
+    m.sender_jid,
+
+    -- Get sender's current push name (WhatsApp display name)
+    COALESCE(p.push_name, '') as sender_push_name,
+
+    -- Get sender's current contact name (saved contact)
+    COALESCE(c_sender.contact_name, '') as sender_contact_name,
+
+    -- Get chat name (for display)
+    COALESCE(
+        c_chat.contact_name,  -- Saved contact name for DMs
+        c_chat.push_name,     -- Push name for DMs or group name for groups
+        m.chat_jid            -- Fallback to JID
+    ) as chat_name,
+
+    -- Original message fields
+    m.text,
+    m.timestamp,
+    m.is_from_me,
+    m.message_type,
+    m.created_at,
+    m.reply_to_id,
+    m.edited_at,
+
+    -- Media metadata fields (nullable)
+    media.file_path as media_file_path,
+    media.file_name as media_file_name,
+    media.file_size as media_file_size,
+    media.mime_type as media_mime_type,
+    media.width as media_width,
+    media.height as media_height,
+    media.duration as media_duration,
+    media.download_status as media_download_status,
+    media.download_timestamp as media_download_timestamp,
+    media.download_error as media_download_error,
+
+    -- Voice note transcript (nullable)
+    t.text as transcript,
+
+    -- Space-separated emojis of the reactions to this message (nullable)
+    (SELECT GROUP_CONCAT(r.emoji, ' ') FROM reactions r WHERE r.target_message_id = m.id) as reactions
+FROM messages m
+LEFT JOIN push_names p ON m.sender_jid = p.jid
+LEFT JOIN chats c_sender ON m.sender_jid = c_sender.jid
+LEFT JOIN chats c_chat ON m.chat_jid = c_chat.jid
+LEFT JOIN media_metadata media ON m.id = media.message_id
+LEFT JOIN transcripts t ON m.id = t.message_id;
@@ -0,0 +1,38 @@
+-- Migration: 018_derive_unread_counts
+-- Description: Derive unread counts from read receipts instead of the never-updated chats.unread_count column
+-- Previous: 017_add_message_deletion
+-- Version: 018
+-- Created: 2026-10-16
+
+-- Receipts for incoming messages can only come from my own devices (read-self
+-- receipts), so a 'read' receipt on an incoming message means I read it.
+-- A chat is read up to the newest message I sent or read; incoming messages
+-- after that are unread.
+ALTER TABLE chats DROP COLUMN unread_count;
+
+CREATE INDEX IF NOT EXISTS idx_receipts_message ON receipts(message_id);
+
+CREATE VIEW chats_with_unread AS
+SELECT
+    c.*,
+    (
+        SELECT COUNT(*)
+        FROM messages m
+        WHERE m.chat_jid = c.jid
+          AND m.is_from_me = FALSE
+          AND m.deleted_at IS NULL
+          AND m.timestamp > MAX(
+              COALESCE((
+                  SELECT MAX(timestamp)
+                  FROM messages
+                  WHERE chat_jid = c.jid AND is_from_me = TRUE
+              ), 0),
+              COALESCE((
+                  SELECT MAX(rm.timestamp)
+                  FROM receipts r
+                  JOIN messages rm ON rm.id = r.message_id
+                  WHERE r.chat_jid = c.jid AND r.status = 'read' AND rm.is_from_me = FALSE
+              ), 0)
+          )
+    ) as unread_count
+FROM chats c;
@@ -0,0 +1,73 @@
+-- Migration: 019_add_contacts
+-- Description: Local copy of WhatsApp contacts (names and phone numbers) used for sender and chat names
+-- Previous: 018_derive_unread_counts
+-- Version: 019
+-- Created: 2026-10-16
+
+-- Synced from the whatsmeow contact store at startup and updated from contact, push name and business name events
+CREATE TABLE IF NOT EXISTS contacts (
+    jid TEXT PRIMARY KEY, -- Canonical JID
+    phone_number TEXT, -- E.164 phone number (null for LIDs without a known phone number)
+    full_name TEXT, -- Saved contact name
+    first_name TEXT,
+    push_name TEXT, -- WhatsApp display name
+    business_name TEXT, -- Verified business name
+    updated_at INTEGER NOT NULL -- Unix timestamp
+);
+
+-- Recreate the view so group senders without a direct chat get their saved contact name
+DROP VIEW IF EXISTS messages_with_names;
+
+CREATE VIEW messages_with_names AS
+SELECT
+    m.id,
+    m.chat_jid,
+    m.sender_jid,
+
+    -- Get sender's current push name (WhatsApp display name)
+    COALESCE(p.push_name, NULLIF(ct.push_name, ''), '') as sender_push_name,
+
+    -- Get sender's current contact name (saved contact)
+    COALESCE(NULLIF(c_sender.contact_name, ''), NULLIF(ct.full_name, ''), NULLIF(ct.first_name, ''), NULLIF(ct.business_name, ''), '') as sender_contact_name,
+
+    -- Get chat name (for display)
+    COALESCE(
+        c_chat.contact_name,  -- Saved contact name for DMs
+        c_chat.push_name,     -- Push name for DMs or group name for groups
+        m.chat_jid            -- Fallback to JID
+    ) as chat_name,
+
+    -- Original message fields
+    m.text,
+    m.timestamp,
+    m.is_from_me,
+    m.message_type,
+    m.created_at,
+    m.reply_to_id,
+    m.edited_at,
+    m.deleted_at,
+
+    -- Media metadata fields (nullable)
+    media.file_path as media_file_path,
+    media.file_name as media_file_name,
+    media.file_size as media_file_size,
+    media.mime_type as media_mime_type,
+    media.width as media_width,
+    media.height as media_height,
+    media.duration as media_duration,
+    media.download_status as media_download_status,
+    media.download_timestamp as media_download_timestamp,
+    media.download_error as media_download_error,
+
+    -- Voice note transcript (nullable)
+    t.text as transcript,
+
+    -- Space-separated emojis of the reactions to this message (nullable)
+    (SELECT GROUP_CONCAT(r.emoji, ' ') FROM reactions r WHERE r.target_message_id = m.id) as reactions
+FROM messages m
+LEFT JOIN push_names p ON m.sender_jid = p.jid
+LEFT JOIN chats c_sender ON m.sender_jid = c_sender.jid
+LEFT JOIN contacts ct ON m.sender_jid = ct.jid
+LEFT JOIN chats c_chat ON m.chat_jid = c_chat.jid
+LEFT JOIN media_metadata media ON m.id = media.message_id
+LEFT JOIN transcripts t ON m.id = t.message_id;
@@ -0,0 +1,22 @@
+-- Migration: 021_add_jid_aliases
+-- Description: Map LID JIDs to the phone number JID of the same account
+-- Previous: 020_add_message_payload
+-- Version: 021
+-- Created: 2026-10-16
+
+-- Filled from whatsmeow's LID mappings (history sync and live lookups).
+-- Rows are stored under the canonical (phone number) JID; an alias only
+-- matters for rows stored before its mapping was known, which are moved to
+-- the canonical JID when the alias is saved, and for JIDs given by clients.
+CREATE TABLE IF NOT EXISTS jid_aliases (
+    alias_jid TEXT PRIMARY KEY, -- LID JID (user@lid)
+    canonical_jid TEXT NOT NULL, -- Phone number JID (user@s.whatsapp.net)
+    updated_at INTEGER NOT NULL -- Unix timestamp
+);
+
+CREATE INDEX IF NOT EXISTS idx_jid_aliases_canonical ON jid_aliases(canonical_jid);
+
+-- Saving an alias moves rows by these JID columns
+CREATE INDEX IF NOT EXISTS idx_receipts_participant ON receipts(participant_jid);
+CREATE INDEX IF NOT EXISTS idx_reactions_sender ON reactions(sender_jid);
+CREATE INDEX IF NOT EXISTS idx_group_participants_participant ON group_participants(participant_jid);
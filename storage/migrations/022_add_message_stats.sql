@@ -0,0 +1,56 @@
+-- Migration: 022_add_message_stats
+-- Description: Hourly message counts per chat, sender and type, kept up to date by triggers
+-- Previous: 021_add_jid_aliases
+-- Version: 022
+-- Created: 2026-10-16
+
+-- Activity reports read these counts instead of scanning messages. Hourly
+-- buckets let reports group by day in any whole-hour time zone.
+CREATE TABLE IF NOT EXISTS message_stats (
+    hour INTEGER NOT NULL, -- Unix timestamp of the start of the hour (UTC)
+    chat_jid TEXT NOT NULL,
+    sender_jid TEXT NOT NULL,
+    message_type TEXT NOT NULL,
+    is_from_me BOOLEAN NOT NULL,
+    message_count INTEGER NOT NULL,
+    PRIMARY KEY (hour, chat_jid, sender_jid, message_type, is_from_me)
+);
+
+CREATE INDEX IF NOT EXISTS idx_message_stats_chat ON message_stats(chat_jid, hour);
+
+-- Count the messages already stored
+INSERT INTO message_stats (hour, chat_jid, sender_jid, message_type, is_from_me, message_count)
+SELECT timestamp - timestamp % 3600, chat_jid, sender_jid, message_type, is_from_me, COUNT(*)
+FROM messages
+GROUP BY 1, 2, 3, 4, 5;
+
+-- Every writer (sync, import, merge, alias unification) goes through these
+CREATE TRIGGER IF NOT EXISTS message_stats_insert AFTER INSERT ON messages
+BEGIN
+    INSERT INTO message_stats (hour, chat_jid, sender_jid, message_type, is_from_me, message_count)
+    VALUES (NEW.timestamp - NEW.timestamp % 3600, NEW.chat_jid, NEW.sender_jid, NEW.message_type, NEW.is_from_me, 1)
+    ON CONFLICT(hour, chat_jid, sender_jid, message_type, is_from_me) DO UPDATE SET
+        message_count = message_count + 1;
+END;
+
+CREATE TRIGGER IF NOT EXISTS message_stats_delete AFTER DELETE ON messages
+BEGIN
+    UPDATE message_stats SET message_count = message_count - 1
+    WHERE hour = OLD.timestamp - OLD.timestamp % 3600 AND chat_jid = OLD.chat_jid AND sender_jid = OLD.sender_jid
+      AND message_type = OLD.message_type AND is_from_me = OLD.is_from_me;
+    DELETE FROM message_stats WHERE message_count <= 0;
+END;
+
+CREATE TRIGGER IF NOT EXISTS message_stats_update AFTER UPDATE OF timestamp, chat_jid, sender_jid, message_type, is_from_me ON messages
+WHEN OLD.timestamp IS NOT NEW.timestamp OR OLD.chat_jid IS NOT NEW.chat_jid OR OLD.sender_jid IS NOT NEW.sender_jid
+  OR OLD.message_type IS NOT NEW.message_type OR OLD.is_from_me IS NOT NEW.is_from_me
+BEGIN
+    UPDATE message_stats SET message_count = message_count - 1
+    WHERE hour = OLD.timestamp - OLD.timestamp % 3600 AND chat_jid = OLD.chat_jid AND sender_jid = OLD.sender_jid
+      AND message_type = OLD.message_type AND is_from_me = OLD.is_from_me;
+    DELETE FROM message_stats WHERE message_count <= 0;
+    INSERT INTO message_stats (hour, chat_jid, sender_jid, message_type, is_from_me, message_count)
+    VALUES (NEW.timestamp - NEW.timestamp % 3600, NEW.chat_jid, NEW.sender_jid, NEW.message_type, NEW.is_from_me, 1)
+    ON CONFLICT(hour, chat_jid, sender_jid, message_type, is_from_me) DO UPDATE SET
+        message_count = message_count + 1;
+END;
@@ -0,0 +1,60 @@
+-- Migration: 023_add_chat_message_counts
+-- Description: Cached message count and last sender on chats, kept up to date by triggers
+-- Previous: 022_add_message_stats
+-- Version: 023
+-- Created: 2026-10-16
+
+ALTER TABLE chats ADD COLUMN message_count INTEGER NOT NULL DEFAULT 0; -- Stored messages, deleted ones included
+ALTER TABLE chats ADD COLUMN last_sender_jid TEXT; -- Sender of the newest stored message
+
+UPDATE chats SET
+    message_count = (SELECT COUNT(*) FROM messages WHERE chat_jid = chats.jid),
+    last_sender_jid = (
+        SELECT sender_jid FROM messages
+        WHERE chat_jid = chats.jid
+        ORDER BY timestamp DESC, id DESC
+        LIMIT 1
+    );
+
+-- The newest message is looked up through idx_chat_timestamp, so only the
+-- inserted message's chat is touched.
+CREATE TRIGGER IF NOT EXISTS chat_counts_insert AFTER INSERT ON messages
+BEGIN
+    UPDATE chats SET
+        message_count = message_count + 1,
+        last_sender_jid = CASE WHEN EXISTS (
+            SELECT 1 FROM messages
+            WHERE chat_jid = NEW.chat_jid
+              AND (timestamp > NEW.timestamp OR (timestamp = NEW.timestamp AND id > NEW.id))
+        ) THEN last_sender_jid ELSE NEW.sender_jid END
+    WHERE jid = NEW.chat_jid;
+END;
+
+CREATE TRIGGER IF NOT EXISTS chat_counts_delete AFTER DELETE ON messages
+BEGIN
+    UPDATE chats SET
+        message_count = message_count - 1,
+        last_sender_jid = (
+            SELECT sender_jid FROM messages
+            WHERE chat_jid = OLD.chat_jid
+            ORDER BY timestamp DESC, id DESC
+            LIMIT 1
+        )
+    WHERE jid = OLD.chat_jid;
+END;
+
+CREATE TRIGGER IF NOT EXISTS chat_counts_update AFTER UPDATE OF chat_jid, sender_jid, timestamp ON messages
+WHEN OLD.chat_jid IS NOT NEW.chat_jid OR OLD.sender_jid IS NOT NEW.sender_jid OR OLD.timestamp IS NOT NEW.timestamp
+BEGIN
+    UPDATE chats SET
+        message_count = message_count
+            + CASE WHEN jid = NEW.chat_jid THEN 1 ELSE 0 END
+            - CASE WHEN jid = OLD.chat_jid THEN 1 ELSE 0 END,
+        last_sender_jid = (
+            SELECT sender_jid FROM messages
+            WHERE chat_jid = chats.jid
+            ORDER BY timestamp DESC, id DESC
+            LIMIT 1
+        )
+    WHERE jid IN (OLD.chat_jid, NEW.chat_jid);
+END;
@@ -0,0 +1,33 @@
+-- Migration: 024_add_tags
+-- Description: Tags on chats and messages, including WhatsApp Business labels
+-- Previous: 023_add_chat_message_counts
+-- Version: 024
+-- Created: 2026-10-16
+
+CREATE TABLE IF NOT EXISTS tags (
+    name TEXT PRIMARY KEY COLLATE NOCASE, -- Tag name, matched case-insensitively
+    label_id TEXT UNIQUE, -- WhatsApp Business label ID (null for local tags)
+    created_at INTEGER NOT NULL -- Unix timestamp
+);
+
+-- No foreign key to chats: labels can be assigned before the chat is stored
+CREATE TABLE IF NOT EXISTS chat_tags (
+    tag TEXT NOT NULL COLLATE NOCASE,
+    chat_jid TEXT NOT NULL, -- Canonical chat JID
+    created_at INTEGER NOT NULL, -- Unix timestamp
+    PRIMARY KEY (tag, chat_jid),
+    FOREIGN KEY (tag) REFERENCES tags(name) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_chat_tags_chat ON chat_tags(chat_jid);
+
+CREATE TABLE IF NOT EXISTS message_tags (
+    tag TEXT NOT NULL COLLATE NOCASE,
+    message_id TEXT NOT NULL,
+    created_at INTEGER NOT NULL, -- Unix timestamp
+    PRIMARY KEY (tag, message_id),
+    FOREIGN KEY (tag) REFERENCES tags(name) ON DELETE CASCADE ON UPDATE CASCADE,
+    FOREIGN KEY (message_id) REFERENCES messages(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_message_tags_message ON message_tags(message_id);
@@ -0,0 +1,15 @@
+-- Migration: 025_add_contact_notes
+-- Description: Free-form local notes on contacts
+-- Previous: 024_add_tags
+-- Version: 025
+-- Created: 2026-10-16
+
+-- Local-only enrichment, never sent to WhatsApp
+CREATE TABLE IF NOT EXISTS contact_notes (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    jid TEXT NOT NULL, -- Canonical JID of the contact
+    text TEXT NOT NULL,
+    created_at INTEGER NOT NULL -- Unix timestamp
+);
+
+CREATE INDEX IF NOT EXISTS idx_contact_notes_jid ON contact_notes(jid, created_at);
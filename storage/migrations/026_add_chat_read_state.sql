@@ -0,0 +1,37 @@
+-- Migration: 026_add_chat_read_state
+-- Description: Track when a chat was marked read and WhatsApp's "marked unread" state, and count unread messages from them
+-- Previous: 025_add_contact_notes
+-- Version: 026
+-- Created: 2026-10-16
+
+ALTER TABLE chats ADD COLUMN read_at INTEGER; -- Chat marked read up to this time (app state or mark_chat_read)
+ALTER TABLE chats ADD COLUMN marked_unread BOOLEAN NOT NULL DEFAULT FALSE; -- Marked unread on a device
+
+-- Same as 018, with the chat's read_at as a third read marker.
+DROP VIEW IF EXISTS chats_with_unread;
+
+CREATE VIEW chats_with_unread AS
+SELECT
+    c.*,
+    (
+        SELECT COUNT(*)
+        FROM messages m
+        WHERE m.chat_jid = c.jid
+          AND m.is_from_me = FALSE
+          AND m.deleted_at IS NULL
+          AND m.timestamp > MAX(
+              COALESCE((
+                  SELECT MAX(timestamp)
+                  FROM messages
+                  WHERE chat_jid = c.jid AND is_from_me = TRUE
+              ), 0),
+              COALESCE((
+                  SELECT MAX(rm.timestamp)
+                  FROM receipts r
+                  JOIN messages rm ON rm.id = r.message_id
+                  WHERE r.chat_jid = c.jid AND r.status = 'read' AND rm.is_from_me = FALSE
+              ), 0),
+              COALESCE(c.read_at, 0)
+          )
+    ) as unread_count
+FROM chats c;
@@ -0,0 +1,15 @@
+-- Migration: 027_add_raw_messages
+-- Description: Optional archive of the raw protobuf of messages (RAW_MESSAGE_ARCHIVE)
+-- Previous: 026_add_chat_read_state
+-- Version: 027
+-- Created: 2026-10-16
+
+-- Serialized waE2E.Message, kept so structured data can be backfilled from it
+-- by later parsers without re-syncing from the phone. Protobuf (not JSON)
+-- keeps fields this build doesn't know about.
+CREATE TABLE IF NOT EXISTS raw_messages (
+    message_id TEXT PRIMARY KEY,
+    data BLOB NOT NULL,
+    created_at INTEGER NOT NULL, -- Unix timestamp
+    FOREIGN KEY (message_id) REFERENCES messages(id) ON DELETE CASCADE
+);
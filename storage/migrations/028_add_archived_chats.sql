@@ -0,0 +1,15 @@
+-- Migration: 028_add_archived_chats
+-- Description: Stubs of the messages moved to the archive database
+-- Previous: 027_add_raw_messages
+-- Version: 028
+-- Created: 2026-10-16
+
+-- Archived messages live in messages_archive.db next to this database; these
+-- rows tell searches which chats and periods have archived messages.
+CREATE TABLE IF NOT EXISTS archived_chats (
+    chat_jid TEXT PRIMARY KEY,
+    message_count INTEGER NOT NULL, -- Messages moved to the archive
+    oldest INTEGER NOT NULL,        -- Unix timestamp of the oldest archived message
+    newest INTEGER NOT NULL,        -- Unix timestamp of the newest archived message
+    archived_at INTEGER NOT NULL    -- Unix timestamp of the last archival run
+);
@@ -0,0 +1,23 @@
+-- Migration: 029_add_calls
+-- Description: Store incoming voice and video calls
+-- Previous: 028_add_archived_chats
+-- Version: 029
+-- Created: 2026-10-16
+
+-- One row per incoming call offer. A call is "ringing" until it is answered,
+-- rejected or ends; a call that ends while still ringing was missed.
+CREATE TABLE IF NOT EXISTS calls (
+    id TEXT PRIMARY KEY, -- WhatsApp call ID
+    caller_jid TEXT NOT NULL, -- Canonical JID of who called
+    chat_jid TEXT NOT NULL, -- Canonical chat JID: the caller, or the group of a group call
+    is_video INTEGER NOT NULL DEFAULT 0,
+    is_group INTEGER NOT NULL DEFAULT 0,
+    status TEXT NOT NULL DEFAULT 'ringing', -- ringing, answered, rejected or missed
+    offered_at INTEGER NOT NULL, -- Unix timestamp
+    ended_at INTEGER, -- Unix timestamp (NULL while the call is going on)
+    end_reason TEXT -- reason given by WhatsApp when the call ended
+);
+
+CREATE INDEX IF NOT EXISTS idx_calls_offered ON calls(offered_at DESC);
+CREATE INDEX IF NOT EXISTS idx_calls_chat ON calls(chat_jid, offered_at DESC);
+CREATE INDEX IF NOT EXISTS idx_calls_caller ON calls(caller_jid);
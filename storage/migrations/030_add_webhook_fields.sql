@@ -0,0 +1,7 @@
+-- Migration: 030_add_webhook_fields
+-- Description: Let webhooks choose which payload data fields they receive
+-- Previous: 029_add_calls
+-- Version: 030
+-- Created: 2026-10-16
+
+ALTER TABLE webhook_registrations ADD COLUMN fields TEXT; -- JSON array of data fields to send (NULL = all)
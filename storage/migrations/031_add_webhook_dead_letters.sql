@@ -0,0 +1,21 @@
+-- Migration: 031_add_webhook_dead_letters
+-- Description: Keep webhook events whose retries were exhausted for manual redelivery
+-- Previous: 030_add_webhook_fields
+-- Version: 031
+-- Created: 2026-10-16
+
+CREATE TABLE IF NOT EXISTS webhook_dead_letters (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    webhook_id TEXT NOT NULL,               -- FK to webhook_registrations
+    payload_id TEXT NOT NULL,               -- Event UUID
+    event_type TEXT NOT NULL,
+    payload TEXT NOT NULL,                  -- Full JSON payload, before field selection
+    attempts INTEGER NOT NULL,              -- Delivery attempts made
+    last_error TEXT,                        -- Error of the last attempt
+    created_at INTEGER NOT NULL,            -- Unix timestamp
+
+    UNIQUE (webhook_id, payload_id),
+    FOREIGN KEY (webhook_id) REFERENCES webhook_registrations(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_dead_letters_webhook ON webhook_dead_letters(webhook_id, id DESC);
@@ -0,0 +1,15 @@
+-- Migration: 032_add_webhook_outbox
+-- Description: Persist pending webhook deliveries that don't fit in the in-memory queue or outlive a restart
+-- Previous: 031_add_webhook_dead_letters
+-- Version: 032
+-- Created: 2026-10-16
+
+CREATE TABLE IF NOT EXISTS webhook_outbox (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    webhook_id TEXT NOT NULL,               -- FK to webhook_registrations
+    payload TEXT NOT NULL,                  -- Full JSON payload
+    attempt INTEGER NOT NULL,               -- Next delivery attempt number
+    created_at INTEGER NOT NULL,            -- Unix timestamp
+
+    FOREIGN KEY (webhook_id) REFERENCES webhook_registrations(id) ON DELETE CASCADE
+);
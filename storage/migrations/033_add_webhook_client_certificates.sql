@@ -0,0 +1,9 @@
+-- Migration: 033_add_webhook_client_certificates
+-- Description: Per-webhook client certificate for mutual TLS
+-- Previous: 032_add_webhook_outbox
+-- Version: 033
+-- Created: 2026-10-16
+
+-- Paths of PEM files on the server; the key itself is never stored
+ALTER TABLE webhook_registrations ADD COLUMN tls_cert_file TEXT;
+ALTER TABLE webhook_registrations ADD COLUMN tls_key_file TEXT;
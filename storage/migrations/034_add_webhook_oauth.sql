@@ -0,0 +1,10 @@
+-- Migration: 034_add_webhook_oauth
+-- Description: Per-webhook OAuth2 client credentials for targets that require a bearer token
+-- Previous: 033_add_webhook_client_certificates
+-- Version: 034
+-- Created: 2026-10-16
+
+ALTER TABLE webhook_registrations ADD COLUMN oauth_token_url TEXT;
+ALTER TABLE webhook_registrations ADD COLUMN oauth_client_id TEXT;
+ALTER TABLE webhook_registrations ADD COLUMN oauth_client_secret TEXT;
+ALTER TABLE webhook_registrations ADD COLUMN oauth_scopes TEXT; -- space-separated
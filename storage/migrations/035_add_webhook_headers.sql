@@ -0,0 +1,7 @@
+-- Migration: 035_add_webhook_headers
+-- Description: Extra request headers per webhook, stored encrypted
+-- Previous: 034_add_webhook_oauth
+-- Version: 035
+-- Created: 2026-10-16
+
+ALTER TABLE webhook_registrations ADD COLUMN headers TEXT; -- AES-GCM sealed JSON object, keyed by WEBHOOK_ENCRYPTION_KEY
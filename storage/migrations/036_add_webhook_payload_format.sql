@@ -0,0 +1,7 @@
+-- Migration: 036_add_webhook_payload_format
+-- Description: Payload format per webhook (native or CloudEvents)
+-- Previous: 035_add_webhook_headers
+-- Version: 036
+-- Created: 2026-10-16
+
+ALTER TABLE webhook_registrations ADD COLUMN payload_format TEXT; -- "cloudevents", NULL for the native format
@@ -0,0 +1,7 @@
+-- Migration: 037_add_webhook_media_delivery
+-- Description: Media content delivery per webhook (base64 or signed URL)
+-- Previous: 036_add_webhook_payload_format
+-- Version: 037
+-- Created: 2026-10-16
+
+ALTER TABLE webhook_registrations ADD COLUMN media_delivery TEXT; -- "base64" or "url", NULL for metadata only
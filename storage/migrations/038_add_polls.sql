@@ -0,0 +1,32 @@
+-- Migration: 038_add_polls
+-- Description: Store polls and their decrypted votes for live poll results
+-- Previous: 037_add_webhook_media_delivery
+-- Version: 038
+-- Created: 2026-10-16
+
+-- Options of a poll, keyed by the ID of the message that created it
+-- (the poll message itself is stored in messages with its question as text)
+CREATE TABLE IF NOT EXISTS polls (
+    message_id TEXT PRIMARY KEY,
+    chat_jid TEXT NOT NULL, -- Canonical chat JID
+    question TEXT NOT NULL,
+    options TEXT NOT NULL, -- JSON array of option names, in order
+    selectable_count INTEGER NOT NULL DEFAULT 0, -- 0 means any number of options
+    created_at INTEGER NOT NULL -- Unix timestamp
+);
+
+-- Latest vote of each voter (a new vote replaces the previous one)
+-- No foreign key: votes can arrive for polls that are not stored (yet)
+CREATE TABLE IF NOT EXISTS poll_votes (
+    poll_message_id TEXT NOT NULL, -- ID of the poll message
+    voter_jid TEXT NOT NULL, -- Canonical JID of who voted
+    chat_jid TEXT NOT NULL, -- Canonical chat JID
+    options TEXT NOT NULL, -- JSON array of the selected option names, empty when the vote was removed
+    timestamp INTEGER NOT NULL, -- Unix timestamp
+
+    PRIMARY KEY (poll_message_id, voter_jid)
+);
+
+CREATE INDEX IF NOT EXISTS idx_polls_chat ON polls(chat_jid);
+CREATE INDEX IF NOT EXISTS idx_poll_votes_voter ON poll_votes(voter_jid);
+CREATE INDEX IF NOT EXISTS idx_poll_votes_chat ON poll_votes(chat_jid);
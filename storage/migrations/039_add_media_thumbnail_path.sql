@@ -0,0 +1,7 @@
+-- Migration: 039_add_media_thumbnail_path
+-- Description: Path of the preview generated for downloaded images and videos
+-- Previous: 038_add_polls
+-- Version: 039
+-- Created: 2026-10-16
+
+ALTER TABLE media_metadata ADD COLUMN thumbnail_path TEXT; -- relative to data/media/, NULL until generated
@@ -0,0 +1,11 @@
+-- Migration: 040_add_chat_app_state
+-- Description: Track chats muted, archived and pinned on my devices through app state sync
+-- Previous: 039_add_media_thumbnail_path
+-- Version: 040
+-- Created: 2026-10-16
+
+ALTER TABLE chats ADD COLUMN muted_until INTEGER NOT NULL DEFAULT 0; -- Unix timestamp the mute ends, -1 = muted forever, 0 = not muted
+ALTER TABLE chats ADD COLUMN archived BOOLEAN NOT NULL DEFAULT FALSE; -- Archived on a device
+ALTER TABLE chats ADD COLUMN pinned_at INTEGER NOT NULL DEFAULT 0; -- Unix timestamp the chat was pinned, 0 = not pinned
+
+CREATE INDEX IF NOT EXISTS idx_chats_archived ON chats(archived) WHERE archived;
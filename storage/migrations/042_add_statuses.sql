@@ -0,0 +1,21 @@
+-- Migration: 042_add_statuses
+-- Description: Status updates posted by contacts, with when they expire
+-- Previous: 041_add_newsletter_posts
+-- Version: 042
+-- Created: 2026-10-16
+
+-- Status updates are stored in messages (chat status@broadcast) like any
+-- other message; this table marks them as statuses and keeps when WhatsApp
+-- stops showing them.
+CREATE TABLE IF NOT EXISTS statuses (
+    message_id TEXT PRIMARY KEY,
+    sender_jid TEXT NOT NULL, -- Contact who posted the status
+    status_type TEXT NOT NULL, -- text, image or video
+    posted_at INTEGER NOT NULL, -- Unix timestamp
+    expires_at INTEGER NOT NULL, -- Unix timestamp, 24 hours after posted_at
+
+    FOREIGN KEY (message_id) REFERENCES messages(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_statuses_sender ON statuses(sender_jid, posted_at);
+CREATE INDEX IF NOT EXISTS idx_statuses_expires_at ON statuses(expires_at);
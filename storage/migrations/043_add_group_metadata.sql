@@ -0,0 +1,16 @@
+-- Migration: 043_add_group_metadata
+-- Description: Group descriptions, kept up to date by the periodic group refresh
+-- Previous: 042_add_statuses
+-- Version: 043
+-- Created: 2026-10-16
+
+-- The group name stays in chats.push_name; this table keeps what only
+-- groups have.
+CREATE TABLE IF NOT EXISTS group_metadata (
+    group_jid TEXT PRIMARY KEY,
+    description TEXT NOT NULL DEFAULT '', -- Group topic, empty if none
+    description_set_at INTEGER, -- Unix timestamp of the last description change, NULL if unknown
+    refreshed_at INTEGER NOT NULL, -- Unix timestamp of the last update
+
+    FOREIGN KEY (group_jid) REFERENCES chats(jid) ON DELETE CASCADE
+);
@@ -0,0 +1,25 @@
+-- Migration: 044_add_backfills
+-- Description: Per-chat history backfill jobs, resumed after a restart
+-- Previous: 043_add_group_metadata
+-- Version: 044
+-- Created: 2026-10-16
+
+-- A backfill keeps requesting older history of a chat from the phone until
+-- its target date or message count is reached. One job per chat; starting a
+-- new one replaces it.
+CREATE TABLE IF NOT EXISTS backfills (
+    chat_jid TEXT PRIMARY KEY,
+    target_date INTEGER, -- Unix timestamp to go back to, NULL for no date target
+    target_count INTEGER NOT NULL DEFAULT 0, -- Messages to fetch, 0 for no count target
+    fetched INTEGER NOT NULL DEFAULT 0, -- Messages fetched so far
+    pages INTEGER NOT NULL DEFAULT 0, -- History requests answered so far
+    oldest_timestamp INTEGER, -- Unix timestamp of the oldest stored message of the chat
+    status TEXT NOT NULL DEFAULT 'running', -- running, done, failed or cancelled
+    last_error TEXT NOT NULL DEFAULT '', -- Why the job failed or the last page had to be retried
+    started_at INTEGER NOT NULL, -- Unix timestamp
+    updated_at INTEGER NOT NULL, -- Unix timestamp
+
+    FOREIGN KEY (chat_jid) REFERENCES chats(jid) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_backfills_status ON backfills(status);
@@ -0,0 +1,36 @@
+-- Migration: 045_fix_message_stats_triggers
+-- Description: Only drop the message_stats bucket a deleted or moved message left empty
+-- Previous: 044_add_backfills
+-- Version: 045
+-- Created: 2026-10-16
+
+-- The triggers of 022 deleted every empty bucket of the table, scanning it on
+-- each delete or move. They now only look at the bucket they decremented.
+DROP TRIGGER IF EXISTS message_stats_delete;
+DROP TRIGGER IF EXISTS message_stats_update;
+
+CREATE TRIGGER IF NOT EXISTS message_stats_delete AFTER DELETE ON messages
+BEGIN
+    UPDATE message_stats SET message_count = message_count - 1
+    WHERE hour = OLD.timestamp - OLD.timestamp % 3600 AND chat_jid = OLD.chat_jid AND sender_jid = OLD.sender_jid
+      AND message_type = OLD.message_type AND is_from_me = OLD.is_from_me;
+    DELETE FROM message_stats
+    WHERE hour = OLD.timestamp - OLD.timestamp % 3600 AND chat_jid = OLD.chat_jid AND sender_jid = OLD.sender_jid
+      AND message_type = OLD.message_type AND is_from_me = OLD.is_from_me AND message_count <= 0;
+END;
+
+CREATE TRIGGER IF NOT EXISTS message_stats_update AFTER UPDATE OF timestamp, chat_jid, sender_jid, message_type, is_from_me ON messages
+WHEN OLD.timestamp IS NOT NEW.timestamp OR OLD.chat_jid IS NOT NEW.chat_jid OR OLD.sender_jid IS NOT NEW.sender_jid
+  OR OLD.message_type IS NOT NEW.message_type OR OLD.is_from_me IS NOT NEW.is_from_me
+BEGIN
+    UPDATE message_stats SET message_count = message_count - 1
+    WHERE hour = OLD.timestamp - OLD.timestamp % 3600 AND chat_jid = OLD.chat_jid AND sender_jid = OLD.sender_jid
+      AND message_type = OLD.message_type AND is_from_me = OLD.is_from_me;
+    DELETE FROM message_stats
+    WHERE hour = OLD.timestamp - OLD.timestamp % 3600 AND chat_jid = OLD.chat_jid AND sender_jid = OLD.sender_jid
+      AND message_type = OLD.message_type AND is_from_me = OLD.is_from_me AND message_count <= 0;
+    INSERT INTO message_stats (hour, chat_jid, sender_jid, message_type, is_from_me, message_count)
+    VALUES (NEW.timestamp - NEW.timestamp % 3600, NEW.chat_jid, NEW.sender_jid, NEW.message_type, NEW.is_from_me, 1)
+    ON CONFLICT(hour, chat_jid, sender_jid, message_type, is_from_me) DO UPDATE SET
+        message_count = message_count + 1;
+END;
@@ -0,0 +1,13 @@
+-- Migration: 046_add_webhook_outbox_claims
+-- Description: Deliver every webhook event from the outbox, claimed by one worker at a time
+-- Previous: 045_fix_message_stats_triggers
+-- Version: 046
+-- Created: 2026-10-16
+
+-- Events are written to the outbox before delivery and deleted once they are
+-- delivered or dead-lettered. A worker claims the oldest event of a webhook;
+-- the next one waits until it's gone, so a webhook gets its events in order.
+ALTER TABLE webhook_outbox ADD COLUMN available_at INTEGER NOT NULL DEFAULT 0; -- Unix timestamp of the next attempt, after a retry backoff
+ALTER TABLE webhook_outbox ADD COLUMN claimed_at INTEGER; -- Unix timestamp, NULL while no worker is delivering it
+
+CREATE INDEX IF NOT EXISTS idx_webhook_outbox_webhook ON webhook_outbox(webhook_id, id);
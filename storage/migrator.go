@@ -374,3 +374,29 @@ func (m *Migrator) MigrateTo(targetVersion int) error {
 	log.Println("Migrations completed successfully")
 	return nil
 }
+
+// Validate checks that the database schema can be used by this build: its
+// version is not newer than the latest embedded migration and the applied
+// migrations are unchanged. It returns the current schema version.
+func (m *Migrator) Validate() (int, error) {
+	var version int
+	err := m.db.QueryRow("SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&version)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read schema version: %w", err)
+	}
+
+	migrations, err := m.loadMigrations()
+	if err != nil {
+		return 0, fmt.Errorf("failed to load migrations: %w", err)
+	}
+
+	if latest := len(migrations); version > latest {
+		return 0, fmt.Errorf("schema version %d is newer than the latest known migration %d", version, latest)
+	}
+
+	if err := m.validateAppliedMigrations(migrations, version); err != nil {
+		return 0, err
+	}
+
+	return version, nil
+}
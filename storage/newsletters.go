@@ -0,0 +1,123 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// NewsletterPost holds the view and reaction counts of a post in a WhatsApp
+// channel (newsletter). The post itself is stored as a message.
+type NewsletterPost struct {
+	MessageID string
+	ChatJID   string // Newsletter JID
+	ServerID  int    // post number in the channel
+	Views     int
+	Reactions map[string]int // emoji -> count, nil when unknown
+	UpdatedAt time.Time
+}
+
+// SaveNewsletterPosts stores the counts of channel posts in a single
+// transaction. Posts whose message isn't stored are skipped. Unknown
+// reactions (nil) and lower view counts don't replace stored ones.
+func (s *MessageStore) SaveNewsletterPosts(posts []NewsletterPost) error {
+	if len(posts) == 0 {
+		return nil
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, p := range posts {
+		var reactions any
+		if p.Reactions != nil {
+			data, err := json.Marshal(p.Reactions)
+			if err != nil {
+				return err
+			}
+			reactions = string(data)
+		}
+
+		_, err = tx.Exec(`
+			INSERT INTO newsletter_posts (message_id, chat_jid, server_id, views, reactions, updated_at)
+			SELECT ?1, ?2, ?3, ?4, COALESCE(?5, '{}'), ?6
+			WHERE EXISTS (SELECT 1 FROM messages WHERE id = ?1)
+			ON CONFLICT(message_id) DO UPDATE SET
+			    server_id = excluded.server_id,
+			    views = MAX(newsletter_posts.views, excluded.views),
+			    reactions = COALESCE(?5, newsletter_posts.reactions),
+			    updated_at = excluded.updated_at
+		`, p.MessageID, p.ChatJID, p.ServerID, p.Views, reactions, p.UpdatedAt.Unix())
+		if err != nil {
+			return fmt.Errorf("failed to save channel post %s: %w", p.MessageID, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// UpdateNewsletterCounts updates the counts of channel posts identified by
+// their server ID, as live updates do. Posts that aren't stored are ignored.
+// It returns how many posts were updated.
+func (s *MessageStore) UpdateNewsletterCounts(posts []NewsletterPost) (int, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	updated := 0
+	for _, p := range posts {
+		var reactions any
+		if p.Reactions != nil {
+			data, err := json.Marshal(p.Reactions)
+			if err != nil {
+				return 0, err
+			}
+			reactions = string(data)
+		}
+
+		result, err := tx.Exec(`
+			UPDATE newsletter_posts SET
+			    views = MAX(views, ?),
+			    reactions = COALESCE(?, reactions),
+			    updated_at = ?
+			WHERE chat_jid = ? AND server_id = ?
+		`, p.Views, reactions, p.UpdatedAt.Unix(), p.ChatJID, p.ServerID)
+		if err != nil {
+			return 0, fmt.Errorf("failed to update channel post %d: %w", p.ServerID, err)
+		}
+		if n, _ := result.RowsAffected(); n > 0 {
+			updated++
+		}
+	}
+
+	return updated, tx.Commit()
+}
+
+// parseReactionCounts parses the JSON reaction counts of a channel post into
+// the form of messages_with_names reactions, most frequent first.
+func parseReactionCounts(data string) []ReactionCount {
+	var counts map[string]int
+	if err := json.Unmarshal([]byte(data), &counts); err != nil {
+		return nil
+	}
+
+	reactions := make([]ReactionCount, 0, len(counts))
+	for emoji, count := range counts {
+		if count > 0 {
+			reactions = append(reactions, ReactionCount{Emoji: emoji, Count: count})
+		}
+	}
+	sort.Slice(reactions, func(i, j int) bool {
+		if reactions[i].Count != reactions[j].Count {
+			return reactions[i].Count > reactions[j].Count
+		}
+		return reactions[i].Emoji < reactions[j].Emoji
+	})
+	return reactions
+}
@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ContactNote is a free-form local note on a contact.
+type ContactNote struct {
+	ID        int64
+	JID       string
+	Text      string
+	CreatedAt time.Time
+}
+
+// AddContactNote attaches a note to a contact and returns it.
+func (s *MessageStore) AddContactNote(jid, text string) (*ContactNote, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, fmt.Errorf("note cannot be empty")
+	}
+
+	now := time.Now()
+	result, err := s.db.Exec(
+		"INSERT INTO contact_notes (jid, text, created_at) VALUES (?, ?, ?)",
+		jid, text, now.Unix(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	return &ContactNote{ID: id, JID: jid, Text: text, CreatedAt: time.Unix(now.Unix(), 0)}, nil
+}
+
+// GetContactNotes returns the notes on a contact, oldest first.
+func (s *MessageStore) GetContactNotes(jid string) ([]ContactNote, error) {
+	rows, err := s.db.Query(`
+		SELECT id, jid, text, created_at
+		FROM contact_notes
+		WHERE jid = ?
+		ORDER BY created_at, id
+	`, jid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var notes []ContactNote
+	for rows.Next() {
+		var note ContactNote
+		var createdAt int64
+		if err := rows.Scan(&note.ID, &note.JID, &note.Text, &createdAt); err != nil {
+			return nil, err
+		}
+		note.CreatedAt = time.Unix(createdAt, 0)
+		notes = append(notes, note)
+	}
+
+	return notes, rows.Err()
+}
+
+// DeleteContactNote deletes a note by ID. It returns false if the note doesn't exist.
+func (s *MessageStore) DeleteContactNote(id int64) (bool, error) {
+	result, err := s.db.Exec("DELETE FROM contact_notes WHERE id = ?", id)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
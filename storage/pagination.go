@@ -0,0 +1,35 @@
+package storage
+
+import "time"
+
+// PageCursor marks the last row of a page for keyset pagination.
+// Rows are ordered by (timestamp, key) descending, so the next page starts
+// strictly after the cursor and is stable while new rows arrive.
+type PageCursor struct {
+	Timestamp time.Time
+	Key       string // message ID or chat JID, breaks timestamp ties
+}
+
+// messageCursorCondition selects messages older than a PageCursor (timestamp, timestamp, id).
+const messageCursorCondition = "(timestamp < ? OR (timestamp = ? AND id < ?))"
+
+// messageAfterCursorCondition selects messages newer than a PageCursor (timestamp, timestamp, id).
+const messageAfterCursorCondition = "(timestamp > ? OR (timestamp = ? AND id > ?))"
+
+// keysetClause returns the conditions (each prefixed with AND) and arguments
+// selecting the messages between two optional cursors, and the ORDER BY clause
+// for a page of them. Paging toward newer messages must fetch the ones closest
+// to after, so those rows come oldest first and ascending is true: reverse
+// them to keep pages newest first.
+func keysetClause(before, after *PageCursor) (conditions string, args []any, order string, ascending bool) {
+	if before != nil {
+		conditions += " AND " + messageCursorCondition
+		args = append(args, before.Timestamp.Unix(), before.Timestamp.Unix(), before.Key)
+	}
+	if after != nil {
+		conditions += " AND " + messageAfterCursorCondition
+		args = append(args, after.Timestamp.Unix(), after.Timestamp.Unix(), after.Key)
+		return conditions, args, " ORDER BY timestamp ASC, id ASC", true
+	}
+	return conditions, args, " ORDER BY timestamp DESC, id DESC", false
+}
@@ -0,0 +1,51 @@
+package storage
+
+import "encoding/json"
+
+// Location is the payload of a location or live location message.
+type Location struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Name      string  `json:"name,omitempty"`
+	Address   string  `json:"address,omitempty"`
+	IsLive    bool    `json:"is_live,omitempty"`
+}
+
+// SharedContact is a contact card shared in a message.
+type SharedContact struct {
+	Name   string   `json:"name"`
+	Phones []string `json:"phones,omitempty"`
+}
+
+// MessagePayload is the structured content of location and contact messages,
+// stored as JSON in messages.payload.
+type MessagePayload struct {
+	Location *Location       `json:"location,omitempty"`
+	Contacts []SharedContact `json:"contacts,omitempty"`
+}
+
+// encodePayload returns the JSON of a payload, or nil for messages without one.
+func encodePayload(payload *MessagePayload) (any, error) {
+	if payload == nil {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// decodePayload parses a stored payload. Invalid JSON is ignored.
+func decodePayload(data string) *MessagePayload {
+	if data == "" {
+		return nil
+	}
+
+	var payload MessagePayload
+	if err := json.Unmarshal([]byte(data), &payload); err != nil {
+		return nil
+	}
+	return &payload
+}
@@ -0,0 +1,195 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Poll represents the options of a poll message.
+type Poll struct {
+	MessageID       string
+	ChatJID         string // Canonical JID
+	Question        string
+	Options         []string
+	SelectableCount int // 0 means any number of options
+	CreatedAt       time.Time
+}
+
+// PollVote represents the latest vote of one voter in a poll.
+type PollVote struct {
+	PollMessageID string
+	ChatJID       string   // Canonical JID
+	VoterJID      string   // Canonical JID
+	Options       []string // selected option names, empty means the vote was removed
+	Timestamp     time.Time
+}
+
+// PollOptionCount is the number of votes for one option of a poll.
+type PollOptionCount struct {
+	Option string
+	Votes  int
+	Voters []string // Canonical JIDs
+}
+
+// PollResults is the running tally of a poll.
+type PollResults struct {
+	Poll   Poll
+	Counts []PollOptionCount // in the poll's order
+	Voters int               // voters with a current vote
+}
+
+// SavePoll stores the options of a poll, replacing them if already stored.
+func (s *MessageStore) SavePoll(p Poll) error {
+	options, err := json.Marshal(p.Options)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.Exec(`
+		INSERT INTO polls (message_id, chat_jid, question, options, selectable_count, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(message_id) DO UPDATE SET
+			question = excluded.question,
+			options = excluded.options,
+			selectable_count = excluded.selectable_count
+	`, p.MessageID, p.ChatJID, p.Question, string(options), p.SelectableCount, p.CreatedAt.Unix())
+	if err != nil {
+		return fmt.Errorf("failed to save poll %s: %w", p.MessageID, err)
+	}
+	return nil
+}
+
+// SavePollVote stores a vote, replacing the previous vote of the same voter
+// in the same poll.
+func (s *MessageStore) SavePollVote(v PollVote) error {
+	return s.SavePollVotes([]PollVote{v})
+}
+
+// SavePollVotes stores multiple votes in a single transaction.
+// Votes older than the stored one of the same voter are ignored, so history
+// sync can't overwrite a newer vote.
+func (s *MessageStore) SavePollVotes(votes []PollVote) error {
+	if len(votes) == 0 {
+		return nil
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, v := range votes {
+		options := v.Options
+		if options == nil {
+			options = []string{}
+		}
+		optionsJSON, err := json.Marshal(options)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(`
+			INSERT INTO poll_votes (poll_message_id, voter_jid, chat_jid, options, timestamp)
+			VALUES (?, ?, ?, ?, ?)
+			ON CONFLICT(poll_message_id, voter_jid) DO UPDATE SET
+				options = excluded.options,
+				timestamp = excluded.timestamp
+			WHERE excluded.timestamp >= poll_votes.timestamp
+		`, v.PollMessageID, v.VoterJID, v.ChatJID, string(optionsJSON), v.Timestamp.Unix())
+		if err != nil {
+			return fmt.Errorf("failed to save vote in poll %s: %w", v.PollMessageID, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetPoll returns the options of a poll, or nil if the poll isn't stored.
+func (s *MessageStore) GetPoll(messageID string) (*Poll, error) {
+	var p Poll
+	var options string
+	var createdAt int64
+	err := s.db.QueryRow(`
+		SELECT message_id, chat_jid, question, options, selectable_count, created_at
+		FROM polls
+		WHERE message_id = ?
+	`, messageID).Scan(&p.MessageID, &p.ChatJID, &p.Question, &options, &p.SelectableCount, &createdAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal([]byte(options), &p.Options); err != nil {
+		return nil, fmt.Errorf("invalid options of poll %s: %w", messageID, err)
+	}
+	p.CreatedAt = time.Unix(createdAt, 0)
+	return &p, nil
+}
+
+// GetPollVotes returns the current votes in a poll ordered by time, without
+// removed votes.
+func (s *MessageStore) GetPollVotes(messageID string) ([]PollVote, error) {
+	rows, err := s.db.Query(`
+		SELECT poll_message_id, chat_jid, voter_jid, options, timestamp
+		FROM poll_votes
+		WHERE poll_message_id = ? AND options != '[]'
+		ORDER BY timestamp ASC
+	`, messageID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var votes []PollVote
+	for rows.Next() {
+		var v PollVote
+		var options string
+		var ts int64
+		if err := rows.Scan(&v.PollMessageID, &v.ChatJID, &v.VoterJID, &options, &ts); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(options), &v.Options); err != nil {
+			return nil, fmt.Errorf("invalid vote in poll %s: %w", messageID, err)
+		}
+		v.Timestamp = time.Unix(ts, 0)
+		votes = append(votes, v)
+	}
+
+	return votes, rows.Err()
+}
+
+// GetPollResults tallies the current votes of a poll per option, or returns
+// nil if the poll isn't stored.
+func (s *MessageStore) GetPollResults(messageID string) (*PollResults, error) {
+	poll, err := s.GetPoll(messageID)
+	if err != nil || poll == nil {
+		return nil, err
+	}
+
+	votes, err := s.GetPollVotes(messageID)
+	if err != nil {
+		return nil, err
+	}
+
+	results := &PollResults{Poll: *poll, Voters: len(votes)}
+	index := make(map[string]int, len(poll.Options))
+	for i, option := range poll.Options {
+		index[option] = i
+		results.Counts = append(results.Counts, PollOptionCount{Option: option})
+	}
+	for _, v := range votes {
+		for _, option := range v.Options {
+			if i, ok := index[option]; ok {
+				results.Counts[i].Votes++
+				results.Counts[i].Voters = append(results.Counts[i].Voters, v.VoterJID)
+			}
+		}
+	}
+
+	return results, nil
+}
@@ -0,0 +1,81 @@
+package storage
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Presence represents the last known online status of a user.
+type Presence struct {
+	JID          string
+	IsOnline     bool
+	LastSeen     *time.Time // nil if unknown or hidden by privacy settings
+	SubscribedAt *time.Time // nil if never subscribed
+	UpdatedAt    *time.Time // when the last presence event was received (nil if none yet)
+}
+
+// SavePresence stores a presence update for a user.
+// A zero lastSeen keeps the previously known last seen time.
+func (s *MessageStore) SavePresence(jid string, isOnline bool, lastSeen time.Time) error {
+	var lastSeenUnix sql.NullInt64
+	if !lastSeen.IsZero() {
+		lastSeenUnix = sql.NullInt64{Int64: lastSeen.Unix(), Valid: true}
+	}
+
+	_, err := s.db.Exec(`
+		INSERT INTO presence (jid, is_online, last_seen, updated_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(jid) DO UPDATE SET
+			is_online = excluded.is_online,
+			last_seen = COALESCE(excluded.last_seen, presence.last_seen),
+			updated_at = excluded.updated_at
+	`, jid, isOnline, lastSeenUnix, time.Now().Unix())
+
+	return err
+}
+
+// MarkPresenceSubscribed records that presence updates were requested for a user.
+func (s *MessageStore) MarkPresenceSubscribed(jid string) error {
+	_, err := s.db.Exec(`
+		INSERT INTO presence (jid, subscribed_at)
+		VALUES (?, ?)
+		ON CONFLICT(jid) DO UPDATE SET
+			subscribed_at = excluded.subscribed_at
+	`, jid, time.Now().Unix())
+
+	return err
+}
+
+// GetPresence retrieves the last known presence of a user.
+// It returns nil if no presence is known.
+func (s *MessageStore) GetPresence(jid string) (*Presence, error) {
+	var p Presence
+	var lastSeen, subscribedAt, updatedAt sql.NullInt64
+
+	err := s.db.QueryRow(`
+		SELECT jid, is_online, last_seen, subscribed_at, updated_at
+		FROM presence
+		WHERE jid = ?
+	`, jid).Scan(&p.JID, &p.IsOnline, &lastSeen, &subscribedAt, &updatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if lastSeen.Valid {
+		t := time.Unix(lastSeen.Int64, 0)
+		p.LastSeen = &t
+	}
+	if subscribedAt.Valid {
+		t := time.Unix(subscribedAt.Int64, 0)
+		p.SubscribedAt = &t
+	}
+	if updatedAt.Valid {
+		t := time.Unix(updatedAt.Int64, 0)
+		p.UpdatedAt = &t
+	}
+
+	return &p, nil
+}
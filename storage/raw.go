@@ -0,0 +1,28 @@
+package storage
+
+import (
+	"database/sql"
+	"time"
+)
+
+// SaveRawMessage archives the serialized waE2E.Message of a stored message,
+// replacing any previous copy.
+func (s *MessageStore) SaveRawMessage(messageID string, data []byte) error {
+	_, err := s.db.Exec(`
+		INSERT OR REPLACE INTO raw_messages (message_id, data, created_at)
+		VALUES (?, ?, ?)
+	`, messageID, data, time.Now().Unix())
+
+	return err
+}
+
+// GetRawMessage returns the archived waE2E.Message of a message, or nil if it
+// wasn't archived.
+func (s *MessageStore) GetRawMessage(messageID string) ([]byte, error) {
+	var data []byte
+	err := s.db.QueryRow("SELECT data FROM raw_messages WHERE message_id = ?", messageID).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return data, err
+}
@@ -0,0 +1,115 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Reaction represents an emoji reaction of one sender to a message.
+type Reaction struct {
+	TargetMessageID string
+	ChatJID         string // Canonical JID
+	SenderJID       string // Canonical JID
+	Emoji           string // empty means the reaction was removed
+	Timestamp       time.Time
+}
+
+// ReactionCount is the number of reactions with the same emoji to a message.
+type ReactionCount struct {
+	Emoji string
+	Count int
+}
+
+// SaveReaction stores a reaction, replacing the previous reaction of the same sender
+// to the same message. A reaction with an empty emoji removes it.
+func (s *MessageStore) SaveReaction(r Reaction) error {
+	return s.SaveReactions([]Reaction{r})
+}
+
+// SaveReactions stores multiple reactions in a single transaction.
+// Reactions older than the stored one of the same sender are ignored, so
+// history sync can't overwrite a newer reaction.
+func (s *MessageStore) SaveReactions(reactions []Reaction) error {
+	if len(reactions) == 0 {
+		return nil
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, r := range reactions {
+		if r.Emoji == "" {
+			_, err = tx.Exec(`
+				DELETE FROM reactions
+				WHERE target_message_id = ? AND sender_jid = ? AND timestamp <= ?
+			`, r.TargetMessageID, r.SenderJID, r.Timestamp.Unix())
+		} else {
+			_, err = tx.Exec(`
+				INSERT INTO reactions (target_message_id, sender_jid, chat_jid, emoji, timestamp)
+				VALUES (?, ?, ?, ?, ?)
+				ON CONFLICT(target_message_id, sender_jid) DO UPDATE SET
+					emoji = excluded.emoji,
+					timestamp = excluded.timestamp
+				WHERE excluded.timestamp >= reactions.timestamp
+			`, r.TargetMessageID, r.SenderJID, r.ChatJID, r.Emoji, r.Timestamp.Unix())
+		}
+		if err != nil {
+			return fmt.Errorf("failed to save reaction to %s: %w", r.TargetMessageID, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetReactions returns all reactions to a message ordered by time.
+func (s *MessageStore) GetReactions(messageID string) ([]Reaction, error) {
+	rows, err := s.db.Query(`
+		SELECT target_message_id, chat_jid, sender_jid, emoji, timestamp
+		FROM reactions
+		WHERE target_message_id = ?
+		ORDER BY timestamp ASC
+	`, messageID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reactions []Reaction
+	for rows.Next() {
+		var r Reaction
+		var ts int64
+		if err := rows.Scan(&r.TargetMessageID, &r.ChatJID, &r.SenderJID, &r.Emoji, &ts); err != nil {
+			return nil, err
+		}
+		r.Timestamp = time.Unix(ts, 0)
+		reactions = append(reactions, r)
+	}
+
+	return reactions, rows.Err()
+}
+
+// parseReactionSummary counts the space-separated emojis of the reactions column
+// of messages_with_names, most frequent first.
+func parseReactionSummary(emojis string) []ReactionCount {
+	var counts []ReactionCount
+	index := make(map[string]int)
+	for _, emoji := range strings.Fields(emojis) {
+		if i, ok := index[emoji]; ok {
+			counts[i].Count++
+			continue
+		}
+		index[emoji] = len(counts)
+		counts = append(counts, ReactionCount{Emoji: emoji, Count: 1})
+	}
+
+	sort.SliceStable(counts, func(i, j int) bool {
+		return counts[i].Count > counts[j].Count
+	})
+
+	return counts
+}
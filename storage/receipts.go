@@ -0,0 +1,80 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+)
+
+// Receipt statuses, in increasing order of progress.
+const (
+	ReceiptDelivered = "delivered"
+	ReceiptRead      = "read"
+	ReceiptPlayed    = "played"
+)
+
+// Receipt represents a delivery/read receipt from one participant for a message.
+type Receipt struct {
+	MessageID      string
+	ChatJID        string
+	ParticipantJID string
+	Status         string // delivered, read, played
+	Timestamp      time.Time
+}
+
+// SaveReceipts stores a receipt for each of the given message IDs.
+// Only the first receipt of each status per participant is kept.
+func (s *MessageStore) SaveReceipts(messageIDs []string, chatJID, participantJID, status string, timestamp time.Time) error {
+	if len(messageIDs) == 0 {
+		return nil
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		INSERT OR IGNORE INTO receipts (message_id, chat_jid, participant_jid, status, timestamp)
+		VALUES (?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, id := range messageIDs {
+		if _, err := stmt.Exec(id, chatJID, participantJID, status, timestamp.Unix()); err != nil {
+			return fmt.Errorf("failed to save receipt for %s: %w", id, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetReceipts returns all receipts for a message ordered by time.
+func (s *MessageStore) GetReceipts(messageID string) ([]Receipt, error) {
+	rows, err := s.db.Query(`
+		SELECT message_id, chat_jid, participant_jid, status, timestamp
+		FROM receipts
+		WHERE message_id = ?
+		ORDER BY timestamp ASC
+	`, messageID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var receipts []Receipt
+	for rows.Next() {
+		var r Receipt
+		var ts int64
+		if err := rows.Scan(&r.MessageID, &r.ChatJID, &r.ParticipantJID, &r.Status, &ts); err != nil {
+			return nil, err
+		}
+		r.Timestamp = time.Unix(ts, 0)
+		receipts = append(receipts, r)
+	}
+
+	return receipts, rows.Err()
+}
@@ -0,0 +1,164 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// StatusLifetime is how long WhatsApp shows a status update.
+const StatusLifetime = 24 * time.Hour
+
+// Status types.
+const (
+	StatusText  = "text"
+	StatusImage = "image"
+	StatusVideo = "video"
+)
+
+// StatusUpdate marks a stored message as a status update posted by a contact.
+type StatusUpdate struct {
+	MessageID string
+	SenderJID string
+	Type      string // text, image or video
+	PostedAt  time.Time
+}
+
+// Status is a status update with its message, names and media.
+type Status struct {
+	MessageWithNames
+	StatusType string // text, image or video
+	ExpiresAt  time.Time
+}
+
+// Expired reports whether WhatsApp no longer shows the status.
+func (s Status) Expired() bool {
+	return !time.Now().Before(s.ExpiresAt)
+}
+
+// SaveStatuses stores status updates in a single transaction. Updates whose
+// message isn't stored, or that are already stored, are skipped.
+func (s *MessageStore) SaveStatuses(statuses []StatusUpdate) error {
+	if len(statuses) == 0 {
+		return nil
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, st := range statuses {
+		_, err = tx.Exec(`
+			INSERT OR IGNORE INTO statuses (message_id, sender_jid, status_type, posted_at, expires_at)
+			SELECT ?1, ?2, ?3, ?4, ?5
+			WHERE EXISTS (SELECT 1 FROM messages WHERE id = ?1)
+		`, st.MessageID, st.SenderJID, st.Type, st.PostedAt.Unix(), st.PostedAt.Add(StatusLifetime).Unix())
+		if err != nil {
+			return fmt.Errorf("failed to save status %s: %w", st.MessageID, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// ListStatuses returns status updates newest first, optionally only those of
+// a contact. Expired statuses are left out unless includeExpired is set;
+// statuses deleted by their sender always are.
+func (s *MessageStore) ListStatuses(ctx context.Context, senderJID string, includeExpired bool, limit int) ([]Status, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT s.message_id, s.status_type, s.expires_at
+		FROM statuses s
+		JOIN messages m ON m.id = s.message_id
+		WHERE (?1 = '' OR s.sender_jid = ?1) AND (?2 OR s.expires_at > ?3) AND m.deleted_at IS NULL
+		ORDER BY s.posted_at DESC, s.message_id
+		LIMIT ?4
+	`, senderJID, includeExpired, time.Now().Unix(), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var statuses []Status
+	for rows.Next() {
+		var st Status
+		var expiresAt int64
+		if err := rows.Scan(&st.ID, &st.StatusType, &expiresAt); err != nil {
+			return nil, err
+		}
+		st.ExpiresAt = time.Unix(expiresAt, 0)
+		statuses = append(statuses, st)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return s.withStatusMessages(ctx, statuses)
+}
+
+// GetStatus returns the status update of a message, expired or not. It
+// returns nil if the message is not a status.
+func (s *MessageStore) GetStatus(ctx context.Context, messageID string) (*Status, error) {
+	var st Status
+	var expiresAt int64
+	err := s.db.QueryRowContext(ctx,
+		"SELECT message_id, status_type, expires_at FROM statuses WHERE message_id = ?", messageID,
+	).Scan(&st.ID, &st.StatusType, &expiresAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	st.ExpiresAt = time.Unix(expiresAt, 0)
+
+	statuses, err := s.withStatusMessages(ctx, []Status{st})
+	if err != nil || len(statuses) == 0 {
+		return nil, err
+	}
+	return &statuses[0], nil
+}
+
+// withStatusMessages fills in the messages of statuses loaded by ID, keeping
+// their order. Statuses whose message is gone are dropped.
+func (s *MessageStore) withStatusMessages(ctx context.Context, statuses []Status) ([]Status, error) {
+	if len(statuses) == 0 {
+		return statuses, nil
+	}
+
+	ids := make([]any, len(statuses))
+	for i, st := range statuses {
+		ids[i] = st.ID
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", ")
+
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT `+messageWithNamesColumns+` FROM messages_with_names WHERE id IN (`+placeholders+`)`, ids...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	messages, err := s.scanMessagesWithNames(rows)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[string]MessageWithNames, len(messages))
+	for _, msg := range messages {
+		byID[msg.ID] = msg
+	}
+
+	result := statuses[:0]
+	for _, st := range statuses {
+		msg, ok := byID[st.ID]
+		if !ok {
+			continue
+		}
+		st.MessageWithNames = msg
+		result = append(result, st)
+	}
+	return result, nil
+}
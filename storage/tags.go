@@ -0,0 +1,246 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Tag is a label on chats and messages, created locally or synced from a
+// WhatsApp Business label.
+type Tag struct {
+	Name     string
+	LabelID  string // WhatsApp Business label ID (empty for local tags)
+	Chats    int    // number of tagged chats
+	Messages int    // number of tagged messages
+}
+
+// ensureTagQuery creates a local tag if no tag with that name exists.
+const ensureTagQuery = `INSERT INTO tags (name, created_at) VALUES (?, ?) ON CONFLICT(name) DO NOTHING`
+
+// TagChat tags a chat, creating the tag if needed.
+func (s *MessageStore) TagChat(tag, chatJID string) error {
+	tag = strings.TrimSpace(tag)
+	if tag == "" {
+		return fmt.Errorf("tag name cannot be empty")
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now().Unix()
+	if _, err := tx.Exec(ensureTagQuery, tag, now); err != nil {
+		return err
+	}
+	_, err = tx.Exec(`
+		INSERT OR IGNORE INTO chat_tags (tag, chat_jid, created_at)
+		SELECT name, ?, ? FROM tags WHERE name = ?
+	`, chatJID, now, tag)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// TagMessage tags a stored message, creating the tag if needed.
+// It returns false if the message is not stored.
+func (s *MessageStore) TagMessage(tag, messageID string) (bool, error) {
+	tag = strings.TrimSpace(tag)
+	if tag == "" {
+		return false, fmt.Errorf("tag name cannot be empty")
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	if err := tx.QueryRow("SELECT EXISTS(SELECT 1 FROM messages WHERE id = ?)", messageID).Scan(&exists); err != nil {
+		return false, err
+	}
+	if !exists {
+		return false, nil
+	}
+
+	now := time.Now().Unix()
+	if _, err := tx.Exec(ensureTagQuery, tag, now); err != nil {
+		return false, err
+	}
+	_, err = tx.Exec(`
+		INSERT OR IGNORE INTO message_tags (tag, message_id, created_at)
+		SELECT name, ?, ? FROM tags WHERE name = ?
+	`, messageID, now, tag)
+	if err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+// UntagChat removes a tag from a chat. It returns false if the chat didn't have it.
+func (s *MessageStore) UntagChat(tag, chatJID string) (bool, error) {
+	result, err := s.db.Exec("DELETE FROM chat_tags WHERE tag = ? AND chat_jid = ?", strings.TrimSpace(tag), chatJID)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// UntagMessage removes a tag from a message. It returns false if the message didn't have it.
+func (s *MessageStore) UntagMessage(tag, messageID string) (bool, error) {
+	result, err := s.db.Exec("DELETE FROM message_tags WHERE tag = ? AND message_id = ?", strings.TrimSpace(tag), messageID)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// ListTags returns all tags with their usage counts, ordered by name.
+func (s *MessageStore) ListTags() ([]Tag, error) {
+	rows, err := s.db.Query(`
+		SELECT t.name, COALESCE(t.label_id, ''),
+		       (SELECT COUNT(*) FROM chat_tags ct WHERE ct.tag = t.name),
+		       (SELECT COUNT(*) FROM message_tags mt WHERE mt.tag = t.name)
+		FROM tags t
+		ORDER BY t.name
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tags []Tag
+	for rows.Next() {
+		var t Tag
+		if err := rows.Scan(&t.Name, &t.LabelID, &t.Chats, &t.Messages); err != nil {
+			return nil, err
+		}
+		tags = append(tags, t)
+	}
+
+	return tags, rows.Err()
+}
+
+// GetChatTags returns the tags of the given chats by chat JID.
+func (s *MessageStore) GetChatTags(chatJIDs []string) (map[string][]string, error) {
+	tags := make(map[string][]string)
+	if len(chatJIDs) == 0 {
+		return tags, nil
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(chatJIDs)), ", ")
+	args := make([]any, len(chatJIDs))
+	for i, jid := range chatJIDs {
+		args[i] = jid
+	}
+
+	rows, err := s.db.Query(`
+		SELECT chat_jid, tag FROM chat_tags
+		WHERE chat_jid IN (`+placeholders+`)
+		ORDER BY tag
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var jid, tag string
+		if err := rows.Scan(&jid, &tag); err != nil {
+			return nil, err
+		}
+		tags[jid] = append(tags[jid], tag)
+	}
+
+	return tags, rows.Err()
+}
+
+// SaveLabel creates or renames the tag of a WhatsApp Business label. Renaming
+// keeps the label's chats and messages tagged.
+func (s *MessageStore) SaveLabel(labelID, name string) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// associations that arrived before the label got a placeholder tag
+	result, err := tx.Exec("UPDATE tags SET name = ? WHERE label_id = ? AND name != ?", name, labelID, name)
+	if err != nil {
+		return fmt.Errorf("failed to rename label %s to %q: %w", labelID, name, err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		// a local tag with the same name becomes the label's tag
+		_, err = tx.Exec(`
+			INSERT INTO tags (name, label_id, created_at) VALUES (?, ?, ?)
+			ON CONFLICT(name) DO UPDATE SET label_id = excluded.label_id
+		`, name, labelID, time.Now().Unix())
+		if err != nil {
+			return fmt.Errorf("failed to save label %s: %w", labelID, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// DeleteLabel deletes the tag of a WhatsApp Business label and its assignments.
+func (s *MessageStore) DeleteLabel(labelID string) error {
+	_, err := s.db.Exec("DELETE FROM tags WHERE label_id = ?", labelID)
+	return err
+}
+
+// ensureLabelQuery creates a placeholder tag for a label whose name isn't known yet.
+const ensureLabelQuery = `
+	INSERT INTO tags (name, label_id, created_at) VALUES ('label ' || ?1, ?1, ?2)
+	ON CONFLICT DO NOTHING
+`
+
+// SetChatLabel assigns or removes a WhatsApp Business label on a chat.
+func (s *MessageStore) SetChatLabel(labelID, chatJID string, labeled bool) error {
+	if !labeled {
+		_, err := s.db.Exec("DELETE FROM chat_tags WHERE chat_jid = ? AND tag = (SELECT name FROM tags WHERE label_id = ?)", chatJID, labelID)
+		return err
+	}
+
+	now := time.Now().Unix()
+	if _, err := s.db.Exec(ensureLabelQuery, labelID, now); err != nil {
+		return err
+	}
+	_, err := s.db.Exec(`
+		INSERT OR IGNORE INTO chat_tags (tag, chat_jid, created_at)
+		SELECT name, ?, ? FROM tags WHERE label_id = ?
+	`, chatJID, now, labelID)
+	return err
+}
+
+// SetMessageLabel assigns or removes a WhatsApp Business label on a message.
+// Labels on messages that aren't stored are ignored.
+func (s *MessageStore) SetMessageLabel(labelID, messageID string, labeled bool) error {
+	if !labeled {
+		_, err := s.db.Exec("DELETE FROM message_tags WHERE message_id = ? AND tag = (SELECT name FROM tags WHERE label_id = ?)", messageID, labelID)
+		return err
+	}
+
+	now := time.Now().Unix()
+	if _, err := s.db.Exec(ensureLabelQuery, labelID, now); err != nil {
+		return err
+	}
+	_, err := s.db.Exec(`
+		INSERT OR IGNORE INTO message_tags (tag, message_id, created_at)
+		SELECT t.name, m.id, ? FROM tags t, messages m WHERE t.label_id = ? AND m.id = ?
+	`, now, labelID, messageID)
+	return err
+}
@@ -0,0 +1,109 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Template is a named message template with {{placeholders}}.
+type Template struct {
+	Name        string
+	Text        string
+	Description string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// SaveTemplate creates a template or replaces the text and description of an existing one.
+func (s *MessageStore) SaveTemplate(name, text, description string) error {
+	if name == "" {
+		return fmt.Errorf("template name cannot be empty")
+	}
+
+	now := time.Now().Unix()
+	_, err := s.db.Exec(`
+		INSERT INTO templates (name, text, description, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(name) DO UPDATE SET
+			text = excluded.text,
+			description = excluded.description,
+			updated_at = excluded.updated_at
+	`, name, text, description, now, now)
+
+	return err
+}
+
+// GetTemplate retrieves a template by name.
+// It returns nil if the template is not found.
+func (s *MessageStore) GetTemplate(name string) (*Template, error) {
+	row := s.db.QueryRow(`
+		SELECT name, text, description, created_at, updated_at
+		FROM templates
+		WHERE name = ?
+	`, name)
+
+	t, err := scanTemplate(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &t, nil
+}
+
+// ListTemplates returns all templates ordered by name.
+func (s *MessageStore) ListTemplates() ([]Template, error) {
+	rows, err := s.db.Query(`
+		SELECT name, text, description, created_at, updated_at
+		FROM templates
+		ORDER BY name
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var templates []Template
+	for rows.Next() {
+		t, err := scanTemplate(rows)
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, t)
+	}
+
+	return templates, rows.Err()
+}
+
+// DeleteTemplate deletes a template by name.
+// It returns false if the template did not exist.
+func (s *MessageStore) DeleteTemplate(name string) (bool, error) {
+	res, err := s.db.Exec("DELETE FROM templates WHERE name = ?", name)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return n > 0, nil
+}
+
+// scanTemplate scans a single templates row.
+func scanTemplate(row interface{ Scan(dest ...any) error }) (Template, error) {
+	var t Template
+	var createdAt, updatedAt int64
+
+	if err := row.Scan(&t.Name, &t.Text, &t.Description, &createdAt, &updatedAt); err != nil {
+		return t, err
+	}
+
+	t.CreatedAt = time.Unix(createdAt, 0)
+	t.UpdatedAt = time.Unix(updatedAt, 0)
+	return t, nil
+}
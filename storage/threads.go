@@ -0,0 +1,44 @@
+package storage
+
+import "context"
+
+// maxThreadDepth bounds the reply chain walk in case of reply loops.
+const maxThreadDepth = 100
+
+// GetThread returns the reply thread of a message, oldest first: the chain of
+// quoted messages up to the first one, and every reply to a message in the thread.
+// Quoted messages that aren't stored end the chain early.
+func (s *MessageStore) GetThread(ctx context.Context, messageID string, limit int) ([]MessageWithNames, error) {
+	query := `
+	WITH RECURSIVE
+	ancestors(id, reply_to_id, depth) AS (
+	    SELECT id, reply_to_id, 0 FROM messages WHERE id = ?
+	    UNION
+	    SELECT m.id, m.reply_to_id, a.depth + 1
+	    FROM messages m
+	    JOIN ancestors a ON m.id = a.reply_to_id
+	    WHERE a.depth < ?
+	),
+	thread(id, depth) AS (
+	    SELECT id, 0 FROM (SELECT id FROM ancestors ORDER BY depth DESC LIMIT 1)
+	    UNION
+	    SELECT m.id, t.depth + 1
+	    FROM messages m
+	    JOIN thread t ON m.reply_to_id = t.id
+	    WHERE t.depth < ?
+	)
+	SELECT ` + messageWithNamesColumns + `
+	FROM messages_with_names
+	WHERE id IN (SELECT id FROM thread) AND deleted_at IS NULL
+	ORDER BY timestamp ASC, id ASC
+	LIMIT ?
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, messageID, maxThreadDepth, maxThreadDepth, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return s.scanMessagesWithNames(rows)
+}
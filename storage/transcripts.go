@@ -0,0 +1,14 @@
+package storage
+
+import "time"
+
+// SaveTranscript stores the transcript of a voice note, replacing any previous one.
+// source describes how it was produced ("http" or "command").
+func (s *MessageStore) SaveTranscript(messageID, text, source string) error {
+	_, err := s.db.Exec(`
+		INSERT OR REPLACE INTO transcripts (message_id, text, source, created_at)
+		VALUES (?, ?, ?, ?)
+	`, messageID, text, source, time.Now().Unix())
+
+	return err
+}
@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 )
 
@@ -11,15 +12,32 @@ import (
 type WebhookRegistration struct {
 	ID         string
 	URL        string
-	Secret     string   // HMAC signing secret
-	EventTypes []string // ["message"]
+	Secret     string            // HMAC signing secret
+	EventTypes []string          // ["message"]
+	Fields     []string          // data fields sent, e.g. ["chat_jid", "text"] (empty = all)
+	TLSCert    string            // client certificate PEM file for mutual TLS (empty = global one, if any)
+	TLSKey     string            // private key PEM file of TLSCert
+	OAuth      *OAuthCredentials // OAuth2 client credentials (nil = none)
+	Headers    string            // extra request headers, sealed by the webhook package (empty = none)
+	Format     string            // payload format, "cloudevents" (empty = native)
+	Media      string            // media content included in message events, "base64" or "url" (empty = none)
 	Active     bool
 	CreatedAt  time.Time
 	UpdatedAt  time.Time
 }
 
+// OAuthCredentials are the OAuth2 client credentials a webhook uses to get
+// the bearer token its target requires.
+type OAuthCredentials struct {
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+}
+
 // DeliveryAttempt represents a webhook delivery attempt.
 type DeliveryAttempt struct {
+	ID            int64 // set when read back
 	WebhookID     string
 	PayloadID     string
 	EventType     string
@@ -30,6 +48,14 @@ type DeliveryAttempt struct {
 	AttemptedAt   time.Time
 }
 
+// DeliveryFilter narrows down the delivery attempts listed by ListDeliveries.
+type DeliveryFilter struct {
+	Success  *bool     // only successful (true) or failed (false) attempts; nil for both
+	Since    time.Time // only attempts at or after this time; zero for all
+	BeforeID int64     // only attempts older than this ID, to page through results; 0 for the newest
+	Limit    int
+}
+
 // DeliveryStats holds statistics about webhook deliveries.
 type DeliveryStats struct {
 	TotalDeliveries      int
@@ -57,9 +83,16 @@ func (s *WebhookStore) CreateWebhook(reg WebhookRegistration) error {
 		return fmt.Errorf("failed to marshal event types: %w", err)
 	}
 
+	fieldsJSON, err := marshalFields(reg.Fields)
+	if err != nil {
+		return err
+	}
+	oauth := oauthColumns(reg.OAuth)
+
 	query := `
-		INSERT INTO webhook_registrations (id, url, secret, event_types, active, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?)
+		INSERT INTO webhook_registrations (id, url, secret, event_types, fields, tls_cert_file, tls_key_file,
+			oauth_token_url, oauth_client_id, oauth_client_secret, oauth_scopes, headers, payload_format, media_delivery, active, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
 	_, err = s.db.Exec(query,
@@ -67,6 +100,16 @@ func (s *WebhookStore) CreateWebhook(reg WebhookRegistration) error {
 		reg.URL,
 		reg.Secret,
 		string(eventTypesJSON),
+		fieldsJSON,
+		nullString(reg.TLSCert),
+		nullString(reg.TLSKey),
+		oauth.TokenURL,
+		oauth.ClientID,
+		oauth.ClientSecret,
+		oauth.Scopes,
+		nullString(reg.Headers),
+		nullString(reg.Format),
+		nullString(reg.Media),
 		reg.Active,
 		reg.CreatedAt.Unix(),
 		reg.UpdatedAt.Unix(),
@@ -80,15 +123,24 @@ func (s *WebhookStore) CreateWebhook(reg WebhookRegistration) error {
 }
 
 // UpsertWebhook inserts a new webhook or updates an existing one if the ID already exists.
+// The field selection, client certificate, OAuth2 credentials, headers, payload
+// format and media delivery of an existing webhook are kept.
 func (s *WebhookStore) UpsertWebhook(reg WebhookRegistration) error {
 	eventTypesJSON, err := json.Marshal(reg.EventTypes)
 	if err != nil {
 		return fmt.Errorf("failed to marshal event types: %w", err)
 	}
 
+	fieldsJSON, err := marshalFields(reg.Fields)
+	if err != nil {
+		return err
+	}
+	oauth := oauthColumns(reg.OAuth)
+
 	query := `
-		INSERT INTO webhook_registrations (id, url, secret, event_types, active, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?)
+		INSERT INTO webhook_registrations (id, url, secret, event_types, fields, tls_cert_file, tls_key_file,
+			oauth_token_url, oauth_client_id, oauth_client_secret, oauth_scopes, headers, payload_format, media_delivery, active, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		ON CONFLICT(id) DO UPDATE SET
 			url = excluded.url,
 			secret = excluded.secret,
@@ -102,6 +154,16 @@ func (s *WebhookStore) UpsertWebhook(reg WebhookRegistration) error {
 		reg.URL,
 		reg.Secret,
 		string(eventTypesJSON),
+		fieldsJSON,
+		nullString(reg.TLSCert),
+		nullString(reg.TLSKey),
+		oauth.TokenURL,
+		oauth.ClientID,
+		oauth.ClientSecret,
+		oauth.Scopes,
+		nullString(reg.Headers),
+		nullString(reg.Format),
+		nullString(reg.Media),
 		reg.Active,
 		reg.CreatedAt.Unix(),
 		reg.UpdatedAt.Unix(),
@@ -117,14 +179,16 @@ func (s *WebhookStore) UpsertWebhook(reg WebhookRegistration) error {
 // GetWebhook retrieves a webhook by ID.
 func (s *WebhookStore) GetWebhook(id string) (*WebhookRegistration, error) {
 	query := `
-		SELECT id, url, secret, event_types, active, created_at, updated_at
+		SELECT id, url, secret, event_types, fields, tls_cert_file, tls_key_file,
+			oauth_token_url, oauth_client_id, oauth_client_secret, oauth_scopes, headers, payload_format, media_delivery, active, created_at, updated_at
 		FROM webhook_registrations
 		WHERE id = ?
 	`
 
 	var reg WebhookRegistration
 	var eventTypesJSON string
-	var secret sql.NullString
+	var secret, fieldsJSON, tlsCert, tlsKey, headers, format, media sql.NullString
+	var oauth oauthRow
 	var createdAt, updatedAt int64
 
 	err := s.db.QueryRow(query, id).Scan(
@@ -132,6 +196,16 @@ func (s *WebhookStore) GetWebhook(id string) (*WebhookRegistration, error) {
 		&reg.URL,
 		&secret,
 		&eventTypesJSON,
+		&fieldsJSON,
+		&tlsCert,
+		&tlsKey,
+		&oauth.TokenURL,
+		&oauth.ClientID,
+		&oauth.ClientSecret,
+		&oauth.Scopes,
+		&headers,
+		&format,
+		&media,
 		&reg.Active,
 		&createdAt,
 		&updatedAt,
@@ -152,6 +226,16 @@ func (s *WebhookStore) GetWebhook(id string) (*WebhookRegistration, error) {
 		return nil, fmt.Errorf("failed to unmarshal event types: %w", err)
 	}
 
+	if reg.Fields, err = unmarshalFields(fieldsJSON); err != nil {
+		return nil, err
+	}
+	reg.TLSCert = tlsCert.String
+	reg.TLSKey = tlsKey.String
+	reg.OAuth = oauth.credentials()
+	reg.Headers = headers.String
+	reg.Format = format.String
+	reg.Media = media.String
+
 	reg.CreatedAt = time.Unix(createdAt, 0)
 	reg.UpdatedAt = time.Unix(updatedAt, 0)
 
@@ -161,7 +245,8 @@ func (s *WebhookStore) GetWebhook(id string) (*WebhookRegistration, error) {
 // ListWebhooks retrieves all webhooks, optionally filtering by active status.
 func (s *WebhookStore) ListWebhooks(activeOnly bool) ([]WebhookRegistration, error) {
 	query := `
-		SELECT id, url, secret, event_types, active, created_at, updated_at
+		SELECT id, url, secret, event_types, fields, tls_cert_file, tls_key_file,
+			oauth_token_url, oauth_client_id, oauth_client_secret, oauth_scopes, headers, payload_format, media_delivery, active, created_at, updated_at
 		FROM webhook_registrations
 	`
 
@@ -182,7 +267,8 @@ func (s *WebhookStore) ListWebhooks(activeOnly bool) ([]WebhookRegistration, err
 	for rows.Next() {
 		var reg WebhookRegistration
 		var eventTypesJSON string
-		var secret sql.NullString
+		var secret, fieldsJSON, tlsCert, tlsKey, headers, format, media sql.NullString
+		var oauth oauthRow
 		var createdAt, updatedAt int64
 
 		err := rows.Scan(
@@ -190,6 +276,16 @@ func (s *WebhookStore) ListWebhooks(activeOnly bool) ([]WebhookRegistration, err
 			&reg.URL,
 			&secret,
 			&eventTypesJSON,
+			&fieldsJSON,
+			&tlsCert,
+			&tlsKey,
+			&oauth.TokenURL,
+			&oauth.ClientID,
+			&oauth.ClientSecret,
+			&oauth.Scopes,
+			&headers,
+			&format,
+			&media,
 			&reg.Active,
 			&createdAt,
 			&updatedAt,
@@ -207,6 +303,16 @@ func (s *WebhookStore) ListWebhooks(activeOnly bool) ([]WebhookRegistration, err
 			return nil, fmt.Errorf("failed to unmarshal event types: %w", err)
 		}
 
+		if reg.Fields, err = unmarshalFields(fieldsJSON); err != nil {
+			return nil, err
+		}
+		reg.TLSCert = tlsCert.String
+		reg.TLSKey = tlsKey.String
+		reg.OAuth = oauth.credentials()
+		reg.Headers = headers.String
+		reg.Format = format.String
+		reg.Media = media.String
+
 		reg.CreatedAt = time.Unix(createdAt, 0)
 		reg.UpdatedAt = time.Unix(updatedAt, 0)
 
@@ -227,11 +333,18 @@ func (s *WebhookStore) UpdateWebhook(reg WebhookRegistration) error {
 		return fmt.Errorf("failed to marshal event types: %w", err)
 	}
 
+	fieldsJSON, err := marshalFields(reg.Fields)
+	if err != nil {
+		return err
+	}
+	oauth := oauthColumns(reg.OAuth)
+
 	reg.UpdatedAt = time.Now()
 
 	query := `
 		UPDATE webhook_registrations
-		SET url = ?, secret = ?, event_types = ?, active = ?, updated_at = ?
+		SET url = ?, secret = ?, event_types = ?, fields = ?, tls_cert_file = ?, tls_key_file = ?,
+			oauth_token_url = ?, oauth_client_id = ?, oauth_client_secret = ?, oauth_scopes = ?, headers = ?, payload_format = ?, media_delivery = ?, active = ?, updated_at = ?
 		WHERE id = ?
 	`
 
@@ -239,6 +352,16 @@ func (s *WebhookStore) UpdateWebhook(reg WebhookRegistration) error {
 		reg.URL,
 		reg.Secret,
 		string(eventTypesJSON),
+		fieldsJSON,
+		nullString(reg.TLSCert),
+		nullString(reg.TLSKey),
+		oauth.TokenURL,
+		oauth.ClientID,
+		oauth.ClientSecret,
+		oauth.Scopes,
+		nullString(reg.Headers),
+		nullString(reg.Format),
+		nullString(reg.Media),
 		reg.Active,
 		reg.UpdatedAt.Unix(),
 		reg.ID,
@@ -260,6 +383,69 @@ func (s *WebhookStore) UpdateWebhook(reg WebhookRegistration) error {
 	return nil
 }
 
+// nullString stores an empty string as NULL.
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+// oauthRow holds the OAuth2 columns of a webhook, all NULL when it has no
+// credentials.
+type oauthRow struct {
+	TokenURL, ClientID, ClientSecret, Scopes sql.NullString
+}
+
+// oauthColumns converts credentials to their columns.
+func oauthColumns(oauth *OAuthCredentials) oauthRow {
+	if oauth == nil {
+		return oauthRow{}
+	}
+	return oauthRow{
+		TokenURL:     nullString(oauth.TokenURL),
+		ClientID:     nullString(oauth.ClientID),
+		ClientSecret: nullString(oauth.ClientSecret),
+		Scopes:       nullString(strings.Join(oauth.Scopes, " ")),
+	}
+}
+
+// credentials converts the columns back to credentials, nil if there are none.
+func (r oauthRow) credentials() *OAuthCredentials {
+	if !r.TokenURL.Valid {
+		return nil
+	}
+	return &OAuthCredentials{
+		TokenURL:     r.TokenURL.String,
+		ClientID:     r.ClientID.String,
+		ClientSecret: r.ClientSecret.String,
+		Scopes:       strings.Fields(r.Scopes.String),
+	}
+}
+
+// marshalFields encodes a field selection for storage, NULL meaning all fields.
+func marshalFields(fields []string) (sql.NullString, error) {
+	if len(fields) == 0 {
+		return sql.NullString{}, nil
+	}
+
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return sql.NullString{}, fmt.Errorf("failed to marshal fields: %w", err)
+	}
+	return sql.NullString{String: string(data), Valid: true}, nil
+}
+
+// unmarshalFields decodes a stored field selection.
+func unmarshalFields(data sql.NullString) ([]string, error) {
+	if !data.Valid {
+		return nil, nil
+	}
+
+	var fields []string
+	if err := json.Unmarshal([]byte(data.String), &fields); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal fields: %w", err)
+	}
+	return fields, nil
+}
+
 // DeleteWebhook removes a webhook registration.
 func (s *WebhookStore) DeleteWebhook(id string) error {
 	query := `DELETE FROM webhook_registrations WHERE id = ?`
@@ -365,3 +551,284 @@ func (s *WebhookStore) GetDeliveryStats(webhookID string, since time.Time) (*Del
 
 	return &stats, nil
 }
+
+// ListDeliveries returns the delivery attempts of a webhook, newest first.
+func (s *WebhookStore) ListDeliveries(webhookID string, filter DeliveryFilter) ([]DeliveryAttempt, error) {
+	query := `
+		SELECT id, webhook_id, payload_id, event_type, attempt_number, status_code, success, error, attempted_at
+		FROM webhook_deliveries
+		WHERE webhook_id = ?
+	`
+	args := []any{webhookID}
+
+	if filter.Success != nil {
+		query += " AND success = ?"
+		args = append(args, *filter.Success)
+	}
+	if !filter.Since.IsZero() {
+		query += " AND attempted_at >= ?"
+		args = append(args, filter.Since.Unix())
+	}
+	if filter.BeforeID > 0 {
+		query += " AND id < ?"
+		args = append(args, filter.BeforeID)
+	}
+
+	query += " ORDER BY id DESC LIMIT ?"
+	args = append(args, filter.Limit)
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var attempts []DeliveryAttempt
+	for rows.Next() {
+		var attempt DeliveryAttempt
+		var statusCode sql.NullInt64
+		var errorMsg sql.NullString
+		var attemptedAt int64
+
+		err := rows.Scan(
+			&attempt.ID,
+			&attempt.WebhookID,
+			&attempt.PayloadID,
+			&attempt.EventType,
+			&attempt.AttemptNumber,
+			&statusCode,
+			&attempt.Success,
+			&errorMsg,
+			&attemptedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan delivery: %w", err)
+		}
+
+		attempt.StatusCode = int(statusCode.Int64)
+		attempt.Error = errorMsg.String
+		attempt.AttemptedAt = time.Unix(attemptedAt, 0)
+
+		attempts = append(attempts, attempt)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return attempts, nil
+}
+
+// DeadLetter is a webhook event whose delivery retries were exhausted.
+type DeadLetter struct {
+	ID        int64
+	WebhookID string
+	PayloadID string
+	EventType string
+	Payload   []byte // full JSON payload
+	Attempts  int
+	LastError string
+	CreatedAt time.Time
+}
+
+// SaveDeadLetter stores an event that couldn't be delivered. An event that
+// failed again after being redelivered replaces its previous dead letter.
+func (s *WebhookStore) SaveDeadLetter(letter DeadLetter) error {
+	query := `
+		INSERT INTO webhook_dead_letters (webhook_id, payload_id, event_type, payload, attempts, last_error, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(webhook_id, payload_id) DO UPDATE SET
+			attempts = webhook_dead_letters.attempts + excluded.attempts,
+			last_error = excluded.last_error,
+			created_at = excluded.created_at
+	`
+
+	_, err := s.db.Exec(query,
+		letter.WebhookID,
+		letter.PayloadID,
+		letter.EventType,
+		string(letter.Payload),
+		letter.Attempts,
+		letter.LastError,
+		letter.CreatedAt.Unix(),
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to save dead letter: %w", err)
+	}
+
+	return nil
+}
+
+// ListDeadLetters returns the dead letters of a webhook, newest first. With
+// ids, only those dead letters are returned.
+func (s *WebhookStore) ListDeadLetters(webhookID string, ids []int64, limit int) ([]DeadLetter, error) {
+	query := `
+		SELECT id, webhook_id, payload_id, event_type, payload, attempts, last_error, created_at
+		FROM webhook_dead_letters
+		WHERE webhook_id = ?
+	`
+	args := []any{webhookID}
+
+	if len(ids) > 0 {
+		query += " AND id IN (" + strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", ") + ")"
+		for _, id := range ids {
+			args = append(args, id)
+		}
+	}
+
+	query += " ORDER BY id DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dead letters: %w", err)
+	}
+	defer rows.Close()
+
+	var letters []DeadLetter
+	for rows.Next() {
+		var letter DeadLetter
+		var payload string
+		var lastError sql.NullString
+		var createdAt int64
+
+		err := rows.Scan(
+			&letter.ID,
+			&letter.WebhookID,
+			&letter.PayloadID,
+			&letter.EventType,
+			&payload,
+			&letter.Attempts,
+			&lastError,
+			&createdAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan dead letter: %w", err)
+		}
+
+		letter.Payload = []byte(payload)
+		letter.LastError = lastError.String
+		letter.CreatedAt = time.Unix(createdAt, 0)
+
+		letters = append(letters, letter)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return letters, nil
+}
+
+// DeleteDeadLetters removes dead letters of a webhook, all of them if ids is
+// empty, and returns how many were removed.
+func (s *WebhookStore) DeleteDeadLetters(webhookID string, ids []int64) (int, error) {
+	query := `DELETE FROM webhook_dead_letters WHERE webhook_id = ?`
+	args := []any{webhookID}
+
+	if len(ids) > 0 {
+		query += " AND id IN (" + strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", ") + ")"
+		for _, id := range ids {
+			args = append(args, id)
+		}
+	}
+
+	result, err := s.db.Exec(query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete dead letters: %w", err)
+	}
+
+	n, err := result.RowsAffected()
+	return int(n), err
+}
+
+// OutboxEntry is a pending webhook delivery persisted in the outbox.
+type OutboxEntry struct {
+	ID        int64
+	WebhookID string
+	Payload   []byte // full JSON payload
+	Attempt   int    // next delivery attempt number
+	CreatedAt time.Time
+}
+
+// AddToOutbox persists a pending delivery, available right away.
+func (s *WebhookStore) AddToOutbox(entry OutboxEntry) error {
+	_, err := s.db.Exec(`
+		INSERT INTO webhook_outbox (webhook_id, payload, attempt, created_at, available_at)
+		VALUES (?, ?, ?, ?, ?)
+	`, entry.WebhookID, string(entry.Payload), entry.Attempt, entry.CreatedAt.Unix(), entry.CreatedAt.Unix())
+
+	if err != nil {
+		return fmt.Errorf("failed to add to outbox: %w", err)
+	}
+
+	return nil
+}
+
+// ClaimOutbox claims the oldest delivery that is available at now and is the
+// oldest of its webhook, so each webhook has at most one delivery in flight
+// and gets them in order. It returns nil if there is none.
+func (s *WebhookStore) ClaimOutbox(now time.Time) (*OutboxEntry, error) {
+	var entry OutboxEntry
+	var payload string
+	var createdAt int64
+
+	err := s.db.QueryRow(`
+		UPDATE webhook_outbox SET claimed_at = ?
+		WHERE id = (
+		    SELECT o.id FROM webhook_outbox o
+		    WHERE o.claimed_at IS NULL AND o.available_at <= ?
+		      AND o.id = (SELECT MIN(id) FROM webhook_outbox WHERE webhook_id = o.webhook_id)
+		    ORDER BY o.id
+		    LIMIT 1
+		)
+		RETURNING id, webhook_id, payload, attempt, created_at
+	`, now.Unix(), now.Unix()).Scan(&entry.ID, &entry.WebhookID, &payload, &entry.Attempt, &createdAt)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim from outbox: %w", err)
+	}
+
+	entry.Payload = []byte(payload)
+	entry.CreatedAt = time.Unix(createdAt, 0)
+
+	return &entry, nil
+}
+
+// RetryOutbox releases a claimed delivery for another attempt at a later time.
+func (s *WebhookStore) RetryOutbox(id int64, attempt int, at time.Time) error {
+	_, err := s.db.Exec(`
+		UPDATE webhook_outbox SET attempt = ?, available_at = ?, claimed_at = NULL
+		WHERE id = ?
+	`, attempt, at.Unix(), id)
+
+	if err != nil {
+		return fmt.Errorf("failed to reschedule outbox entry: %w", err)
+	}
+
+	return nil
+}
+
+// ReleaseOutboxClaims releases the deliveries claimed by workers that didn't
+// finish them, such as before a crash, and returns how many there were.
+func (s *WebhookStore) ReleaseOutboxClaims() (int, error) {
+	result, err := s.db.Exec("UPDATE webhook_outbox SET claimed_at = NULL WHERE claimed_at IS NOT NULL")
+	if err != nil {
+		return 0, fmt.Errorf("failed to release outbox claims: %w", err)
+	}
+
+	n, err := result.RowsAffected()
+	return int(n), err
+}
+
+// RemoveFromOutbox deletes a delivery once it is delivered or dead-lettered.
+func (s *WebhookStore) RemoveFromOutbox(id int64) error {
+	if _, err := s.db.Exec("DELETE FROM webhook_outbox WHERE id = ?", id); err != nil {
+		return fmt.Errorf("failed to remove from outbox: %w", err)
+	}
+	return nil
+}
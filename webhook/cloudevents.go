@@ -0,0 +1,79 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Payload formats a webhook can receive.
+const (
+	PayloadFormatNative      = "native"
+	PayloadFormatCloudEvents = "cloudevents"
+)
+
+// cloudEventsSource identifies this server as the source of CloudEvents.
+const cloudEventsSource = "whatsapp-mcp"
+
+// cloudEvent is a CloudEvents 1.0 event in the structured JSON format.
+type cloudEvent struct {
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	Subject         string          `json:"subject,omitempty"`
+	Time            time.Time       `json:"time"`
+	DataContentType string          `json:"datacontenttype"`
+	Data            json.RawMessage `json:"data"`
+}
+
+// validatePayloadFormat checks a webhook's payload format; empty means native.
+func validatePayloadFormat(format string) error {
+	switch format {
+	case "", PayloadFormatNative, PayloadFormatCloudEvents:
+		return nil
+	}
+	return fmt.Errorf("unsupported payload format: %s (use %s or %s)", format, PayloadFormatNative, PayloadFormatCloudEvents)
+}
+
+// storedPayloadFormat converts a payload format for storage, where the native
+// format is left empty.
+func storedPayloadFormat(format string) string {
+	if format == PayloadFormatNative {
+		return ""
+	}
+	return format
+}
+
+// payloadFormat returns the payload format of a stored webhook.
+func payloadFormat(format string) string {
+	if format == "" {
+		return PayloadFormatNative
+	}
+	return format
+}
+
+// toCloudEvent wraps a serialized native payload, after field selection, in a
+// CloudEvents envelope. The event ID, type and time carry over, and the
+// chat or group of the event becomes the subject.
+func toCloudEvent(jsonData []byte, payload WebhookPayload) ([]byte, error) {
+	var native struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(jsonData, &native); err != nil {
+		return nil, err
+	}
+
+	event := cloudEvent{
+		SpecVersion:     "1.0",
+		ID:              payload.ID,
+		Source:          cloudEventsSource,
+		Type:            payload.EventType,
+		Subject:         payload.ChatJID(),
+		Time:            payload.Timestamp,
+		DataContentType: "application/json",
+		Data:            native.Data,
+	}
+
+	return json.Marshal(event)
+}
@@ -2,18 +2,27 @@ package webhook
 
 import (
 	"os"
+	"strings"
 	"time"
 	"whatsapp-mcp/config"
 )
 
 // Config holds the webhook system configuration.
 type Config struct {
-	PrimaryURL        string          // From WEBHOOK_URL env var
-	MaxRetries        int             // Maximum delivery retry attempts
-	RetryBackoff      []time.Duration // Backoff duration between retries
-	DeliveryTimeout   time.Duration   // HTTP request timeout
-	WorkerPoolSize    int             // Number of concurrent delivery workers
-	ChannelBufferSize int             // Size of delivery queue buffer
+	PrimaryURL       string          // From WEBHOOK_URL env var
+	MaxRetries       int             // Maximum delivery retry attempts
+	RetryBackoff     []time.Duration // Backoff duration between retries
+	DeliveryTimeout  time.Duration   // HTTP request timeout
+	WorkerPoolSize   int             // Number of concurrent delivery workers
+	TLSCertFile      string          // Client certificate for mutual TLS, from WEBHOOK_TLS_CERT_FILE
+	TLSKeyFile       string          // Private key of TLSCertFile, from WEBHOOK_TLS_KEY_FILE
+	TLSCAFile        string          // Extra CA to trust for webhook servers, from WEBHOOK_TLS_CA_FILE
+	EncryptionKey    string          // Encrypts webhook headers at rest, from WEBHOOK_ENCRYPTION_KEY
+	MediaBaseURL     string          // Public base URL of this server for signed media URLs, from WEBHOOK_MEDIA_BASE_URL
+	MediaMaxBytes    int64           // Largest file sent as base64, from WEBHOOK_MEDIA_MAX_BYTES
+	MediaURLTTL      time.Duration   // How long signed media URLs stay valid
+	StreamOrigins    []string        // Origins allowed to open the /ws event stream, from WS_ALLOWED_ORIGINS
+	StreamReplaySize int             // Events kept for /ws clients to replay, from WS_REPLAY_BUFFER
 }
 
 // LoadConfig loads webhook configuration from environment variables.
@@ -27,11 +36,30 @@ func LoadConfig() *Config {
 	}
 
 	return &Config{
-		PrimaryURL:        os.Getenv("WEBHOOK_URL"),
-		MaxRetries:        maxRetries,
-		RetryBackoff:      retryBackoff,
-		DeliveryTimeout:   time.Duration(config.GetEnvInt("WEBHOOK_TIMEOUT_SECONDS", 10)) * time.Second,
-		WorkerPoolSize:    config.GetEnvInt("WEBHOOK_WORKER_POOL_SIZE", 3),
-		ChannelBufferSize: 100,
+		PrimaryURL:       os.Getenv("WEBHOOK_URL"),
+		MaxRetries:       maxRetries,
+		RetryBackoff:     retryBackoff,
+		DeliveryTimeout:  time.Duration(config.GetEnvInt("WEBHOOK_TIMEOUT_SECONDS", 10)) * time.Second,
+		WorkerPoolSize:   config.GetEnvInt("WEBHOOK_WORKER_POOL_SIZE", 3),
+		TLSCertFile:      os.Getenv("WEBHOOK_TLS_CERT_FILE"),
+		TLSKeyFile:       os.Getenv("WEBHOOK_TLS_KEY_FILE"),
+		TLSCAFile:        os.Getenv("WEBHOOK_TLS_CA_FILE"),
+		EncryptionKey:    os.Getenv("WEBHOOK_ENCRYPTION_KEY"),
+		MediaBaseURL:     strings.TrimSuffix(os.Getenv("WEBHOOK_MEDIA_BASE_URL"), "/"),
+		MediaMaxBytes:    config.GetEnvInt64("WEBHOOK_MEDIA_MAX_BYTES", 5*1024*1024),
+		MediaURLTTL:      time.Duration(config.GetEnvInt("WEBHOOK_MEDIA_URL_TTL_MINUTES", 60)) * time.Minute,
+		StreamOrigins:    splitList(os.Getenv("WS_ALLOWED_ORIGINS")),
+		StreamReplaySize: config.GetEnvInt("WS_REPLAY_BUFFER", 1000),
 	}
 }
+
+// splitList splits a comma-separated environment variable, skipping blanks.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
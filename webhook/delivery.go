@@ -24,6 +24,24 @@ func (m *WebhookManager) deliverWebhook(webhook storage.WebhookRegistration, pay
 	if err != nil {
 		return m.recordFailure(webhook, payload, attempt, 0, fmt.Errorf("failed to marshal payload: %w", err))
 	}
+	if webhook.Media != "" {
+		if jsonData, err = m.attachMedia(jsonData, webhook.Media); err != nil {
+			return m.recordFailure(webhook, payload, attempt, 0, fmt.Errorf("failed to attach media: %w", err))
+		}
+	}
+	if len(webhook.Fields) > 0 {
+		if jsonData, err = selectFields(jsonData, webhook.Fields); err != nil {
+			return m.recordFailure(webhook, payload, attempt, 0, fmt.Errorf("failed to select fields: %w", err))
+		}
+	}
+
+	contentType := "application/json"
+	if webhook.Format == PayloadFormatCloudEvents {
+		if jsonData, err = toCloudEvent(jsonData, payload); err != nil {
+			return m.recordFailure(webhook, payload, attempt, 0, fmt.Errorf("failed to build CloudEvent: %w", err))
+		}
+		contentType = "application/cloudevents+json"
+	}
 
 	// Create HTTP request
 	req, err := http.NewRequest("POST", webhook.URL, bytes.NewBuffer(jsonData))
@@ -31,24 +49,47 @@ func (m *WebhookManager) deliverWebhook(webhook storage.WebhookRegistration, pay
 		return m.recordFailure(webhook, payload, attempt, 0, fmt.Errorf("failed to create request: %w", err))
 	}
 
+	// Set the webhook's own headers first so the standard ones below take precedence
+	headers, err := m.openHeaders(webhook.Headers)
+	if err != nil {
+		return m.recordFailure(webhook, payload, attempt, 0, fmt.Errorf("failed to read webhook headers: %w", err))
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
 	// Set headers
-	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Type", contentType)
 	req.Header.Set("User-Agent", "WhatsApp-MCP-Webhook/1.0")
 	req.Header.Set("X-Webhook-ID", webhook.ID)
 	req.Header.Set("X-Event-ID", payload.ID)
 
+	// Attach an OAuth2 access token if the target requires one
+	if webhook.OAuth != nil {
+		authorization, err := m.authorization(webhook)
+		if err != nil {
+			return m.recordFailure(webhook, payload, attempt, 0, fmt.Errorf("failed to get OAuth2 token: %w", err))
+		}
+		req.Header.Set("Authorization", authorization)
+	}
+
 	// Calculate HMAC signature if secret is configured
 	if webhook.Secret != "" {
 		signature := calculateSignature(jsonData, webhook.Secret)
 		req.Header.Set("X-Webhook-Signature", signature)
 	}
 
-	resp, err := m.httpClient.Do(req)
+	resp, err := m.clientFor(webhook).Do(req)
 	if err != nil {
 		return m.recordFailure(webhook, payload, attempt, 0, fmt.Errorf("request failed: %w", err))
 	}
 	defer resp.Body.Close()
 
+	// A rejected token may have been revoked: get a new one for the retry
+	if resp.StatusCode == http.StatusUnauthorized && webhook.OAuth != nil {
+		m.invalidateToken(webhook.ID)
+	}
+
 	// Check status code
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		// Read response body only for error reporting (with 1MB limit to prevent memory exhaustion)
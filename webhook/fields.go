@@ -0,0 +1,70 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// validateFields checks the data fields a webhook selects. Fields are keys of
+// the payload's data, with dots for nested keys (e.g. "media_metadata.file_name").
+func validateFields(fields []string) error {
+	for _, field := range fields {
+		if field == "" {
+			return fmt.Errorf("empty field is not allowed")
+		}
+		for _, key := range strings.Split(field, ".") {
+			if key == "" {
+				return fmt.Errorf("invalid field: %s", field)
+			}
+		}
+	}
+
+	return nil
+}
+
+// selectFields keeps only the given fields of the data of a serialized
+// payload. The event ID, type and timestamp are always kept; fields missing
+// from an event are left out.
+func selectFields(jsonData []byte, fields []string) ([]byte, error) {
+	var payload map[string]any
+	decoder := json.NewDecoder(bytes.NewReader(jsonData))
+	decoder.UseNumber() // keep numbers such as file sizes exact
+	if err := decoder.Decode(&payload); err != nil {
+		return nil, err
+	}
+
+	data, _ := payload["data"].(map[string]any)
+	selected := make(map[string]any)
+	for _, field := range fields {
+		copyField(data, selected, strings.Split(field, "."))
+	}
+	payload["data"] = selected
+
+	return json.Marshal(payload)
+}
+
+// copyField copies the value at path from src to dst, creating the objects
+// that lead to it.
+func copyField(src, dst map[string]any, path []string) {
+	value, ok := src[path[0]]
+	if !ok {
+		return
+	}
+	if len(path) == 1 {
+		dst[path[0]] = value
+		return
+	}
+
+	child, ok := value.(map[string]any)
+	if !ok {
+		return
+	}
+	next, ok := dst[path[0]].(map[string]any)
+	if !ok {
+		next = make(map[string]any)
+		dst[path[0]] = next
+	}
+	copyField(child, next, path[1:])
+}
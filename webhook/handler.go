@@ -3,15 +3,15 @@ package webhook
 import (
 	"crypto/subtle"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
 	"whatsapp-mcp/storage"
-
-	"github.com/google/uuid"
 )
 
 // Handler handles HTTP API requests for webhook management.
@@ -48,18 +48,78 @@ func (h *Handler) ValidateAuth(r *http.Request) bool {
 	return subtle.ConstantTimeCompare([]byte(authHeader), []byte(expectedAuth)) == 1
 }
 
+// ValidateAuthOrKey is ValidateAuth that also accepts the API key as an
+// api_key query parameter, for browsers and WebSocket clients that can't set
+// headers.
+func (h *Handler) ValidateAuthOrKey(r *http.Request) bool {
+	keyOK := subtle.ConstantTimeCompare([]byte(r.URL.Query().Get("api_key")), []byte(h.apiKey)) == 1
+	return keyOK || h.ValidateAuth(r)
+}
+
 var (
 	// supportedEventTypes lists all valid event types
 	supportedEventTypes = map[string]bool{
-		"message": true,
+		"message":             true,
+		EventMessageEdited:    true,
+		EventMessageDeleted:   true,
+		EventMessageReaction:  true,
+		EventMessagePollVote:  true,
+		EventMessageDelivered: true,
+		EventMessageRead:      true,
+
+		EventGroupCreated:            true,
+		EventGroupParticipantAdded:   true,
+		EventGroupParticipantRemoved: true,
+		EventGroupSubjectChanged:     true,
+
+		EventCallIncoming: true,
+		EventCallMissed:   true,
 	}
 )
 
 // CreateWebhookRequest represents a webhook creation request.
 type CreateWebhookRequest struct {
-	URL        string   `json:"url"`
-	Secret     string   `json:"secret,omitempty"`
-	EventTypes []string `json:"event_types"`
+	URL        string            `json:"url"`
+	Secret     string            `json:"secret,omitempty"`
+	EventTypes []string          `json:"event_types"`
+	Fields     []string          `json:"fields,omitempty"`        // data fields to send (omit for all)
+	TLSCert    string            `json:"tls_cert_file,omitempty"` // client certificate for mutual TLS (PEM file on the server)
+	TLSKey     string            `json:"tls_key_file,omitempty"`
+	OAuth      *OAuthConfig      `json:"oauth,omitempty"`          // OAuth2 client credentials for targets that require a token
+	Headers    map[string]string `json:"headers,omitempty"`        // extra headers sent with each delivery, stored encrypted
+	Format     string            `json:"payload_format,omitempty"` // "native" (default) or "cloudevents"
+	Media      string            `json:"media_delivery,omitempty"` // "base64", "url" or "thumbnail" to include media content in message events
+}
+
+// OAuthConfig holds a webhook's OAuth2 client credentials in API requests and
+// responses. The client secret is never returned.
+type OAuthConfig struct {
+	TokenURL     string   `json:"token_url"`
+	ClientID     string   `json:"client_id"`
+	ClientSecret string   `json:"client_secret,omitempty"`
+	Scopes       []string `json:"scopes,omitempty"`
+}
+
+// oauthCredentials converts the request form of OAuth2 credentials.
+func (c *OAuthConfig) oauthCredentials() *storage.OAuthCredentials {
+	if c == nil || c.TokenURL == "" {
+		return nil
+	}
+	return &storage.OAuthCredentials{
+		TokenURL:     c.TokenURL,
+		ClientID:     c.ClientID,
+		ClientSecret: c.ClientSecret,
+		Scopes:       c.Scopes,
+	}
+}
+
+// oauthResponse converts stored OAuth2 credentials for a response, without
+// the secret.
+func oauthResponse(creds *storage.OAuthCredentials) *OAuthConfig {
+	if creds == nil {
+		return nil
+	}
+	return &OAuthConfig{TokenURL: creds.TokenURL, ClientID: creds.ClientID, Scopes: creds.Scopes}
 }
 
 // validateURL checks if the URL is valid and not targeting private/internal networks (SSRF prevention).
@@ -102,12 +162,19 @@ func validateEventTypes(eventTypes []string) error {
 
 // WebhookResponse represents a webhook in API responses.
 type WebhookResponse struct {
-	ID         string    `json:"id"`
-	URL        string    `json:"url"`
-	EventTypes []string  `json:"event_types"`
-	Active     bool      `json:"active"`
-	CreatedAt  time.Time `json:"created_at"`
-	UpdatedAt  time.Time `json:"updated_at"`
+	ID         string       `json:"id"`
+	URL        string       `json:"url"`
+	EventTypes []string     `json:"event_types"`
+	Fields     []string     `json:"fields,omitempty"`
+	TLSCert    string       `json:"tls_cert_file,omitempty"`
+	TLSKey     string       `json:"tls_key_file,omitempty"`
+	OAuth      *OAuthConfig `json:"oauth,omitempty"`
+	Headers    []string     `json:"headers,omitempty"` // header names only, values are never returned
+	Format     string       `json:"payload_format"`
+	Media      string       `json:"media_delivery,omitempty"`
+	Active     bool         `json:"active"`
+	CreatedAt  time.Time    `json:"created_at"`
+	UpdatedAt  time.Time    `json:"updated_at"`
 }
 
 // CreateWebhook handles POST /api/webhooks
@@ -118,54 +185,16 @@ func (h *Handler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Validate request
-	if req.URL == "" {
-		http.Error(w, `{"error":"URL is required"}`, http.StatusBadRequest)
-		return
-	}
-
-	// Validate URL format and prevent SSRF
-	if err := validateURL(req.URL); err != nil {
-		errorResponse(w, "Invalid URL: "+err.Error(), http.StatusBadRequest)
-		return
-	}
-
-	if len(req.EventTypes) == 0 {
-		req.EventTypes = []string{"message"} // default
-	}
-
-	// Validate event types
-	if err := validateEventTypes(req.EventTypes); err != nil {
-		errorResponse(w, err.Error(), http.StatusBadRequest)
-		return
-	}
-
-	// Create webhook registration
-	webhook := storage.WebhookRegistration{
-		ID:         uuid.New().String(),
-		URL:        req.URL,
-		Secret:     req.Secret,
-		EventTypes: req.EventTypes,
-		Active:     true,
-		CreatedAt:  time.Now(),
-		UpdatedAt:  time.Now(),
-	}
-
-	if err := h.store.CreateWebhook(webhook); err != nil {
-		http.Error(w, `{"error":"Failed to create webhook"}`, http.StatusInternalServerError)
+	resp, err := h.manager.Register(req)
+	if err != nil {
+		if isInvalidRequest(err) {
+			errorResponse(w, err.Error(), http.StatusBadRequest)
+		} else {
+			http.Error(w, `{"error":"Failed to create webhook"}`, http.StatusInternalServerError)
+		}
 		return
 	}
 
-	// Return response
-	resp := WebhookResponse{
-		ID:         webhook.ID,
-		URL:        webhook.URL,
-		EventTypes: webhook.EventTypes,
-		Active:     webhook.Active,
-		CreatedAt:  webhook.CreatedAt,
-		UpdatedAt:  webhook.UpdatedAt,
-	}
-
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	_ = json.NewEncoder(w).Encode(resp)
@@ -173,24 +202,12 @@ func (h *Handler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
 
 // ListWebhooks handles GET /api/webhooks
 func (h *Handler) ListWebhooks(w http.ResponseWriter, r *http.Request) {
-	webhooks, err := h.store.ListWebhooks(false) // include inactive
+	resp, err := h.manager.Webhooks()
 	if err != nil {
 		http.Error(w, `{"error":"Failed to list webhooks"}`, http.StatusInternalServerError)
 		return
 	}
 
-	var resp []WebhookResponse
-	for _, wh := range webhooks {
-		resp = append(resp, WebhookResponse{
-			ID:         wh.ID,
-			URL:        wh.URL,
-			EventTypes: wh.EventTypes,
-			Active:     wh.Active,
-			CreatedAt:  wh.CreatedAt,
-			UpdatedAt:  wh.UpdatedAt,
-		})
-	}
-
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(map[string]any{"webhooks": resp})
 }
@@ -212,12 +229,39 @@ func (h *Handler) HandleWebhookByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Check for replay endpoint
+	if len(parts) == 2 && parts[1] == "replay" && r.Method == http.MethodPost {
+		h.ReplayWebhook(w, r, webhookID)
+		return
+	}
+
 	// Check for stats endpoint
 	if len(parts) == 2 && parts[1] == "stats" && r.Method == http.MethodGet {
 		h.GetWebhookStats(w, r, webhookID)
 		return
 	}
 
+	// Check for deliveries endpoint
+	if len(parts) == 2 && parts[1] == "deliveries" && r.Method == http.MethodGet {
+		h.ListDeliveries(w, r, webhookID)
+		return
+	}
+
+	// Check for dead letter endpoints
+	if len(parts) >= 2 && parts[1] == "dead-letters" {
+		switch {
+		case len(parts) == 2 && r.Method == http.MethodGet:
+			h.ListDeadLetters(w, r, webhookID)
+		case len(parts) == 2 && r.Method == http.MethodDelete:
+			h.PurgeDeadLetters(w, r, webhookID)
+		case len(parts) == 3 && parts[2] == "redeliver" && r.Method == http.MethodPost:
+			h.RedeliverDeadLetters(w, r, webhookID)
+		default:
+			http.Error(w, `{"error":"Method not allowed"}`, http.StatusMethodNotAllowed)
+		}
+		return
+	}
+
 	// Route by method
 	switch r.Method {
 	case http.MethodGet:
@@ -239,14 +283,7 @@ func (h *Handler) GetWebhook(w http.ResponseWriter, r *http.Request, webhookID s
 		return
 	}
 
-	resp := WebhookResponse{
-		ID:         webhook.ID,
-		URL:        webhook.URL,
-		EventTypes: webhook.EventTypes,
-		Active:     webhook.Active,
-		CreatedAt:  webhook.CreatedAt,
-		UpdatedAt:  webhook.UpdatedAt,
-	}
+	resp := h.manager.response(*webhook)
 
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(resp)
@@ -254,10 +291,17 @@ func (h *Handler) GetWebhook(w http.ResponseWriter, r *http.Request, webhookID s
 
 // UpdateWebhookRequest represents a webhook update request.
 type UpdateWebhookRequest struct {
-	URL        *string   `json:"url,omitempty"`
-	Secret     *string   `json:"secret,omitempty"`
-	EventTypes *[]string `json:"event_types,omitempty"`
-	Active     *bool     `json:"active,omitempty"`
+	URL        *string            `json:"url,omitempty"`
+	Secret     *string            `json:"secret,omitempty"`
+	EventTypes *[]string          `json:"event_types,omitempty"`
+	Fields     *[]string          `json:"fields,omitempty"`        // [] sends all fields again
+	TLSCert    *string            `json:"tls_cert_file,omitempty"` // "" (with tls_key_file) removes the client certificate
+	TLSKey     *string            `json:"tls_key_file,omitempty"`
+	OAuth      *OAuthConfig       `json:"oauth,omitempty"`   // {} removes the credentials; an omitted client_secret is kept
+	Headers    *map[string]string `json:"headers,omitempty"` // replaces all headers; {} removes them
+	Format     *string            `json:"payload_format,omitempty"`
+	Media      *string            `json:"media_delivery,omitempty"` // "" includes metadata only again
+	Active     *bool              `json:"active,omitempty"`
 }
 
 // UpdateWebhook handles PUT /api/webhooks/{id}
@@ -290,6 +334,75 @@ func (h *Handler) UpdateWebhook(w http.ResponseWriter, r *http.Request, webhookI
 		}
 	}
 
+	if req.Fields != nil {
+		if err := validateFields(*req.Fields); err != nil {
+			errorResponse(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	if req.TLSCert != nil || req.TLSKey != nil {
+		cert, key := webhook.TLSCert, webhook.TLSKey
+		if req.TLSCert != nil {
+			cert = *req.TLSCert
+		}
+		if req.TLSKey != nil {
+			key = *req.TLSKey
+		}
+		if err := validateClientCertificate(cert, key); err != nil {
+			errorResponse(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	oauth := webhook.OAuth
+	if req.OAuth != nil {
+		oauth = req.OAuth.oauthCredentials()
+		if oauth != nil && oauth.ClientSecret == "" && webhook.OAuth != nil {
+			oauth.ClientSecret = webhook.OAuth.ClientSecret
+		}
+		if err := validateOAuth(oauth); err != nil {
+			errorResponse(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	sealedHeaders := webhook.Headers
+	if req.Headers != nil {
+		if err := validateHeaders(*req.Headers, oauth != nil); err != nil {
+			errorResponse(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if sealedHeaders, err = h.manager.sealHeaders(*req.Headers); err != nil {
+			errorResponse(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	} else if req.OAuth != nil && oauth != nil {
+		// headers kept from before can't clash with the new OAuth2 token either
+		headers, err := h.manager.openHeaders(webhook.Headers)
+		if err == nil {
+			err = validateHeaders(headers, true)
+		}
+		if err != nil {
+			errorResponse(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	if req.Format != nil {
+		if err := validatePayloadFormat(*req.Format); err != nil {
+			errorResponse(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	if req.Media != nil {
+		if err := h.manager.validateMediaDelivery(*req.Media); err != nil {
+			errorResponse(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
 	// Apply updates
 	if req.URL != nil {
 		webhook.URL = *req.URL
@@ -302,6 +415,26 @@ func (h *Handler) UpdateWebhook(w http.ResponseWriter, r *http.Request, webhookI
 	if req.EventTypes != nil {
 		webhook.EventTypes = *req.EventTypes
 	}
+	if req.Fields != nil {
+		webhook.Fields = *req.Fields
+	}
+	if req.TLSCert != nil {
+		webhook.TLSCert = *req.TLSCert
+	}
+	if req.TLSKey != nil {
+		webhook.TLSKey = *req.TLSKey
+	}
+	if req.OAuth != nil {
+		webhook.OAuth = oauth
+		h.manager.invalidateToken(webhook.ID)
+	}
+	webhook.Headers = sealedHeaders
+	if req.Format != nil {
+		webhook.Format = storedPayloadFormat(*req.Format)
+	}
+	if req.Media != nil {
+		webhook.Media = *req.Media
+	}
 	if req.Active != nil {
 		webhook.Active = *req.Active
 	}
@@ -318,14 +451,7 @@ func (h *Handler) UpdateWebhook(w http.ResponseWriter, r *http.Request, webhookI
 		return
 	}
 
-	resp := WebhookResponse{
-		ID:         updatedWebhook.ID,
-		URL:        updatedWebhook.URL,
-		EventTypes: updatedWebhook.EventTypes,
-		Active:     updatedWebhook.Active,
-		CreatedAt:  updatedWebhook.CreatedAt,
-		UpdatedAt:  updatedWebhook.UpdatedAt,
-	}
+	resp := h.manager.response(*updatedWebhook)
 
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(resp)
@@ -333,7 +459,7 @@ func (h *Handler) UpdateWebhook(w http.ResponseWriter, r *http.Request, webhookI
 
 // DeleteWebhook handles DELETE /api/webhooks/{id}
 func (h *Handler) DeleteWebhook(w http.ResponseWriter, r *http.Request, webhookID string) {
-	if err := h.store.DeleteWebhook(webhookID); err != nil {
+	if err := h.manager.Unregister(webhookID); err != nil {
 		http.Error(w, `{"error":"Webhook not found"}`, http.StatusNotFound)
 		return
 	}
@@ -343,32 +469,11 @@ func (h *Handler) DeleteWebhook(w http.ResponseWriter, r *http.Request, webhookI
 
 // TestWebhook handles POST /api/webhooks/{id}/test
 func (h *Handler) TestWebhook(w http.ResponseWriter, r *http.Request, webhookID string) {
-	webhook, err := h.store.GetWebhook(webhookID)
-	if err != nil {
+	payloadID, err := h.manager.SendTestEvent(webhookID)
+	if errors.Is(err, errWebhookNotFound) {
 		http.Error(w, `{"error":"Webhook not found"}`, http.StatusNotFound)
 		return
 	}
-
-	// Create a test payload
-	testPayload := WebhookPayload{
-		ID:        uuid.New().String(),
-		EventType: "message.received",
-		Timestamp: time.Now(),
-		Data: MessageEventData{
-			MessageID:   "TEST-" + uuid.New().String(),
-			ChatJID:     "test@s.whatsapp.net",
-			SenderJID:   "test@s.whatsapp.net",
-			Text:        "This is a test message from WhatsApp MCP webhook system",
-			Timestamp:   time.Now(),
-			IsFromMe:    false,
-			MessageType: "text",
-			ChatName:    "Test Chat",
-			IsGroup:     false,
-		},
-	}
-
-	// Attempt delivery
-	err = h.manager.TestDelivery(*webhook, testPayload)
 	if err != nil {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusBadGateway)
@@ -382,10 +487,54 @@ func (h *Handler) TestWebhook(w http.ResponseWriter, r *http.Request, webhookID
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(map[string]any{
 		"status":     "delivered",
-		"payload_id": testPayload.ID,
+		"payload_id": payloadID,
 	})
 }
 
+// ReplayWebhookRequest is the body of POST /api/webhooks/{id}/replay.
+type ReplayWebhookRequest struct {
+	From time.Time  `json:"from"`
+	To   *time.Time `json:"to,omitempty"` // default: now
+}
+
+// ReplayWebhook handles POST /api/webhooks/{id}/replay
+func (h *Handler) ReplayWebhook(w http.ResponseWriter, r *http.Request, webhookID string) {
+	var req ReplayWebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errorResponse(w, "Invalid request body: from and to must be RFC 3339 timestamps", http.StatusBadRequest)
+		return
+	}
+	if req.From.IsZero() {
+		errorResponse(w, "from is required", http.StatusBadRequest)
+		return
+	}
+	to := time.Now()
+	if req.To != nil {
+		to = *req.To
+	}
+
+	queued, err := h.manager.Replay(webhookID, req.From, to)
+	switch {
+	case errors.Is(err, errWebhookNotFound):
+		http.Error(w, `{"error":"Webhook not found"}`, http.StatusNotFound)
+		return
+	case isInvalidRequest(err):
+		errorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	case err != nil:
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"queued": queued,
+			"error":  err.Error(),
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{"queued": queued})
+}
+
 // GetWebhookStats handles GET /api/webhooks/{id}/stats
 func (h *Handler) GetWebhookStats(w http.ResponseWriter, r *http.Request, webhookID string) {
 	// Check webhook exists
@@ -405,3 +554,209 @@ func (h *Handler) GetWebhookStats(w http.ResponseWriter, r *http.Request, webhoo
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(stats)
 }
+
+// DeliveryResponse represents a delivery attempt in API responses.
+type DeliveryResponse struct {
+	ID            int64     `json:"id"`
+	PayloadID     string    `json:"payload_id"`
+	EventType     string    `json:"event_type"`
+	AttemptNumber int       `json:"attempt_number"`
+	StatusCode    int       `json:"status_code,omitempty"` // omitted when no response was received
+	Success       bool      `json:"success"`
+	Error         string    `json:"error,omitempty"`
+	AttemptedAt   time.Time `json:"attempted_at"`
+}
+
+// ListDeliveries handles GET /api/webhooks/{id}/deliveries. Query parameters:
+// success (true or false), since (RFC3339), limit (default 50, max 200) and
+// before, the next_before of the previous page.
+func (h *Handler) ListDeliveries(w http.ResponseWriter, r *http.Request, webhookID string) {
+	if _, err := h.store.GetWebhook(webhookID); err != nil {
+		http.Error(w, `{"error":"Webhook not found"}`, http.StatusNotFound)
+		return
+	}
+
+	query := r.URL.Query()
+	filter := storage.DeliveryFilter{Limit: 50}
+
+	if v := query.Get("success"); v != "" {
+		success, err := strconv.ParseBool(v)
+		if err != nil {
+			errorResponse(w, "Invalid success: must be true or false", http.StatusBadRequest)
+			return
+		}
+		filter.Success = &success
+	}
+	if v := query.Get("since"); v != "" {
+		since, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			errorResponse(w, "Invalid since: must be an RFC3339 time", http.StatusBadRequest)
+			return
+		}
+		filter.Since = since
+	}
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			errorResponse(w, "Invalid limit: must be a positive number", http.StatusBadRequest)
+			return
+		}
+		filter.Limit = min(limit, 200)
+	}
+	if v := query.Get("before"); v != "" {
+		before, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || before <= 0 {
+			errorResponse(w, "Invalid before: must be a delivery ID", http.StatusBadRequest)
+			return
+		}
+		filter.BeforeID = before
+	}
+
+	attempts, err := h.store.ListDeliveries(webhookID, filter)
+	if err != nil {
+		http.Error(w, `{"error":"Failed to list deliveries"}`, http.StatusInternalServerError)
+		return
+	}
+
+	deliveries := make([]DeliveryResponse, 0, len(attempts))
+	for _, a := range attempts {
+		deliveries = append(deliveries, DeliveryResponse{
+			ID:            a.ID,
+			PayloadID:     a.PayloadID,
+			EventType:     a.EventType,
+			AttemptNumber: a.AttemptNumber,
+			StatusCode:    a.StatusCode,
+			Success:       a.Success,
+			Error:         a.Error,
+			AttemptedAt:   a.AttemptedAt,
+		})
+	}
+
+	resp := map[string]any{"deliveries": deliveries}
+	if len(attempts) == filter.Limit {
+		resp["next_before"] = attempts[len(attempts)-1].ID
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// DeadLetterResponse represents a dead-lettered event in API responses.
+type DeadLetterResponse struct {
+	ID        int64           `json:"id"`
+	PayloadID string          `json:"payload_id"`
+	EventType string          `json:"event_type"`
+	Attempts  int             `json:"attempts"`
+	LastError string          `json:"last_error,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+	Payload   json.RawMessage `json:"payload"`
+}
+
+// idsParam parses the optional comma-separated ids query parameter.
+func idsParam(r *http.Request) ([]int64, error) {
+	v := r.URL.Query().Get("ids")
+	if v == "" {
+		return nil, nil
+	}
+
+	var ids []int64
+	for _, s := range strings.Split(v, ",") {
+		id, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+		if err != nil || id <= 0 {
+			return nil, fmt.Errorf("invalid ids: %s", v)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// ListDeadLetters handles GET /api/webhooks/{id}/dead-letters?limit=
+func (h *Handler) ListDeadLetters(w http.ResponseWriter, r *http.Request, webhookID string) {
+	if _, err := h.store.GetWebhook(webhookID); err != nil {
+		http.Error(w, `{"error":"Webhook not found"}`, http.StatusNotFound)
+		return
+	}
+
+	limit := 50
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			errorResponse(w, "Invalid limit: must be a positive number", http.StatusBadRequest)
+			return
+		}
+		limit = min(n, 200)
+	}
+
+	letters, err := h.store.ListDeadLetters(webhookID, nil, limit)
+	if err != nil {
+		http.Error(w, `{"error":"Failed to list dead letters"}`, http.StatusInternalServerError)
+		return
+	}
+
+	resp := make([]DeadLetterResponse, 0, len(letters))
+	for _, l := range letters {
+		resp = append(resp, DeadLetterResponse{
+			ID:        l.ID,
+			PayloadID: l.PayloadID,
+			EventType: l.EventType,
+			Attempts:  l.Attempts,
+			LastError: l.LastError,
+			CreatedAt: l.CreatedAt,
+			Payload:   l.Payload,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{"dead_letters": resp})
+}
+
+// RedeliverDeadLetters handles POST /api/webhooks/{id}/dead-letters/redeliver?ids=
+func (h *Handler) RedeliverDeadLetters(w http.ResponseWriter, r *http.Request, webhookID string) {
+	if _, err := h.store.GetWebhook(webhookID); err != nil {
+		http.Error(w, `{"error":"Webhook not found"}`, http.StatusNotFound)
+		return
+	}
+
+	ids, err := idsParam(r)
+	if err != nil {
+		errorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	queued, err := h.manager.Redeliver(webhookID, ids)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"queued": queued,
+			"error":  err.Error(),
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{"queued": queued})
+}
+
+// PurgeDeadLetters handles DELETE /api/webhooks/{id}/dead-letters?ids=
+func (h *Handler) PurgeDeadLetters(w http.ResponseWriter, r *http.Request, webhookID string) {
+	if _, err := h.store.GetWebhook(webhookID); err != nil {
+		http.Error(w, `{"error":"Webhook not found"}`, http.StatusNotFound)
+		return
+	}
+
+	ids, err := idsParam(r)
+	if err != nil {
+		errorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	deleted, err := h.store.DeleteDeadLetters(webhookID, ids)
+	if err != nil {
+		http.Error(w, `{"error":"Failed to delete dead letters"}`, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{"deleted": deleted})
+}
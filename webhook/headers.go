@@ -0,0 +1,125 @@
+package webhook
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// reservedHeaders are set on every delivery and can't be overridden by a
+// webhook's own headers.
+var reservedHeaders = map[string]bool{
+	"Content-Type":        true,
+	"Content-Length":      true,
+	"Host":                true,
+	"User-Agent":          true,
+	"X-Webhook-Id":        true,
+	"X-Event-Id":          true,
+	"X-Webhook-Signature": true,
+}
+
+// validateHeaders checks the extra headers of a webhook. Authorization is
+// only allowed when the webhook doesn't get its token through OAuth2.
+func validateHeaders(headers map[string]string, oauth bool) error {
+	for name, value := range headers {
+		canonical := http.CanonicalHeaderKey(name)
+		if name == "" || strings.ContainsAny(name, " \t\r\n:") {
+			return fmt.Errorf("invalid header name: %q", name)
+		}
+		if strings.ContainsAny(value, "\r\n") {
+			return fmt.Errorf("invalid value for header %s", canonical)
+		}
+		if reservedHeaders[canonical] {
+			return fmt.Errorf("header %s is set by the webhook system and can't be overridden", canonical)
+		}
+		if canonical == "Authorization" && oauth {
+			return fmt.Errorf("header Authorization can't be set on a webhook with OAuth2 credentials")
+		}
+	}
+	return nil
+}
+
+// headerCipher returns the AES-GCM cipher that seals webhook headers, keyed by
+// WEBHOOK_ENCRYPTION_KEY.
+func (m *WebhookManager) headerCipher() (cipher.AEAD, error) {
+	if m.config.EncryptionKey == "" {
+		return nil, fmt.Errorf("WEBHOOK_ENCRYPTION_KEY must be set to use webhook headers")
+	}
+	key := sha256.Sum256([]byte(m.config.EncryptionKey))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// sealHeaders encrypts a webhook's headers for storage. No headers seal to
+// an empty string.
+func (m *WebhookManager) sealHeaders(headers map[string]string) (string, error) {
+	if len(headers) == 0 {
+		return "", nil
+	}
+	gcm, err := m.headerCipher()
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := json.Marshal(headers)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(gcm.Seal(nonce, nonce, plaintext, nil)), nil
+}
+
+// openHeaders decrypts headers sealed by sealHeaders.
+func (m *WebhookManager) openHeaders(sealed string) (map[string]string, error) {
+	if sealed == "" {
+		return nil, nil
+	}
+	gcm, err := m.headerCipher()
+	if err != nil {
+		return nil, err
+	}
+	data, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < gcm.NonceSize() {
+		return nil, fmt.Errorf("sealed headers are too short")
+	}
+	plaintext, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt headers (was WEBHOOK_ENCRYPTION_KEY changed?): %w", err)
+	}
+	var headers map[string]string
+	if err := json.Unmarshal(plaintext, &headers); err != nil {
+		return nil, err
+	}
+	return headers, nil
+}
+
+// headerNames returns the sorted names of a webhook's headers, for responses
+// that mustn't reveal the values.
+func (m *WebhookManager) headerNames(sealed string) []string {
+	headers, err := m.openHeaders(sealed)
+	if err != nil {
+		m.log.Printf("Warning: %v", err)
+		return nil
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, http.CanonicalHeaderKey(name))
+	}
+	sort.Strings(names)
+	return names
+}
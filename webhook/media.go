@@ -0,0 +1,252 @@
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"whatsapp-mcp/paths"
+	"whatsapp-mcp/storage"
+)
+
+// Ways a webhook can receive the media of message events, besides its metadata.
+const (
+	MediaDeliveryBase64    = "base64"    // file content in media_metadata.content
+	MediaDeliveryURL       = "url"       // signed link to GET /media/{message_id} in media_metadata.url
+	MediaDeliveryThumbnail = "thumbnail" // JPEG preview in media_metadata.thumbnail
+)
+
+// errMediaPending is the content error of media whose auto-download hasn't
+// finished yet. Deliveries don't wait for it.
+var errMediaPending = errors.New("media is still downloading")
+
+// SetMediaAccess gives the manager the media store to read files from and the
+// API key that signs media URLs. Without it, webhooks get media metadata only.
+func (m *WebhookManager) SetMediaAccess(store *storage.MediaStore, apiKey string) {
+	m.media = store
+	m.mediaKey = apiKey
+}
+
+// validateMediaDelivery checks a webhook's media delivery mode; empty means
+// metadata only.
+func (m *WebhookManager) validateMediaDelivery(mode string) error {
+	switch mode {
+	case "", MediaDeliveryBase64, MediaDeliveryThumbnail:
+		return nil
+	case MediaDeliveryURL:
+		if m.config.MediaBaseURL == "" {
+			return fmt.Errorf("WEBHOOK_MEDIA_BASE_URL must be set to deliver media URLs")
+		}
+		return nil
+	}
+	return fmt.Errorf("unsupported media delivery: %s (use %s, %s or %s)", mode, MediaDeliveryBase64, MediaDeliveryURL, MediaDeliveryThumbnail)
+}
+
+// attachMedia adds the media of a serialized message event, as base64 content,
+// signed URLs or a thumbnail. Payloads without media are returned unchanged; if the
+// content can't be included, content_error says why. It never waits for a
+// download: base64 content still downloading is replaced by signed URLs when
+// WEBHOOK_MEDIA_BASE_URL is set, and a thumbnail is the best one available.
+func (m *WebhookManager) attachMedia(jsonData []byte, mode string) ([]byte, error) {
+	if m.media == nil {
+		return jsonData, nil
+	}
+
+	var payload map[string]any
+	decoder := json.NewDecoder(bytes.NewReader(jsonData))
+	decoder.UseNumber() // keep numbers such as file sizes exact
+	if err := decoder.Decode(&payload); err != nil {
+		return nil, err
+	}
+	data, _ := payload["data"].(map[string]any)
+	media, _ := data["media_metadata"].(map[string]any)
+	messageID, _ := media["message_id"].(string)
+	if messageID == "" {
+		return jsonData, nil
+	}
+
+	switch mode {
+	case MediaDeliveryURL:
+		m.addMediaURLs(media, messageID)
+	case MediaDeliveryBase64:
+		content, err := m.mediaContent(messageID)
+		if err != nil {
+			media["content_error"] = err.Error()
+			// the links work once the download is done
+			if errors.Is(err, errMediaPending) && m.config.MediaBaseURL != "" {
+				m.addMediaURLs(media, messageID)
+			}
+		} else {
+			media["content"] = base64.StdEncoding.EncodeToString(content)
+		}
+	case MediaDeliveryThumbnail:
+		thumbnail, err := m.mediaThumbnail(messageID)
+		if err != nil {
+			media["thumbnail_error"] = err.Error()
+		} else {
+			media["thumbnail"] = base64.StdEncoding.EncodeToString(thumbnail)
+		}
+	}
+
+	return json.Marshal(payload)
+}
+
+// addMediaURLs adds signed links to a message's media and thumbnail.
+func (m *WebhookManager) addMediaURLs(media map[string]any, messageID string) {
+	expires := time.Now().Add(m.config.MediaURLTTL)
+	media["url"] = m.mediaURL(messageID, expires, "")
+	media["thumbnail_url"] = m.mediaURL(messageID, expires, "/thumbnail")
+	media["url_expires_at"] = expires.UTC()
+}
+
+// mediaContent reads a message's media file if it is downloaded. It returns
+// errMediaPending while an auto-download is in progress.
+func (m *WebhookManager) mediaContent(messageID string) ([]byte, error) {
+	meta, err := m.media.GetMediaMetadata(messageID)
+	if err != nil {
+		return nil, err
+	}
+	if meta == nil {
+		return nil, fmt.Errorf("media not found")
+	}
+
+	if meta.DownloadStatus == "pending" {
+		return nil, errMediaPending
+	}
+	if meta.DownloadStatus != "downloaded" || meta.FilePath == "" {
+		return nil, fmt.Errorf("media not downloaded (status: %s)", meta.DownloadStatus)
+	}
+	if meta.FileSize > m.config.MediaMaxBytes {
+		return nil, fmt.Errorf("media is larger than %d bytes", m.config.MediaMaxBytes)
+	}
+	return os.ReadFile(paths.GetMediaPath(meta.FilePath))
+}
+
+// mediaThumbnail returns a message's JPEG thumbnail: the one generated from
+// the file once it is downloaded, else the small preview embedded in the
+// message.
+func (m *WebhookManager) mediaThumbnail(messageID string) ([]byte, error) {
+	thumbnail, err := m.media.GetMediaThumbnail(messageID)
+	if err != nil {
+		return nil, err
+	}
+	if len(thumbnail) == 0 {
+		return nil, fmt.Errorf("media has no thumbnail")
+	}
+	return thumbnail, nil
+}
+
+// mediaURL returns a link to a message's media, or with suffix "/thumbnail" to
+// its thumbnail, that works without the API key until expires.
+func (m *WebhookManager) mediaURL(messageID string, expires time.Time, suffix string) string {
+	exp := strconv.FormatInt(expires.Unix(), 10)
+	query := url.Values{
+		"expires":   {exp},
+		"signature": {mediaSignature(m.mediaKey, messageID, exp)},
+	}
+	return m.config.MediaBaseURL + "/media/" + url.PathEscape(messageID) + suffix + "?" + query.Encode()
+}
+
+// mediaSignature signs a media URL's message ID and expiry with the API key.
+func mediaSignature(key, messageID, expires string) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(messageID + "\n" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// validMediaSignature checks the signature of a media URL and that it hasn't
+// expired.
+func validMediaSignature(key, messageID string, query url.Values) bool {
+	expires := query.Get("expires")
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > unix {
+		return false
+	}
+	expected := mediaSignature(key, messageID, expires)
+	return hmac.Equal([]byte(query.Get("signature")), []byte(expected))
+}
+
+// ServeMedia handles GET /media/{message_id} and /media/{message_id}/thumbnail,
+// serving a downloaded media file or its JPEG thumbnail to callers with the
+// API key or a signed URL from a webhook delivery.
+func (h *Handler) ServeMedia(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, `{"error":"Method not allowed"}`, http.StatusMethodNotAllowed)
+		return
+	}
+
+	messageID := strings.TrimPrefix(r.URL.Path, "/media/")
+	messageID, thumbnail := strings.CutSuffix(messageID, "/thumbnail")
+	if messageID == "" || strings.Contains(messageID, "/") {
+		http.Error(w, `{"error":"Not found"}`, http.StatusNotFound)
+		return
+	}
+
+	if !h.ValidateAuth(r) && !validMediaSignature(h.apiKey, messageID, r.URL.Query()) {
+		http.Error(w, `{"error":"Unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+
+	if h.manager.media == nil {
+		http.Error(w, `{"error":"Media not available"}`, http.StatusNotFound)
+		return
+	}
+
+	if thumbnail {
+		data, err := h.manager.media.GetMediaThumbnail(messageID)
+		if err != nil {
+			http.Error(w, `{"error":"Failed to get thumbnail"}`, http.StatusInternalServerError)
+			return
+		}
+		if len(data) == 0 {
+			http.Error(w, `{"error":"No thumbnail"}`, http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "image/jpeg")
+		w.Write(data)
+		return
+	}
+
+	meta, err := h.manager.media.GetMediaMetadata(messageID)
+	if err != nil {
+		http.Error(w, `{"error":"Failed to get media"}`, http.StatusInternalServerError)
+		return
+	}
+	if meta == nil || meta.DownloadStatus != "downloaded" || meta.FilePath == "" {
+		http.Error(w, `{"error":"Media not downloaded"}`, http.StatusNotFound)
+		return
+	}
+
+	file, err := os.Open(paths.GetMediaPath(meta.FilePath))
+	if err != nil {
+		http.Error(w, `{"error":"Media file missing"}`, http.StatusNotFound)
+		return
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		http.Error(w, `{"error":"Failed to read media"}`, http.StatusInternalServerError)
+		return
+	}
+
+	if meta.MimeType != "" {
+		w.Header().Set("Content-Type", meta.MimeType)
+	}
+	if meta.FileName != "" {
+		w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": meta.FileName}))
+	}
+	http.ServeContent(w, r, meta.FileName, info.ModTime(), file)
+}
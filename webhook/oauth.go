@@ -0,0 +1,125 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"whatsapp-mcp/storage"
+)
+
+// tokenExpiryMargin renews tokens this long before they expire, so one isn't
+// sent just as it runs out.
+const tokenExpiryMargin = 30 * time.Second
+
+// oauthToken is a cached OAuth2 access token.
+type oauthToken struct {
+	accessToken string
+	tokenType   string
+	expiresAt   time.Time // zero if the server didn't say
+}
+
+// tokenCache holds the access tokens of the webhooks that use OAuth2, by
+// webhook ID.
+type tokenCache struct {
+	mu     sync.Mutex
+	tokens map[string]oauthToken
+}
+
+// authorization returns the Authorization header for a webhook with OAuth2
+// credentials, getting a new token with the client credentials grant when
+// there is none or it is about to expire.
+func (m *WebhookManager) authorization(webhook storage.WebhookRegistration) (string, error) {
+	m.tokens.mu.Lock()
+	defer m.tokens.mu.Unlock()
+
+	token, ok := m.tokens.tokens[webhook.ID]
+	if !ok || (!token.expiresAt.IsZero() && time.Now().Add(tokenExpiryMargin).After(token.expiresAt)) {
+		var err error
+		if token, err = m.fetchToken(webhook); err != nil {
+			return "", err
+		}
+		m.tokens.tokens[webhook.ID] = token
+	}
+
+	return token.tokenType + " " + token.accessToken, nil
+}
+
+// invalidateToken drops the cached token of a webhook, after its target
+// rejected it.
+func (m *WebhookManager) invalidateToken(webhookID string) {
+	m.tokens.mu.Lock()
+	defer m.tokens.mu.Unlock()
+	delete(m.tokens.tokens, webhookID)
+}
+
+// fetchToken requests an access token with the client credentials grant
+// (RFC 6749 section 4.4), authenticating with HTTP Basic.
+func (m *WebhookManager) fetchToken(webhook storage.WebhookRegistration) (oauthToken, error) {
+	creds := webhook.OAuth
+
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(creds.Scopes) > 0 {
+		form.Set("scope", strings.Join(creds.Scopes, " "))
+	}
+
+	req, err := http.NewRequest("POST", creds.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return oauthToken{}, fmt.Errorf("failed to create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(creds.ClientID), url.QueryEscape(creds.ClientSecret))
+
+	resp, err := m.clientFor(webhook).Do(req)
+	if err != nil {
+		return oauthToken{}, fmt.Errorf("token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024*1024))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return oauthToken{}, fmt.Errorf("token request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var result struct {
+		AccessToken string `json:"access_token"`
+		TokenType   string `json:"token_type"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return oauthToken{}, fmt.Errorf("invalid token response: %w", err)
+	}
+	if result.AccessToken == "" {
+		return oauthToken{}, fmt.Errorf("token response has no access_token")
+	}
+
+	token := oauthToken{accessToken: result.AccessToken, tokenType: "Bearer"}
+	// "bearer" is common, but the header scheme is conventionally capitalized
+	if result.TokenType != "" && !strings.EqualFold(result.TokenType, "bearer") {
+		token.tokenType = result.TokenType
+	}
+	if result.ExpiresIn > 0 {
+		token.expiresAt = time.Now().Add(time.Duration(result.ExpiresIn) * time.Second)
+	}
+	return token, nil
+}
+
+// validateOAuth checks that a webhook's OAuth2 credentials are complete.
+func validateOAuth(creds *storage.OAuthCredentials) error {
+	if creds == nil {
+		return nil
+	}
+	if creds.TokenURL == "" || creds.ClientID == "" || creds.ClientSecret == "" {
+		return fmt.Errorf("oauth requires token_url, client_id and client_secret")
+	}
+	if err := validateURL(creds.TokenURL); err != nil {
+		return fmt.Errorf("invalid oauth token_url: %w", err)
+	}
+	return nil
+}
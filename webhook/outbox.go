@@ -0,0 +1,126 @@
+package webhook
+
+import (
+	"encoding/json"
+	"time"
+
+	"whatsapp-mcp/storage"
+)
+
+// outboxPollInterval is how often idle workers look for deliveries whose
+// retry backoff is over, or that they weren't woken up for.
+const outboxPollInterval = time.Second
+
+// enqueue persists a delivery in the outbox and wakes a worker to deliver it.
+// Deliveries that can't be persisted are dropped.
+func (m *WebhookManager) enqueue(task *deliveryTask) {
+	payload, err := json.Marshal(task.payload)
+	if err == nil {
+		err = m.store.AddToOutbox(storage.OutboxEntry{
+			WebhookID: task.webhook.ID,
+			Payload:   payload,
+			Attempt:   task.attempt,
+			CreatedAt: time.Now(),
+		})
+	}
+	if err != nil {
+		m.log.Printf("Warning: Failed to add payload %s to the outbox, dropping it: %v", task.payload.ID, err)
+		m.notifyQueueFull(task.webhook.ID)
+		return
+	}
+
+	select {
+	case m.wake <- struct{}{}:
+	default: // every worker already has a wakeup pending
+	}
+}
+
+// worker delivers outbox deliveries until the manager stops, waiting for a
+// wakeup or the next poll when there are none.
+func (m *WebhookManager) worker(id int) {
+	defer m.wg.Done()
+
+	m.log.Printf("Worker %d started", id)
+
+	for {
+		for m.ctx.Err() == nil && m.deliverNext(id) {
+		}
+
+		select {
+		case <-m.wake:
+		case <-time.After(outboxPollInterval):
+		case <-m.ctx.Done():
+			return
+		}
+	}
+}
+
+// deliverNext claims the next outbox delivery and delivers it. A failed
+// delivery goes back to the outbox until its backoff is over, or to the dead
+// letters once its retries are exhausted. Deliveries of webhooks that were
+// deleted or disabled are discarded. It reports whether there was one.
+func (m *WebhookManager) deliverNext(workerID int) bool {
+	entry, err := m.store.ClaimOutbox(time.Now())
+	if err != nil {
+		m.log.Printf("Warning: Failed to read the webhook outbox: %v", err)
+		return false
+	}
+	if entry == nil {
+		return false
+	}
+
+	webhook, _ := m.store.GetWebhook(entry.WebhookID)
+	payload, err := decodePayload(entry.Payload)
+	if err != nil {
+		m.log.Printf("Warning: Discarding invalid outbox entry %d: %v", entry.ID, err)
+	}
+	if webhook == nil || !webhook.Active || err != nil {
+		m.removeFromOutbox(entry.ID)
+		return true
+	}
+
+	m.log.Printf("Worker %d processing webhook %s", workerID, webhook.ID)
+	task := &deliveryTask{webhook: *webhook, payload: payload, attempt: entry.Attempt}
+	if err := m.deliverWebhook(task.webhook, task.payload, task.attempt); err != nil {
+		// Retry after the backoff if attempts remain; the webhook's later
+		// events wait behind this one
+		if task.attempt < m.config.MaxRetries && task.attempt < len(m.config.RetryBackoff) {
+			retryAt := time.Now().Add(m.config.RetryBackoff[task.attempt])
+			if err := m.store.RetryOutbox(entry.ID, task.attempt+1, retryAt); err != nil {
+				m.log.Printf("Warning: %v", err)
+			}
+			return true
+		}
+		m.deadLetter(task, err)
+	}
+
+	m.removeFromOutbox(entry.ID)
+	return true
+}
+
+// removeFromOutbox deletes a finished delivery from the outbox.
+func (m *WebhookManager) removeFromOutbox(id int64) {
+	if err := m.store.RemoveFromOutbox(id); err != nil {
+		m.log.Printf("Warning: %v", err)
+	}
+}
+
+// decodePayload decodes a payload stored as JSON, keeping its data as is.
+func decodePayload(data []byte) (WebhookPayload, error) {
+	var stored struct {
+		ID        string          `json:"id"`
+		EventType string          `json:"event_type"`
+		Timestamp time.Time       `json:"timestamp"`
+		Data      json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return WebhookPayload{}, err
+	}
+
+	return WebhookPayload{
+		ID:        stored.ID,
+		EventType: stored.EventType,
+		Timestamp: stored.Timestamp,
+		Data:      stored.Data,
+	}, nil
+}
@@ -0,0 +1,117 @@
+package webhook
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	// publishDropReportInterval is how often dropped events are reported.
+	publishDropReportInterval = time.Minute
+
+	// publishMaxRetryWait caps the wait between attempts to send events a
+	// broker didn't take.
+	publishMaxRetryWait = 30 * time.Second
+)
+
+// PublishQueue buffers events for a Publisher that sends them to a broker in
+// the background, so Publish never blocks. Events that don't fit are dropped,
+// and the drops are logged at most once a minute. The broker, e.g. "NATS",
+// names it in log messages.
+type PublishQueue struct {
+	broker string
+	log    Logger
+	events chan WebhookPayload
+
+	dropMu      sync.Mutex
+	dropped     int
+	lastDropLog time.Time
+}
+
+// NewPublishQueue creates a queue of up to size events.
+func NewPublishQueue(broker string, size int, logger Logger) *PublishQueue {
+	return &PublishQueue{
+		broker: broker,
+		log:    logger,
+		events: make(chan WebhookPayload, size),
+	}
+}
+
+// Push queues an event without blocking, dropping it if the queue is full.
+func (q *PublishQueue) Push(payload WebhookPayload) {
+	select {
+	case q.events <- payload:
+	default:
+		q.reportDrop()
+	}
+}
+
+// reportDrop counts an event dropped because the queue was full, logging the
+// count at most once a minute.
+func (q *PublishQueue) reportDrop() {
+	q.dropMu.Lock()
+	defer q.dropMu.Unlock()
+
+	q.dropped++
+	if time.Since(q.lastDropLog) < publishDropReportInterval {
+		return
+	}
+	q.log.Printf("Warning: %s queue full, dropped %d events", q.broker, q.dropped)
+	q.dropped = 0
+	q.lastDropLog = time.Now()
+}
+
+// Run hands queued events to send, up to maxBatch at a time, until ctx is
+// done. send returns the events the broker didn't take with the reason; they
+// are retried, waiting from one second up to 30 seconds between attempts, and
+// ahead of newer events. Events send can never deliver, such as ones the
+// broker rejects, should be logged and left out of what it returns. What is
+// left when ctx is done is counted in a warning.
+func (q *PublishQueue) Run(ctx context.Context, maxBatch int, send func(batch []WebhookPayload) ([]WebhookPayload, error)) {
+	var pending []WebhookPayload
+	wait := time.Second
+
+	for {
+		if len(pending) == 0 {
+			select {
+			case <-ctx.Done():
+				q.warnUnsent(0)
+				return
+			case payload := <-q.events:
+				pending = append(pending, payload)
+			}
+		}
+		// take what else is waiting, up to a batch
+		for len(pending) < maxBatch && len(q.events) > 0 {
+			pending = append(pending, <-q.events)
+		}
+
+		failed, err := send(pending)
+		if err == nil || len(failed) == 0 {
+			pending = nil
+			wait = time.Second
+			continue
+		}
+		pending = failed
+
+		q.log.Printf("Failed to publish %d events to %s, retrying in %s: %v", len(pending), q.broker, wait, err)
+		select {
+		case <-ctx.Done():
+			q.warnUnsent(len(pending))
+			return
+		case <-time.After(wait):
+		}
+		if wait *= 2; wait > publishMaxRetryWait {
+			wait = publishMaxRetryWait
+		}
+	}
+}
+
+// warnUnsent logs how many events were never sent, the queued ones plus
+// pending taken from the queue.
+func (q *PublishQueue) warnUnsent(pending int) {
+	if n := len(q.events) + pending; n > 0 {
+		q.log.Printf("Warning: %d events were not published to %s", n, q.broker)
+	}
+}
@@ -0,0 +1,170 @@
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"whatsapp-mcp/storage"
+
+	"github.com/google/uuid"
+)
+
+// errWebhookNotFound is returned for an unknown webhook ID.
+var errWebhookNotFound = errors.New("webhook not found")
+
+// invalidRequestError is a registration request the caller has to fix, as
+// opposed to a storage failure.
+type invalidRequestError struct {
+	err error
+}
+
+func (e invalidRequestError) Error() string { return e.err.Error() }
+func (e invalidRequestError) Unwrap() error { return e.err }
+
+// invalid marks err as a problem with the request.
+func invalid(err error) error {
+	return invalidRequestError{err: err}
+}
+
+// isInvalidRequest reports whether err is a problem with the request.
+func isInvalidRequest(err error) bool {
+	var target invalidRequestError
+	return errors.As(err, &target)
+}
+
+// Register validates a registration request and stores the new webhook. It
+// backs both POST /api/webhooks and the register_webhook MCP tool.
+func (m *WebhookManager) Register(req CreateWebhookRequest) (*WebhookResponse, error) {
+	if req.URL == "" {
+		return nil, invalid(errors.New("URL is required"))
+	}
+
+	// Validate URL format and prevent SSRF
+	if err := validateURL(req.URL); err != nil {
+		return nil, invalid(fmt.Errorf("Invalid URL: %w", err))
+	}
+
+	if len(req.EventTypes) == 0 {
+		req.EventTypes = []string{"message"} // default
+	}
+
+	if err := validateEventTypes(req.EventTypes); err != nil {
+		return nil, invalid(err)
+	}
+	if err := validateFields(req.Fields); err != nil {
+		return nil, invalid(err)
+	}
+	if err := validateClientCertificate(req.TLSCert, req.TLSKey); err != nil {
+		return nil, invalid(err)
+	}
+	if err := validateOAuth(req.OAuth.oauthCredentials()); err != nil {
+		return nil, invalid(err)
+	}
+	if err := validateHeaders(req.Headers, req.OAuth.oauthCredentials() != nil); err != nil {
+		return nil, invalid(err)
+	}
+	headers, err := m.sealHeaders(req.Headers)
+	if err != nil {
+		return nil, invalid(err)
+	}
+	if err := validatePayloadFormat(req.Format); err != nil {
+		return nil, invalid(err)
+	}
+	if err := m.validateMediaDelivery(req.Media); err != nil {
+		return nil, invalid(err)
+	}
+
+	webhook := storage.WebhookRegistration{
+		ID:         uuid.New().String(),
+		URL:        req.URL,
+		Secret:     req.Secret,
+		EventTypes: req.EventTypes,
+		Fields:     req.Fields,
+		TLSCert:    req.TLSCert,
+		TLSKey:     req.TLSKey,
+		OAuth:      req.OAuth.oauthCredentials(),
+		Headers:    headers,
+		Format:     storedPayloadFormat(req.Format),
+		Media:      req.Media,
+		Active:     true,
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+	}
+
+	if err := m.store.CreateWebhook(webhook); err != nil {
+		return nil, fmt.Errorf("failed to create webhook: %w", err)
+	}
+
+	resp := m.response(webhook)
+	return &resp, nil
+}
+
+// Webhooks returns all registered webhooks, including inactive ones.
+func (m *WebhookManager) Webhooks() ([]WebhookResponse, error) {
+	webhooks, err := m.store.ListWebhooks(false) // include inactive
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhooks: %w", err)
+	}
+
+	var resp []WebhookResponse
+	for _, wh := range webhooks {
+		resp = append(resp, m.response(wh))
+	}
+	return resp, nil
+}
+
+// Unregister deletes a webhook.
+func (m *WebhookManager) Unregister(webhookID string) error {
+	if err := m.store.DeleteWebhook(webhookID); err != nil {
+		return errWebhookNotFound
+	}
+	return nil
+}
+
+// SendTestEvent delivers a sample message.received event to a webhook right
+// away, without retries, and returns the ID of the test payload.
+func (m *WebhookManager) SendTestEvent(webhookID string) (string, error) {
+	webhook, err := m.store.GetWebhook(webhookID)
+	if err != nil {
+		return "", errWebhookNotFound
+	}
+
+	testPayload := WebhookPayload{
+		ID:        uuid.New().String(),
+		EventType: "message.received",
+		Timestamp: time.Now(),
+		Data: MessageEventData{
+			MessageID:   "TEST-" + uuid.New().String(),
+			ChatJID:     "test@s.whatsapp.net",
+			SenderJID:   "test@s.whatsapp.net",
+			Text:        "This is a test message from WhatsApp MCP webhook system",
+			Timestamp:   time.Now(),
+			IsFromMe:    false,
+			MessageType: "text",
+			ChatName:    "Test Chat",
+			IsGroup:     false,
+		},
+	}
+
+	return testPayload.ID, m.TestDelivery(*webhook, testPayload)
+}
+
+// response converts a stored webhook for API responses, without its secrets.
+func (m *WebhookManager) response(webhook storage.WebhookRegistration) WebhookResponse {
+	return WebhookResponse{
+		ID:         webhook.ID,
+		URL:        webhook.URL,
+		EventTypes: webhook.EventTypes,
+		Fields:     webhook.Fields,
+		TLSCert:    webhook.TLSCert,
+		TLSKey:     webhook.TLSKey,
+		OAuth:      oauthResponse(webhook.OAuth),
+		Headers:    m.headerNames(webhook.Headers),
+		Format:     payloadFormat(webhook.Format),
+		Media:      webhook.Media,
+		Active:     webhook.Active,
+		CreatedAt:  webhook.CreatedAt,
+		UpdatedAt:  webhook.UpdatedAt,
+	}
+}
@@ -0,0 +1,58 @@
+package webhook
+
+import (
+	"errors"
+	"time"
+
+	"whatsapp-mcp/storage"
+)
+
+// SetMessageStore gives the manager the message store that Replay reads
+// stored messages from.
+func (m *WebhookManager) SetMessageStore(store *storage.MessageStore) {
+	m.messages = store
+}
+
+// Replay queues the messages stored between from and to (inclusive), oldest
+// first, as message.received and message.sent events for one webhook, and
+// returns how many were queued. Only webhooks subscribed to "message" events
+// get them; messages deleted for everyone are skipped. Replayed events carry
+// new event IDs, so consumers should deduplicate on data.message_id. Other
+// webhooks, the /ws stream and the publishers don't see them.
+func (m *WebhookManager) Replay(webhookID string, from, to time.Time) (int, error) {
+	if m.messages == nil {
+		return 0, errors.New("message store not configured")
+	}
+
+	webhook, err := m.store.GetWebhook(webhookID)
+	if err != nil {
+		return 0, errWebhookNotFound
+	}
+	if !to.After(from) {
+		return 0, invalid(errors.New("to must be after from"))
+	}
+	if !webhook.Active {
+		return 0, invalid(errors.New("webhook is inactive"))
+	}
+	if !contains(webhook.EventTypes, "message") {
+		return 0, invalid(errors.New("webhook is not subscribed to message events"))
+	}
+
+	// the filter bounds are exclusive and timestamps are stored in seconds
+	after := from.Truncate(time.Second).Add(-time.Second)
+	before := to.Truncate(time.Second).Add(time.Second)
+
+	queued := 0
+	err = m.messages.ForEachMessageWithNames(m.ctx, storage.SearchFilter{After: &after, Before: &before}, func(msg storage.MessageWithNames) error {
+		eventType := "message.received"
+		if msg.IsFromMe {
+			eventType = "message.sent"
+		}
+
+		m.enqueue(&deliveryTask{webhook: *webhook, payload: newPayload(eventType, buildMessageData(msg)), attempt: 1})
+		queued++
+		return nil
+	})
+
+	return queued, err
+}
@@ -0,0 +1,230 @@
+package webhook
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+)
+
+const (
+	// streamSubscriberBuffer is how many events a WebSocket client can fall
+	// behind before it's disconnected. It can reconnect with ?since= to catch up.
+	streamSubscriberBuffer = 256
+
+	// streamPingInterval keeps idle connections alive through proxies.
+	streamPingInterval = 30 * time.Second
+
+	// streamWriteTimeout is how long writing one event may take.
+	streamWriteTimeout = 10 * time.Second
+)
+
+// streamEvent is an event sent to WebSocket clients: a webhook payload with
+// its sequence number in the stream.
+type streamEvent struct {
+	Seq uint64 `json:"seq"`
+	WebhookPayload
+
+	topic string // event type webhooks subscribe to, "message" for new messages
+}
+
+// streamFilter selects the events a WebSocket client receives.
+type streamFilter struct {
+	eventTypes []string // empty = all
+	chats      []string // chat or group JIDs, empty = all
+}
+
+// matches reports whether an event passes the filter.
+func (f streamFilter) matches(event streamEvent) bool {
+	if len(f.eventTypes) > 0 && !contains(f.eventTypes, event.topic) && !contains(f.eventTypes, event.EventType) {
+		return false
+	}
+	if len(f.chats) > 0 && !contains(f.chats, event.ChatJID()) {
+		return false
+	}
+	return true
+}
+
+// streamSubscriber is a connected WebSocket client.
+type streamSubscriber struct {
+	filter streamFilter
+	events chan streamEvent // closed when the client is disconnected
+}
+
+// eventStream fans events out to WebSocket clients and keeps the latest ones
+// for clients that reconnect.
+type eventStream struct {
+	mu          sync.Mutex
+	seq         uint64
+	recent      []streamEvent // oldest first, at most size
+	size        int
+	subscribers map[*streamSubscriber]struct{}
+}
+
+// newEventStream creates an event stream that keeps the last size events.
+func newEventStream(size int) *eventStream {
+	return &eventStream{
+		size:        size,
+		subscribers: make(map[*streamSubscriber]struct{}),
+	}
+}
+
+// publish numbers an event and sends it to the subscribers it matches.
+// Subscribers that can't keep up are dropped.
+func (s *eventStream) publish(topic string, payload WebhookPayload) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.seq++
+	event := streamEvent{Seq: s.seq, WebhookPayload: payload, topic: topic}
+	if s.size > 0 {
+		s.recent = append(s.recent, event)
+		if len(s.recent) > s.size {
+			s.recent = s.recent[len(s.recent)-s.size:]
+		}
+	}
+
+	for sub := range s.subscribers {
+		if !sub.filter.matches(event) {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+			delete(s.subscribers, sub)
+			close(sub.events)
+		}
+	}
+}
+
+// subscribe adds a subscriber and returns it with the kept events after
+// since that match its filter. Events older than the ones kept are lost.
+func (s *eventStream) subscribe(filter streamFilter, since uint64) (*streamSubscriber, []streamEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var backlog []streamEvent
+	for _, event := range s.recent {
+		if event.Seq > since && filter.matches(event) {
+			backlog = append(backlog, event)
+		}
+	}
+
+	sub := &streamSubscriber{filter: filter, events: make(chan streamEvent, streamSubscriberBuffer)}
+	s.subscribers[sub] = struct{}{}
+	return sub, backlog
+}
+
+// unsubscribe removes a subscriber, if it's still there.
+func (s *eventStream) unsubscribe(sub *streamSubscriber) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.subscribers[sub]; ok {
+		delete(s.subscribers, sub)
+		close(sub.events)
+	}
+}
+
+// closeAll disconnects every subscriber, on shutdown.
+func (s *eventStream) closeAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for sub := range s.subscribers {
+		delete(s.subscribers, sub)
+		close(sub.events)
+	}
+}
+
+// ServeStream handles GET /ws, streaming events to a WebSocket client.
+// Query parameters:
+//   - event_types: comma-separated event types, as for webhooks (default: all)
+//   - chats: comma-separated chat or group JIDs (default: all)
+//   - since: replay the kept events after this sequence number first
+//   - api_key: the API key, for clients that can't set the Authorization header
+func (h *Handler) ServeStream(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if !h.ValidateAuthOrKey(r) {
+		http.Error(w, `{"error":"Unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+
+	var filter streamFilter
+	if eventTypes := query.Get("event_types"); eventTypes != "" {
+		filter.eventTypes = strings.Split(eventTypes, ",")
+		if err := validateEventTypes(filter.eventTypes); err != nil {
+			errorResponse(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	if chats := query.Get("chats"); chats != "" {
+		filter.chats = strings.Split(chats, ",")
+	}
+
+	var since uint64
+	if s := query.Get("since"); s != "" {
+		var err error
+		if since, err = strconv.ParseUint(s, 10, 64); err != nil {
+			errorResponse(w, "Invalid since: "+s, http.StatusBadRequest)
+			return
+		}
+	}
+
+	conn, err := websocket.Accept(w, r, &websocket.AcceptOptions{
+		OriginPatterns: h.manager.config.StreamOrigins,
+	})
+	if err != nil {
+		return // Accept has written the error response
+	}
+	defer conn.CloseNow()
+
+	// clients don't send anything; this handles pings and notices disconnects
+	ctx := conn.CloseRead(r.Context())
+
+	sub, backlog := h.manager.stream.subscribe(filter, since)
+	defer h.manager.stream.unsubscribe(sub)
+
+	for _, event := range backlog {
+		if err := writeStreamEvent(ctx, conn, event); err != nil {
+			return
+		}
+	}
+
+	ping := time.NewTicker(streamPingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-sub.events:
+			if !ok {
+				conn.Close(websocket.StatusTryAgainLater, "event stream closed or client too slow, reconnect with since")
+				return
+			}
+			if err := writeStreamEvent(ctx, conn, event); err != nil {
+				return
+			}
+		case <-ping.C:
+			pingCtx, cancel := context.WithTimeout(ctx, streamWriteTimeout)
+			err := conn.Ping(pingCtx)
+			cancel()
+			if err != nil {
+				return
+			}
+		}
+	}
+}
+
+// writeStreamEvent sends one event to a WebSocket client.
+func writeStreamEvent(ctx context.Context, conn *websocket.Conn, event streamEvent) error {
+	ctx, cancel := context.WithTimeout(ctx, streamWriteTimeout)
+	defer cancel()
+	return wsjson.Write(ctx, conn, event)
+}
@@ -0,0 +1,100 @@
+package webhook
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"whatsapp-mcp/storage"
+)
+
+// newHTTPClient creates a delivery client that presents the key pair in
+// certFile and keyFile, if set, and trusts roots in addition to the system CAs.
+func newHTTPClient(timeout time.Duration, certFile, keyFile string, roots *x509.CertPool) *http.Client {
+	tlsConfig := &tls.Config{RootCAs: roots}
+	if certFile != "" {
+		tlsConfig.GetClientCertificate = clientCertificate(certFile, keyFile)
+	}
+
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			MaxIdleConns:        100,
+			MaxIdleConnsPerHost: 10,
+			IdleConnTimeout:     90 * time.Second,
+			TLSClientConfig:     tlsConfig,
+		},
+	}
+}
+
+// clientCertificate loads the key pair on every handshake, so a renewed
+// certificate is used without a restart.
+func clientCertificate(certFile, keyFile string) func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	return func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		return &cert, nil
+	}
+}
+
+// loadRoots returns the system CAs plus the PEM certificates in caFile, or
+// nil to use the system CAs only when caFile is empty.
+func loadRoots(caFile string) (*x509.CertPool, error) {
+	if caFile == "" {
+		return nil, nil
+	}
+
+	pem, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, err
+	}
+
+	roots, err := x509.SystemCertPool()
+	if err != nil {
+		roots = x509.NewCertPool()
+	}
+	if !roots.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in %s", caFile)
+	}
+	return roots, nil
+}
+
+// validateClientCertificate checks that a webhook's certificate and key are
+// set together and can be loaded.
+func validateClientCertificate(certFile, keyFile string) error {
+	if certFile == "" && keyFile == "" {
+		return nil
+	}
+	if certFile == "" || keyFile == "" {
+		return fmt.Errorf("tls_cert_file and tls_key_file must be set together")
+	}
+	if _, err := tls.LoadX509KeyPair(certFile, keyFile); err != nil {
+		return fmt.Errorf("invalid client certificate: %w", err)
+	}
+	return nil
+}
+
+// clientFor returns the HTTP client used to deliver to a webhook: the shared
+// one, or one presenting the webhook's own client certificate.
+func (m *WebhookManager) clientFor(webhook storage.WebhookRegistration) *http.Client {
+	if webhook.TLSCert == "" {
+		return m.httpClient
+	}
+
+	key := webhook.TLSCert + "\x00" + webhook.TLSKey
+
+	m.clientsMu.Lock()
+	defer m.clientsMu.Unlock()
+
+	client, ok := m.clients[key]
+	if !ok {
+		client = newHTTPClient(m.config.DeliveryTimeout, webhook.TLSCert, webhook.TLSKey, m.roots)
+		m.clients[key] = client
+	}
+	return client
+}
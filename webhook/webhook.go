@@ -2,6 +2,9 @@ package webhook
 
 import (
 	"context"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
 	"net/http"
 	"strings"
 	"sync"
@@ -14,10 +17,10 @@ import (
 
 // WebhookPayload represents the JSON structure sent to webhook endpoints.
 type WebhookPayload struct {
-	ID        string           `json:"id"`         // Event UUID
-	EventType string           `json:"event_type"` // "message.received" or "message.sent"
-	Timestamp time.Time        `json:"timestamp"`
-	Data      MessageEventData `json:"data"`
+	ID        string    `json:"id"`         // Event UUID
+	EventType string    `json:"event_type"` // "message.received", "message.sent", "message.edited", "message.deleted", "message.reaction", "message.poll_vote", "message.delivered", "message.read" or one of the "group." and "call." events
+	Timestamp time.Time `json:"timestamp"`
+	Data      any       `json:"data"` // MessageEventData, GroupEventData for "group." events or CallEventData for "call." events
 }
 
 // ReferralInfo holds Click-to-WhatsApp (CTWA) ad referral metadata.
@@ -44,6 +47,63 @@ type MessageEventData struct {
 	IsGroup           bool            `json:"is_group"`
 	MediaMetadata     *MediaReference `json:"media_metadata,omitempty"`
 	Referral          *ReferralInfo   `json:"referral,omitempty"`
+	EditedAt          *time.Time      `json:"edited_at,omitempty"`  // message.edited and later events of an edited message
+	DeletedAt         *time.Time      `json:"deleted_at,omitempty"` // message.deleted
+	Reaction          *ReactionInfo   `json:"reaction,omitempty"`   // message.reaction
+	PollVote          *PollVoteInfo   `json:"poll_vote,omitempty"`  // message.poll_vote
+	Receipt           *ReceiptInfo    `json:"receipt,omitempty"`    // message.delivered and message.read
+}
+
+// ReactionInfo describes a reaction to the message of a message.reaction event.
+type ReactionInfo struct {
+	SenderJID string    `json:"sender_jid"`
+	Emoji     string    `json:"emoji"` // empty when the reaction was removed
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// PollVoteInfo describes a vote in the poll of a message.poll_vote event,
+// with the poll's results after it.
+type PollVoteInfo struct {
+	VoterJID  string             `json:"voter_jid"`
+	Options   []string           `json:"options"` // selected options, empty when the vote was removed
+	Timestamp time.Time          `json:"timestamp"`
+	Question  string             `json:"question"`
+	Results   []PollOptionResult `json:"results"` // in the poll's order
+	Voters    int                `json:"voters"`  // voters with a current vote
+}
+
+// PollOptionResult is the number of votes for one option of a poll.
+type PollOptionResult struct {
+	Option string   `json:"option"`
+	Votes  int      `json:"votes"`
+	Voters []string `json:"voters,omitempty"`
+}
+
+// ReceiptInfo describes who a message of a message.delivered or message.read
+// event reached.
+type ReceiptInfo struct {
+	ParticipantJID string    `json:"participant_jid"`
+	Status         string    `json:"status"` // delivered, read or played (voice notes and videos)
+	Timestamp      time.Time `json:"timestamp"`
+}
+
+// GroupEventData contains the group event details.
+type GroupEventData struct {
+	GroupJID     string    `json:"group_jid"`
+	GroupName    string    `json:"group_name,omitempty"`
+	Participants []string  `json:"participants,omitempty"` // added or removed participants, or the members of a new group
+	ActorJID     string    `json:"actor_jid,omitempty"`    // who made the change, if known
+	Timestamp    time.Time `json:"timestamp"`
+}
+
+// CallEventData contains the call event details.
+type CallEventData struct {
+	CallID    string    `json:"call_id"`
+	CallerJID string    `json:"caller_jid"`
+	ChatJID   string    `json:"chat_jid"` // the caller, or the group of a group call
+	IsVideo   bool      `json:"is_video"`
+	IsGroup   bool      `json:"is_group"`
+	Timestamp time.Time `json:"timestamp"` // when the call started ringing
 }
 
 // MediaReference contains metadata about media attachments.
@@ -70,42 +130,106 @@ type Logger interface {
 
 // WebhookManager manages webhook deliveries with retry logic.
 type WebhookManager struct {
-	store        *storage.WebhookStore
-	config       *Config
-	deliveryChan chan *deliveryTask
-	httpClient   *http.Client
-	ctx          context.Context
-	cancel       context.CancelFunc
-	wg           sync.WaitGroup
-	log          Logger
+	store      *storage.WebhookStore
+	config     *Config
+	wake       chan struct{} // tells idle workers the outbox has deliveries
+	httpClient *http.Client
+	roots      *x509.CertPool // extra CAs trusted for webhook servers (nil = system CAs)
+	clientsMu  sync.Mutex
+	clients    map[string]*http.Client // per client certificate, see clientFor
+	tokens     tokenCache              // OAuth2 access tokens, see authorization
+	media      *storage.MediaStore     // optional, see SetMediaAccess
+	mediaKey   string                  // signs media URLs
+	messages   *storage.MessageStore   // optional, see SetMessageStore
+	stream     *eventStream            // events for /ws clients
+	publishers []Publisher             // see AddPublisher
+	ctx        context.Context
+	cancel     context.CancelFunc
+	wg         sync.WaitGroup
+	log        Logger
+
+	queueFullMu      sync.Mutex
+	onQueueFull      func(webhookID string) // optional, see SetQueueFullHandler
+	lastQueueFullLog time.Time
 }
 
+// queueFullReportInterval limits how often a full delivery queue is reported.
+const queueFullReportInterval = time.Minute
+
 // NewWebhookManager creates a new webhook manager.
 func NewWebhookManager(store *storage.WebhookStore, config *Config, logger Logger) *WebhookManager {
 	ctx, cancel := context.WithCancel(context.Background())
 
-	httpClient := &http.Client{
-		Timeout: config.DeliveryTimeout,
-		Transport: &http.Transport{
-			MaxIdleConns:        100,
-			MaxIdleConnsPerHost: 10,
-			IdleConnTimeout:     90 * time.Second,
-		},
+	roots, err := loadRoots(config.TLSCAFile)
+	if err != nil {
+		logger.Printf("Warning: Failed to load WEBHOOK_TLS_CA_FILE, using the system CAs only: %v", err)
+	}
+	if config.TLSCertFile != "" && config.TLSKeyFile == "" {
+		logger.Printf("Warning: WEBHOOK_TLS_CERT_FILE is set without WEBHOOK_TLS_KEY_FILE")
 	}
 
+	// the global client certificate, if any, is used by webhooks without their own
+	httpClient := newHTTPClient(config.DeliveryTimeout, config.TLSCertFile, config.TLSKeyFile, roots)
+
 	return &WebhookManager{
-		store:        store,
-		config:       config,
-		deliveryChan: make(chan *deliveryTask, config.ChannelBufferSize),
-		httpClient:   httpClient,
-		ctx:          ctx,
-		cancel:       cancel,
-		log:          logger,
+		store:      store,
+		config:     config,
+		wake:       make(chan struct{}, config.WorkerPoolSize),
+		httpClient: httpClient,
+		roots:      roots,
+		clients:    make(map[string]*http.Client),
+		tokens:     tokenCache{tokens: make(map[string]oauthToken)},
+		stream:     newEventStream(config.StreamReplaySize),
+		ctx:        ctx,
+		cancel:     cancel,
+		log:        logger,
 	}
 }
 
-// Start launches the webhook delivery workers.
+// Publisher receives every event the manager emits, alongside webhooks, to
+// mirror them to a message broker. Publish must not block.
+type Publisher interface {
+	Publish(payload WebhookPayload)
+}
+
+// AddPublisher registers a publisher for all events. It must be called before
+// the WhatsApp client starts emitting events.
+func (m *WebhookManager) AddPublisher(p Publisher) {
+	m.publishers = append(m.publishers, p)
+}
+
+// SetQueueFullHandler registers fn to be called when events are dropped because
+// they couldn't be saved to the outbox. It is called at most once per minute.
+func (m *WebhookManager) SetQueueFullHandler(fn func(webhookID string)) {
+	m.queueFullMu.Lock()
+	defer m.queueFullMu.Unlock()
+	m.onQueueFull = fn
+}
+
+// notifyQueueFull reports a dropped event to the queue full handler, if any.
+func (m *WebhookManager) notifyQueueFull(webhookID string) {
+	m.queueFullMu.Lock()
+	fn := m.onQueueFull
+	if fn == nil || time.Since(m.lastQueueFullLog) < queueFullReportInterval {
+		m.queueFullMu.Unlock()
+		return
+	}
+	m.lastQueueFullLog = time.Now()
+	m.queueFullMu.Unlock()
+
+	fn(webhookID)
+}
+
+// Start launches the webhook delivery workers, which deliver what is left in
+// the outbox from the last run first.
 func (m *WebhookManager) Start() {
+	// deliveries claimed when the last run stopped or crashed weren't finished
+	if n, err := m.store.ReleaseOutboxClaims(); err != nil {
+		m.log.Printf("Warning: %v", err)
+	} else if n > 0 {
+		m.log.Printf("Retrying %d webhook deliveries interrupted by the last shutdown", n)
+	}
+
 	for i := 0; i < m.config.WorkerPoolSize; i++ {
 		m.wg.Add(1)
 		go m.worker(i)
@@ -117,9 +241,7 @@ func (m *WebhookManager) Start() {
 func (m *WebhookManager) Stop() {
 	m.log.Println("Stopping webhook manager...")
 	m.cancel() // Signal workers to stop
-
-	// Close the delivery channel to signal no more tasks will be sent
-	close(m.deliveryChan)
+	m.stream.closeAll()
 
 	// Wait for workers to finish current tasks (with timeout)
 	done := make(chan struct{})
@@ -128,6 +250,7 @@ func (m *WebhookManager) Stop() {
 		close(done)
 	}()
 
+	// Whatever is still in the outbox is delivered after the next start
 	select {
 	case <-done:
 		m.log.Println("All webhook workers stopped gracefully")
@@ -136,47 +259,182 @@ func (m *WebhookManager) Stop() {
 	}
 }
 
+// Event types of changes to a stored message. Unlike new messages, which
+// webhooks receive by subscribing to "message", each of them must be listed
+// in a webhook's event types.
+const (
+	EventMessageEdited   = "message.edited"
+	EventMessageDeleted  = "message.deleted"
+	EventMessageReaction = "message.reaction"
+	EventMessagePollVote = "message.poll_vote"
+
+	EventMessageDelivered = "message.delivered"
+	EventMessageRead      = "message.read"
+)
+
+// Event types of group changes, which webhooks receive only if they list them.
+const (
+	EventGroupCreated            = "group.created"
+	EventGroupParticipantAdded   = "group.participant_added"
+	EventGroupParticipantRemoved = "group.participant_removed"
+	EventGroupSubjectChanged     = "group.subject_changed"
+)
+
+// Event types of incoming calls, which webhooks receive only if they list them.
+const (
+	EventCallIncoming = "call.incoming"
+	EventCallMissed   = "call.missed"
+)
+
 // EmitMessageEvent emits a message event to all registered webhooks.
 func (m *WebhookManager) EmitMessageEvent(msg storage.MessageWithNames) error {
+	eventType := "message.received"
+	if msg.IsFromMe {
+		eventType = "message.sent"
+	}
+
+	return m.emit("message", newPayload(eventType, buildMessageData(msg)))
+}
+
+// EmitMessageUpdateEvent emits an edit, deletion or reaction (one of the
+// EventMessage* types) to the webhooks subscribed to it. msg is the message
+// that changed; reaction is only set for message.reaction.
+func (m *WebhookManager) EmitMessageUpdateEvent(eventType string, msg storage.MessageWithNames, reaction *storage.Reaction) error {
+	data := buildMessageData(msg)
+	if reaction != nil {
+		data.Reaction = &ReactionInfo{
+			SenderJID: reaction.SenderJID,
+			Emoji:     reaction.Emoji,
+			Timestamp: reaction.Timestamp,
+		}
+	}
+
+	return m.emit(eventType, newPayload(eventType, data))
+}
+
+// EmitPollVoteEvent emits a vote in a poll (EventMessagePollVote) to the
+// webhooks subscribed to it. msg is the poll message.
+func (m *WebhookManager) EmitPollVoteEvent(msg storage.MessageWithNames, vote storage.PollVote, results storage.PollResults) error {
+	data := buildMessageData(msg)
+	data.PollVote = &PollVoteInfo{
+		VoterJID:  vote.VoterJID,
+		Options:   vote.Options,
+		Timestamp: vote.Timestamp,
+		Question:  results.Poll.Question,
+		Voters:    results.Voters,
+	}
+	for _, count := range results.Counts {
+		data.PollVote.Results = append(data.PollVote.Results, PollOptionResult{
+			Option: count.Option,
+			Votes:  count.Votes,
+			Voters: count.Voters,
+		})
+	}
+
+	return m.emit(EventMessagePollVote, newPayload(EventMessagePollVote, data))
+}
+
+// EmitReceiptEvent emits a delivery or read receipt for one of my messages
+// (EventMessageDelivered or EventMessageRead) to the webhooks subscribed to it.
+func (m *WebhookManager) EmitReceiptEvent(eventType string, msg storage.MessageWithNames, receipt storage.Receipt) error {
+	data := buildMessageData(msg)
+	data.Receipt = &ReceiptInfo{
+		ParticipantJID: receipt.ParticipantJID,
+		Status:         receipt.Status,
+		Timestamp:      receipt.Timestamp,
+	}
+
+	return m.emit(eventType, newPayload(eventType, data))
+}
+
+// EmitGroupEvent emits a group change (one of the EventGroup* types) to the
+// webhooks subscribed to it.
+func (m *WebhookManager) EmitGroupEvent(eventType, groupJID, groupName string, participants []string, actorJID string, timestamp time.Time) error {
+	return m.emit(eventType, newPayload(eventType, GroupEventData{
+		GroupJID:     groupJID,
+		GroupName:    groupName,
+		Participants: participants,
+		ActorJID:     actorJID,
+		Timestamp:    timestamp,
+	}))
+}
+
+// EmitCallEvent emits an incoming or missed call (one of the EventCall*
+// types) to the webhooks subscribed to it.
+func (m *WebhookManager) EmitCallEvent(eventType string, call storage.Call) error {
+	return m.emit(eventType, newPayload(eventType, CallEventData{
+		CallID:    call.ID,
+		CallerJID: call.CallerJID,
+		ChatJID:   call.ChatJID,
+		IsVideo:   call.IsVideo,
+		IsGroup:   call.IsGroup,
+		Timestamp: call.OfferedAt,
+	}))
+}
+
+// emit publishes payload to the /ws stream and the publishers, and enqueues it
+// for every active webhook subscribed to eventType.
+func (m *WebhookManager) emit(eventType string, payload WebhookPayload) error {
+	m.stream.publish(eventType, payload)
+	for _, p := range m.publishers {
+		p.Publish(payload)
+	}
+
 	webhooks, err := m.store.ListWebhooks(true) // active only
 	if err != nil {
 		return err
 	}
 
-	payload := m.buildMessagePayload(msg)
-
 	for _, webhook := range webhooks {
 		// Filter by event types
-		if !contains(webhook.EventTypes, "message") {
+		if !contains(webhook.EventTypes, eventType) {
 			continue
 		}
 
-		// Enqueue delivery task (non-blocking)
-		task := &deliveryTask{
+		// Persist the delivery in the outbox for the workers
+		m.enqueue(&deliveryTask{
 			webhook: webhook,
 			payload: payload,
 			attempt: 1,
-		}
-
-		select {
-		case m.deliveryChan <- task:
-			// Enqueued successfully
-		default:
-			// Channel full - log warning but don't block message processing
-			m.log.Printf("Warning: Webhook delivery queue full, dropping event for webhook %s", webhook.ID)
-		}
+		})
 	}
 
 	return nil
 }
 
-// buildMessagePayload converts a storage message to a webhook payload.
-func (m *WebhookManager) buildMessagePayload(msg storage.MessageWithNames) WebhookPayload {
-	eventType := "message.received"
-	if msg.IsFromMe {
-		eventType = "message.sent"
+// newPayload wraps event data in a webhook payload.
+func newPayload(eventType string, data any) WebhookPayload {
+	return WebhookPayload{
+		ID:        uuid.New().String(),
+		EventType: eventType,
+		Timestamp: time.Now(),
+		Data:      data,
 	}
+}
 
+// ChatJID returns the chat or group an event is about, or "" for events that
+// aren't about one. It reads the serialized data, since payloads restored from
+// the outbox carry raw JSON.
+func (p WebhookPayload) ChatJID() string {
+	raw, err := json.Marshal(p.Data)
+	if err != nil {
+		return ""
+	}
+	var subject struct {
+		ChatJID  string `json:"chat_jid"`
+		GroupJID string `json:"group_jid"`
+	}
+	if err := json.Unmarshal(raw, &subject); err != nil {
+		return ""
+	}
+	if subject.GroupJID != "" {
+		return subject.GroupJID
+	}
+	return subject.ChatJID
+}
+
+// buildMessageData converts a storage message to webhook event data.
+func buildMessageData(msg storage.MessageWithNames) MessageEventData {
 	data := MessageEventData{
 		MessageID:         msg.ID,
 		ChatJID:           msg.ChatJID,
@@ -189,6 +447,8 @@ func (m *WebhookManager) buildMessagePayload(msg storage.MessageWithNames) Webho
 		SenderPushName:    msg.SenderPushName,
 		SenderContactName: msg.SenderContactName,
 		IsGroup:           strings.Contains(msg.ChatJID, "@g.us"),
+		EditedAt:          msg.EditedAt,
+		DeletedAt:         msg.DeletedAt,
 	}
 
 	// Add media metadata if present
@@ -213,51 +473,66 @@ func (m *WebhookManager) buildMessagePayload(msg storage.MessageWithNames) Webho
 		}
 	}
 
-	return WebhookPayload{
-		ID:        uuid.New().String(),
-		EventType: eventType,
-		Timestamp: time.Now(),
-		Data:      data,
+	return data
+}
+
+// deadLetter keeps an event whose retries were exhausted so it can be
+// redelivered later.
+func (m *WebhookManager) deadLetter(task *deliveryTask, deliveryErr error) {
+	payload, err := json.Marshal(task.payload)
+	if err != nil {
+		m.log.Printf("Warning: Failed to dead-letter payload %s: %v", task.payload.ID, err)
+		return
 	}
+
+	letter := storage.DeadLetter{
+		WebhookID: task.webhook.ID,
+		PayloadID: task.payload.ID,
+		EventType: task.payload.EventType,
+		Payload:   payload,
+		Attempts:  task.attempt,
+		LastError: deliveryErr.Error(),
+		CreatedAt: time.Now(),
+	}
+	if err := m.store.SaveDeadLetter(letter); err != nil {
+		m.log.Printf("Warning: Failed to dead-letter payload %s: %v", task.payload.ID, err)
+		return
+	}
+	m.log.Printf("Retries exhausted, dead-lettered payload %s for webhook %s", task.payload.ID, task.webhook.ID)
 }
 
-// worker processes delivery tasks from the queue.
-func (m *WebhookManager) worker(id int) {
-	defer m.wg.Done()
-
-	m.log.Printf("Worker %d started", id)
-
-	for {
-		select {
-		case task := <-m.deliveryChan:
-			m.log.Printf("Worker %d processing webhook %s", id, task.webhook.ID)
-			if err := m.deliverWebhook(task.webhook, task.payload, task.attempt); err != nil {
-				// Schedule retry if attempts remain and backoff configuration is available
-				if task.attempt < m.config.MaxRetries && task.attempt < len(m.config.RetryBackoff) {
-					backoff := m.config.RetryBackoff[task.attempt]
-					task.attempt++
-
-					// Schedule the retry without blocking this worker goroutine.
-					go func(t *deliveryTask, delay time.Duration) {
-						select {
-						case <-time.After(delay):
-							// Only enqueue if context is still active.
-							select {
-							case m.deliveryChan <- t:
-								// retry enqueued
-							case <-m.ctx.Done():
-								// context canceled; stop processing
-							}
-						case <-m.ctx.Done():
-							// context canceled during backoff
-						}
-					}(task, backoff)
-				}
-			}
-		case <-m.ctx.Done():
-			return
+// Redeliver queues dead-lettered events of a webhook for delivery again, all
+// of them if ids is empty, and returns how many were queued. Events are sent
+// with the webhook's current URL, secret and field selection; events that fail
+// again are dead-lettered again.
+func (m *WebhookManager) Redeliver(webhookID string, ids []int64) (int, error) {
+	webhook, err := m.store.GetWebhook(webhookID)
+	if err != nil {
+		return 0, err
+	}
+
+	letters, err := m.store.ListDeadLetters(webhookID, ids, -1)
+	if err != nil {
+		return 0, err
+	}
+
+	queued := 0
+	for _, letter := range letters {
+		payload, err := decodePayload(letter.Payload)
+		if err != nil {
+			return queued, fmt.Errorf("invalid dead letter %d: %w", letter.ID, err)
+		}
+
+		// removed first, so the event can be dead-lettered again if it fails
+		if _, err := m.store.DeleteDeadLetters(webhookID, []int64{letter.ID}); err != nil {
+			return queued, err
 		}
+
+		m.enqueue(&deliveryTask{webhook: *webhook, payload: payload, attempt: 1})
+		queued++
 	}
+
+	return queued, nil
 }
 
 // contains checks if a slice contains a specific string.
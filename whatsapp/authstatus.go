@@ -0,0 +1,78 @@
+package whatsapp
+
+import (
+	"time"
+
+	"go.mau.fi/whatsmeow/proto/waHistorySync"
+)
+
+// HistorySyncStatus is the progress of the history WhatsApp sends after
+// pairing, in batches, and of on-demand history requests.
+type HistorySyncStatus struct {
+	Progress      int       `json:"progress"`                // percent of the initial history received, as reported by WhatsApp
+	LastType      string    `json:"last_type,omitempty"`     // type of the last batch, e.g. INITIAL_BOOTSTRAP, RECENT, FULL or ON_DEMAND
+	Batches       int       `json:"batches"`                 // batches processed since startup
+	Chats         int       `json:"chats"`                   // chats updated since startup
+	Messages      int       `json:"messages"`                // messages saved since startup
+	LastBatchAt   time.Time `json:"last_batch_at,omitempty"` // when the last batch was processed
+	OnDemandCount int       `json:"on_demand_requests"`      // on-demand history requests waiting for an answer
+}
+
+// AuthStatus describes the login and connection of the WhatsApp session.
+type AuthStatus struct {
+	LoggedIn      bool              `json:"logged_in"`
+	JID           string            `json:"jid,omitempty"`
+	Phone         string            `json:"phone,omitempty"`
+	PushName      string            `json:"push_name,omitempty"`
+	DeviceName    string            `json:"device_name"`              // name shown in Linked devices (WA_DEVICE_NAME)
+	Platform      string            `json:"platform"`                 // platform the device is linked as (WA_DEVICE_PLATFORM)
+	PhonePlatform string            `json:"phone_platform,omitempty"` // platform of the phone the device is linked to
+	Connection    ConnectionStatus  `json:"connection"`
+	Pairing       PairingStatus     `json:"pairing"`
+	HistorySync   HistorySyncStatus `json:"history_sync"`
+}
+
+// AuthStatus returns whether the session is logged in, as whom, and the state
+// of its connection, pairing and history sync.
+func (c *Client) AuthStatus() AuthStatus {
+	status := AuthStatus{
+		LoggedIn:    c.IsLoggedIn(),
+		DeviceName:  c.deviceConfig.Name,
+		Platform:    c.deviceConfig.Platform,
+		Connection:  c.ConnectionStatus(),
+		Pairing:     c.PairingStatus(),
+		HistorySync: c.HistorySyncStatus(),
+	}
+	if status.LoggedIn {
+		status.JID = c.wa().Store.ID.ToNonAD().String()
+		status.Phone = "+" + c.wa().Store.ID.User
+		status.PushName = c.wa().Store.PushName
+		status.PhonePlatform = c.wa().Store.Platform
+	}
+	return status
+}
+
+// HistorySyncStatus returns the history sync progress since startup.
+func (c *Client) HistorySyncStatus() HistorySyncStatus {
+	c.historySyncMux.Lock()
+	defer c.historySyncMux.Unlock()
+	status := c.historyStatus
+	status.OnDemandCount = len(c.historySyncChans)
+	return status
+}
+
+// recordHistorySync adds a processed history sync batch to the progress.
+func (c *Client) recordHistorySync(data *waHistorySync.HistorySync, chats, messages int) {
+	c.historySyncMux.Lock()
+	defer c.historySyncMux.Unlock()
+
+	// only some batches report the progress of the initial sync
+	if data.Progress != nil {
+		c.historyStatus.Progress = int(data.GetProgress())
+	}
+	c.historyStatus.LastType = data.GetSyncType().String()
+	c.historyStatus.Batches++
+	c.historyStatus.Chats += chats
+	c.historyStatus.Messages += messages
+	c.historyStatus.LastBatchAt = time.Now()
+}
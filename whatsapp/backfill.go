@@ -0,0 +1,224 @@
+package whatsapp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"whatsapp-mcp/storage"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+const (
+	backfillPageSize   = 50              // messages asked for per history request
+	backfillPageDelay  = 3 * time.Second // pause between requests, so the phone isn't flooded
+	backfillMaxRetries = 3               // failed requests in a row before a backfill gives up
+)
+
+// backfillWorker is a running backfill, see startBackfillWorker.
+type backfillWorker struct {
+	cancel context.CancelFunc
+}
+
+// StartBackfill starts requesting older history of a chat page by page in
+// the background, until the oldest stored message is older than until or
+// count messages were fetched (zero values for no target, at least one must
+// be set), or the phone has no older history. A backfill of the chat already
+// running is replaced. Jobs are stored, so they resume after a restart or
+// reconnect; follow them with GetBackfill.
+func (c *Client) StartBackfill(chatJID string, until time.Time, count int) (storage.Backfill, error) {
+	if until.IsZero() && count <= 0 {
+		return storage.Backfill{}, errors.New("a target date or message count is required")
+	}
+
+	parsedJID, err := types.ParseJID(chatJID)
+	if err != nil {
+		return storage.Backfill{}, fmt.Errorf("invalid chat JID: %w", err)
+	}
+	normalizedJID := c.normalizeJID(parsedJID)
+
+	oldestMessage, err := c.store.GetOldestMessage(normalizedJID)
+	if err != nil {
+		return storage.Backfill{}, fmt.Errorf("failed to get oldest message: %w", err)
+	}
+	if oldestMessage == nil {
+		return storage.Backfill{}, errors.New("no messages in database for this chat. Please wait for initial history sync")
+	}
+
+	c.stopBackfill(normalizedJID)
+
+	now := time.Now()
+	backfill := storage.Backfill{
+		ChatJID:         normalizedJID,
+		TargetCount:     max(count, 0),
+		OldestTimestamp: &oldestMessage.Timestamp,
+		Status:          storage.BackfillRunning,
+		StartedAt:       now,
+		UpdatedAt:       now,
+	}
+	if !until.IsZero() {
+		backfill.TargetDate = &until
+	}
+	if backfill.TargetReached() {
+		backfill.Status = storage.BackfillDone
+	}
+	if err := c.store.SaveBackfill(backfill); err != nil {
+		return storage.Backfill{}, fmt.Errorf("failed to save backfill: %w", err)
+	}
+
+	if backfill.Status == storage.BackfillRunning {
+		c.startBackfillWorker(backfill)
+	}
+	return backfill, nil
+}
+
+// CancelBackfill stops the running backfill of a chat. Messages fetched so
+// far are kept.
+func (c *Client) CancelBackfill(chatJID string) (storage.Backfill, error) {
+	backfill, err := c.GetBackfill(chatJID)
+	if err != nil {
+		return storage.Backfill{}, err
+	}
+	if backfill == nil || backfill.Status != storage.BackfillRunning {
+		return storage.Backfill{}, errors.New("no backfill running for this chat")
+	}
+
+	c.stopBackfill(backfill.ChatJID)
+
+	backfill.Status = storage.BackfillCancelled
+	backfill.UpdatedAt = time.Now()
+	if err := c.store.SaveBackfill(*backfill); err != nil {
+		return storage.Backfill{}, fmt.Errorf("failed to save backfill: %w", err)
+	}
+	return *backfill, nil
+}
+
+// GetBackfill returns the backfill job of a chat, or nil if it has none.
+func (c *Client) GetBackfill(chatJID string) (*storage.Backfill, error) {
+	parsedJID, err := types.ParseJID(chatJID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid chat JID: %w", err)
+	}
+	return c.store.GetBackfill(c.normalizeJID(parsedJID))
+}
+
+// resumeBackfills restarts the stored backfills that were still running when
+// the connection was lost or the server stopped.
+func (c *Client) resumeBackfills() {
+	backfills, err := c.store.ListBackfills(storage.BackfillRunning)
+	if err != nil {
+		c.log.Errorf("Failed to load backfills: %v", err)
+		return
+	}
+	for _, backfill := range backfills {
+		c.startBackfillWorker(backfill)
+	}
+}
+
+// startBackfillWorker runs a backfill in the background, unless it is
+// already running.
+func (c *Client) startBackfillWorker(backfill storage.Backfill) {
+	c.backfillMux.Lock()
+	defer c.backfillMux.Unlock()
+
+	if _, running := c.backfills[backfill.ChatJID]; running {
+		return
+	}
+	ctx, cancel := context.WithCancel(c.ctx)
+	worker := &backfillWorker{cancel: cancel}
+	c.backfills[backfill.ChatJID] = worker
+
+	go func() {
+		defer func() {
+			c.backfillMux.Lock()
+			if c.backfills[backfill.ChatJID] == worker {
+				delete(c.backfills, backfill.ChatJID)
+			}
+			c.backfillMux.Unlock()
+			cancel()
+		}()
+		c.runBackfill(ctx, backfill)
+	}()
+}
+
+// stopBackfill stops the worker of a chat's backfill, if running.
+func (c *Client) stopBackfill(chatJID string) {
+	c.backfillMux.Lock()
+	worker, running := c.backfills[chatJID]
+	delete(c.backfills, chatJID)
+	c.backfillMux.Unlock()
+
+	if running {
+		worker.cancel()
+	}
+}
+
+// runBackfill requests older pages of a chat until the backfill's target is
+// reached, saving its progress after each page. It returns, leaving the job
+// running, when the connection is lost; resumeBackfills picks it up again.
+func (c *Client) runBackfill(ctx context.Context, backfill storage.Backfill) {
+	c.log.Infof("Backfilling chat %s (%d messages fetched so far)", backfill.ChatJID, backfill.Fetched)
+
+	failures := 0
+	for !backfill.TargetReached() {
+		if err := c.ConnectionError(); err != nil {
+			c.log.Infof("Pausing backfill of %s until reconnected: %v", backfill.ChatJID, err)
+			return
+		}
+
+		pageSize := backfillPageSize
+		if backfill.TargetCount > 0 {
+			pageSize = min(pageSize, backfill.TargetCount-backfill.Fetched)
+		}
+
+		messages, err := c.RequestHistorySync(ctx, backfill.ChatJID, pageSize, true, nil)
+		if ctx.Err() != nil {
+			return // cancelled, replaced or shutting down
+		}
+		if err != nil {
+			failures++
+			backfill.LastError = err.Error()
+			if failures >= backfillMaxRetries {
+				backfill.Status = storage.BackfillFailed
+				c.reportf(LogWarning, "Backfill of %s failed after %d attempts: %v", backfill.ChatJID, failures, err)
+			}
+			c.saveBackfillProgress(&backfill)
+			if backfill.Status == storage.BackfillFailed {
+				return
+			}
+		} else {
+			failures = 0
+			backfill.LastError = ""
+			backfill.Pages++
+			backfill.Fetched += len(messages)
+			if len(messages) > 0 {
+				oldest := messages[len(messages)-1].Timestamp
+				backfill.OldestTimestamp = &oldest
+			} else {
+				// the phone has no older history of this chat
+				break
+			}
+			c.saveBackfillProgress(&backfill)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backfillPageDelay * time.Duration(failures+1)):
+		}
+	}
+
+	backfill.Status = storage.BackfillDone
+	c.saveBackfillProgress(&backfill)
+	c.reportf(LogInfo, "Backfill of %s done: %d messages in %d pages", backfill.ChatJID, backfill.Fetched, backfill.Pages)
+}
+
+// saveBackfillProgress stores the progress of a running backfill.
+func (c *Client) saveBackfillProgress(backfill *storage.Backfill) {
+	backfill.UpdatedAt = time.Now()
+	if err := c.store.SaveBackfill(*backfill); err != nil {
+		c.log.Errorf("Failed to save backfill of %s: %v", backfill.ChatJID, err)
+	}
+}
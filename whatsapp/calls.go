@@ -0,0 +1,119 @@
+package whatsapp
+
+import (
+	"context"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+
+	"whatsapp-mcp/storage"
+)
+
+// handleCallOffer stores an incoming one-to-one call.
+func (c *Client) handleCallOffer(evt *events.CallOffer) {
+	// the offer carries a <video> element for video calls
+	isVideo := false
+	if evt.Data != nil {
+		_, isVideo = evt.Data.GetOptionalChildByTag("video")
+	}
+	c.saveCallOffer(evt.BasicCallMeta, isVideo)
+
+	if c.callConfig.AutoReject {
+		go c.rejectCall(evt.BasicCallMeta)
+	}
+}
+
+// rejectCall rejects a ringing one-to-one call and, if configured, tells the
+// caller by message where they can reach me instead.
+func (c *Client) rejectCall(meta types.BasicCallMeta) {
+	ctx, cancel := context.WithTimeout(c.ctx, 30*time.Second)
+	defer cancel()
+
+	caller := meta.CallCreator
+	if caller.IsEmpty() {
+		caller = meta.From
+	}
+
+	if err := c.wa().RejectCall(ctx, caller, meta.CallID); err != nil {
+		c.log.Errorf("Failed to reject call %s from %s: %v", meta.CallID, caller, err)
+		return
+	}
+	c.setCallStatus(meta.CallID, storage.CallRejected)
+	c.log.Infof("Rejected call %s from %s", meta.CallID, caller)
+
+	if c.callConfig.RejectMessage == "" {
+		return
+	}
+	if _, err := c.SendTextMessage(ctx, c.normalizeJID(caller), c.callConfig.RejectMessage, nil); err != nil {
+		c.log.Errorf("Failed to reply to rejected call from %s: %v", caller, err)
+	}
+}
+
+// handleCallOfferNotice stores an incoming group call.
+func (c *Client) handleCallOfferNotice(evt *events.CallOfferNotice) {
+	c.saveCallOffer(evt.BasicCallMeta, evt.Media == "video")
+}
+
+// saveCallOffer stores a call that started ringing and reports it to webhooks.
+func (c *Client) saveCallOffer(meta types.BasicCallMeta, isVideo bool) {
+	caller := meta.CallCreator
+	if caller.IsEmpty() {
+		caller = meta.From
+	}
+
+	call := storage.Call{
+		ID:        meta.CallID,
+		CallerJID: c.normalizeJID(caller),
+		ChatJID:   c.normalizeJID(caller),
+		IsVideo:   isVideo,
+		OfferedAt: meta.Timestamp,
+	}
+	if !meta.GroupJID.IsEmpty() {
+		call.ChatJID = c.normalizeJID(meta.GroupJID)
+		call.IsGroup = true
+	}
+
+	if err := c.store.SaveCallOffer(call); err != nil {
+		c.log.Errorf("Failed to save call %s from %s: %v", call.ID, call.CallerJID, err)
+		return
+	}
+	c.log.Debugf("Incoming call %s from %s (video=%v)", call.ID, call.CallerJID, isVideo)
+
+	c.emitCallEvent("call.incoming", call)
+}
+
+// setCallStatus records that a ringing call was answered or rejected.
+func (c *Client) setCallStatus(callID, status string) {
+	if err := c.store.SetCallStatus(callID, status); err != nil {
+		c.log.Errorf("Failed to mark call %s %s: %v", callID, status, err)
+	}
+}
+
+// handleCallTerminate records the end of a call and reports missed calls to
+// webhooks.
+func (c *Client) handleCallTerminate(evt *events.CallTerminate) {
+	call, err := c.store.EndCall(evt.CallID, evt.Timestamp, evt.Reason)
+	if err != nil {
+		c.log.Errorf("Failed to end call %s: %v", evt.CallID, err)
+		return
+	}
+	if call == nil {
+		// calls I made aren't stored
+		return
+	}
+
+	if call.Status == storage.CallMissed {
+		c.emitCallEvent("call.missed", *call)
+	}
+}
+
+// emitCallEvent emits a webhook event for an incoming call.
+func (c *Client) emitCallEvent(eventType string, call storage.Call) {
+	if c.webhookManager == nil {
+		return
+	}
+	if err := c.webhookManager.EmitCallEvent(eventType, call); err != nil {
+		c.log.Errorf("Failed to emit webhook event: %v", err)
+	}
+}
@@ -0,0 +1,91 @@
+package whatsapp
+
+import (
+	"time"
+
+	"go.mau.fi/whatsmeow/proto/waHistorySync"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// muteEnd converts a WhatsApp mute end timestamp, -1 for muted forever, to
+// when the mute ends. App state sends milliseconds, history sync seconds.
+func muteEnd(ts int64) (forever bool, until time.Time) {
+	switch {
+	case ts == -1:
+		return true, time.Time{}
+	case ts > 1e12:
+		return false, time.UnixMilli(ts)
+	default:
+		return false, time.Unix(ts, 0)
+	}
+}
+
+// handleMute stores a chat being muted or unmuted on one of my devices
+// through app state sync.
+func (c *Client) handleMute(evt *events.Mute) {
+	chatJID := c.normalizeJID(evt.JID)
+
+	forever, until := muteEnd(evt.Action.GetMuteEndTimestamp())
+	if evt.Action.GetMuted() && evt.Action.MuteEndTimestamp == nil {
+		forever = true
+	}
+	if err := c.store.SetChatMuted(chatJID, evt.Action.GetMuted(), forever, until); err != nil {
+		c.log.Errorf("Failed to save mute state of %s: %v", chatJID, err)
+	}
+}
+
+// handleArchive stores a chat being archived or unarchived on one of my
+// devices through app state sync.
+func (c *Client) handleArchive(evt *events.Archive) {
+	chatJID := c.normalizeJID(evt.JID)
+	if err := c.store.SetChatArchived(chatJID, evt.Action.GetArchived()); err != nil {
+		c.log.Errorf("Failed to save archive state of %s: %v", chatJID, err)
+	}
+}
+
+// handlePin stores a chat being pinned or unpinned on one of my devices
+// through app state sync.
+func (c *Client) handlePin(evt *events.Pin) {
+	chatJID := c.normalizeJID(evt.JID)
+
+	var pinnedAt time.Time
+	if evt.Action.GetPinned() {
+		pinnedAt = evt.Timestamp
+	}
+	if err := c.store.SetChatPinned(chatJID, pinnedAt); err != nil {
+		c.log.Errorf("Failed to save pin state of %s: %v", chatJID, err)
+	}
+}
+
+// saveHistoryChatState stores which conversations in a history sync are
+// muted, archived or pinned. Only set states are stored, app state sync
+// takes care of clearing them. Chats must already be saved.
+func (c *Client) saveHistoryChatState(data *waHistorySync.HistorySync) {
+	for _, conv := range data.GetConversations() {
+		jid, err := types.ParseJID(conv.GetID())
+		if err != nil {
+			continue
+		}
+		chatJID := c.normalizeJID(jid)
+
+		if end := int64(conv.GetMuteEndTime()); end != 0 {
+			forever, until := muteEnd(end)
+			if forever || until.After(time.Now()) {
+				if err := c.store.SetChatMuted(chatJID, true, forever, until); err != nil {
+					c.log.Warnf("Failed to save mute state of %s: %v", chatJID, err)
+				}
+			}
+		}
+		if conv.GetArchived() {
+			if err := c.store.SetChatArchived(chatJID, true); err != nil {
+				c.log.Warnf("Failed to save archive state of %s: %v", chatJID, err)
+			}
+		}
+		if conv.GetPinned() > 0 {
+			if err := c.store.SetChatPinned(chatJID, time.Unix(int64(conv.GetPinned()), 0)); err != nil {
+				c.log.Warnf("Failed to save pin state of %s: %v", chatJID, err)
+			}
+		}
+	}
+}
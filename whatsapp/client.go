@@ -3,14 +3,18 @@ package whatsapp
 import (
 	"context"
 	"fmt"
+	"net/url"
 	"os"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 	"whatsapp-mcp/paths"
 	"whatsapp-mcp/storage"
 
 	"go.mau.fi/whatsmeow"
 	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/store"
 	"go.mau.fi/whatsmeow/store/sqlstore"
 	"go.mau.fi/whatsmeow/types"
 	waLog "go.mau.fi/whatsmeow/util/log"
@@ -20,23 +24,61 @@ import (
 // WebhookManager defines the interface for webhook emission.
 type WebhookManager interface {
 	EmitMessageEvent(msg storage.MessageWithNames) error
+	EmitMessageUpdateEvent(eventType string, msg storage.MessageWithNames, reaction *storage.Reaction) error
+	EmitPollVoteEvent(msg storage.MessageWithNames, vote storage.PollVote, results storage.PollResults) error
+	EmitReceiptEvent(eventType string, msg storage.MessageWithNames, receipt storage.Receipt) error
+	EmitGroupEvent(eventType, groupJID, groupName string, participants []string, actorJID string, timestamp time.Time) error
+	EmitCallEvent(eventType string, call storage.Call) error
 }
 
 // Client wraps the WhatsApp client with additional functionality.
 type Client struct {
-	wa               *whatsmeow.Client
-	store            *storage.MessageStore
-	mediaStore       *storage.MediaStore
-	webhookManager   WebhookManager // optional webhook manager
-	mediaConfig      MediaConfig
-	log              waLog.Logger
-	logFile          *os.File
-	historySyncChans map[string]chan bool // tracks pending sync requests by chat JID
-	historySyncMux   sync.Mutex           // protects the map
-	ctx              context.Context      // client lifecycle context
-	cancel           context.CancelFunc   // cancel function to stop all goroutines
+	waClient            atomic.Pointer[whatsmeow.Client] // see wa
+	container           *sqlstore.Container              // auth store, for new devices after a logout
+	store               *storage.MessageStore
+	mediaStore          *storage.MediaStore
+	webhookManager      WebhookManager // optional webhook manager
+	mediaConfig         MediaConfig
+	transcriptionConfig TranscriptionConfig
+	log                 waLog.Logger
+	logFile             *os.File
+	historySyncChans    map[string]chan bool       // tracks pending sync requests by chat JID
+	historySyncMux      sync.Mutex                 // protects historySyncChans and historyStatus
+	historyStatus       HistorySyncStatus          // history sync progress, see recordHistorySync
+	backfills           map[string]*backfillWorker // running backfills by chat JID
+	backfillMux         sync.Mutex                 // protects backfills
+	ctx                 context.Context            // client lifecycle context
+	cancel              context.CancelFunc         // cancel function to stop all goroutines
+	messageListeners    []func(storage.MessageWithNames)
+	logListeners        []func(level, message string)
+	listenersMux        sync.RWMutex       // protects messageListeners and logListeners
+	savedAliases        sync.Map           // LID JIDs whose alias is already stored
+	rawArchive          string             // raw message archive mode (RawArchiveOff, RawArchiveUnknown or RawArchiveAll)
+	conn                *supervisor        // connection state and reconnects, see supervise
+	loops               sync.WaitGroup     // supervise and runGroupRefresh, see startLoops
+	stopLoops           context.CancelFunc // stops supervise and runGroupRefresh
+	logoutMu            sync.Mutex         // serializes Logout, which replaces the whatsmeow client
+	presenceConfig      PresenceConfig
+	callConfig          CallConfig
+	deviceConfig        DeviceConfig
+	groupRefresh        time.Duration // how often joined groups are synced again, 0 to only sync on connect
+	presence            ownPresence
+	mediaRetries        map[string]*mediaRetry // expired media waiting for the phone to upload it again, by message ID
+	mediaRetryMux       sync.Mutex             // protects mediaRetries
+	pairing             pairing                // login of a new device, see StartPairing
+	downloads           *downloadQueue         // background media downloads, see runDownloads
+	writes              *writeQueue            // received messages and receipts waiting to be stored, see runWriter
+	newsletterNames     sync.Map               // channel names by newsletter JID, see newsletterName
+	newsletterSubs      atomic.Int64           // generation of the live updates subscription, see subscribeNewsletters
 }
 
+// Log levels of events reported to log listeners (same names as MCP logging levels).
+const (
+	LogInfo    = "info"
+	LogWarning = "warning"
+	LogError   = "error"
+)
+
 // fileLogger wraps a logger to write to both stdout and a file.
 type fileLogger struct {
 	base waLog.Logger
@@ -112,6 +154,34 @@ func NewClient(store *storage.MessageStore, mediaStore *storage.MediaStore, webh
 		mediaConfig.AutoDownloadMaxSize/(1024*1024),
 		getEnabledTypes(mediaConfig.AutoDownloadTypes))
 
+	transcriptionConfig := LoadTranscriptionConfig()
+	if transcriptionConfig.Enabled() {
+		logger.Infof("Voice note transcription: enabled (auto=%v)", transcriptionConfig.Auto)
+	}
+
+	rawArchive := LoadRawArchiveMode()
+	if rawArchive != RawArchiveOff {
+		logger.Infof("Raw message archive: %s", rawArchive)
+	}
+
+	presenceConfig := LoadPresenceConfig()
+	if presenceConfig.Mode != PresenceModeNone {
+		logger.Infof("Own presence: %s", presenceConfig.Mode)
+	}
+
+	groupRefresh := LoadGroupRefreshInterval()
+
+	callConfig := LoadCallConfig()
+	if callConfig.AutoReject {
+		logger.Infof("Incoming calls: auto-reject (reply=%v)", callConfig.RejectMessage != "")
+	}
+
+	writeQueueSize := LoadWriteQueueSize()
+
+	deviceConfig := LoadDeviceConfig()
+	applyDeviceConfig(deviceConfig)
+	logger.Infof("Device: %s (%s)", deviceConfig.Name, deviceConfig.Platform)
+
 	ctx := context.Background()
 
 	container, err := sqlstore.New(ctx, "sqlite", "file:"+paths.WhatsAppAuthDBPath+"?_pragma=foreign_keys(1)&_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)", logger)
@@ -124,46 +194,175 @@ func NewClient(store *storage.MessageStore, mediaStore *storage.MediaStore, webh
 		return nil, fmt.Errorf("failed to get  device: %w", err)
 	}
 
-	waClient := whatsmeow.NewClient(deviceStore, logger)
+	waClient, err := newWAClient(deviceStore, deviceConfig, logger)
+	if err != nil {
+		return nil, err
+	}
+	if proxyAddress := LoadProxyAddress(); proxyAddress != "" {
+		logger.Infof("WhatsApp connection through proxy %s", redactProxyAddress(proxyAddress))
+	}
 
 	// create client lifecycle context
 	clientCtx, cancel := context.WithCancel(context.Background())
 
 	client := &Client{
-		wa:               waClient,
-		store:            store,
-		mediaStore:       mediaStore,
-		webhookManager:   webhookManager,
-		mediaConfig:      mediaConfig,
-		log:              logger,
-		logFile:          logFile,
-		historySyncChans: make(map[string]chan bool),
-		ctx:              clientCtx,
-		cancel:           cancel,
+		container:           container,
+		store:               store,
+		mediaStore:          mediaStore,
+		webhookManager:      webhookManager,
+		mediaConfig:         mediaConfig,
+		transcriptionConfig: transcriptionConfig,
+		rawArchive:          rawArchive,
+		presenceConfig:      presenceConfig,
+		callConfig:          callConfig,
+		deviceConfig:        deviceConfig,
+		groupRefresh:        groupRefresh,
+		log:                 logger,
+		logFile:             logFile,
+		historySyncChans:    make(map[string]chan bool),
+		backfills:           make(map[string]*backfillWorker),
+		mediaRetries:        make(map[string]*mediaRetry),
+		downloads:           newDownloadQueue(mediaConfig.DownloadWorkers),
+		writes:              newWriteQueue(writeQueueSize),
+		ctx:                 clientCtx,
+		cancel:              cancel,
+		conn:                newSupervisor(),
 	}
 
+	client.waClient.Store(waClient)
 	waClient.AddEventHandler(client.eventHandler)
+	client.startLoops()
+	go client.runDownloads()
+	go client.runWriter()
 
 	return client, nil
 }
 
+// wa returns the whatsmeow client. It is replaced by Logout, so it must be
+// called again rather than kept.
+func (c *Client) wa() *whatsmeow.Client {
+	return c.waClient.Load()
+}
+
+// startLoops starts the goroutines that reconnect and refresh groups on their
+// own, which Logout stops while it replaces the whatsmeow client.
+func (c *Client) startLoops() {
+	ctx, cancel := context.WithCancel(c.ctx)
+	c.stopLoops = cancel
+
+	c.loops.Add(2)
+	go func() {
+		defer c.loops.Done()
+		c.supervise(ctx)
+	}()
+	go func() {
+		defer c.loops.Done()
+		c.runGroupRefresh(ctx)
+	}()
+}
+
+// newWAClient creates the whatsmeow client of a device.
+func newWAClient(deviceStore *store.Device, deviceConfig DeviceConfig, logger waLog.Logger) (*whatsmeow.Client, error) {
+	// presence can't be sent without a push name, which WhatsApp only sends
+	// after the first app state sync
+	if deviceStore.PushName == "" && deviceConfig.PushName != "" {
+		deviceStore.PushName = deviceConfig.PushName
+	}
+
+	waClient := whatsmeow.NewClient(deviceStore, logger)
+	waClient.EnableAutoReconnect = false // reconnects are handled by supervise
+
+	// the websocket and media uploads/downloads share the proxy
+	if proxyAddress := LoadProxyAddress(); proxyAddress != "" {
+		if err := waClient.SetProxyAddress(proxyAddress); err != nil {
+			return nil, fmt.Errorf("invalid WA_PROXY: %w", err)
+		}
+	}
+
+	return waClient, nil
+}
+
+// AddMessageListener registers fn to be called for every new message received live.
+// Listeners run on the goroutine writing received messages and must not block.
+func (c *Client) AddMessageListener(fn func(storage.MessageWithNames)) {
+	c.listenersMux.Lock()
+	defer c.listenersMux.Unlock()
+	c.messageListeners = append(c.messageListeners, fn)
+}
+
+// hasMessageListeners reports whether any message listener is registered.
+func (c *Client) hasMessageListeners() bool {
+	c.listenersMux.RLock()
+	defer c.listenersMux.RUnlock()
+	return len(c.messageListeners) > 0
+}
+
+// notifyMessageListeners calls every registered message listener with msg.
+func (c *Client) notifyMessageListeners(msg storage.MessageWithNames) {
+	c.listenersMux.RLock()
+	listeners := c.messageListeners
+	c.listenersMux.RUnlock()
+
+	for _, fn := range listeners {
+		fn(msg)
+	}
+}
+
+// AddLogListener registers fn to be called for important client events such as
+// connection changes, history sync progress and failed sends.
+// Listeners run on the event handler goroutine and must not block.
+func (c *Client) AddLogListener(fn func(level, message string)) {
+	c.listenersMux.Lock()
+	defer c.listenersMux.Unlock()
+	c.logListeners = append(c.logListeners, fn)
+}
+
+// reportf logs an important event and forwards it to every log listener.
+func (c *Client) reportf(level, format string, args ...any) {
+	switch level {
+	case LogError:
+		c.log.Errorf(format, args...)
+	case LogWarning:
+		c.log.Warnf(format, args...)
+	default:
+		c.log.Infof(format, args...)
+	}
+
+	c.listenersMux.RLock()
+	listeners := c.logListeners
+	c.listenersMux.RUnlock()
+
+	message := fmt.Sprintf(format, args...)
+	for _, fn := range listeners {
+		fn(level, message)
+	}
+}
+
 // IsLoggedIn reports whether the client is logged in.
 func (c *Client) IsLoggedIn() bool {
-	return c.wa.Store.ID != nil
+	return c.wa().Store.ID != nil
 }
 
-// Connect establishes a connection to WhatsApp.
+// Connect establishes a connection to WhatsApp. If it fails, connecting is
+// retried in the background.
 func (c *Client) Connect() error {
-	return c.wa.Connect()
+	err := c.wa().Connect()
+	if err != nil && c.IsLoggedIn() {
+		c.connectionLost(err.Error())
+	}
+	return err
 }
 
-// Disconnect closes the WhatsApp connection and cleans up resources.
+// Disconnect closes the WhatsApp connection, writes the queued events and
+// cleans up resources.
 func (c *Client) Disconnect() {
+	c.wa().Disconnect()
+	c.flushWrites()
+
 	// cancel context to stop all running goroutines
 	if c.cancel != nil {
 		c.cancel()
 	}
-	c.wa.Disconnect()
 	if c.logFile != nil {
 		if err := c.logFile.Close(); err != nil {
 			c.log.Errorf("failed to close log file: %v", err)
@@ -171,43 +370,83 @@ func (c *Client) Disconnect() {
 	}
 }
 
-// GetQRChannel returns a channel for receiving QR codes for authentication.
-func (c *Client) GetQRChannel(ctx context.Context) (<-chan whatsmeow.QRChannelItem, error) {
-	if c.IsLoggedIn() {
-		return nil, fmt.Errorf("already logged in")
+// PairPhone requests an 8-character pairing code for logging in with a phone
+// number instead of scanning a QR code. It must be called once pairing has
+// delivered its first QR code, i.e. once connected. The code is entered on
+// the phone under Linked devices > Link with phone number instead, which also
+// shows a notification. phone may contain spaces, dashes and a leading +.
+func (c *Client) PairPhone(ctx context.Context, phone string) (string, error) {
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, phone)
+	if digits == "" {
+		return "", fmt.Errorf("invalid phone number %q", phone)
 	}
 
-	qrChan, err := c.wa.GetQRChannel(ctx)
+	clientType, displayName := c.deviceConfig.pairClient()
+	return c.wa().PairPhone(ctx, digits, true, clientType, displayName)
+}
+
+// redactProxyAddress hides the password of a proxy URL for logging.
+func redactProxyAddress(address string) string {
+	parsed, err := url.Parse(address)
 	if err != nil {
-		return nil, err
+		return "(invalid)"
 	}
-
-	go func() {
-		err := c.Connect()
-		if err != nil {
-			c.log.Errorf("failed to connect: %v", err)
-		}
-	}()
-
-	return qrChan, nil
+	return parsed.Redacted()
 }
 
-// SendTextMessage sends a text message to a chat.
-func (c *Client) SendTextMessage(ctx context.Context, chatJID string, text string) error {
+// SendTextMessage sends a text message to a chat, optionally mentioning users.
+// Each mentioned JID gets an "@<number>" token in the text (replacing "@Name" when present),
+// which WhatsApp clients render as the contact's name. It returns the stored message.
+func (c *Client) SendTextMessage(ctx context.Context, chatJID string, text string, mentions []string) (storage.Message, error) {
 	targetJID, err := types.ParseJID(chatJID)
 	if err != nil {
-		return err
+		return storage.Message{}, err
 	}
 
-	resp, err := c.wa.SendMessage(ctx, targetJID, &waE2E.Message{
+	message := &waE2E.Message{
 		Conversation: proto.String(text),
-	})
+	}
 
+	var mentionJIDs []types.JID
+	if len(mentions) > 0 {
+		for _, raw := range mentions {
+			jid, err := types.ParseJID(raw)
+			if err != nil {
+				return storage.Message{}, fmt.Errorf("invalid mention JID %q: %w", raw, err)
+			}
+			mentionJIDs = append(mentionJIDs, jid.ToNonAD())
+		}
+
+		text = c.renderMentions(text, mentionJIDs)
+
+		mentioned := make([]string, 0, len(mentionJIDs))
+		for _, jid := range mentionJIDs {
+			mentioned = append(mentioned, jid.String())
+		}
+
+		message = &waE2E.Message{
+			ExtendedTextMessage: &waE2E.ExtendedTextMessage{
+				Text: proto.String(text),
+				ContextInfo: &waE2E.ContextInfo{
+					MentionedJID: mentioned,
+				},
+			},
+		}
+	}
+
+	c.presenceForSend(ctx)
+	resp, err := c.wa().SendMessage(ctx, targetJID, message)
 	if err != nil {
-		return err
+		c.reportf(LogError, "Failed to send message to %s: %v", chatJID, err)
+		return storage.Message{}, err
 	}
 
-	c.store.SaveMessage(storage.Message{
+	msg := storage.Message{
 		ID:          resp.ID,
 		ChatJID:     chatJID,
 		SenderJID:   resp.Sender.String(),
@@ -215,14 +454,159 @@ func (c *Client) SendTextMessage(ctx context.Context, chatJID string, text strin
 		Timestamp:   resp.Timestamp,
 		IsFromMe:    true,
 		MessageType: "text",
-	})
+	}
+	c.store.SaveMessage(msg)
+
+	if len(mentionJIDs) > 0 {
+		mentioned := make([]string, 0, len(mentionJIDs))
+		for _, jid := range mentionJIDs {
+			mentioned = append(mentioned, c.normalizeJID(jid))
+		}
+		if err := c.store.SaveMentions(resp.ID, mentioned); err != nil {
+			c.log.Errorf("Failed to save mentions for %s: %v", resp.ID, err)
+		}
+	}
+
+	return msg, nil
+}
+
+// renderMentions makes sure text contains an "@<number>" token for every mentioned JID.
+// An existing "@Name" for the contact is replaced; otherwise the token is prepended.
+func (c *Client) renderMentions(text string, mentions []types.JID) string {
+	var missing []string
+	for _, jid := range mentions {
+		token := "@" + jid.User
+		if strings.Contains(text, token) {
+			continue
+		}
+
+		if name := c.mentionName(jid); name != "" && strings.Contains(text, "@"+name) {
+			text = strings.Replace(text, "@"+name, token, 1)
+			continue
+		}
+
+		missing = append(missing, token)
+	}
+
+	if len(missing) > 0 {
+		text = strings.Join(missing, " ") + " " + text
+	}
+
+	return text
+}
+
+// mentionName returns the best known display name for a mentioned user.
+func (c *Client) mentionName(jid types.JID) string {
+	canonical := c.normalizeJID(jid)
 
-	return nil
+	if chat, err := c.store.GetChatByJID(canonical); err == nil && chat != nil {
+		if chat.ContactName != "" {
+			return chat.ContactName
+		}
+		if chat.PushName != "" {
+			return chat.PushName
+		}
+	}
+
+	if pushName, err := c.store.GetPushName(canonical); err == nil {
+		return pushName
+	}
+
+	return ""
+}
+
+// OwnJIDs returns the canonical JIDs that identify the logged-in user.
+// Mentions of the user may be stored under either the phone number or the LID.
+func (c *Client) OwnJIDs() []string {
+	if !c.IsLoggedIn() {
+		return nil
+	}
+
+	jids := []string{c.wa().Store.ID.ToNonAD().String()}
+	if lid := c.wa().Store.GetLID(); !lid.IsEmpty() {
+		jids = append(jids, lid.ToNonAD().String())
+	}
+
+	return jids
+}
+
+// GetDisappearingTimer returns the disappearing messages timer of a chat.
+// For groups it is fetched from WhatsApp and persisted; for DMs the last known value is returned.
+func (c *Client) GetDisappearingTimer(ctx context.Context, chatJID string) (time.Duration, error) {
+	jid, err := types.ParseJID(chatJID)
+	if err != nil {
+		return 0, err
+	}
+
+	if jid.Server == types.GroupServer {
+		info, err := c.wa().GetGroupInfo(ctx, jid)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get group info: %w", err)
+		}
+
+		seconds := 0
+		if info.IsEphemeral {
+			seconds = int(info.DisappearingTimer)
+		}
+		if err := c.store.SetDisappearingTimer(c.normalizeJID(jid), seconds); err != nil {
+			c.log.Errorf("Failed to save disappearing timer for %s: %v", chatJID, err)
+		}
+		return time.Duration(seconds) * time.Second, nil
+	}
+
+	chat, err := c.store.GetChatByJID(c.normalizeJID(jid))
+	if err != nil {
+		return 0, err
+	}
+	if chat == nil {
+		return 0, nil
+	}
+	return time.Duration(chat.DisappearingTimer) * time.Second, nil
+}
+
+// SetDisappearingTimer sets the disappearing messages timer of a chat (0 turns it off).
+func (c *Client) SetDisappearingTimer(ctx context.Context, chatJID string, timer time.Duration) error {
+	jid, err := types.ParseJID(chatJID)
+	if err != nil {
+		return err
+	}
+
+	if err := c.wa().SetDisappearingTimer(ctx, jid, timer, time.Now()); err != nil {
+		return err
+	}
+
+	return c.store.SetDisappearingTimer(c.normalizeJID(jid), int(timer/time.Second))
+}
+
+// SubscribePresence subscribes to online/last seen updates of a user.
+// Updates arrive as presence events and are stored in the presence table.
+func (c *Client) SubscribePresence(ctx context.Context, jid string) error {
+	target, err := types.ParseJID(jid)
+	if err != nil {
+		return err
+	}
+
+	if err := c.wa().SubscribePresence(ctx, target); err != nil {
+		return err
+	}
+
+	return c.store.MarkPresenceSubscribed(c.normalizeJID(target))
+}
+
+// GetPresence returns the last known presence of a user, or nil if none was received.
+func (c *Client) GetPresence(jid string) (*storage.Presence, error) {
+	target, err := types.ParseJID(jid)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.store.GetPresence(c.normalizeJID(target))
 }
 
 // RequestHistorySync requests additional message history from WhatsApp.
-// If waitForSync is true, it blocks until the sync completes and returns the new messages.
-func (c *Client) RequestHistorySync(ctx context.Context, chatJID string, count int, waitForSync bool) ([]storage.MessageWithNames, error) {
+// If waitForSync is true, it blocks until the sync completes and returns the new messages,
+// calling onProgress (if not nil) about every second with the number of messages received so far.
+func (c *Client) RequestHistorySync(ctx context.Context, chatJID string, count int, waitForSync bool, onProgress func(received int)) ([]storage.MessageWithNames, error) {
 	// parse the chatJID string to types.JID
 	parsedJID, err := types.ParseJID(chatJID)
 	if err != nil {
@@ -249,7 +633,7 @@ func (c *Client) RequestHistorySync(ctx context.Context, chatJID string, count i
 		Timestamp: oldestMessage.Timestamp,
 	}
 
-	reqMsg := c.wa.BuildHistorySyncRequest(lastKnownMessageInfo, count)
+	reqMsg := c.wa().BuildHistorySyncRequest(lastKnownMessageInfo, count)
 
 	if waitForSync {
 		oldestTimestamp := oldestMessage.Timestamp
@@ -260,7 +644,7 @@ func (c *Client) RequestHistorySync(ctx context.Context, chatJID string, count i
 		c.historySyncChans[normalizedJID] = syncChan
 		c.historySyncMux.Unlock()
 
-		_, err = c.wa.SendMessage(ctx, c.wa.Store.ID.ToNonAD(), reqMsg, whatsmeow.SendRequestExtra{Peer: true})
+		_, err = c.wa().SendMessage(ctx, c.wa().Store.ID.ToNonAD(), reqMsg, whatsmeow.SendRequestExtra{Peer: true})
 		if err != nil {
 			// clean up the channel on error
 			c.historySyncMux.Lock()
@@ -272,28 +656,55 @@ func (c *Client) RequestHistorySync(ctx context.Context, chatJID string, count i
 		c.log.Infof("Sent ON_DEMAND history sync request for chat %s (count: %d)", normalizedJID, count)
 
 		// wait for signal with timeout (30 seconds)
-		select {
-		case <-syncChan:
-			c.log.Debugf("History sync completed for chat %s", normalizedJID)
-		case <-time.After(30 * time.Second):
-			// clean up on timeout
-			c.historySyncMux.Lock()
-			delete(c.historySyncChans, normalizedJID)
-			c.historySyncMux.Unlock()
-			return nil, fmt.Errorf("timeout waiting for history sync. Try using wait_for_sync=false for async mode")
+		timeout := time.After(30 * time.Second)
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+
+	wait:
+		for {
+			select {
+			case <-syncChan:
+				c.log.Debugf("History sync completed for chat %s", normalizedJID)
+				break wait
+			case <-ticker.C:
+				if onProgress == nil {
+					continue
+				}
+				received, err := c.store.CountChatMessagesOlderThan(normalizedJID, oldestTimestamp)
+				if err != nil {
+					c.log.Warnf("Failed to count synced messages for %s: %v", normalizedJID, err)
+					continue
+				}
+				onProgress(min(received, count))
+			case <-timeout:
+				// clean up on timeout
+				c.historySyncMux.Lock()
+				delete(c.historySyncChans, normalizedJID)
+				c.historySyncMux.Unlock()
+				return nil, fmt.Errorf("timeout waiting for history sync. Try using wait_for_sync=false for async mode")
+			case <-ctx.Done():
+				// the caller gave up, the synced messages are still saved when they arrive
+				c.historySyncMux.Lock()
+				delete(c.historySyncChans, normalizedJID)
+				c.historySyncMux.Unlock()
+				return nil, ctx.Err()
+			}
 		}
 
 		// retrieve newly loaded messages from database
-		messages, err := c.store.GetChatMessagesOlderThan(normalizedJID, oldestTimestamp, count)
+		messages, err := c.store.GetChatMessagesOlderThan(ctx, normalizedJID, oldestTimestamp, count)
 		if err != nil {
 			return nil, fmt.Errorf("failed to retrieve newly loaded messages: %w", err)
 		}
 
 		c.log.Infof("Retrieved %d newly loaded messages for chat %s", len(messages), normalizedJID)
+		if onProgress != nil {
+			onProgress(len(messages))
+		}
 		return messages, nil
 	} else {
 		// asynchronous mode - send request and return immediately
-		_, err = c.wa.SendMessage(ctx, c.wa.Store.ID.ToNonAD(), reqMsg, whatsmeow.SendRequestExtra{Peer: true})
+		_, err = c.wa().SendMessage(ctx, c.wa().Store.ID.ToNonAD(), reqMsg, whatsmeow.SendRequestExtra{Peer: true})
 		if err != nil {
 			return nil, fmt.Errorf("failed to send history sync request: %w", err)
 		}
@@ -319,10 +730,10 @@ func (c *Client) GetMyInfo(ctx context.Context) (*MyInfo, error) {
 		return nil, fmt.Errorf("not logged in")
 	}
 
-	myJID := c.wa.Store.ID.ToNonAD()
+	myJID := c.wa().Store.ID.ToNonAD()
 
 	// Get basic user info (status, picture ID, verified business name)
-	userInfoMap, err := c.wa.GetUserInfo(ctx, []types.JID{myJID})
+	userInfoMap, err := c.wa().GetUserInfo(ctx, []types.JID{myJID})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user info: %w", err)
 	}
@@ -333,12 +744,12 @@ func (c *Client) GetMyInfo(ctx context.Context) (*MyInfo, error) {
 	}
 
 	// Get push name from store
-	pushName := c.wa.Store.PushName
+	pushName := c.wa().Store.PushName
 
 	// Get contact info for business name (if available)
 	var businessName string
-	if c.wa.Store.Contacts != nil {
-		contactInfo, err := c.wa.Store.Contacts.GetContact(ctx, myJID)
+	if c.wa().Store.Contacts != nil {
+		contactInfo, err := c.wa().Store.Contacts.GetContact(ctx, myJID)
 		if err == nil && contactInfo.Found {
 			businessName = contactInfo.BusinessName
 		}
@@ -346,7 +757,7 @@ func (c *Client) GetMyInfo(ctx context.Context) (*MyInfo, error) {
 
 	// Try to get profile picture URL
 	var pictureURL string
-	picInfo, err := c.wa.GetProfilePictureInfo(ctx, myJID, &whatsmeow.GetProfilePictureParams{
+	picInfo, err := c.wa().GetProfilePictureInfo(ctx, myJID, &whatsmeow.GetProfilePictureParams{
 		Preview: false,
 	})
 	if err == nil && picInfo != nil {
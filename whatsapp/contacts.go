@@ -0,0 +1,154 @@
+package whatsapp
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mau.fi/whatsmeow/appstate"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+
+	"whatsapp-mcp/storage"
+)
+
+// newContact returns a contact with the canonical JID and phone number of jid.
+func (c *Client) newContact(jid types.JID) storage.Contact {
+	contact := storage.Contact{JID: c.normalizeJID(jid)}
+	if canonical, err := types.ParseJID(contact.JID); err == nil && canonical.Server == types.DefaultUserServer {
+		contact.PhoneNumber = "+" + canonical.User
+	}
+	return contact
+}
+
+// contactFromInfo converts a whatsmeow contact store entry.
+func (c *Client) contactFromInfo(jid types.JID, info types.ContactInfo) storage.Contact {
+	contact := c.newContact(jid)
+	contact.FullName = info.FullName
+	contact.FirstName = info.FirstName
+	contact.PushName = info.PushName
+	contact.BusinessName = info.BusinessName
+	return contact
+}
+
+// ContactSyncResult describes what SyncContacts stored.
+type ContactSyncResult struct {
+	Contacts   int `json:"contacts"`    // contacts in the whatsmeow contact store
+	ChatsNamed int `json:"chats_named"` // direct chats whose contact name was filled in or changed
+}
+
+// syncContacts copies the whatsmeow contact store into the contacts table.
+// It runs in the background after connecting.
+func (c *Client) syncContacts() {
+	if c.wa().Store.Contacts == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	result, err := c.SyncContacts(ctx, false)
+	if err != nil {
+		c.log.Errorf("Failed to sync contacts: %v", err)
+		return
+	}
+	c.log.Infof("Synced %d contacts, named %d chats", result.Contacts, result.ChatsNamed)
+}
+
+// SyncContacts copies the complete whatsmeow contact store into the contacts
+// table and names the direct chats of known contacts. With fetchAppState, the
+// contact list is first fetched again from WhatsApp (a full app state sync),
+// which fills in contacts a fresh install hasn't received yet.
+func (c *Client) SyncContacts(ctx context.Context, fetchAppState bool) (ContactSyncResult, error) {
+	var result ContactSyncResult
+	if c.wa().Store.Contacts == nil {
+		return result, fmt.Errorf("not logged in")
+	}
+
+	if fetchAppState {
+		if err := c.wa().FetchAppState(ctx, appstate.WAPatchCriticalUnblockLow, true, false); err != nil {
+			return result, fmt.Errorf("failed to fetch contact list from WhatsApp: %w", err)
+		}
+	}
+
+	all, err := c.wa().Store.Contacts.GetAllContacts(ctx)
+	if err != nil {
+		return result, fmt.Errorf("failed to load contacts: %w", err)
+	}
+
+	contacts := make([]storage.Contact, 0, len(all))
+	for jid, info := range all {
+		contacts = append(contacts, c.contactFromInfo(jid, info))
+	}
+	if err := c.store.SaveContacts(contacts); err != nil {
+		return result, fmt.Errorf("failed to save contacts: %w", err)
+	}
+	result.Contacts = len(contacts)
+
+	if result.ChatsNamed, err = c.store.FillChatContactNames(); err != nil {
+		return result, fmt.Errorf("failed to name chats: %w", err)
+	}
+	return result, nil
+}
+
+// lookupContact returns the stored contact for a JID. Contacts that are not stored
+// yet are loaded from the whatsmeow contact store and saved. It returns nil if
+// the contact is unknown.
+func (c *Client) lookupContact(ctx context.Context, jid types.JID) *storage.Contact {
+	contact, err := c.store.GetContact(c.normalizeJID(jid))
+	if err != nil {
+		c.log.Debugf("Failed to get contact %s: %v", jid, err)
+	}
+	if contact != nil || c.wa().Store.Contacts == nil {
+		return contact
+	}
+
+	info, err := c.wa().Store.Contacts.GetContact(ctx, jid)
+	if err != nil || !info.Found {
+		return nil
+	}
+
+	loaded := c.contactFromInfo(jid, info)
+	if err := c.store.SaveContact(loaded); err != nil {
+		c.log.Debugf("Failed to save contact %s: %v", jid, err)
+	}
+	return &loaded
+}
+
+// handleContact stores contact name updates from app state sync.
+func (c *Client) handleContact(evt *events.Contact) {
+	contact := c.newContact(evt.JID)
+	contact.FullName = evt.Action.GetFullName()
+	contact.FirstName = evt.Action.GetFirstName()
+
+	if err := c.store.SaveContact(contact); err != nil {
+		c.log.Errorf("Failed to save contact %s: %v", contact.JID, err)
+		return
+	}
+	c.log.Debugf("Contact info updated: %s (FullName: %s, FirstName: %s)",
+		evt.JID, contact.FullName, contact.FirstName)
+}
+
+// handlePushName stores push name updates from WhatsApp.
+func (c *Client) handlePushName(evt *events.PushName) {
+	contact := c.newContact(evt.JID)
+	contact.PushName = evt.NewPushName
+
+	if err := c.store.SaveContact(contact); err != nil {
+		c.log.Errorf("Failed to save push name of %s: %v", contact.JID, err)
+		return
+	}
+	c.log.Debugf("Push name updated: %s -> %s", evt.JID, evt.NewPushName)
+}
+
+// handleBusinessName stores verified business name updates.
+func (c *Client) handleBusinessName(evt *events.BusinessName) {
+	contact := c.newContact(evt.JID)
+	contact.BusinessName = evt.NewBusinessName
+
+	if err := c.store.SaveContact(contact); err != nil {
+		c.log.Errorf("Failed to save business name of %s: %v", contact.JID, err)
+		return
+	}
+	c.log.Debugf("Business name updated: %s -> %s", evt.JID, evt.NewBusinessName)
+}
@@ -0,0 +1,43 @@
+package whatsapp
+
+import (
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/proto/waCompanionReg"
+	"go.mau.fi/whatsmeow/store"
+	"google.golang.org/protobuf/proto"
+)
+
+// devicePlatform is how a WA_DEVICE_PLATFORM value is reported when pairing
+// with a QR code and with a phone number pairing code.
+type devicePlatform struct {
+	props    waCompanionReg.DeviceProps_PlatformType
+	pairType whatsmeow.PairClientType
+	display  string // browser shown in the pairing code notification
+}
+
+var devicePlatforms = map[string]devicePlatform{
+	"chrome":  {waCompanionReg.DeviceProps_CHROME, whatsmeow.PairClientChrome, "Chrome"},
+	"edge":    {waCompanionReg.DeviceProps_EDGE, whatsmeow.PairClientEdge, "Edge"},
+	"firefox": {waCompanionReg.DeviceProps_FIREFOX, whatsmeow.PairClientFirefox, "Firefox"},
+	"ie":      {waCompanionReg.DeviceProps_IE, whatsmeow.PairClientIE, "IE"},
+	"opera":   {waCompanionReg.DeviceProps_OPERA, whatsmeow.PairClientOpera, "Opera"},
+	"safari":  {waCompanionReg.DeviceProps_SAFARI, whatsmeow.PairClientSafari, "Safari"},
+	"desktop": {waCompanionReg.DeviceProps_DESKTOP, whatsmeow.PairClientChrome, "Chrome"}, // pairing codes need a browser
+}
+
+// applyDeviceConfig sets the device identity whatsmeow reports. whatsmeow
+// keeps it in package globals, so it applies to every client of the process.
+func applyDeviceConfig(cfg DeviceConfig) {
+	store.SetOSInfo(cfg.Name, cfg.OSVersion)
+	store.DeviceProps.PlatformType = devicePlatforms[cfg.Platform].props.Enum()
+	if cfg.Manufacturer != "" {
+		store.BaseClientPayload.UserAgent.Manufacturer = proto.String(cfg.Manufacturer)
+	}
+}
+
+// pairClient returns the client type and display name sent with a phone
+// number pairing code, e.g. "Chrome (WhatsApp MCP)".
+func (cfg DeviceConfig) pairClient() (whatsmeow.PairClientType, string) {
+	platform := devicePlatforms[cfg.Platform]
+	return platform.pairType, platform.display + " (" + cfg.Name + ")"
+}
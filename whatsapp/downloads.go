@@ -0,0 +1,305 @@
+package whatsapp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"whatsapp-mcp/storage"
+
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	downloadQueueSize    = 1000             // queued downloads, more stay pending until the next scan
+	downloadScanInterval = 5 * time.Minute  // how often pending media is looked up again
+	downloadTimeout      = 60 * time.Second // per download, retries included
+	maxDownloadFailures  = 20               // recent failures kept for DownloadQueueStatus
+)
+
+// DownloadQueueStatus describes the background media downloads.
+type DownloadQueueStatus struct {
+	Workers        int               `json:"workers"`
+	Queued         int               `json:"queued"`      // waiting for a worker
+	Downloading    int               `json:"downloading"` // being downloaded right now
+	Downloaded     int               `json:"downloaded"`  // since startup
+	Failed         int               `json:"failed"`      // since startup
+	Expired        int               `json:"expired"`     // since startup, no longer on the WhatsApp servers
+	Skipped        int               `json:"skipped"`     // since startup, no longer allowed by the auto-download rules
+	RecentFailures []DownloadFailure `json:"recent_failures,omitempty"`
+}
+
+// DownloadFailure is a media download that failed, newest first in
+// DownloadQueueStatus.
+type DownloadFailure struct {
+	MessageID string    `json:"message_id"`
+	Status    string    `json:"status"` // failed or expired
+	Error     string    `json:"error"`
+	Time      time.Time `json:"time"`
+}
+
+// downloadQueue hands pending media to a fixed number of download workers.
+type downloadQueue struct {
+	jobs   chan string     // message IDs
+	mu     sync.Mutex      // protects queued and status
+	queued map[string]bool // queued or downloading, to not queue twice
+	status DownloadQueueStatus
+}
+
+// newDownloadQueue creates a queue for the given number of workers.
+func newDownloadQueue(workers int) *downloadQueue {
+	if workers < 1 {
+		workers = 1
+	}
+	return &downloadQueue{
+		jobs:   make(chan string, downloadQueueSize),
+		queued: make(map[string]bool),
+		status: DownloadQueueStatus{Workers: workers},
+	}
+}
+
+// DownloadQueueStatus returns the state of the background media downloads.
+func (c *Client) DownloadQueueStatus() DownloadQueueStatus {
+	c.downloads.mu.Lock()
+	defer c.downloads.mu.Unlock()
+
+	status := c.downloads.status
+	status.Queued = len(c.downloads.queued) - status.Downloading
+	status.RecentFailures = append([]DownloadFailure(nil), status.RecentFailures...)
+	return status
+}
+
+// enqueueDownload queues the pending media of a message for download. Media
+// that doesn't fit in the queue stays pending and is queued by a later scan.
+func (c *Client) enqueueDownload(messageID string) {
+	c.downloads.mu.Lock()
+	defer c.downloads.mu.Unlock()
+
+	if c.downloads.queued[messageID] {
+		return
+	}
+	select {
+	case c.downloads.jobs <- messageID:
+		c.downloads.queued[messageID] = true
+	default:
+		c.log.Debugf("Download queue full, %s stays pending", messageID)
+	}
+}
+
+// runDownloads starts the download workers and queues pending media now and
+// then, e.g. media left pending by a restart.
+func (c *Client) runDownloads() {
+	for i := 0; i < c.downloads.status.Workers; i++ {
+		go c.downloadWorker()
+	}
+
+	ticker := time.NewTicker(downloadScanInterval)
+	defer ticker.Stop()
+	for {
+		if c.ConnectionError() == nil {
+			c.queuePendingMedia()
+		}
+
+		select {
+		case <-c.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// queuePendingMedia queues stored media that is still waiting for download.
+func (c *Client) queuePendingMedia() {
+	pending, err := c.mediaStore.ListMediaByStatus("pending", downloadQueueSize)
+	if err != nil {
+		c.log.Errorf("Failed to list pending media: %v", err)
+		return
+	}
+	for _, meta := range pending {
+		c.enqueueDownload(meta.MessageID)
+	}
+	if len(pending) > 0 {
+		c.log.Infof("Queued %d pending media downloads", len(pending))
+	}
+}
+
+// downloadWorker downloads queued media until the client stops.
+func (c *Client) downloadWorker() {
+	for {
+		select {
+		case <-c.ctx.Done():
+			return
+		case messageID := <-c.downloads.jobs:
+			c.downloads.mu.Lock()
+			c.downloads.status.Downloading++
+			c.downloads.mu.Unlock()
+
+			status, err := c.downloadPendingMedia(messageID)
+
+			c.downloads.mu.Lock()
+			c.downloads.status.Downloading--
+			delete(c.downloads.queued, messageID)
+			switch status {
+			case "downloaded":
+				c.downloads.status.Downloaded++
+			case "skipped":
+				c.downloads.status.Skipped++
+			case "failed", "expired":
+				if status == "failed" {
+					c.downloads.status.Failed++
+				} else {
+					c.downloads.status.Expired++
+				}
+				failure := DownloadFailure{MessageID: messageID, Status: status, Error: err.Error(), Time: time.Now()}
+				c.downloads.status.RecentFailures = append([]DownloadFailure{failure}, c.downloads.status.RecentFailures...)
+				if len(c.downloads.status.RecentFailures) > maxDownloadFailures {
+					c.downloads.status.RecentFailures = c.downloads.status.RecentFailures[:maxDownloadFailures]
+				}
+			}
+			c.downloads.mu.Unlock()
+		}
+	}
+}
+
+// downloadPendingMedia downloads the media of a message if it is still
+// pending and allowed by the auto-download rules, and stores the outcome.
+// It returns the new download status, or "" if there was nothing to do.
+func (c *Client) downloadPendingMedia(messageID string) (string, error) {
+	meta, err := c.mediaStore.GetMediaMetadata(messageID)
+	if err != nil || meta == nil || meta.DownloadStatus != "pending" {
+		return "", nil
+	}
+
+	msg, err := c.store.GetMessageWithNames(c.ctx, messageID)
+	if err != nil || msg == nil {
+		return "", nil
+	}
+
+	// the rules may have changed since the media was marked pending
+	if !c.shouldAutoDownload(msg.MessageType, meta.FileSize) {
+		c.mediaStore.UpdateDownloadStatus(messageID, "skipped", nil, nil)
+		return "skipped", nil
+	}
+
+	ctx, cancel := context.WithTimeout(c.ctx, downloadTimeout)
+	defer cancel()
+
+	return c.downloadStoredMedia(ctx, msg, meta)
+}
+
+// DownloadMedia downloads the media of a message right away, whatever the
+// auto-download rules say, and returns its updated metadata. Media already
+// downloaded is returned as is.
+func (c *Client) DownloadMedia(ctx context.Context, messageID string) (*storage.MediaMetadata, error) {
+	meta, err := c.mediaStore.GetMediaMetadata(messageID)
+	if err != nil {
+		return nil, err
+	}
+	if meta == nil {
+		return nil, fmt.Errorf("message %s has no media", messageID)
+	}
+	if meta.DownloadStatus == "downloaded" {
+		return meta, nil
+	}
+
+	msg, err := c.store.GetMessageWithNames(ctx, messageID)
+	if err != nil {
+		return nil, err
+	}
+	if msg == nil {
+		return nil, fmt.Errorf("message %s not found", messageID)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, downloadTimeout)
+	defer cancel()
+
+	if _, err := c.downloadStoredMedia(ctx, msg, meta); err != nil {
+		return nil, err
+	}
+	return c.mediaStore.GetMediaMetadata(messageID)
+}
+
+// downloadStoredMedia downloads the media of a stored message and stores the
+// outcome. Expired media is asked to be re-uploaded by the sender. It returns
+// the new download status.
+func (c *Client) downloadStoredMedia(ctx context.Context, msg *storage.MessageWithNames, meta *storage.MediaMetadata) (string, error) {
+	media := mediaMessageFromMetadata(meta)
+
+	filePath, err := c.downloadMediaWithRetry(ctx, media, meta)
+	if err != nil {
+		c.log.Errorf("Failed to download media %s: %v", msg.ID, err)
+		if isMediaExpired(err) {
+			c.mediaStore.UpdateDownloadStatus(msg.ID, "expired", nil, err)
+			c.requestMediaRetry(storedMessageInfo(msg.Message), media, *meta)
+			return "expired", err
+		}
+		c.mediaStore.UpdateDownloadStatus(msg.ID, "failed", nil, err)
+		return "failed", err
+	}
+
+	c.mediaDownloaded(msg.ID, msg.MessageType, meta.MimeType, filePath)
+	return "downloaded", nil
+}
+
+// mediaDownloaded records a downloaded media file, then generates its
+// thumbnail and transcript if enabled.
+func (c *Client) mediaDownloaded(messageID, mediaType, mimeType, filePath string) {
+	c.mediaStore.UpdateDownloadStatus(messageID, "downloaded", &filePath, nil)
+	c.generateThumbnail(messageID, mimeType, filePath)
+	c.autoTranscribe(messageID, mediaType, filePath)
+}
+
+// mediaMessageFromMetadata rebuilds a downloadable message from stored media
+// metadata. Stickers download like images.
+func mediaMessageFromMetadata(meta *storage.MediaMetadata) *waE2E.Message {
+	mimeType := proto.String(meta.MimeType)
+	fileLength := proto.Uint64(uint64(meta.FileSize))
+	directPath := proto.String(meta.DirectPath)
+
+	switch {
+	case strings.HasPrefix(meta.MimeType, "image/"):
+		return &waE2E.Message{ImageMessage: &waE2E.ImageMessage{
+			Mimetype: mimeType, FileLength: fileLength, DirectPath: directPath,
+			MediaKey: meta.MediaKey, FileSHA256: meta.FileSHA256, FileEncSHA256: meta.FileEncSHA256,
+		}}
+	case strings.HasPrefix(meta.MimeType, "video/"):
+		return &waE2E.Message{VideoMessage: &waE2E.VideoMessage{
+			Mimetype: mimeType, FileLength: fileLength, DirectPath: directPath,
+			MediaKey: meta.MediaKey, FileSHA256: meta.FileSHA256, FileEncSHA256: meta.FileEncSHA256,
+		}}
+	case strings.HasPrefix(meta.MimeType, "audio/"):
+		return &waE2E.Message{AudioMessage: &waE2E.AudioMessage{
+			Mimetype: mimeType, FileLength: fileLength, DirectPath: directPath,
+			MediaKey: meta.MediaKey, FileSHA256: meta.FileSHA256, FileEncSHA256: meta.FileEncSHA256,
+		}}
+	default:
+		return &waE2E.Message{DocumentMessage: &waE2E.DocumentMessage{
+			Mimetype: mimeType, FileLength: fileLength, DirectPath: directPath,
+			MediaKey: meta.MediaKey, FileSHA256: meta.FileSHA256, FileEncSHA256: meta.FileEncSHA256,
+		}}
+	}
+}
+
+// storedMessageInfo rebuilds the message info a media retry request needs
+// from a stored message.
+func storedMessageInfo(msg storage.Message) *types.MessageInfo {
+	chat, err := types.ParseJID(msg.ChatJID)
+	if err != nil {
+		return nil
+	}
+	sender, _ := types.ParseJID(msg.SenderJID)
+
+	return &types.MessageInfo{
+		MessageSource: types.MessageSource{
+			Chat:     chat,
+			Sender:   sender,
+			IsFromMe: msg.IsFromMe,
+			IsGroup:  chat.Server == types.GroupServer,
+		},
+		ID: msg.ID,
+	}
+}
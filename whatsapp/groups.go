@@ -0,0 +1,207 @@
+package whatsapp
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	waHistorySync "go.mau.fi/whatsmeow/proto/waHistorySync"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+
+	"whatsapp-mcp/storage"
+)
+
+// SyncGroupParticipants fetches the participant list and description of a group
+// from WhatsApp and stores them.
+func (c *Client) SyncGroupParticipants(ctx context.Context, groupJID string) error {
+	jid, err := types.ParseJID(groupJID)
+	if err != nil {
+		return err
+	}
+
+	if jid.Server != types.GroupServer {
+		return fmt.Errorf("%s is not a group", groupJID)
+	}
+
+	info, err := c.wa().GetGroupInfo(ctx, jid)
+	if err != nil {
+		return fmt.Errorf("failed to get group info: %w", err)
+	}
+
+	if err := c.saveGroupParticipants(info); err != nil {
+		return err
+	}
+	c.saveGroupDescription(info.JID, info.GroupTopic)
+	return nil
+}
+
+// saveGroupParticipants stores the participant list of a group info result.
+func (c *Client) saveGroupParticipants(info *types.GroupInfo) error {
+	participants := make([]storage.GroupParticipant, 0, len(info.Participants))
+	for _, p := range info.Participants {
+		participants = append(participants, storage.GroupParticipant{
+			ParticipantJID: c.normalizeJID(p.JID),
+			IsAdmin:        p.IsAdmin || p.IsSuperAdmin,
+		})
+	}
+
+	return c.store.SetGroupParticipants(c.normalizeJID(info.JID), info.Name, participants)
+}
+
+// saveHistoryGroupParticipants stores the participant list included with a
+// group conversation in a history sync.
+func (c *Client) saveHistoryGroupParticipants(groupJID types.JID, conv *waHistorySync.Conversation) error {
+	participants := make([]storage.GroupParticipant, 0, len(conv.GetParticipant()))
+	for _, p := range conv.GetParticipant() {
+		jid, err := types.ParseJID(p.GetUserJID())
+		if err != nil {
+			c.log.Debugf("Failed to parse participant JID %s: %v", p.GetUserJID(), err)
+			continue
+		}
+		participants = append(participants, storage.GroupParticipant{
+			ParticipantJID: c.normalizeJID(jid),
+			IsAdmin:        p.GetRank() != waHistorySync.GroupParticipant_REGULAR,
+		})
+	}
+
+	return c.store.SetGroupParticipants(c.normalizeJID(groupJID), conv.GetName(), participants)
+}
+
+// syncJoinedGroups stores the names, descriptions and participant lists of
+// all joined groups. It runs in the background after connecting and then
+// every GROUP_REFRESH_HOURS, so renamed groups don't keep their old name.
+func (c *Client) syncJoinedGroups() {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	groups, err := c.wa().GetJoinedGroups(ctx)
+	if err != nil {
+		c.log.Errorf("Failed to get joined groups: %v", err)
+		return
+	}
+
+	for _, info := range groups {
+		if err := c.saveGroupParticipants(info); err != nil {
+			c.log.Errorf("Failed to save participants of %s: %v", info.JID, err)
+			continue
+		}
+		c.saveGroupDescription(info.JID, info.GroupTopic)
+	}
+
+	c.log.Infof("Synced %d groups", len(groups))
+}
+
+// runGroupRefresh syncs the joined groups again every GROUP_REFRESH_HOURS
+// while connected, until ctx is done.
+func (c *Client) runGroupRefresh(ctx context.Context) {
+	if c.groupRefresh <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(c.groupRefresh)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if c.ConnectionError() == nil {
+			c.syncJoinedGroups()
+		}
+	}
+}
+
+// saveGroupDescription stores the description (topic) of a group.
+func (c *Client) saveGroupDescription(groupJID types.JID, topic types.GroupTopic) {
+	description := topic.Topic
+	if topic.TopicDeleted {
+		description = ""
+	}
+	if err := c.store.SetGroupDescription(c.normalizeJID(groupJID), description, topic.TopicSetAt); err != nil {
+		c.log.Errorf("Failed to save description of %s: %v", groupJID, err)
+	}
+}
+
+// updateGroupParticipants applies participant changes from a group info event.
+func (c *Client) updateGroupParticipants(groupJID types.JID, join, leave, promote, demote []types.JID, timestamp time.Time) {
+	group := c.normalizeJID(groupJID)
+
+	if len(join) > 0 {
+		if err := c.store.AddGroupParticipants(group, c.normalizeJIDs(join), timestamp); err != nil {
+			c.log.Errorf("Failed to add participants to %s: %v", group, err)
+		}
+	}
+	if len(leave) > 0 {
+		if err := c.store.RemoveGroupParticipants(group, c.normalizeJIDs(leave)); err != nil {
+			c.log.Errorf("Failed to remove participants from %s: %v", group, err)
+		}
+	}
+	if len(promote) > 0 {
+		if err := c.store.SetGroupAdmins(group, c.normalizeJIDs(promote), true); err != nil {
+			c.log.Errorf("Failed to promote participants in %s: %v", group, err)
+		}
+	}
+	if len(demote) > 0 {
+		if err := c.store.SetGroupAdmins(group, c.normalizeJIDs(demote), false); err != nil {
+			c.log.Errorf("Failed to demote participants in %s: %v", group, err)
+		}
+	}
+}
+
+// handleJoinedGroup stores the participants of a group I was added to or
+// created, and reports new groups to webhooks.
+func (c *Client) handleJoinedGroup(evt *events.JoinedGroup) {
+	if err := c.saveGroupParticipants(&evt.GroupInfo); err != nil {
+		c.log.Errorf("Failed to save participants of %s: %v", evt.JID, err)
+	} else {
+		c.saveGroupDescription(evt.JID, evt.GroupTopic)
+	}
+
+	if evt.Type != "new" {
+		return
+	}
+	members := make([]types.JID, 0, len(evt.Participants))
+	for _, p := range evt.Participants {
+		members = append(members, p.JID)
+	}
+	actor := evt.Sender
+	if actor == nil && !evt.OwnerJID.IsEmpty() {
+		actor = &evt.OwnerJID
+	}
+	c.emitGroupEvent("group.created", evt.JID, evt.Name, members, actor, evt.GroupCreated)
+}
+
+// emitGroupEvent emits a webhook event for a group change. An empty
+// groupName is looked up in the stored chat.
+func (c *Client) emitGroupEvent(eventType string, groupJID types.JID, groupName string, participants []types.JID, actor *types.JID, timestamp time.Time) {
+	if c.webhookManager == nil {
+		return
+	}
+
+	group := c.normalizeJID(groupJID)
+	if groupName == "" {
+		if chat, err := c.store.GetChatByJID(group); err == nil && chat != nil {
+			groupName = chat.PushName // group name goes in PushName
+		}
+	}
+	var actorJID string
+	if actor != nil {
+		actorJID = c.normalizeJID(*actor)
+	}
+
+	if err := c.webhookManager.EmitGroupEvent(eventType, group, groupName, c.normalizeJIDs(participants), actorJID, timestamp); err != nil {
+		c.log.Errorf("Failed to emit webhook event: %v", err)
+	}
+}
+
+// normalizeJIDs converts a list of JIDs to canonical string format.
+func (c *Client) normalizeJIDs(jids []types.JID) []string {
+	normalized := make([]string, 0, len(jids))
+	for _, jid := range jids {
+		normalized = append(normalized, c.normalizeJID(jid))
+	}
+	return normalized
+}
@@ -2,11 +2,9 @@ package whatsapp
 
 import (
 	"context"
-	"errors"
-	"sync"
+	"slices"
 	"time"
 
-	"go.mau.fi/whatsmeow"
 	"go.mau.fi/whatsmeow/proto/waE2E"
 	waHistorySync "go.mau.fi/whatsmeow/proto/waHistorySync"
 	"go.mau.fi/whatsmeow/proto/waWeb"
@@ -18,25 +16,72 @@ import (
 
 // eventHandler processes all WhatsApp events from the client.
 func (c *Client) eventHandler(evt any) {
+	c.handleConnectionEvent(evt)
+
 	switch v := evt.(type) {
 	case *events.Message:
-		c.handleMessage(v)
+		c.enqueueWrite(v)
 	case *events.HistorySync:
 		c.handleHistorySync(v)
 	case *events.Contact:
 		c.handleContact(v)
 	case *events.PushName:
 		c.handlePushName(v)
+	case *events.BusinessName:
+		c.handleBusinessName(v)
 	case *events.Connected:
-		c.log.Infof("Connected to WhatsApp (JID: %s)", c.wa.Store.ID)
+		c.reportf(LogInfo, "Connected to WhatsApp (JID: %s)", c.wa().Store.ID)
+		go c.syncJoinedGroups()
+		go c.syncContacts()
+		go c.applyPresenceMode()
+		go c.syncNewsletters()
+		go c.resumeBackfills()
 	case *events.Disconnected:
-		c.log.Warnf("Disconnected from WhatsApp")
+		c.reportf(LogWarning, "Disconnected from WhatsApp")
+	case *events.LoggedOut:
+		c.reportf(LogError, "Logged out from WhatsApp (reason: %v), use the logout tool or POST /api/auth/logout to pair again", v.Reason)
 	case *events.QR:
 		// QR codes are handled externally via GetQRChannel
 	case *events.PairSuccess:
 		c.log.Infof("Successfully paired device")
 	case *events.GroupInfo:
 		c.handleGroupInfo(v)
+	case *events.JoinedGroup:
+		c.handleJoinedGroup(v)
+	case *events.Presence:
+		c.handlePresence(v)
+	case *events.CallOffer:
+		c.handleCallOffer(v)
+	case *events.CallOfferNotice:
+		c.handleCallOfferNotice(v)
+	case *events.CallAccept:
+		c.setCallStatus(v.CallID, storage.CallAnswered)
+	case *events.CallReject:
+		c.setCallStatus(v.CallID, storage.CallRejected)
+	case *events.CallTerminate:
+		c.handleCallTerminate(v)
+	case *events.Receipt:
+		c.enqueueWrite(v)
+	case *events.MediaRetry:
+		c.handleMediaRetry(v)
+	case *events.MarkChatAsRead:
+		c.handleMarkChatAsRead(v)
+	case *events.Mute:
+		c.handleMute(v)
+	case *events.Archive:
+		c.handleArchive(v)
+	case *events.Pin:
+		c.handlePin(v)
+	case *events.NewsletterJoin:
+		c.handleNewsletterJoin(v)
+	case *events.NewsletterLiveUpdate:
+		c.handleNewsletterLiveUpdate(v)
+	case *events.LabelEdit:
+		c.handleLabelEdit(v)
+	case *events.LabelAssociationChat:
+		c.handleLabelAssociationChat(v)
+	case *events.LabelAssociationMessage:
+		c.handleLabelAssociationMessage(v)
 	}
 }
 
@@ -57,9 +102,10 @@ func (c *Client) normalizeJID(jid types.JID) string {
 	// this prevents duplicate contacts for the same person
 	if jid.Server == "lid" {
 		ctx := context.Background()
-		pnJID, err := c.wa.Store.LIDs.GetPNForLID(ctx, jid)
+		pnJID, err := c.wa().Store.LIDs.GetPNForLID(ctx, jid)
 		if err == nil && !pnJID.IsEmpty() {
 			// successfully converted LID to PN, use PN instead
+			c.saveAlias(jid.ToNonAD().String(), pnJID.ToNonAD().String())
 			jid = pnJID
 		}
 		// if conversion fails, fall through to use LID
@@ -69,6 +115,52 @@ func (c *Client) normalizeJID(jid types.JID) string {
 	return jid.ToNonAD().String()
 }
 
+// saveAlias stores a LID -> PN mapping the first time it is seen, which moves
+// anything stored under the LID before the mapping was known to the PN.
+func (c *Client) saveAlias(lid, pn string) {
+	if existing, ok := c.savedAliases.Load(lid); ok && existing == pn {
+		return
+	}
+	if err := c.store.SaveJIDAlias(lid, pn); err != nil {
+		c.log.Warnf("Failed to save alias %s -> %s: %v", lid, pn, err)
+		return
+	}
+	c.savedAliases.Store(lid, pn)
+}
+
+// saveHistoryAliases stores the LID -> PN mappings included in a history sync
+// that aren't saved yet.
+func (c *Client) saveHistoryAliases(data *waHistorySync.HistorySync) {
+	aliases := make(map[string]string)
+	for _, mapping := range data.GetPhoneNumberToLidMappings() {
+		lid, err := types.ParseJID(mapping.GetLidJID())
+		if err != nil {
+			continue
+		}
+		pn, err := types.ParseJID(mapping.GetPnJID())
+		if err != nil {
+			continue
+		}
+		lidJID, pnJID := lid.ToNonAD().String(), pn.ToNonAD().String()
+		if existing, ok := c.savedAliases.Load(lidJID); ok && existing == pnJID {
+			continue
+		}
+		aliases[lidJID] = pnJID
+	}
+	if len(aliases) == 0 {
+		return
+	}
+
+	if err := c.store.SaveJIDAliases(aliases); err != nil {
+		c.log.Errorf("Failed to save LID mappings: %v", err)
+		return
+	}
+	for lid, pn := range aliases {
+		c.savedAliases.Store(lid, pn)
+	}
+	c.log.Infof("Saved %d LID mappings", len(aliases))
+}
+
 // messageData holds parsed message information for processing.
 type messageData struct {
 	MessageID   string
@@ -80,7 +172,10 @@ type messageData struct {
 	MessageType string
 	PushName    string // sender's WhatsApp display name from message
 	IsGroup     bool
-	ReplyToID   string // ID of message being replied to or reacted to (for reactions/replies)
+	ReplyToID   string   // ID of message being replied to or reacted to (for reactions/replies)
+	Mentions    []string // raw JIDs mentioned in the message (from ContextInfo)
+	LinkPreview *storage.LinkPreview
+	Payload     *storage.MessagePayload // location or shared contacts
 }
 
 // getGroupInfoCached fetches group info with database caching to avoid excessive API calls.
@@ -95,7 +190,7 @@ func (c *Client) getGroupInfoCached(ctx context.Context, groupJID types.JID) (st
 	}
 
 	// fetch from API if not cached or empty
-	groupInfo, err := c.wa.GetGroupInfo(ctx, groupJID)
+	groupInfo, err := c.wa().GetGroupInfo(ctx, groupJID)
 	if err != nil {
 		return "", err
 	}
@@ -114,17 +209,16 @@ func (c *Client) getSenderPushName(ctx context.Context, senderJID types.JID, mes
 		return messagePushName
 	}
 
-	// for group messages, try contact store as fallback
-	if isGroup && c.wa.Store.Contacts != nil {
-		contactInfo, err := c.wa.Store.Contacts.GetContact(ctx, senderJID)
-		if err == nil && contactInfo.Found {
+	// for group messages, fall back to the contact
+	if isGroup {
+		if contact := c.lookupContact(ctx, senderJID); contact != nil {
 			// priority: PushName > FullName > BusinessName
-			if contactInfo.PushName != "" {
-				return contactInfo.PushName
-			} else if contactInfo.FullName != "" {
-				return contactInfo.FullName
-			} else if contactInfo.BusinessName != "" {
-				return contactInfo.BusinessName
+			if contact.PushName != "" {
+				return contact.PushName
+			} else if contact.FullName != "" {
+				return contact.FullName
+			} else if contact.BusinessName != "" {
+				return contact.BusinessName
 			}
 		}
 	}
@@ -145,19 +239,15 @@ func (c *Client) getChatInfo(ctx context.Context, chatJID types.JID, isGroup boo
 		return groupName, ""
 	}
 
-	// for DMs, get contact name from contact store
-	if c.wa.Store.Contacts != nil {
-		contactInfo, err := c.wa.Store.Contacts.GetContact(ctx, chatJID)
-		if err == nil && contactInfo.Found {
-			// priority: FullName (saved contact) > FirstName > BusinessName
-			if contactInfo.FullName != "" {
-				contactName = contactInfo.FullName
-			} else if contactInfo.FirstName != "" {
-				contactName = contactInfo.FirstName
-			} else if contactInfo.BusinessName != "" {
-				contactName = contactInfo.BusinessName
-			}
-		}
+	// for channels, the channel name
+	if chatJID.Server == types.NewsletterServer {
+		return c.newsletterName(ctx, chatJID), ""
+	}
+
+	// for DMs, get contact name from the contacts table
+	// priority: FullName (saved contact) > FirstName > BusinessName
+	if contact := c.lookupContact(ctx, chatJID); contact != nil {
+		contactName = contact.DisplayName()
 	}
 
 	// for DMs, push name comes from the message (if not from me)
@@ -170,14 +260,32 @@ func (c *Client) getChatInfo(ctx context.Context, chatJID types.JID, isGroup boo
 
 // processMessageData saves a message and its associated chat to the database.
 func (c *Client) processMessageData(ctx context.Context, data messageData) error {
+	chat, msg := c.messageRecords(ctx, data)
+
+	// save/update chat BEFORE message (for foreign key constraint)
+	if err := c.store.SaveChat(chat); err != nil {
+		c.log.Errorf("Failed to save chat %s: %v", chat.JID, err)
+		return err
+	}
+
+	if err := c.store.SaveMessage(msg); err != nil {
+		c.log.Errorf("Failed to save message %s in chat %s: %v",
+			data.MessageID, chat.JID, err)
+		return err
+	}
+
+	c.saveMessageExtras(ctx, data)
+	return nil
+}
+
+// messageRecords builds the chat and message rows of a message.
+func (c *Client) messageRecords(ctx context.Context, data messageData) (storage.Chat, storage.Message) {
 	// normalize JIDs to canonical format
 	chatJID := c.normalizeJID(data.ChatJID)
-	senderJID := c.normalizeJID(data.SenderJID)
 
 	// get chat info (group name or DM contact/push names)
 	chatPushName, chatContactName := c.getChatInfo(ctx, data.ChatJID, data.IsGroup, data.PushName)
 
-	// save/update chat BEFORE message (for foreign key constraint)
 	chat := storage.Chat{
 		JID:             chatJID,
 		PushName:        chatPushName,
@@ -186,27 +294,45 @@ func (c *Client) processMessageData(ctx context.Context, data messageData) error
 		IsGroup:         data.IsGroup,
 	}
 
-	if err := c.store.SaveChat(chat); err != nil {
-		c.log.Errorf("Failed to save chat %s: %v", chatJID, err)
-		return err
-	}
-
-	// save message
 	msg := storage.Message{
 		ID:          data.MessageID,
 		ChatJID:     chatJID,
-		SenderJID:   senderJID,
+		SenderJID:   c.normalizeJID(data.SenderJID),
 		Text:        data.Text,
 		Timestamp:   data.Timestamp,
 		IsFromMe:    data.IsFromMe,
 		MessageType: data.MessageType,
 		ReplyToID:   data.ReplyToID,
+		Payload:     data.Payload,
 	}
 
-	if err := c.store.SaveMessage(msg); err != nil {
-		c.log.Errorf("Failed to save message %s in chat %s: %v",
-			data.MessageID, chatJID, err)
-		return err
+	return chat, msg
+}
+
+// saveMessageExtras saves what belongs to a stored message: its mentions,
+// link preview and the sender's push name.
+func (c *Client) saveMessageExtras(ctx context.Context, data messageData) {
+	// save mentions (normalized so LID mentions match PN JIDs)
+	if len(data.Mentions) > 0 {
+		mentioned := make([]string, 0, len(data.Mentions))
+		for _, raw := range data.Mentions {
+			jid, err := types.ParseJID(raw)
+			if err != nil {
+				c.log.Debugf("Failed to parse mentioned JID %s: %v", raw, err)
+				continue
+			}
+			mentioned = append(mentioned, c.normalizeJID(jid))
+		}
+		if err := c.store.SaveMentions(data.MessageID, mentioned); err != nil {
+			c.log.Errorf("Failed to save mentions for %s: %v", data.MessageID, err)
+		}
+	}
+
+	// save link preview (URL and page title)
+	if data.LinkPreview != nil {
+		if err := c.store.SaveLinkPreview(*data.LinkPreview); err != nil {
+			c.log.Errorf("Failed to save link preview for %s: %v", data.MessageID, err)
+		}
 	}
 
 	// get and save sender push name
@@ -220,15 +346,13 @@ func (c *Client) processMessageData(ctx context.Context, data messageData) error
 
 	c.log.Infof("Saved message %s from %s (IsFromMe=%v, Type=%s)",
 		data.MessageID, data.SenderJID, data.IsFromMe, data.MessageType)
-
-	return nil
 }
 
 // parseHistoryMessage parses a WebMessageInfo from history sync into messageData.
 // It returns nil if the message cannot be parsed.
 func (c *Client) parseHistoryMessage(chatJID types.JID, msg *waWeb.WebMessageInfo, pushNameMap map[string]string) *messageData {
 	// try ParseWebMessage first
-	parsedMsg, parseErr := c.wa.ParseWebMessage(chatJID, msg)
+	parsedMsg, parseErr := c.wa().ParseWebMessage(chatJID, msg)
 	if parseErr == nil {
 		// successfully parsed - use the parsed info
 		info := parsedMsg.Info
@@ -264,10 +388,10 @@ func (c *Client) parseHistoryMessage(chatJID types.JID, msg *waWeb.WebMessageInf
 				text = "[Document]"
 			} else if message.GetStickerMessage() != nil {
 				text = "[Sticker]"
-			} else if message.GetContactMessage() != nil || message.GetContactsArrayMessage() != nil {
-				text = "[Contact]"
-			} else if message.GetLocationMessage() != nil || message.GetLiveLocationMessage() != nil {
-				text = "[Location]"
+			} else if poll := pollCreation(message); poll != nil {
+				text = describePoll(poll)
+			} else if payload := extractPayload(message); payload != nil {
+				text = describePayload(payload)
 			} else if message.GetReactionMessage() != nil || message.GetEncReactionMessage() != nil {
 				text, replyToID = extractReactionData(message)
 				if text == "" {
@@ -277,10 +401,15 @@ func (c *Client) parseHistoryMessage(chatJID types.JID, msg *waWeb.WebMessageInf
 				text = "[Protocol]"
 			} else {
 				c.log.Warnf("unknown message type in history: %v", message)
-				text = "[Unknown message type]"
+				text = unknownMessageText
 			}
 		}
 
+		// quoted message (replies)
+		if replyToID == "" {
+			replyToID = messageContextInfo(msg.GetMessage()).GetStanzaID()
+		}
+
 		return &messageData{
 			MessageID:   info.ID,
 			ChatJID:     chatJID,
@@ -292,6 +421,9 @@ func (c *Client) parseHistoryMessage(chatJID types.JID, msg *waWeb.WebMessageInf
 			PushName:    pushName,
 			IsGroup:     chatJID.Server == "g.us",
 			ReplyToID:   replyToID,
+			Mentions:    extractMentions(msg.GetMessage()),
+			LinkPreview: extractLinkPreview(msg.GetMessage(), info.ID),
+			Payload:     extractPayload(msg.GetMessage()),
 		}
 	}
 
@@ -308,7 +440,7 @@ func (c *Client) parseHistoryMessage(chatJID types.JID, msg *waWeb.WebMessageInf
 	// determine sender JID
 	var senderJID types.JID
 	if fromMe {
-		senderJID = *c.wa.Store.ID
+		senderJID = *c.wa().Store.ID
 	} else if key.GetParticipant() != "" {
 		var err error
 		senderJID, err = types.ParseJID(key.GetParticipant())
@@ -332,9 +464,13 @@ func (c *Client) parseHistoryMessage(chatJID types.JID, msg *waWeb.WebMessageInf
 		pushName = pushNameMap[senderJID.String()]
 	}
 
+	payload := extractPayload(msg.GetMessage())
+
 	text := extractText(msg.GetMessage())
-	if text == "" {
-		text = "[Media or unknown]"
+	if text == "" && payload != nil {
+		text = describePayload(payload)
+	} else if text == "" {
+		text = storage.UnknownMessageText
 	}
 
 	return &messageData{
@@ -347,6 +483,10 @@ func (c *Client) parseHistoryMessage(chatJID types.JID, msg *waWeb.WebMessageInf
 		MessageType: c.getMessageType(msg.GetMessage()),
 		PushName:    pushName,
 		IsGroup:     chatJID.Server == "g.us",
+		ReplyToID:   messageContextInfo(msg.GetMessage()).GetStanzaID(),
+		Mentions:    extractMentions(msg.GetMessage()),
+		LinkPreview: extractLinkPreview(msg.GetMessage(), messageID),
+		Payload:     payload,
 	}
 }
 
@@ -364,21 +504,79 @@ func (c *Client) handleMessage(evt *events.Message) {
 		return
 	}
 
-	// extract media metadata (if exists)
-	var mediaMetadata *storage.MediaMetadata
-	mediaType := getMediaTypeFromMessage(evt.Message)
-	if mediaType != "" && mediaType != "vcard" && mediaType != "contact_array" {
-		mediaMetadata = c.extractMediaMetadata(evt.Message, info.ID, false)
+	// track disappearing messages timer changes before skipping protocol messages
+	if pm := evt.Message.GetProtocolMessage(); pm != nil && pm.GetType() == waE2E.ProtocolMessage_EPHEMERAL_SETTING {
+		c.updateDisappearingTimer(info.Chat, int(pm.GetEphemeralExpiration()))
+	} else if expiration := messageContextInfo(evt.Message).GetExpiration(); expiration > 0 {
+		c.updateDisappearingTimer(info.Chat, int(expiration))
+	}
+
+	// apply edits to the stored message
+	if pm := evt.Message.GetProtocolMessage(); pm != nil && pm.GetType() == waE2E.ProtocolMessage_MESSAGE_EDIT {
+		c.applyEdit(pm.GetKey().GetID(), extractText(pm.GetEditedMessage()), info.Timestamp)
+		return
+	}
+
+	// mark messages deleted for everyone
+	if pm := evt.Message.GetProtocolMessage(); pm != nil && pm.GetType() == waE2E.ProtocolMessage_REVOKE {
+		c.markDeleted(pm.GetKey().GetID(), info.Timestamp)
+		return
 	}
 
-	// skip protocol messages (edits, deletes, encryption updates, etc.)
+	// skip other protocol messages (encryption updates, etc.)
 	if evt.Message.GetProtocolMessage() != nil {
 		c.log.Debugf("Skipping protocol message (system message type)")
 		return
 	}
 
+	// reactions are stored with their target message instead of as messages
+	if reaction := evt.Message.GetReactionMessage(); reaction != nil {
+		c.saveReaction(info.Chat, info.Sender, reaction.GetText(), reaction.GetKey().GetID(), info.Timestamp)
+		return
+	}
+	if evt.Message.GetEncReactionMessage() != nil {
+		c.log.Debugf("Skipping encrypted reaction %s", info.ID)
+		return
+	}
+
+	// poll votes are tallied with their poll instead of stored as messages
+	if evt.Message.GetPollUpdateMessage() != nil {
+		c.handlePollVote(ctx, evt)
+		return
+	}
+
+	// edited channel posts arrive as the new content under the original ID
+	if evt.NewsletterMeta != nil && !evt.NewsletterMeta.EditTS.IsZero() {
+		c.applyEdit(info.ID, extractText(evt.Message), evt.NewsletterMeta.EditTS)
+		return
+	}
+
+	msg := c.newLiveMessage(evt)
+
+	if poll := pollCreation(evt.Message); poll != nil {
+		c.savePoll(info.ID, info.Chat, poll, info.Timestamp)
+	}
+
+	if err := c.processMessageData(ctx, msg.data); err != nil {
+		return
+	}
+
+	c.finishLiveMessage(ctx, msg)
+}
+
+// liveMessage is a received message ready to be stored.
+type liveMessage struct {
+	evt       *events.Message
+	data      messageData
+	media     *storage.MediaMetadata // nil for messages without downloadable media
+	mediaType string
+}
+
+// newLiveMessage extracts the text, metadata and media of a received message.
+func (c *Client) newLiveMessage(evt *events.Message) *liveMessage {
+	info := evt.Info
+
 	text := extractText(evt.Message)
-	var replyToID string
 	if text == "" {
 		if evt.Message.GetImageMessage() != nil {
 			text = "[Image]"
@@ -390,15 +588,10 @@ func (c *Client) handleMessage(evt *events.Message) {
 			text = "[Document]"
 		} else if evt.Message.GetStickerMessage() != nil {
 			text = "[Sticker]"
-		} else if evt.Message.GetContactMessage() != nil || evt.Message.GetContactsArrayMessage() != nil {
-			text = "[Contact]"
-		} else if evt.Message.GetLocationMessage() != nil || evt.Message.GetLiveLocationMessage() != nil {
-			text = "[Location]"
-		} else if evt.Message.GetReactionMessage() != nil || evt.Message.GetEncReactionMessage() != nil {
-			text, replyToID = extractReactionData(evt.Message)
-			if text == "" {
-				text = "[Reaction]"
-			}
+		} else if poll := pollCreation(evt.Message); poll != nil {
+			text = describePoll(poll)
+		} else if payload := extractPayload(evt.Message); payload != nil {
+			text = describePayload(payload)
 		} else if evt.Message.GetProtocolMessage() != nil {
 			text = "[Protocol]"
 		} else {
@@ -418,17 +611,59 @@ func (c *Client) handleMessage(evt *events.Message) {
 		MessageType: c.getMessageType(evt.Message),
 		PushName:    info.PushName,
 		IsGroup:     info.Chat.Server == "g.us",
-		ReplyToID:   replyToID,
+		ReplyToID:   messageContextInfo(evt.Message).GetStanzaID(),
+		Mentions:    extractMentions(evt.Message),
+		LinkPreview: extractLinkPreview(evt.Message, info.ID),
+		Payload:     extractPayload(evt.Message),
 	}
 
-	// skip saving poll-related messages
-	if data.MessageType == "poll" {
-		c.log.Debugf("Skipping poll message (not implemented)")
-		return
+	// extract media metadata (if exists)
+	var mediaMetadata *storage.MediaMetadata
+	mediaType := getMediaTypeFromMessage(evt.Message)
+	if mediaType != "" && mediaType != "vcard" && mediaType != "contact_array" {
+		mediaMetadata = c.extractMediaMetadata(evt.Message, info.ID, false)
 	}
 
-	if err := c.processMessageData(ctx, data); err != nil {
-		return
+	return &liveMessage{evt: evt, data: data, media: mediaMetadata, mediaType: mediaType}
+}
+
+// batchableMessage reports whether a received message is a plain message that
+// is only stored, so it can be saved in a batch with others. Protocol
+// messages, reactions, polls, channel edits and timer changes update other
+// rows and are handled one by one by handleMessage.
+func batchableMessage(evt *events.Message) bool {
+	msg := evt.Message
+	switch {
+	case msg.GetSenderKeyDistributionMessage() != nil,
+		msg.GetProtocolMessage() != nil,
+		msg.GetReactionMessage() != nil,
+		msg.GetEncReactionMessage() != nil,
+		msg.GetPollUpdateMessage() != nil,
+		pollCreation(msg) != nil,
+		messageContextInfo(msg).GetExpiration() > 0:
+		return false
+	}
+	return evt.NewsletterMeta == nil || evt.NewsletterMeta.EditTS.IsZero()
+}
+
+// finishLiveMessage handles a received message once it is stored: archives
+// it, saves its media and emits it to webhooks and listeners.
+func (c *Client) finishLiveMessage(ctx context.Context, msg *liveMessage) {
+	info := msg.evt.Info
+	data := msg.data
+	mediaMetadata := msg.media
+	mediaType := msg.mediaType
+
+	if c.shouldArchiveRaw(data.MessageType, data.Text) {
+		c.archiveRaw(info.ID, msg.evt.Message)
+	}
+
+	if info.Chat.Server == types.NewsletterServer {
+		c.saveLiveNewsletterPost(info)
+	}
+
+	if info.Chat == types.StatusBroadcastJID {
+		c.saveStatus(info, msg.evt.Message)
 	}
 
 	if mediaMetadata != nil {
@@ -440,29 +675,9 @@ func (c *Client) handleMessage(evt *events.Message) {
 
 			// should auto-download?
 			if mediaMetadata.DownloadStatus == "pending" {
-				c.log.Infof("Auto-downloading %s media (%d bytes) from %s",
+				c.log.Infof("Queueing %s media (%d bytes) from %s for download",
 					mediaType, mediaMetadata.FileSize, info.ID)
-
-				// download asynchronously to avoid blocking message processing
-				go func(meta *storage.MediaMetadata, msgID string) {
-					downloadCtx, cancel := context.WithTimeout(c.ctx, 60*time.Second)
-					defer cancel()
-
-					filePath, err := c.downloadMediaWithRetry(downloadCtx, evt.Message, meta)
-					if err != nil {
-						c.log.Errorf("Failed to download media %s: %v", msgID, err)
-						// update status based on error type
-						if errors.Is(err, whatsmeow.ErrMediaDownloadFailedWith404) ||
-							errors.Is(err, whatsmeow.ErrMediaDownloadFailedWith410) {
-							c.mediaStore.UpdateDownloadStatus(msgID, "expired", nil, err)
-						} else {
-							c.mediaStore.UpdateDownloadStatus(msgID, "failed", nil, err)
-						}
-					} else {
-						// update status with file path on success
-						c.mediaStore.UpdateDownloadStatus(msgID, "downloaded", &filePath, nil)
-					}
-				}(mediaMetadata, info.ID)
+				c.enqueueDownload(info.ID)
 			} else {
 				c.log.Debugf("Skipping auto-download for %s media (%d bytes) from %s (status: %s)",
 					mediaType, mediaMetadata.FileSize, info.ID, mediaMetadata.DownloadStatus)
@@ -470,8 +685,8 @@ func (c *Client) handleMessage(evt *events.Message) {
 		}
 	}
 
-	// Emit webhook event if manager is configured
-	if c.webhookManager != nil {
+	// Emit webhook event and notify listeners if any are configured
+	if c.webhookManager != nil || c.hasMessageListeners() {
 		// Get chat names for context
 		chatPushName, chatContactName := c.getChatInfo(ctx, data.ChatJID, data.IsGroup, data.PushName)
 
@@ -497,23 +712,207 @@ func (c *Client) handleMessage(evt *events.Message) {
 				IsFromMe:    data.IsFromMe,
 				MessageType: data.MessageType,
 				ReplyToID:   data.ReplyToID,
+				Payload:     data.Payload,
 			},
 			ChatName:          chatName,
 			SenderPushName:    senderPushName,
 			SenderContactName: senderContactName,
 			MediaMetadata:     mediaMetadata,
-			Referral:          extractReferral(evt.Message),
+			Referral:          extractReferral(msg.evt.Message),
 		}
 
 		// Emit webhook event (already non-blocking via worker queue)
-		if err := c.webhookManager.EmitMessageEvent(msgWithNames); err != nil {
+		if c.webhookManager != nil {
+			if err := c.webhookManager.EmitMessageEvent(msgWithNames); err != nil {
+				c.log.Errorf("Failed to emit webhook event: %v", err)
+			}
+		}
+
+		c.notifyMessageListeners(msgWithNames)
+	}
+}
+
+// updateDisappearingTimer persists a chat's disappearing messages timer.
+func (c *Client) updateDisappearingTimer(chatJID types.JID, seconds int) {
+	if err := c.store.SetDisappearingTimer(c.normalizeJID(chatJID), seconds); err != nil {
+		c.log.Errorf("Failed to update disappearing timer for %s: %v", chatJID, err)
+		return
+	}
+	c.log.Debugf("Disappearing timer for %s is now %ds", chatJID, seconds)
+}
+
+// applyEdit replaces the text of an edited message, keeping the previous version.
+func (c *Client) applyEdit(messageID, newText string, editedAt time.Time) {
+	if messageID == "" || newText == "" {
+		return
+	}
+
+	found, err := c.store.EditMessage(messageID, newText, editedAt)
+	if err != nil {
+		c.log.Errorf("Failed to apply edit to %s: %v", messageID, err)
+		return
+	}
+	if !found {
+		c.log.Debugf("Skipping edit of unknown message %s", messageID)
+		return
+	}
+	c.log.Debugf("Applied edit to message %s", messageID)
+	c.emitMessageUpdate("message.edited", messageID, nil)
+}
+
+// markDeleted marks a revoked message as deleted, keeping its content.
+func (c *Client) markDeleted(messageID string, deletedAt time.Time) {
+	if messageID == "" {
+		return
+	}
+
+	found, err := c.store.MarkMessageDeleted(messageID, deletedAt)
+	if err != nil {
+		c.log.Errorf("Failed to mark message %s as deleted: %v", messageID, err)
+		return
+	}
+	if !found {
+		c.log.Debugf("Skipping deletion of unknown message %s", messageID)
+		return
+	}
+	c.log.Debugf("Marked message %s as deleted", messageID)
+	c.emitMessageUpdate("message.deleted", messageID, nil)
+}
+
+// saveReaction stores a reaction to a message. An empty emoji removes the sender's reaction.
+func (c *Client) saveReaction(chatJID, senderJID types.JID, emoji, targetMessageID string, timestamp time.Time) {
+	if targetMessageID == "" {
+		return
+	}
+
+	reaction := storage.Reaction{
+		TargetMessageID: targetMessageID,
+		ChatJID:         c.normalizeJID(chatJID),
+		SenderJID:       c.normalizeJID(senderJID),
+		Emoji:           emoji,
+		Timestamp:       timestamp,
+	}
+
+	if err := c.store.SaveReaction(reaction); err != nil {
+		c.log.Errorf("Failed to save reaction to %s: %v", targetMessageID, err)
+		return
+	}
+	c.log.Debugf("Saved reaction %q from %s to %s", emoji, reaction.SenderJID, targetMessageID)
+	c.emitMessageUpdate("message.reaction", targetMessageID, &reaction)
+}
+
+// emitMessageUpdate emits a webhook event for a change to a stored message.
+// Reactions to messages that aren't stored only carry the message and chat IDs.
+func (c *Client) emitMessageUpdate(eventType, messageID string, reaction *storage.Reaction) {
+	if c.webhookManager == nil {
+		return
+	}
+
+	msg, err := c.store.GetMessageWithNames(context.Background(), messageID)
+	if err != nil {
+		c.log.Errorf("Failed to load message %s for webhook: %v", messageID, err)
+		return
+	}
+	if msg == nil {
+		if reaction == nil {
+			return
+		}
+		msg = &storage.MessageWithNames{Message: storage.Message{ID: messageID, ChatJID: reaction.ChatJID}}
+	}
+
+	if err := c.webhookManager.EmitMessageUpdateEvent(eventType, *msg, reaction); err != nil {
+		c.log.Errorf("Failed to emit webhook event: %v", err)
+	}
+}
+
+// emitReceipts emits a webhook event for each message a receipt from someone
+// else covers. Played receipts count as read. Messages that aren't stored only
+// carry their ID and chat.
+func (c *Client) emitReceipts(messageIDs []string, receipt storage.Receipt) {
+	if c.webhookManager == nil {
+		return
+	}
+
+	eventType := "message.read"
+	if receipt.Status == storage.ReceiptDelivered {
+		eventType = "message.delivered"
+	}
+
+	for _, messageID := range messageIDs {
+		msg, err := c.store.GetMessageWithNames(context.Background(), messageID)
+		if err != nil {
+			c.log.Errorf("Failed to load message %s for webhook: %v", messageID, err)
+			continue
+		}
+		if msg == nil {
+			msg = &storage.MessageWithNames{Message: storage.Message{ID: messageID, ChatJID: receipt.ChatJID, IsFromMe: true}}
+		}
+
+		receipt.MessageID = messageID
+		if err := c.webhookManager.EmitReceiptEvent(eventType, *msg, receipt); err != nil {
 			c.log.Errorf("Failed to emit webhook event: %v", err)
 		}
 	}
 }
 
-// handleGroupInfo processes group info updates like name changes.
+// historyReactions returns the reactions attached to a history sync message.
+func (c *Client) historyReactions(chatJID types.JID, msg *waWeb.WebMessageInfo) []storage.Reaction {
+	var reactions []storage.Reaction
+	for _, r := range msg.GetReactions() {
+		key := r.GetKey()
+		if key == nil || r.GetText() == "" {
+			continue
+		}
+
+		// the reaction key identifies who reacted, like a message key
+		var sender types.JID
+		switch {
+		case key.GetFromMe() && c.wa().Store.ID != nil:
+			sender = *c.wa().Store.ID
+		case key.GetParticipant() != "":
+			sender, _ = types.ParseJID(key.GetParticipant())
+		default:
+			sender, _ = types.ParseJID(key.GetRemoteJID())
+		}
+		if sender.IsEmpty() {
+			continue
+		}
+
+		reactions = append(reactions, storage.Reaction{
+			TargetMessageID: msg.GetKey().GetID(),
+			ChatJID:         c.normalizeJID(chatJID),
+			SenderJID:       c.normalizeJID(sender),
+			Emoji:           r.GetText(),
+			Timestamp:       time.UnixMilli(r.GetSenderTimestampMS()),
+		})
+	}
+	return reactions
+}
+
+// handleGroupInfo processes group info updates like name changes and participant changes.
 func (c *Client) handleGroupInfo(evt *events.GroupInfo) {
+	// track disappearing messages setting changes
+	if evt.Ephemeral != nil {
+		seconds := 0
+		if evt.Ephemeral.IsEphemeral {
+			seconds = int(evt.Ephemeral.DisappearingTimer)
+		}
+		c.updateDisappearingTimer(evt.JID, seconds)
+	}
+
+	// track participant changes
+	c.updateGroupParticipants(evt.JID, evt.Join, evt.Leave, evt.Promote, evt.Demote, evt.Timestamp)
+	if len(evt.Join) > 0 {
+		c.emitGroupEvent("group.participant_added", evt.JID, "", evt.Join, evt.Sender, evt.Timestamp)
+	}
+	if len(evt.Leave) > 0 {
+		c.emitGroupEvent("group.participant_removed", evt.JID, "", evt.Leave, evt.Sender, evt.Timestamp)
+	}
+
+	if evt.Topic != nil {
+		c.saveGroupDescription(evt.JID, *evt.Topic)
+	}
+
 	// update group name if changed
 	if evt.Name != nil {
 		groupJID := c.normalizeJID(evt.JID)
@@ -531,35 +930,74 @@ func (c *Client) handleGroupInfo(evt *events.GroupInfo) {
 		}
 
 		c.log.Infof("Updated group name: %s -> %s", evt.JID, evt.Name.Name)
+		c.emitGroupEvent("group.subject_changed", evt.JID, evt.Name.Name, nil, evt.Sender, evt.Timestamp)
 	}
 }
 
-// handleContact processes contact info updates from app state sync.
-func (c *Client) handleContact(evt *events.Contact) {
-	c.log.Debugf("Contact info updated: %s (FullName: %s, FirstName: %s)",
-		evt.JID, evt.Action.GetFullName(), evt.Action.GetFirstName())
-	// contact info is automatically stored by whatsmeow in the contact store
-	// no additional action needed - getChatInfo() will retrieve it
+// handleReceipt stores delivery/read receipts for messages.
+func (c *Client) handleReceipt(evt *events.Receipt) {
+	var status string
+	switch evt.Type {
+	case types.ReceiptTypeDelivered:
+		status = storage.ReceiptDelivered
+	case types.ReceiptTypeRead:
+		status = storage.ReceiptRead
+	case types.ReceiptTypePlayed:
+		status = storage.ReceiptPlayed
+	case types.ReceiptTypeReadSelf:
+		// read on one of my devices, used to derive unread counts
+		status = storage.ReceiptRead
+	default:
+		// sender, retry and other receipt types are not tracked
+		return
+	}
+
+	chatJID := c.normalizeJID(evt.Chat)
+	participantJID := c.normalizeJID(evt.Sender)
+
+	if err := c.store.SaveReceipts(evt.MessageIDs, chatJID, participantJID, status, evt.Timestamp); err != nil {
+		c.log.Errorf("Failed to save %s receipt from %s: %v", status, participantJID, err)
+		return
+	}
+
+	c.log.Debugf("Saved %s receipt from %s for %d messages in %s", status, participantJID, len(evt.MessageIDs), chatJID)
+
+	// receipts from my other devices are about messages I received
+	if !evt.IsFromMe {
+		c.emitReceipts(evt.MessageIDs, storage.Receipt{
+			ChatJID:        chatJID,
+			ParticipantJID: participantJID,
+			Status:         status,
+			Timestamp:      evt.Timestamp,
+		})
+	}
 }
 
-// handlePushName processes push name updates from WhatsApp.
-func (c *Client) handlePushName(evt *events.PushName) {
-	c.log.Debugf("Push name updated: %s -> %s", evt.JID, evt.NewPushName)
-	// push name is automatically stored by whatsmeow in the contact store
-	// no additional action needed - getChatInfo() will retrieve it
+// handlePresence stores online/last seen updates for subscribed users.
+func (c *Client) handlePresence(evt *events.Presence) {
+	jid := c.normalizeJID(evt.From)
+	if err := c.store.SavePresence(jid, !evt.Unavailable, evt.LastSeen); err != nil {
+		c.log.Errorf("Failed to save presence for %s: %v", jid, err)
+		return
+	}
+	c.log.Debugf("Presence update: %s online=%v last_seen=%v", jid, !evt.Unavailable, evt.LastSeen)
 }
 
 func (c *Client) handleHistorySync(evt *events.HistorySync) {
 	// check if this is an ON_DEMAND sync
 	isOnDemand := evt.Data.GetSyncType() == waHistorySync.HistorySync_ON_DEMAND
 	if isOnDemand {
-		c.log.Infof("Received ON_DEMAND history sync: %d conversations", len(evt.Data.GetConversations()))
+		c.reportf(LogInfo, "Received ON_DEMAND history sync: %d conversations", len(evt.Data.GetConversations()))
 	} else {
-		c.log.Infof("Starting history sync: %d conversations to process", len(evt.Data.GetConversations()))
+		c.reportf(LogInfo, "Starting history sync: %d conversations to process (progress: %d%%)",
+			len(evt.Data.GetConversations()), evt.Data.GetProgress())
 	}
 
 	ctx := context.Background()
 
+	// store the mappings first so this sync's messages are unified with older ones
+	c.saveHistoryAliases(evt.Data)
+
 	pushNameMap, err := c.store.LoadAllPushNames()
 	if err != nil {
 		c.log.Errorf("Failed to load existing push names: %v", err)
@@ -588,10 +1026,9 @@ func (c *Client) handleHistorySync(evt *events.HistorySync) {
 		c.log.Infof("No new push names in this HistorySync event (using %d existing from database)", existingCount)
 	}
 
-	var allMessages []storage.Message
-	var allMediaMetadata []storage.MediaMetadata
-	chatMap := make(map[string]*storage.Chat)      // track chats by canonical JID
-	additionalPushNames := make(map[string]string) // collect push names from messages
+	batch := newHistoryBatch()
+	saved := 0                                // messages saved
+	chatMap := make(map[string]*storage.Chat) // chats seen in this sync by canonical JID, with their latest message time
 
 	for idx, conv := range evt.Data.GetConversations() {
 		chatJID, err := types.ParseJID(conv.GetID())
@@ -604,6 +1041,13 @@ func (c *Client) handleHistorySync(evt *events.HistorySync) {
 			idx+1, len(evt.Data.GetConversations()),
 			chatJID.String(), len(conv.GetMessages()))
 
+		// group conversations include the participant list
+		if chatJID.Server == types.GroupServer && len(conv.GetParticipant()) > 0 {
+			if err := c.saveHistoryGroupParticipants(chatJID, conv); err != nil {
+				c.log.Warnf("Failed to save participants of %s: %v", chatJID, err)
+			}
+		}
+
 		for _, histMsg := range conv.GetMessages() {
 			msg := histMsg.GetMessage()
 			if msg == nil {
@@ -622,8 +1066,32 @@ func (c *Client) handleHistorySync(evt *events.HistorySync) {
 				continue
 			}
 
-			// skip saving poll-related messages
-			if msgData.MessageType == "poll" {
+			// votes sent as messages can't be decrypted here, history sync
+			// attaches them to their poll instead
+			if msgData.MessageType == "poll_vote" {
+				continue
+			}
+			if poll := pollCreation(msg.GetMessage()); poll != nil {
+				c.savePoll(msgData.MessageID, chatJID, poll, msgData.Timestamp)
+				batch.pollVotes = append(batch.pollVotes, c.historyPollVotes(chatJID, msg, poll)...)
+			}
+
+			// reactions attached to this message
+			batch.reactions = append(batch.reactions, c.historyReactions(chatJID, msg)...)
+
+			// reactions are stored with their target message instead of as messages
+			if reaction := msg.GetMessage().GetReactionMessage(); reaction != nil {
+				batch.reactions = append(batch.reactions, storage.Reaction{
+					TargetMessageID: reaction.GetKey().GetID(),
+					ChatJID:         c.normalizeJID(msgData.ChatJID),
+					SenderJID:       c.normalizeJID(msgData.SenderJID),
+					Emoji:           reaction.GetText(),
+					Timestamp:       msgData.Timestamp,
+				})
+				continue
+			}
+			if msgData.MessageType == "reaction" {
+				// encrypted reactions can't be read
 				continue
 			}
 
@@ -634,7 +1102,7 @@ func (c *Client) handleHistorySync(evt *events.HistorySync) {
 				if mediaType != "" && mediaType != "vcard" && mediaType != "contact_array" {
 					mediaMetadata := c.extractMediaMetadata(actualMessage, msgData.MessageID, true)
 					if mediaMetadata != nil {
-						allMediaMetadata = append(allMediaMetadata, *mediaMetadata)
+						batch.media = append(batch.media, *mediaMetadata)
 					}
 				}
 			}
@@ -645,13 +1113,13 @@ func (c *Client) handleHistorySync(evt *events.HistorySync) {
 
 			// collect push name for later saving
 			if msgData.PushName != "" && !msgData.IsFromMe {
-				additionalPushNames[msgData.SenderJID.String()] = msgData.PushName
+				batch.pushNames[msgData.SenderJID.String()] = msgData.PushName
 			}
 
 			// get enhanced sender push name (with contact fallback for groups)
 			senderPushName := c.getSenderPushName(ctx, msgData.SenderJID, msgData.PushName, msgData.IsGroup, msgData.IsFromMe)
 			if senderPushName != "" && !msgData.IsFromMe {
-				additionalPushNames[msgData.SenderJID.String()] = senderPushName
+				batch.pushNames[msgData.SenderJID.String()] = senderPushName
 			}
 
 			// track chat for batch saving
@@ -663,20 +1131,41 @@ func (c *Client) handleHistorySync(evt *events.HistorySync) {
 						existingChat.LastMessageTime = msgData.Timestamp
 					}
 				} else {
-					// create new chat entry (will be saved in batch later)
+					// create new chat entry (will be saved with the batch)
 					chatPushName, chatContactName := c.getChatInfo(ctx, msgData.ChatJID, msgData.IsGroup, msgData.PushName)
-					chatMap[normalizedChatJID] = &storage.Chat{
+					existingChat = &storage.Chat{
 						JID:             normalizedChatJID,
 						PushName:        chatPushName,
 						ContactName:     chatContactName,
 						LastMessageTime: msgData.Timestamp,
 						IsGroup:         msgData.IsGroup,
 					}
+					chatMap[normalizedChatJID] = existingChat
 				}
+				batch.chats[normalizedChatJID] = existingChat
+			}
+
+			// collect mentions (saved after the messages)
+			for _, raw := range msgData.Mentions {
+				if jid, err := types.ParseJID(raw); err == nil {
+					batch.mentions[msgData.MessageID] = append(batch.mentions[msgData.MessageID], c.normalizeJID(jid))
+				}
+			}
+
+			if msgData.LinkPreview != nil {
+				batch.linkPreviews = append(batch.linkPreviews, *msgData.LinkPreview)
+			}
+
+			if c.shouldArchiveRaw(msgData.MessageType, msgData.Text) {
+				batch.rawMessages[msgData.MessageID] = msg.GetMessage()
+			}
+
+			if status := c.statusUpdate(msgData.MessageID, chatJID, msgData.SenderJID, msgData.IsFromMe, msg.GetMessage(), msgData.Timestamp); status != nil {
+				batch.statuses = append(batch.statuses, *status)
 			}
 
 			// add message to batch
-			allMessages = append(allMessages, storage.Message{
+			batch.messages = append(batch.messages, storage.Message{
 				ID:          msgData.MessageID,
 				ChatJID:     normalizedChatJID,
 				SenderJID:   normalizedSenderJID,
@@ -685,39 +1174,177 @@ func (c *Client) handleHistorySync(evt *events.HistorySync) {
 				IsFromMe:    msgData.IsFromMe,
 				MessageType: msgData.MessageType,
 				ReplyToID:   msgData.ReplyToID,
+				Payload:     msgData.Payload,
 			})
+
+			// save as we go, large syncs would otherwise be held in memory
+			// and only show up once the whole sync is processed
+			if len(batch.messages) >= historyBatchSize {
+				saved += c.saveHistoryBatch(batch)
+				batch = newHistoryBatch()
+			}
+		}
+	}
+
+	saved += c.saveHistoryBatch(batch)
+
+	if saved > 0 {
+		c.reportf(LogInfo, "History sync complete: %d chats updated, %d messages saved",
+			len(chatMap), saved)
+	}
+	c.recordHistorySync(evt.Data, len(chatMap), saved)
+
+	c.saveHistoryReadState(evt.Data)
+	c.saveHistoryChatState(evt.Data)
+
+	// signal waiting synchronous requests for ON_DEMAND syncs
+	if isOnDemand {
+		c.historySyncMux.Lock()
+		for _, conv := range evt.Data.GetConversations() {
+			chatJID, err := types.ParseJID(conv.GetID())
+			if err != nil {
+				continue
+			}
+			normalizedJID := c.normalizeJID(chatJID)
+			if syncChan, exists := c.historySyncChans[normalizedJID]; exists {
+				select {
+				case syncChan <- true:
+					c.log.Debugf("Signaled completion for chat %s", normalizedJID)
+				default:
+				}
+				delete(c.historySyncChans, normalizedJID)
+			}
 		}
+		c.historySyncMux.Unlock()
+	}
+}
+
+// dropArchived removes the messages already moved to the archive database
+// from a history batch, with what refers to them, so a re-sync doesn't bring
+// them back.
+func (c *Client) dropArchived(batch *historyBatch) {
+	if len(batch.messages) == 0 {
+		return
 	}
 
-	// save chats BEFORE messages (for foreign key constraint)
-	if len(chatMap) > 0 {
-		c.log.Infof("Updating %d chat names from history sync", len(chatMap))
-		for _, chat := range chatMap {
+	ids := make([]string, len(batch.messages))
+	var chatJIDs []string
+	for i, msg := range batch.messages {
+		ids[i] = msg.ID
+		if !slices.Contains(chatJIDs, msg.ChatJID) {
+			chatJIDs = append(chatJIDs, msg.ChatJID)
+		}
+	}
+
+	archived, err := c.store.ArchivedMessageIDs(context.Background(), chatJIDs, ids)
+	if err != nil {
+		c.log.Warnf("Failed to look up archived messages: %v", err)
+		return
+	}
+	if len(archived) == 0 {
+		return
+	}
+
+	batch.messages = slices.DeleteFunc(batch.messages, func(m storage.Message) bool { return archived[m.ID] })
+	batch.media = slices.DeleteFunc(batch.media, func(m storage.MediaMetadata) bool { return archived[m.MessageID] })
+	batch.linkPreviews = slices.DeleteFunc(batch.linkPreviews, func(p storage.LinkPreview) bool { return archived[p.MessageID] })
+	batch.reactions = slices.DeleteFunc(batch.reactions, func(r storage.Reaction) bool { return archived[r.TargetMessageID] })
+	batch.pollVotes = slices.DeleteFunc(batch.pollVotes, func(v storage.PollVote) bool { return archived[v.PollMessageID] })
+	batch.statuses = slices.DeleteFunc(batch.statuses, func(s storage.StatusUpdate) bool { return archived[s.MessageID] })
+	for id := range archived {
+		delete(batch.mentions, id)
+		delete(batch.rawMessages, id)
+	}
+	c.log.Infof("Skipped %d archived messages from history sync", len(archived))
+}
+
+// historyBatchSize is how many history sync messages are saved at a time.
+const historyBatchSize = 500
+
+// historyBatch collects what handleHistorySync parsed from a slice of a
+// history sync until saveHistoryBatch stores it.
+type historyBatch struct {
+	messages     []storage.Message
+	media        []storage.MediaMetadata
+	mentions     map[string][]string // mentioned JIDs by message ID
+	linkPreviews []storage.LinkPreview
+	reactions    []storage.Reaction
+	pollVotes    []storage.PollVote
+	statuses     []storage.StatusUpdate
+	rawMessages  map[string]*waE2E.Message // messages to archive by ID
+	chats        map[string]*storage.Chat  // chats of the batch's messages by canonical JID
+	pushNames    map[string]string         // push names collected from messages
+}
+
+// newHistoryBatch creates an empty history batch.
+func newHistoryBatch() *historyBatch {
+	return &historyBatch{
+		mentions:    make(map[string][]string),
+		rawMessages: make(map[string]*waE2E.Message),
+		chats:       make(map[string]*storage.Chat),
+		pushNames:   make(map[string]string),
+	}
+}
+
+// saveHistoryBatch stores a history batch in a foreign key friendly order,
+// chats before their messages and messages before what refers to them. It
+// returns the number of messages saved.
+func (c *Client) saveHistoryBatch(batch *historyBatch) int {
+	c.dropArchived(batch)
+
+	if len(batch.chats) > 0 {
+		c.log.Infof("Updating %d chat names from history sync", len(batch.chats))
+		for _, chat := range batch.chats {
 			if err := c.store.SaveChat(*chat); err != nil {
 				c.log.Warnf("Failed to update chat %s: %v", chat.JID, err)
 			}
 		}
 	}
 
-	if len(allMessages) > 0 {
-		c.log.Infof("Saving %d messages from history sync", len(allMessages))
+	if len(batch.messages) > 0 {
+		c.log.Infof("Saving %d messages from history sync", len(batch.messages))
 
-		if err := c.store.SaveBulk(allMessages); err != nil {
-			c.log.Errorf("Failed to save bulk messages: %v", err)
-			return
+		if err := c.store.SaveBulk(batch.messages); err != nil {
+			c.reportf(LogError, "Failed to save %d history sync messages: %v", len(batch.messages), err)
+			return 0
+		}
+	}
+
+	for messageID, raw := range batch.rawMessages {
+		c.archiveRaw(messageID, raw)
+	}
+
+	for messageID, mentioned := range batch.mentions {
+		if err := c.store.SaveMentions(messageID, mentioned); err != nil {
+			c.log.Warnf("Failed to save mentions for %s: %v", messageID, err)
 		}
+	}
 
-		c.log.Infof("History sync complete: %d chats updated, %d messages saved",
-			len(chatMap), len(allMessages))
+	for _, preview := range batch.linkPreviews {
+		if err := c.store.SaveLinkPreview(preview); err != nil {
+			c.log.Warnf("Failed to save link preview for %s: %v", preview.MessageID, err)
+		}
 	}
 
-	if len(allMediaMetadata) > 0 {
-		c.log.Infof("Saving %d media metadata records from history sync", len(allMediaMetadata))
+	if err := c.store.SaveReactions(batch.reactions); err != nil {
+		c.log.Warnf("Failed to save %d reactions from history sync: %v", len(batch.reactions), err)
+	}
+
+	if err := c.store.SavePollVotes(batch.pollVotes); err != nil {
+		c.log.Warnf("Failed to save %d poll votes from history sync: %v", len(batch.pollVotes), err)
+	}
+
+	if err := c.store.SaveStatuses(batch.statuses); err != nil {
+		c.log.Warnf("Failed to save %d statuses from history sync: %v", len(batch.statuses), err)
+	}
+
+	if len(batch.media) > 0 {
+		c.log.Infof("Saving %d media metadata records from history sync", len(batch.media))
 
 		savedCount := 0
 		pendingDownloads := []storage.MediaMetadata{}
 
-		for _, mediaMetadata := range allMediaMetadata {
+		for _, mediaMetadata := range batch.media {
 			if err := c.mediaStore.SaveMediaMetadata(mediaMetadata); err != nil {
 				c.log.Warnf("Failed to save media metadata for %s: %v", mediaMetadata.MessageID, err)
 			} else {
@@ -729,122 +1356,27 @@ func (c *Client) handleHistorySync(evt *events.HistorySync) {
 			}
 		}
 
-		c.log.Infof("Saved %d/%d media metadata records", savedCount, len(allMediaMetadata))
+		c.log.Infof("Saved %d/%d media metadata records", savedCount, len(batch.media))
 
-		// trigger async downloads for pending media (if enabled)
+		// pending media only exists when MEDIA_AUTO_DOWNLOAD_FROM_HISTORY is on
 		if len(pendingDownloads) > 0 {
-			if c.mediaConfig.AutoDownloadFromHistory {
-				c.log.Infof("Triggering downloads for %d media files from history sync", len(pendingDownloads))
-			} else {
-				c.log.Infof("Skipping auto-download for %d history media files (MEDIA_AUTO_DOWNLOAD_FROM_HISTORY=false)", len(pendingDownloads))
-			}
-		}
-
-		if len(pendingDownloads) > 0 && c.mediaConfig.AutoDownloadFromHistory {
-			// build message lookup map once (O(M) instead of O(N*M))
-			messageByID := make(map[string]*waE2E.Message)
-			for _, conv := range evt.Data.GetConversations() {
-				for _, histMsg := range conv.GetMessages() {
-					msg := histMsg.GetMessage()
-					if msg == nil {
-						continue
-					}
-					key := msg.GetKey()
-					if key == nil {
-						continue
-					}
-					id := key.GetID()
-					if id == "" {
-						continue
-					}
-					actualMessage := msg.GetMessage()
-					if actualMessage == nil {
-						continue
-					}
-					messageByID[id] = actualMessage
-				}
-			}
-
-			// now process downloads with O(1) lookups
-			var wg sync.WaitGroup
-			for _, metadata := range pendingDownloads {
-				wg.Add(1)
-				go func(meta storage.MediaMetadata) {
-					defer wg.Done()
-
-					actualMessage, ok := messageByID[meta.MessageID]
-					if !ok || actualMessage == nil {
-						c.log.Warnf("Could not find message %s for download", meta.MessageID)
-						return
-					}
-
-					// check if context was cancelled before starting download
-					select {
-					case <-c.ctx.Done():
-						c.log.Debugf("Context cancelled before downloading %s", meta.MessageID)
-						return
-					default:
-					}
-
-					// found the message, download media
-					downloadCtx, cancel := context.WithTimeout(c.ctx, 60*time.Second)
-					defer cancel()
-
-					filePath, err := c.downloadMediaWithRetry(downloadCtx, actualMessage, &meta)
-					if err != nil {
-						c.log.Errorf("Failed to download history media %s: %v", meta.MessageID, err)
-						// update status based on error type
-						if errors.Is(err, whatsmeow.ErrMediaDownloadFailedWith404) ||
-							errors.Is(err, whatsmeow.ErrMediaDownloadFailedWith410) {
-							c.mediaStore.UpdateDownloadStatus(meta.MessageID, "expired", nil, err)
-						} else {
-							c.mediaStore.UpdateDownloadStatus(meta.MessageID, "failed", nil, err)
-						}
-					} else {
-						// update status with file path on success
-						c.mediaStore.UpdateDownloadStatus(meta.MessageID, "downloaded", &filePath, nil)
-						c.log.Infof("Downloaded history media %s successfully", meta.MessageID)
-					}
-				}(metadata)
+			c.log.Infof("Queueing %d media files from history sync for download", len(pendingDownloads))
+			for _, meta := range pendingDownloads {
+				c.enqueueDownload(meta.MessageID)
 			}
-
-			// log completion asynchronously (don't block)
-			go func() {
-				wg.Wait()
-				c.log.Infof("Completed all %d history media downloads", len(pendingDownloads))
-			}()
 		}
 	}
 
 	// save additional push names collected from messages
-	if len(additionalPushNames) > 0 {
-		if err := c.store.SavePushNames(additionalPushNames); err != nil {
+	if len(batch.pushNames) > 0 {
+		if err := c.store.SavePushNames(batch.pushNames); err != nil {
 			c.log.Errorf("Failed to save additional push names: %v", err)
 		} else {
-			c.log.Infof("Saved %d additional push names from messages", len(additionalPushNames))
+			c.log.Infof("Saved %d additional push names from messages", len(batch.pushNames))
 		}
 	}
 
-	// signal waiting synchronous requests for ON_DEMAND syncs
-	if isOnDemand {
-		c.historySyncMux.Lock()
-		for _, conv := range evt.Data.GetConversations() {
-			chatJID, err := types.ParseJID(conv.GetID())
-			if err != nil {
-				continue
-			}
-			normalizedJID := c.normalizeJID(chatJID)
-			if syncChan, exists := c.historySyncChans[normalizedJID]; exists {
-				select {
-				case syncChan <- true:
-					c.log.Debugf("Signaled completion for chat %s", normalizedJID)
-				default:
-				}
-				delete(c.historySyncChans, normalizedJID)
-			}
-		}
-		c.historySyncMux.Unlock()
-	}
+	return len(batch.messages)
 }
 
 // extractReferral extracts Click-to-WhatsApp (CTWA) ad referral metadata from a message.
@@ -901,6 +1433,51 @@ func extractReferral(msg *waE2E.Message) *storage.ReferralInfo {
 	}
 }
 
+// messageContextInfo returns the ContextInfo attached to a message, if any.
+// ContextInfo can be attached to text as well as to media messages.
+func messageContextInfo(msg *waE2E.Message) *waE2E.ContextInfo {
+	if msg == nil {
+		return nil
+	}
+
+	switch {
+	case msg.GetExtendedTextMessage() != nil:
+		return msg.GetExtendedTextMessage().GetContextInfo()
+	case msg.GetImageMessage() != nil:
+		return msg.GetImageMessage().GetContextInfo()
+	case msg.GetVideoMessage() != nil:
+		return msg.GetVideoMessage().GetContextInfo()
+	case msg.GetDocumentMessage() != nil:
+		return msg.GetDocumentMessage().GetContextInfo()
+	case msg.GetAudioMessage() != nil:
+		return msg.GetAudioMessage().GetContextInfo()
+	case msg.GetStickerMessage() != nil:
+		return msg.GetStickerMessage().GetContextInfo()
+	}
+
+	return nil
+}
+
+// extractMentions returns the JIDs mentioned in a message's ContextInfo.
+func extractMentions(msg *waE2E.Message) []string {
+	return messageContextInfo(msg).GetMentionedJID()
+}
+
+// extractLinkPreview returns the link preview of an ExtendedTextMessage, or nil if there is none.
+func extractLinkPreview(msg *waE2E.Message, messageID string) *storage.LinkPreview {
+	ext := msg.GetExtendedTextMessage()
+	if ext == nil || ext.GetMatchedText() == "" {
+		return nil
+	}
+
+	return &storage.LinkPreview{
+		MessageID:   messageID,
+		URL:         ext.GetMatchedText(),
+		Title:       ext.GetTitle(),
+		Description: ext.GetDescription(),
+	}
+}
+
 // extractText extracts text content from a WhatsApp message.
 // It checks extended text first, then plain text, then media captions.
 func extractText(msg *waE2E.Message) string {
@@ -937,7 +1514,7 @@ func extractText(msg *waE2E.Message) string {
 }
 
 // getTypeFromMessage returns the high-level message type.
-// Possible values are text, media, reaction, poll, or unknown.
+// Possible values are text, media, reaction, poll, poll_vote, location, live_location, or unknown.
 func (c *Client) getTypeFromMessage(msg *waE2E.Message) string {
 	if msg == nil {
 		return "unknown"
@@ -956,9 +1533,14 @@ func (c *Client) getTypeFromMessage(msg *waE2E.Message) string {
 		return c.getTypeFromMessage(msg.DocumentWithCaptionMessage.Message)
 	case msg.ReactionMessage != nil, msg.EncReactionMessage != nil:
 		return "reaction"
-	// TODO: implement poll parse and poll update message events
-	case msg.PollCreationMessage != nil, msg.PollCreationMessageV3 != nil, msg.PollUpdateMessage != nil:
+	case pollCreation(msg) != nil:
 		return "poll"
+	case msg.PollUpdateMessage != nil:
+		return "poll_vote"
+	case msg.LocationMessage != nil:
+		return "location"
+	case msg.LiveLocationMessage != nil:
+		return "live_location"
 	case getMediaTypeFromMessage(msg) != "":
 		return "media"
 	case msg.Conversation != nil, msg.ExtendedTextMessage != nil, msg.ProtocolMessage != nil:
@@ -0,0 +1,31 @@
+package whatsapp
+
+import "go.mau.fi/whatsmeow/types/events"
+
+// handleLabelEdit maps a WhatsApp Business label created, renamed or deleted
+// through app state sync to a tag.
+func (c *Client) handleLabelEdit(evt *events.LabelEdit) {
+	var err error
+	if evt.Action.GetDeleted() {
+		err = c.store.DeleteLabel(evt.LabelID)
+	} else {
+		err = c.store.SaveLabel(evt.LabelID, evt.Action.GetName())
+	}
+	if err != nil {
+		c.log.Errorf("Failed to save label %s: %v", evt.LabelID, err)
+	}
+}
+
+// handleLabelAssociationChat tags or untags a chat with a WhatsApp Business label.
+func (c *Client) handleLabelAssociationChat(evt *events.LabelAssociationChat) {
+	if err := c.store.SetChatLabel(evt.LabelID, c.normalizeJID(evt.JID), evt.Action.GetLabeled()); err != nil {
+		c.log.Errorf("Failed to save label %s of chat %s: %v", evt.LabelID, evt.JID, err)
+	}
+}
+
+// handleLabelAssociationMessage tags or untags a message with a WhatsApp Business label.
+func (c *Client) handleLabelAssociationMessage(evt *events.LabelAssociationMessage) {
+	if err := c.store.SetMessageLabel(evt.LabelID, evt.MessageID, evt.Action.GetLabeled()); err != nil {
+		c.log.Errorf("Failed to save label %s of message %s: %v", evt.LabelID, evt.MessageID, err)
+	}
+}
@@ -3,7 +3,6 @@ package whatsapp
 import (
 	"context"
 	"crypto/sha256"
-	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -14,7 +13,6 @@ import (
 
 	"whatsapp-mcp/storage"
 
-	"go.mau.fi/whatsmeow"
 	"go.mau.fi/whatsmeow/proto/waE2E"
 )
 
@@ -75,6 +73,7 @@ func (c *Client) extractMediaMetadata(msg *waE2E.Message, messageID string, from
 			FileSHA256:     img.GetFileSHA256(),
 			FileEncSHA256:  img.GetFileEncSHA256(),
 			DownloadStatus: c.getInitialDownloadStatus("image", fileSize, fromHistory),
+			Thumbnail:      img.GetJPEGThumbnail(),
 		}
 	}
 
@@ -97,6 +96,7 @@ func (c *Client) extractMediaMetadata(msg *waE2E.Message, messageID string, from
 			FileSHA256:     vid.GetFileSHA256(),
 			FileEncSHA256:  vid.GetFileEncSHA256(),
 			DownloadStatus: c.getInitialDownloadStatus("video", fileSize, fromHistory),
+			Thumbnail:      vid.GetJPEGThumbnail(),
 		}
 	}
 
@@ -147,6 +147,7 @@ func (c *Client) extractMediaMetadata(msg *waE2E.Message, messageID string, from
 			FileSHA256:     doc.GetFileSHA256(),
 			FileEncSHA256:  doc.GetFileEncSHA256(),
 			DownloadStatus: c.getInitialDownloadStatus("document", fileSize, fromHistory),
+			Thumbnail:      doc.GetJPEGThumbnail(),
 		}
 	}
 
@@ -221,15 +222,15 @@ func (c *Client) downloadMedia(ctx context.Context, msg *waE2E.Message, meta *st
 	var data []byte
 	switch d := downloadable.(type) {
 	case *waE2E.ImageMessage:
-		data, err = c.wa.Download(ctx, d)
+		data, err = c.wa().Download(ctx, d)
 	case *waE2E.VideoMessage:
-		data, err = c.wa.Download(ctx, d)
+		data, err = c.wa().Download(ctx, d)
 	case *waE2E.AudioMessage:
-		data, err = c.wa.Download(ctx, d)
+		data, err = c.wa().Download(ctx, d)
 	case *waE2E.DocumentMessage:
-		data, err = c.wa.Download(ctx, d)
+		data, err = c.wa().Download(ctx, d)
 	case *waE2E.StickerMessage:
-		data, err = c.wa.Download(ctx, d)
+		data, err = c.wa().Download(ctx, d)
 	default:
 		return "", fmt.Errorf("unknown downloadable type")
 	}
@@ -336,8 +337,7 @@ func (c *Client) downloadMediaWithRetry(ctx context.Context, msg *waE2E.Message,
 
 		// is error retryable?
 		// 404/410 errors indicate expired/deleted media - don't retry
-		if errors.Is(err, whatsmeow.ErrMediaDownloadFailedWith404) ||
-			errors.Is(err, whatsmeow.ErrMediaDownloadFailedWith410) {
+		if isMediaExpired(err) {
 			return "", err
 		}
 
@@ -419,6 +419,10 @@ func mimeToExtension(mime string) string {
 		"text/plain":      ".txt",
 	}
 
+	// drop parameters such as "; codecs=opus"
+	mime, _, _ = strings.Cut(mime, ";")
+	mime = strings.TrimSpace(mime)
+
 	if ext, ok := extensions[mime]; ok {
 		return ext
 	}
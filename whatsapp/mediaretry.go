@@ -0,0 +1,144 @@
+package whatsapp
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"whatsapp-mcp/storage"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/proto/waMmsRetry"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+	"google.golang.org/protobuf/proto"
+)
+
+// mediaRetryTimeout is how long a media retry request waits for the phone to
+// answer before it is forgotten.
+const mediaRetryTimeout = 10 * time.Minute
+
+// mediaRetry is a request to the phone to re-upload expired media, kept
+// until the phone answers with the new path.
+type mediaRetry struct {
+	msg       *waE2E.Message
+	meta      storage.MediaMetadata
+	mediaType string
+	sentAt    time.Time
+}
+
+// isMediaExpired reports whether a download failed because the media is no
+// longer on the WhatsApp servers.
+func isMediaExpired(err error) bool {
+	return errors.Is(err, whatsmeow.ErrMediaDownloadFailedWith404) ||
+		errors.Is(err, whatsmeow.ErrMediaDownloadFailedWith410)
+}
+
+// downloadableMedia returns the part of a message holding the media.
+func downloadableMedia(msg *waE2E.Message) interface {
+	GetMediaKey() []byte
+} {
+	switch {
+	case msg.GetStickerMessage() != nil:
+		return msg.GetStickerMessage()
+	case msg.GetImageMessage() != nil:
+		return msg.GetImageMessage()
+	case msg.GetVideoMessage() != nil:
+		return msg.GetVideoMessage()
+	case msg.GetAudioMessage() != nil:
+		return msg.GetAudioMessage()
+	case msg.GetDocumentMessage() != nil:
+		return msg.GetDocumentMessage()
+	default:
+		return nil
+	}
+}
+
+// setMediaDirectPath points the media of a message to a re-uploaded copy.
+func setMediaDirectPath(msg *waE2E.Message, directPath string) {
+	switch {
+	case msg.GetStickerMessage() != nil:
+		msg.StickerMessage.DirectPath = proto.String(directPath)
+	case msg.GetImageMessage() != nil:
+		msg.ImageMessage.DirectPath = proto.String(directPath)
+	case msg.GetVideoMessage() != nil:
+		msg.VideoMessage.DirectPath = proto.String(directPath)
+	case msg.GetAudioMessage() != nil:
+		msg.AudioMessage.DirectPath = proto.String(directPath)
+	case msg.GetDocumentMessage() != nil:
+		msg.DocumentMessage.DirectPath = proto.String(directPath)
+	}
+}
+
+// requestMediaRetry asks the phone to re-upload expired media. The download
+// is attempted again when the phone answers, see handleMediaRetry. The media
+// stays expired if it can't be requested or the phone doesn't have it anymore.
+func (c *Client) requestMediaRetry(info *types.MessageInfo, msg *waE2E.Message, meta storage.MediaMetadata) {
+	media := downloadableMedia(msg)
+	if info == nil || media == nil || len(media.GetMediaKey()) == 0 {
+		return
+	}
+
+	c.mediaRetryMux.Lock()
+	for id, retry := range c.mediaRetries {
+		if time.Since(retry.sentAt) > mediaRetryTimeout {
+			delete(c.mediaRetries, id)
+		}
+	}
+	c.mediaRetries[info.ID] = &mediaRetry{
+		msg:       proto.Clone(msg).(*waE2E.Message),
+		meta:      meta,
+		mediaType: getMediaTypeFromMessage(msg),
+		sentAt:    time.Now(),
+	}
+	c.mediaRetryMux.Unlock()
+
+	if err := c.wa().SendMediaRetryReceipt(c.ctx, info, media.GetMediaKey()); err != nil {
+		c.log.Warnf("Failed to request media retry for %s: %v", info.ID, err)
+		c.mediaRetryMux.Lock()
+		delete(c.mediaRetries, info.ID)
+		c.mediaRetryMux.Unlock()
+		return
+	}
+	c.log.Infof("Media of %s expired, asked the phone to upload it again", info.ID)
+}
+
+// handleMediaRetry downloads re-uploaded media once the phone answers a media
+// retry request.
+func (c *Client) handleMediaRetry(evt *events.MediaRetry) {
+	c.mediaRetryMux.Lock()
+	retry, ok := c.mediaRetries[evt.MessageID]
+	delete(c.mediaRetries, evt.MessageID)
+	c.mediaRetryMux.Unlock()
+	if !ok {
+		return
+	}
+
+	notification, err := whatsmeow.DecryptMediaRetryNotification(evt, downloadableMedia(retry.msg).GetMediaKey())
+	if err == nil && notification.GetResult() != waMmsRetry.MediaRetryNotification_SUCCESS {
+		err = errors.New("phone could not upload the media again: " + notification.GetResult().String())
+	}
+	if err != nil {
+		c.log.Warnf("Media retry for %s failed: %v", evt.MessageID, err)
+		c.mediaStore.UpdateDownloadStatus(evt.MessageID, "expired", nil, err)
+		return
+	}
+
+	setMediaDirectPath(retry.msg, notification.GetDirectPath())
+
+	go func() {
+		downloadCtx, cancel := context.WithTimeout(c.ctx, 60*time.Second)
+		defer cancel()
+
+		filePath, err := c.downloadMediaWithRetry(downloadCtx, retry.msg, &retry.meta)
+		if err != nil {
+			c.log.Errorf("Failed to download re-uploaded media %s: %v", evt.MessageID, err)
+			c.mediaStore.UpdateDownloadStatus(evt.MessageID, "failed", nil, err)
+			return
+		}
+
+		c.mediaDownloaded(evt.MessageID, retry.mediaType, retry.meta.MimeType, filePath)
+		c.log.Infof("Downloaded re-uploaded media %s successfully", evt.MessageID)
+	}()
+}
@@ -0,0 +1,237 @@
+package whatsapp
+
+import (
+	"context"
+	"time"
+
+	"whatsapp-mcp/storage"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+const (
+	newsletterFetchCount = 50              // posts fetched per channel when syncing
+	newsletterSyncDelay  = 2 * time.Second // between channels, to not hammer the server
+)
+
+// newsletterName returns the name of a channel, asking WhatsApp the first time.
+func (c *Client) newsletterName(ctx context.Context, jid types.JID) string {
+	if name, ok := c.newsletterNames.Load(jid.String()); ok {
+		return name.(string)
+	}
+
+	if chat, err := c.store.GetChatByJID(jid.String()); err == nil && chat != nil && chat.PushName != "" {
+		c.newsletterNames.Store(jid.String(), chat.PushName)
+		return chat.PushName
+	}
+
+	info, err := c.wa().GetNewsletterInfo(ctx, jid)
+	if err != nil {
+		c.log.Debugf("Failed to get channel info for %s: %v", jid, err)
+		return ""
+	}
+	c.newsletterNames.Store(jid.String(), info.ThreadMeta.Name.Text)
+	return info.ThreadMeta.Name.Text
+}
+
+// syncNewsletters stores the recent posts of the channels I follow and
+// subscribes to their view and reaction counts.
+func (c *Client) syncNewsletters() {
+	ctx, cancel := context.WithTimeout(c.ctx, 5*time.Minute)
+	defer cancel()
+
+	newsletters, err := c.wa().GetSubscribedNewsletters(ctx)
+	if err != nil {
+		c.log.Errorf("Failed to get followed channels: %v", err)
+		return
+	}
+
+	jids := make([]types.JID, 0, len(newsletters))
+	posts := 0
+	for i, info := range newsletters {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(newsletterSyncDelay):
+			}
+		}
+		c.newsletterNames.Store(info.ID.String(), info.ThreadMeta.Name.Text)
+		jids = append(jids, info.ID)
+		posts += c.fetchNewsletterPosts(ctx, info.ID)
+	}
+
+	c.log.Infof("Synced %d posts of %d channels", posts, len(newsletters))
+	go c.subscribeNewsletters(jids)
+}
+
+// fetchNewsletterPosts stores the latest posts of a channel with their view
+// and reaction counts. It returns how many posts were stored.
+func (c *Client) fetchNewsletterPosts(ctx context.Context, jid types.JID) int {
+	messages, err := c.wa().GetNewsletterMessages(ctx, jid, &whatsmeow.GetNewsletterMessagesParams{Count: newsletterFetchCount})
+	if err != nil {
+		c.log.Warnf("Failed to get posts of channel %s: %v", jid, err)
+		return 0
+	}
+
+	var posts []storage.NewsletterPost
+	for _, post := range messages {
+		if post.Message == nil || post.MessageID == "" {
+			continue
+		}
+		if !c.saveNewsletterPost(ctx, jid, post.MessageID, post.Message, post.Timestamp) {
+			continue
+		}
+		posts = append(posts, storage.NewsletterPost{
+			MessageID: post.MessageID,
+			ChatJID:   jid.String(),
+			ServerID:  post.MessageServerID,
+			Views:     post.ViewsCount,
+			Reactions: post.ReactionCounts,
+			UpdatedAt: time.Now(),
+		})
+	}
+
+	if err := c.store.SaveNewsletterPosts(posts); err != nil {
+		c.log.Errorf("Failed to save counts of channel %s: %v", jid, err)
+	}
+	return len(posts)
+}
+
+// saveNewsletterPost stores a fetched channel post like a history message:
+// no webhooks, and media follows the history auto-download rules.
+func (c *Client) saveNewsletterPost(ctx context.Context, jid types.JID, messageID string, msg *waE2E.Message, timestamp time.Time) bool {
+	text := extractText(msg)
+	if text == "" {
+		switch {
+		case msg.GetImageMessage() != nil:
+			text = "[Image]"
+		case msg.GetVideoMessage() != nil:
+			text = "[Video]"
+		case msg.GetAudioMessage() != nil:
+			text = "[Audio]"
+		case msg.GetDocumentMessage() != nil:
+			text = "[Document]"
+		case msg.GetStickerMessage() != nil:
+			text = "[Sticker]"
+		case pollCreation(msg) != nil:
+			text = describePoll(pollCreation(msg))
+		default:
+			text = storage.UnknownMessageText
+		}
+	}
+
+	data := messageData{
+		MessageID:   messageID,
+		ChatJID:     jid,
+		SenderJID:   jid,
+		Text:        text,
+		Timestamp:   timestamp,
+		MessageType: c.getMessageType(msg),
+		LinkPreview: extractLinkPreview(msg, messageID),
+		Payload:     extractPayload(msg),
+	}
+	if err := c.processMessageData(ctx, data); err != nil {
+		return false
+	}
+
+	if poll := pollCreation(msg); poll != nil {
+		c.savePoll(messageID, jid, poll, timestamp)
+	}
+
+	mediaType := getMediaTypeFromMessage(msg)
+	if mediaType != "" && mediaType != "vcard" && mediaType != "contact_array" {
+		if meta := c.extractMediaMetadata(msg, messageID, true); meta != nil {
+			if err := c.mediaStore.SaveMediaMetadata(*meta); err != nil {
+				c.log.Errorf("Failed to save media metadata for %s: %v", messageID, err)
+			} else if meta.DownloadStatus == "pending" {
+				c.enqueueDownload(messageID)
+			}
+		}
+	}
+	return true
+}
+
+// saveLiveNewsletterPost records the server ID of a channel post received
+// live, so later live updates of its counts can find it.
+func (c *Client) saveLiveNewsletterPost(info types.MessageInfo) {
+	err := c.store.SaveNewsletterPosts([]storage.NewsletterPost{{
+		MessageID: info.ID,
+		ChatJID:   info.Chat.String(),
+		ServerID:  info.ServerID,
+		UpdatedAt: time.Now(),
+	}})
+	if err != nil {
+		c.log.Errorf("Failed to save channel post %s: %v", info.ID, err)
+	}
+}
+
+// subscribeNewsletters keeps a live updates subscription to the given
+// channels while connected. Subscriptions expire, so they are renewed; a
+// newer call (after a reconnect) replaces this one.
+func (c *Client) subscribeNewsletters(jids []types.JID) {
+	if len(jids) == 0 {
+		return
+	}
+	generation := c.newsletterSubs.Add(1)
+
+	for {
+		renew := time.Hour
+		for _, jid := range jids {
+			duration, err := c.wa().NewsletterSubscribeLiveUpdates(c.ctx, jid)
+			if err != nil {
+				c.log.Debugf("Failed to subscribe to live updates of channel %s: %v", jid, err)
+				continue
+			}
+			renew = min(renew, duration)
+		}
+		// renew a bit before the subscription expires
+		renew = max(renew-renew/10, time.Minute)
+
+		select {
+		case <-c.ctx.Done():
+			return
+		case <-time.After(renew):
+		}
+		if c.newsletterSubs.Load() != generation || c.ConnectionError() != nil {
+			return
+		}
+	}
+}
+
+// handleNewsletterLiveUpdate stores new view and reaction counts of channel posts.
+func (c *Client) handleNewsletterLiveUpdate(evt *events.NewsletterLiveUpdate) {
+	posts := make([]storage.NewsletterPost, 0, len(evt.Messages))
+	for _, post := range evt.Messages {
+		posts = append(posts, storage.NewsletterPost{
+			ChatJID:   evt.JID.String(),
+			ServerID:  post.MessageServerID,
+			Views:     post.ViewsCount,
+			Reactions: post.ReactionCounts,
+			UpdatedAt: evt.Time,
+		})
+	}
+
+	updated, err := c.store.UpdateNewsletterCounts(posts)
+	if err != nil {
+		c.log.Errorf("Failed to update counts of channel %s: %v", evt.JID, err)
+		return
+	}
+	c.log.Debugf("Updated counts of %d posts in channel %s", updated, evt.JID)
+}
+
+// handleNewsletterJoin stores the recent posts of a channel I started following.
+func (c *Client) handleNewsletterJoin(evt *events.NewsletterJoin) {
+	c.newsletterNames.Store(evt.ID.String(), evt.ThreadMeta.Name.Text)
+
+	go func() {
+		ctx, cancel := context.WithTimeout(c.ctx, time.Minute)
+		defer cancel()
+
+		posts := c.fetchNewsletterPosts(ctx, evt.ID)
+		c.log.Infof("Joined channel %s, stored %d posts", evt.ThreadMeta.Name.Text, posts)
+	}()
+}
@@ -0,0 +1,210 @@
+package whatsapp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+)
+
+// PairingStatus describes the login of a new device, for the pairing
+// endpoints and startup output.
+type PairingStatus struct {
+	Active      bool      `json:"active"`                 // waiting for a QR code scan or pairing code
+	QRCode      string    `json:"qr_code,omitempty"`      // content of the current QR code, replaced about every 20 seconds
+	ExpiresAt   time.Time `json:"expires_at,omitempty"`   // when the current QR code is replaced
+	Phone       string    `json:"phone,omitempty"`        // phone number pairing with a code, if any
+	PairingCode string    `json:"pairing_code,omitempty"` // code to enter on the phone, if pairing with a phone number
+	LastEvent   string    `json:"last_event,omitempty"`   // last pairing event: code, success, timeout or an error
+	Error       string    `json:"error,omitempty"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// pairing tracks the login of a new device, see StartPairing.
+type pairing struct {
+	mu        sync.Mutex
+	status    PairingStatus
+	cancel    context.CancelFunc // stops the running QR channel
+	listeners []func(PairingStatus)
+}
+
+// PairingStatus returns the state of the current or last pairing.
+func (c *Client) PairingStatus() PairingStatus {
+	c.pairing.mu.Lock()
+	defer c.pairing.mu.Unlock()
+	return c.pairing.status
+}
+
+// AddPairingListener registers fn to be called whenever the pairing status
+// changes, e.g. to show new QR codes. Listeners must not block.
+func (c *Client) AddPairingListener(fn func(PairingStatus)) {
+	c.pairing.mu.Lock()
+	defer c.pairing.mu.Unlock()
+	c.pairing.listeners = append(c.pairing.listeners, fn)
+}
+
+// updatePairing changes the pairing status and notifies the listeners.
+func (c *Client) updatePairing(update func(*PairingStatus)) {
+	c.pairing.mu.Lock()
+	update(&c.pairing.status)
+	c.pairing.status.UpdatedAt = time.Now()
+	status := c.pairing.status
+	listeners := c.pairing.listeners
+	c.pairing.mu.Unlock()
+
+	for _, fn := range listeners {
+		fn(status)
+	}
+}
+
+// StartPairing connects without an account and starts pairing a new device
+// in the background, with a pairing code for phone if set or QR codes
+// otherwise. Follow it with PairingStatus or AddPairingListener. A pairing
+// already running is restarted.
+func (c *Client) StartPairing(phone string) error {
+	if c.IsLoggedIn() {
+		return errors.New("already logged in, log out first to pair again")
+	}
+
+	c.stopPairing()
+
+	ctx, cancel := context.WithCancel(c.ctx)
+	qrChan, err := c.wa().GetQRChannel(ctx)
+	if err != nil {
+		cancel()
+		return err
+	}
+
+	c.pairing.mu.Lock()
+	c.pairing.cancel = cancel
+	c.pairing.mu.Unlock()
+
+	c.setState(StatePairing, "")
+	c.updatePairing(func(status *PairingStatus) {
+		*status = PairingStatus{Active: true, Phone: phone}
+	})
+
+	go c.runPairing(ctx, qrChan, phone)
+	go func() {
+		if err := c.Connect(); err != nil {
+			c.log.Errorf("failed to connect: %v", err)
+		}
+	}()
+
+	return nil
+}
+
+// stopPairing stops a running pairing and closes its connection.
+func (c *Client) stopPairing() {
+	c.pairing.mu.Lock()
+	cancel := c.pairing.cancel
+	c.pairing.cancel = nil
+	c.pairing.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+		c.wa().Disconnect()
+	}
+}
+
+// runPairing follows the QR channel of a pairing until it succeeds, times out
+// or is stopped.
+func (c *Client) runPairing(ctx context.Context, qrChan <-chan whatsmeow.QRChannelItem, phone string) {
+	for evt := range qrChan {
+		switch evt.Event {
+		case whatsmeow.QRChannelEventCode:
+			c.updatePairing(func(status *PairingStatus) {
+				status.QRCode = evt.Code
+				status.ExpiresAt = time.Now().Add(evt.Timeout)
+				status.LastEvent = evt.Event
+			})
+
+			// the first QR code means we're connected and can ask for a pairing code instead
+			if phone != "" && c.PairingStatus().PairingCode == "" {
+				code, err := c.PairPhone(ctx, phone)
+				c.updatePairing(func(status *PairingStatus) {
+					status.PairingCode = code
+					if err != nil {
+						status.Error = fmt.Sprintf("failed to request pairing code: %v", err)
+					}
+				})
+			}
+		case whatsmeow.QRChannelSuccess.Event:
+			c.updatePairing(func(status *PairingStatus) {
+				*status = PairingStatus{LastEvent: evt.Event}
+			})
+			c.reportf(LogInfo, "Paired a new device")
+		default:
+			cause := evt.Event
+			if evt.Error != nil {
+				cause = evt.Error.Error()
+			}
+			c.updatePairing(func(status *PairingStatus) {
+				status.Active = false
+				status.QRCode = ""
+				status.PairingCode = ""
+				status.LastEvent = evt.Event
+				status.Error = cause
+			})
+			if ctx.Err() == nil {
+				c.reportf(LogWarning, "Pairing ended: %s, start it again to get a new QR code", cause)
+			}
+		}
+	}
+}
+
+// Logout unlinks the device from the WhatsApp account, deletes its keys from
+// the auth store and starts pairing a new device (see StartPairing), so the
+// server doesn't have to be restarted to log in again. Stored messages are
+// kept. It also works after the device was unlinked from the phone.
+func (c *Client) Logout(ctx context.Context, phone string) error {
+	c.logoutMu.Lock()
+	defer c.logoutMu.Unlock()
+
+	c.stopPairing()
+
+	wa := c.wa()
+	if c.IsLoggedIn() {
+		// no reconnects while the device goes away
+		c.setState(StateLoggedOut, "logged out by request")
+
+		if wa.IsConnected() {
+			if err := wa.Logout(ctx); err != nil {
+				if wa.IsConnected() {
+					c.setState(StateConnected, "")
+				} else {
+					c.setState(StateDisconnected, "")
+					c.connectionLost(err.Error())
+				}
+				return fmt.Errorf("failed to log out: %w", err)
+			}
+		} else {
+			// the phone keeps listing the device until it's removed there
+			c.log.Warnf("Not connected, deleting the device from the auth store without unlinking it")
+			if err := wa.Store.Delete(ctx); err != nil {
+				return fmt.Errorf("failed to delete device: %w", err)
+			}
+		}
+		c.reportf(LogInfo, "Logged out from WhatsApp")
+	}
+
+	// a logged out client keeps the deleted device, pairing needs a new one
+	waClient, err := newWAClient(c.container.NewDevice(), c.deviceConfig, c.log)
+	if err != nil {
+		return err
+	}
+	waClient.AddEventHandler(c.eventHandler)
+
+	// the loops would reconnect or sync groups with the old client
+	c.stopLoops()
+	c.loops.Wait()
+	wa.Disconnect()
+	c.waClient.Store(waClient)
+	c.savedAliases.Clear()
+	c.startLoops()
+
+	return c.StartPairing(phone)
+}
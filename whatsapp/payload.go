@@ -0,0 +1,94 @@
+package whatsapp
+
+import (
+	"fmt"
+	"strings"
+
+	"go.mau.fi/whatsmeow/proto/waE2E"
+
+	"whatsapp-mcp/storage"
+)
+
+// extractPayload returns the structured content of location and contact messages.
+// It returns nil for other messages.
+func extractPayload(msg *waE2E.Message) *storage.MessagePayload {
+	if msg == nil {
+		return nil
+	}
+
+	switch {
+	case msg.GetLocationMessage() != nil:
+		loc := msg.GetLocationMessage()
+		return &storage.MessagePayload{Location: &storage.Location{
+			Latitude:  loc.GetDegreesLatitude(),
+			Longitude: loc.GetDegreesLongitude(),
+			Name:      loc.GetName(),
+			Address:   loc.GetAddress(),
+		}}
+	case msg.GetLiveLocationMessage() != nil:
+		loc := msg.GetLiveLocationMessage()
+		return &storage.MessagePayload{Location: &storage.Location{
+			Latitude:  loc.GetDegreesLatitude(),
+			Longitude: loc.GetDegreesLongitude(),
+			Name:      loc.GetCaption(),
+			IsLive:    true,
+		}}
+	case msg.GetContactMessage() != nil:
+		return &storage.MessagePayload{Contacts: []storage.SharedContact{sharedContact(msg.GetContactMessage())}}
+	case msg.GetContactsArrayMessage() != nil:
+		var contacts []storage.SharedContact
+		for _, contact := range msg.GetContactsArrayMessage().GetContacts() {
+			contacts = append(contacts, sharedContact(contact))
+		}
+		return &storage.MessagePayload{Contacts: contacts}
+	}
+
+	return nil
+}
+
+// sharedContact converts a shared contact card, reading the phone numbers from its vCard.
+func sharedContact(contact *waE2E.ContactMessage) storage.SharedContact {
+	shared := storage.SharedContact{Name: contact.GetDisplayName()}
+
+	for _, line := range strings.Split(contact.GetVcard(), "\n") {
+		line = strings.TrimSpace(line)
+		// e.g. TEL;type=CELL;waid=5511999999999:+55 11 99999-9999
+		if !strings.HasPrefix(strings.ToUpper(line), "TEL") {
+			continue
+		}
+		if i := strings.LastIndex(line, ":"); i >= 0 && i < len(line)-1 {
+			shared.Phones = append(shared.Phones, strings.TrimSpace(line[i+1:]))
+		}
+	}
+
+	return shared
+}
+
+// describePayload returns the text stored for a location or contact message.
+func describePayload(payload *storage.MessagePayload) string {
+	if loc := payload.Location; loc != nil {
+		label := "[Location]"
+		if loc.IsLive {
+			label = "[Live location]"
+		}
+
+		var parts []string
+		for _, s := range []string{loc.Name, loc.Address} {
+			if s != "" {
+				parts = append(parts, s)
+			}
+		}
+		parts = append(parts, fmt.Sprintf("(%.6f, %.6f)", loc.Latitude, loc.Longitude))
+		return label + " " + strings.Join(parts, " ")
+	}
+
+	contacts := make([]string, 0, len(payload.Contacts))
+	for _, contact := range payload.Contacts {
+		if len(contact.Phones) > 0 {
+			contacts = append(contacts, fmt.Sprintf("%s (%s)", contact.Name, strings.Join(contact.Phones, ", ")))
+		} else {
+			contacts = append(contacts, contact.Name)
+		}
+	}
+	return "[Contact] " + strings.Join(contacts, "; ")
+}
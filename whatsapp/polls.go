@@ -0,0 +1,183 @@
+package whatsapp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"whatsapp-mcp/storage"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/proto/waWeb"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// pollCreation returns the poll of a poll creation message, whichever
+// version of it was sent, or nil for other messages.
+func pollCreation(msg *waE2E.Message) *waE2E.PollCreationMessage {
+	for _, poll := range []*waE2E.PollCreationMessage{
+		msg.GetPollCreationMessage(),
+		msg.GetPollCreationMessageV2(),
+		msg.GetPollCreationMessageV3(),
+		msg.GetPollCreationMessageV5(),
+		msg.GetPollCreationMessageV6(),
+	} {
+		if poll != nil {
+			return poll
+		}
+	}
+	return nil
+}
+
+// pollOptionNames returns the option names of a poll, in order.
+func pollOptionNames(poll *waE2E.PollCreationMessage) []string {
+	options := make([]string, 0, len(poll.GetOptions()))
+	for _, option := range poll.GetOptions() {
+		options = append(options, option.GetOptionName())
+	}
+	return options
+}
+
+// describePoll returns the text stored for a poll message.
+func describePoll(poll *waE2E.PollCreationMessage) string {
+	return fmt.Sprintf("[Poll] %s (%s)", poll.GetName(), strings.Join(pollOptionNames(poll), " / "))
+}
+
+// savePoll stores the options of a poll message so votes can be tallied.
+func (c *Client) savePoll(messageID string, chatJID types.JID, poll *waE2E.PollCreationMessage, timestamp time.Time) {
+	err := c.store.SavePoll(storage.Poll{
+		MessageID:       messageID,
+		ChatJID:         c.normalizeJID(chatJID),
+		Question:        poll.GetName(),
+		Options:         pollOptionNames(poll),
+		SelectableCount: int(poll.GetSelectableOptionsCount()),
+		CreatedAt:       timestamp,
+	})
+	if err != nil {
+		c.log.Errorf("Failed to save poll %s: %v", messageID, err)
+	}
+}
+
+// selectedPollOptions maps the SHA-256 hashes of a vote back to the option
+// names of the poll. Hashes of unknown options are dropped.
+func selectedPollOptions(options []string, hashes [][]byte) []string {
+	byHash := make(map[string]string, len(options))
+	for i, hash := range whatsmeow.HashPollOptions(options) {
+		byHash[string(hash)] = options[i]
+	}
+
+	selected := make([]string, 0, len(hashes))
+	for _, hash := range hashes {
+		if option, ok := byHash[string(hash)]; ok {
+			selected = append(selected, option)
+		}
+	}
+	return selected
+}
+
+// handlePollVote decrypts a vote in a poll, stores it and emits a
+// message.poll_vote webhook event with the updated results.
+func (c *Client) handlePollVote(ctx context.Context, evt *events.Message) {
+	update := evt.Message.GetPollUpdateMessage()
+	pollID := update.GetPollCreationMessageKey().GetID()
+	if pollID == "" {
+		return
+	}
+
+	poll, err := c.store.GetPoll(pollID)
+	if err != nil {
+		c.log.Errorf("Failed to load poll %s: %v", pollID, err)
+		return
+	}
+	if poll == nil {
+		c.log.Debugf("Skipping vote %s in unknown poll %s", evt.Info.ID, pollID)
+		return
+	}
+
+	vote, err := c.wa().DecryptPollVote(ctx, evt)
+	if err != nil {
+		c.log.Warnf("Failed to decrypt vote %s in poll %s: %v", evt.Info.ID, pollID, err)
+		return
+	}
+
+	pollVote := storage.PollVote{
+		PollMessageID: pollID,
+		ChatJID:       c.normalizeJID(evt.Info.Chat),
+		VoterJID:      c.normalizeJID(evt.Info.Sender),
+		Options:       selectedPollOptions(poll.Options, vote.GetSelectedOptions()),
+		Timestamp:     evt.Info.Timestamp,
+	}
+	if err := c.store.SavePollVote(pollVote); err != nil {
+		c.log.Errorf("Failed to save vote in poll %s: %v", pollID, err)
+		return
+	}
+	c.log.Debugf("Saved vote %v from %s in poll %s", pollVote.Options, pollVote.VoterJID, pollID)
+	c.emitPollVote(pollVote)
+}
+
+// emitPollVote emits a message.poll_vote webhook event with the poll's
+// current results.
+func (c *Client) emitPollVote(vote storage.PollVote) {
+	if c.webhookManager == nil {
+		return
+	}
+
+	msg, err := c.store.GetMessageWithNames(context.Background(), vote.PollMessageID)
+	if err != nil {
+		c.log.Errorf("Failed to load poll %s for webhook: %v", vote.PollMessageID, err)
+		return
+	}
+	if msg == nil {
+		msg = &storage.MessageWithNames{Message: storage.Message{ID: vote.PollMessageID, ChatJID: vote.ChatJID, MessageType: "poll"}}
+	}
+
+	results, err := c.store.GetPollResults(vote.PollMessageID)
+	if err != nil || results == nil {
+		c.log.Errorf("Failed to load results of poll %s for webhook: %v", vote.PollMessageID, err)
+		return
+	}
+
+	if err := c.webhookManager.EmitPollVoteEvent(*msg, vote, *results); err != nil {
+		c.log.Errorf("Failed to emit poll vote webhook for %s: %v", vote.PollMessageID, err)
+	}
+}
+
+// historyPollVotes returns the votes history sync attached to a poll
+// message. They arrive already decrypted.
+func (c *Client) historyPollVotes(chatJID types.JID, msg *waWeb.WebMessageInfo, poll *waE2E.PollCreationMessage) []storage.PollVote {
+	options := pollOptionNames(poll)
+
+	var votes []storage.PollVote
+	for _, update := range msg.GetPollUpdates() {
+		key := update.GetPollUpdateMessageKey()
+		if key == nil || update.GetVote() == nil {
+			continue
+		}
+
+		// the vote key identifies who voted, like a message key
+		var voter types.JID
+		switch {
+		case key.GetFromMe() && c.wa().Store.ID != nil:
+			voter = *c.wa().Store.ID
+		case key.GetParticipant() != "":
+			voter, _ = types.ParseJID(key.GetParticipant())
+		default:
+			voter, _ = types.ParseJID(key.GetRemoteJID())
+		}
+		if voter.IsEmpty() {
+			continue
+		}
+
+		votes = append(votes, storage.PollVote{
+			PollMessageID: msg.GetKey().GetID(),
+			ChatJID:       c.normalizeJID(chatJID),
+			VoterJID:      c.normalizeJID(voter),
+			Options:       selectedPollOptions(options, update.GetVote().GetSelectedOptions()),
+			Timestamp:     time.UnixMilli(update.GetSenderTimestampMS()),
+		})
+	}
+	return votes
+}
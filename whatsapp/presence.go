@@ -0,0 +1,85 @@
+package whatsapp
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+// ownPresence tracks the presence this device last sent.
+type ownPresence struct {
+	mu     sync.Mutex
+	online bool
+	gen    int // bumped on every change, so a stale on_send timer does nothing
+}
+
+// applyPresenceMode sends the configured presence after connecting.
+func (c *Client) applyPresenceMode() {
+	var err error
+	switch c.presenceConfig.Mode {
+	case PresenceModeAvailable:
+		err = c.SetPresence(c.ctx, true)
+	case PresenceModeUnavailable, PresenceModeOnSend:
+		err = c.SetPresence(c.ctx, false)
+	}
+	if err != nil {
+		c.log.Warnf("Failed to send presence: %v", err)
+	}
+}
+
+// SetPresence sets my presence to online (available) or offline right away.
+// In on_send mode a later send goes online again for a while.
+func (c *Client) SetPresence(ctx context.Context, available bool) error {
+	c.presence.mu.Lock()
+	defer c.presence.mu.Unlock()
+
+	c.presence.gen++
+	return c.sendPresence(ctx, available)
+}
+
+// presenceForSend goes online before sending a message in on_send mode, and
+// offline again once no message has been sent for the linger time.
+func (c *Client) presenceForSend(ctx context.Context) {
+	if c.presenceConfig.Mode != PresenceModeOnSend {
+		return
+	}
+
+	c.presence.mu.Lock()
+	defer c.presence.mu.Unlock()
+
+	c.presence.gen++
+	if !c.presence.online {
+		if err := c.sendPresence(ctx, true); err != nil {
+			c.log.Warnf("Failed to send available presence: %v", err)
+			return
+		}
+	}
+
+	gen := c.presence.gen
+	time.AfterFunc(c.presenceConfig.Linger, func() {
+		c.presence.mu.Lock()
+		defer c.presence.mu.Unlock()
+
+		if c.presence.gen != gen {
+			return // sent again or changed since
+		}
+		if err := c.sendPresence(c.ctx, false); err != nil {
+			c.log.Warnf("Failed to send unavailable presence: %v", err)
+		}
+	})
+}
+
+// sendPresence sends my presence. The caller holds c.presence.mu.
+func (c *Client) sendPresence(ctx context.Context, available bool) error {
+	state := types.PresenceUnavailable
+	if available {
+		state = types.PresenceAvailable
+	}
+	if err := c.wa().SendPresence(ctx, state); err != nil {
+		return err
+	}
+	c.presence.online = available
+	return nil
+}
@@ -0,0 +1,36 @@
+package whatsapp
+
+import (
+	"whatsapp-mcp/storage"
+
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"google.golang.org/protobuf/proto"
+)
+
+// unknownMessageText is the text of live messages with an unrecognized type.
+const unknownMessageText = "[Unknown message type]"
+
+// shouldArchiveRaw reports whether the raw message of a parsed message is
+// archived in the current RAW_MESSAGE_ARCHIVE mode.
+func (c *Client) shouldArchiveRaw(messageType, text string) bool {
+	switch c.rawArchive {
+	case RawArchiveAll:
+		return true
+	case RawArchiveUnknown:
+		return messageType == "unknown" || text == unknownMessageText || text == storage.UnknownMessageText
+	default:
+		return false
+	}
+}
+
+// archiveRaw stores the serialized message. The message must already be stored.
+func (c *Client) archiveRaw(messageID string, msg *waE2E.Message) {
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		c.log.Warnf("Failed to serialize raw message %s: %v", messageID, err)
+		return
+	}
+	if err := c.store.SaveRawMessage(messageID, data); err != nil {
+		c.log.Warnf("Failed to archive raw message %s: %v", messageID, err)
+	}
+}
@@ -0,0 +1,121 @@
+package whatsapp
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mau.fi/whatsmeow/appstate"
+	"go.mau.fi/whatsmeow/proto/waHistorySync"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// handleMarkChatAsRead stores a chat being marked read or unread on one of my
+// devices through app state sync.
+func (c *Client) handleMarkChatAsRead(evt *events.MarkChatAsRead) {
+	chatJID := c.normalizeJID(evt.JID)
+
+	var err error
+	if evt.Action.GetRead() {
+		readAt := evt.Timestamp
+		if ts := evt.Action.GetMessageRange().GetLastMessageTimestamp(); ts > 0 {
+			readAt = time.Unix(ts, 0)
+		}
+		err = c.store.MarkChatRead(chatJID, readAt)
+	} else {
+		err = c.store.SetChatMarkedUnread(chatJID, true)
+	}
+	if err != nil {
+		c.log.Errorf("Failed to save read state of %s: %v", chatJID, err)
+	}
+}
+
+// saveHistoryReadState stores the read state of the conversations in a
+// history sync: a conversation without unread messages is read up to its last
+// message, and "marked unread" is kept as is. Chats must already be saved.
+func (c *Client) saveHistoryReadState(data *waHistorySync.HistorySync) {
+	for _, conv := range data.GetConversations() {
+		jid, err := types.ParseJID(conv.GetID())
+		if err != nil {
+			continue
+		}
+		chatJID := c.normalizeJID(jid)
+
+		if conv.GetMarkedAsUnread() {
+			err = c.store.SetChatMarkedUnread(chatJID, true)
+		} else if conv.UnreadCount != nil && conv.GetUnreadCount() == 0 && conv.GetConversationTimestamp() > 0 {
+			err = c.store.MarkChatRead(chatJID, time.Unix(int64(conv.GetConversationTimestamp()), 0))
+		}
+		if err != nil {
+			c.log.Warnf("Failed to save read state of %s: %v", chatJID, err)
+		}
+	}
+}
+
+// MarkChatRead sends read receipts for the unread messages of a chat and
+// marks it read, on WhatsApp and locally. It returns the number of messages
+// that were unread.
+func (c *Client) MarkChatRead(ctx context.Context, chatJID string) (int, error) {
+	jid, err := types.ParseJID(chatJID)
+	if err != nil {
+		return 0, err
+	}
+	canonical := c.normalizeJID(jid)
+
+	chat, err := c.store.GetChatByJID(canonical)
+	if err != nil {
+		return 0, err
+	}
+	if chat == nil {
+		return 0, fmt.Errorf("chat not found: %s", canonical)
+	}
+
+	unread, err := c.store.GetUnreadMessages(canonical)
+	if err != nil {
+		return 0, err
+	}
+
+	// receipts are sent per sender, which is only needed in groups
+	readAt := time.Now()
+	bySender := make(map[string][]types.MessageID)
+	var senders []string
+	for _, msg := range unread {
+		sender := ""
+		if chat.IsGroup {
+			sender = msg.SenderJID
+		}
+		if _, ok := bySender[sender]; !ok {
+			senders = append(senders, sender)
+		}
+		bySender[sender] = append(bySender[sender], msg.ID)
+	}
+	for _, sender := range senders {
+		var senderJID types.JID
+		if sender != "" {
+			if senderJID, err = types.ParseJID(sender); err != nil {
+				return 0, err
+			}
+		}
+		if err := c.wa().MarkRead(ctx, bySender[sender], readAt, jid, senderJID); err != nil {
+			return 0, fmt.Errorf("failed to send read receipts: %w", err)
+		}
+	}
+
+	if chat.MarkedUnread {
+		patch := appstate.BuildMarkChatAsRead(jid, true, chat.LastMessageTime, nil)
+		if err := c.wa().SendAppState(ctx, patch); err != nil {
+			return 0, fmt.Errorf("failed to clear marked unread: %w", err)
+		}
+	}
+
+	// unread messages are counted by timestamp, so read up to the newest one
+	if len(unread) > 0 {
+		readAt = unread[len(unread)-1].Timestamp
+	}
+	if chat.LastMessageTime.After(readAt) {
+		readAt = chat.LastMessageTime
+	}
+
+	return len(unread), c.store.MarkChatRead(canonical, readAt)
+}
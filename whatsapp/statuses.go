@@ -0,0 +1,53 @@
+package whatsapp
+
+import (
+	"time"
+
+	"whatsapp-mcp/storage"
+
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+)
+
+// statusType returns the kind of a status update, or "" for statuses that
+// aren't kept (voice statuses, status reactions...).
+func statusType(msg *waE2E.Message) string {
+	switch {
+	case msg.GetImageMessage() != nil:
+		return storage.StatusImage
+	case msg.GetVideoMessage() != nil:
+		return storage.StatusVideo
+	case extractText(msg) != "":
+		return storage.StatusText
+	}
+	return ""
+}
+
+// statusUpdate returns the status update of a message posted by a contact to
+// status@broadcast, or nil if it is not one.
+func (c *Client) statusUpdate(messageID string, chatJID, senderJID types.JID, isFromMe bool, msg *waE2E.Message, timestamp time.Time) *storage.StatusUpdate {
+	if chatJID != types.StatusBroadcastJID || isFromMe {
+		return nil
+	}
+	kind := statusType(msg)
+	if kind == "" {
+		return nil
+	}
+	return &storage.StatusUpdate{
+		MessageID: messageID,
+		SenderJID: c.normalizeJID(senderJID),
+		Type:      kind,
+		PostedAt:  timestamp,
+	}
+}
+
+// saveStatus marks a stored message as a status update if it is one.
+func (c *Client) saveStatus(info types.MessageInfo, msg *waE2E.Message) {
+	status := c.statusUpdate(info.ID, info.Chat, info.Sender, info.IsFromMe, msg, info.Timestamp)
+	if status == nil {
+		return
+	}
+	if err := c.store.SaveStatuses([]storage.StatusUpdate{*status}); err != nil {
+		c.log.Errorf("Failed to save status %s: %v", info.ID, err)
+	}
+}
@@ -0,0 +1,210 @@
+package whatsapp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// Connection states reported by ConnectionStatus.
+const (
+	StateConnecting   = "connecting"
+	StateConnected    = "connected"
+	StateDisconnected = "disconnected" // reconnecting in the background
+	StateReplaced     = "replaced"     // another client took over the session, not reconnecting
+	StateLoggedOut    = "logged_out"   // the device was unlinked, re-pairing needed
+	StatePairing      = "pairing"      // not logged in, waiting for a new device to be paired
+)
+
+const (
+	minReconnectWait = 2 * time.Second
+	maxReconnectWait = 5 * time.Minute
+)
+
+// ConnectionStatus describes the WhatsApp connection, for /health and errors.
+type ConnectionStatus struct {
+	State             string    `json:"state"`
+	Since             time.Time `json:"since"`                // when the state last changed
+	LastConnected     time.Time `json:"last_connected"`       // when the connection was last established, zero if never
+	LastError         string    `json:"last_error,omitempty"` // why the connection was last lost or failed
+	ReconnectAttempts int       `json:"reconnect_attempts"`   // attempts since the connection was lost
+	Reconnects        int       `json:"reconnects"`           // successful reconnects since startup
+	FailedReconnects  int       `json:"failed_reconnects"`    // failed attempts since startup
+}
+
+// supervisor keeps the connection up: whatsmeow's own auto-reconnect (a
+// linear, unbounded backoff) is turned off and lost connections are retried
+// here with an exponential backoff instead, until the device is logged out.
+type supervisor struct {
+	mu     sync.Mutex
+	status ConnectionStatus
+	wake   chan struct{} // asks the reconnect loop to run
+}
+
+// newSupervisor creates a supervisor for a client that hasn't connected yet.
+func newSupervisor() *supervisor {
+	return &supervisor{
+		status: ConnectionStatus{State: StateConnecting, Since: time.Now()},
+		wake:   make(chan struct{}, 1),
+	}
+}
+
+// ConnectionStatus returns the current connection state and reconnect counters.
+func (c *Client) ConnectionStatus() ConnectionStatus {
+	c.conn.mu.Lock()
+	defer c.conn.mu.Unlock()
+	return c.conn.status
+}
+
+// ConnectionError returns nil when connected, or an error describing why
+// WhatsApp can't be used right now.
+func (c *Client) ConnectionError() error {
+	status := c.ConnectionStatus()
+	switch status.State {
+	case StateConnected:
+		return nil
+	case StateLoggedOut:
+		return errors.New("WhatsApp is logged out: re-pairing needed (use the logout tool or POST /api/auth/logout to pair again)")
+	case StatePairing:
+		if pairing := c.PairingStatus(); !pairing.Active {
+			return errors.New("WhatsApp is not logged in and pairing stopped: start it again from /qr or with POST /api/auth/pairing")
+		}
+		return errors.New("WhatsApp is not logged in: pair the device with the QR code at /qr, or the pairing code from GET /api/auth/pairing")
+	case StateReplaced:
+		return errors.New("WhatsApp session was taken over by another client: restart the server to reconnect")
+	case StateDisconnected:
+		if status.LastError != "" {
+			return fmt.Errorf("WhatsApp is disconnected, reconnecting (attempt %d, last error: %s)", status.ReconnectAttempts, status.LastError)
+		}
+		return fmt.Errorf("WhatsApp is disconnected, reconnecting (attempt %d)", status.ReconnectAttempts)
+	default:
+		return errors.New("WhatsApp is not connected yet")
+	}
+}
+
+// setState records a state change, with the error that caused it if any.
+func (c *Client) setState(state string, cause string) {
+	c.conn.mu.Lock()
+	defer c.conn.mu.Unlock()
+
+	if state == StateConnected && c.conn.status.State == StateDisconnected {
+		c.conn.status.Reconnects++
+	}
+	if state == StateConnected {
+		c.conn.status.ReconnectAttempts = 0
+		c.conn.status.LastConnected = time.Now()
+	}
+	if cause != "" {
+		c.conn.status.LastError = cause
+	}
+	if c.conn.status.State != state {
+		c.conn.status.State = state
+		c.conn.status.Since = time.Now()
+	}
+}
+
+// connectionLost marks the connection as lost and wakes the reconnect loop,
+// unless the device was logged out or replaced meanwhile, or isn't paired yet.
+func (c *Client) connectionLost(cause string) {
+	c.conn.mu.Lock()
+	state := c.conn.status.State
+	c.conn.mu.Unlock()
+	if state == StateLoggedOut || state == StateReplaced || state == StatePairing {
+		return
+	}
+
+	c.setState(StateDisconnected, cause)
+	select {
+	case c.conn.wake <- struct{}{}:
+	default: // already woken
+	}
+}
+
+// handleConnectionEvent updates the connection state from whatsmeow events.
+func (c *Client) handleConnectionEvent(evt any) {
+	switch v := evt.(type) {
+	case *events.Connected:
+		c.setState(StateConnected, "")
+	case *events.Disconnected:
+		c.connectionLost("connection closed by the server")
+	case *events.StreamError:
+		c.connectionLost("stream error " + v.Code)
+	case *events.ConnectFailure:
+		c.connectionLost(fmt.Sprintf("connect failure %d: %s", v.Reason, v.Message))
+	case *events.TemporaryBan:
+		c.connectionLost(v.String())
+	case *events.KeepAliveTimeout:
+		// whatsmeow only drops a dead connection itself when its auto-reconnect is on
+		if time.Since(v.LastSuccess) > whatsmeow.KeepAliveMaxFailTime {
+			c.reportf(LogWarning, "No keepalive response for %s, reconnecting", time.Since(v.LastSuccess).Round(time.Second))
+			c.wa().Disconnect()
+			c.connectionLost("keepalive timed out")
+		}
+	case *events.StreamReplaced:
+		c.setState(StateReplaced, "replaced by another client")
+		c.reportf(LogError, "WhatsApp session was taken over by another client, not reconnecting")
+	case *events.LoggedOut:
+		c.setState(StateLoggedOut, fmt.Sprintf("logged out: %v", v.Reason))
+	}
+}
+
+// supervise reconnects whenever the connection is lost, until ctx is done.
+func (c *Client) supervise(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-c.conn.wake:
+		}
+
+		c.reconnect(ctx)
+	}
+}
+
+// reconnect retries connecting with an exponential backoff until connected,
+// logged out or stopped.
+func (c *Client) reconnect(ctx context.Context) {
+	wait := minReconnectWait
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+
+		if c.ConnectionStatus().State != StateDisconnected || !c.IsLoggedIn() {
+			return
+		}
+		wa := c.wa()
+		if wa.IsConnected() {
+			// reconnected on its own (e.g. after a 515 restart); Connected sets the state
+			return
+		}
+
+		c.conn.mu.Lock()
+		c.conn.status.ReconnectAttempts++
+		attempt := c.conn.status.ReconnectAttempts
+		c.conn.mu.Unlock()
+
+		c.log.Infof("Reconnecting to WhatsApp (attempt %d)", attempt)
+		err := wa.Connect()
+		if err == nil || errors.Is(err, whatsmeow.ErrAlreadyConnected) {
+			return
+		}
+
+		c.conn.mu.Lock()
+		c.conn.status.FailedReconnects++
+		c.conn.status.LastError = err.Error()
+		c.conn.mu.Unlock()
+
+		if wait *= 2; wait > maxReconnectWait {
+			wait = maxReconnectWait
+		}
+		c.reportf(LogWarning, "Reconnect attempt %d failed: %v (retrying in %s)", attempt, err, wait)
+	}
+}
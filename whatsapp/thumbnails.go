@@ -0,0 +1,147 @@
+package whatsapp
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	_ "image/gif" // register decoders for image.Decode
+	_ "image/png"
+
+	"whatsapp-mcp/paths"
+)
+
+const (
+	thumbnailMaxSize = 320 // longest side in pixels
+	thumbnailQuality = 75
+	thumbnailTimeout = 30 * time.Second // for ffmpeg
+)
+
+// generateThumbnail creates a small JPEG preview of a downloaded image or
+// video next to the other media (thumbnails/) and records it in the media
+// metadata. Images are scaled in-process; videos need ffmpeg. Formats that
+// can't be decoded, such as WebP stickers, are skipped.
+func (c *Client) generateThumbnail(messageID, mimeType, filePath string) {
+	var generate func(src, dst string) error
+	switch {
+	case mimeType == "image/jpeg" || mimeType == "image/png" || mimeType == "image/gif":
+		generate = imageThumbnail
+	case strings.HasPrefix(mimeType, "video/"):
+		if _, err := exec.LookPath(c.mediaConfig.FFmpegPath); err != nil {
+			c.log.Debugf("Skipping video thumbnail for %s: %s not found", messageID, c.mediaConfig.FFmpegPath)
+			return
+		}
+		generate = c.videoThumbnail
+	default:
+		return
+	}
+
+	base := strings.TrimSuffix(filepath.Base(filePath), filepath.Ext(filePath))
+	thumbnailPath := filepath.Join("thumbnails", base+".jpg")
+	dst := paths.GetMediaPath(thumbnailPath)
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		c.log.Warnf("Failed to create thumbnail directory: %v", err)
+		return
+	}
+
+	if err := generate(paths.GetMediaPath(filePath), dst); err != nil {
+		os.Remove(dst)
+		c.log.Warnf("Failed to generate thumbnail for %s: %v", messageID, err)
+		return
+	}
+	if err := c.mediaStore.SetThumbnailPath(messageID, thumbnailPath); err != nil {
+		c.log.Errorf("Failed to save thumbnail path for %s: %v", messageID, err)
+		return
+	}
+	c.log.Debugf("Generated thumbnail %s for %s", thumbnailPath, messageID)
+}
+
+// imageThumbnail writes a scaled-down JPEG copy of an image.
+func imageThumbnail(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	img, _, err := image.Decode(in)
+	if err != nil {
+		return fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	return jpeg.Encode(out, scaleDown(img, thumbnailMaxSize), &jpeg.Options{Quality: thumbnailQuality})
+}
+
+// videoThumbnail writes a scaled-down JPEG of a video's first second with ffmpeg.
+func (c *Client) videoThumbnail(src, dst string) error {
+	ctx, cancel := context.WithTimeout(c.ctx, thumbnailTimeout)
+	defer cancel()
+
+	scale := fmt.Sprintf("scale='min(%d,iw)':'min(%d,ih)':force_original_aspect_ratio=decrease", thumbnailMaxSize, thumbnailMaxSize)
+	cmd := exec.CommandContext(ctx, c.mediaConfig.FFmpegPath,
+		"-y", "-loglevel", "error",
+		"-ss", "1", "-i", src,
+		"-frames:v", "1", "-vf", scale, "-q:v", "5",
+		dst,
+	)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("ffmpeg: %v: %s", err, strings.TrimSpace(string(output)))
+	}
+
+	// videos shorter than a second have no frame at 1s
+	if stat, err := os.Stat(dst); err != nil || stat.Size() == 0 {
+		return fmt.Errorf("ffmpeg produced no frame")
+	}
+	return nil
+}
+
+// scaleDown shrinks an image so its longest side is at most maxSize pixels,
+// averaging the source pixels each thumbnail pixel covers.
+func scaleDown(src image.Image, maxSize int) image.Image {
+	bounds := src.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	if w <= maxSize && h <= maxSize {
+		return src
+	}
+
+	dw, dh := maxSize, h*maxSize/w
+	if h > w {
+		dw, dh = w*maxSize/h, maxSize
+	}
+	dw, dh = max(dw, 1), max(dh, 1)
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		y0, y1 := bounds.Min.Y+y*h/dh, bounds.Min.Y+max((y+1)*h/dh, y*h/dh+1)
+		for x := 0; x < dw; x++ {
+			x0, x1 := bounds.Min.X+x*w/dw, bounds.Min.X+max((x+1)*w/dw, x*w/dw+1)
+
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					pr, pg, pb, pa := src.At(sx, sy).RGBA()
+					r, g, b, a, n = r+uint64(pr), g+uint64(pg), b+uint64(pb), a+uint64(pa), n+1
+				}
+			}
+
+			i := dst.PixOffset(x, y)
+			dst.Pix[i+0] = uint8(r / n >> 8)
+			dst.Pix[i+1] = uint8(g / n >> 8)
+			dst.Pix[i+2] = uint8(b / n >> 8)
+			dst.Pix[i+3] = uint8(a / n >> 8)
+		}
+	}
+	return dst
+}
@@ -0,0 +1,189 @@
+package whatsapp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"whatsapp-mcp/paths"
+)
+
+// ErrTranscriptionDisabled is returned when no transcription backend is configured.
+var ErrTranscriptionDisabled = errors.New("transcription is not configured (set TRANSCRIPTION_URL or TRANSCRIPTION_COMMAND)")
+
+// TranscribeMessage transcribes a downloaded audio message and stores the transcript.
+func (c *Client) TranscribeMessage(ctx context.Context, messageID string) (string, error) {
+	if !c.transcriptionConfig.Enabled() {
+		return "", ErrTranscriptionDisabled
+	}
+
+	meta, err := c.mediaStore.GetMediaMetadata(messageID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get media metadata: %w", err)
+	}
+	if meta == nil {
+		return "", fmt.Errorf("message %s has no media", messageID)
+	}
+	if !strings.HasPrefix(meta.MimeType, "audio/") {
+		return "", fmt.Errorf("message %s is not audio (%s)", messageID, meta.MimeType)
+	}
+	if meta.DownloadStatus != "downloaded" {
+		return "", fmt.Errorf("audio not downloaded (status: %s)", meta.DownloadStatus)
+	}
+
+	return c.transcribeAndSave(ctx, messageID, meta.FilePath)
+}
+
+// autoTranscribe transcribes a freshly downloaded voice note when auto transcription is enabled.
+func (c *Client) autoTranscribe(messageID, mediaType, relPath string) {
+	if mediaType != "ptt" || !c.transcriptionConfig.Enabled() || !c.transcriptionConfig.Auto {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.ctx, c.transcriptionConfig.Timeout)
+	defer cancel()
+
+	if _, err := c.transcribeAndSave(ctx, messageID, relPath); err != nil {
+		c.log.Errorf("Failed to transcribe voice note %s: %v", messageID, err)
+	}
+}
+
+// transcribeAndSave transcribes the media file at relPath (relative to the media directory)
+// and stores the result.
+func (c *Client) transcribeAndSave(ctx context.Context, messageID, relPath string) (string, error) {
+	filePath := paths.GetMediaPath(filepath.Clean(relPath))
+
+	var text, source string
+	var err error
+	if c.transcriptionConfig.URL != "" {
+		text, err = c.transcribeHTTP(ctx, filePath)
+		source = "http"
+	} else {
+		text, err = c.transcribeCommand(ctx, filePath)
+		source = "command"
+	}
+	if err != nil {
+		return "", err
+	}
+
+	text = strings.TrimSpace(text)
+	if err := c.store.SaveTranscript(messageID, text, source); err != nil {
+		return "", fmt.Errorf("failed to save transcript: %w", err)
+	}
+
+	c.log.Infof("Transcribed voice note %s (%d chars, via %s)", messageID, len(text), source)
+	return text, nil
+}
+
+// transcribeHTTP posts the audio file to an OpenAI-compatible transcription endpoint.
+func (c *Client) transcribeHTTP(ctx context.Context, filePath string) (string, error) {
+	cfg := c.transcriptionConfig
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+
+	part, err := form.CreateFormFile("file", filepath.Base(filePath))
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(part, file); err != nil {
+		return "", err
+	}
+	if err := form.WriteField("model", cfg.Model); err != nil {
+		return "", err
+	}
+	if cfg.Language != "" {
+		if err := form.WriteField("language", cfg.Language); err != nil {
+			return "", err
+		}
+	}
+	if err := form.Close(); err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, cfg.URL, &body)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	if cfg.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+cfg.APIKey)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("transcription request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", fmt.Errorf("transcription endpoint returned %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+
+	// OpenAI-compatible servers answer {"text": "..."}; fall back to the raw body for plain text responses
+	var result struct {
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(respBody, &result); err == nil {
+		return result.Text, nil
+	}
+
+	return string(respBody), nil
+}
+
+// transcribeCommand runs the configured local transcription command and returns its stdout.
+func (c *Client) transcribeCommand(ctx context.Context, filePath string) (string, error) {
+	args := strings.Fields(c.transcriptionConfig.Command)
+	if len(args) == 0 {
+		return "", ErrTranscriptionDisabled
+	}
+
+	// substitute the audio path, or append it when there is no placeholder
+	replaced := false
+	for i, arg := range args {
+		if strings.Contains(arg, "{file}") {
+			args[i] = strings.ReplaceAll(arg, "{file}", filePath)
+			replaced = true
+		}
+	}
+	if !replaced {
+		args = append(args, filePath)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.transcriptionConfig.Timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("transcription command failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	return stdout.String(), nil
+}
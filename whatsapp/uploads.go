@@ -0,0 +1,50 @@
+package whatsapp
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// uploadPath resolves the path of a file to send. The tools that send files
+// are driven by a model that reads incoming messages, so only files inside
+// MEDIA_UPLOAD_DIR can be sent (relative paths are taken from there), and
+// only up to MEDIA_UPLOAD_MAX_MB. Symlinks are resolved before the check. It
+// returns the resolved path and the size of the file.
+func (c *Client) uploadPath(filePath string) (string, int64, error) {
+	dir, err := filepath.Abs(c.mediaConfig.UploadDir)
+	if err != nil {
+		return "", 0, err
+	}
+	if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+		dir = resolved
+	}
+
+	path := filePath
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	path, err = filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", 0, fmt.Errorf("file not accessible: %w", err)
+	}
+
+	rel, err := filepath.Rel(dir, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", 0, fmt.Errorf("%s is outside the upload directory %s (see MEDIA_UPLOAD_DIR)", filePath, c.mediaConfig.UploadDir)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", 0, fmt.Errorf("file not accessible: %w", err)
+	}
+	if !info.Mode().IsRegular() {
+		return "", 0, fmt.Errorf("%s is not a file", filePath)
+	}
+	if c.mediaConfig.UploadMaxSize > 0 && info.Size() > c.mediaConfig.UploadMaxSize {
+		return "", 0, fmt.Errorf("%s is too large: %d MB, at most %d MB (see MEDIA_UPLOAD_MAX_MB)",
+			filePath, info.Size()/(1024*1024), c.mediaConfig.UploadMaxSize/(1024*1024))
+	}
+	return path, info.Size(), nil
+}
@@ -0,0 +1,303 @@
+package whatsapp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"whatsapp-mcp/storage"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	videoEncodeTimeout = 10 * time.Minute
+	videoMaxWidth      = 1280 // re-encoded videos are scaled down to this width
+)
+
+// videoInfo is what WhatsApp shows of a video before it is downloaded.
+type videoInfo struct {
+	Seconds   int
+	Width     int
+	Height    int
+	Thumbnail []byte // JPEG, nil if it couldn't be generated
+}
+
+// SendVideo sends a video file from the upload directory (see uploadPath)
+// with an optional caption. Videos that aren't mp4, or are larger than
+// MEDIA_VIDEO_COMPRESS_MB, are re-encoded to H.264 mp4 with ffmpeg. The
+// duration, dimensions and preview thumbnail are read with ffprobe and ffmpeg
+// so the video shows up with a proper preview; without them an mp4 is still
+// sent, as a blank tile. The file is streamed to ffmpeg and the upload rather
+// than read into memory. It returns the stored message.
+func (c *Client) SendVideo(ctx context.Context, chatJID string, filePath string, caption string) (storage.Message, error) {
+	targetJID, err := types.ParseJID(chatJID)
+	if err != nil {
+		return storage.Message{}, err
+	}
+
+	path, size, err := c.uploadPath(filePath)
+	if err != nil {
+		return storage.Message{}, err
+	}
+	isMP4, err := isMP4File(path)
+	if err != nil {
+		return storage.Message{}, fmt.Errorf("video file not accessible: %w", err)
+	}
+
+	_, ffmpegErr := exec.LookPath(c.mediaConfig.FFmpegPath)
+	compress := c.mediaConfig.VideoCompressSize > 0 && size > c.mediaConfig.VideoCompressSize
+	switch {
+	case !isMP4 && ffmpegErr != nil:
+		return storage.Message{}, fmt.Errorf("only mp4 videos can be sent without %s (see FFMPEG_PATH)", c.mediaConfig.FFmpegPath)
+	case (!isMP4 || compress) && ffmpegErr == nil:
+		encoded, encodedSize, err := c.encodeVideo(ctx, path)
+		if err != nil {
+			return storage.Message{}, err
+		}
+		defer os.Remove(encoded)
+		if !isMP4 || encodedSize < size {
+			c.log.Infof("Re-encoded video %s: %d -> %d bytes", filepath.Base(path), size, encodedSize)
+			path = encoded
+		}
+	case compress:
+		c.log.Warnf("Sending video %s uncompressed: %s not found", filepath.Base(path), c.mediaConfig.FFmpegPath)
+	}
+
+	info := c.probeVideo(ctx, path)
+
+	file, err := os.Open(path)
+	if err != nil {
+		return storage.Message{}, fmt.Errorf("video file not accessible: %w", err)
+	}
+	defer file.Close()
+	uploaded, err := c.wa().UploadReader(ctx, file, nil, whatsmeow.MediaVideo)
+	if err != nil {
+		return storage.Message{}, fmt.Errorf("failed to upload video: %w", err)
+	}
+
+	video := &waE2E.VideoMessage{
+		URL:           proto.String(uploaded.URL),
+		DirectPath:    proto.String(uploaded.DirectPath),
+		MediaKey:      uploaded.MediaKey,
+		Mimetype:      proto.String("video/mp4"),
+		FileEncSHA256: uploaded.FileEncSHA256,
+		FileSHA256:    uploaded.FileSHA256,
+		FileLength:    proto.Uint64(uploaded.FileLength),
+		JPEGThumbnail: info.Thumbnail,
+	}
+	if caption != "" {
+		video.Caption = proto.String(caption)
+	}
+	if info.Seconds > 0 {
+		video.Seconds = proto.Uint32(uint32(info.Seconds))
+	}
+	if info.Width > 0 && info.Height > 0 {
+		video.Width = proto.Uint32(uint32(info.Width))
+		video.Height = proto.Uint32(uint32(info.Height))
+	}
+
+	c.presenceForSend(ctx)
+	resp, err := c.wa().SendMessage(ctx, targetJID, &waE2E.Message{VideoMessage: video})
+	if err != nil {
+		c.reportf(LogError, "Failed to send video to %s: %v", chatJID, err)
+		return storage.Message{}, err
+	}
+
+	text := caption
+	if text == "" {
+		text = "[Video]"
+	}
+	msg := storage.Message{
+		ID:          resp.ID,
+		ChatJID:     chatJID,
+		SenderJID:   resp.Sender.String(),
+		Text:        text,
+		Timestamp:   resp.Timestamp,
+		IsFromMe:    true,
+		MessageType: "video",
+	}
+	c.store.SaveMessage(msg)
+
+	meta := storage.MediaMetadata{
+		MessageID:      resp.ID,
+		FileName:       strings.TrimSuffix(filepath.Base(filePath), filepath.Ext(filePath)) + ".mp4",
+		FileSize:       int64(uploaded.FileLength),
+		MimeType:       "video/mp4",
+		MediaKey:       uploaded.MediaKey,
+		DirectPath:     uploaded.DirectPath,
+		FileSHA256:     uploaded.FileSHA256,
+		FileEncSHA256:  uploaded.FileEncSHA256,
+		DownloadStatus: "pending",
+		Thumbnail:      info.Thumbnail,
+		CreatedAt:      resp.Timestamp,
+	}
+	if info.Seconds > 0 {
+		meta.Duration = intPtr(info.Seconds)
+	}
+	if info.Width > 0 && info.Height > 0 {
+		meta.Width, meta.Height = intPtr(info.Width), intPtr(info.Height)
+	}
+	if err := c.mediaStore.SaveMediaMetadata(meta); err != nil {
+		c.log.Errorf("Failed to save media metadata for video %s: %v", resp.ID, err)
+		return msg, nil
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		c.log.Warnf("Failed to keep sent media %s: %v", resp.ID, err)
+		return msg, nil
+	}
+	c.keepSentMedia(&meta, file)
+
+	return msg, nil
+}
+
+// isMP4File reports whether a file starts like an mp4 video.
+func isMP4File(path string) (bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	head := make([]byte, 512) // all DetectContentType looks at
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return false, err
+	}
+	return http.DetectContentType(head[:n]) == "video/mp4", nil
+}
+
+// encodeVideo re-encodes a video to an H.264/AAC mp4 of at most 1280 pixels
+// wide that starts playing before it is fully downloaded. It returns the path
+// of the temporary file it wrote, which the caller removes, and its size.
+func (c *Client) encodeVideo(ctx context.Context, filePath string) (string, int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, videoEncodeTimeout)
+	defer cancel()
+
+	tmp, err := os.CreateTemp("", "whatsapp-video-*.mp4")
+	if err != nil {
+		return "", 0, err
+	}
+	tmp.Close()
+
+	// even dimensions are required by H.264
+	scale := fmt.Sprintf("scale='min(%d,iw)':-2", videoMaxWidth)
+	cmd := exec.CommandContext(ctx, c.mediaConfig.FFmpegPath,
+		"-y", "-loglevel", "error",
+		"-i", filePath,
+		"-vf", scale, "-c:v", "libx264", "-preset", "veryfast", "-crf", "28", "-pix_fmt", "yuv420p",
+		"-c:a", "aac", "-b:a", "96k",
+		"-movflags", "+faststart",
+		tmp.Name(),
+	)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		os.Remove(tmp.Name())
+		return "", 0, fmt.Errorf("failed to re-encode video: %v: %s", err, strings.TrimSpace(string(output)))
+	}
+
+	stat, err := os.Stat(tmp.Name())
+	if err != nil {
+		os.Remove(tmp.Name())
+		return "", 0, err
+	}
+	if stat.Size() == 0 {
+		os.Remove(tmp.Name())
+		return "", 0, fmt.Errorf("failed to re-encode video: ffmpeg produced no video")
+	}
+	return tmp.Name(), stat.Size(), nil
+}
+
+// probeVideo reads the duration and dimensions of a video file with ffprobe
+// and generates its preview thumbnail with ffmpeg. What can't be read is left
+// empty.
+func (c *Client) probeVideo(ctx context.Context, filePath string) videoInfo {
+	var info videoInfo
+
+	if _, err := exec.LookPath(c.mediaConfig.FFprobePath); err != nil {
+		c.log.Warnf("Sending video without duration and size: %s not found", c.mediaConfig.FFprobePath)
+	} else if err := c.ffprobeVideo(ctx, filePath, &info); err != nil {
+		c.log.Warnf("Failed to read video duration and size: %v", err)
+	}
+
+	if _, err := exec.LookPath(c.mediaConfig.FFmpegPath); err != nil {
+		c.log.Warnf("Sending video without preview: %s not found", c.mediaConfig.FFmpegPath)
+		return info
+	}
+	tmp, err := os.CreateTemp("", "whatsapp-video-*.jpg")
+	if err != nil {
+		c.log.Warnf("Failed to generate video preview: %v", err)
+		return info
+	}
+	tmp.Close()
+	thumbnail := tmp.Name()
+	defer os.Remove(thumbnail)
+	if err := c.videoThumbnail(filePath, thumbnail); err != nil {
+		c.log.Warnf("Failed to generate video preview: %v", err)
+		return info
+	}
+	if info.Thumbnail, err = os.ReadFile(thumbnail); err != nil {
+		c.log.Warnf("Failed to read video preview: %v", err)
+	}
+	return info
+}
+
+// ffprobeVideo reads the duration and the dimensions of the first video
+// stream, swapped for videos recorded in portrait with a rotation flag.
+func (c *Client) ffprobeVideo(ctx context.Context, filePath string, info *videoInfo) error {
+	ctx, cancel := context.WithTimeout(ctx, thumbnailTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, c.mediaConfig.FFprobePath,
+		"-v", "error",
+		"-select_streams", "v:0",
+		"-show_entries", "stream=width,height:stream_side_data=rotation:format=duration",
+		"-of", "json",
+		filePath,
+	)
+	output, err := cmd.Output()
+	if err != nil {
+		return fmt.Errorf("ffprobe: %w", err)
+	}
+
+	var probe struct {
+		Streams []struct {
+			Width    int `json:"width"`
+			Height   int `json:"height"`
+			SideData []struct {
+				Rotation int `json:"rotation"`
+			} `json:"side_data_list"`
+		} `json:"streams"`
+		Format struct {
+			Duration string `json:"duration"`
+		} `json:"format"`
+	}
+	if err := json.Unmarshal(output, &probe); err != nil {
+		return fmt.Errorf("invalid ffprobe output: %w", err)
+	}
+
+	if duration, err := strconv.ParseFloat(probe.Format.Duration, 64); err == nil {
+		info.Seconds = max(int(math.Round(duration)), 1)
+	}
+	if len(probe.Streams) > 0 {
+		stream := probe.Streams[0]
+		info.Width, info.Height = stream.Width, stream.Height
+		for _, side := range stream.SideData {
+			if side.Rotation == 90 || side.Rotation == -90 || side.Rotation == 270 || side.Rotation == -270 {
+				info.Width, info.Height = info.Height, info.Width
+			}
+		}
+	}
+	return nil
+}
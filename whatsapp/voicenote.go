@@ -0,0 +1,227 @@
+package whatsapp
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"whatsapp-mcp/storage"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	voiceNoteMimeType   = "audio/ogg; codecs=opus"
+	voiceNoteTimeout    = 2 * time.Minute // per ffmpeg run
+	waveformSamples     = 64              // bars WhatsApp draws for a voice note
+	waveformSampleRate  = 8000            // decoding rate for the waveform, plenty for loudness
+	voiceNoteMaxSeconds = 30 * 60
+)
+
+// SendVoiceNote sends an audio file from the upload directory (see
+// uploadPath) as a voice note. Any format ffmpeg can read (mp3, m4a, wav,
+// ogg...) is transcoded to the mono 48 kHz ogg/opus WhatsApp plays as a voice
+// note, and its waveform is computed so it renders like one recorded on the
+// phone. It returns the stored message.
+func (c *Client) SendVoiceNote(ctx context.Context, chatJID string, filePath string) (storage.Message, error) {
+	targetJID, err := types.ParseJID(chatJID)
+	if err != nil {
+		return storage.Message{}, err
+	}
+	path, _, err := c.uploadPath(filePath)
+	if err != nil {
+		return storage.Message{}, err
+	}
+	if _, err := exec.LookPath(c.mediaConfig.FFmpegPath); err != nil {
+		return storage.Message{}, fmt.Errorf("%s not found, it is needed to send voice notes (see FFMPEG_PATH)", c.mediaConfig.FFmpegPath)
+	}
+
+	data, err := c.transcodeVoiceNote(ctx, path)
+	if err != nil {
+		return storage.Message{}, err
+	}
+	waveform, seconds, err := c.voiceNoteWaveform(ctx, data)
+	if err != nil {
+		return storage.Message{}, err
+	}
+	if seconds > voiceNoteMaxSeconds {
+		return storage.Message{}, fmt.Errorf("voice note is too long: %ds, at most %ds", seconds, voiceNoteMaxSeconds)
+	}
+
+	uploaded, err := c.wa().Upload(ctx, data, whatsmeow.MediaAudio)
+	if err != nil {
+		return storage.Message{}, fmt.Errorf("failed to upload voice note: %w", err)
+	}
+
+	message := &waE2E.Message{
+		AudioMessage: &waE2E.AudioMessage{
+			URL:           proto.String(uploaded.URL),
+			DirectPath:    proto.String(uploaded.DirectPath),
+			MediaKey:      uploaded.MediaKey,
+			Mimetype:      proto.String(voiceNoteMimeType),
+			FileEncSHA256: uploaded.FileEncSHA256,
+			FileSHA256:    uploaded.FileSHA256,
+			FileLength:    proto.Uint64(uploaded.FileLength),
+			Seconds:       proto.Uint32(uint32(seconds)),
+			PTT:           proto.Bool(true),
+			Waveform:      waveform,
+		},
+	}
+
+	c.presenceForSend(ctx)
+	resp, err := c.wa().SendMessage(ctx, targetJID, message)
+	if err != nil {
+		c.reportf(LogError, "Failed to send voice note to %s: %v", chatJID, err)
+		return storage.Message{}, err
+	}
+
+	msg := storage.Message{
+		ID:          resp.ID,
+		ChatJID:     chatJID,
+		SenderJID:   resp.Sender.String(),
+		Text:        "[Audio]",
+		Timestamp:   resp.Timestamp,
+		IsFromMe:    true,
+		MessageType: "ptt",
+	}
+	c.store.SaveMessage(msg)
+
+	meta := storage.MediaMetadata{
+		MessageID:      resp.ID,
+		FileSize:       int64(uploaded.FileLength),
+		MimeType:       voiceNoteMimeType,
+		Duration:       intPtr(seconds),
+		MediaKey:       uploaded.MediaKey,
+		DirectPath:     uploaded.DirectPath,
+		FileSHA256:     uploaded.FileSHA256,
+		FileEncSHA256:  uploaded.FileEncSHA256,
+		DownloadStatus: "pending",
+		CreatedAt:      resp.Timestamp,
+	}
+	if err := c.mediaStore.SaveMediaMetadata(meta); err != nil {
+		c.log.Errorf("Failed to save media metadata for voice note %s: %v", resp.ID, err)
+		return msg, nil
+	}
+	c.keepSentMedia(&meta, bytes.NewReader(data))
+
+	return msg, nil
+}
+
+// transcodeVoiceNote converts an audio file to mono 48 kHz ogg/opus with ffmpeg.
+func (c *Client) transcodeVoiceNote(ctx context.Context, filePath string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, voiceNoteTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, c.mediaConfig.FFmpegPath,
+		"-loglevel", "error",
+		"-i", filePath,
+		"-vn", "-ac", "1", "-ar", "48000",
+		"-c:a", "libopus", "-b:a", "32k", "-application", "voip",
+		"-f", "ogg", "pipe:1",
+	)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	data, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to transcode voice note: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("failed to transcode voice note: ffmpeg produced no audio")
+	}
+	return data, nil
+}
+
+// voiceNoteWaveform decodes a voice note to PCM with ffmpeg and returns its
+// waveform, the peak loudness of each of 64 equal slices scaled to 0-100, and
+// its duration in seconds.
+func (c *Client) voiceNoteWaveform(ctx context.Context, data []byte) ([]byte, int, error) {
+	ctx, cancel := context.WithTimeout(ctx, voiceNoteTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, c.mediaConfig.FFmpegPath,
+		"-loglevel", "error",
+		"-i", "pipe:0",
+		"-ac", "1", "-ar", fmt.Sprint(waveformSampleRate),
+		"-f", "s16le", "pipe:1",
+	)
+	cmd.Stdin = bytes.NewReader(data)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	pcm, err := cmd.Output()
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to decode voice note: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	samples := len(pcm) / 2
+	if samples == 0 {
+		return nil, 0, fmt.Errorf("voice note has no audio")
+	}
+	seconds := max((samples+waveformSampleRate-1)/waveformSampleRate, 1)
+
+	peaks := make([]int, waveformSamples)
+	loudest := 0
+	for i := 0; i < samples; i++ {
+		sample := int(int16(binary.LittleEndian.Uint16(pcm[2*i:])))
+		if sample < 0 {
+			sample = -sample
+		}
+		bar := i * waveformSamples / samples
+		peaks[bar] = max(peaks[bar], sample)
+		loudest = max(loudest, sample)
+	}
+
+	waveform := make([]byte, waveformSamples)
+	if loudest > 0 {
+		for i, peak := range peaks {
+			waveform[i] = byte(peak * 100 / loudest)
+		}
+	}
+	return waveform, seconds, nil
+}
+
+// keepSentMedia stores a copy of media sent from here with the downloaded
+// media, so it is available like received media without downloading it back,
+// thumbnail included.
+func (c *Client) keepSentMedia(meta *storage.MediaMetadata, data io.Reader) {
+	filePath, err := c.generateMediaFilePath(meta)
+	if err != nil {
+		c.log.Warnf("Failed to keep sent media %s: %v", meta.MessageID, err)
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		c.log.Warnf("Failed to keep sent media %s: %v", meta.MessageID, err)
+		return
+	}
+	file, err := os.Create(filePath)
+	if err != nil {
+		c.log.Warnf("Failed to keep sent media %s: %v", meta.MessageID, err)
+		return
+	}
+	_, err = io.Copy(file, data)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(filePath)
+		c.log.Warnf("Failed to keep sent media %s: %v", meta.MessageID, err)
+		return
+	}
+
+	relPath, err := filepath.Rel(c.mediaConfig.StoragePath, filePath)
+	if err != nil {
+		os.Remove(filePath)
+		return
+	}
+	c.mediaStore.UpdateDownloadStatus(meta.MessageID, "downloaded", &relPath, nil)
+	c.generateThumbnail(meta.MessageID, meta.MimeType, relPath)
+}
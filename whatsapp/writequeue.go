@@ -0,0 +1,164 @@
+package whatsapp
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.mau.fi/whatsmeow/types/events"
+
+	"whatsapp-mcp/storage"
+)
+
+const (
+	writeBatchSize    = 100              // events written per batch, plain messages in one transaction
+	writeFlushTimeout = 10 * time.Second // how long Disconnect waits for queued events to be written
+)
+
+// writeQueue hands received messages and receipts to a single writer
+// goroutine, so storing them doesn't hold up whatsmeow's event loop.
+type writeQueue struct {
+	events  chan any
+	stopped chan struct{} // closed once the writer wrote every queued event
+	mu      sync.RWMutex  // protects closed, held while sending to events
+	closed  bool
+}
+
+// newWriteQueue creates a queue holding up to size events.
+func newWriteQueue(size int) *writeQueue {
+	return &writeQueue{
+		events:  make(chan any, size),
+		stopped: make(chan struct{}),
+	}
+}
+
+// enqueueWrite queues an event for the writer. When the queue is full it
+// blocks until there is room, so events are never dropped. Once the queue is
+// closed, the event is handled right away.
+func (c *Client) enqueueWrite(evt any) {
+	c.writes.mu.RLock()
+	if c.writes.closed {
+		c.writes.mu.RUnlock()
+		c.processWrites([]any{evt})
+		return
+	}
+	defer c.writes.mu.RUnlock()
+
+	select {
+	case c.writes.events <- evt:
+	default:
+		c.log.Warnf("Write queue full (%d events), waiting for the database", cap(c.writes.events))
+		c.writes.events <- evt
+	}
+}
+
+// runWriter writes queued events in batches until the queue is closed and
+// drained.
+func (c *Client) runWriter() {
+	defer close(c.writes.stopped)
+
+	for evt := range c.writes.events {
+		batch := []any{evt}
+	drain:
+		for len(batch) < writeBatchSize {
+			select {
+			case evt, ok := <-c.writes.events:
+				if !ok {
+					break drain
+				}
+				batch = append(batch, evt)
+			default:
+				break drain
+			}
+		}
+		c.processWrites(batch)
+	}
+}
+
+// flushWrites stops queueing events and waits until the queued ones are
+// written, at most writeFlushTimeout.
+func (c *Client) flushWrites() {
+	c.writes.mu.Lock()
+	if !c.writes.closed {
+		c.writes.closed = true
+		close(c.writes.events)
+	}
+	pending := len(c.writes.events)
+	c.writes.mu.Unlock()
+
+	if pending > 0 {
+		c.log.Infof("Writing %d queued events", pending)
+	}
+
+	select {
+	case <-c.writes.stopped:
+	case <-time.After(writeFlushTimeout):
+		c.log.Warnf("Gave up writing queued events after %s, %d left", writeFlushTimeout, len(c.writes.events))
+	}
+}
+
+// processWrites handles a batch of events in order. Consecutive plain messages
+// are stored in one transaction; anything else first writes the messages
+// before it, so events are stored in the order they arrived.
+func (c *Client) processWrites(batch []any) {
+	var messages []*liveMessage
+	for _, evt := range batch {
+		if msg, ok := evt.(*events.Message); ok && batchableMessage(msg) {
+			c.log.Debugf("Received message: %s from %s in %s", msg.Info.ID, msg.Info.Sender, msg.Info.Chat)
+			messages = append(messages, c.newLiveMessage(msg))
+			continue
+		}
+
+		c.saveLiveMessages(messages)
+		messages = nil
+
+		switch v := evt.(type) {
+		case *events.Message:
+			c.handleMessage(v)
+		case *events.Receipt:
+			c.handleReceipt(v)
+		}
+	}
+	c.saveLiveMessages(messages)
+}
+
+// saveLiveMessages stores received messages and their chats in one
+// transaction, then handles what comes after storing each of them. If the
+// transaction fails, the messages are saved one by one so a bad one doesn't
+// lose the others.
+func (c *Client) saveLiveMessages(messages []*liveMessage) {
+	if len(messages) == 0 {
+		return
+	}
+	ctx := context.Background()
+
+	var chats []storage.Chat
+	chatIndex := make(map[string]int)
+	records := make([]storage.Message, 0, len(messages))
+	for _, msg := range messages {
+		chat, record := c.messageRecords(ctx, msg.data)
+		// the last message of a chat sets its last message time, as if saved one by one
+		if i, ok := chatIndex[chat.JID]; ok {
+			chats[i] = chat
+		} else {
+			chatIndex[chat.JID] = len(chats)
+			chats = append(chats, chat)
+		}
+		records = append(records, record)
+	}
+
+	if err := c.store.SaveMessageBatch(chats, records); err != nil {
+		c.log.Warnf("Failed to save batch of %d messages, saving them one by one: %v", len(messages), err)
+		for _, msg := range messages {
+			if err := c.processMessageData(ctx, msg.data); err == nil {
+				c.finishLiveMessage(ctx, msg)
+			}
+		}
+		return
+	}
+
+	for _, msg := range messages {
+		c.saveMessageExtras(ctx, msg.data)
+		c.finishLiveMessage(ctx, msg)
+	}
+}